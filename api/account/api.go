@@ -49,6 +49,18 @@ type CredentialRepresentation struct {
 	Temporary      *bool   `json:"temporary,omitempty"`
 }
 
+// SessionRepresentation struct
+type SessionRepresentation struct {
+	ID         *string   `json:"id,omitempty"`
+	IPAddress  *string   `json:"ipAddress,omitempty"`
+	Started    *int64    `json:"started,omitempty"`
+	LastAccess *int64    `json:"lastAccess,omitempty"`
+	Expires    *int64    `json:"expires,omitempty"`
+	Browser    *string   `json:"browser,omitempty"`
+	Current    *bool     `json:"current,omitempty"`
+	Clients    *[]string `json:"clients,omitempty"`
+}
+
 // Configuration struct
 type Configuration struct {
 	EditingEnabled                    *bool           `json:"editing_enabled"`
@@ -68,6 +80,23 @@ type UpdatePasswordBody struct {
 	ConfirmPassword string `json:"confirmPassword"`
 }
 
+// PasswordValidationBody is the definition of the expected body content of ValidatePassword method
+type PasswordValidationBody struct {
+	Password string `json:"password"`
+}
+
+// PasswordValidationRepresentation struct. Failures lists the rules of the password policy which are not respected.
+type PasswordValidationRepresentation struct {
+	Valid    bool                                  `json:"valid"`
+	Failures []PasswordPolicyFailureRepresentation `json:"failures"`
+}
+
+// PasswordPolicyFailureRepresentation struct
+type PasswordPolicyFailureRepresentation struct {
+	Rule  string  `json:"rule"`
+	Value *string `json:"value,omitempty"`
+}
+
 // LabelBody struct
 type LabelBody struct {
 	Label string `json:"label,omitempty"`
@@ -86,6 +115,46 @@ func ConvertCredential(credKc *kc.CredentialRepresentation) CredentialRepresenta
 	return cred
 }
 
+// ConvertToAPISession creates an API session from a KC account session
+func ConvertToAPISession(session kc.SessionRepresentation) SessionRepresentation {
+	var clients = []string{}
+	if session.Clients != nil {
+		for _, client := range *session.Clients {
+			if client.ClientID != nil {
+				clients = append(clients, *client.ClientID)
+			}
+		}
+	}
+
+	return SessionRepresentation{
+		ID:         session.ID,
+		IPAddress:  session.IPAddress,
+		Started:    session.Started,
+		LastAccess: session.LastAccess,
+		Expires:    session.Expires,
+		Browser:    session.Browser,
+		Current:    session.Current,
+		Clients:    &clients,
+	}
+}
+
+// ConvertToAPIPasswordValidation creates an API password validation result from the failures of the password policy
+func ConvertToAPIPasswordValidation(failures []keycloakb.PasswordPolicyFailure) PasswordValidationRepresentation {
+	var res = PasswordValidationRepresentation{
+		Valid:    len(failures) == 0,
+		Failures: []PasswordPolicyFailureRepresentation{},
+	}
+	for _, failure := range failures {
+		var apiFailure = PasswordPolicyFailureRepresentation{Rule: failure.Rule}
+		if failure.Value != "" {
+			var value = failure.Value
+			apiFailure.Value = &value
+		}
+		res.Failures = append(res.Failures, apiFailure)
+	}
+	return res
+}
+
 // ConvertToAPIAccount creates an API account representation from a KC user representation
 func ConvertToAPIAccount(ctx context.Context, userKc kc.UserRepresentation, logger keycloakb.Logger) AccountRepresentation {
 	var userRep AccountRepresentation
@@ -181,6 +250,13 @@ func (updatePwd UpdatePasswordBody) Validate() error {
 		Status()
 }
 
+// Validate is a validator for PasswordValidationBody
+func (body PasswordValidationBody) Validate() error {
+	return validation.NewParameterValidator().
+		ValidateParameterRegExp(msg.Password, &body.Password, RegExpPassword, true).
+		Status()
+}
+
 // Validate is a validator for CredentialRepresentation
 func (credential CredentialRepresentation) Validate() error {
 	return validation.NewParameterValidator().
@@ -6,6 +6,7 @@ import (
 
 	"github.com/cloudtrust/common-service/log"
 	"github.com/cloudtrust/keycloak-bridge/internal/constants"
+	"github.com/cloudtrust/keycloak-bridge/internal/keycloakb"
 
 	kc "github.com/cloudtrust/keycloak-client"
 	"github.com/stretchr/testify/assert"
@@ -30,6 +31,33 @@ func TestConvertCredential(t *testing.T) {
 	assert.Equal(t, "{}", *ConvertCredential(&credKc).CredentialData)
 }
 
+func TestConvertToAPISession(t *testing.T) {
+	var sessionID = "session-id"
+	var current = true
+	var portal = "portal"
+
+	var session = ConvertToAPISession(kc.SessionRepresentation{ID: &sessionID, Current: &current, Clients: &[]kc.ClientRepresentation{{ClientID: &portal}, {}}})
+	assert.Equal(t, sessionID, *session.ID)
+	assert.True(t, *session.Current)
+	assert.Equal(t, []string{portal}, *session.Clients)
+
+	session = ConvertToAPISession(kc.SessionRepresentation{ID: &sessionID})
+	assert.Len(t, *session.Clients, 0)
+}
+
+func TestConvertToAPIPasswordValidation(t *testing.T) {
+	var res = ConvertToAPIPasswordValidation([]keycloakb.PasswordPolicyFailure{})
+	assert.True(t, res.Valid)
+	assert.NotNil(t, res.Failures)
+
+	res = ConvertToAPIPasswordValidation([]keycloakb.PasswordPolicyFailure{{Rule: "length", Value: "8"}, {Rule: "breached"}})
+	assert.False(t, res.Valid)
+	assert.Len(t, res.Failures, 2)
+	assert.Equal(t, "8", *res.Failures[0].Value)
+	assert.Equal(t, "breached", res.Failures[1].Rule)
+	assert.Nil(t, res.Failures[1].Value)
+}
+
 func TestConvertToAPIAccount(t *testing.T) {
 	var ctx = context.TODO()
 	var logger = log.NewNopLogger()
@@ -154,6 +182,11 @@ func TestValidateUpdatePasswordRepresentation(t *testing.T) {
 
 }
 
+func TestValidatePasswordValidationBody(t *testing.T) {
+	assert.Nil(t, PasswordValidationBody{Password: "P@ssw0rd"}.Validate())
+	assert.NotNil(t, PasswordValidationBody{}.Validate())
+}
+
 func TestValidateCredentialRepresentation(t *testing.T) {
 	{
 		credential := createValidCredentialRepresentation()
@@ -2,15 +2,23 @@ package apimanagement
 
 import (
 	"context"
+	"encoding/csv"
 	"encoding/json"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/cloudtrust/common-service/configuration"
 	errorhandler "github.com/cloudtrust/common-service/errors"
 	"github.com/cloudtrust/common-service/validation"
 	"github.com/cloudtrust/keycloak-bridge/internal/constants"
+	"github.com/cloudtrust/keycloak-bridge/internal/dto"
 	"github.com/cloudtrust/keycloak-bridge/internal/keycloakb"
 	kc "github.com/cloudtrust/keycloak-client"
 	"github.com/spf13/cast"
+	"gopkg.in/yaml.v3"
 )
 
 // UserRepresentation struct
@@ -39,6 +47,43 @@ type UserRepresentation struct {
 	Label                *string                        `json:"label,omitempty"`
 	Accreditations       *[]AccreditationRepresentation `json:"accreditations,omitempty"`
 	CreatedTimestamp     *int64                         `json:"createdTimestamp,omitempty"`
+	DeletedTimestamp     *int64                         `json:"deletedTimestamp,omitempty"`
+	TemporaryGrants      *[]GrantRepresentation         `json:"temporaryGrants,omitempty"`
+}
+
+// GrantRepresentation is a temporary group membership, a trustID group or a client role which is removed once expired.
+// TargetID is the group identifier, the trustID group name or the role identifier depending on the type.
+type GrantRepresentation struct {
+	Type      *string `json:"type"`
+	TargetID  *string `json:"targetId"`
+	ClientID  *string `json:"clientId,omitempty"`
+	Name      *string `json:"name,omitempty"`
+	ExpiresAt *int64  `json:"expiresAt"`
+	GrantedBy *string `json:"grantedBy"`
+}
+
+// ScheduledUserChangeRepresentation struct. ApplyAt and CreatedAt are Unix timestamps.
+type ScheduledUserChangeRepresentation struct {
+	ID        *int64  `json:"id,omitempty"`
+	Action    *string `json:"action"`
+	ApplyAt   *int64  `json:"applyAt"`
+	CreatedAt *int64  `json:"createdAt,omitempty"`
+	CreatedBy *string `json:"createdBy,omitempty"`
+}
+
+// ImpersonationRequestRepresentation struct. Duration is given in seconds, the maximum duration is used when it is missing.
+type ImpersonationRequestRepresentation struct {
+	Justification *string `json:"justification"`
+	Duration      *int64  `json:"duration,omitempty"`
+}
+
+// ImpersonationRepresentation struct. The tokens are the ones of the session opened on behalf of the user, ExpiresAt is
+// the Unix timestamp at which this session is ended.
+type ImpersonationRepresentation struct {
+	AccessToken  *string `json:"accessToken,omitempty"`
+	RefreshToken *string `json:"refreshToken,omitempty"`
+	SessionID    *string `json:"sessionId,omitempty"`
+	ExpiresAt    *int64  `json:"expiresAt"`
 }
 
 // AccreditationRepresentation is a representation of accreditations
@@ -71,6 +116,29 @@ type ClientRepresentation struct {
 	ClientID *string `json:"clientId,omitempty"`
 	Protocol *string `json:"protocol,omitempty"`
 	Enabled  *bool   `json:"enabled,omitempty"`
+
+	Description               *string   `json:"description,omitempty"`
+	RedirectURIs              *[]string `json:"redirectUris,omitempty"`
+	WebOrigins                *[]string `json:"webOrigins,omitempty"`
+	PublicClient              *bool     `json:"publicClient,omitempty"`
+	StandardFlowEnabled       *bool     `json:"standardFlowEnabled,omitempty"`
+	ImplicitFlowEnabled       *bool     `json:"implicitFlowEnabled,omitempty"`
+	DirectAccessGrantsEnabled *bool     `json:"directAccessGrantsEnabled,omitempty"`
+	ServiceAccountsEnabled    *bool     `json:"serviceAccountsEnabled,omitempty"`
+}
+
+// ClientSecretRepresentation struct
+type ClientSecretRepresentation struct {
+	Value *string `json:"value"`
+}
+
+// ClientPolicyRepresentation struct. It restricts the settings of the clients created or updated through the bridge.
+type ClientPolicyRepresentation struct {
+	ImplicitFlowAllowed         *bool    `json:"implicitFlowAllowed"`
+	DirectAccessGrantsAllowed   *bool    `json:"directAccessGrantsAllowed"`
+	PublicClientsAllowed        *bool    `json:"publicClientsAllowed"`
+	WildcardRedirectURIsAllowed *bool    `json:"wildcardRedirectUrisAllowed"`
+	AllowedRedirectURIPrefixes  []string `json:"allowedRedirectUriPrefixes"`
 }
 
 // RequiredActionRepresentation struct
@@ -91,6 +159,29 @@ type CredentialRepresentation struct {
 	Temporary      *bool   `json:"temporary,omitempty"`
 }
 
+// ReEnrollmentRequestRepresentation struct. Type is the type of credential the user has to enroll again.
+type ReEnrollmentRequestRepresentation struct {
+	Type *string `json:"type"`
+}
+
+// CredentialTypeRequiredActions are the required actions which make a user enroll a credential of the given type
+var CredentialTypeRequiredActions = map[string]string{
+	"password":              "UPDATE_PASSWORD",
+	"otp":                   "CONFIGURE_TOTP",
+	"webauthn":              "webauthn-register",
+	"webauthn-passwordless": "webauthn-register-passwordless",
+}
+
+// UserSessionRepresentation struct
+type UserSessionRepresentation struct {
+	ID         *string   `json:"id,omitempty"`
+	IPAddress  *string   `json:"ipAddress,omitempty"`
+	Start      *int64    `json:"start,omitempty"`
+	LastAccess *int64    `json:"lastAccess,omitempty"`
+	Clients    *[]string `json:"clients,omitempty"`
+	Offline    *bool     `json:"offline,omitempty"`
+}
+
 // AttackDetectionStatusRepresentation struct
 type AttackDetectionStatusRepresentation struct {
 	NumFailures   *int64  `json:"numFailures,omitempty"`
@@ -107,12 +198,21 @@ type RoleRepresentation struct {
 	Description *string `json:"description,omitempty"`
 	ID          *string `json:"id,omitempty"`
 	Name        *string `json:"name,omitempty"`
+	ExpiresAt   *int64  `json:"expiresAt,omitempty"`
 }
 
 // GroupRepresentation struct
 type GroupRepresentation struct {
-	ID   *string `json:"id,omitempty"`
-	Name *string `json:"name,omitempty"`
+	ID        *string                `json:"id,omitempty"`
+	Name      *string                `json:"name,omitempty"`
+	Path      *string                `json:"path,omitempty"`
+	SubGroups *[]GroupRepresentation `json:"subGroups,omitempty"`
+	ExpiresAt *int64                 `json:"expiresAt,omitempty"`
+}
+
+// GroupParentRepresentation struct. A group without parent is a top-level group.
+type GroupParentRepresentation struct {
+	ParentID *string `json:"parentId"`
 }
 
 // AuthorizationsRepresentation struct
@@ -156,19 +256,25 @@ const (
 )
 
 var (
-	allowedBoConfKeys    = map[string]bool{BOConfKeyCustomers: true, BOConfKeyTeams: true}
-	allowedAdminConfMode = map[string]bool{"trustID": true, "corporate": true}
-	allowedBarcodeType   = map[string]bool{"CODE128": true}
+	allowedBoConfKeys     = map[string]bool{BOConfKeyCustomers: true, BOConfKeyTeams: true}
+	allowedAdminConfMode  = map[string]bool{"trustID": true, "corporate": true}
+	allowedBarcodeType    = map[string]bool{"CODE128": true}
+	allowedFrequencies    = map[string]bool{dto.ReportFrequencyDaily: true, dto.ReportFrequencyWeekly: true, dto.ReportFrequencyMonthly: true}
+	allowedBulkOperations = map[string]bool{BulkOperationLockUser: true, BulkOperationUnlockUser: true, BulkOperationDeleteUser: true,
+		BulkOperationAddGroupToUser: true, BulkOperationExecuteActionsEmail: true, BulkOperationSendReminderEmail: true}
+	allowedScheduledActions = map[string]bool{dto.ScheduledActionLock: true, dto.ScheduledActionUnlock: true, dto.ScheduledActionExpire: true}
+	allowedClientProtocols  = map[string]bool{"openid-connect": true, "saml": true}
 )
 
 // BackOfficeConfiguration type
 type BackOfficeConfiguration map[string]map[string][]string
 
-// RealmAdminConfiguration struct
+// RealmAdminConfiguration struct. When ClientPolicy is missing from an update, the current client policy is kept.
 type RealmAdminConfiguration struct {
-	Mode            *string                   `json:"mode"`
-	AvailableChecks map[string]bool           `json:"available-checks"`
-	Accreditations  []RealmAdminAccreditation `json:"accreditations"`
+	Mode            *string                     `json:"mode"`
+	AvailableChecks map[string]bool             `json:"available-checks"`
+	Accreditations  []RealmAdminAccreditation   `json:"accreditations"`
+	ClientPolicy    *ClientPolicyRepresentation `json:"client-policy,omitempty"`
 }
 
 // RealmAdminAccreditation struct
@@ -180,13 +286,459 @@ type RealmAdminAccreditation struct {
 
 // FederatedIdentityRepresentation struct
 type FederatedIdentityRepresentation struct {
-	UserID   *string `json:"userID,omitempty"`
-	Username *string `json:"username,omitempty"`
+	IdentityProvider *string `json:"identityProvider,omitempty"`
+	UserID           *string `json:"userID,omitempty"`
+	Username         *string `json:"username,omitempty"`
+}
+
+// IdentityProviderRepresentation struct
+type IdentityProviderRepresentation struct {
+	Alias                     *string            `json:"alias,omitempty"`
+	DisplayName               *string            `json:"displayName,omitempty"`
+	ProviderID                *string            `json:"providerId,omitempty"`
+	Enabled                   *bool              `json:"enabled,omitempty"`
+	TrustEmail                *bool              `json:"trustEmail,omitempty"`
+	StoreToken                *bool              `json:"storeToken,omitempty"`
+	FirstBrokerLoginFlowAlias *string            `json:"firstBrokerLoginFlowAlias,omitempty"`
+	Config                    *map[string]string `json:"config,omitempty"`
+}
+
+// PasswordPolicyRepresentation struct. The items of the policy which are not known by the bridge are listed in others.
+type PasswordPolicyRepresentation struct {
+	Length                     *int              `json:"length,omitempty"`
+	MaxLength                  *int              `json:"maxLength,omitempty"`
+	Digits                     *int              `json:"digits,omitempty"`
+	LowerCase                  *int              `json:"lowerCase,omitempty"`
+	UpperCase                  *int              `json:"upperCase,omitempty"`
+	SpecialChars               *int              `json:"specialChars,omitempty"`
+	NotUsername                *bool             `json:"notUsername,omitempty"`
+	NotEmail                   *bool             `json:"notEmail,omitempty"`
+	RegexPattern               *string           `json:"regexPattern,omitempty"`
+	PasswordHistory            *int              `json:"passwordHistory,omitempty"`
+	ForceExpiredPasswordChange *int              `json:"forceExpiredPasswordChange,omitempty"`
+	HashAlgorithm              *string           `json:"hashAlgorithm,omitempty"`
+	HashIterations             *int              `json:"hashIterations,omitempty"`
+	PasswordBlacklist          *string           `json:"passwordBlacklist,omitempty"`
+	Others                     map[string]string `json:"others,omitempty"`
 }
 
 // RequiredAction type
 type RequiredAction string
 
+// StatisticsReportScheduleRepresentation struct. Day is the day of the week (0 is Sunday) for weekly reports or the day of
+// the month for monthly reports. Hour is expressed in UTC.
+type StatisticsReportScheduleRepresentation struct {
+	ID         *int64   `json:"id,omitempty"`
+	Frequency  *string  `json:"frequency"`
+	Day        *int     `json:"day,omitempty"`
+	Hour       *int     `json:"hour"`
+	Recipients []string `json:"recipients"`
+	LastSent   *int64   `json:"lastSent,omitempty"`
+}
+
+// ApprovalPolicyRepresentation lists the actions which require the approval of a second operator
+type ApprovalPolicyRepresentation struct {
+	Actions []string `json:"actions"`
+}
+
+// ApprovalRequestRepresentation struct. The parameters of the requested action let the approver know what they approve.
+// They are not returned for a password reset as they contain the password, and not kept once the request is decided.
+type ApprovalRequestRepresentation struct {
+	ID                *int64           `json:"id"`
+	Action            *string          `json:"action"`
+	TargetID          *string          `json:"targetId,omitempty"`
+	Parameters        *json.RawMessage `json:"parameters,omitempty"`
+	RequesterID       *string          `json:"requesterId"`
+	RequesterUsername *string          `json:"requesterUsername"`
+	Status            *string          `json:"status"`
+	CreatedAt         *int64           `json:"createdAt"`
+	DeciderID         *string          `json:"deciderId,omitempty"`
+	DeciderUsername   *string          `json:"deciderUsername,omitempty"`
+	DecidedAt         *int64           `json:"decidedAt,omitempty"`
+	Comment           *string          `json:"comment,omitempty"`
+}
+
+// ApprovalDecisionRepresentation struct
+type ApprovalDecisionRepresentation struct {
+	Comment *string `json:"comment,omitempty"`
+}
+
+// ConfigurationRevisionRepresentation struct. Type is configuration or admin_configuration, RollbackOf is the restored
+// revision when the change is a rollback.
+type ConfigurationRevisionRepresentation struct {
+	ID             *int64  `json:"id"`
+	Type           *string `json:"type"`
+	AuthorID       *string `json:"authorId"`
+	AuthorUsername *string `json:"authorUsername"`
+	CreatedAt      *int64  `json:"createdAt"`
+	RollbackOf     *int64  `json:"rollbackOf,omitempty"`
+}
+
+// ConfigurationDiffRepresentation is a setting which differs between two revisions. From or To is missing when the
+// setting is not defined in the revision. ApprovalID is the pending approval request of a synchronized setting whose
+// update requires an approval.
+type ConfigurationDiffRepresentation struct {
+	Setting    string      `json:"setting"`
+	From       interface{} `json:"from,omitempty"`
+	To         interface{} `json:"to,omitempty"`
+	ApprovalID *int64      `json:"approvalId,omitempty"`
+}
+
+// RealmTemplateRepresentation is the desired state of a realm. Only the given parts of the template are provisioned.
+type RealmTemplateRepresentation struct {
+	Realm               *string                       `json:"realm"`
+	DisplayName         *string                       `json:"displayName,omitempty"`
+	Enabled             *bool                         `json:"enabled,omitempty"`
+	PasswordPolicy      *PasswordPolicyRepresentation `json:"passwordPolicy,omitempty"`
+	Clients             []ClientRepresentation        `json:"clients,omitempty"`
+	Groups              []GroupTemplateRepresentation `json:"groups,omitempty"`
+	CustomConfiguration *RealmCustomConfiguration     `json:"customConfiguration,omitempty"`
+	AdminConfiguration  *RealmAdminConfiguration      `json:"adminConfiguration,omitempty"`
+}
+
+// GroupTemplateRepresentation is a top-level group of a realm template with its authorizations and its back-office configuration
+type GroupTemplateRepresentation struct {
+	Name                    *string                       `json:"name"`
+	Authorizations          *AuthorizationsRepresentation `json:"authorizations,omitempty"`
+	BackOfficeConfiguration BackOfficeConfiguration       `json:"backOfficeConfiguration,omitempty"`
+}
+
+// Types of the steps of a provisioning plan
+const (
+	ProvisioningStepRealm                   = "realm"
+	ProvisioningStepClient                  = "client"
+	ProvisioningStepCustomConfiguration     = "custom_configuration"
+	ProvisioningStepAdminConfiguration      = "admin_configuration"
+	ProvisioningStepGroup                   = "group"
+	ProvisioningStepAuthorizations          = "authorizations"
+	ProvisioningStepBackOfficeConfiguration = "backoffice_configuration"
+)
+
+// Actions of the steps of a provisioning plan
+const (
+	ProvisioningActionCreate    = "create"
+	ProvisioningActionUpdate    = "update"
+	ProvisioningActionUnchanged = "unchanged"
+)
+
+// ProvisioningPlanRepresentation lists the steps needed to bring a realm to the state of a template. Applied is false
+// for a dry-run.
+type ProvisioningPlanRepresentation struct {
+	Realm   string                           `json:"realm"`
+	Applied bool                             `json:"applied"`
+	Steps   []ProvisioningStepRepresentation `json:"steps"`
+}
+
+// ProvisioningStepRepresentation struct. Name is the realm name, the clientId or the group name.
+type ProvisioningStepRepresentation struct {
+	Type   string `json:"type"`
+	Name   string `json:"name"`
+	Action string `json:"action"`
+}
+
+// RealmStateRepresentation is the state of a realm owned by the bridge, as kept in a configuration repository. The
+// authorizations and the back-office configurations are given by group name. The missing parts are not managed.
+type RealmStateRepresentation struct {
+	Realm                   *string                                 `json:"realm"`
+	Configuration           *RealmCustomConfiguration               `json:"configuration,omitempty"`
+	AdminConfiguration      *RealmAdminConfiguration                `json:"admin_configuration,omitempty"`
+	Authorizations          map[string]AuthorizationsRepresentation `json:"authorizations"`
+	BackOfficeConfiguration map[string]BackOfficeConfiguration      `json:"backoffice_configuration"`
+}
+
+// EffectiveAuthorizationRepresentation is an action allowed to the members of a group. In a user report, the targets are
+// the realms and the groups the action is allowed on. In a target report, they are the ones of the authorization: "*"
+// stands for any realm or any group, "/path/*" for a group and its descendants.
+type EffectiveAuthorizationRepresentation struct {
+	Realm       string  `json:"realm"`
+	Group       string  `json:"group"`
+	Action      string  `json:"action"`
+	TargetRealm *string `json:"targetRealm,omitempty"`
+	TargetGroup *string `json:"targetGroup,omitempty"`
+}
+
+// UserAuthorizationsReportRepresentation lists the actions allowed to a user through their groups
+type UserAuthorizationsReportRepresentation struct {
+	Realm          string                                 `json:"realm"`
+	UserID         string                                 `json:"userId"`
+	Username       *string                                `json:"username,omitempty"`
+	Groups         []string                               `json:"groups"`
+	Authorizations []EffectiveAuthorizationRepresentation `json:"authorizations"`
+}
+
+// TargetAuthorizationsReportRepresentation lists the groups, with their members, allowed to act on a realm or on a group
+type TargetAuthorizationsReportRepresentation struct {
+	TargetRealm string                          `json:"targetRealm"`
+	TargetGroup *string                         `json:"targetGroup,omitempty"`
+	Groups      []AuthorizedGroupRepresentation `json:"groups"`
+}
+
+// AuthorizedGroupRepresentation is a group holding authorizations on the target of a report
+type AuthorizedGroupRepresentation struct {
+	Realm          string                                 `json:"realm"`
+	Group          string                                 `json:"group"`
+	Authorizations []EffectiveAuthorizationRepresentation `json:"authorizations"`
+	Members        []AuthorizedUserRepresentation         `json:"members"`
+}
+
+// AuthorizedUserRepresentation struct
+type AuthorizedUserRepresentation struct {
+	ID       string  `json:"id"`
+	Username *string `json:"username,omitempty"`
+}
+
+// Authorizations reports formats
+const (
+	ReportFormatJSON = "json"
+	ReportFormatCSV  = "csv"
+)
+
+// Users import formats
+const (
+	ImportFormatCSV  = "csv"
+	ImportFormatJSON = "json"
+)
+
+// Status of the rows of a users import
+const (
+	ImportRowCreated          = "created"
+	ImportRowCreatedWithError = "created_with_error"
+	ImportRowValid            = "valid"
+	ImportRowInvalid          = "invalid"
+	ImportRowForbidden        = "forbidden"
+	ImportRowFailed           = "failed"
+)
+
+const (
+	importColumnString = iota
+	importColumnList
+	importColumnBool
+)
+
+// Columns accepted in a CSV users import
+var importColumns = map[string]int{
+	"username": importColumnString, "gender": importColumnString, "firstName": importColumnString, "lastName": importColumnString,
+	"email": importColumnString, "emailVerified": importColumnBool, "phoneNumber": importColumnString, "phoneNumberVerified": importColumnBool,
+	"birthDate": importColumnString, "birthLocation": importColumnString, "idDocumentType": importColumnString,
+	"idDocumentNumber": importColumnString, "idDocumentExpiration": importColumnString, "groups": importColumnList,
+	"trustIdGroups": importColumnList, "roles": importColumnList, "locale": importColumnString, "enabled": importColumnBool,
+	"label": importColumnString,
+}
+
+// UserImportReportRepresentation is the result of a users import. With dry-run, rows are only validated.
+type UserImportReportRepresentation struct {
+	DryRun    bool                          `json:"dryRun"`
+	Total     int                           `json:"total"`
+	Succeeded int                           `json:"succeeded"`
+	Failed    int                           `json:"failed"`
+	Rows      []UserImportRowRepresentation `json:"rows"`
+}
+
+// UserImportRowRepresentation is the result of the import of a single user. Line starts at 1 with the first user.
+type UserImportRowRepresentation struct {
+	Line     int     `json:"line"`
+	Username *string `json:"username,omitempty"`
+	Status   string  `json:"status"`
+	UserID   *string `json:"userId,omitempty"`
+	Error    *string `json:"error,omitempty"`
+}
+
+// BulkJobRepresentation is the state of a bulk operation executed in background
+type BulkJobRepresentation struct {
+	ID       string      `json:"id"`
+	Status   string      `json:"status"`
+	Progress *int        `json:"progress,omitempty"`
+	Report   interface{} `json:"report,omitempty"`
+	Error    *string     `json:"error,omitempty"`
+}
+
+// Users export formats
+const (
+	ExportFormatCSV    = "csv"
+	ExportFormatNDJSON = "ndjson"
+)
+
+// Columns of a users export. They are named after the JSON fields of UserRepresentation.
+var (
+	ExportColumns        = []string{"id", "username", "gender", "firstName", "lastName", "email", "emailVerified", "phoneNumber", "phoneNumberVerified", "birthDate", "birthLocation", "idDocumentType", "idDocumentNumber", "idDocumentExpiration", "trustIdGroups", "locale", "smsSent", "smsAttempts", "enabled", "label", "accreditations", "createdTimestamp"}
+	DefaultExportColumns = []string{"id", "username", "email", "firstName", "lastName", "enabled"}
+	userDetailsColumns   = map[string]bool{"birthLocation": true, "idDocumentType": true, "idDocumentNumber": true, "idDocumentExpiration": true}
+)
+
+// HasUserDetailsColumns tells whether the export contains fields of the user details stored encrypted in the users database
+func HasUserDetailsColumns(columns []string) bool {
+	for _, column := range columns {
+		if userDetailsColumns[column] {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateExportColumns checks that all the columns of an export exist
+func ValidateExportColumns(columns []string) error {
+	for _, column := range columns {
+		var found = false
+		for _, exportColumn := range ExportColumns {
+			found = found || column == exportColumn
+		}
+		if !found {
+			return errorhandler.CreateBadRequestError(constants.MsgErrInvalidParam + "." + constants.Columns + "." + column)
+		}
+	}
+	return nil
+}
+
+// Operations which can be applied to many users at once
+const (
+	BulkOperationLockUser            = "LockUser"
+	BulkOperationUnlockUser          = "UnlockUser"
+	BulkOperationDeleteUser          = "DeleteUser"
+	BulkOperationAddGroupToUser      = "AddGroupToUser"
+	BulkOperationExecuteActionsEmail = "ExecuteActionsEmail"
+	BulkOperationSendReminderEmail   = "SendReminderEmail"
+)
+
+// Status of the users of a bulk operation
+const (
+	BulkUserSucceeded = "succeeded"
+	BulkUserForbidden = "forbidden"
+	BulkUserFailed    = "failed"
+	BulkUserPending   = "pending"
+)
+
+// BulkUserOperationRepresentation is an operation applied to a list of users or to the users matching a filter.
+// GroupID is used by AddGroupToUser, Actions by ExecuteActionsEmail and ClientID, RedirectURI and Lifespan by the emails.
+type BulkUserOperationRepresentation struct {
+	Operation   *string                       `json:"operation"`
+	UserIDs     *[]string                     `json:"userIds,omitempty"`
+	Filter      *BulkUserFilterRepresentation `json:"filter,omitempty"`
+	GroupID     *string                       `json:"groupId,omitempty"`
+	Actions     *[]RequiredAction             `json:"actions,omitempty"`
+	ClientID    *string                       `json:"clientId,omitempty"`
+	RedirectURI *string                       `json:"redirectUri,omitempty"`
+	Lifespan    *int                          `json:"lifespan,omitempty"`
+}
+
+// BulkUserFilterRepresentation selects the users of a bulk operation the same way GetUsers does
+type BulkUserFilterRepresentation struct {
+	GroupIDs  []string `json:"groupIds"`
+	Search    *string  `json:"search,omitempty"`
+	Email     *string  `json:"email,omitempty"`
+	FirstName *string  `json:"firstName,omitempty"`
+	LastName  *string  `json:"lastName,omitempty"`
+	Username  *string  `json:"username,omitempty"`
+}
+
+// BulkOperationReportRepresentation is the result of a bulk operation
+type BulkOperationReportRepresentation struct {
+	Operation string                         `json:"operation"`
+	Total     int                            `json:"total"`
+	Succeeded int                            `json:"succeeded"`
+	Failed    int                            `json:"failed"`
+	Pending   int                            `json:"pending"`
+	Users     []BulkUserResultRepresentation `json:"users"`
+}
+
+// BulkUserResultRepresentation is the result of a bulk operation for a single user. ApprovalID is the request created when
+// the operation requires an approval in the realm.
+type BulkUserResultRepresentation struct {
+	UserID     string  `json:"userId"`
+	Status     string  `json:"status"`
+	Error      *string `json:"error,omitempty"`
+	ApprovalID *int64  `json:"approvalId,omitempty"`
+}
+
+// UserSearchCriteria are the criteria of an advanced users search. Keycloak parameters and groups are forwarded to Keycloak,
+// phone number, birth date and ID document number are searched in the bridge index, the other criteria are checked on each user
+type UserSearchCriteria struct {
+	GroupIDs                   []string
+	KeycloakParams             []string
+	PhoneNumber                *string
+	BirthDate                  *string
+	IDDocumentNumber           *string
+	Enabled                    *bool
+	CreatedAfter               *time.Time
+	CreatedBefore              *time.Time
+	AccreditationType          *string
+	AccreditationExpiresAfter  *time.Time
+	AccreditationExpiresBefore *time.Time
+	IncludeDeleted             bool
+	First                      int
+	Max                        int
+}
+
+// HasKeycloakCriteria returns true if Keycloak has to filter the users
+func (criteria UserSearchCriteria) HasKeycloakCriteria() bool {
+	return len(criteria.GroupIDs) > 0 || len(criteria.KeycloakParams) > 0
+}
+
+// HasIndexedCriteria returns true if the users search index has to be used
+func (criteria UserSearchCriteria) HasIndexedCriteria() bool {
+	return criteria.PhoneNumber != nil || criteria.BirthDate != nil || criteria.IDDocumentNumber != nil
+}
+
+// HasUserCriteria returns true if the users have to be checked one by one
+func (criteria UserSearchCriteria) HasUserCriteria() bool {
+	return criteria.Enabled != nil || criteria.CreatedAfter != nil || criteria.CreatedBefore != nil || criteria.AccreditationType != nil ||
+		criteria.AccreditationExpiresAfter != nil || criteria.AccreditationExpiresBefore != nil
+}
+
+// Match checks the deletion mark, the enabled state, the creation date and the accreditations of a user
+func (criteria UserSearchCriteria) Match(user UserRepresentation) bool {
+	if !criteria.IncludeDeleted && user.DeletedTimestamp != nil {
+		return false
+	}
+	if criteria.Enabled != nil && (user.Enabled == nil || *user.Enabled != *criteria.Enabled) {
+		return false
+	}
+	if criteria.CreatedAfter != nil || criteria.CreatedBefore != nil {
+		if user.CreatedTimestamp == nil {
+			return false
+		}
+		var created = time.Unix(0, *user.CreatedTimestamp*int64(time.Millisecond))
+		if (criteria.CreatedAfter != nil && created.Before(*criteria.CreatedAfter)) || (criteria.CreatedBefore != nil && !created.Before(*criteria.CreatedBefore)) {
+			return false
+		}
+	}
+	if criteria.AccreditationType == nil && criteria.AccreditationExpiresAfter == nil && criteria.AccreditationExpiresBefore == nil {
+		return true
+	}
+	if user.Accreditations != nil {
+		for _, accred := range *user.Accreditations {
+			if criteria.matchAccreditation(accred) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (criteria UserSearchCriteria) matchAccreditation(accred AccreditationRepresentation) bool {
+	if criteria.AccreditationType != nil && (accred.Type == nil || *accred.Type != *criteria.AccreditationType) {
+		return false
+	}
+	if criteria.AccreditationExpiresAfter == nil && criteria.AccreditationExpiresBefore == nil {
+		return true
+	}
+	if accred.ExpiryDate == nil {
+		return false
+	}
+	var expiry, err = time.Parse(constants.SupportedDateLayouts[0], *accred.ExpiryDate)
+	if err != nil {
+		return false
+	}
+	return (criteria.AccreditationExpiresAfter == nil || !expiry.Before(*criteria.AccreditationExpiresAfter)) &&
+		(criteria.AccreditationExpiresBefore == nil || expiry.Before(*criteria.AccreditationExpiresBefore))
+}
+
+// SearchIndexReportRepresentation is the report of the reindexing of the users of a realm
+type SearchIndexReportRepresentation struct {
+	Total   int `json:"total"`
+	Indexed int `json:"indexed"`
+	Failed  int `json:"failed"`
+}
+
 // ConvertCredential creates an API credential from a KC credential
 func ConvertCredential(credKc *kc.CredentialRepresentation) CredentialRepresentation {
 	var cred CredentialRepresentation
@@ -201,6 +753,26 @@ func ConvertCredential(credKc *kc.CredentialRepresentation) CredentialRepresenta
 	return cred
 }
 
+// ConvertToAPIUserSession creates an API user session from a KC user session
+func ConvertToAPIUserSession(session kc.UserSessionRepresentation, offline bool) UserSessionRepresentation {
+	var clients = []string{}
+	if session.Clients != nil {
+		for _, clientID := range *session.Clients {
+			clients = append(clients, clientID)
+		}
+		sort.Strings(clients)
+	}
+
+	return UserSessionRepresentation{
+		ID:         session.ID,
+		IPAddress:  session.IPAddress,
+		Start:      session.Start,
+		LastAccess: session.LastAccess,
+		Clients:    &clients,
+		Offline:    &offline,
+	}
+}
+
 // ConvertAttackDetectionStatus creates a brute force status from a map
 func ConvertAttackDetectionStatus(status map[string]interface{}) AttackDetectionStatusRepresentation {
 	var res AttackDetectionStatusRepresentation
@@ -260,6 +832,11 @@ func ConvertToAPIUser(ctx context.Context, userKc kc.UserRepresentation, logger
 	if value := userKc.GetAttribute(constants.AttrbTrustIDGroups); value != nil {
 		userRep.TrustIDGroups = &value
 	}
+	if value := userKc.GetAttributeString(constants.AttrbSoftDeleted); value != nil {
+		if deleted, err := strconv.ParseInt(*value, 10, 64); err == nil {
+			userRep.DeletedTimestamp = &deleted
+		}
+	}
 	if values := userKc.GetAttribute(constants.AttrbAccreditations); len(values) > 0 {
 		var accreds []AccreditationRepresentation
 		for _, accredJSON := range values {
@@ -295,6 +872,20 @@ func ConvertToAPIUsersPage(ctx context.Context, users kc.UsersPageRepresentation
 	}
 }
 
+// WithoutDeletedUsers removes the soft deleted users from a page, which may then contain less users than requested. The
+// count is the one of Keycloak: decreased, it would end a pagination before its last users. Callers paginating the
+// whole list must include the deleted users and skip them, as a short page does not mean the end of the list.
+func (page UsersPageRepresentation) WithoutDeletedUsers() UsersPageRepresentation {
+	var users = []UserRepresentation{}
+	for _, user := range page.Users {
+		if user.DeletedTimestamp == nil {
+			users = append(users, user)
+		}
+	}
+	page.Users = users
+	return page
+}
+
 // ConvertToKCUser creates a KC user representation from an API user
 func ConvertToKCUser(user UserRepresentation) kc.UserRepresentation {
 	var userRep kc.UserRepresentation
@@ -324,6 +915,92 @@ func ConvertToKCUser(user UserRepresentation) kc.UserRepresentation {
 	return userRep
 }
 
+// ConvertToAPIClient creates an API client from a KC client
+func ConvertToAPIClient(client kc.ClientRepresentation) ClientRepresentation {
+	return ClientRepresentation{
+		ID:                        client.ID,
+		Name:                      client.Name,
+		BaseURL:                   client.BaseURL,
+		ClientID:                  client.ClientID,
+		Protocol:                  client.Protocol,
+		Enabled:                   client.Enabled,
+		Description:               client.Description,
+		RedirectURIs:              client.RedirectUris,
+		WebOrigins:                client.WebOrigins,
+		PublicClient:              client.PublicClient,
+		StandardFlowEnabled:       client.StandardFlowEnabled,
+		ImplicitFlowEnabled:       client.ImplicitFlowEnabled,
+		DirectAccessGrantsEnabled: client.DirectAccessGrantsEnabled,
+		ServiceAccountsEnabled:    client.ServiceAccountsEnabled,
+	}
+}
+
+// MergeIntoKCClient applies the values of an API client to a KC client. Missing values are left unchanged.
+func MergeIntoKCClient(client ClientRepresentation, kcClient *kc.ClientRepresentation) {
+	mergeString(client.ClientID, &kcClient.ClientID)
+	mergeString(client.Name, &kcClient.Name)
+	mergeString(client.BaseURL, &kcClient.BaseURL)
+	mergeString(client.Protocol, &kcClient.Protocol)
+	mergeString(client.Description, &kcClient.Description)
+	mergeBool(client.Enabled, &kcClient.Enabled)
+	mergeBool(client.PublicClient, &kcClient.PublicClient)
+	mergeBool(client.StandardFlowEnabled, &kcClient.StandardFlowEnabled)
+	mergeBool(client.ImplicitFlowEnabled, &kcClient.ImplicitFlowEnabled)
+	mergeBool(client.DirectAccessGrantsEnabled, &kcClient.DirectAccessGrantsEnabled)
+	mergeBool(client.ServiceAccountsEnabled, &kcClient.ServiceAccountsEnabled)
+	if client.RedirectURIs != nil {
+		kcClient.RedirectUris = client.RedirectURIs
+	}
+	if client.WebOrigins != nil {
+		kcClient.WebOrigins = client.WebOrigins
+	}
+}
+
+func mergeString(value *string, target **string) {
+	if value != nil {
+		*target = value
+	}
+}
+
+func mergeBool(value *bool, target **bool) {
+	if value != nil {
+		*target = value
+	}
+}
+
+// ConvertToAPIClientPolicy creates an API client policy from a DB one
+func ConvertToAPIClientPolicy(policy dto.ClientPolicy) ClientPolicyRepresentation {
+	var prefixes = policy.AllowedRedirectURIPrefixes
+	if prefixes == nil {
+		prefixes = []string{}
+	}
+	return ClientPolicyRepresentation{
+		ImplicitFlowAllowed:         &policy.ImplicitFlowAllowed,
+		DirectAccessGrantsAllowed:   &policy.DirectAccessGrantsAllowed,
+		PublicClientsAllowed:        &policy.PublicClientsAllowed,
+		WildcardRedirectURIsAllowed: &policy.WildcardRedirectURIsAllowed,
+		AllowedRedirectURIPrefixes:  prefixes,
+	}
+}
+
+// ConvertToDBStruct creates a DB client policy. Missing values are refused.
+func (policy ClientPolicyRepresentation) ConvertToDBStruct() dto.ClientPolicy {
+	var res = dto.ClientPolicy{AllowedRedirectURIPrefixes: policy.AllowedRedirectURIPrefixes}
+	if policy.ImplicitFlowAllowed != nil {
+		res.ImplicitFlowAllowed = *policy.ImplicitFlowAllowed
+	}
+	if policy.DirectAccessGrantsAllowed != nil {
+		res.DirectAccessGrantsAllowed = *policy.DirectAccessGrantsAllowed
+	}
+	if policy.PublicClientsAllowed != nil {
+		res.PublicClientsAllowed = *policy.PublicClientsAllowed
+	}
+	if policy.WildcardRedirectURIsAllowed != nil {
+		res.WildcardRedirectURIsAllowed = *policy.WildcardRedirectURIsAllowed
+	}
+	return res
+}
+
 // ConvertToKCGroup creates a KC group representation from an API group
 func ConvertToKCGroup(group GroupRepresentation) kc.GroupRepresentation {
 	return kc.GroupRepresentation{
@@ -331,6 +1008,23 @@ func ConvertToKCGroup(group GroupRepresentation) kc.GroupRepresentation {
 	}
 }
 
+// ConvertToAPIGroup creates an API group from a KC group, with its subgroups
+func ConvertToAPIGroup(group kc.GroupRepresentation) GroupRepresentation {
+	var res = GroupRepresentation{
+		ID:   group.ID,
+		Name: group.Name,
+		Path: group.Path,
+	}
+	if group.SubGroups != nil && len(*group.SubGroups) > 0 {
+		var subGroups = []GroupRepresentation{}
+		for _, subGroup := range *group.SubGroups {
+			subGroups = append(subGroups, ConvertToAPIGroup(subGroup))
+		}
+		res.SubGroups = &subGroups
+	}
+	return res
+}
+
 // ConvertToAPIAuthorizations creates a API authorization representation from an array of DB Authorization
 func ConvertToAPIAuthorizations(authorizations []configuration.Authorization) AuthorizationsRepresentation {
 	var matrix = make(map[string]map[string]map[string]struct{})
@@ -433,6 +1127,99 @@ func ConvertToKCFedID(fedID FederatedIdentityRepresentation) kc.FederatedIdentit
 	return kcFedID
 }
 
+// ConvertToAPIFedID creates an API federated identity representation from a KC federated identity representation
+func ConvertToAPIFedID(kcFedID kc.FederatedIdentityRepresentation) FederatedIdentityRepresentation {
+	return FederatedIdentityRepresentation{
+		IdentityProvider: kcFedID.IdentityProvider,
+		UserID:           kcFedID.UserID,
+		Username:         kcFedID.UserName,
+	}
+}
+
+// ConvertToAPIIdentityProvider creates an API identity provider from a KC identity provider
+func ConvertToAPIIdentityProvider(idp kc.IdentityProviderRepresentation) IdentityProviderRepresentation {
+	return IdentityProviderRepresentation{
+		Alias:                     idp.Alias,
+		DisplayName:               idp.DisplayName,
+		ProviderID:                idp.ProviderID,
+		Enabled:                   idp.Enabled,
+		TrustEmail:                idp.TrustEmail,
+		StoreToken:                idp.StoreToken,
+		FirstBrokerLoginFlowAlias: idp.FirstBrokerLoginFlowAlias,
+		Config:                    idp.Config,
+	}
+}
+
+// MergeIntoKCIdentityProvider overrides the settings of the KC identity provider with the ones provided in the API identity provider.
+// The alias can't be changed.
+func MergeIntoKCIdentityProvider(idp IdentityProviderRepresentation, kcIdp *kc.IdentityProviderRepresentation) {
+	mergeString(idp.DisplayName, &kcIdp.DisplayName)
+	mergeString(idp.ProviderID, &kcIdp.ProviderID)
+	mergeString(idp.FirstBrokerLoginFlowAlias, &kcIdp.FirstBrokerLoginFlowAlias)
+	mergeBool(idp.Enabled, &kcIdp.Enabled)
+	mergeBool(idp.TrustEmail, &kcIdp.TrustEmail)
+	mergeBool(idp.StoreToken, &kcIdp.StoreToken)
+	if idp.Config != nil {
+		var config = map[string]string{}
+		if kcIdp.Config != nil {
+			for k, v := range *kcIdp.Config {
+				config[k] = v
+			}
+		}
+		for k, v := range *idp.Config {
+			config[k] = v
+		}
+		kcIdp.Config = &config
+	}
+}
+
+// ConvertToAPIPasswordPolicy creates an API password policy from a parsed Keycloak password policy
+func ConvertToAPIPasswordPolicy(policy keycloakb.PasswordPolicy) PasswordPolicyRepresentation {
+	var res = PasswordPolicyRepresentation{
+		Length:                     policy.Length,
+		MaxLength:                  policy.MaxLength,
+		Digits:                     policy.Digits,
+		LowerCase:                  policy.LowerCase,
+		UpperCase:                  policy.UpperCase,
+		SpecialChars:               policy.SpecialChars,
+		RegexPattern:               policy.RegexPattern,
+		PasswordHistory:            policy.PasswordHistory,
+		ForceExpiredPasswordChange: policy.ForceExpiredPasswordChange,
+		HashAlgorithm:              policy.HashAlgorithm,
+		HashIterations:             policy.HashIterations,
+		PasswordBlacklist:          policy.PasswordBlacklist,
+		Others:                     policy.Others,
+	}
+	if policy.NotUsername {
+		res.NotUsername = &policy.NotUsername
+	}
+	if policy.NotEmail {
+		res.NotEmail = &policy.NotEmail
+	}
+	return res
+}
+
+// ConvertToKeycloakbStruct creates a Keycloak password policy from an API password policy
+func (policy PasswordPolicyRepresentation) ConvertToKeycloakbStruct() keycloakb.PasswordPolicy {
+	return keycloakb.PasswordPolicy{
+		Length:                     policy.Length,
+		MaxLength:                  policy.MaxLength,
+		Digits:                     policy.Digits,
+		LowerCase:                  policy.LowerCase,
+		UpperCase:                  policy.UpperCase,
+		SpecialChars:               policy.SpecialChars,
+		NotUsername:                policy.NotUsername != nil && *policy.NotUsername,
+		NotEmail:                   policy.NotEmail != nil && *policy.NotEmail,
+		RegexPattern:               policy.RegexPattern,
+		PasswordHistory:            policy.PasswordHistory,
+		ForceExpiredPasswordChange: policy.ForceExpiredPasswordChange,
+		HashAlgorithm:              policy.HashAlgorithm,
+		HashIterations:             policy.HashIterations,
+		PasswordBlacklist:          policy.PasswordBlacklist,
+		Others:                     policy.Others,
+	}
+}
+
 // CreateDefaultRealmAdminConfiguration creates a default admin configuration
 func CreateDefaultRealmAdminConfiguration() RealmAdminConfiguration {
 	var mode = "corporate"
@@ -493,7 +1280,108 @@ func ConvertRealmAccreditationsFromDBStruct(accreds []configuration.RealmAdminAc
 	return res
 }
 
-// Validators
+// ConvertToAPIReportSchedule creates an API report schedule from a DB struct
+func ConvertToAPIReportSchedule(schedule dto.DBReportSchedule) StatisticsReportScheduleRepresentation {
+	var res = StatisticsReportScheduleRepresentation{
+		ID:         &schedule.ID,
+		Frequency:  &schedule.Frequency,
+		Hour:       &schedule.Hour,
+		Recipients: schedule.Recipients,
+	}
+	if schedule.Frequency != dto.ReportFrequencyDaily {
+		res.Day = &schedule.Day
+	}
+	if schedule.LastSent != nil {
+		var lastSent = schedule.LastSent.Unix()
+		res.LastSent = &lastSent
+	}
+	return res
+}
+
+// approvalActionResetPassword is the action whose approval requests hide their parameters
+const approvalActionResetPassword = "MGMT_ResetPassword"
+
+// ConvertToAPIApprovalRequest creates an API approval request from a DB one
+func ConvertToAPIApprovalRequest(request dto.DBApprovalRequest) ApprovalRequestRepresentation {
+	var createdAt = request.CreatedAt.Unix()
+	var res = ApprovalRequestRepresentation{
+		ID:                &request.ID,
+		Action:            &request.Action,
+		TargetID:          request.TargetID,
+		RequesterID:       &request.RequesterID,
+		RequesterUsername: &request.RequesterUsername,
+		Status:            &request.Status,
+		CreatedAt:         &createdAt,
+		DeciderID:         request.DeciderID,
+		DeciderUsername:   request.DeciderUsername,
+		Comment:           request.Comment,
+	}
+	if request.DecidedAt != nil {
+		var decidedAt = request.DecidedAt.Unix()
+		res.DecidedAt = &decidedAt
+	}
+	if len(request.Payload) > 0 && request.Action != approvalActionResetPassword {
+		var parameters = json.RawMessage(request.Payload)
+		res.Parameters = &parameters
+	}
+	return res
+}
+
+// ConvertToAPIConfigurationRevision creates an API configuration revision from a DB one. The content of the revision is not returned.
+func ConvertToAPIConfigurationRevision(revision dto.DBConfigurationRevision) ConfigurationRevisionRepresentation {
+	var createdAt = revision.CreatedAt.Unix()
+	return ConfigurationRevisionRepresentation{
+		ID:             &revision.ID,
+		Type:           &revision.ConfigType,
+		AuthorID:       &revision.AuthorID,
+		AuthorUsername: &revision.AuthorUsername,
+		CreatedAt:      &createdAt,
+		RollbackOf:     revision.RollbackOf,
+	}
+}
+
+// ConvertToAPIGrant creates an API temporary grant from a DB one
+func ConvertToAPIGrant(grant dto.DBTemporaryGrant) GrantRepresentation {
+	var expiresAt = grant.ExpiresAt.Unix()
+	return GrantRepresentation{
+		Type:      &grant.GrantType,
+		TargetID:  &grant.TargetID,
+		ClientID:  grant.ClientID,
+		Name:      grant.Name,
+		ExpiresAt: &expiresAt,
+		GrantedBy: &grant.GrantedBy,
+	}
+}
+
+// ConvertToAPIScheduledUserChange creates an API scheduled user change from a DB one
+func ConvertToAPIScheduledUserChange(change dto.DBScheduledUserChange) ScheduledUserChangeRepresentation {
+	var applyAt = change.ApplyAt.Unix()
+	var createdAt = change.CreatedAt.Unix()
+	return ScheduledUserChangeRepresentation{
+		ID:        &change.ID,
+		Action:    &change.Action,
+		ApplyAt:   &applyAt,
+		CreatedAt: &createdAt,
+		CreatedBy: &change.CreatorUsername,
+	}
+}
+
+// ConvertToDBStruct creates a DB report schedule
+func (schedule StatisticsReportScheduleRepresentation) ConvertToDBStruct(realmName string) dto.DBReportSchedule {
+	var res = dto.DBReportSchedule{
+		RealmName:  realmName,
+		Frequency:  *schedule.Frequency,
+		Hour:       *schedule.Hour,
+		Recipients: schedule.Recipients,
+	}
+	if schedule.ID != nil {
+		res.ID = *schedule.ID
+	}
+	if schedule.Day != nil {
+		res.Day = *schedule.Day
+	}
+	return res
+}
 
 // NewBackOfficeConfigurationFromJSON creates and validates a new BackOfficeConfiguration from a JSON value
 func NewBackOfficeConfigurationFromJSON(confJSON string) (BackOfficeConfiguration, error) {
@@ -503,6 +1391,11 @@ func NewBackOfficeConfigurationFromJSON(confJSON string) (BackOfficeConfiguratio
 		return BackOfficeConfiguration{}, errorhandler.CreateBadRequestError(errorhandler.MsgErrInvalidQueryParam + ".body")
 	}
 
+	return boConf, boConf.Validate()
+}
+
+// Validate is a validator for BackOfficeConfiguration
+func (boConf BackOfficeConfiguration) Validate() error {
 	var validator = validation.NewParameterValidator()
 	for _, realmConf := range boConf {
 		for keyBoConf, valueBoConf := range realmConf {
@@ -511,9 +1404,150 @@ func NewBackOfficeConfigurationFromJSON(confJSON string) (BackOfficeConfiguratio
 		}
 	}
 
-	return boConf, validator.Status()
+	return validator.Status()
+}
+
+// CSVRecords gives the report as CSV records, one per authorization, header included
+func (report UserAuthorizationsReportRepresentation) CSVRecords() [][]string {
+	var records = [][]string{{"realm", "userId", "username", "group", "action", "targetRealm", "targetGroup"}}
+	for _, authz := range report.Authorizations {
+		records = append(records, []string{report.Realm, report.UserID, stringValue(report.Username), authz.Group, authz.Action,
+			stringValue(authz.TargetRealm), stringValue(authz.TargetGroup)})
+	}
+	return records
+}
+
+// CSVRecords gives the report as CSV records, one per authorization and member of the group, header included. The
+// authorizations of a group without members are listed with an empty user.
+func (report TargetAuthorizationsReportRepresentation) CSVRecords() [][]string {
+	var records = [][]string{{"targetRealm", "targetGroup", "realm", "group", "action", "grantedTargetRealm", "grantedTargetGroup", "userId", "username"}}
+	for _, group := range report.Groups {
+		var members = group.Members
+		if len(members) == 0 {
+			members = []AuthorizedUserRepresentation{{}}
+		}
+		for _, authz := range group.Authorizations {
+			for _, member := range members {
+				records = append(records, []string{report.TargetRealm, stringValue(report.TargetGroup), group.Realm, group.Group, authz.Action,
+					stringValue(authz.TargetRealm), stringValue(authz.TargetGroup), member.ID, stringValue(member.Username)})
+			}
+		}
+	}
+	return records
+}
+
+func stringValue(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
+}
+
+// ParseRealmStateYAML reads the state of a realm from a YAML document. The document is converted to JSON so that the
+// names of the settings are the same as in the API.
+func ParseRealmStateYAML(content []byte) (RealmStateRepresentation, error) {
+	var invalidErr = errorhandler.CreateBadRequestError(constants.MsgErrInvalidParam + "." + constants.Config)
+
+	var document interface{}
+	if err := yaml.Unmarshal(content, &document); err != nil {
+		return RealmStateRepresentation{}, invalidErr
+	}
+	var jsonContent, err = json.Marshal(document)
+	if err != nil {
+		return RealmStateRepresentation{}, invalidErr
+	}
+
+	var state RealmStateRepresentation
+	if err = json.Unmarshal(jsonContent, &state); err != nil {
+		return RealmStateRepresentation{}, invalidErr
+	}
+	return state, state.Validate()
+}
+
+// ToYAML writes the state of a realm as a YAML document
+func (state RealmStateRepresentation) ToYAML() ([]byte, error) {
+	var jsonContent, err = json.Marshal(state)
+	if err != nil {
+		return nil, err
+	}
+
+	var document interface{}
+	if err = json.Unmarshal(jsonContent, &document); err != nil {
+		return nil, err
+	}
+	return yaml.Marshal(document)
+}
+
+// ParseUsersImport reads the users of an import. Format csv expects a header line with the names of the JSON fields of
+// UserRepresentation (list fields are comma separated), formats json and ndjson expect one JSON user per line.
+func ParseUsersImport(format string, content string) ([]UserRepresentation, error) {
+	if format == ImportFormatCSV {
+		return parseUsersCSV(content)
+	}
+
+	var res []UserRepresentation
+	for _, line := range strings.Split(content, "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		var user UserRepresentation
+		if err := json.Unmarshal([]byte(line), &user); err != nil {
+			return nil, errorhandler.CreateBadRequestError(constants.MsgErrInvalidParam + "." + constants.Body)
+		}
+		res = append(res, user)
+	}
+	return res, nil
+}
+
+func parseUsersCSV(content string) ([]UserRepresentation, error) {
+	var reader = csv.NewReader(strings.NewReader(content))
+	reader.TrimLeadingSpace = true
+
+	var records, err = reader.ReadAll()
+	if err != nil || len(records) == 0 {
+		return nil, errorhandler.CreateBadRequestError(constants.MsgErrInvalidParam + "." + constants.Body)
+	}
+
+	var header = records[0]
+	for _, column := range header {
+		if _, ok := importColumns[column]; !ok {
+			return nil, errorhandler.CreateBadRequestError(constants.MsgErrInvalidParam + "." + constants.Body + "." + column)
+		}
+	}
+
+	var res []UserRepresentation
+	for _, record := range records[1:] {
+		var fields = map[string]interface{}{}
+		for i, value := range record {
+			if value == "" {
+				continue
+			}
+			switch importColumns[header[i]] {
+			case importColumnList:
+				var values = strings.Split(value, ",")
+				for j := range values {
+					values[j] = strings.TrimSpace(values[j])
+				}
+				fields[header[i]] = values
+			case importColumnBool:
+				fields[header[i]] = strings.EqualFold(value, "true")
+			default:
+				fields[header[i]] = value
+			}
+		}
+
+		var user UserRepresentation
+		var bytes, _ = json.Marshal(fields)
+		if err = json.Unmarshal(bytes, &user); err != nil {
+			return nil, errorhandler.CreateBadRequestError(constants.MsgErrInvalidParam + "." + constants.Body)
+		}
+		res = append(res, user)
+	}
+	return res, nil
 }
 
+// Validators
+
 // Validate is a validator for UserRepresentation
 func (user UserRepresentation) Validate() error {
 	var v = validation.NewParameterValidator().
@@ -553,6 +1587,70 @@ func (role RoleRepresentation) Validate() error {
 		Status()
 }
 
+// Validate is a validator for ClientRepresentation
+func (client ClientRepresentation) Validate() error {
+	return validation.NewParameterValidator().
+		ValidateParameterRegExp(constants.ClientID, client.ClientID, constants.RegExpClientID, false).
+		ValidateParameterRegExp(constants.Name, client.Name, constants.RegExpName, false).
+		ValidateParameterRegExp(constants.Description, client.Description, constants.RegExpDescription, false).
+		ValidateParameterIn(constants.Protocol, client.Protocol, allowedClientProtocols, false).
+		ValidateParameterRegExp(constants.BaseURL, client.BaseURL, constants.RegExpRedirectURI, false).
+		ValidateParameterFunc(func() error {
+			if client.RedirectURIs == nil {
+				return nil
+			}
+			return ValidateRedirectURIs(*client.RedirectURIs)
+		}).
+		ValidateParameterFunc(func() error {
+			if client.WebOrigins == nil {
+				return nil
+			}
+			return validateURIs(constants.WebOrigins, *client.WebOrigins)
+		}).
+		Status()
+}
+
+// ValidateRedirectURIs is a validator for the redirect URIs of a client
+func ValidateRedirectURIs(uris []string) error {
+	return validateURIs(constants.RedirectURIs, uris)
+}
+
+func validateURIs(name string, uris []string) error {
+	var v = validation.NewParameterValidator()
+	for _, uri := range uris {
+		var value = uri
+		v = v.ValidateParameterRegExp(name, &value, constants.RegExpRedirectURI, true)
+	}
+	return v.Status()
+}
+
+// Validate is a validator for CredentialRepresentation
+func (credential CredentialRepresentation) Validate() error {
+	return validation.NewParameterValidator().
+		ValidateParameterRegExp(constants.ID, credential.ID, RegExpID, false).
+		ValidateParameterRegExp(constants.Type, credential.Type, RegExpName, false).
+		ValidateParameterRegExp(constants.Label, credential.UserLabel, RegExpLabel, false).
+		Status()
+}
+
+// Validate is a validator for ReEnrollmentRequestRepresentation
+func (request ReEnrollmentRequestRepresentation) Validate() error {
+	return validation.NewParameterValidator().
+		ValidateParameterNotNil(constants.Type, request.Type).
+		ValidateParameterFunc(func() error {
+			if _, ok := CredentialTypeRequiredActions[*request.Type]; !ok {
+				return errorhandler.CreateBadRequestError(constants.MsgErrInvalidParam + "." + constants.Type)
+			}
+			return nil
+		}).
+		Status()
+}
+
+// Validate is a validator for ClientPolicyRepresentation
+func (policy ClientPolicyRepresentation) Validate() error {
+	return validateURIs(constants.AllowedRedirectURIPrefixes, policy.AllowedRedirectURIPrefixes)
+}
+
 // Validate is a validator for GroupRepresentation
 func (group GroupRepresentation) Validate() error {
 	return validation.NewParameterValidator().
@@ -561,6 +1659,13 @@ func (group GroupRepresentation) Validate() error {
 		Status()
 }
 
+// Validate is a validator for GroupParentRepresentation
+func (parent GroupParentRepresentation) Validate() error {
+	return validation.NewParameterValidator().
+		ValidateParameterRegExp(constants.ParentID, parent.ParentID, constants.RegExpID, false).
+		Status()
+}
+
 // Validate is a validator for PasswordRepresentation
 func (password PasswordRepresentation) Validate() error {
 	return validation.NewParameterValidator().
@@ -584,6 +1689,12 @@ func (rac RealmAdminConfiguration) Validate() error {
 	return validation.NewParameterValidator().
 		ValidateParameterIn("mode", rac.Mode, allowedAdminConfMode, true).
 		ValidateParameterFunc(rac.validateAvailableChecks).
+		ValidateParameterFunc(func() error {
+			if rac.ClientPolicy == nil {
+				return nil
+			}
+			return rac.ClientPolicy.Validate()
+		}).
 		Status()
 }
 
@@ -650,9 +1761,285 @@ func (fedID FederatedIdentityRepresentation) Validate() error {
 		Status()
 }
 
+// Validate is a validator for IdentityProviderRepresentation
+func (idp IdentityProviderRepresentation) Validate() error {
+	return validation.NewParameterValidator().
+		ValidateParameterRegExp(constants.Alias, idp.Alias, constants.RegExpName, false).
+		ValidateParameterRegExp(constants.DisplayName, idp.DisplayName, constants.RegExpDescription, false).
+		ValidateParameterRegExp(constants.ProviderID, idp.ProviderID, constants.RegExpName, false).
+		ValidateParameterRegExp(constants.FirstBrokerLoginFlowAlias, idp.FirstBrokerLoginFlowAlias, constants.RegExpDescription, false).
+		Status()
+}
+
+// Validate is a validator for PasswordPolicyRepresentation
+func (policy PasswordPolicyRepresentation) Validate() error {
+	return validation.NewParameterValidator().
+		ValidateParameterFunc(policy.validateCounts).
+		ValidateParameterFunc(func() error {
+			if policy.Length != nil && policy.MaxLength != nil && *policy.Length > *policy.MaxLength {
+				return errorhandler.CreateBadRequestError(constants.MsgErrInvalidParam + "." + keycloakb.PolicyMaxLength)
+			}
+			return nil
+		}).
+		ValidateParameterFunc(func() error {
+			if policy.RegexPattern != nil {
+				if _, err := regexp.Compile(*policy.RegexPattern); err != nil {
+					return errorhandler.CreateBadRequestError(constants.MsgErrInvalidParam + "." + keycloakb.PolicyRegexPattern)
+				}
+			}
+			return nil
+		}).
+		ValidateParameterRegExp(keycloakb.PolicyHashAlgorithm, policy.HashAlgorithm, constants.RegExpName, false).
+		ValidateParameterRegExp(keycloakb.PolicyPasswordBlacklist, policy.PasswordBlacklist, constants.RegExpName, false).
+		Status()
+}
+
+func (policy PasswordPolicyRepresentation) validateCounts() error {
+	var counts = []struct {
+		name  string
+		value *int
+	}{
+		{keycloakb.PolicyLength, policy.Length},
+		{keycloakb.PolicyMaxLength, policy.MaxLength},
+		{keycloakb.PolicyDigits, policy.Digits},
+		{keycloakb.PolicyLowerCase, policy.LowerCase},
+		{keycloakb.PolicyUpperCase, policy.UpperCase},
+		{keycloakb.PolicySpecialChars, policy.SpecialChars},
+		{keycloakb.PolicyPasswordHistory, policy.PasswordHistory},
+		{keycloakb.PolicyForceExpiredPasswordChange, policy.ForceExpiredPasswordChange},
+		{keycloakb.PolicyHashIterations, policy.HashIterations},
+	}
+	for _, count := range counts {
+		if count.value != nil && *count.value < 0 {
+			return errorhandler.CreateBadRequestError(constants.MsgErrInvalidParam + "." + count.name)
+		}
+	}
+	return nil
+}
+
+// Validate is a validator for StatisticsReportScheduleRepresentation
+func (schedule StatisticsReportScheduleRepresentation) Validate() error {
+	return validation.NewParameterValidator().
+		ValidateParameterIn(constants.Frequency, schedule.Frequency, allowedFrequencies, true).
+		ValidateParameterNotNil(constants.Hour, schedule.Hour).
+		ValidateParameterFunc(schedule.validateTime).
+		ValidateParameterFunc(schedule.validateRecipients).
+		Status()
+}
+
+func (schedule StatisticsReportScheduleRepresentation) validateTime() error {
+	if *schedule.Hour < 0 || *schedule.Hour > 23 {
+		return errorhandler.CreateBadRequestError(constants.MsgErrInvalidParam + "." + constants.Hour)
+	}
+
+	var minDay, maxDay int
+	switch *schedule.Frequency {
+	case dto.ReportFrequencyWeekly:
+		minDay, maxDay = 0, 6
+	case dto.ReportFrequencyMonthly:
+		minDay, maxDay = 1, 31
+	default:
+		return nil
+	}
+	if schedule.Day == nil {
+		return errorhandler.CreateMissingParameterError(constants.Day)
+	}
+	if *schedule.Day < minDay || *schedule.Day > maxDay {
+		return errorhandler.CreateBadRequestError(constants.MsgErrInvalidParam + "." + constants.Day)
+	}
+	return nil
+}
+
+func (schedule StatisticsReportScheduleRepresentation) validateRecipients() error {
+	if len(schedule.Recipients) == 0 {
+		return errorhandler.CreateMissingParameterError(constants.Recipients)
+	}
+	var v = validation.NewParameterValidator()
+	for _, recipient := range schedule.Recipients {
+		var value = recipient
+		v = v.ValidateParameterRegExp(constants.Recipients, &value, constants.RegExpEmail, true)
+	}
+	return v.Status()
+}
+
+// Validate is a validator for ApprovalDecisionRepresentation
+func (decision ApprovalDecisionRepresentation) Validate() error {
+	return validation.NewParameterValidator().
+		ValidateParameterRegExp(constants.Comment, decision.Comment, RegExpDescription, false).
+		Status()
+}
+
+// Validate is a validator for ScheduledUserChangeRepresentation
+func (change ScheduledUserChangeRepresentation) Validate() error {
+	return validation.NewParameterValidator().
+		ValidateParameterIn(constants.Action, change.Action, allowedScheduledActions, true).
+		ValidateParameterNotNil(constants.ApplyAt, change.ApplyAt).
+		Status()
+}
+
+// Validate is a validator for ImpersonationRequestRepresentation
+func (request ImpersonationRequestRepresentation) Validate() error {
+	return validation.NewParameterValidator().
+		ValidateParameterLength(constants.Justification, request.Justification, 10, 1000, true).
+		ValidateParameterFunc(func() error {
+			if request.Duration != nil && *request.Duration <= 0 {
+				return errorhandler.CreateBadRequestError(constants.MsgErrInvalidParam + "." + constants.Duration)
+			}
+			return nil
+		}).
+		Status()
+}
+
+// Validate is a validator for RealmTemplateRepresentation
+func (template RealmTemplateRepresentation) Validate() error {
+	var v = validation.NewParameterValidator().
+		ValidateParameterRegExp(constants.Realm, template.Realm, constants.RegExpRealmName, true).
+		ValidateParameterRegExp(constants.DisplayName, template.DisplayName, constants.RegExpName, false).
+		ValidateParameterFunc(func() error {
+			if template.PasswordPolicy == nil {
+				return nil
+			}
+			return template.PasswordPolicy.Validate()
+		}).
+		ValidateParameterFunc(func() error {
+			if template.CustomConfiguration == nil {
+				return nil
+			}
+			return template.CustomConfiguration.Validate()
+		}).
+		ValidateParameterFunc(func() error {
+			if template.AdminConfiguration == nil {
+				return nil
+			}
+			return template.AdminConfiguration.Validate()
+		})
+
+	var clientIDs = make(map[string]bool)
+	for _, client := range template.Clients {
+		var c = client
+		v = v.ValidateParameterNotNil(constants.ClientID, c.ClientID).
+			ValidateParameterFunc(c.Validate).
+			ValidateParameterFunc(func() error {
+				if clientIDs[*c.ClientID] {
+					return errorhandler.CreateBadRequestError(constants.MsgErrInvalidParam + "." + constants.Clients)
+				}
+				clientIDs[*c.ClientID] = true
+				return nil
+			})
+	}
+
+	var groupNames = make(map[string]bool)
+	for _, group := range template.Groups {
+		var g = group
+		v = v.ValidateParameterRegExp(constants.GroupName, g.Name, constants.RegExpName, true).
+			ValidateParameterFunc(func() error {
+				if groupNames[*g.Name] {
+					return errorhandler.CreateBadRequestError(constants.MsgErrInvalidParam + "." + constants.Groups)
+				}
+				groupNames[*g.Name] = true
+				return nil
+			}).
+			ValidateParameterFunc(g.BackOfficeConfiguration.Validate)
+	}
+
+	return v.Status()
+}
+
+// Validate is a validator for RealmStateRepresentation
+func (state RealmStateRepresentation) Validate() error {
+	var v = validation.NewParameterValidator().
+		ValidateParameterRegExp(constants.Realm, state.Realm, constants.RegExpRealmName, true).
+		ValidateParameterFunc(func() error {
+			if state.Configuration == nil {
+				return nil
+			}
+			return state.Configuration.Validate()
+		}).
+		ValidateParameterFunc(func() error {
+			if state.AdminConfiguration == nil {
+				return nil
+			}
+			return state.AdminConfiguration.Validate()
+		})
+
+	for _, boConf := range state.BackOfficeConfiguration {
+		v = v.ValidateParameterFunc(boConf.Validate)
+	}
+
+	return v.Status()
+}
+
+// Validate is a validator for BulkUserOperationRepresentation
+func (op BulkUserOperationRepresentation) Validate() error {
+	return validation.NewParameterValidator().
+		ValidateParameterIn(constants.Operation, op.Operation, allowedBulkOperations, true).
+		ValidateParameterFunc(op.validateTargets).
+		ValidateParameterFunc(op.validateParameters).
+		ValidateParameterRegExp(constants.ClientID, op.ClientID, constants.RegExpClientID, false).
+		ValidateParameterRegExp(constants.RedirectURI, op.RedirectURI, constants.RegExpRedirectURI, false).
+		Status()
+}
+
+func (op BulkUserOperationRepresentation) validateTargets() error {
+	var hasUserIDs = op.UserIDs != nil && len(*op.UserIDs) > 0
+	if hasUserIDs == (op.Filter != nil) {
+		return errorhandler.CreateBadRequestError(constants.MsgErrInvalidParam + "." + constants.UserIDs)
+	}
+
+	var v = validation.NewParameterValidator()
+	if hasUserIDs {
+		for _, userID := range *op.UserIDs {
+			var value = userID
+			v = v.ValidateParameterRegExp(constants.UserIDs, &value, constants.RegExpID, true)
+		}
+		return v.Status()
+	}
+
+	if len(op.Filter.GroupIDs) == 0 {
+		return errorhandler.CreateMissingParameterError(constants.GroupIDs)
+	}
+	for _, groupID := range op.Filter.GroupIDs {
+		var value = groupID
+		v = v.ValidateParameterRegExp(constants.GroupIDs, &value, constants.RegExpID, true)
+	}
+	return v.ValidateParameterRegExp(constants.Filter, op.Filter.Search, constants.RegExpSearch, false).
+		ValidateParameterRegExp(constants.Email, op.Filter.Email, constants.RegExpEmail, false).
+		ValidateParameterRegExp(constants.Firstname, op.Filter.FirstName, constants.RegExpFirstName, false).
+		ValidateParameterRegExp(constants.Lastname, op.Filter.LastName, constants.RegExpLastName, false).
+		ValidateParameterRegExp(constants.Username, op.Filter.Username, constants.RegExpUsername, false).
+		Status()
+}
+
+func (op BulkUserOperationRepresentation) validateParameters() error {
+	if op.Lifespan != nil && *op.Lifespan <= 0 {
+		return errorhandler.CreateBadRequestError(constants.MsgErrInvalidParam + "." + constants.Lifespan)
+	}
+
+	if op.Operation == nil {
+		return nil
+	}
+	switch *op.Operation {
+	case BulkOperationAddGroupToUser:
+		return validation.NewParameterValidator().
+			ValidateParameterRegExp(constants.GroupID, op.GroupID, constants.RegExpID, true).
+			Status()
+	case BulkOperationExecuteActionsEmail:
+		if op.Actions == nil || len(*op.Actions) == 0 {
+			return errorhandler.CreateMissingParameterError(constants.Actions)
+		}
+		for _, action := range *op.Actions {
+			if err := action.Validate(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
 // Regular expressions for parameters validation
 const (
 	RegExpID          = constants.RegExpID
+	RegExpIDNullable  = `^([a-z0-9]{8}-[a-z0-9]{4}-[a-z0-9]{4}-[a-z0-9]{4}-[a-z0-9]{12})|(null)$`
 	RegExpName        = constants.RegExpName
 	RegExpDescription = constants.RegExpDescription
 
@@ -679,9 +2066,17 @@ const (
 	RegExpRequiredAction = constants.RegExpRequiredAction
 
 	// Others
-	RegExpRealmName = constants.RegExpRealmName
-	RegExpSearch    = constants.RegExpSearch
-	RegExpLifespan  = constants.RegExpLifespan
-	RegExpGroupIds  = constants.RegExpGroupIds
-	RegExpNumber    = constants.RegExpNumber
+	RegExpRealmName        = constants.RegExpRealmName
+	RegExpSearch           = constants.RegExpSearch
+	RegExpLifespan         = constants.RegExpLifespan
+	RegExpGroupIds         = constants.RegExpGroupIds
+	RegExpNumber           = constants.RegExpNumber
+	RegExpJobID            = `^[\w-]{1,255}$`
+	RegExpBoolean          = `^(true|false)$`
+	RegExpFormat           = `^(csv|json|ndjson)$`
+	RegExpColumns          = `^[a-zA-Z,]{1,1024}$`
+	RegExpDate             = `^(\d{2}\.\d{2}\.\d{4}|\d{4}-\d{2}-\d{2})$`
+	RegExpIDDocumentNumber = constants.RegExpIDDocumentNumber
+	RegExpApprovalStatus   = `^(PENDING|APPROVED|REJECTED|FAILED)$`
+	RegExpConfigType       = `^(configuration|admin_configuration)$`
 )
@@ -5,10 +5,13 @@ import (
 	"encoding/json"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/cloudtrust/common-service/configuration"
 	"github.com/cloudtrust/common-service/log"
 	"github.com/cloudtrust/keycloak-bridge/internal/constants"
+	"github.com/cloudtrust/keycloak-bridge/internal/dto"
+	"github.com/cloudtrust/keycloak-bridge/internal/keycloakb"
 	kc "github.com/cloudtrust/keycloak-client"
 	"github.com/stretchr/testify/assert"
 )
@@ -32,6 +35,22 @@ func TestConvertCredential(t *testing.T) {
 	assert.Equal(t, "{}", *ConvertCredential(&credKc).CredentialData)
 }
 
+func TestConvertToAPIUserSession(t *testing.T) {
+	var sessionID = "session-id"
+	var start = int64(1591000000000)
+	var clients = map[string]string{"uuid-2": "portal", "uuid-1": "admin-console"}
+
+	var session = ConvertToAPIUserSession(kc.UserSessionRepresentation{ID: &sessionID, Start: &start, Clients: &clients}, true)
+	assert.Equal(t, sessionID, *session.ID)
+	assert.Equal(t, start, *session.Start)
+	assert.Equal(t, []string{"admin-console", "portal"}, *session.Clients)
+	assert.True(t, *session.Offline)
+
+	session = ConvertToAPIUserSession(kc.UserSessionRepresentation{ID: &sessionID}, false)
+	assert.Len(t, *session.Clients, 0)
+	assert.False(t, *session.Offline)
+}
+
 func TestConvertAttackDetectionStatus(t *testing.T) {
 	t.Run("missing keys", func(t *testing.T) {
 		var status = map[string]interface{}{}
@@ -195,6 +214,28 @@ func TestConvertToKCGroup(t *testing.T) {
 	assert.Equal(t, name, *ConvertToKCGroup(group).Name)
 }
 
+func TestConvertToAPIGroup(t *testing.T) {
+	var parentID, parentName, parentPath = "parent-id", "parent", "/parent"
+	var childID, childName, childPath = "child-id", "child", "/parent/child"
+	var group = kc.GroupRepresentation{ID: &parentID, Name: &parentName, Path: &parentPath, SubGroups: &[]kc.GroupRepresentation{
+		{ID: &childID, Name: &childName, Path: &childPath, SubGroups: &[]kc.GroupRepresentation{}},
+	}}
+
+	var res = ConvertToAPIGroup(group)
+	assert.Equal(t, parentPath, *res.Path)
+	assert.Len(t, *res.SubGroups, 1)
+	assert.Equal(t, childPath, *(*res.SubGroups)[0].Path)
+	assert.Nil(t, (*res.SubGroups)[0].SubGroups)
+}
+
+func TestValidateGroupParentRepresentation(t *testing.T) {
+	var parentID = "f467ed7c-0a1d-4eee-9bb8-669c6f89c0ee"
+	var invalidID = "not an id"
+	assert.Nil(t, GroupParentRepresentation{}.Validate())
+	assert.Nil(t, GroupParentRepresentation{ParentID: &parentID}.Validate())
+	assert.NotNil(t, GroupParentRepresentation{ParentID: &invalidID}.Validate())
+}
+
 func TestConvertToDBAuthorizations(t *testing.T) {
 	// Nil matrix authorizations
 	{
@@ -547,6 +588,11 @@ func TestValidateRealmAdminConfiguration(t *testing.T) {
 		var realmAdminConf = createValidRealmAdminConfiguration()
 		assert.Nil(t, realmAdminConf.Validate())
 	})
+	t.Run("Invalid client policy", func(t *testing.T) {
+		var realmAdminConf = createValidRealmAdminConfiguration()
+		realmAdminConf.ClientPolicy = &ClientPolicyRepresentation{AllowedRedirectURIPrefixes: []string{"not a URI"}}
+		assert.NotNil(t, realmAdminConf.Validate())
+	})
 	t.Run("Missing mode", func(t *testing.T) {
 		var realmAdminConf = createValidRealmAdminConfiguration()
 		realmAdminConf.Mode = nil
@@ -616,6 +662,424 @@ func TestValidateFederatedIdentityRepresentation(t *testing.T) {
 	assert.NotNil(t, fi.Validate())
 }
 
+func TestValidateStatisticsReportScheduleRepresentation(t *testing.T) {
+	var createSchedule = func(frequency string, day *int, hour int, recipients ...string) StatisticsReportScheduleRepresentation {
+		return StatisticsReportScheduleRepresentation{Frequency: &frequency, Day: day, Hour: &hour, Recipients: recipients}
+	}
+	var monday = 1
+	var thirtyFirst = 31
+	var recipient = "admin@example.com"
+
+	assert.Nil(t, createSchedule(dto.ReportFrequencyDaily, nil, 8, recipient).Validate())
+	assert.Nil(t, createSchedule(dto.ReportFrequencyWeekly, &monday, 0, recipient).Validate())
+	assert.Nil(t, createSchedule(dto.ReportFrequencyMonthly, &thirtyFirst, 23, recipient).Validate())
+
+	assert.NotNil(t, createSchedule("yearly", nil, 8, recipient).Validate())
+	assert.NotNil(t, createSchedule(dto.ReportFrequencyDaily, nil, 24, recipient).Validate())
+	assert.NotNil(t, createSchedule(dto.ReportFrequencyWeekly, nil, 8, recipient).Validate())
+	assert.NotNil(t, createSchedule(dto.ReportFrequencyWeekly, &thirtyFirst, 8, recipient).Validate())
+	assert.NotNil(t, createSchedule(dto.ReportFrequencyDaily, nil, 8).Validate())
+	assert.NotNil(t, createSchedule(dto.ReportFrequencyDaily, nil, 8, recipient, "not-an-email").Validate())
+	assert.NotNil(t, StatisticsReportScheduleRepresentation{Recipients: []string{recipient}}.Validate())
+}
+
+func TestValidateBulkUserOperationRepresentation(t *testing.T) {
+	var userIDs = []string{"f467ed7c-0a1d-4eee-9bb8-669c6f89c0ee"}
+	var invalidUserIDs = []string{"not an id"}
+	var groupID = "7767ed7c-0a1d-4eee-9bb8-669c6f89c007"
+	var actions = []RequiredAction{"verify-email"}
+	var lifespan = 0
+	var createOperation = func(operation string) BulkUserOperationRepresentation {
+		return BulkUserOperationRepresentation{Operation: &operation, UserIDs: &userIDs}
+	}
+
+	var op = createOperation(BulkOperationLockUser)
+	assert.Nil(t, op.Validate())
+
+	op.Filter = &BulkUserFilterRepresentation{GroupIDs: []string{groupID}}
+	assert.NotNil(t, op.Validate())
+
+	op.UserIDs = nil
+	assert.Nil(t, op.Validate())
+
+	op.Filter.GroupIDs = nil
+	assert.NotNil(t, op.Validate())
+
+	op.Filter = nil
+	assert.NotNil(t, op.Validate())
+
+	op.UserIDs = &invalidUserIDs
+	assert.NotNil(t, op.Validate())
+
+	op = createOperation("ResetPassword")
+	assert.NotNil(t, op.Validate())
+
+	op = createOperation(BulkOperationAddGroupToUser)
+	assert.NotNil(t, op.Validate())
+	op.GroupID = &groupID
+	assert.Nil(t, op.Validate())
+
+	op = createOperation(BulkOperationExecuteActionsEmail)
+	assert.NotNil(t, op.Validate())
+	op.Actions = &actions
+	assert.Nil(t, op.Validate())
+	op.Lifespan = &lifespan
+	assert.NotNil(t, op.Validate())
+}
+
+func TestValidateScheduledUserChangeRepresentation(t *testing.T) {
+	var applyAt = time.Now().Add(time.Hour).Unix()
+	var createChange = func(action string) ScheduledUserChangeRepresentation {
+		return ScheduledUserChangeRepresentation{Action: &action, ApplyAt: &applyAt}
+	}
+
+	assert.Nil(t, createChange(dto.ScheduledActionLock).Validate())
+	assert.Nil(t, createChange(dto.ScheduledActionUnlock).Validate())
+	assert.Nil(t, createChange(dto.ScheduledActionExpire).Validate())
+
+	assert.NotNil(t, createChange("DELETE").Validate())
+	var change = createChange(dto.ScheduledActionLock)
+	change.ApplyAt = nil
+	assert.NotNil(t, change.Validate())
+}
+
+func TestValidateImpersonationRequestRepresentation(t *testing.T) {
+	var justification = "Ticket #1234: user can't see their documents"
+	var duration = int64(600)
+	var request = ImpersonationRequestRepresentation{Justification: &justification}
+	assert.Nil(t, request.Validate())
+
+	request.Duration = &duration
+	assert.Nil(t, request.Validate())
+
+	duration = 0
+	assert.NotNil(t, request.Validate())
+
+	var tooShort = "support"
+	assert.NotNil(t, ImpersonationRequestRepresentation{Justification: &tooShort}.Validate())
+	assert.NotNil(t, ImpersonationRequestRepresentation{}.Validate())
+}
+
+func TestValidateClientRepresentation(t *testing.T) {
+	var clientID = "my-app"
+	var protocol = "openid-connect"
+	var redirectURIs = []string{"https://app.example.com/*"}
+	var client = ClientRepresentation{ClientID: &clientID, Protocol: &protocol, RedirectURIs: &redirectURIs}
+	assert.Nil(t, client.Validate())
+
+	var invalidProtocol = "cas"
+	var invalidClientID = "my app"
+	var invalidURIs = []string{"https://app.example.com/", "not a URI"}
+	assert.NotNil(t, ClientRepresentation{Protocol: &invalidProtocol}.Validate())
+	assert.NotNil(t, ClientRepresentation{ClientID: &invalidClientID}.Validate())
+	assert.NotNil(t, ClientRepresentation{RedirectURIs: &invalidURIs}.Validate())
+	assert.NotNil(t, ClientRepresentation{WebOrigins: &invalidURIs}.Validate())
+	assert.NotNil(t, ClientPolicyRepresentation{AllowedRedirectURIPrefixes: invalidURIs}.Validate())
+}
+
+func TestMergeIntoKCClient(t *testing.T) {
+	var name = "My application"
+	var enabled = false
+	var oldName = "old name"
+	var clientID = "my-app"
+	var client = kc.ClientRepresentation{ClientID: &clientID, Name: &oldName}
+
+	MergeIntoKCClient(ClientRepresentation{Name: &name, Enabled: &enabled}, &client)
+	assert.Equal(t, clientID, *client.ClientID)
+	assert.Equal(t, name, *client.Name)
+	assert.False(t, *client.Enabled)
+	assert.Nil(t, client.PublicClient)
+}
+
+func TestValidateIdentityProviderRepresentation(t *testing.T) {
+	var alias = "partner-idp"
+	var providerID = "oidc"
+	assert.Nil(t, IdentityProviderRepresentation{Alias: &alias, ProviderID: &providerID}.Validate())
+
+	var invalidAlias = "partner idp"
+	assert.NotNil(t, IdentityProviderRepresentation{Alias: &invalidAlias}.Validate())
+	assert.NotNil(t, IdentityProviderRepresentation{ProviderID: &invalidAlias}.Validate())
+}
+
+func TestMergeIntoKCIdentityProvider(t *testing.T) {
+	var alias = "partner-idp"
+	var newAlias = "other-idp"
+	var displayName = "Partner"
+	var config = map[string]string{"clientId": "bridge", "syncMode": "IMPORT"}
+	var idp = kc.IdentityProviderRepresentation{Alias: &alias, Config: &config}
+
+	MergeIntoKCIdentityProvider(IdentityProviderRepresentation{Alias: &newAlias, DisplayName: &displayName, Config: &map[string]string{"syncMode": "FORCE"}}, &idp)
+	assert.Equal(t, alias, *idp.Alias)
+	assert.Equal(t, displayName, *idp.DisplayName)
+	assert.Equal(t, map[string]string{"clientId": "bridge", "syncMode": "FORCE"}, *idp.Config)
+	assert.Equal(t, "IMPORT", config["syncMode"])
+}
+
+func TestConvertToAPIConfigurationRevision(t *testing.T) {
+	var rollbackOf = int64(3)
+	var revision = dto.DBConfigurationRevision{
+		ID:             7,
+		RealmID:        "realm-id",
+		ConfigType:     dto.ConfigurationTypeAdmin,
+		Content:        `{"mode":"trustID"}`,
+		AuthorID:       "author-id",
+		AuthorUsername: "author",
+		CreatedAt:      time.Unix(1600000000, 0),
+		RollbackOf:     &rollbackOf,
+	}
+
+	var res = ConvertToAPIConfigurationRevision(revision)
+	assert.Equal(t, int64(7), *res.ID)
+	assert.Equal(t, dto.ConfigurationTypeAdmin, *res.Type)
+	assert.Equal(t, "author", *res.AuthorUsername)
+	assert.Equal(t, int64(1600000000), *res.CreatedAt)
+	assert.Equal(t, rollbackOf, *res.RollbackOf)
+}
+
+func TestValidateCredentialRepresentation(t *testing.T) {
+	var label = "My phone"
+	var invalidID = "not-an-id"
+	assert.Nil(t, CredentialRepresentation{UserLabel: &label}.Validate())
+	assert.NotNil(t, CredentialRepresentation{ID: &invalidID, UserLabel: &label}.Validate())
+}
+
+func TestValidateReEnrollmentRequestRepresentation(t *testing.T) {
+	var otp = "otp"
+	var unknown = "ctpapercard"
+	assert.Nil(t, ReEnrollmentRequestRepresentation{Type: &otp}.Validate())
+	assert.NotNil(t, ReEnrollmentRequestRepresentation{}.Validate())
+	assert.NotNil(t, ReEnrollmentRequestRepresentation{Type: &unknown}.Validate())
+}
+
+func TestValidatePasswordPolicyRepresentation(t *testing.T) {
+	var length = 12
+	var maxLength = 64
+	var negative = -1
+	var pattern = "[a-z]+"
+	var invalidPattern = "[a-z"
+	assert.Nil(t, PasswordPolicyRepresentation{Length: &length, MaxLength: &maxLength, RegexPattern: &pattern}.Validate())
+
+	assert.NotNil(t, PasswordPolicyRepresentation{Digits: &negative}.Validate())
+	assert.NotNil(t, PasswordPolicyRepresentation{Length: &maxLength, MaxLength: &length}.Validate())
+	assert.NotNil(t, PasswordPolicyRepresentation{RegexPattern: &invalidPattern}.Validate())
+}
+
+func TestValidateRealmTemplateRepresentation(t *testing.T) {
+	var realm = "customer"
+	var invalidRealm = "customer realm"
+	var clientID = "portal"
+	var groupName = "operators"
+	var negative = -1
+	var createTemplate = func() RealmTemplateRepresentation {
+		return RealmTemplateRepresentation{
+			Realm:   &realm,
+			Clients: []ClientRepresentation{{ClientID: &clientID}},
+			Groups: []GroupTemplateRepresentation{{Name: &groupName, BackOfficeConfiguration: BackOfficeConfiguration{
+				realm: {BOConfKeyCustomers: []string{groupName}},
+			}}},
+		}
+	}
+
+	var template = createTemplate()
+	assert.Nil(t, template.Validate())
+
+	template.Realm = nil
+	assert.NotNil(t, template.Validate())
+
+	template.Realm = &invalidRealm
+	assert.NotNil(t, template.Validate())
+
+	template = createTemplate()
+	template.Clients = append(template.Clients, ClientRepresentation{})
+	assert.NotNil(t, template.Validate())
+
+	template = createTemplate()
+	template.Clients = append(template.Clients, template.Clients[0])
+	assert.NotNil(t, template.Validate())
+
+	template = createTemplate()
+	template.Groups = append(template.Groups, GroupTemplateRepresentation{Name: &groupName})
+	assert.NotNil(t, template.Validate())
+
+	template = createTemplate()
+	template.Groups[0].BackOfficeConfiguration[realm]["unknown"] = []string{}
+	assert.NotNil(t, template.Validate())
+
+	template = createTemplate()
+	template.PasswordPolicy = &PasswordPolicyRepresentation{Digits: &negative}
+	assert.NotNil(t, template.Validate())
+}
+
+func TestRealmStateYAML(t *testing.T) {
+	var realm = "customer"
+	var showPasswordTab = true
+	var adminConf = CreateDefaultRealmAdminConfiguration()
+	var matrix = map[string]map[string]map[string]struct{}{"MGMT_GetUsers": {realm: {"*": {}}}}
+	var state = RealmStateRepresentation{
+		Realm:                   &realm,
+		Configuration:           &RealmCustomConfiguration{ShowPasswordTab: &showPasswordTab},
+		AdminConfiguration:      &adminConf,
+		Authorizations:          map[string]AuthorizationsRepresentation{"operators": {Matrix: &matrix}},
+		BackOfficeConfiguration: map[string]BackOfficeConfiguration{"operators": {realm: {BOConfKeyCustomers: []string{"operators"}}}},
+	}
+
+	t.Run("Round trip", func(t *testing.T) {
+		var content, err = state.ToYAML()
+		assert.Nil(t, err)
+		assert.Contains(t, string(content), "show_password_tab: true")
+
+		parsed, err := ParseRealmStateYAML(content)
+		assert.Nil(t, err)
+		assert.Equal(t, state, parsed)
+	})
+
+	t.Run("Missing parts are not managed", func(t *testing.T) {
+		var parsed, err = ParseRealmStateYAML([]byte("realm: customer\n"))
+		assert.Nil(t, err)
+		assert.Nil(t, parsed.Configuration)
+		assert.Nil(t, parsed.Authorizations)
+	})
+
+	t.Run("Invalid YAML", func(t *testing.T) {
+		var _, err = ParseRealmStateYAML([]byte("realm: [customer"))
+		assert.NotNil(t, err)
+	})
+
+	t.Run("Invalid state", func(t *testing.T) {
+		var _, err = ParseRealmStateYAML([]byte("configuration:\n  show_password_tab: true\n"))
+		assert.NotNil(t, err)
+
+		_, err = ParseRealmStateYAML([]byte("realm: customer\nbackoffice_configuration:\n  operators:\n    customer:\n      unknown: []\n"))
+		assert.NotNil(t, err)
+	})
+}
+
+func TestConvertPasswordPolicy(t *testing.T) {
+	var policy, _ = keycloakb.ParsePasswordPolicy("length(10) and notUsername and hashAlgorithm(pbkdf2-sha256) and customPolicy(42)")
+	var apiPolicy = ConvertToAPIPasswordPolicy(policy)
+	assert.Equal(t, 10, *apiPolicy.Length)
+	assert.True(t, *apiPolicy.NotUsername)
+	assert.Nil(t, apiPolicy.NotEmail)
+	assert.Equal(t, "pbkdf2-sha256", *apiPolicy.HashAlgorithm)
+	assert.Equal(t, map[string]string{"customPolicy": "42"}, apiPolicy.Others)
+
+	assert.Equal(t, policy.String(), apiPolicy.ConvertToKeycloakbStruct().String())
+}
+
+func TestConvertScheduledUserChange(t *testing.T) {
+	var applyAt = time.Unix(1600000000, 0)
+	var change = ConvertToAPIScheduledUserChange(dto.DBScheduledUserChange{ID: 3, Action: dto.ScheduledActionExpire, ApplyAt: applyAt,
+		CreatedAt: applyAt.Add(-time.Hour), CreatorUsername: "operator"})
+	assert.Equal(t, int64(3), *change.ID)
+	assert.Equal(t, applyAt.Unix(), *change.ApplyAt)
+	assert.Equal(t, applyAt.Unix()-3600, *change.CreatedAt)
+	assert.Equal(t, "operator", *change.CreatedBy)
+}
+
+func TestConvertReportSchedule(t *testing.T) {
+	var lastSent = time.Unix(1600000000, 0)
+	var dbSchedule = dto.DBReportSchedule{
+		ID:         5,
+		RealmName:  "my-realm",
+		Frequency:  dto.ReportFrequencyWeekly,
+		Day:        2,
+		Hour:       7,
+		Recipients: []string{"admin@example.com"},
+		LastSent:   &lastSent,
+	}
+
+	var schedule = ConvertToAPIReportSchedule(dbSchedule)
+	assert.Equal(t, int64(5), *schedule.ID)
+	assert.Equal(t, 2, *schedule.Day)
+	assert.Equal(t, int64(1600000000), *schedule.LastSent)
+
+	dbSchedule.LastSent = nil
+	assert.Equal(t, dbSchedule, schedule.ConvertToDBStruct("my-realm"))
+
+	dbSchedule.Frequency = dto.ReportFrequencyDaily
+	assert.Nil(t, ConvertToAPIReportSchedule(dbSchedule).Day)
+}
+
+func TestConvertApprovalRequest(t *testing.T) {
+	var targetID = "b5c7a3f2-0a1d-4eee-9bb8-669c6f89c0ee"
+	var decidedAt = time.Unix(1600000100, 0)
+	var dbRequest = dto.DBApprovalRequest{
+		ID:                3,
+		RealmName:         "my-realm",
+		Action:            "MGMT_UpdateAuthorizations",
+		TargetID:          &targetID,
+		Payload:           []byte(`{"matrix":{}}`),
+		RequesterID:       "requester-id",
+		RequesterUsername: "requester",
+		Status:            "APPROVED",
+		CreatedAt:         time.Unix(1600000000, 0),
+		DecidedAt:         &decidedAt,
+	}
+
+	t.Run("Parameters are returned", func(t *testing.T) {
+		var request = ConvertToAPIApprovalRequest(dbRequest)
+		assert.Equal(t, int64(3), *request.ID)
+		assert.Equal(t, int64(1600000000), *request.CreatedAt)
+		assert.Equal(t, int64(1600000100), *request.DecidedAt)
+		assert.Equal(t, `{"matrix":{}}`, string(*request.Parameters))
+	})
+
+	t.Run("Password is not returned", func(t *testing.T) {
+		var resetPassword = dbRequest
+		resetPassword.Action = "MGMT_ResetPassword"
+		resetPassword.Payload = []byte(`{"value":"P@ssw0rd"}`)
+		assert.Nil(t, ConvertToAPIApprovalRequest(resetPassword).Parameters)
+	})
+
+	t.Run("No parameters", func(t *testing.T) {
+		var deleteUser = dbRequest
+		deleteUser.Action = "MGMT_DeleteUser"
+		deleteUser.Payload = nil
+		assert.Nil(t, ConvertToAPIApprovalRequest(deleteUser).Parameters)
+	})
+}
+
+func TestParseUsersImport(t *testing.T) {
+	t.Run("JSON lines", func(t *testing.T) {
+		var users, err = ParseUsersImport(ImportFormatJSON, `{"username":"jdoe","groups":["grp1"]}`+"\n\n"+`{"username":"asmith","enabled":true}`)
+		assert.Nil(t, err)
+		assert.Len(t, users, 2)
+		assert.Equal(t, "jdoe", *users[0].Username)
+		assert.Equal(t, []string{"grp1"}, *users[0].Groups)
+		assert.True(t, *users[1].Enabled)
+
+		_, err = ParseUsersImport(ImportFormatJSON, `{"username":`)
+		assert.NotNil(t, err)
+	})
+
+	t.Run("CSV", func(t *testing.T) {
+		var content = "username,email,enabled,groups,trustIdGroups\n" +
+			"jdoe,jdoe@example.com,true,\"grp1, grp2\",\n" +
+			"asmith,,false,grp1,l1_support_agent\n"
+		var users, err = ParseUsersImport(ImportFormatCSV, content)
+		assert.Nil(t, err)
+		assert.Len(t, users, 2)
+		assert.Equal(t, "jdoe@example.com", *users[0].Email)
+		assert.True(t, *users[0].Enabled)
+		assert.Equal(t, []string{"grp1", "grp2"}, *users[0].Groups)
+		assert.Nil(t, users[0].TrustIDGroups)
+		assert.Nil(t, users[1].Email)
+		assert.False(t, *users[1].Enabled)
+		assert.Equal(t, []string{"l1_support_agent"}, *users[1].TrustIDGroups)
+	})
+
+	t.Run("Invalid CSV", func(t *testing.T) {
+		var _, err = ParseUsersImport(ImportFormatCSV, "")
+		assert.NotNil(t, err)
+
+		_, err = ParseUsersImport(ImportFormatCSV, "username,password\njdoe,secret\n")
+		assert.NotNil(t, err)
+
+		_, err = ParseUsersImport(ImportFormatCSV, "username,email\njdoe\n")
+		assert.NotNil(t, err)
+	})
+}
+
 func createValidUserRepresentation() UserRepresentation {
 	var groups = []string{"f467ed7c-0a1d-4eee-9bb8-669c6f89c0ee", "7767ed7c-0a1d-4eee-9bb8-669c6f89c007"}
 	var roles = []string{"abcded7c-0a1d-4eee-9bb8-669c6f89c0ee", "7767ed7c-0a1d-4eee-9bb8-669c6f898888"}
@@ -701,3 +1165,93 @@ func createValidRealmCustomConfiguration() RealmCustomConfiguration {
 func createValidRequiredAction() RequiredAction {
 	return RequiredAction("verify-email")
 }
+
+func TestUserSearchCriteriaMatch(t *testing.T) {
+	var enabled, disabled = true, false
+	var created = time.Date(2020, 6, 15, 10, 0, 0, 0, time.UTC)
+	var createdTimestamp = created.UnixNano() / int64(time.Millisecond)
+	var shadow, expiry = "SHADOW", "31.12.2030"
+	var user = UserRepresentation{
+		Enabled:          &enabled,
+		CreatedTimestamp: &createdTimestamp,
+		Accreditations:   &[]AccreditationRepresentation{{Type: &shadow, ExpiryDate: &expiry}},
+	}
+	var date = func(year int, month time.Month, day int) *time.Time {
+		var res = time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+		return &res
+	}
+
+	t.Run("Criteria kinds", func(t *testing.T) {
+		var criteria = UserSearchCriteria{}
+		assert.False(t, criteria.HasKeycloakCriteria() || criteria.HasIndexedCriteria() || criteria.HasUserCriteria())
+		criteria = UserSearchCriteria{GroupIDs: []string{"group"}, PhoneNumber: &shadow, Enabled: &enabled}
+		assert.True(t, criteria.HasKeycloakCriteria() && criteria.HasIndexedCriteria() && criteria.HasUserCriteria())
+	})
+	t.Run("Enabled", func(t *testing.T) {
+		assert.True(t, UserSearchCriteria{Enabled: &enabled}.Match(user))
+		assert.False(t, UserSearchCriteria{Enabled: &disabled}.Match(user))
+	})
+	t.Run("Creation date", func(t *testing.T) {
+		assert.True(t, UserSearchCriteria{CreatedAfter: date(2020, 6, 15), CreatedBefore: date(2020, 6, 16)}.Match(user))
+		assert.False(t, UserSearchCriteria{CreatedAfter: date(2020, 6, 16)}.Match(user))
+		assert.False(t, UserSearchCriteria{CreatedBefore: date(2020, 6, 15)}.Match(user))
+		assert.False(t, UserSearchCriteria{CreatedBefore: date(2020, 6, 15)}.Match(UserRepresentation{}))
+	})
+	t.Run("Accreditations", func(t *testing.T) {
+		var other = "OTHER"
+		assert.True(t, UserSearchCriteria{AccreditationType: &shadow}.Match(user))
+		assert.False(t, UserSearchCriteria{AccreditationType: &other}.Match(user))
+		assert.True(t, UserSearchCriteria{AccreditationType: &shadow, AccreditationExpiresBefore: date(2031, 1, 1)}.Match(user))
+		assert.False(t, UserSearchCriteria{AccreditationExpiresAfter: date(2031, 1, 1)}.Match(user))
+		assert.False(t, UserSearchCriteria{AccreditationType: &shadow}.Match(UserRepresentation{}))
+	})
+	t.Run("Soft deleted user", func(t *testing.T) {
+		var deleted = user
+		deleted.DeletedTimestamp = &createdTimestamp
+		assert.False(t, UserSearchCriteria{}.Match(deleted))
+		assert.True(t, UserSearchCriteria{IncludeDeleted: true}.Match(deleted))
+	})
+}
+
+func TestWithoutDeletedUsers(t *testing.T) {
+	var deletedTimestamp int64 = 1591000000000
+	var count = 12
+	var page = UsersPageRepresentation{
+		Users: []UserRepresentation{{}, {DeletedTimestamp: &deletedTimestamp}, {}},
+		Count: &count,
+	}
+
+	var res = page.WithoutDeletedUsers()
+	assert.Len(t, res.Users, 2)
+	// The count still bounds the pagination of the whole list
+	assert.Equal(t, 12, *res.Count)
+	assert.Len(t, page.Users, 3)
+}
+
+func TestTargetAuthorizationsReportCSVRecords(t *testing.T) {
+	var targetRealm = "customer"
+	var anyGroup = "*"
+	var username = "jdoe"
+	var report = TargetAuthorizationsReportRepresentation{
+		TargetRealm: targetRealm,
+		Groups: []AuthorizedGroupRepresentation{
+			{
+				Realm:          targetRealm,
+				Group:          "operators",
+				Authorizations: []EffectiveAuthorizationRepresentation{{Action: "MGMT_GetUsers", TargetRealm: &targetRealm, TargetGroup: &anyGroup}},
+			},
+			{
+				Realm:          "master",
+				Group:          "admins",
+				Authorizations: []EffectiveAuthorizationRepresentation{{Action: "MGMT_GetUsers", TargetRealm: &anyGroup, TargetGroup: &anyGroup}, {Action: "MGMT_GetRealm", TargetRealm: &anyGroup}},
+				Members:        []AuthorizedUserRepresentation{{ID: "user-1", Username: &username}, {ID: "user-2"}},
+			},
+		},
+	}
+
+	var records = report.CSVRecords()
+	assert.Len(t, records, 6)
+	assert.Equal(t, []string{"customer", "", "customer", "operators", "MGMT_GetUsers", "customer", "*", "", ""}, records[1])
+	assert.Equal(t, []string{"customer", "", "master", "admins", "MGMT_GetUsers", "*", "*", "user-1", "jdoe"}, records[2])
+	assert.Equal(t, []string{"customer", "", "master", "admins", "MGMT_GetRealm", "*", "", "user-2", ""}, records[5])
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/cloudtrust/common-service/validation"
 	"github.com/cloudtrust/keycloak-bridge/internal/constants"
+	"github.com/cloudtrust/keycloak-bridge/internal/keycloakb"
 	kc "github.com/cloudtrust/keycloak-client"
 )
 
@@ -36,6 +37,26 @@ type ConfigurationRepresentation struct {
 	RedirectCancelledRegistrationURL *string `json:"redirect_cancelled_registration_url,omitempty"`
 }
 
+// PasswordValidationRequest struct. Username and email are optional: the rules of the password policy which depend on them
+// are only checked when they are given.
+type PasswordValidationRequest struct {
+	Password *string `json:"password"`
+	Username *string `json:"username,omitempty"`
+	Email    *string `json:"email,omitempty"`
+}
+
+// PasswordValidationRepresentation struct. Failures lists the rules of the password policy which are not respected.
+type PasswordValidationRepresentation struct {
+	Valid    bool                                  `json:"valid"`
+	Failures []PasswordPolicyFailureRepresentation `json:"failures"`
+}
+
+// PasswordPolicyFailureRepresentation struct
+type PasswordPolicyFailureRepresentation struct {
+	Rule  string  `json:"rule"`
+	Value *string `json:"value,omitempty"`
+}
+
 // Parameter references
 const (
 	prmUserGender               = "user_gender"
@@ -49,6 +70,9 @@ const (
 	prmUserIDDocumentNumber     = "user_idDocNumber"
 	prmUserIDDocumentExpiration = "user_idDocExpiration"
 	prmUserLocale               = "user_locale"
+	prmPassword                 = "password"
+	prmUsername                 = "username"
+	prmEmail                    = "email"
 
 	regExpGender           = constants.RegExpGender
 	regExpFirstName        = constants.RegExpNameSpecialChars
@@ -57,6 +81,8 @@ const (
 	regExpBirthLocation    = constants.RegExpNameSpecialChars
 	regExpIDDocumentNumber = constants.RegExpIDDocumentNumber
 	regExpLocale           = `^\w{2}(-\w{2})?$`
+	regExpPassword         = constants.RegExpPassword
+	regExpUsername         = constants.RegExpUsername
 )
 
 var (
@@ -122,3 +148,29 @@ func (u *UserRepresentation) Validate() error {
 		ValidateParameterRegExp(prmUserLocale, u.Locale, regExpLocale, true).
 		Status()
 }
+
+// ConvertToAPIPasswordValidation creates an API password validation result from the failures of the password policy
+func ConvertToAPIPasswordValidation(failures []keycloakb.PasswordPolicyFailure) PasswordValidationRepresentation {
+	var res = PasswordValidationRepresentation{
+		Valid:    len(failures) == 0,
+		Failures: []PasswordPolicyFailureRepresentation{},
+	}
+	for _, failure := range failures {
+		var apiFailure = PasswordPolicyFailureRepresentation{Rule: failure.Rule}
+		if failure.Value != "" {
+			var value = failure.Value
+			apiFailure.Value = &value
+		}
+		res.Failures = append(res.Failures, apiFailure)
+	}
+	return res
+}
+
+// Validate checks the validity of the given password validation request
+func (r PasswordValidationRequest) Validate() error {
+	return validation.NewParameterValidator().
+		ValidateParameterRegExp(prmPassword, r.Password, regExpPassword, true).
+		ValidateParameterRegExp(prmUsername, r.Username, regExpUsername, false).
+		ValidateParameterRegExp(prmEmail, r.Email, regExpEmail, false).
+		Status()
+}
@@ -3,6 +3,7 @@ package apiregister
 import (
 	"testing"
 
+	"github.com/cloudtrust/keycloak-bridge/internal/keycloakb"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -108,3 +109,28 @@ func TestValidateUserRepresentation(t *testing.T) {
 		}
 	})
 }
+
+func TestValidatePasswordValidationRequest(t *testing.T) {
+	var password = "P@ssw0rd"
+	var username = "jdoe"
+	var email = "john.doe@example.com"
+	var empty = ""
+
+	assert.Nil(t, PasswordValidationRequest{Password: &password}.Validate())
+	assert.Nil(t, PasswordValidationRequest{Password: &password, Username: &username, Email: &email}.Validate())
+
+	assert.NotNil(t, PasswordValidationRequest{}.Validate())
+	assert.NotNil(t, PasswordValidationRequest{Password: &empty}.Validate())
+	assert.NotNil(t, PasswordValidationRequest{Password: &password, Email: &username}.Validate())
+}
+
+func TestConvertToAPIPasswordValidation(t *testing.T) {
+	var res = ConvertToAPIPasswordValidation(nil)
+	assert.True(t, res.Valid)
+	assert.NotNil(t, res.Failures)
+
+	res = ConvertToAPIPasswordValidation([]keycloakb.PasswordPolicyFailure{{Rule: "digits", Value: "2"}})
+	assert.False(t, res.Valid)
+	assert.Equal(t, "digits", res.Failures[0].Rule)
+	assert.Equal(t, "2", *res.Failures[0].Value)
+}
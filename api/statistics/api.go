@@ -2,8 +2,10 @@ package apistatistics
 
 import (
 	"database/sql"
+	"time"
 
 	api_events "github.com/cloudtrust/keycloak-bridge/api/events"
+	"github.com/cloudtrust/keycloak-bridge/internal/constants"
 	kc "github.com/cloudtrust/keycloak-client"
 )
 
@@ -13,6 +15,12 @@ const (
 	RegExpNumber          = `^\d+$`
 	RegExpTimeshift       = `^[+-]\d{1,4}$`
 	RegExpTwoDigitsNumber = `^\d{1,2}$`
+	RegExpBoolean         = `^(true|false)$`
+	RegExpDate            = `^(\d{2}\.\d{2}\.\d{4}|\d{4}-\d{2}-\d{2})$`
+	RegExpJobID           = `^[\w-]{1,255}$`
+	RegExpID              = constants.RegExpID
+	RegExpGroupIds        = constants.RegExpGroupIds
+	RegExpExportFormat    = `^(csv|xlsx)$`
 )
 
 // ActionRepresentation struct
@@ -51,6 +59,72 @@ type StatisticsConnectionRepresentation struct {
 	IP     string `json:"IP"`
 }
 
+// StatisticsDeviceRepresentation is the number of successful authentications made with a kind of device
+type StatisticsDeviceRepresentation struct {
+	DeviceClass string `json:"deviceClass"`
+	OS          string `json:"os"`
+	Browser     string `json:"browser"`
+	Count       int64  `json:"count"`
+}
+
+// StatisticsUserConnectionRepresentation is a successful authentication of a user. NewDevice and NewCountry are set when
+// the user never logged in before with the same device or from the same country.
+type StatisticsUserConnectionRepresentation struct {
+	Date        int64  `json:"date"`
+	IP          string `json:"IP"`
+	Country     string `json:"country,omitempty"`
+	DeviceClass string `json:"deviceClass"`
+	OS          string `json:"os"`
+	Browser     string `json:"browser"`
+	NewDevice   bool   `json:"newDevice"`
+	NewCountry  bool   `json:"newCountry"`
+}
+
+// MigrationReportFilter describes which users are included in a migration report and which page of them is returned
+type MigrationReportFilter struct {
+	GroupIDs      []string
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+	Migrated      *bool
+	First         int
+	Max           int
+}
+
+// MigrationReportRepresentation elements returned by GetMigrationReport and GetMigrationReportJob. Total, Migrated and NotMigrated are computed on the users matching
+// the group and creation date filters, Count is the number of those users also matching the migration status filter
+type MigrationReportRepresentation struct {
+	Total       int                                 `json:"total"`
+	Migrated    int                                 `json:"migrated"`
+	NotMigrated int                                 `json:"notMigrated"`
+	Count       int                                 `json:"count"`
+	Users       []MigrationReportUserRepresentation `json:"users"`
+}
+
+// MigrationReportUserRepresentation is the migration status of a single user
+type MigrationReportUserRepresentation struct {
+	ID               *string `json:"id,omitempty"`
+	Username         *string `json:"username,omitempty"`
+	CreatedTimestamp *int64  `json:"createdTimestamp,omitempty"`
+	Migrated         bool    `json:"migrated"`
+}
+
+// MigrationReportJobRepresentation is the state of a migration report computed in background
+type MigrationReportJobRepresentation struct {
+	ID       string                         `json:"id"`
+	Status   string                         `json:"status"`
+	Progress *int                           `json:"progress,omitempty"`
+	Report   *MigrationReportRepresentation `json:"report,omitempty"`
+	Error    *string                        `json:"error,omitempty"`
+}
+
+// StatisticsExportJobRepresentation is the state of a statistics export produced in background
+type StatisticsExportJobRepresentation struct {
+	ID       string  `json:"id"`
+	Status   string  `json:"status"`
+	Progress *int    `json:"progress,omitempty"`
+	Error    *string `json:"error,omitempty"`
+}
+
 // DbConnectionRepresentation is a non serializable StatisticsConnectionRepresentation read from database
 type DbConnectionRepresentation struct {
 	Date   sql.NullString
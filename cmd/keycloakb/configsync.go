@@ -0,0 +1,179 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	api "github.com/cloudtrust/keycloak-bridge/api/management"
+	"github.com/cloudtrust/keycloak-bridge/pkg/management"
+	kc "github.com/cloudtrust/keycloak-client"
+)
+
+// Exit statuses of the config command
+const (
+	configExitOK    = 0
+	configExitError = 1
+	configExitDrift = 2
+)
+
+const (
+	configCmdExport = "export"
+	configCmdSync   = "sync"
+	configCmdDrift  = "drift"
+
+	realmStateFileExtension = ".yaml"
+)
+
+const configUsage = `usage: keycloak_bridge [--config-file <file>] config <export|sync|drift> <directory> [realm...]
+  export  writes the state of the realms to <directory>/<realm>.yaml
+  sync    applies the states read from the directory to the realms
+  drift   lists the differences between the realms and the states read from the directory, exits with status 2 if any`
+
+// runConfigCommand runs the config command and returns the exit status. The state of each realm is stored in its own
+// file of the directory. Without realm arguments, export uses all the realms and sync/drift all the files of the directory.
+func runConfigCommand(ctx context.Context, component management.Component, synchronizer management.ConfigSynchronizer, args []string, out io.Writer) int {
+	if len(args) < 2 {
+		fmt.Fprintln(out, configUsage)
+		return configExitError
+	}
+	var command, directory, realms = args[0], args[1], args[2:]
+
+	var err error
+	var drift bool
+	switch command {
+	case configCmdExport:
+		err = exportRealmStates(ctx, component, synchronizer, directory, realms, out)
+	case configCmdSync:
+		_, err = syncRealmStates(ctx, synchronizer.SyncRealmState, directory, realms, out)
+	case configCmdDrift:
+		drift, err = syncRealmStates(ctx, synchronizer.DiffRealmState, directory, realms, out)
+	default:
+		fmt.Fprintln(out, configUsage)
+		return configExitError
+	}
+
+	if err != nil {
+		fmt.Fprintf(out, "config %s failed: %s\n", command, err.Error())
+		return configExitError
+	}
+	if drift {
+		return configExitDrift
+	}
+	return configExitOK
+}
+
+func exportRealmStates(ctx context.Context, component management.Component, synchronizer management.ConfigSynchronizer, directory string, realms []string, out io.Writer) error {
+	if len(realms) == 0 {
+		var realmReps, err = component.GetRealms(ctx)
+		if err != nil {
+			return err
+		}
+		for _, realm := range realmReps {
+			if realm.Realm != nil {
+				realms = append(realms, *realm.Realm)
+			}
+		}
+	}
+
+	if err := os.MkdirAll(directory, 0755); err != nil {
+		return err
+	}
+	for _, realmName := range realms {
+		var state, err = synchronizer.ExportRealmState(ctx, realmName)
+		if err != nil {
+			return err
+		}
+		content, err := state.ToYAML()
+		if err != nil {
+			return err
+		}
+		var path = filepath.Join(directory, realmName+realmStateFileExtension)
+		if err = ioutil.WriteFile(path, content, 0644); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "%s: exported to %s\n", realmName, path)
+	}
+	return nil
+}
+
+// syncRealmStates compares or synchronizes the realms with the states of the directory. It returns true if differences were found.
+func syncRealmStates(ctx context.Context, apply func(context.Context, api.RealmStateRepresentation) ([]api.ConfigurationDiffRepresentation, error),
+	directory string, realms []string, out io.Writer) (bool, error) {
+	var paths []string
+	if len(realms) == 0 {
+		var err error
+		if paths, err = filepath.Glob(filepath.Join(directory, "*"+realmStateFileExtension)); err != nil {
+			return false, err
+		}
+	}
+	for _, realmName := range realms {
+		paths = append(paths, filepath.Join(directory, realmName+realmStateFileExtension))
+	}
+
+	var drift bool
+	for _, path := range paths {
+		var content, err = ioutil.ReadFile(path)
+		if err != nil {
+			return drift, err
+		}
+		state, err := api.ParseRealmStateYAML(content)
+		if err != nil {
+			return drift, fmt.Errorf("%s: %s", path, err.Error())
+		}
+		if expected := strings.TrimSuffix(filepath.Base(path), realmStateFileExtension); *state.Realm != expected {
+			return drift, fmt.Errorf("%s: realm %s does not match the file name", path, *state.Realm)
+		}
+
+		diffs, err := apply(ctx, state)
+		if err != nil {
+			return drift, fmt.Errorf("%s: %s", *state.Realm, err.Error())
+		}
+		if len(diffs) == 0 {
+			fmt.Fprintf(out, "%s: up to date\n", *state.Realm)
+			continue
+		}
+		drift = true
+		for _, diff := range diffs {
+			var pending string
+			if diff.ApprovalID != nil {
+				pending = fmt.Sprintf(" (pending approval request %d)", *diff.ApprovalID)
+			}
+			fmt.Fprintf(out, "%s: %s: %s -> %s%s\n", *state.Realm, diff.Setting, diffValue(diff.From), diffValue(diff.To), pending)
+		}
+	}
+	return drift, nil
+}
+
+// technicalUserFinder is the part of the Keycloak client used to identify the technical user
+type technicalUserFinder interface {
+	GetUsers(accessToken string, reqRealmName, targetRealmName string, paramKV ...string) (kc.UsersPageRepresentation, error)
+}
+
+// technicalUserID returns the ID of the technical user. The config changes, events and approval requests are attributed to it.
+func technicalUserID(keycloakClient technicalUserFinder, accessToken, realmName, username string) (string, error) {
+	var users, err = keycloakClient.GetUsers(accessToken, realmName, realmName, "username", username)
+	if err != nil {
+		return "", err
+	}
+	// The search by username also returns the users whose username contains the given one
+	for _, user := range users.Users {
+		if user.ID != nil && user.Username != nil && strings.EqualFold(*user.Username, username) {
+			return *user.ID, nil
+		}
+	}
+	return "", fmt.Errorf("technical user %s not found in realm %s", username, realmName)
+}
+
+func diffValue(value interface{}) string {
+	if value == nil {
+		return "<none>"
+	}
+	var content, _ = json.Marshal(value)
+	return string(content)
+}
@@ -70,6 +70,7 @@ type RateKey int
 const (
 	defaultPublishingIP = "0.0.0.0"
 	pathHealthCheck     = "/health/check"
+	pathMetrics         = "/metrics"
 
 	RateKeyAccount    = iota
 	RateKeyEvent      = iota
@@ -96,6 +97,7 @@ const (
 	CfgValidationBasicAuthToken = "validation-basic-auth-token"
 	CfgPprofRouteEnabled        = "pprof-route-enabled"
 	CfgInfluxWriteInterval      = "influx-write-interval"
+	CfgMetricsBackends          = "metrics-backends"
 	CfgSentryDsn                = "sentry-dsn"
 	CfgAuditRwDbParams          = "db-audit-rw"
 	CfgAuditRoDbParams          = "db-audit-ro"
@@ -136,6 +138,26 @@ const (
 	CfgSsePublicURL             = "sse-public-url"
 	CfgDbAesGcmKey              = "db-aesgcm-key"
 	CfgDbAesGcmTagSize          = "db-aesgcm-tag-size"
+	CfgDbSearchIndexKey         = "db-search-index-key"
+	CfgJobsRetention            = "jobs-retention"
+	CfgReportsEnabled           = "statistics-reports-enabled"
+	CfgReportsInterval          = "statistics-reports-interval"
+	CfgSoftDeletionGracePeriod  = "soft-deletion-grace-period"
+	CfgSoftDeletionInterval     = "soft-deletion-purge-interval"
+	CfgGrantsInterval           = "temporary-grants-interval"
+	CfgUserChangesInterval      = "scheduled-user-changes-interval"
+	CfgImpersonationMaxDuration = "impersonation-max-duration"
+	CfgImpersonationInterval    = "impersonation-interval"
+	CfgImpersonationClientID    = "impersonation-client-id"
+	CfgImpersonationSecret      = "impersonation-client-secret"
+	CfgEmailSender              = "email-sender"
+	CfgSMTPHost                 = "smtp-host"
+	CfgSMTPPort                 = "smtp-port"
+	CfgSMTPUsername             = "smtp-username"
+	CfgSMTPPassword             = "smtp-password"
+	CfgSMTPFrom                 = "smtp-from"
+	CfgGeoIPDatabase            = "geoip-database"
+	CfgBreachedPasswordsFile    = "breached-passwords-file"
 )
 
 func init() {
@@ -197,9 +219,41 @@ func main() {
 		// Enabled units
 		pprofRouteEnabled = c.GetBool(CfgPprofRouteEnabled)
 
-		// Influx
+		// Metrics
+		metricsBackends     = c.GetStringSlice(CfgMetricsBackends)
 		influxWriteInterval = c.GetDuration(CfgInfluxWriteInterval)
 
+		// Background jobs
+		jobsRetention = c.GetDuration(CfgJobsRetention)
+
+		// Scheduled statistics reports
+		reportsEnabled  = c.GetBool(CfgReportsEnabled)
+		reportsInterval = c.GetDuration(CfgReportsInterval)
+		emailSender     = c.GetString(CfgEmailSender)
+
+		// Soft deletion of users (disabled when the grace period is 0)
+		softDeletionGracePeriod   = c.GetDuration(CfgSoftDeletionGracePeriod)
+		softDeletionPurgeInterval = c.GetDuration(CfgSoftDeletionInterval)
+
+		// Removal of the expired temporary grants
+		grantsInterval = c.GetDuration(CfgGrantsInterval)
+
+		// Application of the scheduled lock, unlock and expiration of users
+		userChangesInterval = c.GetDuration(CfgUserChangesInterval)
+
+		// Impersonation of users: maximum duration, interval at which the expired impersonations are ended and client used
+		// to exchange tokens
+		impersonationMaxDuration = c.GetDuration(CfgImpersonationMaxDuration)
+		impersonationInterval    = c.GetDuration(CfgImpersonationInterval)
+		impersonationClientID    = c.GetString(CfgImpersonationClientID)
+		impersonationSecret      = c.GetString(CfgImpersonationSecret)
+
+		// Events enrichment
+		geoIPDatabase = c.GetString(CfgGeoIPDatabase)
+
+		// Password validation: local list of breached passwords (not checked if empty)
+		breachedPasswordsFile = c.GetString(CfgBreachedPasswordsFile)
+
 		// DB - for the moment used just for audit events
 		auditRwDbParams = database.GetDbConfig(c, CfgAuditRwDbParams)
 
@@ -334,6 +388,13 @@ func main() {
 		return
 	}
 
+	// Security - blind indexes used to search users by their PII
+	blindIndexer, err := keycloakb.NewBlindIndexerFromBase64(c.GetString(CfgDbSearchIndexKey))
+	if err != nil {
+		logger.Error(ctx, "msg", "could not create blind indexer for the users search index", "error", err)
+		return
+	}
+
 	// Security - allowed trustID groups
 	var trustIDGroups = c.GetStringSlice(CfgTrustIDGroups)
 
@@ -377,15 +438,30 @@ func main() {
 		defer sentryClient.Close()
 	}
 
-	var influxMetrics metrics.Metrics
+	// Metrics backends
+	var metricsClient metrics.Metrics
+	var prometheusMetrics keycloakb.PrometheusMetrics
 	{
-		var err error
-		influxMetrics, err = metrics.NewMetrics(c, "influx", logger)
-		if err != nil {
-			logger.Error(ctx, "msg", "could not create Influx client", "error", err)
-			return
+		var backends []metrics.Metrics
+		for _, backend := range metricsBackends {
+			switch backend {
+			case keycloakb.MetricsBackendInflux:
+				influxMetrics, err := metrics.NewMetrics(c, "influx", logger)
+				if err != nil {
+					logger.Error(ctx, "msg", "could not create Influx client", "error", err)
+					return
+				}
+				backends = append(backends, influxMetrics)
+			case keycloakb.MetricsBackendPrometheus:
+				prometheusMetrics = keycloakb.NewPrometheusMetrics()
+				backends = append(backends, prometheusMetrics)
+			default:
+				logger.Error(ctx, "msg", "unknown metrics backend", "backend", backend)
+				return
+			}
 		}
-		defer influxMetrics.Close()
+		metricsClient = keycloakb.NewMultiMetrics(backends...)
+		defer metricsClient.Close()
 	}
 
 	// Jaeger client.
@@ -515,15 +591,15 @@ func main() {
 		var consoleModule event.ConsoleModule
 		{
 			consoleModule = event.NewConsoleModule(log.With(eventLogger, "module", "console"))
-			consoleModule = event.MakeConsoleModuleInstrumentingMW(influxMetrics.NewHistogram("console_module"))(consoleModule)
+			consoleModule = event.MakeConsoleModuleInstrumentingMW(metricsClient.NewHistogram("console_module"))(consoleModule)
 			consoleModule = event.MakeConsoleModuleLoggingMW(log.With(eventLogger, "mw", "module", "unit", "console"))(consoleModule)
 			consoleModule = event.MakeConsoleModuleTracingMW(tracer)(consoleModule)
 		}
 
 		var statisticModule event.StatisticModule
 		{
-			statisticModule = event.NewStatisticModule(influxMetrics)
-			statisticModule = event.MakeStatisticModuleInstrumentingMW(influxMetrics.NewHistogram("statistic_module"))(statisticModule)
+			statisticModule = event.NewStatisticModule(metricsClient)
+			statisticModule = event.MakeStatisticModuleInstrumentingMW(metricsClient.NewHistogram("statistic_module"))(statisticModule)
 			statisticModule = event.MakeStatisticModuleLoggingMW(log.With(eventLogger, "mw", "module", "unit", "statistic"))(statisticModule)
 			statisticModule = event.MakeStatisticModuleTracingMW(tracer)(statisticModule)
 		}
@@ -532,16 +608,41 @@ func main() {
 		var eventsDBModule database.EventsDBModule
 		{
 			eventsDBModule = database.NewEventsDBModule(eventsDBConn)
-			eventsDBModule = event.MakeEventsDBModuleInstrumentingMW(influxMetrics.NewHistogram("eventsDB_module"))(eventsDBModule)
+			eventsDBModule = event.MakeEventsDBModuleInstrumentingMW(metricsClient.NewHistogram("eventsDB_module"))(eventsDBModule)
 			eventsDBModule = event.MakeEventsDBModuleLoggingMW(log.With(eventLogger, "mw", "module", "unit", "eventsDB"))(eventsDBModule)
 			eventsDBModule = event.MakeEventsDBModuleTracingMW(tracer)(eventsDBModule)
 		}
 
+		// enrichment of the events with the country of the IP address and the device deduced from the user agent
+		var eventEnricher event.EventEnricher
+		{
+			var geoIP event.GeoIPLocator
+			if geoIPDatabase != "" {
+				var locator, err = keycloakb.NewGeoIPLocator(geoIPDatabase)
+				if err != nil {
+					logger.Error(ctx, "msg", "could not open GeoIP database", "error", err)
+					return
+				}
+				defer locator.Close()
+				geoIP = locator
+			}
+			eventEnricher = event.NewEventEnricher(geoIP, log.With(eventLogger, "unit", "enrichment"))
+		}
+
+		// module storing the enriched logins
+		var authenticationsDBModule event.AuthenticationsDBModule
+		{
+			authenticationsDBModule = event.NewAuthenticationsDBModule(eventsDBConn)
+			authenticationsDBModule = event.MakeAuthenticationsDBModuleInstrumentingMW(metricsClient.NewHistogram("authenticationsDB_module"))(authenticationsDBModule)
+			authenticationsDBModule = event.MakeAuthenticationsDBModuleLoggingMW(log.With(eventLogger, "mw", "module", "unit", "authenticationsDB"))(authenticationsDBModule)
+			authenticationsDBModule = event.MakeAuthenticationsDBModuleTracingMW(tracer)(authenticationsDBModule)
+		}
+
 		var eventAdminComponent event.AdminComponent
 		{
 			var fns = []event.FuncEvent{consoleModule.Print, statisticModule.Stats, eventsDBModule.Store}
 			eventAdminComponent = event.NewAdminComponent(fns, fns, fns, fns)
-			eventAdminComponent = event.MakeAdminComponentInstrumentingMW(influxMetrics.NewHistogram("admin_component"))(eventAdminComponent)
+			eventAdminComponent = event.MakeAdminComponentInstrumentingMW(metricsClient.NewHistogram("admin_component"))(eventAdminComponent)
 			eventAdminComponent = event.MakeAdminComponentLoggingMW(log.With(eventLogger, "mw", "component", "unit", "admin_event"))(eventAdminComponent)
 			eventAdminComponent = event.MakeAdminComponentTracingMW(tracer)(eventAdminComponent)
 		}
@@ -549,8 +650,9 @@ func main() {
 		var eventComponent event.Component
 		{
 			var fns = []event.FuncEvent{consoleModule.Print, statisticModule.Stats, eventsDBModule.Store}
-			eventComponent = event.NewComponent(fns, fns)
-			eventComponent = event.MakeComponentInstrumentingMW(influxMetrics.NewHistogram("component"))(eventComponent)
+			var stdFns = append(fns, authenticationsDBModule.Store)
+			eventComponent = event.NewComponent(eventEnricher, stdFns, fns)
+			eventComponent = event.MakeComponentInstrumentingMW(metricsClient.NewHistogram("component"))(eventComponent)
 			eventComponent = event.MakeComponentLoggingMW(log.With(eventLogger, "mw", "component", "unit", "event"))(eventComponent)
 			eventComponent = event.MakeComponentTracingMW(tracer)(eventComponent)
 		}
@@ -560,7 +662,7 @@ func main() {
 		var muxComponent event.MuxComponent
 		{
 			muxComponent = event.NewMuxComponent(eventComponent, eventAdminComponent)
-			muxComponent = event.MakeMuxComponentInstrumentingMW(influxMetrics.NewHistogram("mux_component"))(muxComponent)
+			muxComponent = event.MakeMuxComponentInstrumentingMW(metricsClient.NewHistogram("mux_component"))(muxComponent)
 			muxComponent = event.MakeMuxComponentLoggingMW(log.With(eventLogger, "mw", "component", "unit", "mux"))(muxComponent)
 			muxComponent = event.MakeMuxComponentTracingMW(tracer)(muxComponent)
 			muxComponent = event.MakeMuxComponentTrackingMW(sentryClient, log.With(eventLogger, "mw", "component"))(muxComponent)
@@ -569,7 +671,7 @@ func main() {
 		var eventEndpoint cs.Endpoint
 		{
 			eventEndpoint = event.MakeEventEndpoint(muxComponent)
-			eventEndpoint = middleware.MakeEndpointInstrumentingMW(influxMetrics, "event_endpoint")(eventEndpoint)
+			eventEndpoint = keycloakb.MakeEndpointInstrumentingMW(metricsClient, "event_endpoint")(eventEndpoint)
 			eventEndpoint = middleware.MakeEndpointLoggingMW(log.With(eventLogger, "mw", "endpoint"))(eventEndpoint)
 			eventEndpoint = tracer.MakeEndpointTracingMW("event_endpoint")(eventEndpoint)
 		}
@@ -584,13 +686,91 @@ func main() {
 	// new module for reading events from the DB
 	eventsRODBModule := keycloakb.NewEventsDBModule(eventsRODBConn)
 
+	// Configuration as code: the config command synchronizes the realms with declared states and exits
+	if args := pflag.Args(); len(args) > 0 && args[0] == "config" {
+		var configSyncLogger = log.With(logger, "svc", "config-sync")
+		var accessToken, err = technicalTokenProvider.ProvideToken(ctx)
+		if err != nil {
+			logger.Error(ctx, "msg", "Can't get technical token", "err", err.Error())
+			os.Exit(configExitError)
+		}
+		technicalID, err := technicalUserID(keycloakClient, accessToken, technicalRealm, technicalUsername)
+		if err != nil {
+			logger.Error(ctx, "msg", "Can't get technical user", "err", err.Error())
+			os.Exit(configExitError)
+		}
+		var configSyncCtx = context.WithValue(ctx, cs.CtContextCorrelationID, idGenerator.NextID())
+		configSyncCtx = context.WithValue(configSyncCtx, cs.CtContextAccessToken, accessToken)
+		configSyncCtx = context.WithValue(configSyncCtx, cs.CtContextRealm, technicalRealm)
+		configSyncCtx = context.WithValue(configSyncCtx, cs.CtContextUserID, technicalID)
+		configSyncCtx = context.WithValue(configSyncCtx, cs.CtContextUsername, technicalUsername)
+
+		var configSyncEventsDBModule = configureEventsDbModule(baseEventsDBModule, metricsClient, configSyncLogger, tracer)
+		var component = management.NewComponent(keycloakClient,
+			keycloakb.NewUsersDetailsDBModule(usersRwDBConn, aesEncryption, configSyncLogger),
+			configSyncEventsDBModule,
+			createConfigurationDBModule(configurationRwDBConn, metricsClient, configSyncLogger),
+			trustIDGroups, configSyncLogger)
+		// the approval policy of the realms applies to the config changes as to the ones made through the API
+		component = management.MakeApprovalComponentMW(keycloakb.NewApprovalsDBModule(configurationRwDBConn, aesEncryption, configSyncLogger),
+			configSyncEventsDBModule, configSyncLogger)(component)
+		os.Exit(runConfigCommand(configSyncCtx, component, management.NewConfigSynchronizer(component, configSyncLogger), args[1:], os.Stdout))
+	}
+
+	// Soft deletion of users: the deleted users are disabled and purged once the grace period is elapsed
+	var softDeletionLogger = log.With(logger, "svc", "soft-deletion")
+	var userSoftDeletion = keycloakb.NewUserSoftDeletion(keycloakClient, technicalTokenProvider,
+		keycloakb.NewSoftDeletedUsersDBModule(usersRwDBConn, softDeletionLogger),
+		keycloakb.NewUsersDetailsDBModule(usersRwDBConn, aesEncryption, softDeletionLogger),
+		configureEventsDbModule(baseEventsDBModule, metricsClient, softDeletionLogger, tracer),
+		softDeletionGracePeriod, idGenerator, softDeletionLogger)
+
+	// Temporary grants
+	var grantsLogger = log.With(logger, "svc", "temporary-grants")
+	var grantsDBModule = keycloakb.NewTemporaryGrantsDBModule(usersRwDBConn, grantsLogger)
+	var grantsReconciler = keycloakb.NewGrantsReconciler(keycloakClient, technicalTokenProvider, grantsDBModule,
+		configureEventsDbModule(baseEventsDBModule, metricsClient, grantsLogger, tracer), idGenerator, grantsLogger)
+
+	// Scheduled user changes: the scheduler is created with the management component
+	var scheduledChangesDBModule = keycloakb.NewScheduledUserChangesDBModule(usersRwDBConn, log.With(logger, "svc", "scheduled-user-changes"))
+	var userChangesScheduler management.UserChangesScheduler
+
+	// Email sender used for the statistics reports, the impersonation notifications and the passwords of the approved resets
+	var sender keycloakb.EmailSender
+	switch emailSender {
+	case keycloakb.EmailSenderSMTP:
+		sender = keycloakb.NewSMTPEmailSender(c.GetString(CfgSMTPHost), c.GetInt(CfgSMTPPort), c.GetString(CfgSMTPUsername), c.GetString(CfgSMTPPassword), c.GetString(CfgSMTPFrom))
+	case keycloakb.EmailSenderFake:
+		sender = keycloakb.NewFakeEmailSender(log.With(logger, "svc", "email-sender"))
+	default:
+		sender = keycloakb.NewKeycloakEmailSender(keycloakClient.AccountClient(), technicalTokenProvider)
+	}
+
+	// Validation of the passwords against the password policy of the realms, before they are submitted to Keycloak
+	var passwordValidator keycloakb.PasswordValidator
+	{
+		var breachedPasswords keycloakb.BreachedPasswords
+		if breachedPasswordsFile != "" {
+			var err error
+			if breachedPasswords, err = keycloakb.LoadBreachedPasswords(breachedPasswordsFile); err != nil {
+				logger.Error(ctx, "msg", "could not load breached passwords", "error", err)
+				return
+			}
+		}
+		passwordValidator = keycloakb.NewPasswordValidator(keycloakClient, technicalTokenProvider, breachedPasswords, log.With(logger, "svc", "password-validation"))
+	}
+
+	// Impersonation of users: the terminator is created with the management component
+	var impersonationsDBModule = keycloakb.NewImpersonationsDBModule(usersRwDBConn, log.With(logger, "svc", "impersonations"))
+	var impersonationTerminator management.ImpersonationTerminator
+
 	// Validation service.
 	var validationEndpoints validation.Endpoints
 	{
 		var validationLogger = log.With(logger, "svc", "validation")
 
 		// module to store validation API calls
-		eventsDBModule := configureEventsDbModule(baseEventsDBModule, influxMetrics, validationLogger, tracer)
+		eventsDBModule := configureEventsDbModule(baseEventsDBModule, metricsClient, validationLogger, tracer)
 
 		// module for storing and retrieving details of the users
 		var usersDBModule = keycloakb.NewUsersDetailsDBModule(usersRwDBConn, aesEncryption, validationLogger)
@@ -606,9 +786,9 @@ func main() {
 
 		var rateLimitValidation = rateLimit[RateKeyValidation]
 		validationEndpoints = validation.Endpoints{
-			GetUser:     prepareEndpoint(validation.MakeGetUserEndpoint(validationComponent), "get_user", influxMetrics, validationLogger, tracer, rateLimitValidation),
-			UpdateUser:  prepareEndpoint(validation.MakeUpdateUserEndpoint(validationComponent), "update_user", influxMetrics, validationLogger, tracer, rateLimitValidation),
-			CreateCheck: prepareEndpoint(validation.MakeCreateCheckEndpoint(validationComponent), "create_check", influxMetrics, validationLogger, tracer, rateLimitValidation),
+			GetUser:     prepareEndpoint(validation.MakeGetUserEndpoint(validationComponent), "get_user", metricsClient, validationLogger, tracer, rateLimitValidation),
+			UpdateUser:  prepareEndpoint(validation.MakeUpdateUserEndpoint(validationComponent), "update_user", metricsClient, validationLogger, tracer, rateLimitValidation),
+			CreateCheck: prepareEndpoint(validation.MakeCreateCheckEndpoint(validationComponent), "create_check", metricsClient, validationLogger, tracer, rateLimitValidation),
 		}
 	}
 
@@ -617,18 +797,27 @@ func main() {
 	{
 		var statisticsLogger = log.With(logger, "svc", "statistics")
 
-		statisticsComponent := statistics.NewComponent(eventsRODBModule, keycloakClient, statisticsLogger)
+		var statisticsJobs = keycloakb.NewJobStore(idGenerator, jobsRetention)
+		statisticsComponent := statistics.NewComponent(eventsRODBModule, keycloakClient, statisticsJobs, technicalTokenProvider, technicalRealm, statisticsLogger)
 		statisticsComponent = statistics.MakeAuthorizationManagementComponentMW(log.With(statisticsLogger, "mw", "endpoint"), authorizationManager)(statisticsComponent)
 
 		var rateLimitStatistics = rateLimit[RateKeyStatistics]
 		statisticsEndpoints = statistics.Endpoints{
-			GetActions:                      prepareEndpoint(statistics.MakeGetActionsEndpoint(statisticsComponent), "get_actions", influxMetrics, statisticsLogger, tracer, rateLimitStatistics),
-			GetStatistics:                   prepareEndpoint(statistics.MakeGetStatisticsEndpoint(statisticsComponent), "get_statistics", influxMetrics, statisticsLogger, tracer, rateLimitStatistics),
-			GetStatisticsUsers:              prepareEndpoint(statistics.MakeGetStatisticsUsersEndpoint(statisticsComponent), "get_statistics_users", influxMetrics, statisticsLogger, tracer, rateLimitStatistics),
-			GetStatisticsAuthentications:    prepareEndpoint(statistics.MakeGetStatisticsAuthenticationsEndpoint(statisticsComponent), "get_statistics_authentications", influxMetrics, statisticsLogger, tracer, rateLimitStatistics),
-			GetStatisticsAuthenticationsLog: prepareEndpoint(statistics.MakeGetStatisticsAuthenticationsLogEndpoint(statisticsComponent), "get_statistics_authentications_log", influxMetrics, statisticsLogger, tracer, rateLimitStatistics),
-			GetStatisticsAuthenticators:     prepareEndpoint(statistics.MakeGetStatisticsAuthenticatorsEndpoint(statisticsComponent), "get_statistics_authenticators", influxMetrics, statisticsLogger, tracer, rateLimitStatistics),
-			GetMigrationReport:              prepareEndpoint(statistics.MakeGetMigrationReportEndpoint(statisticsComponent), "get_migration_report", influxMetrics, statisticsLogger, tracer, rateLimitStatistics),
+			GetActions:                            prepareEndpoint(statistics.MakeGetActionsEndpoint(statisticsComponent), "get_actions", metricsClient, statisticsLogger, tracer, rateLimitStatistics),
+			GetStatistics:                         prepareEndpoint(statistics.MakeGetStatisticsEndpoint(statisticsComponent), "get_statistics", metricsClient, statisticsLogger, tracer, rateLimitStatistics),
+			GetStatisticsUsers:                    prepareEndpoint(statistics.MakeGetStatisticsUsersEndpoint(statisticsComponent), "get_statistics_users", metricsClient, statisticsLogger, tracer, rateLimitStatistics),
+			GetStatisticsAuthentications:          prepareEndpoint(statistics.MakeGetStatisticsAuthenticationsEndpoint(statisticsComponent), "get_statistics_authentications", metricsClient, statisticsLogger, tracer, rateLimitStatistics),
+			GetStatisticsAuthenticationsLog:       prepareEndpoint(statistics.MakeGetStatisticsAuthenticationsLogEndpoint(statisticsComponent), "get_statistics_authentications_log", metricsClient, statisticsLogger, tracer, rateLimitStatistics),
+			GetStatisticsAuthenticators:           prepareEndpoint(statistics.MakeGetStatisticsAuthenticatorsEndpoint(statisticsComponent), "get_statistics_authenticators", metricsClient, statisticsLogger, tracer, rateLimitStatistics),
+			GetMigrationReport:                    prepareEndpoint(statistics.MakeGetMigrationReportEndpoint(statisticsComponent), "get_migration_report", metricsClient, statisticsLogger, tracer, rateLimitStatistics),
+			StartMigrationReport:                  prepareEndpoint(statistics.MakeStartMigrationReportEndpoint(statisticsComponent), "start_migration_report", metricsClient, statisticsLogger, tracer, rateLimitStatistics),
+			GetMigrationReportJob:                 prepareEndpoint(statistics.MakeGetMigrationReportJobEndpoint(statisticsComponent), "get_migration_report_job", metricsClient, statisticsLogger, tracer, rateLimitStatistics),
+			StartStatisticsExport:                 prepareEndpoint(statistics.MakeStartStatisticsExportEndpoint(statisticsComponent), "start_statistics_export", metricsClient, statisticsLogger, tracer, rateLimitStatistics),
+			GetStatisticsExportJob:                prepareEndpoint(statistics.MakeGetStatisticsExportJobEndpoint(statisticsComponent), "get_statistics_export_job", metricsClient, statisticsLogger, tracer, rateLimitStatistics),
+			GetStatisticsExportFile:               prepareEndpoint(statistics.MakeGetStatisticsExportFileEndpoint(statisticsComponent), "get_statistics_export_file", metricsClient, statisticsLogger, tracer, rateLimitStatistics),
+			GetStatisticsAuthenticationsByCountry: prepareEndpoint(statistics.MakeGetStatisticsAuthenticationsByCountryEndpoint(statisticsComponent), "get_statistics_authentications_by_country", metricsClient, statisticsLogger, tracer, rateLimitStatistics),
+			GetStatisticsAuthenticationsByDevice:  prepareEndpoint(statistics.MakeGetStatisticsAuthenticationsByDeviceEndpoint(statisticsComponent), "get_statistics_authentications_by_device", metricsClient, statisticsLogger, tracer, rateLimitStatistics),
+			GetStatisticsUserAuthentications:      prepareEndpoint(statistics.MakeGetStatisticsUserAuthenticationsEndpoint(statisticsComponent), "get_statistics_user_authentications", metricsClient, statisticsLogger, tracer, rateLimitStatistics),
 		}
 	}
 
@@ -638,17 +827,17 @@ func main() {
 		var eventsLogger = log.With(logger, "svc", "events")
 
 		// module to store API calls of the back office to the DB
-		eventsDBModule := configureEventsDbModule(baseEventsDBModule, influxMetrics, eventsLogger, tracer)
+		eventsDBModule := configureEventsDbModule(baseEventsDBModule, metricsClient, eventsLogger, tracer)
 
 		eventsComponent := events.NewComponent(eventsRODBModule, eventsDBModule, eventsLogger)
 		eventsComponent = events.MakeAuthorizationManagementComponentMW(log.With(eventsLogger, "mw", "endpoint"), authorizationManager)(eventsComponent)
 
 		var rateLimitEvents = rateLimit[RateKeyEvents]
 		eventsEndpoints = events.Endpoints{
-			GetActions:       prepareEndpoint(events.MakeGetActionsEndpoint(eventsComponent), "get_actions", influxMetrics, eventsLogger, tracer, rateLimitEvents),
-			GetEvents:        prepareEndpoint(events.MakeGetEventsEndpoint(eventsComponent), "get_events", influxMetrics, eventsLogger, tracer, rateLimitEvents),
-			GetEventsSummary: prepareEndpoint(events.MakeGetEventsSummaryEndpoint(eventsComponent), "get_events_summary", influxMetrics, eventsLogger, tracer, rateLimitEvents),
-			GetUserEvents:    prepareEndpoint(events.MakeGetUserEventsEndpoint(eventsComponent), "get_user_events", influxMetrics, eventsLogger, tracer, rateLimitEvents),
+			GetActions:       prepareEndpoint(events.MakeGetActionsEndpoint(eventsComponent), "get_actions", metricsClient, eventsLogger, tracer, rateLimitEvents),
+			GetEvents:        prepareEndpoint(events.MakeGetEventsEndpoint(eventsComponent), "get_events", metricsClient, eventsLogger, tracer, rateLimitEvents),
+			GetEventsSummary: prepareEndpoint(events.MakeGetEventsSummaryEndpoint(eventsComponent), "get_events_summary", metricsClient, eventsLogger, tracer, rateLimitEvents),
+			GetUserEvents:    prepareEndpoint(events.MakeGetUserEventsEndpoint(eventsComponent), "get_user_events", metricsClient, eventsLogger, tracer, rateLimitEvents),
 		}
 	}
 
@@ -658,83 +847,216 @@ func main() {
 		var managementLogger = log.With(logger, "svc", "management")
 
 		// module to store API calls of the back office to the DB
-		eventsDBModule := configureEventsDbModule(baseEventsDBModule, influxMetrics, managementLogger, tracer)
+		eventsDBModule := configureEventsDbModule(baseEventsDBModule, metricsClient, managementLogger, tracer)
 
 		// module for storing and retrieving the custom configuration
-		var configDBModule = createConfigurationDBModule(configurationRwDBConn, influxMetrics, managementLogger)
+		var configDBModule = createConfigurationDBModule(configurationRwDBConn, metricsClient, managementLogger)
 
 		// module for storing and retrieving details of the users
 		var usersDBModule = keycloakb.NewUsersDetailsDBModule(usersRwDBConn, aesEncryption, managementLogger)
 
+		// module for the search index of the users
+		var usersSearchIndexDBModule = keycloakb.NewUsersSearchIndexDBModule(usersRwDBConn, managementLogger)
+
+		// module for the four-eyes approval of sensitive actions
+		var approvalsDBModule = keycloakb.NewApprovalsDBModule(configurationRwDBConn, aesEncryption, managementLogger)
+
 		var keycloakComponent management.Component
+		var bulkComponent management.BulkComponent
+		var searchComponent management.SearchComponent
+		var softDeletionComponent management.SoftDeletionComponent
+		var approvalComponent management.ApprovalComponent
+		var grantsComponent management.GrantsComponent
+		var scheduledChangesComponent management.ScheduledChangesComponent
+		var impersonationComponent management.ImpersonationComponent
+		var provisioningComponent management.ProvisioningComponent
+		var authorizationsReportComponent management.AuthorizationsReportComponent
 		{
+			var usersIndexer = management.NewUsersIndexer(keycloakClient, usersDBModule, usersSearchIndexDBModule, blindIndexer, managementLogger)
 			keycloakComponent = management.NewComponent(keycloakClient, usersDBModule, eventsDBModule, configDBModule, trustIDGroups, managementLogger)
+			keycloakComponent = management.MakeSearchIndexComponentMW(usersIndexer, managementLogger)(keycloakComponent)
+			keycloakComponent = management.MakeTemporaryGrantsComponentMW(grantsDBModule, managementLogger)(keycloakComponent)
+			if softDeletionGracePeriod > 0 {
+				// soft deleted users stay in the search index until they are purged
+				keycloakComponent = management.MakeSoftDeletionComponentMW(userSoftDeletion, eventsDBModule, managementLogger)(keycloakComponent)
+			}
+
+			// approved requests are executed by the management component before the approval middleware
+			approvalComponent = management.NewApprovalComponent(keycloakComponent, approvalsDBModule, authorizationManager, sender, eventsDBModule, managementLogger)
+			approvalComponent = management.MakeAuthorizationApprovalComponentMW(log.With(managementLogger, "mw", "endpoint"), authorizationManager)(approvalComponent)
+			keycloakComponent = management.MakeApprovalComponentMW(approvalsDBModule, eventsDBModule, managementLogger)(keycloakComponent)
+
+			// temporary grants check the same authorizations as the permanent ones
+			grantsComponent = management.NewGrantsComponent(keycloakComponent, grantsDBModule, eventsDBModule, managementLogger)
+			grantsComponent = management.MakeAuthorizationGrantsComponentMW(log.With(managementLogger, "mw", "endpoint"), authorizationManager)(grantsComponent)
+
+			// scheduled changes are applied on behalf of their creator by the management component before the authorization middleware
+			scheduledChangesComponent = management.NewScheduledChangesComponent(scheduledChangesDBModule, eventsDBModule, managementLogger)
+			scheduledChangesComponent = management.MakeAuthorizationScheduledChangesComponentMW(log.With(managementLogger, "mw", "endpoint"), authorizationManager)(scheduledChangesComponent)
+			userChangesScheduler = management.NewUserChangesScheduler(keycloakComponent, keycloakClient, authorizationManager, scheduledChangesDBModule,
+				technicalTokenProvider, eventsDBModule, idGenerator, managementLogger)
+
+			var tokenExchanger = keycloakb.NewTokenExchanger(keycloakPublicURL, impersonationClientID, impersonationSecret, keycloakConfig.Timeout)
+			impersonationComponent = management.NewImpersonationComponent(keycloakClient, tokenExchanger, impersonationsDBModule, sender, eventsDBModule, impersonationMaxDuration, managementLogger)
+			impersonationComponent = management.MakeAuthorizationImpersonationComponentMW(log.With(managementLogger, "mw", "endpoint"), authorizationManager)(impersonationComponent)
+			impersonationTerminator = management.NewImpersonationTerminator(keycloakClient, impersonationsDBModule, technicalTokenProvider, eventsDBModule, idGenerator, managementLogger)
+
+			// bulk operations check the authorizations user per user, they use the management component before the authorization middleware
+			var managementJobs = keycloakb.NewJobStore(idGenerator, jobsRetention)
+			bulkComponent = management.NewBulkComponent(keycloakComponent, authorizationManager, managementJobs, managementLogger)
+			bulkComponent = management.MakeAuthorizationBulkComponentMW(log.With(managementLogger, "mw", "endpoint"), authorizationManager)(bulkComponent)
+
+			// the operator is authorized once for the whole template, the provisioning uses the management component before the authorization middleware
+			provisioningComponent = management.NewProvisioningComponent(keycloakClient, keycloakComponent, approvalsDBModule, eventsDBModule, managementLogger)
+			provisioningComponent = management.MakeAuthorizationProvisioningComponentMW(log.With(managementLogger, "mw", "endpoint"), authorizationManager)(provisioningComponent)
+
+			authorizationsReportComponent = management.NewAuthorizationsReportComponent(keycloakClient, configDBModule, authorizationManager, managementLogger)
+			authorizationsReportComponent = management.MakeAuthorizationAuthorizationsReportComponentMW(log.With(managementLogger, "mw", "endpoint"), authorizationManager)(authorizationsReportComponent)
+
+			searchComponent = management.NewSearchComponent(keycloakClient, usersDBModule, usersSearchIndexDBModule, blindIndexer, usersIndexer, managementJobs, managementLogger)
+			searchComponent = management.MakeAuthorizationSearchComponentMW(log.With(managementLogger, "mw", "endpoint"), authorizationManager)(searchComponent)
+
+			softDeletionComponent = management.NewSoftDeletionComponent(userSoftDeletion, eventsDBModule, managementLogger)
+			softDeletionComponent = management.MakeAuthorizationSoftDeletionComponentMW(log.With(managementLogger, "mw", "endpoint"), authorizationManager)(softDeletionComponent)
+
 			keycloakComponent = management.MakeAuthorizationManagementComponentMW(log.With(managementLogger, "mw", "endpoint"), authorizationManager)(keycloakComponent)
 		}
 
 		var rateLimitMgmt = rateLimit[RateKeyManagement]
 		managementEndpoints = management.Endpoints{
-			GetActions: prepareEndpoint(management.MakeGetActionsEndpoint(keycloakComponent), "get_actions_endpoint", influxMetrics, managementLogger, tracer, rateLimitMgmt),
-
-			GetRealms: prepareEndpoint(management.MakeGetRealmsEndpoint(keycloakComponent), "realms_endpoint", influxMetrics, managementLogger, tracer, rateLimitMgmt),
-			GetRealm:  prepareEndpoint(management.MakeGetRealmEndpoint(keycloakComponent), "realm_endpoint", influxMetrics, managementLogger, tracer, rateLimitMgmt),
-
-			GetClients:         prepareEndpoint(management.MakeGetClientsEndpoint(keycloakComponent), "get_clients_endpoint", influxMetrics, managementLogger, tracer, rateLimitMgmt),
-			GetClient:          prepareEndpoint(management.MakeGetClientEndpoint(keycloakComponent), "get_client_endpoint", influxMetrics, managementLogger, tracer, rateLimitMgmt),
-			GetRequiredActions: prepareEndpoint(management.MakeGetRequiredActionsEndpoint(keycloakComponent), "get_required-actions_endpoint", influxMetrics, managementLogger, tracer, rateLimitMgmt),
-
-			CreateUser:                prepareEndpoint(management.MakeCreateUserEndpoint(keycloakComponent, managementLogger), "create_user_endpoint", influxMetrics, managementLogger, tracer, rateLimitMgmt),
-			GetUser:                   prepareEndpoint(management.MakeGetUserEndpoint(keycloakComponent), "get_user_endpoint", influxMetrics, managementLogger, tracer, rateLimitMgmt),
-			UpdateUser:                prepareEndpoint(management.MakeUpdateUserEndpoint(keycloakComponent), "update_user_endpoint", influxMetrics, managementLogger, tracer, rateLimitMgmt),
-			LockUser:                  prepareEndpoint(management.MakeLockUserEndpoint(keycloakComponent), "lock_user_endpoint", influxMetrics, managementLogger, tracer, rateLimitMgmt),
-			UnlockUser:                prepareEndpoint(management.MakeUnlockUserEndpoint(keycloakComponent), "unlock_user_endpoint", influxMetrics, managementLogger, tracer, rateLimitMgmt),
-			DeleteUser:                prepareEndpoint(management.MakeDeleteUserEndpoint(keycloakComponent), "delete_user_endpoint", influxMetrics, managementLogger, tracer, rateLimitMgmt),
-			GetUsers:                  prepareEndpoint(management.MakeGetUsersEndpoint(keycloakComponent), "get_users_endpoint", influxMetrics, managementLogger, tracer, rateLimitMgmt),
-			GetUserAccountStatus:      prepareEndpoint(management.MakeGetUserAccountStatusEndpoint(keycloakComponent), "get_user_accountstatus", influxMetrics, managementLogger, tracer, rateLimitMgmt),
-			GetGroupsOfUser:           prepareEndpoint(management.MakeGetGroupsOfUserEndpoint(keycloakComponent), "get_user_groups", influxMetrics, managementLogger, tracer, rateLimitMgmt),
-			AddGroupToUser:            prepareEndpoint(management.MakeAddGroupToUserEndpoint(keycloakComponent), "add_user_group", influxMetrics, managementLogger, tracer, rateLimitMgmt),
-			DeleteGroupForUser:        prepareEndpoint(management.MakeDeleteGroupForUserEndpoint(keycloakComponent), "del_user_group", influxMetrics, managementLogger, tracer, rateLimitMgmt),
-			GetAvailableTrustIDGroups: prepareEndpoint(management.MakeGetAvailableTrustIDGroupsEndpoint(keycloakComponent), "get_available_trustid_groups_endpoint", influxMetrics, managementLogger, tracer, rateLimitMgmt),
-			GetTrustIDGroupsOfUser:    prepareEndpoint(management.MakeGetTrustIDGroupsOfUserEndpoint(keycloakComponent), "get_user_trustid_groups_endpoint", influxMetrics, managementLogger, tracer, rateLimitMgmt),
-			SetTrustIDGroupsToUser:    prepareEndpoint(management.MakeSetTrustIDGroupsToUserEndpoint(keycloakComponent), "set_user_trustid_groups_endpoint", influxMetrics, managementLogger, tracer, rateLimitMgmt),
-			GetRolesOfUser:            prepareEndpoint(management.MakeGetRolesOfUserEndpoint(keycloakComponent), "get_user_roles", influxMetrics, managementLogger, tracer, rateLimitMgmt),
-
-			GetRoles: prepareEndpoint(management.MakeGetRolesEndpoint(keycloakComponent), "get_roles_endpoint", influxMetrics, managementLogger, tracer, rateLimitMgmt),
-			GetRole:  prepareEndpoint(management.MakeGetRoleEndpoint(keycloakComponent), "get_role_endpoint", influxMetrics, managementLogger, tracer, rateLimitMgmt),
-
-			GetGroups:            prepareEndpoint(management.MakeGetGroupsEndpoint(keycloakComponent), "get_groups_endpoint", influxMetrics, managementLogger, tracer, rateLimitMgmt),
-			CreateGroup:          prepareEndpoint(management.MakeCreateGroupEndpoint(keycloakComponent, managementLogger), "create_group_endpoint", influxMetrics, managementLogger, tracer, rateLimitMgmt),
-			DeleteGroup:          prepareEndpoint(management.MakeDeleteGroupEndpoint(keycloakComponent), "delete_group_endpoint", influxMetrics, managementLogger, tracer, rateLimitMgmt),
-			GetAuthorizations:    prepareEndpoint(management.MakeGetAuthorizationsEndpoint(keycloakComponent), "get_authorizations_endpoint", influxMetrics, managementLogger, tracer, rateLimitMgmt),
-			UpdateAuthorizations: prepareEndpoint(management.MakeUpdateAuthorizationsEndpoint(keycloakComponent), "update_authorizations_endpoint", influxMetrics, managementLogger, tracer, rateLimitMgmt),
-
-			GetClientRoles:       prepareEndpoint(management.MakeGetClientRolesEndpoint(keycloakComponent), "get_client_roles_endpoint", influxMetrics, managementLogger, tracer, rateLimitMgmt),
-			CreateClientRole:     prepareEndpoint(management.MakeCreateClientRoleEndpoint(keycloakComponent, managementLogger), "create_client_role_endpoint", influxMetrics, managementLogger, tracer, rateLimitMgmt),
-			GetClientRoleForUser: prepareEndpoint(management.MakeGetClientRolesForUserEndpoint(keycloakComponent), "get_client_roles_for_user_endpoint", influxMetrics, managementLogger, tracer, rateLimitMgmt),
-			AddClientRoleToUser:  prepareEndpoint(management.MakeAddClientRolesToUserEndpoint(keycloakComponent), "get_client_roles_for_user_endpoint", influxMetrics, managementLogger, tracer, rateLimitMgmt),
-
-			ResetPassword:                  prepareEndpointWithoutLogging(management.MakeResetPasswordEndpoint(keycloakComponent), "reset_password_endpoint", influxMetrics, tracer, rateLimitMgmt),
-			ExecuteActionsEmail:            prepareEndpoint(management.MakeExecuteActionsEmailEndpoint(keycloakComponent), "execute_actions_email_endpoint", influxMetrics, managementLogger, tracer, rateLimitMgmt),
-			SendReminderEmail:              prepareEndpoint(management.MakeSendReminderEmailEndpoint(keycloakComponent), "send_reminder_email_endpoint", influxMetrics, managementLogger, tracer, rateLimitMgmt),
-			SendNewEnrolmentCode:           prepareEndpoint(management.MakeSendNewEnrolmentCodeEndpoint(keycloakComponent), "send_new_enrolment_code_endpoint", influxMetrics, managementLogger, tracer, rateLimitMgmt),
-			ResetSmsCounter:                prepareEndpoint(management.MakeResetSmsCounterEndpoint(keycloakComponent), "reset_sms_counter_endpoint", influxMetrics, managementLogger, tracer, rateLimitMgmt),
-			CreateRecoveryCode:             prepareEndpoint(management.MakeCreateRecoveryCodeEndpoint(keycloakComponent), "create_recovery_code_endpoint", influxMetrics, managementLogger, tracer, rateLimitMgmt),
-			GetCredentialsForUser:          prepareEndpoint(management.MakeGetCredentialsForUserEndpoint(keycloakComponent), "get_credentials_for_user_endpoint", influxMetrics, managementLogger, tracer, rateLimitMgmt),
-			DeleteCredentialsForUser:       prepareEndpoint(management.MakeDeleteCredentialsForUserEndpoint(keycloakComponent), "delete_credentials_for_user_endpoint", influxMetrics, managementLogger, tracer, rateLimitMgmt),
-			ResetCredentialFailuresForUser: prepareEndpoint(management.MakeResetCredentialFailuresForUserEndpoint(keycloakComponent), "reset_credential_failures_for_user_endpoint", influxMetrics, managementLogger, tracer, rateLimitMgmt),
-			ClearUserLoginFailures:         prepareEndpoint(management.MakeClearUserLoginFailures(keycloakComponent), "clear_user_login_failures_endpoint", influxMetrics, managementLogger, tracer, rateLimitMgmt),
-			GetAttackDetectionStatus:       prepareEndpoint(management.MakeGetAttackDetectionStatus(keycloakComponent), "get_attack_detection_status_endpoint", influxMetrics, managementLogger, tracer, rateLimitMgmt),
-
-			GetRealmCustomConfiguration:    prepareEndpoint(management.MakeGetRealmCustomConfigurationEndpoint(keycloakComponent), "get_realm_custom_config_endpoint", influxMetrics, managementLogger, tracer, rateLimitMgmt),
-			UpdateRealmCustomConfiguration: prepareEndpoint(management.MakeUpdateRealmCustomConfigurationEndpoint(keycloakComponent), "update_realm_custom_config_endpoint", influxMetrics, managementLogger, tracer, rateLimitMgmt),
-			GetRealmAdminConfiguration:     prepareEndpoint(management.MakeGetRealmAdminConfigurationEndpoint(keycloakComponent), "get_realm_admin_config_endpoint", influxMetrics, managementLogger, tracer, rateLimitMgmt),
-			UpdateRealmAdminConfiguration:  prepareEndpoint(management.MakeUpdateRealmAdminConfigurationEndpoint(keycloakComponent), "update_realm_admin_config_endpoint", influxMetrics, managementLogger, tracer, rateLimitMgmt),
-
-			GetRealmBackOfficeConfiguration:     prepareEndpoint(management.MakeGetRealmBackOfficeConfigurationEndpoint(keycloakComponent), "get_realm_back_office_config_endpoint", influxMetrics, managementLogger, tracer, rateLimitMgmt),
-			UpdateRealmBackOfficeConfiguration:  prepareEndpoint(management.MakeUpdateRealmBackOfficeConfigurationEndpoint(keycloakComponent), "update_realm_back_office_config_endpoint", influxMetrics, managementLogger, tracer, rateLimitMgmt),
-			GetUserRealmBackOfficeConfiguration: prepareEndpoint(management.MakeGetUserRealmBackOfficeConfigurationEndpoint(keycloakComponent), "get_user_realm_back_office_config_endpoint", influxMetrics, managementLogger, tracer, rateLimitMgmt),
-
-			LinkShadowUser: prepareEndpoint(management.MakeLinkShadowUserEndpoint(keycloakComponent), "link_shadow_user_endpoint", influxMetrics, managementLogger, tracer, rateLimitMgmt),
+			GetActions: prepareEndpoint(management.MakeGetActionsEndpoint(keycloakComponent), "get_actions_endpoint", metricsClient, managementLogger, tracer, rateLimitMgmt),
+
+			GetRealms: prepareEndpoint(management.MakeGetRealmsEndpoint(keycloakComponent), "realms_endpoint", metricsClient, managementLogger, tracer, rateLimitMgmt),
+			GetRealm:  prepareEndpoint(management.MakeGetRealmEndpoint(keycloakComponent), "realm_endpoint", metricsClient, managementLogger, tracer, rateLimitMgmt),
+
+			GetClients:         prepareEndpoint(management.MakeGetClientsEndpoint(keycloakComponent), "get_clients_endpoint", metricsClient, managementLogger, tracer, rateLimitMgmt),
+			GetClient:          prepareEndpoint(management.MakeGetClientEndpoint(keycloakComponent), "get_client_endpoint", metricsClient, managementLogger, tracer, rateLimitMgmt),
+			GetRequiredActions: prepareEndpoint(management.MakeGetRequiredActionsEndpoint(keycloakComponent), "get_required-actions_endpoint", metricsClient, managementLogger, tracer, rateLimitMgmt),
+
+			CreateClient:             prepareEndpoint(management.MakeCreateClientEndpoint(keycloakComponent, managementLogger), "create_client_endpoint", metricsClient, managementLogger, tracer, rateLimitMgmt),
+			UpdateClient:             prepareEndpoint(management.MakeUpdateClientEndpoint(keycloakComponent), "update_client_endpoint", metricsClient, managementLogger, tracer, rateLimitMgmt),
+			DeleteClient:             prepareEndpoint(management.MakeDeleteClientEndpoint(keycloakComponent), "delete_client_endpoint", metricsClient, managementLogger, tracer, rateLimitMgmt),
+			RegenerateClientSecret:   prepareEndpoint(management.MakeRegenerateClientSecretEndpoint(keycloakComponent), "regenerate_client_secret_endpoint", metricsClient, managementLogger, tracer, rateLimitMgmt),
+			UpdateClientRedirectURIs: prepareEndpoint(management.MakeUpdateClientRedirectURIsEndpoint(keycloakComponent), "update_client_redirect_uris_endpoint", metricsClient, managementLogger, tracer, rateLimitMgmt),
+
+			CreateUser:                prepareEndpoint(management.MakeCreateUserEndpoint(keycloakComponent, managementLogger), "create_user_endpoint", metricsClient, managementLogger, tracer, rateLimitMgmt),
+			GetUser:                   prepareEndpoint(management.MakeGetUserEndpoint(keycloakComponent), "get_user_endpoint", metricsClient, managementLogger, tracer, rateLimitMgmt),
+			UpdateUser:                prepareEndpoint(management.MakeUpdateUserEndpoint(keycloakComponent), "update_user_endpoint", metricsClient, managementLogger, tracer, rateLimitMgmt),
+			LockUser:                  prepareEndpoint(management.MakeLockUserEndpoint(keycloakComponent), "lock_user_endpoint", metricsClient, managementLogger, tracer, rateLimitMgmt),
+			UnlockUser:                prepareEndpoint(management.MakeUnlockUserEndpoint(keycloakComponent), "unlock_user_endpoint", metricsClient, managementLogger, tracer, rateLimitMgmt),
+			DeleteUser:                prepareEndpoint(management.MakeDeleteUserEndpoint(keycloakComponent), "delete_user_endpoint", metricsClient, managementLogger, tracer, rateLimitMgmt),
+			GetUsers:                  prepareEndpoint(management.MakeGetUsersEndpoint(keycloakComponent), "get_users_endpoint", metricsClient, managementLogger, tracer, rateLimitMgmt),
+			GetUserAccountStatus:      prepareEndpoint(management.MakeGetUserAccountStatusEndpoint(keycloakComponent), "get_user_accountstatus", metricsClient, managementLogger, tracer, rateLimitMgmt),
+			GetGroupsOfUser:           prepareEndpoint(management.MakeGetGroupsOfUserEndpoint(keycloakComponent), "get_user_groups", metricsClient, managementLogger, tracer, rateLimitMgmt),
+			AddGroupToUser:            prepareEndpoint(management.MakeAddGroupToUserEndpoint(keycloakComponent), "add_user_group", metricsClient, managementLogger, tracer, rateLimitMgmt),
+			DeleteGroupForUser:        prepareEndpoint(management.MakeDeleteGroupForUserEndpoint(keycloakComponent), "del_user_group", metricsClient, managementLogger, tracer, rateLimitMgmt),
+			GetAvailableTrustIDGroups: prepareEndpoint(management.MakeGetAvailableTrustIDGroupsEndpoint(keycloakComponent), "get_available_trustid_groups_endpoint", metricsClient, managementLogger, tracer, rateLimitMgmt),
+			GetTrustIDGroupsOfUser:    prepareEndpoint(management.MakeGetTrustIDGroupsOfUserEndpoint(keycloakComponent), "get_user_trustid_groups_endpoint", metricsClient, managementLogger, tracer, rateLimitMgmt),
+			SetTrustIDGroupsToUser:    prepareEndpoint(management.MakeSetTrustIDGroupsToUserEndpoint(keycloakComponent), "set_user_trustid_groups_endpoint", metricsClient, managementLogger, tracer, rateLimitMgmt),
+			GetRolesOfUser:            prepareEndpoint(management.MakeGetRolesOfUserEndpoint(keycloakComponent), "get_user_roles", metricsClient, managementLogger, tracer, rateLimitMgmt),
+			AddRoleToUser:             prepareEndpoint(management.MakeAddRoleToUserEndpoint(keycloakComponent), "add_user_role", metricsClient, managementLogger, tracer, rateLimitMgmt),
+			DeleteRoleForUser:         prepareEndpoint(management.MakeDeleteRoleForUserEndpoint(keycloakComponent), "delete_user_role", metricsClient, managementLogger, tracer, rateLimitMgmt),
+
+			GetRoles:   prepareEndpoint(management.MakeGetRolesEndpoint(keycloakComponent), "get_roles_endpoint", metricsClient, managementLogger, tracer, rateLimitMgmt),
+			GetRole:    prepareEndpoint(management.MakeGetRoleEndpoint(keycloakComponent), "get_role_endpoint", metricsClient, managementLogger, tracer, rateLimitMgmt),
+			CreateRole: prepareEndpoint(management.MakeCreateRoleEndpoint(keycloakComponent, managementLogger), "create_role_endpoint", metricsClient, managementLogger, tracer, rateLimitMgmt),
+			UpdateRole: prepareEndpoint(management.MakeUpdateRoleEndpoint(keycloakComponent), "update_role_endpoint", metricsClient, managementLogger, tracer, rateLimitMgmt),
+			DeleteRole: prepareEndpoint(management.MakeDeleteRoleEndpoint(keycloakComponent), "delete_role_endpoint", metricsClient, managementLogger, tracer, rateLimitMgmt),
+
+			GetGroups:            prepareEndpoint(management.MakeGetGroupsEndpoint(keycloakComponent), "get_groups_endpoint", metricsClient, managementLogger, tracer, rateLimitMgmt),
+			CreateGroup:          prepareEndpoint(management.MakeCreateGroupEndpoint(keycloakComponent, managementLogger), "create_group_endpoint", metricsClient, managementLogger, tracer, rateLimitMgmt),
+			CreateSubGroup:       prepareEndpoint(management.MakeCreateSubGroupEndpoint(keycloakComponent, managementLogger), "create_subgroup_endpoint", metricsClient, managementLogger, tracer, rateLimitMgmt),
+			UpdateGroup:          prepareEndpoint(management.MakeUpdateGroupEndpoint(keycloakComponent), "update_group_endpoint", metricsClient, managementLogger, tracer, rateLimitMgmt),
+			MoveGroup:            prepareEndpoint(management.MakeMoveGroupEndpoint(keycloakComponent), "move_group_endpoint", metricsClient, managementLogger, tracer, rateLimitMgmt),
+			DeleteGroup:          prepareEndpoint(management.MakeDeleteGroupEndpoint(keycloakComponent), "delete_group_endpoint", metricsClient, managementLogger, tracer, rateLimitMgmt),
+			GetAuthorizations:    prepareEndpoint(management.MakeGetAuthorizationsEndpoint(keycloakComponent), "get_authorizations_endpoint", metricsClient, managementLogger, tracer, rateLimitMgmt),
+			UpdateAuthorizations: prepareEndpoint(management.MakeUpdateAuthorizationsEndpoint(keycloakComponent), "update_authorizations_endpoint", metricsClient, managementLogger, tracer, rateLimitMgmt),
+
+			GetClientRoles:          prepareEndpoint(management.MakeGetClientRolesEndpoint(keycloakComponent), "get_client_roles_endpoint", metricsClient, managementLogger, tracer, rateLimitMgmt),
+			CreateClientRole:        prepareEndpoint(management.MakeCreateClientRoleEndpoint(keycloakComponent, managementLogger), "create_client_role_endpoint", metricsClient, managementLogger, tracer, rateLimitMgmt),
+			GetClientRoleForUser:    prepareEndpoint(management.MakeGetClientRolesForUserEndpoint(keycloakComponent), "get_client_roles_for_user_endpoint", metricsClient, managementLogger, tracer, rateLimitMgmt),
+			AddClientRoleToUser:     prepareEndpoint(management.MakeAddClientRolesToUserEndpoint(keycloakComponent), "get_client_roles_for_user_endpoint", metricsClient, managementLogger, tracer, rateLimitMgmt),
+			DeleteClientRoleForUser: prepareEndpoint(management.MakeDeleteClientRoleForUserEndpoint(keycloakComponent), "delete_client_role_for_user_endpoint", metricsClient, managementLogger, tracer, rateLimitMgmt),
+
+			ResetPassword:                    prepareEndpointWithoutLogging(management.MakeResetPasswordEndpoint(keycloakComponent), "reset_password_endpoint", metricsClient, tracer, rateLimitMgmt),
+			ExecuteActionsEmail:              prepareEndpoint(management.MakeExecuteActionsEmailEndpoint(keycloakComponent), "execute_actions_email_endpoint", metricsClient, managementLogger, tracer, rateLimitMgmt),
+			SendReminderEmail:                prepareEndpoint(management.MakeSendReminderEmailEndpoint(keycloakComponent), "send_reminder_email_endpoint", metricsClient, managementLogger, tracer, rateLimitMgmt),
+			SendNewEnrolmentCode:             prepareEndpoint(management.MakeSendNewEnrolmentCodeEndpoint(keycloakComponent), "send_new_enrolment_code_endpoint", metricsClient, managementLogger, tracer, rateLimitMgmt),
+			ResetSmsCounter:                  prepareEndpoint(management.MakeResetSmsCounterEndpoint(keycloakComponent), "reset_sms_counter_endpoint", metricsClient, managementLogger, tracer, rateLimitMgmt),
+			CreateRecoveryCode:               prepareEndpoint(management.MakeCreateRecoveryCodeEndpoint(keycloakComponent), "create_recovery_code_endpoint", metricsClient, managementLogger, tracer, rateLimitMgmt),
+			GetCredentialsForUser:            prepareEndpoint(management.MakeGetCredentialsForUserEndpoint(keycloakComponent), "get_credentials_for_user_endpoint", metricsClient, managementLogger, tracer, rateLimitMgmt),
+			DeleteCredentialsForUser:         prepareEndpoint(management.MakeDeleteCredentialsForUserEndpoint(keycloakComponent), "delete_credentials_for_user_endpoint", metricsClient, managementLogger, tracer, rateLimitMgmt),
+			ResetCredentialFailuresForUser:   prepareEndpoint(management.MakeResetCredentialFailuresForUserEndpoint(keycloakComponent), "reset_credential_failures_for_user_endpoint", metricsClient, managementLogger, tracer, rateLimitMgmt),
+			UpdateLabelCredentialForUser:     prepareEndpoint(management.MakeUpdateLabelCredentialForUserEndpoint(keycloakComponent), "update_label_credential_for_user_endpoint", metricsClient, managementLogger, tracer, rateLimitMgmt),
+			MoveCredentialForUser:            prepareEndpoint(management.MakeMoveCredentialForUserEndpoint(keycloakComponent), "move_credential_for_user_endpoint", metricsClient, managementLogger, tracer, rateLimitMgmt),
+			GetCredentialRegistratorsForUser: prepareEndpoint(management.MakeGetCredentialRegistratorsForUserEndpoint(keycloakComponent), "get_credential_registrators_for_user_endpoint", metricsClient, managementLogger, tracer, rateLimitMgmt),
+			RequireReEnrollment:              prepareEndpoint(management.MakeRequireReEnrollmentEndpoint(keycloakComponent), "require_re_enrollment_endpoint", metricsClient, managementLogger, tracer, rateLimitMgmt),
+			ClearUserLoginFailures:           prepareEndpoint(management.MakeClearUserLoginFailures(keycloakComponent), "clear_user_login_failures_endpoint", metricsClient, managementLogger, tracer, rateLimitMgmt),
+			GetAttackDetectionStatus:         prepareEndpoint(management.MakeGetAttackDetectionStatus(keycloakComponent), "get_attack_detection_status_endpoint", metricsClient, managementLogger, tracer, rateLimitMgmt),
+			GetUserSessions:                  prepareEndpoint(management.MakeGetUserSessionsEndpoint(keycloakComponent), "get_user_sessions_endpoint", metricsClient, managementLogger, tracer, rateLimitMgmt),
+			RevokeUserSession:                prepareEndpoint(management.MakeRevokeUserSessionEndpoint(keycloakComponent), "revoke_user_session_endpoint", metricsClient, managementLogger, tracer, rateLimitMgmt),
+			RevokeUserSessions:               prepareEndpoint(management.MakeRevokeUserSessionsEndpoint(keycloakComponent), "revoke_user_sessions_endpoint", metricsClient, managementLogger, tracer, rateLimitMgmt),
+
+			GetRealmCustomConfiguration:    prepareEndpoint(management.MakeGetRealmCustomConfigurationEndpoint(keycloakComponent), "get_realm_custom_config_endpoint", metricsClient, managementLogger, tracer, rateLimitMgmt),
+			UpdateRealmCustomConfiguration: prepareEndpoint(management.MakeUpdateRealmCustomConfigurationEndpoint(keycloakComponent), "update_realm_custom_config_endpoint", metricsClient, managementLogger, tracer, rateLimitMgmt),
+			GetRealmAdminConfiguration:     prepareEndpoint(management.MakeGetRealmAdminConfigurationEndpoint(keycloakComponent), "get_realm_admin_config_endpoint", metricsClient, managementLogger, tracer, rateLimitMgmt),
+			UpdateRealmAdminConfiguration:  prepareEndpoint(management.MakeUpdateRealmAdminConfigurationEndpoint(keycloakComponent), "update_realm_admin_config_endpoint", metricsClient, managementLogger, tracer, rateLimitMgmt),
+
+			GetRealmBackOfficeConfiguration:     prepareEndpoint(management.MakeGetRealmBackOfficeConfigurationEndpoint(keycloakComponent), "get_realm_back_office_config_endpoint", metricsClient, managementLogger, tracer, rateLimitMgmt),
+			UpdateRealmBackOfficeConfiguration:  prepareEndpoint(management.MakeUpdateRealmBackOfficeConfigurationEndpoint(keycloakComponent), "update_realm_back_office_config_endpoint", metricsClient, managementLogger, tracer, rateLimitMgmt),
+			GetUserRealmBackOfficeConfiguration: prepareEndpoint(management.MakeGetUserRealmBackOfficeConfigurationEndpoint(keycloakComponent), "get_user_realm_back_office_config_endpoint", metricsClient, managementLogger, tracer, rateLimitMgmt),
+
+			LinkShadowUser:          prepareEndpoint(management.MakeLinkShadowUserEndpoint(keycloakComponent), "link_shadow_user_endpoint", metricsClient, managementLogger, tracer, rateLimitMgmt),
+			GetFederatedIdentities:  prepareEndpoint(management.MakeGetFederatedIdentitiesEndpoint(keycloakComponent), "get_federated_identities_endpoint", metricsClient, managementLogger, tracer, rateLimitMgmt),
+			DeleteFederatedIdentity: prepareEndpoint(management.MakeDeleteFederatedIdentityEndpoint(keycloakComponent), "delete_federated_identity_endpoint", metricsClient, managementLogger, tracer, rateLimitMgmt),
+
+			GetIdentityProviders:    prepareEndpoint(management.MakeGetIdentityProvidersEndpoint(keycloakComponent), "get_identity_providers_endpoint", metricsClient, managementLogger, tracer, rateLimitMgmt),
+			CreateIdentityProvider:  prepareEndpoint(management.MakeCreateIdentityProviderEndpoint(keycloakComponent), "create_identity_provider_endpoint", metricsClient, managementLogger, tracer, rateLimitMgmt),
+			UpdateIdentityProvider:  prepareEndpoint(management.MakeUpdateIdentityProviderEndpoint(keycloakComponent), "update_identity_provider_endpoint", metricsClient, managementLogger, tracer, rateLimitMgmt),
+			DisableIdentityProvider: prepareEndpoint(management.MakeDisableIdentityProviderEndpoint(keycloakComponent), "disable_identity_provider_endpoint", metricsClient, managementLogger, tracer, rateLimitMgmt),
+
+			GetPasswordPolicy:    prepareEndpoint(management.MakeGetPasswordPolicyEndpoint(keycloakComponent), "get_password_policy_endpoint", metricsClient, managementLogger, tracer, rateLimitMgmt),
+			UpdatePasswordPolicy: prepareEndpoint(management.MakeUpdatePasswordPolicyEndpoint(keycloakComponent), "update_password_policy_endpoint", metricsClient, managementLogger, tracer, rateLimitMgmt),
+
+			GetConfigurationRevisions:  prepareEndpoint(management.MakeGetConfigurationRevisionsEndpoint(keycloakComponent), "get_configuration_revisions_endpoint", metricsClient, managementLogger, tracer, rateLimitMgmt),
+			DiffConfigurationRevisions: prepareEndpoint(management.MakeDiffConfigurationRevisionsEndpoint(keycloakComponent), "diff_configuration_revisions_endpoint", metricsClient, managementLogger, tracer, rateLimitMgmt),
+			RollbackConfiguration:      prepareEndpoint(management.MakeRollbackConfigurationEndpoint(keycloakComponent), "rollback_configuration_endpoint", metricsClient, managementLogger, tracer, rateLimitMgmt),
+
+			GetStatisticsReportSchedules:   prepareEndpoint(management.MakeGetStatisticsReportSchedulesEndpoint(keycloakComponent), "get_statistics_report_schedules_endpoint", metricsClient, managementLogger, tracer, rateLimitMgmt),
+			CreateStatisticsReportSchedule: prepareEndpoint(management.MakeCreateStatisticsReportScheduleEndpoint(keycloakComponent), "create_statistics_report_schedule_endpoint", metricsClient, managementLogger, tracer, rateLimitMgmt),
+			UpdateStatisticsReportSchedule: prepareEndpoint(management.MakeUpdateStatisticsReportScheduleEndpoint(keycloakComponent), "update_statistics_report_schedule_endpoint", metricsClient, managementLogger, tracer, rateLimitMgmt),
+			DeleteStatisticsReportSchedule: prepareEndpoint(management.MakeDeleteStatisticsReportScheduleEndpoint(keycloakComponent), "delete_statistics_report_schedule_endpoint", metricsClient, managementLogger, tracer, rateLimitMgmt),
+
+			ExportUsers:        prepareEndpoint(management.MakeExportUsersEndpoint(keycloakComponent), "export_users_endpoint", metricsClient, managementLogger, tracer, rateLimitMgmt),
+			ImportUsers:        prepareEndpoint(management.MakeImportUsersEndpoint(bulkComponent), "import_users_endpoint", metricsClient, managementLogger, tracer, rateLimitMgmt),
+			ApplyBulkOperation: prepareEndpoint(management.MakeApplyBulkOperationEndpoint(bulkComponent), "apply_bulk_operation_endpoint", metricsClient, managementLogger, tracer, rateLimitMgmt),
+			GetBulkJob:         prepareEndpoint(management.MakeGetBulkJobEndpoint(bulkComponent), "get_bulk_job_endpoint", metricsClient, managementLogger, tracer, rateLimitMgmt),
+
+			SearchUsers: prepareEndpoint(management.MakeSearchUsersEndpoint(searchComponent), "search_users_endpoint", metricsClient, managementLogger, tracer, rateLimitMgmt),
+			IndexUsers:  prepareEndpoint(management.MakeIndexUsersEndpoint(searchComponent), "index_users_endpoint", metricsClient, managementLogger, tracer, rateLimitMgmt),
+
+			RestoreUser: prepareEndpoint(management.MakeRestoreUserEndpoint(softDeletionComponent), "restore_user_endpoint", metricsClient, managementLogger, tracer, rateLimitMgmt),
+
+			GetApprovalPolicy:    prepareEndpoint(management.MakeGetApprovalPolicyEndpoint(approvalComponent), "get_approval_policy_endpoint", metricsClient, managementLogger, tracer, rateLimitMgmt),
+			UpdateApprovalPolicy: prepareEndpoint(management.MakeUpdateApprovalPolicyEndpoint(approvalComponent), "update_approval_policy_endpoint", metricsClient, managementLogger, tracer, rateLimitMgmt),
+			GetApprovalRequests:  prepareEndpoint(management.MakeGetApprovalRequestsEndpoint(approvalComponent), "get_approval_requests_endpoint", metricsClient, managementLogger, tracer, rateLimitMgmt),
+			ApproveRequest:       prepareEndpoint(management.MakeApproveRequestEndpoint(approvalComponent), "approve_request_endpoint", metricsClient, managementLogger, tracer, rateLimitMgmt),
+			RejectRequest:        prepareEndpoint(management.MakeRejectRequestEndpoint(approvalComponent), "reject_request_endpoint", metricsClient, managementLogger, tracer, rateLimitMgmt),
+
+			AddTemporaryGroupToUser:         prepareEndpoint(management.MakeAddTemporaryGroupToUserEndpoint(grantsComponent), "add_temporary_group_to_user_endpoint", metricsClient, managementLogger, tracer, rateLimitMgmt),
+			SetTemporaryTrustIDGroupsToUser: prepareEndpoint(management.MakeSetTemporaryTrustIDGroupsToUserEndpoint(grantsComponent), "set_temporary_trustid_groups_to_user_endpoint", metricsClient, managementLogger, tracer, rateLimitMgmt),
+			AddTemporaryClientRolesToUser:   prepareEndpoint(management.MakeAddTemporaryClientRolesToUserEndpoint(grantsComponent), "add_temporary_client_roles_to_user_endpoint", metricsClient, managementLogger, tracer, rateLimitMgmt),
+
+			GetScheduledUserChanges:   prepareEndpoint(management.MakeGetScheduledUserChangesEndpoint(scheduledChangesComponent), "get_scheduled_user_changes_endpoint", metricsClient, managementLogger, tracer, rateLimitMgmt),
+			ScheduleUserChange:        prepareEndpoint(management.MakeScheduleUserChangeEndpoint(scheduledChangesComponent), "schedule_user_change_endpoint", metricsClient, managementLogger, tracer, rateLimitMgmt),
+			CancelScheduledUserChange: prepareEndpoint(management.MakeCancelScheduledUserChangeEndpoint(scheduledChangesComponent), "cancel_scheduled_user_change_endpoint", metricsClient, managementLogger, tracer, rateLimitMgmt),
+
+			ImpersonateUser: prepareEndpoint(management.MakeImpersonateUserEndpoint(impersonationComponent), "impersonate_user_endpoint", metricsClient, managementLogger, tracer, rateLimitMgmt),
+
+			ProvisionRealm: prepareEndpoint(management.MakeProvisionRealmEndpoint(provisioningComponent), "provision_realm_endpoint", metricsClient, managementLogger, tracer, rateLimitMgmt),
+
+			GetUserAuthorizationsReport:   prepareEndpoint(management.MakeGetUserAuthorizationsReportEndpoint(authorizationsReportComponent), "get_user_authorizations_report_endpoint", metricsClient, managementLogger, tracer, rateLimitMgmt),
+			GetTargetAuthorizationsReport: prepareEndpoint(management.MakeGetTargetAuthorizationsReportEndpoint(authorizationsReportComponent), "get_target_authorizations_report_endpoint", metricsClient, managementLogger, tracer, rateLimitMgmt),
 		}
 	}
 
@@ -744,36 +1066,43 @@ func main() {
 		var accountLogger = log.With(logger, "svc", "account")
 
 		// Configure events db module
-		eventsDBModule := configureEventsDbModule(baseEventsDBModule, influxMetrics, accountLogger, tracer)
+		eventsDBModule := configureEventsDbModule(baseEventsDBModule, metricsClient, accountLogger, tracer)
 
 		// module for retrieving the custom configuration
 		var configDBModule keycloakb.ConfigurationDBModule
 		{
 			configDBModule = keycloakb.NewConfigurationDBModule(configurationRoDBConn, accountLogger)
-			configDBModule = keycloakb.MakeConfigurationDBModuleInstrumentingMW(influxMetrics.NewHistogram("configDB_module"))(configDBModule)
+			configDBModule = keycloakb.MakeConfigurationDBModuleInstrumentingMW(metricsClient.NewHistogram("configDB_module"))(configDBModule)
 		}
 
 		// module for storing and retrieving details of the self-registered users
 		var usersDBModule = keycloakb.NewUsersDetailsDBModule(usersRwDBConn, aesEncryption, accountLogger)
 
 		// new module for account service
-		accountComponent := account.NewComponent(keycloakClient.AccountClient(), eventsDBModule, configDBModule, usersDBModule, accountLogger)
+		accountComponent := account.NewComponent(keycloakClient.AccountClient(), eventsDBModule, configDBModule, usersDBModule, passwordValidator, accountLogger)
+		if softDeletionGracePeriod > 0 {
+			accountComponent = account.MakeSoftDeletionComponentMW(userSoftDeletion, eventsDBModule, accountLogger)(accountComponent)
+		}
 		accountComponent = account.MakeAuthorizationAccountComponentMW(log.With(accountLogger, "mw", "endpoint"), configDBModule)(accountComponent)
 
 		var rateLimitAccount = rateLimit[RateKeyAccount]
 		accountEndpoints = account.Endpoints{
-			GetAccount:                prepareEndpoint(account.MakeGetAccountEndpoint(accountComponent), "get_account", influxMetrics, accountLogger, tracer, rateLimitAccount),
-			UpdateAccount:             prepareEndpoint(account.MakeUpdateAccountEndpoint(accountComponent), "update_account", influxMetrics, accountLogger, tracer, rateLimitAccount),
-			DeleteAccount:             prepareEndpoint(account.MakeDeleteAccountEndpoint(accountComponent), "delete_account", influxMetrics, accountLogger, tracer, rateLimitAccount),
-			UpdatePassword:            prepareEndpointWithoutLogging(account.MakeUpdatePasswordEndpoint(accountComponent), "update_password", influxMetrics, tracer, rateLimitAccount),
-			GetCredentials:            prepareEndpoint(account.MakeGetCredentialsEndpoint(accountComponent), "get_credentials", influxMetrics, accountLogger, tracer, rateLimitAccount),
-			GetCredentialRegistrators: prepareEndpoint(account.MakeGetCredentialRegistratorsEndpoint(accountComponent), "get_credential_registrators", influxMetrics, accountLogger, tracer, rateLimitAccount),
-			DeleteCredential:          prepareEndpoint(account.MakeDeleteCredentialEndpoint(accountComponent), "delete_credential", influxMetrics, accountLogger, tracer, rateLimitAccount),
-			UpdateLabelCredential:     prepareEndpoint(account.MakeUpdateLabelCredentialEndpoint(accountComponent), "update_label_credential", influxMetrics, accountLogger, tracer, rateLimitAccount),
-			MoveCredential:            prepareEndpoint(account.MakeMoveCredentialEndpoint(accountComponent), "move_credential", influxMetrics, accountLogger, tracer, rateLimitAccount),
-			GetConfiguration:          prepareEndpoint(account.MakeGetConfigurationEndpoint(accountComponent), "get_configuration", influxMetrics, accountLogger, tracer, rateLimitAccount),
-			SendVerifyEmail:           prepareEndpoint(account.MakeSendVerifyEmailEndpoint(accountComponent), "send_verify_email", influxMetrics, accountLogger, tracer, rateLimitAccount),
-			SendVerifyPhoneNumber:     prepareEndpoint(account.MakeSendVerifyPhoneNumberEndpoint(accountComponent), "send_verify_phone_number", influxMetrics, accountLogger, tracer, rateLimitAccount),
+			GetAccount:                prepareEndpoint(account.MakeGetAccountEndpoint(accountComponent), "get_account", metricsClient, accountLogger, tracer, rateLimitAccount),
+			UpdateAccount:             prepareEndpoint(account.MakeUpdateAccountEndpoint(accountComponent), "update_account", metricsClient, accountLogger, tracer, rateLimitAccount),
+			DeleteAccount:             prepareEndpoint(account.MakeDeleteAccountEndpoint(accountComponent), "delete_account", metricsClient, accountLogger, tracer, rateLimitAccount),
+			UpdatePassword:            prepareEndpointWithoutLogging(account.MakeUpdatePasswordEndpoint(accountComponent), "update_password", metricsClient, tracer, rateLimitAccount),
+			GetCredentials:            prepareEndpoint(account.MakeGetCredentialsEndpoint(accountComponent), "get_credentials", metricsClient, accountLogger, tracer, rateLimitAccount),
+			GetCredentialRegistrators: prepareEndpoint(account.MakeGetCredentialRegistratorsEndpoint(accountComponent), "get_credential_registrators", metricsClient, accountLogger, tracer, rateLimitAccount),
+			DeleteCredential:          prepareEndpoint(account.MakeDeleteCredentialEndpoint(accountComponent), "delete_credential", metricsClient, accountLogger, tracer, rateLimitAccount),
+			UpdateLabelCredential:     prepareEndpoint(account.MakeUpdateLabelCredentialEndpoint(accountComponent), "update_label_credential", metricsClient, accountLogger, tracer, rateLimitAccount),
+			MoveCredential:            prepareEndpoint(account.MakeMoveCredentialEndpoint(accountComponent), "move_credential", metricsClient, accountLogger, tracer, rateLimitAccount),
+			GetConfiguration:          prepareEndpoint(account.MakeGetConfigurationEndpoint(accountComponent), "get_configuration", metricsClient, accountLogger, tracer, rateLimitAccount),
+			SendVerifyEmail:           prepareEndpoint(account.MakeSendVerifyEmailEndpoint(accountComponent), "send_verify_email", metricsClient, accountLogger, tracer, rateLimitAccount),
+			SendVerifyPhoneNumber:     prepareEndpoint(account.MakeSendVerifyPhoneNumberEndpoint(accountComponent), "send_verify_phone_number", metricsClient, accountLogger, tracer, rateLimitAccount),
+			GetSessions:               prepareEndpoint(account.MakeGetSessionsEndpoint(accountComponent), "get_sessions", metricsClient, accountLogger, tracer, rateLimitAccount),
+			DeleteSession:             prepareEndpoint(account.MakeDeleteSessionEndpoint(accountComponent), "delete_session", metricsClient, accountLogger, tracer, rateLimitAccount),
+			DeleteSessions:            prepareEndpoint(account.MakeDeleteSessionsEndpoint(accountComponent), "delete_sessions", metricsClient, accountLogger, tracer, rateLimitAccount),
+			ValidatePassword:          prepareEndpoint(account.MakeValidatePasswordEndpoint(accountComponent), "validate_password", metricsClient, accountLogger, tracer, rateLimitAccount),
 		}
 	}
 
@@ -786,7 +1115,7 @@ func main() {
 		var configDBModule keycloakb.ConfigurationDBModule
 		{
 			configDBModule = keycloakb.NewConfigurationDBModule(configurationRoDBConn, mobileLogger)
-			configDBModule = keycloakb.MakeConfigurationDBModuleInstrumentingMW(influxMetrics.NewHistogram("configDB_module"))(configDBModule)
+			configDBModule = keycloakb.MakeConfigurationDBModuleInstrumentingMW(metricsClient.NewHistogram("configDB_module"))(configDBModule)
 		}
 
 		// module for storing and retrieving details of the self-registered users
@@ -798,7 +1127,7 @@ func main() {
 
 		var rateLimitMobile = rateLimit[RateKeyMobile]
 		mobileEndpoints = mobile.Endpoints{
-			GetUserInformation: prepareEndpoint(mobile.MakeGetUserInformationEndpoint(mobileComponent), "get_user_information", influxMetrics, mobileLogger, tracer, rateLimitMobile),
+			GetUserInformation: prepareEndpoint(mobile.MakeGetUserInformationEndpoint(mobileComponent), "get_user_information", metricsClient, mobileLogger, tracer, rateLimitMobile),
 		}
 	}
 
@@ -809,16 +1138,16 @@ func main() {
 			var registerLogger = log.With(logger, "svc", "register")
 
 			// Configure events db module
-			eventsDBModule := configureEventsDbModule(baseEventsDBModule, influxMetrics, registerLogger, tracer)
+			eventsDBModule := configureEventsDbModule(baseEventsDBModule, metricsClient, registerLogger, tracer)
 
 			// module for storing and retrieving the custom configuration
-			var configDBModule = createConfigurationDBModule(configurationRwDBConn, influxMetrics, registerLogger)
+			var configDBModule = createConfigurationDBModule(configurationRwDBConn, metricsClient, registerLogger)
 
 			// module for storing and retrieving details of the self-registered users
 			var usersDBModule = keycloakb.NewUsersDetailsDBModule(usersRwDBConn, aesEncryption, registerLogger)
 
 			// new module for register service
-			registerComponent, err := register.NewComponent(keycloakPublicURL, registerRealm, ssePublicURL, registerEnduserClientID, registerEnduserGroups, keycloakClient, oidcTokenProvider, usersDBModule, configDBModule, eventsDBModule, registerLogger)
+			registerComponent, err := register.NewComponent(keycloakPublicURL, registerRealm, ssePublicURL, registerEnduserClientID, registerEnduserGroups, keycloakClient, oidcTokenProvider, usersDBModule, configDBModule, eventsDBModule, passwordValidator, registerLogger)
 			if err != nil {
 				registerLogger.Error(ctx, "msg", "Can't initialize register component. Check the provided group names", "err", err.Error())
 				return
@@ -827,8 +1156,9 @@ func main() {
 
 			var rateLimitRegister = rateLimit[RateKeyRegister]
 			registerEndpoints = register.Endpoints{
-				RegisterUser:     prepareEndpoint(register.MakeRegisterUserEndpoint(registerComponent), "register_user", influxMetrics, registerLogger, tracer, rateLimitRegister),
-				GetConfiguration: prepareEndpoint(register.MakeGetConfigurationEndpoint(registerComponent), "get_configuration", influxMetrics, registerLogger, tracer, rateLimitRegister),
+				RegisterUser:     prepareEndpoint(register.MakeRegisterUserEndpoint(registerComponent), "register_user", metricsClient, registerLogger, tracer, rateLimitRegister),
+				GetConfiguration: prepareEndpoint(register.MakeGetConfigurationEndpoint(registerComponent), "get_configuration", metricsClient, registerLogger, tracer, rateLimitRegister),
+				ValidatePassword: prepareEndpoint(register.MakeValidatePasswordEndpoint(registerComponent), "validate_password", metricsClient, registerLogger, tracer, rateLimitRegister),
 			}
 		}
 	}
@@ -844,7 +1174,7 @@ func main() {
 		var kycLogger = log.With(logger, "svc", "kyc")
 
 		// Configure events db module
-		eventsDBModule := configureEventsDbModule(baseEventsDBModule, influxMetrics, kycLogger, tracer)
+		eventsDBModule := configureEventsDbModule(baseEventsDBModule, metricsClient, kycLogger, tracer)
 
 		// module for storing and retrieving details of the users
 		var usersDBModule = keycloakb.NewUsersDetailsDBModule(usersRwDBConn, aesEncryption, kycLogger)
@@ -862,11 +1192,11 @@ func main() {
 
 		var rateLimitKyc = rateLimit[RateKeyKYC]
 		kycEndpoints = kyc.Endpoints{
-			GetActions:                     prepareEndpoint(kyc.MakeGetActionsEndpoint(kycComponent), "register_get_actions", influxMetrics, kycLogger, tracer, rateLimitKyc),
-			GetUserInSocialRealm:           prepareEndpoint(kyc.MakeGetUserInSocialRealmEndpoint(kycComponent), "get_user_in_social_realm", influxMetrics, kycLogger, tracer, rateLimitKyc),
-			GetUserByUsernameInSocialRealm: prepareEndpoint(kyc.MakeGetUserByUsernameInSocialRealmEndpoint(kycComponent), "get_user_by_usernamein_social_realm", influxMetrics, kycLogger, tracer, rateLimitKyc),
-			ValidateUserInSocialRealm:      prepareEndpoint(kyc.MakeValidateUserInSocialRealmEndpoint(kycComponent), "validate_userin_social_realm", influxMetrics, kycLogger, tracer, rateLimitKyc),
-			ValidateUser:                   prepareEndpoint(kyc.MakeValidateUserEndpoint(kycComponent), "validate_user", influxMetrics, kycLogger, tracer, rateLimitKyc),
+			GetActions:                     prepareEndpoint(kyc.MakeGetActionsEndpoint(kycComponent), "register_get_actions", metricsClient, kycLogger, tracer, rateLimitKyc),
+			GetUserInSocialRealm:           prepareEndpoint(kyc.MakeGetUserInSocialRealmEndpoint(kycComponent), "get_user_in_social_realm", metricsClient, kycLogger, tracer, rateLimitKyc),
+			GetUserByUsernameInSocialRealm: prepareEndpoint(kyc.MakeGetUserByUsernameInSocialRealmEndpoint(kycComponent), "get_user_by_usernamein_social_realm", metricsClient, kycLogger, tracer, rateLimitKyc),
+			ValidateUserInSocialRealm:      prepareEndpoint(kyc.MakeValidateUserInSocialRealmEndpoint(kycComponent), "validate_userin_social_realm", metricsClient, kycLogger, tracer, rateLimitKyc),
+			ValidateUser:                   prepareEndpoint(kyc.MakeValidateUserEndpoint(kycComponent), "validate_user", metricsClient, kycLogger, tracer, rateLimitKyc),
 		}
 	}
 
@@ -891,6 +1221,11 @@ func main() {
 		route.Handle("/", commonhttp.MakeVersionHandler(keycloakb.ComponentName, ComponentID, keycloakb.Version, Environment, GitCommit))
 		route.Handle(pathHealthCheck, healthChecker.MakeHandler())
 
+		// Prometheus metrics.
+		if prometheusMetrics != nil {
+			route.Handle(pathMetrics, prometheusMetrics.Handler()).Methods("GET")
+		}
+
 		// Event.
 		var eventSubroute = route.PathPrefix("/event").Subrouter()
 
@@ -959,6 +1294,14 @@ func main() {
 		var getStatisticsAuthenticationsHandler = configureStatisiticsHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(statisticsEndpoints.GetStatisticsAuthentications)
 		var getStatisticsAuthenticationsLogHandler = configureStatisiticsHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(statisticsEndpoints.GetStatisticsAuthenticationsLog)
 		var getMigrationReportHandler = configureStatisiticsHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(statisticsEndpoints.GetMigrationReport)
+		var startMigrationReportHandler = configureStatisiticsHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(statisticsEndpoints.StartMigrationReport)
+		var getMigrationReportJobHandler = configureStatisiticsHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(statisticsEndpoints.GetMigrationReportJob)
+		var startStatisticsExportHandler = configureStatisiticsHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(statisticsEndpoints.StartStatisticsExport)
+		var getStatisticsExportJobHandler = configureStatisiticsHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(statisticsEndpoints.GetStatisticsExportJob)
+		var getStatisticsExportFileHandler = configureStatisiticsHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(statisticsEndpoints.GetStatisticsExportFile)
+		var getStatisticsAuthenticationsByCountryHandler = configureStatisiticsHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(statisticsEndpoints.GetStatisticsAuthenticationsByCountry)
+		var getStatisticsAuthenticationsByDeviceHandler = configureStatisiticsHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(statisticsEndpoints.GetStatisticsAuthenticationsByDevice)
+		var getStatisticsUserAuthenticationsHandler = configureStatisiticsHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(statisticsEndpoints.GetStatisticsUserAuthentications)
 
 		route.Path("/statistics/actions").Methods("GET").Handler(getStatisticsActionsHandler)
 		route.Path("/statistics/realms/{realm}").Methods("GET").Handler(getStatisticsHandler)
@@ -967,6 +1310,14 @@ func main() {
 		route.Path("/statistics/realms/{realm}/authentications-graph").Methods("GET").Handler(getStatisticsAuthenticationsHandler)
 		route.Path("/statistics/realms/{realm}/authentications-log").Methods("GET").Handler(getStatisticsAuthenticationsLogHandler)
 		route.Path("/statistics/realms/{realm}/migration").Methods("GET").Handler(getMigrationReportHandler)
+		route.Path("/statistics/realms/{realm}/migration/jobs").Methods("POST").Handler(startMigrationReportHandler)
+		route.Path("/statistics/realms/{realm}/migration/jobs/{jobID}").Methods("GET").Handler(getMigrationReportJobHandler)
+		route.Path("/statistics/realms/{realm}/export/jobs").Methods("POST").Handler(startStatisticsExportHandler)
+		route.Path("/statistics/realms/{realm}/export/jobs/{jobID}").Methods("GET").Handler(getStatisticsExportJobHandler)
+		route.Path("/statistics/realms/{realm}/export/jobs/{jobID}/file").Methods("GET").Handler(getStatisticsExportFileHandler)
+		route.Path("/statistics/realms/{realm}/authentications-countries").Methods("GET").Handler(getStatisticsAuthenticationsByCountryHandler)
+		route.Path("/statistics/realms/{realm}/authentications-devices").Methods("GET").Handler(getStatisticsAuthenticationsByDeviceHandler)
+		route.Path("/statistics/realms/{realm}/users/{userID}/authentications").Methods("GET").Handler(getStatisticsUserAuthenticationsHandler)
 
 		// Events
 		var getEventsActionsHandler = configureEventsHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(eventsEndpoints.GetActions)
@@ -987,6 +1338,11 @@ func main() {
 
 		var getClientsHandler = configureManagementHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(managementEndpoints.GetClients)
 		var getClientHandler = configureManagementHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(managementEndpoints.GetClient)
+		var createClientHandler = configureManagementHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(managementEndpoints.CreateClient)
+		var updateClientHandler = configureManagementHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(managementEndpoints.UpdateClient)
+		var deleteClientHandler = configureManagementHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(managementEndpoints.DeleteClient)
+		var regenerateClientSecretHandler = configureManagementHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(managementEndpoints.RegenerateClientSecret)
+		var updateClientRedirectURIsHandler = configureManagementHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(managementEndpoints.UpdateClientRedirectURIs)
 
 		var getRequiredActionsHandler = configureManagementHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(managementEndpoints.GetRequiredActions)
 
@@ -998,6 +1354,8 @@ func main() {
 		var deleteUserHandler = configureManagementHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(managementEndpoints.DeleteUser)
 		var getUsersHandler = configureManagementHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(managementEndpoints.GetUsers)
 		var getRolesForUserHandler = configureManagementHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(managementEndpoints.GetRolesOfUser)
+		var addRoleToUserHandler = configureManagementHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(managementEndpoints.AddRoleToUser)
+		var deleteRoleForUserHandler = configureManagementHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(managementEndpoints.DeleteRoleForUser)
 		var getGroupsForUserHandler = configureManagementHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(managementEndpoints.GetGroupsOfUser)
 		var addGroupToUserHandler = configureManagementHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(managementEndpoints.AddGroupToUser)
 		var deleteGroupForUserHandler = configureManagementHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(managementEndpoints.DeleteGroupForUser)
@@ -1008,14 +1366,21 @@ func main() {
 
 		var getClientRoleForUserHandler = configureManagementHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(managementEndpoints.GetClientRoleForUser)
 		var addClientRoleToUserHandler = configureManagementHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(managementEndpoints.AddClientRoleToUser)
+		var deleteClientRoleForUserHandler = configureManagementHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(managementEndpoints.DeleteClientRoleForUser)
 
 		var getRolesHandler = configureManagementHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(managementEndpoints.GetRoles)
 		var getRoleHandler = configureManagementHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(managementEndpoints.GetRole)
+		var createRoleHandler = configureManagementHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(managementEndpoints.CreateRole)
+		var updateRoleHandler = configureManagementHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(managementEndpoints.UpdateRole)
+		var deleteRoleHandler = configureManagementHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(managementEndpoints.DeleteRole)
 		var getClientRolesHandler = configureManagementHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(managementEndpoints.GetClientRoles)
 		var createClientRolesHandler = configureManagementHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(managementEndpoints.CreateClientRole)
 
 		var getGroupsHandler = configureManagementHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(managementEndpoints.GetGroups)
 		var createGroupHandler = configureManagementHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(managementEndpoints.CreateGroup)
+		var createSubGroupHandler = configureManagementHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(managementEndpoints.CreateSubGroup)
+		var updateGroupHandler = configureManagementHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(managementEndpoints.UpdateGroup)
+		var moveGroupHandler = configureManagementHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(managementEndpoints.MoveGroup)
 		var deleteGroupHandler = configureManagementHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(managementEndpoints.DeleteGroup)
 		var getAuthorizationsHandler = configureManagementHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(managementEndpoints.GetAuthorizations)
 		var updateAuthorizationsHandler = configureManagementHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(managementEndpoints.UpdateAuthorizations)
@@ -1031,8 +1396,15 @@ func main() {
 		var getCredentialsForUserHandler = configureManagementHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(managementEndpoints.GetCredentialsForUser)
 		var deleteCredentialsForUserHandler = configureManagementHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(managementEndpoints.DeleteCredentialsForUser)
 		var resetCredentialFailuresForUserHandler = configureManagementHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(managementEndpoints.ResetCredentialFailuresForUser)
+		var updateLabelCredentialForUserHandler = configureManagementHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(managementEndpoints.UpdateLabelCredentialForUser)
+		var moveCredentialForUserHandler = configureManagementHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(managementEndpoints.MoveCredentialForUser)
+		var getCredentialRegistratorsForUserHandler = configureManagementHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(managementEndpoints.GetCredentialRegistratorsForUser)
+		var requireReEnrollmentHandler = configureManagementHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(managementEndpoints.RequireReEnrollment)
 		var clearUserLoginFailuresHandler = configureManagementHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(managementEndpoints.ClearUserLoginFailures)
 		var getAttackDetectionStatusHandler = configureManagementHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(managementEndpoints.GetAttackDetectionStatus)
+		var getUserSessionsHandler = configureManagementHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(managementEndpoints.GetUserSessions)
+		var revokeUserSessionHandler = configureManagementHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(managementEndpoints.RevokeUserSession)
+		var revokeUserSessionsHandler = configureManagementHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(managementEndpoints.RevokeUserSessions)
 
 		var getRealmCustomConfigurationHandler = configureManagementHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(managementEndpoints.GetRealmCustomConfiguration)
 		var updateRealmCustomConfigurationHandler = configureManagementHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(managementEndpoints.UpdateRealmCustomConfiguration)
@@ -1044,17 +1416,63 @@ func main() {
 		var getUserRealmBackOfficeConfigurationHandler = configureManagementHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(managementEndpoints.GetUserRealmBackOfficeConfiguration)
 
 		var linkShadowUserHandler = configureManagementHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(managementEndpoints.LinkShadowUser)
+		var getFederatedIdentitiesHandler = configureManagementHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(managementEndpoints.GetFederatedIdentities)
+		var deleteFederatedIdentityHandler = configureManagementHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(managementEndpoints.DeleteFederatedIdentity)
+
+		var getIdentityProvidersHandler = configureManagementHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(managementEndpoints.GetIdentityProviders)
+		var createIdentityProviderHandler = configureManagementHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(managementEndpoints.CreateIdentityProvider)
+		var updateIdentityProviderHandler = configureManagementHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(managementEndpoints.UpdateIdentityProvider)
+		var disableIdentityProviderHandler = configureManagementHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(managementEndpoints.DisableIdentityProvider)
+		var getPasswordPolicyHandler = configureManagementHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(managementEndpoints.GetPasswordPolicy)
+		var updatePasswordPolicyHandler = configureManagementHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(managementEndpoints.UpdatePasswordPolicy)
+		var getConfigurationRevisionsHandler = configureManagementHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(managementEndpoints.GetConfigurationRevisions)
+		var diffConfigurationRevisionsHandler = configureManagementHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(managementEndpoints.DiffConfigurationRevisions)
+		var rollbackConfigurationHandler = configureManagementHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(managementEndpoints.RollbackConfiguration)
+
+		var getStatisticsReportSchedulesHandler = configureManagementHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(managementEndpoints.GetStatisticsReportSchedules)
+		var createStatisticsReportScheduleHandler = configureManagementHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(managementEndpoints.CreateStatisticsReportSchedule)
+		var updateStatisticsReportScheduleHandler = configureManagementHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(managementEndpoints.UpdateStatisticsReportSchedule)
+		var deleteStatisticsReportScheduleHandler = configureManagementHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(managementEndpoints.DeleteStatisticsReportSchedule)
+
+		var exportUsersHandler = configureManagementHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(managementEndpoints.ExportUsers)
+		var importUsersHandler = configureManagementHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(managementEndpoints.ImportUsers)
+		var applyBulkOperationHandler = configureManagementHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(managementEndpoints.ApplyBulkOperation)
+		var getBulkJobHandler = configureManagementHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(managementEndpoints.GetBulkJob)
+		var searchUsersHandler = configureManagementHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(managementEndpoints.SearchUsers)
+		var indexUsersHandler = configureManagementHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(managementEndpoints.IndexUsers)
+		var restoreUserHandler = configureManagementHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(managementEndpoints.RestoreUser)
+		var getApprovalPolicyHandler = configureManagementHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(managementEndpoints.GetApprovalPolicy)
+		var updateApprovalPolicyHandler = configureManagementHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(managementEndpoints.UpdateApprovalPolicy)
+		var getApprovalRequestsHandler = configureManagementHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(managementEndpoints.GetApprovalRequests)
+		var approveRequestHandler = configureManagementHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(managementEndpoints.ApproveRequest)
+		var rejectRequestHandler = configureManagementHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(managementEndpoints.RejectRequest)
+		var addTemporaryGroupToUserHandler = configureManagementHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(managementEndpoints.AddTemporaryGroupToUser)
+		var setTemporaryTrustIDGroupsHandler = configureManagementHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(managementEndpoints.SetTemporaryTrustIDGroupsToUser)
+		var addTemporaryClientRolesHandler = configureManagementHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(managementEndpoints.AddTemporaryClientRolesToUser)
+		var getScheduledUserChangesHandler = configureManagementHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(managementEndpoints.GetScheduledUserChanges)
+		var scheduleUserChangeHandler = configureManagementHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(managementEndpoints.ScheduleUserChange)
+		var cancelScheduledUserChangeHandler = configureManagementHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(managementEndpoints.CancelScheduledUserChange)
+		var impersonateUserHandler = configureManagementHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(managementEndpoints.ImpersonateUser)
+		var provisionRealmHandler = configureManagementHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(managementEndpoints.ProvisionRealm)
+		var getUserAuthorizationsReportHandler = configureManagementHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(managementEndpoints.GetUserAuthorizationsReport)
+		var getTargetAuthorizationsReportHandler = configureManagementHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(managementEndpoints.GetTargetAuthorizationsReport)
 
 		// actions
 		managementSubroute.Path("/actions").Methods("GET").Handler(getManagementActionsHandler)
 
 		// realms
 		managementSubroute.Path("/realms").Methods("GET").Handler(getRealmsHandler)
+		managementSubroute.Path("/realms").Methods("POST").Handler(provisionRealmHandler)
 		managementSubroute.Path("/realms/{realm}").Methods("GET").Handler(getRealmHandler)
 
 		// clients
 		managementSubroute.Path("/realms/{realm}/clients").Methods("GET").Handler(getClientsHandler)
+		managementSubroute.Path("/realms/{realm}/clients").Methods("POST").Handler(createClientHandler)
 		managementSubroute.Path("/realms/{realm}/clients/{clientID}").Methods("GET").Handler(getClientHandler)
+		managementSubroute.Path("/realms/{realm}/clients/{clientID}").Methods("PUT").Handler(updateClientHandler)
+		managementSubroute.Path("/realms/{realm}/clients/{clientID}").Methods("DELETE").Handler(deleteClientHandler)
+		managementSubroute.Path("/realms/{realm}/clients/{clientID}/secret").Methods("POST").Handler(regenerateClientSecretHandler)
+		managementSubroute.Path("/realms/{realm}/clients/{clientID}/redirect-uris").Methods("PUT").Handler(updateClientRedirectURIsHandler)
 
 		// required-actions
 		managementSubroute.Path("/realms/{realm}/required-actions").Methods("GET").Handler(getRequiredActionsHandler)
@@ -1065,22 +1483,38 @@ func main() {
 		// users
 		managementSubroute.Path("/realms/{realm}/users").Methods("GET").Handler(getUsersHandler)
 		managementSubroute.Path("/realms/{realm}/users").Methods("POST").Handler(createUserHandler)
+		managementSubroute.Path("/realms/{realm}/users/export").Methods("GET").Handler(exportUsersHandler)
+		managementSubroute.Path("/realms/{realm}/users/import").Methods("POST").Handler(importUsersHandler)
+		managementSubroute.Path("/realms/{realm}/users/bulk").Methods("POST").Handler(applyBulkOperationHandler)
+		managementSubroute.Path("/realms/{realm}/users/search").Methods("GET").Handler(searchUsersHandler)
+		managementSubroute.Path("/realms/{realm}/users/search-index").Methods("POST").Handler(indexUsersHandler)
 		managementSubroute.Path("/realms/{realm}/users/{userID}").Methods("GET").Handler(getUserHandler)
 		managementSubroute.Path("/realms/{realm}/users/{userID}").Methods("PUT").Handler(updateUserHandler)
 		managementSubroute.Path("/realms/{realm}/users/{userID}").Methods("DELETE").Handler(deleteUserHandler)
+		managementSubroute.Path("/realms/{realm}/users/{userID}/restore").Methods("POST").Handler(restoreUserHandler)
 		managementSubroute.Path("/realms/{realm}/users/{userID}/lock").Methods("PUT").Handler(lockUserHandler)
 		managementSubroute.Path("/realms/{realm}/users/{userID}/unlock").Methods("PUT").Handler(unlockUserHandler)
+		managementSubroute.Path("/realms/{realm}/users/{userID}/scheduled-changes").Methods("GET").Handler(getScheduledUserChangesHandler)
+		managementSubroute.Path("/realms/{realm}/users/{userID}/scheduled-changes").Methods("POST").Handler(scheduleUserChangeHandler)
+		managementSubroute.Path("/realms/{realm}/users/{userID}/scheduled-changes/{changeID}").Methods("DELETE").Handler(cancelScheduledUserChangeHandler)
+		managementSubroute.Path("/realms/{realm}/users/{userID}/impersonation").Methods("POST").Handler(impersonateUserHandler)
 		managementSubroute.Path("/realms/{realm}/users/{userID}/groups").Methods("GET").Handler(getGroupsForUserHandler)
+		managementSubroute.Path("/realms/{realm}/users/{userID}/groups/{groupID}").Methods("POST").Queries("expiresAt", "{expiresAt}").Handler(addTemporaryGroupToUserHandler)
 		managementSubroute.Path("/realms/{realm}/users/{userID}/groups/{groupID}").Methods("POST").Handler(addGroupToUserHandler)
 		managementSubroute.Path("/realms/{realm}/users/{userID}/groups/{groupID}").Methods("DELETE").Handler(deleteGroupForUserHandler)
 		managementSubroute.Path("/realms/{realm}/users/{userID}/roles").Methods("GET").Handler(getRolesForUserHandler)
+		managementSubroute.Path("/realms/{realm}/users/{userID}/roles/{roleID}").Methods("POST").Handler(addRoleToUserHandler)
+		managementSubroute.Path("/realms/{realm}/users/{userID}/roles/{roleID}").Methods("DELETE").Handler(deleteRoleForUserHandler)
 		managementSubroute.Path("/realms/{realm}/users/{userID}/status").Methods("GET").Handler(getUserAccountStatusHandler)
 		managementSubroute.Path("/realms/{realm}/users/{userID}/trustIdGroups").Methods("GET").Handler(getTrustIDGroupsOfUserHandler)
+		managementSubroute.Path("/realms/{realm}/users/{userID}/trustIdGroups").Methods("PUT").Queries("expiresAt", "{expiresAt}").Handler(setTemporaryTrustIDGroupsHandler)
 		managementSubroute.Path("/realms/{realm}/users/{userID}/trustIdGroups").Methods("PUT").Handler(setTrustIDGroupsToUserHandler)
 
 		// role mappings
 		managementSubroute.Path("/realms/{realm}/users/{userID}/role-mappings/clients/{clientID}").Methods("GET").Handler(getClientRoleForUserHandler)
+		managementSubroute.Path("/realms/{realm}/users/{userID}/role-mappings/clients/{clientID}").Methods("POST").Queries("expiresAt", "{expiresAt}").Handler(addTemporaryClientRolesHandler)
 		managementSubroute.Path("/realms/{realm}/users/{userID}/role-mappings/clients/{clientID}").Methods("POST").Handler(addClientRoleToUserHandler)
+		managementSubroute.Path("/realms/{realm}/users/{userID}/role-mappings/clients/{clientID}/{roleID}").Methods("DELETE").Handler(deleteClientRoleForUserHandler)
 
 		managementSubroute.Path("/realms/{realm}/users/{userID}/reset-password").Methods("PUT").Handler(resetPasswordHandler)
 		managementSubroute.Path("/realms/{realm}/users/{userID}/execute-actions-email").Methods("PUT").Handler(executeActionsEmailHandler)
@@ -1091,15 +1525,25 @@ func main() {
 
 		// Credentials
 		managementSubroute.Path("/realms/{realm}/users/{userID}/credentials").Methods("GET").Handler(getCredentialsForUserHandler)
+		managementSubroute.Path("/realms/{realm}/users/{userID}/credentials/registrators").Methods("GET").Handler(getCredentialRegistratorsForUserHandler)
+		managementSubroute.Path("/realms/{realm}/users/{userID}/credentials/re-enrollment").Methods("POST").Handler(requireReEnrollmentHandler)
 		managementSubroute.Path("/realms/{realm}/users/{userID}/credentials/{credentialID}").Methods("DELETE").Handler(deleteCredentialsForUserHandler)
+		managementSubroute.Path("/realms/{realm}/users/{userID}/credentials/{credentialID}").Methods("PUT").Handler(updateLabelCredentialForUserHandler)
+		managementSubroute.Path("/realms/{realm}/users/{userID}/credentials/{credentialID}/after/{previousCredentialID}").Methods("POST").Handler(moveCredentialForUserHandler)
 		managementSubroute.Path("/realms/{realm}/users/{userID}/credentials/{credentialID}/reset-failures").Methods("PUT").Handler(resetCredentialFailuresForUserHandler)
 
 		managementSubroute.Path("/realms/{realm}/users/{userID}/clear-login-failures").Methods("DELETE").Handler(clearUserLoginFailuresHandler)
 		managementSubroute.Path("/realms/{realm}/users/{userID}/attack-detection-status").Methods("GET").Handler(getAttackDetectionStatusHandler)
+		managementSubroute.Path("/realms/{realm}/users/{userID}/sessions").Methods("GET").Handler(getUserSessionsHandler)
+		managementSubroute.Path("/realms/{realm}/users/{userID}/sessions").Methods("DELETE").Handler(revokeUserSessionsHandler)
+		managementSubroute.Path("/realms/{realm}/users/{userID}/sessions/{sessionID}").Methods("DELETE").Handler(revokeUserSessionHandler)
 
 		// roles
 		managementSubroute.Path("/realms/{realm}/roles").Methods("GET").Handler(getRolesHandler)
+		managementSubroute.Path("/realms/{realm}/roles").Methods("POST").Handler(createRoleHandler)
 		managementSubroute.Path("/realms/{realm}/roles-by-id/{roleID}").Methods("GET").Handler(getRoleHandler)
+		managementSubroute.Path("/realms/{realm}/roles-by-id/{roleID}").Methods("PUT").Handler(updateRoleHandler)
+		managementSubroute.Path("/realms/{realm}/roles-by-id/{roleID}").Methods("DELETE").Handler(deleteRoleHandler)
 		managementSubroute.Path("/realms/{realm}/clients/{clientID}/roles").Methods("GET").Handler(getClientRolesHandler)
 		managementSubroute.Path("/realms/{realm}/clients/{clientID}/roles").Methods("POST").Handler(createClientRolesHandler)
 
@@ -1107,6 +1551,9 @@ func main() {
 		managementSubroute.Path("/realms/{realm}/groups").Methods("GET").Handler(getGroupsHandler)
 		managementSubroute.Path("/realms/{realm}/groups").Methods("POST").Handler(createGroupHandler)
 		managementSubroute.Path("/realms/{realm}/groups/{groupID}").Methods("DELETE").Handler(deleteGroupHandler)
+		managementSubroute.Path("/realms/{realm}/groups/{groupID}").Methods("PUT").Handler(updateGroupHandler)
+		managementSubroute.Path("/realms/{realm}/groups/{groupID}/children").Methods("POST").Handler(createSubGroupHandler)
+		managementSubroute.Path("/realms/{realm}/groups/{groupID}/parent").Methods("PUT").Handler(moveGroupHandler)
 		managementSubroute.Path("/realms/{realm}/groups/{groupID}/authorizations").Methods("GET").Handler(getAuthorizationsHandler)
 		managementSubroute.Path("/realms/{realm}/groups/{groupID}/authorizations").Methods("PUT").Handler(updateAuthorizationsHandler)
 
@@ -1115,13 +1562,48 @@ func main() {
 		managementSubroute.Path("/realms/{realm}/configuration").Methods("PUT").Handler(updateRealmCustomConfigurationHandler)
 		managementSubroute.Path("/realms/{realm}/admin-configuration").Methods("GET").Handler(getRealmAdminConfigurationHandler)
 		managementSubroute.Path("/realms/{realm}/admin-configuration").Methods("PUT").Handler(updateRealmAdminConfigurationHandler)
+		managementSubroute.Path("/realms/{realm}/configuration-revisions").Methods("GET").Handler(getConfigurationRevisionsHandler)
+		managementSubroute.Path("/realms/{realm}/configuration-revisions/diff").Methods("GET").Handler(diffConfigurationRevisionsHandler)
+		managementSubroute.Path("/realms/{realm}/configuration-revisions/{revisionID}/rollback").Methods("POST").Handler(rollbackConfigurationHandler)
 
 		managementSubroute.Path("/realms/{realm}/backoffice-configuration/groups").Methods("GET").Handler(getRealmBackOfficeConfigurationHandler)
 		managementSubroute.Path("/realms/{realm}/backoffice-configuration/groups").Methods("PUT").Handler(updateRealmBackOfficeConfigurationHandler)
 		managementSubroute.Path("/realms/{realm}/backoffice-configuration").Methods("GET").Handler(getUserRealmBackOfficeConfigurationHandler)
 
+		// authorizations reports
+		managementSubroute.Path("/realms/{realm}/authorizations-report").Methods("GET").Handler(getTargetAuthorizationsReportHandler)
+		managementSubroute.Path("/realms/{realm}/users/{userID}/authorizations-report").Methods("GET").Handler(getUserAuthorizationsReportHandler)
+
 		// brokering - shadow users
+		managementSubroute.Path("/realms/{realm}/users/{userID}/federated-identity").Methods("GET").Handler(getFederatedIdentitiesHandler)
 		managementSubroute.Path("/realms/{realm}/users/{userID}/federated-identity/{provider}").Methods("POST").Handler(linkShadowUserHandler)
+		managementSubroute.Path("/realms/{realm}/users/{userID}/federated-identity/{provider}").Methods("DELETE").Handler(deleteFederatedIdentityHandler)
+
+		// identity providers
+		managementSubroute.Path("/realms/{realm}/identity-providers").Methods("GET").Handler(getIdentityProvidersHandler)
+		managementSubroute.Path("/realms/{realm}/identity-providers").Methods("POST").Handler(createIdentityProviderHandler)
+		managementSubroute.Path("/realms/{realm}/identity-providers/{provider}").Methods("PUT").Handler(updateIdentityProviderHandler)
+		managementSubroute.Path("/realms/{realm}/identity-providers/{provider}/disable").Methods("PUT").Handler(disableIdentityProviderHandler)
+
+		// password policy
+		managementSubroute.Path("/realms/{realm}/password-policy").Methods("GET").Handler(getPasswordPolicyHandler)
+		managementSubroute.Path("/realms/{realm}/password-policy").Methods("PUT").Handler(updatePasswordPolicyHandler)
+
+		// scheduled statistics reports
+		managementSubroute.Path("/realms/{realm}/statistics-reports").Methods("GET").Handler(getStatisticsReportSchedulesHandler)
+		managementSubroute.Path("/realms/{realm}/statistics-reports").Methods("POST").Handler(createStatisticsReportScheduleHandler)
+		managementSubroute.Path("/realms/{realm}/statistics-reports/{scheduleID}").Methods("PUT").Handler(updateStatisticsReportScheduleHandler)
+		managementSubroute.Path("/realms/{realm}/statistics-reports/{scheduleID}").Methods("DELETE").Handler(deleteStatisticsReportScheduleHandler)
+
+		// background jobs of the bulk operations
+		managementSubroute.Path("/realms/{realm}/jobs/{jobID}").Methods("GET").Handler(getBulkJobHandler)
+
+		// four-eyes approval of sensitive actions
+		managementSubroute.Path("/realms/{realm}/approval-policy").Methods("GET").Handler(getApprovalPolicyHandler)
+		managementSubroute.Path("/realms/{realm}/approval-policy").Methods("PUT").Handler(updateApprovalPolicyHandler)
+		managementSubroute.Path("/realms/{realm}/approvals").Methods("GET").Handler(getApprovalRequestsHandler)
+		managementSubroute.Path("/realms/{realm}/approvals/{approvalID}/approve").Methods("POST").Handler(approveRequestHandler)
+		managementSubroute.Path("/realms/{realm}/approvals/{approvalID}/reject").Methods("POST").Handler(rejectRequestHandler)
 
 		// KYC handlers
 		var kycGetActionsHandler = configureKYCHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, endpointPhysicalCheckAvailabilityChecker, false, logger)(kycEndpoints.GetActions)
@@ -1174,6 +1656,10 @@ func main() {
 		var getConfigurationHandler = configureAccountHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(accountEndpoints.GetConfiguration)
 		var sendVerifyEmailHandler = configureAccountHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(accountEndpoints.SendVerifyEmail)
 		var sendVerifyPhoneNumberHandler = configureAccountHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(accountEndpoints.SendVerifyPhoneNumber)
+		var getSessionsHandler = configureAccountHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(accountEndpoints.GetSessions)
+		var deleteSessionHandler = configureAccountHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(accountEndpoints.DeleteSession)
+		var deleteSessionsHandler = configureAccountHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(accountEndpoints.DeleteSessions)
+		var validatePasswordHandler = configureAccountHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(accountEndpoints.ValidatePassword)
 
 		route.Path("/account").Methods("GET").Handler(getAccountHandler)
 		route.Path("/account").Methods("POST").Handler(updateAccountHandler)
@@ -1183,6 +1669,7 @@ func main() {
 
 		route.Path("/account/credentials").Methods("GET").Handler(getCredentialsHandler)
 		route.Path("/account/credentials/password").Methods("POST").Handler(updatePasswordHandler)
+		route.Path("/account/credentials/password/validate").Methods("POST").Handler(validatePasswordHandler)
 		route.Path("/account/credentials/registrators").Methods("GET").Handler(getCredentialRegistratorsHandler)
 		route.Path("/account/credentials/{credentialID}").Methods("DELETE").Handler(deleteCredentialHandler)
 		route.Path("/account/credentials/{credentialID}").Methods("PUT").Handler(updateLabelCredentialHandler)
@@ -1191,6 +1678,10 @@ func main() {
 		route.Path("/account/verify-email").Methods("PUT").Handler(sendVerifyEmailHandler)
 		route.Path("/account/verify-phone-number").Methods("PUT").Handler(sendVerifyPhoneNumberHandler)
 
+		route.Path("/account/sessions").Methods("GET").Handler(getSessionsHandler)
+		route.Path("/account/sessions").Methods("DELETE").Handler(deleteSessionsHandler)
+		route.Path("/account/sessions/{sessionID}").Methods("DELETE").Handler(deleteSessionHandler)
+
 		var handler http.Handler = route
 
 		if accessLogsEnabled {
@@ -1249,9 +1740,13 @@ func main() {
 			// Configuration
 			var getConfigurationHandler = configurePublicRegisterHandler(keycloakb.ComponentName, ComponentID, idGenerator, tracer, logger)(registerEndpoints.GetConfiguration)
 
+			// Password validation
+			var validatePasswordHandler = configurePublicRegisterHandler(keycloakb.ComponentName, ComponentID, idGenerator, tracer, logger)(registerEndpoints.ValidatePassword)
+
 			// Register
 			route.Path("/register/user").Methods("POST").Handler(registerUserHandler)
 			route.Path("/register/config").Methods("GET").Handler(getConfigurationHandler)
+			route.Path("/register/password/validate").Methods("POST").Handler(validatePasswordHandler)
 
 			var handler http.Handler = route
 
@@ -1266,11 +1761,56 @@ func main() {
 		}()
 	}
 
-	// Influx writing.
+	// Scheduled statistics reports.
+	if reportsEnabled {
+		var reportsLogger = log.With(logger, "svc", "statistics-reports")
+
+		var configDBModule = createConfigurationDBModule(configurationRwDBConn, metricsClient, reportsLogger)
+		var reportScheduler = statistics.NewReportScheduler(configDBModule, eventsRODBModule,
+			configureEventsDbModule(baseEventsDBModule, metricsClient, reportsLogger, tracer), sender, idGenerator, reportsLogger)
+
+		go func() {
+			var tic = time.NewTicker(reportsInterval)
+			defer tic.Stop()
+			reportScheduler.Run(tic.C)
+		}()
+	}
+
+	// Purge of the soft deleted users.
+	if softDeletionGracePeriod > 0 {
+		go func() {
+			var tic = time.NewTicker(softDeletionPurgeInterval)
+			defer tic.Stop()
+			userSoftDeletion.Run(tic.C)
+		}()
+	}
+
+	// Removal of the expired temporary grants.
+	go func() {
+		var tic = time.NewTicker(grantsInterval)
+		defer tic.Stop()
+		grantsReconciler.Run(tic.C)
+	}()
+
+	// Application of the scheduled user changes.
+	go func() {
+		var tic = time.NewTicker(userChangesInterval)
+		defer tic.Stop()
+		userChangesScheduler.Run(tic.C)
+	}()
+
+	// End of the expired impersonations.
+	go func() {
+		var tic = time.NewTicker(impersonationInterval)
+		defer tic.Stop()
+		impersonationTerminator.Run(tic.C)
+	}()
+
+	// Metrics writing (only meaningful for Influx).
 	go func() {
 		var tic = time.NewTicker(influxWriteInterval)
 		defer tic.Stop()
-		influxMetrics.WriteLoop(tic.C)
+		metricsClient.WriteLoop(tic.C)
 	}()
 
 	logger.Info(ctx, "msg", "Started")
@@ -1312,6 +1852,7 @@ func config(ctx context.Context, logger log.Logger) *viper.Viper {
 	//Encryption key
 	v.SetDefault(CfgDbAesGcmTagSize, 16)
 	v.SetDefault(CfgDbAesGcmKey, "")
+	v.SetDefault(CfgDbSearchIndexKey, "")
 
 	// CORS configuration
 	v.SetDefault(CfgAllowedOrigins, []string{})
@@ -1353,6 +1894,9 @@ func config(ctx context.Context, logger log.Logger) *viper.Viper {
 	v.SetDefault(CfgRateKeyRegister, 1000)
 	v.SetDefault(CfgRateKeyKYC, 1000)
 
+	// Metrics backends (influx, prometheus)
+	v.SetDefault(CfgMetricsBackends, []string{"influx"})
+
 	// Influx DB client default.
 	v.SetDefault("influx", false)
 	v.SetDefault("influx-host-port", "")
@@ -1364,6 +1908,41 @@ func config(ctx context.Context, logger log.Logger) *viper.Viper {
 	v.SetDefault("influx-write-consistency", "")
 	v.SetDefault(CfgInfluxWriteInterval, "1s")
 
+	// Background jobs: how long results are kept once a job is finished
+	v.SetDefault(CfgJobsRetention, "1h")
+
+	// Scheduled statistics reports: due reports are checked at each interval
+	v.SetDefault(CfgReportsEnabled, false)
+	v.SetDefault(CfgReportsInterval, "5m")
+
+	// Soft deletion of users
+	v.SetDefault(CfgSoftDeletionGracePeriod, "0s")
+	v.SetDefault(CfgSoftDeletionInterval, "1h")
+	v.SetDefault(CfgEmailSender, keycloakb.EmailSenderKeycloak)
+	v.SetDefault(CfgSMTPHost, "")
+	v.SetDefault(CfgSMTPPort, 25)
+	v.SetDefault(CfgSMTPUsername, "")
+	v.SetDefault(CfgSMTPPassword, "")
+	v.SetDefault(CfgSMTPFrom, "")
+
+	// Temporary grants
+	v.SetDefault(CfgGrantsInterval, "5m")
+
+	// Scheduled user changes
+	v.SetDefault(CfgUserChangesInterval, "1m")
+
+	// Impersonation of users
+	v.SetDefault(CfgImpersonationMaxDuration, "30m")
+	v.SetDefault(CfgImpersonationInterval, "1m")
+	v.SetDefault(CfgImpersonationClientID, "impersonation")
+	v.SetDefault(CfgImpersonationSecret, "")
+
+	// Events enrichment: offline GeoIP2/GeoLite2 country database (countries are not resolved if empty)
+	v.SetDefault(CfgGeoIPDatabase, "")
+
+	// Password validation: local list of breached passwords, one per line (not checked if empty)
+	v.SetDefault(CfgBreachedPasswordsFile, "")
+
 	// Sentry client default.
 	v.SetDefault("sentry", false)
 	v.SetDefault(CfgSentryDsn, "")
@@ -1420,6 +1999,9 @@ func config(ctx context.Context, logger log.Logger) *viper.Viper {
 	v.BindEnv(CfgTechnicalPassword, "CT_BRIDGE_TECHNICAL_PASSWORD")
 	censoredParameters[CfgTechnicalPassword] = true
 
+	v.BindEnv(CfgImpersonationSecret, "CT_BRIDGE_IMPERSONATION_CLIENT_SECRET")
+	censoredParameters[CfgImpersonationSecret] = true
+
 	v.BindEnv("influx-username", "CT_BRIDGE_INFLUX_USERNAME")
 	v.BindEnv("influx-password", "CT_BRIDGE_INFLUX_PASSWORD")
 	censoredParameters["influx-password"] = true
@@ -1435,6 +2017,8 @@ func config(ctx context.Context, logger log.Logger) *viper.Viper {
 
 	v.BindEnv(CfgDbAesGcmKey, "CT_BRIDGE_DB_AES_KEY")
 	censoredParameters[CfgDbAesGcmKey] = true
+	v.BindEnv(CfgDbSearchIndexKey, "CT_BRIDGE_DB_SEARCH_INDEX_KEY")
+	censoredParameters[CfgDbSearchIndexKey] = true
 
 	// Load and log config.
 	v.SetConfigFile(v.GetString(CfgConfigFile))
@@ -1566,31 +2150,31 @@ func configurePublicRegisterHandler(ComponentName string, ComponentID string, id
 	}
 }
 
-func createConfigurationDBModule(configDBConn sqltypes.CloudtrustDB, influxMetrics metrics.Metrics, logger log.Logger) keycloakb.ConfigurationDBModule {
+func createConfigurationDBModule(configDBConn sqltypes.CloudtrustDB, metricsClient metrics.Metrics, logger log.Logger) keycloakb.ConfigurationDBModule {
 	var configDBModule keycloakb.ConfigurationDBModule
 	{
 		configDBModule = keycloakb.NewConfigurationDBModule(configDBConn, logger)
-		configDBModule = keycloakb.MakeConfigurationDBModuleInstrumentingMW(influxMetrics.NewHistogram("configDB_module"))(configDBModule)
+		configDBModule = keycloakb.MakeConfigurationDBModuleInstrumentingMW(metricsClient.NewHistogram("configDB_module"))(configDBModule)
 	}
 	return configDBModule
 }
 
-func configureEventsDbModule(baseEventsDBModule database.EventsDBModule, influxMetrics metrics.Metrics, logger log.Logger, tracer tracing.OpentracingClient) database.EventsDBModule {
-	eventsDBModule := event.MakeEventsDBModuleInstrumentingMW(influxMetrics.NewHistogram("eventsDB_module"))(baseEventsDBModule)
+func configureEventsDbModule(baseEventsDBModule database.EventsDBModule, metricsClient metrics.Metrics, logger log.Logger, tracer tracing.OpentracingClient) database.EventsDBModule {
+	eventsDBModule := event.MakeEventsDBModuleInstrumentingMW(metricsClient.NewHistogram("eventsDB_module"))(baseEventsDBModule)
 	eventsDBModule = event.MakeEventsDBModuleLoggingMW(log.With(logger, "mw", "module", "unit", "eventsDB"))(eventsDBModule)
 	eventsDBModule = event.MakeEventsDBModuleTracingMW(tracer)(eventsDBModule)
 	return eventsDBModule
 }
 
-func prepareEndpoint(e cs.Endpoint, endpointName string, influxMetrics metrics.Metrics, logger log.Logger, tracer tracing.OpentracingClient, rateLimit int) endpoint.Endpoint {
-	e = middleware.MakeEndpointInstrumentingMW(influxMetrics, endpointName)(e)
+func prepareEndpoint(e cs.Endpoint, endpointName string, metricsClient metrics.Metrics, logger log.Logger, tracer tracing.OpentracingClient, rateLimit int) endpoint.Endpoint {
+	e = keycloakb.MakeEndpointInstrumentingMW(metricsClient, endpointName)(e)
 	e = middleware.MakeEndpointLoggingMW(log.With(logger, "mw", endpointName))(e)
 	e = tracer.MakeEndpointTracingMW(endpointName)(e)
 	return keycloakb.LimitRate(e, rateLimit)
 }
 
-func prepareEndpointWithoutLogging(e cs.Endpoint, endpointName string, influxMetrics metrics.Metrics, tracer tracing.OpentracingClient, rateLimit int) endpoint.Endpoint {
-	e = middleware.MakeEndpointInstrumentingMW(influxMetrics, endpointName)(e)
+func prepareEndpointWithoutLogging(e cs.Endpoint, endpointName string, metricsClient metrics.Metrics, tracer tracing.OpentracingClient, rateLimit int) endpoint.Endpoint {
+	e = keycloakb.MakeEndpointInstrumentingMW(metricsClient, endpointName)(e)
 	e = tracer.MakeEndpointTracingMW(endpointName)(e)
 	return keycloakb.LimitRate(e, rateLimit)
 }
@@ -15,6 +15,11 @@ const (
 	MsgErrUnknown              = "unknowError"
 	MsgErrNotConfigured        = "notConfigured"
 	MsgErrUnverified           = "unverifiedFlag"
+	MsgErrAlreadyDecided       = "alreadyDecided"
+	MsgErrSelfApproval         = "selfApproval"
+	MsgErrTooManyResults       = "tooManyResults"
+	MsgErrExistingValue        = "existingValue"
+	MsgErrApprovalRequired     = "approvalRequired"
 
 	BodyContent                       = "bodyContent"
 	RealmConfiguration                = "realmConfiguration"
@@ -69,4 +74,46 @@ const (
 	Timeshift                         = "timeshift"
 	IdentityProvider                  = "identityProvider"
 	TrustIDGroupName                  = "trustIDGroupName"
+	First                             = "first"
+	CreatedAfter                      = "createdAfter"
+	CreatedBefore                     = "createdBefore"
+	Migrated                          = "migrated"
+	JobID                             = "jobId"
+	Format                            = "format"
+	ReportSchedule                    = "reportSchedule"
+	ScheduleID                        = "scheduleId"
+	Frequency                         = "frequency"
+	Day                               = "day"
+	Hour                              = "hour"
+	Recipients                        = "recipients"
+	Operation                         = "operation"
+	UserIDs                           = "userIds"
+	Filter                            = "filter"
+	Actions                           = "actions"
+	Lifespan                          = "lifespan"
+	Columns                           = "columns"
+	ApprovalID                        = "approvalId"
+	Comment                           = "comment"
+	ExpiresAt                         = "expiresAt"
+	Action                            = "action"
+	ApplyAt                           = "applyAt"
+	ChangeID                          = "changeId"
+	ParentID                          = "parentId"
+	SessionID                         = "sessionId"
+	Justification                     = "justification"
+	Duration                          = "duration"
+	Protocol                          = "protocol"
+	BaseURL                           = "baseUrl"
+	RedirectURIs                      = "redirectUris"
+	WebOrigins                        = "webOrigins"
+	PublicClient                      = "publicClient"
+	ImplicitFlowEnabled               = "implicitFlowEnabled"
+	DirectAccessGrantsEnabled         = "directAccessGrantsEnabled"
+	AllowedRedirectURIPrefixes        = "allowedRedirectUriPrefixes"
+	Alias                             = "alias"
+	DisplayName                       = "displayName"
+	ProviderID                        = "providerId"
+	FirstBrokerLoginFlowAlias         = "firstBrokerLoginFlowAlias"
+	RevisionID                        = "revisionId"
+	Clients                           = "clients"
 )
@@ -21,6 +21,7 @@ const (
 	AttrbPhoneNumberVerified = kc.AttributeKey("phoneNumberVerified")
 	AttrbSmsSent             = kc.AttributeKey("smsSent")
 	AttrbSmsAttempts         = kc.AttributeKey("smsAttempts")
+	AttrbSoftDeleted         = kc.AttributeKey("softDeletedTimestamp")
 	AttrbTrustIDAuthToken    = kc.AttributeKey("trustIDAuthToken")
 	AttrbTrustIDGroups       = kc.AttributeKey("trustIDGroups")
 )
@@ -1,4 +1,119 @@
 package dto
 
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/cloudtrust/common-service/configuration"
+)
+
 // BackOfficeConfiguration definition
 type BackOfficeConfiguration map[string]map[string][]string
+
+// Statistics report frequencies
+const (
+	ReportFrequencyDaily   = "daily"
+	ReportFrequencyWeekly  = "weekly"
+	ReportFrequencyMonthly = "monthly"
+)
+
+// DBReportSchedule struct. Day is the day of the week (0 is Sunday) for weekly reports or the day of the month for
+// monthly reports. Hour is expressed in UTC.
+type DBReportSchedule struct {
+	ID         int64
+	RealmName  string
+	Frequency  string
+	Day        int
+	Hour       int
+	Recipients []string
+	LastSent   *time.Time
+}
+
+// ClientPolicy is the allowed-settings policy of the clients managed through the bridge. A realm without policy gets the
+// zero value: implicit flow, direct access grants, public clients and wildcards in redirect URIs are refused. When
+// AllowedRedirectURIPrefixes is not empty, the redirect URIs must start with one of the prefixes.
+type ClientPolicy struct {
+	ImplicitFlowAllowed         bool     `json:"implicitFlowAllowed"`
+	DirectAccessGrantsAllowed   bool     `json:"directAccessGrantsAllowed"`
+	PublicClientsAllowed        bool     `json:"publicClientsAllowed"`
+	WildcardRedirectURIsAllowed bool     `json:"wildcardRedirectUrisAllowed"`
+	AllowedRedirectURIPrefixes  []string `json:"allowedRedirectUriPrefixes"`
+}
+
+// adminConfigurationClientPolicy is the part of the admin configuration document which holds the client policy
+type adminConfigurationClientPolicy struct {
+	ClientPolicy ClientPolicy `json:"client_policy"`
+}
+
+// MarshalAdminConfiguration creates the admin configuration document of a realm. The client policy is stored in this
+// document, next to the settings of the admin configuration.
+func MarshalAdminConfiguration(config configuration.RealmAdminConfiguration, policy ClientPolicy) ([]byte, error) {
+	var content, err = json.Marshal(config)
+	if err != nil {
+		return nil, err
+	}
+	var document map[string]interface{}
+	if err = json.Unmarshal(content, &document); err != nil {
+		return nil, err
+	}
+	document["client_policy"] = policy
+	return json.Marshal(document)
+}
+
+// UnmarshalAdminConfiguration reads an admin configuration document. A document without client policy gives the default policy.
+func UnmarshalAdminConfiguration(content []byte) (configuration.RealmAdminConfiguration, ClientPolicy, error) {
+	var config configuration.RealmAdminConfiguration
+	if err := json.Unmarshal(content, &config); err != nil {
+		return configuration.RealmAdminConfiguration{}, ClientPolicy{}, err
+	}
+	var policy adminConfigurationClientPolicy
+	if err := json.Unmarshal(content, &policy); err != nil {
+		return configuration.RealmAdminConfiguration{}, ClientPolicy{}, err
+	}
+	return config, policy.ClientPolicy, nil
+}
+
+// Configuration types of the revisions
+const (
+	ConfigurationTypeCustom = "configuration"
+	ConfigurationTypeAdmin  = "admin_configuration"
+)
+
+// DBConfigurationRevision struct. Content is the JSON custom or admin configuration of the realm as it was stored by the
+// change. RollbackOf is set when the change is the rollback to a previous revision.
+type DBConfigurationRevision struct {
+	ID             int64
+	RealmID        string
+	ConfigType     string
+	Content        string
+	AuthorID       string
+	AuthorUsername string
+	CreatedAt      time.Time
+	RollbackOf     *int64
+}
+
+// Approval requests status
+const (
+	ApprovalStatusPending  = "PENDING"
+	ApprovalStatusApproved = "APPROVED"
+	ApprovalStatusRejected = "REJECTED"
+	ApprovalStatusFailed   = "FAILED"
+)
+
+// DBApprovalRequest struct. TargetID is the user or the group targeted by the action, Payload contains the JSON
+// parameters of the action. A request which failed to be executed once approved has the status FAILED.
+type DBApprovalRequest struct {
+	ID                int64
+	RealmName         string
+	Action            string
+	TargetID          *string
+	Payload           []byte
+	RequesterID       string
+	RequesterUsername string
+	Status            string
+	CreatedAt         time.Time
+	DeciderID         *string
+	DeciderUsername   *string
+	DecidedAt         *time.Time
+	Comment           *string
+}
@@ -24,3 +24,78 @@ type DBCheck struct {
 	ProofType *string
 	Comment   *string
 }
+
+// DBUserSearchIndex struct contains the blind indexes of a user's personal information
+type DBUserSearchIndex struct {
+	UserID               string
+	PhoneNumberHash      *string
+	BirthDateHash        *string
+	IDDocumentNumberHash *string
+}
+
+// DBSoftDeletedUser struct. A soft deleted user is disabled until it is restored or purged.
+type DBSoftDeletedUser struct {
+	RealmName  string
+	UserID     string
+	Username   *string
+	DeletedAt  time.Time
+	PurgeAt    time.Time
+	WasEnabled bool
+}
+
+// Types of the temporary grants
+const (
+	GrantTypeGroup        = "GROUP"
+	GrantTypeTrustIDGroup = "TRUSTID_GROUP"
+	GrantTypeClientRole   = "CLIENT_ROLE"
+)
+
+// DBTemporaryGrant struct. TargetID is the group identifier, the trustID group name or the role identifier depending on
+// the grant type. ClientID and Name are only set for client roles.
+type DBTemporaryGrant struct {
+	RealmName string
+	UserID    string
+	GrantType string
+	TargetID  string
+	ClientID  *string
+	Name      *string
+	ExpiresAt time.Time
+	GrantedBy string
+}
+
+// Actions of the scheduled user changes
+const (
+	ScheduledActionLock   = "LOCK"
+	ScheduledActionUnlock = "UNLOCK"
+	ScheduledActionExpire = "EXPIRE"
+)
+
+// DBScheduledUserChange struct. An expiration locks the account like a lock but is reported as an account expiry.
+// The change is applied on behalf of the operator who scheduled it. Attempts counts the failed attempts to apply it.
+type DBScheduledUserChange struct {
+	ID              int64
+	RealmName       string
+	UserID          string
+	Action          string
+	ApplyAt         time.Time
+	CreatedAt       time.Time
+	CreatorRealm    string
+	CreatorID       string
+	CreatorUsername string
+	Attempts        int
+}
+
+// DBImpersonation struct. SessionID is the Keycloak session opened by the impersonation, it is ended once the impersonation
+// expires. It is nil when the session could not be identified.
+type DBImpersonation struct {
+	ID            int64
+	RealmName     string
+	UserID        string
+	SessionID     *string
+	Justification string
+	StartedAt     time.Time
+	ExpiresAt     time.Time
+	AgentRealm    string
+	AgentID       string
+	AgentUsername string
+}
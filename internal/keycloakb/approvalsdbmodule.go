@@ -0,0 +1,201 @@
+package keycloakb
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/cloudtrust/common-service/database/sqltypes"
+	"github.com/cloudtrust/common-service/log"
+	"github.com/cloudtrust/common-service/security"
+	"github.com/cloudtrust/keycloak-bridge/internal/dto"
+)
+
+const (
+	selectApprovalPolicyStmt = `SELECT actions FROM approval_policy WHERE realm_id=?;`
+	updateApprovalPolicyStmt = `INSERT INTO approval_policy (realm_id, actions)
+	  VALUES (?, ?)
+	  ON DUPLICATE KEY UPDATE actions=?;`
+	insertApprovalRequestStmt = `
+		INSERT INTO approval_request (realm_id, action, target_id, payload, requester_id, requester_username, status, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	selectApprovalRequestsStmt = `
+		SELECT id, realm_id, action, target_id, payload, requester_id, requester_username, status, unix_timestamp(created_at),
+		  decider_id, decider_username, unix_timestamp(decided_at), comment
+		FROM approval_request
+		WHERE realm_id=? AND (? IS NULL OR status=?)
+		ORDER BY id
+	`
+	selectApprovalRequestStmt = `
+		SELECT id, realm_id, action, target_id, payload, requester_id, requester_username, status, unix_timestamp(created_at),
+		  decider_id, decider_username, unix_timestamp(decided_at), comment
+		FROM approval_request
+		WHERE realm_id=? AND id=?
+	`
+	updateApprovalRequestStatusStmt = `
+		UPDATE approval_request
+		SET status=?, decider_id=?, decider_username=?, decided_at=?, comment=?, payload=NULL
+		WHERE realm_id=? AND id=? AND status=?
+	`
+)
+
+// ApprovalsDBModule is the interface of the module storing the approval policies and the approval requests
+type ApprovalsDBModule interface {
+	GetApprovalPolicy(ctx context.Context, realm string) ([]string, error)
+	UpdateApprovalPolicy(ctx context.Context, realm string, actions []string) error
+	CreateApprovalRequest(ctx context.Context, request dto.DBApprovalRequest) (int64, error)
+	GetApprovalRequest(ctx context.Context, realm string, requestID int64) (*dto.DBApprovalRequest, error)
+	GetApprovalRequests(ctx context.Context, realm string, status *string) ([]dto.DBApprovalRequest, error)
+	UpdateApprovalRequestStatus(ctx context.Context, request dto.DBApprovalRequest, currentStatus string) (bool, error)
+}
+
+type approvalsDBModule struct {
+	db     sqltypes.CloudtrustDB
+	cipher security.EncrypterDecrypter
+	logger log.Logger
+}
+
+// NewApprovalsDBModule returns an approvals DB module. The payloads of the requests are encrypted as they can contain
+// sensitive data like passwords.
+func NewApprovalsDBModule(db sqltypes.CloudtrustDB, cipher security.EncrypterDecrypter, logger log.Logger) ApprovalsDBModule {
+	return &approvalsDBModule{
+		db:     db,
+		cipher: cipher,
+		logger: logger,
+	}
+}
+
+// GetApprovalPolicy returns the actions which require an approval. No action requires an approval when the realm has no policy.
+func (c *approvalsDBModule) GetApprovalPolicy(ctx context.Context, realm string) ([]string, error) {
+	var actionsJSON string
+	switch err := c.db.QueryRow(selectApprovalPolicyStmt, realm).Scan(&actionsJSON); err {
+	case sql.ErrNoRows:
+		return []string{}, nil
+	case nil:
+		var actions []string
+		if err = json.Unmarshal([]byte(actionsJSON), &actions); err != nil {
+			c.logger.Warn(ctx, "msg", "Can't unmarshal approval policy", "error", err.Error(), "realmID", realm)
+			return nil, err
+		}
+		return actions, nil
+	default:
+		c.logger.Warn(ctx, "msg", "Can't get approval policy", "error", err.Error(), "realmID", realm)
+		return nil, err
+	}
+}
+
+func (c *approvalsDBModule) UpdateApprovalPolicy(ctx context.Context, realm string, actions []string) error {
+	var actionsJSON, _ = json.Marshal(actions)
+	var _, err = c.db.Exec(updateApprovalPolicyStmt, realm, string(actionsJSON), string(actionsJSON))
+	if err != nil {
+		c.logger.Warn(ctx, "msg", "Can't update approval policy", "error", err.Error(), "realmID", realm)
+	}
+	return err
+}
+
+func (c *approvalsDBModule) CreateApprovalRequest(ctx context.Context, request dto.DBApprovalRequest) (int64, error) {
+	var payload []byte
+	if request.Payload != nil {
+		var err error
+		if payload, err = c.cipher.Encrypt(request.Payload, []byte(request.RealmName)); err != nil {
+			c.logger.Warn(ctx, "msg", "Can't encrypt the approval request payload", "error", err.Error(), "realmID", request.RealmName)
+			return 0, err
+		}
+	}
+
+	var res, err = c.db.Exec(insertApprovalRequestStmt, request.RealmName, request.Action, request.TargetID, payload, request.RequesterID,
+		request.RequesterUsername, request.Status, request.CreatedAt)
+	if err != nil {
+		c.logger.Warn(ctx, "msg", "Can't insert approval request", "error", err.Error(), "realmID", request.RealmName, "action", request.Action)
+		return 0, err
+	}
+	return res.LastInsertId()
+}
+
+// GetApprovalRequest returns nil when the request does not exist
+func (c *approvalsDBModule) GetApprovalRequest(ctx context.Context, realm string, requestID int64) (*dto.DBApprovalRequest, error) {
+	var request, err = c.scanApprovalRequest(c.db.QueryRow(selectApprovalRequestStmt, realm, requestID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		c.logger.Warn(ctx, "msg", "Can't get approval request", "error", err.Error(), "realmID", realm, "requestID", requestID)
+		return nil, err
+	}
+	return &request, nil
+}
+
+// GetApprovalRequests returns the requests of a realm, filtered by status if a status is given
+func (c *approvalsDBModule) GetApprovalRequests(ctx context.Context, realm string, status *string) ([]dto.DBApprovalRequest, error) {
+	var rows, err = c.db.Query(selectApprovalRequestsStmt, realm, nullableString(status), nullableString(status))
+	if err != nil {
+		c.logger.Warn(ctx, "msg", "Can't get approval requests", "error", err.Error(), "realmID", realm)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res = make([]dto.DBApprovalRequest, 0)
+	for rows.Next() {
+		var request, err = c.scanApprovalRequest(rows)
+		if err != nil {
+			c.logger.Warn(ctx, "msg", "Can't get approval requests. Scan failed", "error", err.Error(), "realmID", realm)
+			return nil, err
+		}
+		res = append(res, request)
+	}
+	return res, rows.Err()
+}
+
+// UpdateApprovalRequestStatus stores the decision on a request if its status is still currentStatus. It returns false
+// when the request has been decided in the meantime. The payload is erased as it is only needed by a pending request.
+func (c *approvalsDBModule) UpdateApprovalRequestStatus(ctx context.Context, request dto.DBApprovalRequest, currentStatus string) (bool, error) {
+	var res, err = c.db.Exec(updateApprovalRequestStatusStmt, request.Status, request.DeciderID, request.DeciderUsername, request.DecidedAt,
+		request.Comment, request.RealmName, request.ID, currentStatus)
+	if err != nil {
+		c.logger.Warn(ctx, "msg", "Can't update approval request", "error", err.Error(), "realmID", request.RealmName, "requestID", request.ID)
+		return false, err
+	}
+	var count int64
+	if count, err = res.RowsAffected(); err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+func (c *approvalsDBModule) scanApprovalRequest(scanner Scanner) (dto.DBApprovalRequest, error) {
+	var (
+		request         dto.DBApprovalRequest
+		targetID        sql.NullString
+		payload         []byte
+		createdAt       int64
+		deciderID       sql.NullString
+		deciderUsername sql.NullString
+		decidedAt       sql.NullInt64
+		comment         sql.NullString
+	)
+
+	err := scanner.Scan(&request.ID, &request.RealmName, &request.Action, &targetID, &payload, &request.RequesterID, &request.RequesterUsername,
+		&request.Status, &createdAt, &deciderID, &deciderUsername, &decidedAt, &comment)
+	if err != nil {
+		return dto.DBApprovalRequest{}, err
+	}
+
+	if payload != nil {
+		if request.Payload, err = c.cipher.Decrypt(payload, []byte(request.RealmName)); err != nil {
+			return dto.DBApprovalRequest{}, err
+		}
+	}
+	request.TargetID = nullStringToPtr(targetID)
+	request.CreatedAt = time.Unix(createdAt, 0).UTC()
+	request.DeciderID = nullStringToPtr(deciderID)
+	request.DeciderUsername = nullStringToPtr(deciderUsername)
+	if decidedAt.Valid {
+		var date = time.Unix(decidedAt.Int64, 0).UTC()
+		request.DecidedAt = &date
+	}
+	request.Comment = nullStringToPtr(comment)
+
+	return request, nil
+}
@@ -0,0 +1,157 @@
+package keycloakb
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/cloudtrust/common-service/log"
+	"github.com/cloudtrust/keycloak-bridge/internal/dto"
+	"github.com/cloudtrust/keycloak-bridge/internal/keycloakb/mock"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApprovalPolicy(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+	var mockDB = mock.NewCloudtrustDB(mockCtrl)
+	var mockSQLRow = mock.NewSQLRow(mockCtrl)
+	var mockCrypter = mock.NewEncrypterDecrypter(mockCtrl)
+
+	var module = NewApprovalsDBModule(mockDB, mockCrypter, log.NewNopLogger())
+	var ctx = context.TODO()
+	var realm = "my-realm"
+	var expectedError = errors.New("db error")
+
+	t.Run("No policy", func(t *testing.T) {
+		mockDB.EXPECT().QueryRow(selectApprovalPolicyStmt, realm).Return(mockSQLRow)
+		mockSQLRow.EXPECT().Scan(gomock.Any()).Return(sql.ErrNoRows)
+		var actions, err = module.GetApprovalPolicy(ctx, realm)
+		assert.Nil(t, err)
+		assert.Len(t, actions, 0)
+	})
+	t.Run("Query fails", func(t *testing.T) {
+		mockDB.EXPECT().QueryRow(selectApprovalPolicyStmt, realm).Return(mockSQLRow)
+		mockSQLRow.EXPECT().Scan(gomock.Any()).Return(expectedError)
+		var _, err = module.GetApprovalPolicy(ctx, realm)
+		assert.Equal(t, expectedError, err)
+	})
+	t.Run("Get policy", func(t *testing.T) {
+		mockDB.EXPECT().QueryRow(selectApprovalPolicyStmt, realm).Return(mockSQLRow)
+		mockSQLRow.EXPECT().Scan(gomock.Any()).DoAndReturn(func(actions *string) error {
+			*actions = `["MGMT_DeleteUser"]`
+			return nil
+		})
+		var actions, err = module.GetApprovalPolicy(ctx, realm)
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"MGMT_DeleteUser"}, actions)
+	})
+	t.Run("Update policy", func(t *testing.T) {
+		mockDB.EXPECT().Exec(updateApprovalPolicyStmt, realm, `["MGMT_DeleteUser"]`, `["MGMT_DeleteUser"]`).Return(nil, expectedError)
+		assert.Equal(t, expectedError, module.UpdateApprovalPolicy(ctx, realm, []string{"MGMT_DeleteUser"}))
+	})
+}
+
+func TestApprovalRequests(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+	var mockDB = mock.NewCloudtrustDB(mockCtrl)
+	var mockSQLRow = mock.NewSQLRow(mockCtrl)
+	var mockSQLRows = mock.NewSQLRows(mockCtrl)
+	var mockCrypter = mock.NewEncrypterDecrypter(mockCtrl)
+
+	var module = NewApprovalsDBModule(mockDB, mockCrypter, log.NewNopLogger())
+	var ctx = context.TODO()
+	var realm = "my-realm"
+	var targetID = "user-id"
+	var now = time.Unix(1600000000, 0).UTC()
+	var expectedError = errors.New("db error")
+	var request = dto.DBApprovalRequest{
+		ID:                3,
+		RealmName:         realm,
+		Action:            "MGMT_ResetPassword",
+		TargetID:          &targetID,
+		Payload:           []byte(`{"value":"secret"}`),
+		RequesterID:       "requester-id",
+		RequesterUsername: "requester",
+		Status:            dto.ApprovalStatusPending,
+		CreatedAt:         now,
+	}
+	var scanRequest = func(dest ...interface{}) error {
+		*(dest[0].(*int64)) = request.ID
+		*(dest[1].(*string)) = realm
+		*(dest[2].(*string)) = request.Action
+		*(dest[3].(*sql.NullString)) = sql.NullString{String: targetID, Valid: true}
+		*(dest[4].(*[]byte)) = []byte("encrypted")
+		*(dest[5].(*string)) = request.RequesterID
+		*(dest[6].(*string)) = request.RequesterUsername
+		*(dest[7].(*string)) = request.Status
+		*(dest[8].(*int64)) = now.Unix()
+		return nil
+	}
+
+	t.Run("Create request, encryption fails", func(t *testing.T) {
+		mockCrypter.EXPECT().Encrypt(request.Payload, []byte(realm)).Return(nil, expectedError)
+		var _, err = module.CreateApprovalRequest(ctx, request)
+		assert.Equal(t, expectedError, err)
+	})
+	t.Run("Create request", func(t *testing.T) {
+		mockCrypter.EXPECT().Encrypt(request.Payload, []byte(realm)).Return([]byte("encrypted"), nil)
+		mockDB.EXPECT().Exec(insertApprovalRequestStmt, realm, request.Action, &targetID, []byte("encrypted"), request.RequesterID, request.RequesterUsername,
+			dto.ApprovalStatusPending, now).Return(sqlResult{id: 3}, nil)
+		var id, err = module.CreateApprovalRequest(ctx, request)
+		assert.Nil(t, err)
+		assert.Equal(t, int64(3), id)
+	})
+
+	t.Run("Get unknown request", func(t *testing.T) {
+		mockDB.EXPECT().QueryRow(selectApprovalRequestStmt, realm, int64(3)).Return(mockSQLRow)
+		mockSQLRow.EXPECT().Scan(gomock.Any()).Return(sql.ErrNoRows)
+		var res, err = module.GetApprovalRequest(ctx, realm, 3)
+		assert.Nil(t, err)
+		assert.Nil(t, res)
+	})
+	t.Run("Get request", func(t *testing.T) {
+		mockDB.EXPECT().QueryRow(selectApprovalRequestStmt, realm, int64(3)).Return(mockSQLRow)
+		mockSQLRow.EXPECT().Scan(gomock.Any()).DoAndReturn(scanRequest)
+		mockCrypter.EXPECT().Decrypt([]byte("encrypted"), []byte(realm)).Return(request.Payload, nil)
+		var res, err = module.GetApprovalRequest(ctx, realm, 3)
+		assert.Nil(t, err)
+		assert.Equal(t, request, *res)
+	})
+
+	t.Run("Get requests fails", func(t *testing.T) {
+		var status = dto.ApprovalStatusPending
+		mockDB.EXPECT().Query(selectApprovalRequestsStmt, realm, &status, &status).Return(nil, expectedError)
+		var _, err = module.GetApprovalRequests(ctx, realm, &status)
+		assert.Equal(t, expectedError, err)
+	})
+	t.Run("Get requests", func(t *testing.T) {
+		gomock.InOrder(
+			mockDB.EXPECT().Query(selectApprovalRequestsStmt, realm, nil, nil).Return(mockSQLRows, nil),
+			mockSQLRows.EXPECT().Next().Return(true),
+			mockSQLRows.EXPECT().Scan(gomock.Any()).DoAndReturn(scanRequest),
+			mockCrypter.EXPECT().Decrypt([]byte("encrypted"), []byte(realm)).Return(request.Payload, nil),
+			mockSQLRows.EXPECT().Next().Return(false),
+			mockSQLRows.EXPECT().Err().Return(nil),
+			mockSQLRows.EXPECT().Close(),
+		)
+		var res, err = module.GetApprovalRequests(ctx, realm, nil)
+		assert.Nil(t, err)
+		assert.Equal(t, []dto.DBApprovalRequest{request}, res)
+	})
+
+	t.Run("Update status", func(t *testing.T) {
+		var decided = request
+		decided.Status = dto.ApprovalStatusApproved
+		decided.DecidedAt = &now
+		mockDB.EXPECT().Exec(updateApprovalRequestStatusStmt, decided.Status, gomock.Nil(), gomock.Nil(), &now, gomock.Nil(), realm, request.ID,
+			dto.ApprovalStatusPending).Return(sqlResult{}, nil)
+		var updated, err = module.UpdateApprovalRequestStatus(ctx, decided, dto.ApprovalStatusPending)
+		assert.Nil(t, err)
+		assert.True(t, updated)
+	})
+}
@@ -0,0 +1,77 @@
+package keycloakb
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/cloudtrust/keycloak-bridge/internal/constants"
+)
+
+// Fields of the users search index
+const (
+	IndexPhoneNumber      = "phoneNumber"
+	IndexBirthDate        = "birthDate"
+	IndexIDDocumentNumber = "idDocumentNumber"
+)
+
+// BlindIndexer computes keyed hashes of personal information. They allow exact match searches
+// without storing the values themselves.
+type BlindIndexer interface {
+	Index(realm string, field string, value string) string
+}
+
+type blindIndexer struct {
+	key []byte
+}
+
+// NewBlindIndexerFromBase64 creates a blind indexer from a base64 encoded key of at least 32 bytes
+func NewBlindIndexerFromBase64(key string) (BlindIndexer, error) {
+	var bytes, err = base64.StdEncoding.DecodeString(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(bytes) < 32 {
+		return nil, errors.New("blind index key should be at least 32 bytes long")
+	}
+	return &blindIndexer{key: bytes}, nil
+}
+
+// Index normalizes the value and returns its hash. The realm and the field are part of the hash so that
+// equal values are not linkable across realms or fields.
+func (b *blindIndexer) Index(realm string, field string, value string) string {
+	var mac = hmac.New(sha256.New, b.key)
+	mac.Write([]byte(realm + "|" + field + "|" + NormalizeIndexedValue(field, value)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// NormalizeIndexedValue removes the formatting differences of values which are equal
+func NormalizeIndexedValue(field string, value string) string {
+	switch field {
+	case IndexPhoneNumber:
+		return strings.Map(func(r rune) rune {
+			if (r >= '0' && r <= '9') || r == '+' {
+				return r
+			}
+			return -1
+		}, value)
+	case IndexBirthDate:
+		for _, layout := range constants.SupportedDateLayouts {
+			if date, err := time.Parse(layout, value); err == nil {
+				return date.Format("2006-01-02")
+			}
+		}
+		return value
+	default:
+		return strings.ToUpper(strings.Map(func(r rune) rune {
+			if r == ' ' || r == '-' || r == '.' {
+				return -1
+			}
+			return r
+		}, value))
+	}
+}
@@ -0,0 +1,38 @@
+package keycloakb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewBlindIndexerFromBase64(t *testing.T) {
+	t.Run("Invalid base64", func(t *testing.T) {
+		var _, err = NewBlindIndexerFromBase64("not base 64")
+		assert.NotNil(t, err)
+	})
+	t.Run("Key too short", func(t *testing.T) {
+		var _, err = NewBlindIndexerFromBase64("c2hvcnQta2V5")
+		assert.NotNil(t, err)
+	})
+	t.Run("Valid key", func(t *testing.T) {
+		var _, err = NewBlindIndexerFromBase64("3q8Xbp1NlUqzyFjz+5xk1u6uW8Y7Fg0m9yZbq2iCqWU=")
+		assert.Nil(t, err)
+	})
+}
+
+func TestBlindIndex(t *testing.T) {
+	var indexer, _ = NewBlindIndexerFromBase64("3q8Xbp1NlUqzyFjz+5xk1u6uW8Y7Fg0m9yZbq2iCqWU=")
+
+	t.Run("Formatting differences are ignored", func(t *testing.T) {
+		assert.Equal(t, indexer.Index("DEP", IndexPhoneNumber, "+41 79 123 45 67"), indexer.Index("DEP", IndexPhoneNumber, "+41791234567"))
+		assert.Equal(t, indexer.Index("DEP", IndexBirthDate, "31.12.1990"), indexer.Index("DEP", IndexBirthDate, "1990-12-31"))
+		assert.Equal(t, indexer.Index("DEP", IndexIDDocumentNumber, "ab-123 456"), indexer.Index("DEP", IndexIDDocumentNumber, "AB123456"))
+	})
+	t.Run("Hashes depend on realm and field", func(t *testing.T) {
+		var hash = indexer.Index("DEP", IndexIDDocumentNumber, "12345678")
+		assert.Len(t, hash, 64)
+		assert.NotEqual(t, hash, indexer.Index("OTHER", IndexIDDocumentNumber, "12345678"))
+		assert.NotEqual(t, hash, indexer.Index("DEP", IndexPhoneNumber, "12345678"))
+	})
+}
@@ -27,15 +27,27 @@ type ConfigurationDBModule interface {
 	NewTransaction(context context.Context) (sqltypes.Transaction, error)
 	StoreOrUpdateConfiguration(context.Context, string, configuration.RealmConfiguration) error
 	GetConfiguration(context.Context, string) (configuration.RealmConfiguration, error)
-	StoreOrUpdateAdminConfiguration(context.Context, string, configuration.RealmAdminConfiguration) error
+	StoreOrUpdateAdminConfiguration(context.Context, string, configuration.RealmAdminConfiguration, dto.ClientPolicy) error
 	GetAdminConfiguration(context.Context, string) (configuration.RealmAdminConfiguration, error)
+	GetClientPolicy(context context.Context, realmID string) (dto.ClientPolicy, error)
 	GetBackOfficeConfiguration(context.Context, string, []string) (dto.BackOfficeConfiguration, error)
 	DeleteBackOfficeConfiguration(context.Context, string, string, string, *string, *string) error
 	InsertBackOfficeConfiguration(context.Context, string, string, string, string, []string) error
 	GetAuthorizations(context context.Context, realmID string, groupName string) ([]configuration.Authorization, error)
+	GetAuthorizationsOnTargetRealm(context context.Context, targetRealmID string) ([]configuration.Authorization, error)
 	CreateAuthorization(context context.Context, authz configuration.Authorization) error
 	DeleteAuthorizations(context context.Context, realmID string, groupName string) error
 	DeleteAllAuthorizationsWithGroup(context context.Context, realmName, groupName string) error
+	UpdateGroupReferences(context context.Context, realmID, oldName, newName, oldPath, newPath string) error
+	GetReportSchedules(context context.Context, realmName string) ([]dto.DBReportSchedule, error)
+	GetAllReportSchedules(context context.Context) ([]dto.DBReportSchedule, error)
+	CreateReportSchedule(context context.Context, schedule dto.DBReportSchedule) (int64, error)
+	UpdateReportSchedule(context context.Context, schedule dto.DBReportSchedule) error
+	ClaimReportSchedule(context context.Context, scheduleID int64, occurrence time.Time, now time.Time) (bool, error)
+	DeleteReportSchedule(context context.Context, realmName string, scheduleID int64) error
+	CreateConfigurationRevision(context context.Context, revision dto.DBConfigurationRevision) (int64, error)
+	GetConfigurationRevisions(context context.Context, realmID string, configType *string) ([]dto.DBConfigurationRevision, error)
+	GetConfigurationRevision(context context.Context, realmID string, revisionID int64) (*dto.DBConfigurationRevision, error)
 }
 
 // MakeConfigurationDBModuleInstrumentingMW makes an instrumenting middleware at module level.
@@ -73,11 +85,11 @@ func (m *configDBModuleInstrumentingMW) GetConfiguration(ctx context.Context, re
 }
 
 // configDBModuleInstrumentingMW implements Module.
-func (m *configDBModuleInstrumentingMW) StoreOrUpdateAdminConfiguration(ctx context.Context, realmName string, config configuration.RealmAdminConfiguration) error {
+func (m *configDBModuleInstrumentingMW) StoreOrUpdateAdminConfiguration(ctx context.Context, realmName string, config configuration.RealmAdminConfiguration, clientPolicy dto.ClientPolicy) error {
 	defer func(begin time.Time) {
 		m.h.With(KeyCorrelationID, ctx.Value(cs.CtContextCorrelationID).(string)).Observe(time.Since(begin).Seconds())
 	}(time.Now())
-	return m.next.StoreOrUpdateAdminConfiguration(ctx, realmName, config)
+	return m.next.StoreOrUpdateAdminConfiguration(ctx, realmName, config, clientPolicy)
 }
 
 // configDBModuleInstrumentingMW implements Module.
@@ -118,6 +130,14 @@ func (m *configDBModuleInstrumentingMW) GetAuthorizations(ctx context.Context, r
 	return m.next.GetAuthorizations(ctx, realmID, groupID)
 }
 
+// configDBModuleInstrumentingMW implements Module.
+func (m *configDBModuleInstrumentingMW) GetAuthorizationsOnTargetRealm(ctx context.Context, targetRealmID string) ([]configuration.Authorization, error) {
+	defer func(begin time.Time) {
+		m.h.With(KeyCorrelationID, ctx.Value(cs.CtContextCorrelationID).(string)).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	return m.next.GetAuthorizationsOnTargetRealm(ctx, targetRealmID)
+}
+
 // configDBModuleInstrumentingMW implements Module.
 func (m *configDBModuleInstrumentingMW) CreateAuthorization(ctx context.Context, auth configuration.Authorization) error {
 	defer func(begin time.Time) {
@@ -141,3 +161,91 @@ func (m *configDBModuleInstrumentingMW) DeleteAllAuthorizationsWithGroup(ctx con
 	}(time.Now())
 	return m.next.DeleteAllAuthorizationsWithGroup(ctx, realmID, groupName)
 }
+
+// configDBModuleInstrumentingMW implements Module.
+func (m *configDBModuleInstrumentingMW) UpdateGroupReferences(ctx context.Context, realmID, oldName, newName, oldPath, newPath string) error {
+	defer func(begin time.Time) {
+		m.h.With(KeyCorrelationID, ctx.Value(cs.CtContextCorrelationID).(string)).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	return m.next.UpdateGroupReferences(ctx, realmID, oldName, newName, oldPath, newPath)
+}
+
+// configDBModuleInstrumentingMW implements Module.
+func (m *configDBModuleInstrumentingMW) GetReportSchedules(ctx context.Context, realmName string) ([]dto.DBReportSchedule, error) {
+	defer func(begin time.Time) {
+		m.h.With(KeyCorrelationID, ctx.Value(cs.CtContextCorrelationID).(string)).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	return m.next.GetReportSchedules(ctx, realmName)
+}
+
+// configDBModuleInstrumentingMW implements Module.
+func (m *configDBModuleInstrumentingMW) GetAllReportSchedules(ctx context.Context) ([]dto.DBReportSchedule, error) {
+	defer func(begin time.Time) {
+		m.h.With(KeyCorrelationID, ctx.Value(cs.CtContextCorrelationID).(string)).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	return m.next.GetAllReportSchedules(ctx)
+}
+
+// configDBModuleInstrumentingMW implements Module.
+func (m *configDBModuleInstrumentingMW) CreateReportSchedule(ctx context.Context, schedule dto.DBReportSchedule) (int64, error) {
+	defer func(begin time.Time) {
+		m.h.With(KeyCorrelationID, ctx.Value(cs.CtContextCorrelationID).(string)).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	return m.next.CreateReportSchedule(ctx, schedule)
+}
+
+// configDBModuleInstrumentingMW implements Module.
+func (m *configDBModuleInstrumentingMW) UpdateReportSchedule(ctx context.Context, schedule dto.DBReportSchedule) error {
+	defer func(begin time.Time) {
+		m.h.With(KeyCorrelationID, ctx.Value(cs.CtContextCorrelationID).(string)).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	return m.next.UpdateReportSchedule(ctx, schedule)
+}
+
+// configDBModuleInstrumentingMW implements Module.
+func (m *configDBModuleInstrumentingMW) ClaimReportSchedule(ctx context.Context, scheduleID int64, occurrence time.Time, now time.Time) (bool, error) {
+	defer func(begin time.Time) {
+		m.h.With(KeyCorrelationID, ctx.Value(cs.CtContextCorrelationID).(string)).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	return m.next.ClaimReportSchedule(ctx, scheduleID, occurrence, now)
+}
+
+// configDBModuleInstrumentingMW implements Module.
+func (m *configDBModuleInstrumentingMW) GetClientPolicy(ctx context.Context, realmID string) (dto.ClientPolicy, error) {
+	defer func(begin time.Time) {
+		m.h.With(KeyCorrelationID, ctx.Value(cs.CtContextCorrelationID).(string)).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	return m.next.GetClientPolicy(ctx, realmID)
+}
+
+// configDBModuleInstrumentingMW implements Module.
+func (m *configDBModuleInstrumentingMW) DeleteReportSchedule(ctx context.Context, realmName string, scheduleID int64) error {
+	defer func(begin time.Time) {
+		m.h.With(KeyCorrelationID, ctx.Value(cs.CtContextCorrelationID).(string)).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	return m.next.DeleteReportSchedule(ctx, realmName, scheduleID)
+}
+
+// configDBModuleInstrumentingMW implements Module.
+func (m *configDBModuleInstrumentingMW) CreateConfigurationRevision(ctx context.Context, revision dto.DBConfigurationRevision) (int64, error) {
+	defer func(begin time.Time) {
+		m.h.With(KeyCorrelationID, ctx.Value(cs.CtContextCorrelationID).(string)).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	return m.next.CreateConfigurationRevision(ctx, revision)
+}
+
+// configDBModuleInstrumentingMW implements Module.
+func (m *configDBModuleInstrumentingMW) GetConfigurationRevisions(ctx context.Context, realmID string, configType *string) ([]dto.DBConfigurationRevision, error) {
+	defer func(begin time.Time) {
+		m.h.With(KeyCorrelationID, ctx.Value(cs.CtContextCorrelationID).(string)).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	return m.next.GetConfigurationRevisions(ctx, realmID, configType)
+}
+
+// configDBModuleInstrumentingMW implements Module.
+func (m *configDBModuleInstrumentingMW) GetConfigurationRevision(ctx context.Context, realmID string, revisionID int64) (*dto.DBConfigurationRevision, error) {
+	defer func(begin time.Time) {
+		m.h.With(KeyCorrelationID, ctx.Value(cs.CtContextCorrelationID).(string)).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	return m.next.GetConfigurationRevision(ctx, realmID, revisionID)
+}
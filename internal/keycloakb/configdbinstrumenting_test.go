@@ -105,16 +105,16 @@ func TestComponentInstrumentingMW(t *testing.T) {
 	})
 
 	t.Run("Update admin configuration with correlation ID", func(t *testing.T) {
-		mockComponent.EXPECT().StoreOrUpdateAdminConfiguration(ctx, "realmID", gomock.Any()).Return(nil).Times(1)
+		mockComponent.EXPECT().StoreOrUpdateAdminConfiguration(ctx, "realmID", gomock.Any(), gomock.Any()).Return(nil).Times(1)
 		mockHistogram.EXPECT().With("correlation_id", corrID).Return(mockHistogram).Times(1)
 		mockHistogram.EXPECT().Observe(gomock.Any()).Return().Times(1)
-		m.StoreOrUpdateAdminConfiguration(ctx, "realmID", configuration.RealmAdminConfiguration{})
+		m.StoreOrUpdateAdminConfiguration(ctx, "realmID", configuration.RealmAdminConfiguration{}, dto.ClientPolicy{})
 	})
 
 	t.Run("Update configuration without correlation ID", func(t *testing.T) {
-		mockComponent.EXPECT().StoreOrUpdateAdminConfiguration(context.Background(), "realmID", gomock.Any()).Return(nil).Times(1)
+		mockComponent.EXPECT().StoreOrUpdateAdminConfiguration(context.Background(), "realmID", gomock.Any(), gomock.Any()).Return(nil).Times(1)
 		f = func() {
-			m.StoreOrUpdateAdminConfiguration(context.Background(), "realmID", configuration.RealmAdminConfiguration{})
+			m.StoreOrUpdateAdminConfiguration(context.Background(), "realmID", configuration.RealmAdminConfiguration{}, dto.ClientPolicy{})
 		}
 		assert.Panics(t, f)
 	})
@@ -156,4 +156,60 @@ func TestComponentInstrumentingMW(t *testing.T) {
 			m.InsertBackOfficeConfiguration(context.Background(), realmID, groupName, confType, realmID, groupNames)
 		})
 	})
+
+	t.Run("Report schedules", func(t *testing.T) {
+		var schedule = dto.DBReportSchedule{ID: 7, RealmName: realmID}
+		var now = time.Now()
+		mockHistogram.EXPECT().With("correlation_id", corrID).Return(mockHistogram).Times(6)
+		mockHistogram.EXPECT().Observe(gomock.Any()).Return().Times(6)
+
+		mockComponent.EXPECT().GetReportSchedules(ctx, realmID).Return(nil, nil)
+		m.GetReportSchedules(ctx, realmID)
+		mockComponent.EXPECT().GetAllReportSchedules(ctx).Return(nil, nil)
+		m.GetAllReportSchedules(ctx)
+		mockComponent.EXPECT().CreateReportSchedule(ctx, schedule).Return(int64(7), nil)
+		m.CreateReportSchedule(ctx, schedule)
+		mockComponent.EXPECT().UpdateReportSchedule(ctx, schedule).Return(nil)
+		m.UpdateReportSchedule(ctx, schedule)
+		mockComponent.EXPECT().ClaimReportSchedule(ctx, schedule.ID, now, now).Return(true, nil)
+		m.ClaimReportSchedule(ctx, schedule.ID, now, now)
+		mockComponent.EXPECT().DeleteReportSchedule(ctx, realmID, schedule.ID).Return(nil)
+		m.DeleteReportSchedule(ctx, realmID, schedule.ID)
+	})
+
+	t.Run("Client policy", func(t *testing.T) {
+		var policy = dto.ClientPolicy{ImplicitFlowAllowed: true}
+		mockHistogram.EXPECT().With("correlation_id", corrID).Return(mockHistogram).Times(1)
+		mockHistogram.EXPECT().Observe(gomock.Any()).Return().Times(1)
+
+		mockComponent.EXPECT().GetClientPolicy(ctx, realmID).Return(policy, nil)
+		m.GetClientPolicy(ctx, realmID)
+	})
+
+	t.Run("Update group references", func(t *testing.T) {
+		mockHistogram.EXPECT().With("correlation_id", corrID).Return(mockHistogram).Times(1)
+		mockHistogram.EXPECT().Observe(gomock.Any()).Return().Times(1)
+		mockComponent.EXPECT().UpdateGroupReferences(ctx, realmID, groupName, "renamed", "/"+groupName, "/renamed").Return(nil)
+		m.UpdateGroupReferences(ctx, realmID, groupName, "renamed", "/"+groupName, "/renamed")
+	})
+
+	t.Run("Authorizations on target realm", func(t *testing.T) {
+		mockHistogram.EXPECT().With("correlation_id", corrID).Return(mockHistogram).Times(1)
+		mockHistogram.EXPECT().Observe(gomock.Any()).Return().Times(1)
+		mockComponent.EXPECT().GetAuthorizationsOnTargetRealm(ctx, realmID).Return(nil, nil)
+		m.GetAuthorizationsOnTargetRealm(ctx, realmID)
+	})
+
+	t.Run("Configuration revisions", func(t *testing.T) {
+		var revision = dto.DBConfigurationRevision{ID: 4, RealmID: realmID, ConfigType: dto.ConfigurationTypeAdmin}
+		mockHistogram.EXPECT().With("correlation_id", corrID).Return(mockHistogram).Times(3)
+		mockHistogram.EXPECT().Observe(gomock.Any()).Return().Times(3)
+
+		mockComponent.EXPECT().CreateConfigurationRevision(ctx, revision).Return(int64(4), nil)
+		m.CreateConfigurationRevision(ctx, revision)
+		mockComponent.EXPECT().GetConfigurationRevisions(ctx, realmID, nil).Return(nil, nil)
+		m.GetConfigurationRevisions(ctx, realmID, nil)
+		mockComponent.EXPECT().GetConfigurationRevision(ctx, realmID, revision.ID).Return(&revision, nil)
+		m.GetConfigurationRevision(ctx, realmID, revision.ID)
+	})
 }
@@ -5,6 +5,8 @@ import (
 	"database/sql"
 	"encoding/json"
 	"strings"
+	"time"
+	"unicode/utf8"
 
 	"github.com/cloudtrust/common-service/configuration"
 	"github.com/cloudtrust/common-service/database/sqltypes"
@@ -21,7 +23,8 @@ const (
 	updateAdminConfigStmt = `INSERT INTO realm_configuration (realm_id, admin_configuration)
 	  VALUES (?, ?)
 	  ON DUPLICATE KEY UPDATE admin_configuration = ?;`
-	selectBOConfigStmt = `
+	selectAdminConfigStmt = `SELECT admin_configuration FROM realm_configuration WHERE realm_id = ?;`
+	selectBOConfigStmt    = `
 		SELECT distinct target_realm_id, target_type, target_group_name
 		FROM backoffice_configuration
 		WHERE realm_id=? AND group_name IN (???)
@@ -38,11 +41,49 @@ const (
 		  AND (? IS NULL OR target_type=?)
 		  AND (? IS NULL OR target_group_name=?)
 	`
-	selectAuthzStmt = `SELECT realm_id, group_name, action, target_realm_id, target_group_name FROM authorizations WHERE realm_id = ? AND group_name = ?;`
-	createAuthzStmt = `INSERT INTO authorizations (realm_id, group_name, action, target_realm_id, target_group_name) 
+	selectAuthzStmt              = `SELECT realm_id, group_name, action, target_realm_id, target_group_name FROM authorizations WHERE realm_id = ? AND group_name = ?;`
+	selectAuthzOnTargetRealmStmt = `SELECT realm_id, group_name, action, target_realm_id, target_group_name FROM authorizations WHERE target_realm_id = ? OR target_realm_id = '*';`
+	createAuthzStmt              = `INSERT INTO authorizations (realm_id, group_name, action, target_realm_id, target_group_name) 
 		VALUES (?, ?, ?, ?, ?);`
 	deleteAuthzStmt             = `DELETE FROM authorizations WHERE realm_id = ? AND group_name = ?;`
 	deleteAllAuthzWithGroupStmt = `DELETE FROM authorizations WHERE (realm_id = ? AND group_name = ?) OR (target_realm_id = ? AND target_group_name = ?);`
+	renameAuthzGroupStmt        = `UPDATE authorizations SET group_name = ? WHERE realm_id = ? AND group_name = ?;`
+	renameAuthzTargetGroupStmt  = `UPDATE authorizations SET target_group_name = ? WHERE target_realm_id = ? AND target_group_name = ?;`
+	moveAuthzTargetSubtreeStmt  = `UPDATE authorizations SET target_group_name = CONCAT(?, SUBSTRING(target_group_name, ?)) WHERE target_realm_id = ? AND LEFT(target_group_name, ?) = ?;`
+	renameBOConfigGroupStmt     = `UPDATE backoffice_configuration SET group_name = ? WHERE realm_id = ? AND group_name = ?;`
+	renameBOConfigTargetStmt    = `UPDATE backoffice_configuration SET target_group_name = ? WHERE target_realm_id = ? AND target_group_name = ?;`
+	selectReportSchedulesStmt   = `
+		SELECT id, realm_id, frequency, day, hour, recipients, unix_timestamp(last_sent)
+		FROM statistics_report_schedule
+		WHERE ? IS NULL OR realm_id=?
+		ORDER BY id
+	`
+	insertReportScheduleStmt = `
+		INSERT INTO statistics_report_schedule (realm_id, frequency, day, hour, recipients)
+		VALUES (?, ?, ?, ?, ?)
+	`
+	updateReportScheduleStmt = `
+		UPDATE statistics_report_schedule
+		SET frequency=?, day=?, hour=?, recipients=?
+		WHERE realm_id=? AND id=?
+	`
+	claimReportScheduleStmt  = `UPDATE statistics_report_schedule SET last_sent=? WHERE id=? AND (last_sent IS NULL OR last_sent<?);`
+	deleteReportScheduleStmt = `DELETE FROM statistics_report_schedule WHERE realm_id=? AND id=?;`
+	insertConfigRevisionStmt = `
+		INSERT INTO realm_configuration_revision (realm_id, config_type, content, author_id, author_username, created_at, rollback_of)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`
+	selectConfigRevisionsStmt = `
+		SELECT id, realm_id, config_type, content, author_id, author_username, unix_timestamp(created_at), rollback_of
+		FROM realm_configuration_revision
+		WHERE realm_id=? AND (? IS NULL OR config_type=?)
+		ORDER BY id DESC
+	`
+	selectConfigRevisionStmt = `
+		SELECT id, realm_id, config_type, content, author_id, author_username, unix_timestamp(created_at), rollback_of
+		FROM realm_configuration_revision
+		WHERE realm_id=? AND id=?
+	`
 )
 
 // Scanner used to get data from SQL cursors
@@ -89,11 +130,15 @@ func (c *configurationDBModule) GetConfiguration(ctx context.Context, realmID st
 	return config, err
 }
 
-func (c *configurationDBModule) StoreOrUpdateAdminConfiguration(context context.Context, realmID string, config configuration.RealmAdminConfiguration) error {
-	var bytes, _ = json.Marshal(config)
+// StoreOrUpdateAdminConfiguration stores the admin configuration of a realm with its client policy
+func (c *configurationDBModule) StoreOrUpdateAdminConfiguration(context context.Context, realmID string, config configuration.RealmAdminConfiguration, clientPolicy dto.ClientPolicy) error {
+	var bytes, err = dto.MarshalAdminConfiguration(config, clientPolicy)
+	if err != nil {
+		return err
+	}
 	var configJSON = string(bytes)
 	// update value in DB
-	var _, err = c.db.Exec(updateAdminConfigStmt, realmID, configJSON, configJSON)
+	_, err = c.db.Exec(updateAdminConfigStmt, realmID, configJSON, configJSON)
 	return err
 }
 
@@ -101,6 +146,29 @@ func (c *configurationDBModule) GetAdminConfiguration(ctx context.Context, realm
 	return c.ConfigurationReaderDBModule.GetAdminConfiguration(ctx, realmID)
 }
 
+// GetClientPolicy returns the client policy stored in the admin configuration of the realm. A realm without policy gets
+// the default one.
+func (c *configurationDBModule) GetClientPolicy(ctx context.Context, realmID string) (dto.ClientPolicy, error) {
+	var configJSON sql.NullString
+	switch err := c.db.QueryRow(selectAdminConfigStmt, realmID).Scan(&configJSON); err {
+	case sql.ErrNoRows:
+		return dto.ClientPolicy{}, nil
+	case nil:
+		if !configJSON.Valid {
+			return dto.ClientPolicy{}, nil
+		}
+		var _, policy, err = dto.UnmarshalAdminConfiguration([]byte(configJSON.String))
+		if err != nil {
+			c.logger.Warn(ctx, "msg", "Can't unmarshal client policy", "error", err.Error(), "realmID", realmID)
+			return dto.ClientPolicy{}, err
+		}
+		return policy, nil
+	default:
+		c.logger.Warn(ctx, "msg", "Can't get client policy", "error", err.Error(), "realmID", realmID)
+		return dto.ClientPolicy{}, err
+	}
+}
+
 func (c *configurationDBModule) GetBackOfficeConfiguration(ctx context.Context, realmID string, groupNames []string) (dto.BackOfficeConfiguration, error) {
 	var sqlRequest = strings.Replace(selectBOConfigStmt, "???", "?"+strings.Repeat(",?", len(groupNames)-1), 1)
 	var args = []interface{}{realmID}
@@ -180,6 +248,29 @@ func (c *configurationDBModule) GetAuthorizations(ctx context.Context, realmID s
 	return res, nil
 }
 
+// GetAuthorizationsOnTargetRealm returns the authorizations of all the realms which target the given realm, including the
+// authorizations targeting any realm
+func (c *configurationDBModule) GetAuthorizationsOnTargetRealm(ctx context.Context, targetRealmID string) ([]configuration.Authorization, error) {
+	rows, err := c.db.Query(selectAuthzOnTargetRealmStmt, targetRealmID)
+	if err != nil {
+		c.logger.Warn(ctx, "msg", "Can't get authorizations on target realm", "error", err.Error(), "targetRealmID", targetRealmID)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res = make([]configuration.Authorization, 0)
+	for rows.Next() {
+		var authz, err = c.scanAuthorization(rows)
+		if err != nil {
+			c.logger.Warn(ctx, "msg", "Can't get authorizations on target realm. Scan failed", "error", err.Error(), "targetRealmID", targetRealmID)
+			return nil, err
+		}
+		res = append(res, authz)
+	}
+
+	return res, rows.Err()
+}
+
 func (c *configurationDBModule) CreateAuthorization(context context.Context, auth configuration.Authorization) error {
 	_, err := c.db.Exec(createAuthzStmt, nullableString(auth.RealmID), nullableString(auth.GroupName),
 		nullableString(auth.Action), nullableString(auth.TargetRealmID), nullableString(auth.TargetGroupName))
@@ -196,6 +287,169 @@ func (c *configurationDBModule) DeleteAllAuthorizationsWithGroup(context context
 	return err
 }
 
+// UpdateGroupReferences updates the references to a renamed or moved group in a single transaction: the authorizations
+// given to the group or targeting it, the subtree target group names built with its path and the back-office configuration
+func (c *configurationDBModule) UpdateGroupReferences(ctx context.Context, realmID, oldName, newName, oldPath, newPath string) error {
+	tx, err := c.db.BeginTx(ctx, nil)
+	if err != nil {
+		c.logger.Warn(ctx, "msg", "Can't start transaction", "error", err.Error())
+		return err
+	}
+	// Rolls back the changes if the transaction is not committed
+	defer tx.Close()
+
+	if oldName != newName {
+		var renames = []struct {
+			stmt    string
+			message string
+		}{
+			{renameAuthzGroupStmt, "Can't rename group of authorizations"},
+			{renameAuthzTargetGroupStmt, "Can't rename target group of authorizations"},
+			{renameBOConfigGroupStmt, "Can't rename group of back-office configuration"},
+			{renameBOConfigTargetStmt, "Can't rename target group of back-office configuration"},
+		}
+		for _, rename := range renames {
+			if _, err = tx.Exec(rename.stmt, newName, realmID, oldName); err != nil {
+				c.logger.Warn(ctx, "msg", rename.message, "error", err.Error(), "realmID", realmID, "groupName", oldName)
+				return err
+			}
+		}
+	}
+	if oldPath != newPath {
+		// Subtree target group names of the group and its descendants start with the path of the group. MySQL string
+		// functions count characters, not bytes.
+		var prefix = oldPath + "/"
+		var prefixLength = utf8.RuneCountInString(prefix)
+		if _, err = tx.Exec(moveAuthzTargetSubtreeStmt, newPath+"/", prefixLength+1, realmID, prefixLength, prefix); err != nil {
+			c.logger.Warn(ctx, "msg", "Can't move target groups of authorizations", "error", err.Error(), "realmID", realmID, "groupPath", oldPath)
+			return err
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		c.logger.Warn(ctx, "msg", "Can't commit group references", "error", err.Error(), "realmID", realmID, "groupName", oldName)
+		return err
+	}
+	return nil
+}
+
+func (c *configurationDBModule) GetReportSchedules(ctx context.Context, realmName string) ([]dto.DBReportSchedule, error) {
+	return c.queryReportSchedules(ctx, &realmName)
+}
+
+func (c *configurationDBModule) GetAllReportSchedules(ctx context.Context) ([]dto.DBReportSchedule, error) {
+	return c.queryReportSchedules(ctx, nil)
+}
+
+func (c *configurationDBModule) queryReportSchedules(ctx context.Context, realmName *string) ([]dto.DBReportSchedule, error) {
+	rows, err := c.db.Query(selectReportSchedulesStmt, nullableString(realmName), nullableString(realmName))
+	if err != nil {
+		c.logger.Warn(ctx, "msg", "Can't get report schedules", "error", err.Error())
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res = make([]dto.DBReportSchedule, 0)
+	for rows.Next() {
+		var schedule, err = c.scanReportSchedule(rows)
+		if err != nil {
+			c.logger.Warn(ctx, "msg", "Can't get report schedules. Scan failed", "error", err.Error())
+			return nil, err
+		}
+		res = append(res, schedule)
+	}
+
+	return res, rows.Err()
+}
+
+func (c *configurationDBModule) CreateReportSchedule(ctx context.Context, schedule dto.DBReportSchedule) (int64, error) {
+	var recipients, _ = json.Marshal(schedule.Recipients)
+	var res, err = c.db.Exec(insertReportScheduleStmt, schedule.RealmName, schedule.Frequency, schedule.Day, schedule.Hour, string(recipients))
+	if err != nil {
+		c.logger.Warn(ctx, "msg", "Can't insert report schedule", "error", err.Error(), "realmID", schedule.RealmName)
+		return 0, err
+	}
+	return res.LastInsertId()
+}
+
+func (c *configurationDBModule) UpdateReportSchedule(ctx context.Context, schedule dto.DBReportSchedule) error {
+	var recipients, _ = json.Marshal(schedule.Recipients)
+	var _, err = c.db.Exec(updateReportScheduleStmt, schedule.Frequency, schedule.Day, schedule.Hour, string(recipients), schedule.RealmName, schedule.ID)
+	if err != nil {
+		c.logger.Warn(ctx, "msg", "Can't update report schedule", "error", err.Error(), "realmID", schedule.RealmName, "scheduleID", schedule.ID)
+	}
+	return err
+}
+
+// ClaimReportSchedule marks the report as sent at the given time unless it was already sent since the given occurrence.
+// It returns false when the report was already claimed, for instance by another instance of the bridge.
+func (c *configurationDBModule) ClaimReportSchedule(ctx context.Context, scheduleID int64, occurrence time.Time, now time.Time) (bool, error) {
+	var res, err = c.db.Exec(claimReportScheduleStmt, now, scheduleID, occurrence)
+	if err != nil {
+		c.logger.Warn(ctx, "msg", "Can't claim report schedule", "error", err.Error(), "scheduleID", scheduleID)
+		return false, err
+	}
+	var count int64
+	if count, err = res.RowsAffected(); err != nil {
+		return false, err
+	}
+	return count == 1, nil
+}
+
+func (c *configurationDBModule) DeleteReportSchedule(ctx context.Context, realmName string, scheduleID int64) error {
+	var _, err = c.db.Exec(deleteReportScheduleStmt, realmName, scheduleID)
+	if err != nil {
+		c.logger.Warn(ctx, "msg", "Can't delete report schedule", "error", err.Error(), "realmID", realmName, "scheduleID", scheduleID)
+	}
+	return err
+}
+
+// CreateConfigurationRevision stores a revision of the custom or admin configuration of a realm
+func (c *configurationDBModule) CreateConfigurationRevision(ctx context.Context, revision dto.DBConfigurationRevision) (int64, error) {
+	var res, err = c.db.Exec(insertConfigRevisionStmt, revision.RealmID, revision.ConfigType, revision.Content, revision.AuthorID,
+		revision.AuthorUsername, revision.CreatedAt, nullableInt64(revision.RollbackOf))
+	if err != nil {
+		c.logger.Warn(ctx, "msg", "Can't insert configuration revision", "error", err.Error(), "realmID", revision.RealmID)
+		return 0, err
+	}
+	return res.LastInsertId()
+}
+
+// GetConfigurationRevisions returns the revisions of a realm, most recent first, filtered by configuration type if a type is given
+func (c *configurationDBModule) GetConfigurationRevisions(ctx context.Context, realmID string, configType *string) ([]dto.DBConfigurationRevision, error) {
+	var rows, err = c.db.Query(selectConfigRevisionsStmt, realmID, nullableString(configType), nullableString(configType))
+	if err != nil {
+		c.logger.Warn(ctx, "msg", "Can't get configuration revisions", "error", err.Error(), "realmID", realmID)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res = make([]dto.DBConfigurationRevision, 0)
+	for rows.Next() {
+		var revision, err = c.scanConfigurationRevision(rows)
+		if err != nil {
+			c.logger.Warn(ctx, "msg", "Can't get configuration revisions. Scan failed", "error", err.Error(), "realmID", realmID)
+			return nil, err
+		}
+		res = append(res, revision)
+	}
+
+	return res, rows.Err()
+}
+
+// GetConfigurationRevision returns a revision of the configuration of a realm or nil if it does not exist
+func (c *configurationDBModule) GetConfigurationRevision(ctx context.Context, realmID string, revisionID int64) (*dto.DBConfigurationRevision, error) {
+	var revision, err = c.scanConfigurationRevision(c.db.QueryRow(selectConfigRevisionStmt, realmID, revisionID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		c.logger.Warn(ctx, "msg", "Can't get configuration revision", "error", err.Error(), "realmID", realmID, "revisionID", revisionID)
+		return nil, err
+	}
+	return &revision, nil
+}
+
 func (c *configurationDBModule) NewTransaction(context context.Context) (sqltypes.Transaction, error) {
 	return c.db.BeginTx(context, nil)
 }
@@ -231,6 +485,58 @@ func (c *configurationDBModule) scanAuthorization(scanner Scanner) (configuratio
 	return authz, nil
 }
 
+func (c *configurationDBModule) scanReportSchedule(scanner Scanner) (dto.DBReportSchedule, error) {
+	var (
+		schedule   dto.DBReportSchedule
+		recipients string
+		lastSent   sql.NullInt64
+	)
+
+	err := scanner.Scan(&schedule.ID, &schedule.RealmName, &schedule.Frequency, &schedule.Day, &schedule.Hour, &recipients, &lastSent)
+	if err != nil {
+		return dto.DBReportSchedule{}, err
+	}
+
+	if err = json.Unmarshal([]byte(recipients), &schedule.Recipients); err != nil {
+		return dto.DBReportSchedule{}, err
+	}
+
+	if lastSent.Valid {
+		var date = time.Unix(lastSent.Int64, 0).UTC()
+		schedule.LastSent = &date
+	}
+
+	return schedule, nil
+}
+
+func (c *configurationDBModule) scanConfigurationRevision(scanner Scanner) (dto.DBConfigurationRevision, error) {
+	var (
+		revision   dto.DBConfigurationRevision
+		createdAt  int64
+		rollbackOf sql.NullInt64
+	)
+
+	err := scanner.Scan(&revision.ID, &revision.RealmID, &revision.ConfigType, &revision.Content, &revision.AuthorID,
+		&revision.AuthorUsername, &createdAt, &rollbackOf)
+	if err != nil {
+		return dto.DBConfigurationRevision{}, err
+	}
+
+	revision.CreatedAt = time.Unix(createdAt, 0).UTC()
+	if rollbackOf.Valid {
+		revision.RollbackOf = &rollbackOf.Int64
+	}
+
+	return revision, nil
+}
+
+func nullableInt64(value *int64) interface{} {
+	if value != nil {
+		return value
+	}
+	return nil
+}
+
 func nullableString(value *string) interface{} {
 	if value != nil {
 		return value
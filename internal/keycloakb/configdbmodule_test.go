@@ -7,11 +7,13 @@ import (
 	"errors"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/cloudtrust/common-service/configuration"
 	"github.com/cloudtrust/common-service/log"
 
 	msg "github.com/cloudtrust/keycloak-bridge/internal/constants"
+	"github.com/cloudtrust/keycloak-bridge/internal/dto"
 	"github.com/cloudtrust/keycloak-bridge/internal/keycloakb/mock"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
@@ -92,11 +94,11 @@ func TestStoreOrGetAdminConfiguration(t *testing.T) {
 
 	t.Run("Store-SQL fails", func(t *testing.T) {
 		mockDB.EXPECT().Exec(gomock.Any(), gomock.Any()).Return(nil, sqlError)
-		assert.Equal(t, sqlError, configDBModule.StoreOrUpdateAdminConfiguration(ctx, realmID, adminConfig))
+		assert.Equal(t, sqlError, configDBModule.StoreOrUpdateAdminConfiguration(ctx, realmID, adminConfig, dto.ClientPolicy{}))
 	})
 	t.Run("Store-success", func(t *testing.T) {
 		mockDB.EXPECT().Exec(gomock.Any(), gomock.Any()).Return(nil, nil)
-		assert.Nil(t, configDBModule.StoreOrUpdateAdminConfiguration(ctx, realmID, adminConfig))
+		assert.Nil(t, configDBModule.StoreOrUpdateAdminConfiguration(ctx, realmID, adminConfig, dto.ClientPolicy{}))
 	})
 	t.Run("Get-SQL query fails", func(t *testing.T) {
 		mockDB.EXPECT().QueryRow(gomock.Any(), realmID).Return(mockSQLRow)
@@ -124,6 +126,86 @@ func TestStoreOrGetAdminConfiguration(t *testing.T) {
 	})
 }
 
+func TestClientPolicy(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var mockDB = mock.NewCloudtrustDB(mockCtrl)
+	var mockSQLRow = mock.NewSQLRow(mockCtrl)
+
+	var configDBModule = NewConfigurationDBModule(mockDB, log.NewNopLogger())
+	var ctx = context.TODO()
+	var realmID = "myrealm"
+	var policy = dto.ClientPolicy{ImplicitFlowAllowed: true, AllowedRedirectURIPrefixes: []string{"https://app.example.com/"}}
+	var adminConfigJSON = `{"mode":"corporate","client_policy":{"implicitFlowAllowed":true,"directAccessGrantsAllowed":false,"publicClientsAllowed":false,"wildcardRedirectUrisAllowed":false,"allowedRedirectUriPrefixes":["https://app.example.com/"]}}`
+	var sqlError = errors.New("sql")
+
+	t.Run("Get-SQL query fails", func(t *testing.T) {
+		mockDB.EXPECT().QueryRow(selectAdminConfigStmt, realmID).Return(mockSQLRow)
+		mockSQLRow.EXPECT().Scan(gomock.Any()).Return(sqlError)
+		var _, err = configDBModule.GetClientPolicy(ctx, realmID)
+		assert.Equal(t, sqlError, err)
+	})
+
+	t.Run("Get-no policy", func(t *testing.T) {
+		mockDB.EXPECT().QueryRow(selectAdminConfigStmt, realmID).Return(mockSQLRow)
+		mockSQLRow.EXPECT().Scan(gomock.Any()).Return(sql.ErrNoRows)
+		var res, err = configDBModule.GetClientPolicy(ctx, realmID)
+		assert.Nil(t, err)
+		assert.Equal(t, dto.ClientPolicy{}, res)
+
+		mockDB.EXPECT().QueryRow(selectAdminConfigStmt, realmID).Return(mockSQLRow)
+		mockSQLRow.EXPECT().Scan(gomock.Any()).Return(nil)
+		res, err = configDBModule.GetClientPolicy(ctx, realmID)
+		assert.Nil(t, err)
+		assert.Equal(t, dto.ClientPolicy{}, res)
+	})
+
+	t.Run("Get-invalid policy", func(t *testing.T) {
+		mockDB.EXPECT().QueryRow(selectAdminConfigStmt, realmID).Return(mockSQLRow)
+		mockSQLRow.EXPECT().Scan(gomock.Any()).DoAndReturn(func(value *sql.NullString) error {
+			*value = sql.NullString{String: "{", Valid: true}
+			return nil
+		})
+		var _, err = configDBModule.GetClientPolicy(ctx, realmID)
+		assert.NotNil(t, err)
+	})
+
+	t.Run("Get-success", func(t *testing.T) {
+		mockDB.EXPECT().QueryRow(selectAdminConfigStmt, realmID).Return(mockSQLRow)
+		mockSQLRow.EXPECT().Scan(gomock.Any()).DoAndReturn(func(value *sql.NullString) error {
+			*value = sql.NullString{String: adminConfigJSON, Valid: true}
+			return nil
+		})
+		var res, err = configDBModule.GetClientPolicy(ctx, realmID)
+		assert.Nil(t, err)
+		assert.Equal(t, policy, res)
+	})
+
+	t.Run("Get-admin configuration without policy", func(t *testing.T) {
+		mockDB.EXPECT().QueryRow(selectAdminConfigStmt, realmID).Return(mockSQLRow)
+		mockSQLRow.EXPECT().Scan(gomock.Any()).DoAndReturn(func(value *sql.NullString) error {
+			*value = sql.NullString{String: `{"mode":"corporate"}`, Valid: true}
+			return nil
+		})
+		var res, err = configDBModule.GetClientPolicy(ctx, realmID)
+		assert.Nil(t, err)
+		assert.Equal(t, dto.ClientPolicy{}, res)
+	})
+
+	t.Run("Store with the admin configuration", func(t *testing.T) {
+		var mode = "corporate"
+		mockDB.EXPECT().Exec(updateAdminConfigStmt, realmID, gomock.Any(), gomock.Any()).DoAndReturn(func(_ string, args ...interface{}) (sql.Result, error) {
+			var config, storedPolicy, err = dto.UnmarshalAdminConfiguration([]byte(args[1].(string)))
+			assert.Nil(t, err)
+			assert.Equal(t, mode, *config.Mode)
+			assert.Equal(t, policy, storedPolicy)
+			return nil, nil
+		})
+		assert.Nil(t, configDBModule.StoreOrUpdateAdminConfiguration(ctx, realmID, configuration.RealmAdminConfiguration{Mode: &mode}, policy))
+	})
+}
+
 func TestBackOfficeConfiguration(t *testing.T) {
 	var mockCtrl = gomock.NewController(t)
 	defer mockCtrl.Finish()
@@ -207,3 +289,325 @@ func TestBackOfficeConfiguration(t *testing.T) {
 		assert.Nil(t, err)
 	})
 }
+
+type sqlResult struct {
+	id int64
+}
+
+func (r sqlResult) LastInsertId() (int64, error) {
+	return r.id, nil
+}
+
+func (r sqlResult) RowsAffected() (int64, error) {
+	return 1, nil
+}
+
+type sqlResultNoRows struct{}
+
+func (r sqlResultNoRows) LastInsertId() (int64, error) {
+	return 0, nil
+}
+
+func (r sqlResultNoRows) RowsAffected() (int64, error) {
+	return 0, nil
+}
+
+func TestUpdateGroupReferences(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var mockDB = mock.NewCloudtrustDB(mockCtrl)
+	var mockTx = mock.NewTransaction(mockCtrl)
+	var configDBModule = NewConfigurationDBModule(mockDB, log.NewNopLogger())
+	var expectedError = errors.New("error")
+	var realmID = "my-realm"
+	var ctx = context.TODO()
+
+	t.Run("Transaction can't be started", func(t *testing.T) {
+		mockDB.EXPECT().BeginTx(ctx, nil).Return(nil, expectedError)
+		assert.Equal(t, expectedError, configDBModule.UpdateGroupReferences(ctx, realmID, "old-team", "team", "/org/old-team", "/org/team"))
+	})
+
+	t.Run("Rename fails", func(t *testing.T) {
+		mockDB.EXPECT().BeginTx(ctx, nil).Return(mockTx, nil)
+		mockTx.EXPECT().Exec(renameAuthzGroupStmt, "team", realmID, "old-team").Return(nil, nil)
+		mockTx.EXPECT().Exec(renameAuthzTargetGroupStmt, "team", realmID, "old-team").Return(nil, nil)
+		mockTx.EXPECT().Exec(renameBOConfigGroupStmt, "team", realmID, "old-team").Return(nil, expectedError)
+		mockTx.EXPECT().Close()
+		assert.Equal(t, expectedError, configDBModule.UpdateGroupReferences(ctx, realmID, "old-team", "team", "/org/old-team", "/org/team"))
+	})
+
+	t.Run("Rename", func(t *testing.T) {
+		mockDB.EXPECT().BeginTx(ctx, nil).Return(mockTx, nil)
+		mockTx.EXPECT().Exec(renameAuthzGroupStmt, "team", realmID, "old-team").Return(nil, nil)
+		mockTx.EXPECT().Exec(renameAuthzTargetGroupStmt, "team", realmID, "old-team").Return(nil, nil)
+		mockTx.EXPECT().Exec(renameBOConfigGroupStmt, "team", realmID, "old-team").Return(nil, nil)
+		mockTx.EXPECT().Exec(renameBOConfigTargetStmt, "team", realmID, "old-team").Return(nil, nil)
+		mockTx.EXPECT().Exec(moveAuthzTargetSubtreeStmt, "/org/team/", 15, realmID, 14, "/org/old-team/").Return(nil, nil)
+		mockTx.EXPECT().Commit().Return(nil)
+		mockTx.EXPECT().Close()
+		assert.Nil(t, configDBModule.UpdateGroupReferences(ctx, realmID, "old-team", "team", "/org/old-team", "/org/team"))
+	})
+
+	t.Run("Move", func(t *testing.T) {
+		mockDB.EXPECT().BeginTx(ctx, nil).Return(mockTx, nil)
+		mockTx.EXPECT().Exec(moveAuthzTargetSubtreeStmt, "/team/", 11, realmID, 10, "/org/team/").Return(nil, expectedError)
+		mockTx.EXPECT().Close()
+		assert.Equal(t, expectedError, configDBModule.UpdateGroupReferences(ctx, realmID, "team", "team", "/org/team", "/team"))
+	})
+
+	t.Run("Commit fails", func(t *testing.T) {
+		mockDB.EXPECT().BeginTx(ctx, nil).Return(mockTx, nil)
+		mockTx.EXPECT().Exec(moveAuthzTargetSubtreeStmt, "/team/", 9, realmID, 8, "/équipe/").Return(nil, nil)
+		mockTx.EXPECT().Commit().Return(expectedError)
+		mockTx.EXPECT().Close()
+		assert.Equal(t, expectedError, configDBModule.UpdateGroupReferences(ctx, realmID, "team", "team", "/équipe", "/team"))
+	})
+}
+
+func TestGetAuthorizationsOnTargetRealm(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var mockDB = mock.NewCloudtrustDB(mockCtrl)
+	var mockSQLRows = mock.NewSQLRows(mockCtrl)
+	var configDBModule = NewConfigurationDBModule(mockDB, log.NewNopLogger())
+	var expectedError = errors.New("error")
+	var targetRealmID = "my-realm"
+	var ctx = context.TODO()
+
+	t.Run("Query fails", func(t *testing.T) {
+		mockDB.EXPECT().Query(selectAuthzOnTargetRealmStmt, targetRealmID).Return(nil, expectedError)
+		var _, err = configDBModule.GetAuthorizationsOnTargetRealm(ctx, targetRealmID)
+		assert.Equal(t, expectedError, err)
+	})
+
+	t.Run("Scan fails", func(t *testing.T) {
+		mockDB.EXPECT().Query(selectAuthzOnTargetRealmStmt, targetRealmID).Return(mockSQLRows, nil)
+		mockSQLRows.EXPECT().Next().Return(true)
+		mockSQLRows.EXPECT().Scan(gomock.Any()).Return(expectedError)
+		mockSQLRows.EXPECT().Close()
+		var _, err = configDBModule.GetAuthorizationsOnTargetRealm(ctx, targetRealmID)
+		assert.Equal(t, expectedError, err)
+	})
+
+	t.Run("Success", func(t *testing.T) {
+		gomock.InOrder(
+			mockDB.EXPECT().Query(selectAuthzOnTargetRealmStmt, targetRealmID).Return(mockSQLRows, nil),
+			mockSQLRows.EXPECT().Next().Return(true),
+			mockSQLRows.EXPECT().Scan(gomock.Any()).DoAndReturn(func(realmID, groupName, action *string, targetRealm, targetGroup *sql.NullString) error {
+				*realmID = "master"
+				*groupName = "admins"
+				*action = "MGMT_GetUsers"
+				*targetRealm = sql.NullString{String: "*", Valid: true}
+				*targetGroup = sql.NullString{String: "*", Valid: true}
+				return nil
+			}),
+			mockSQLRows.EXPECT().Next().Return(false),
+			mockSQLRows.EXPECT().Err().Return(nil),
+			mockSQLRows.EXPECT().Close(),
+		)
+		var authorizations, err = configDBModule.GetAuthorizationsOnTargetRealm(ctx, targetRealmID)
+		assert.Nil(t, err)
+		assert.Len(t, authorizations, 1)
+		assert.Equal(t, "admins", *authorizations[0].GroupName)
+		assert.Equal(t, "*", *authorizations[0].TargetRealmID)
+	})
+}
+
+func TestReportSchedules(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var mockDB = mock.NewCloudtrustDB(mockCtrl)
+	var mockSQLRows = mock.NewSQLRows(mockCtrl)
+	var mockLogger = log.NewNopLogger()
+
+	var configDBModule = NewConfigurationDBModule(mockDB, mockLogger)
+	var expectedError = errors.New("error")
+	var realmName = "my-realm"
+	var schedule = dto.DBReportSchedule{
+		ID:         12,
+		RealmName:  realmName,
+		Frequency:  dto.ReportFrequencyWeekly,
+		Day:        1,
+		Hour:       8,
+		Recipients: []string{"admin@example.com"},
+	}
+	var ctx = context.TODO()
+
+	t.Run("GET-SQL query fails", func(t *testing.T) {
+		mockDB.EXPECT().Query(gomock.Any(), &realmName, &realmName).Return(nil, expectedError)
+		var _, err = configDBModule.GetReportSchedules(ctx, realmName)
+		assert.Equal(t, expectedError, err)
+	})
+	t.Run("GET-Scan fails", func(t *testing.T) {
+		mockDB.EXPECT().Query(gomock.Any(), nil, nil).Return(mockSQLRows, nil)
+		mockSQLRows.EXPECT().Next().Return(true)
+		mockSQLRows.EXPECT().Scan(gomock.Any()).Return(expectedError)
+		mockSQLRows.EXPECT().Close()
+		var _, err = configDBModule.GetAllReportSchedules(ctx)
+		assert.Equal(t, expectedError, err)
+	})
+	t.Run("GET-Scan ok", func(t *testing.T) {
+		gomock.InOrder(
+			mockDB.EXPECT().Query(gomock.Any(), &realmName, &realmName).Return(mockSQLRows, nil),
+			mockSQLRows.EXPECT().Next().Return(true),
+			mockSQLRows.EXPECT().Scan(gomock.Any()).DoAndReturn(func(id *int64, realm *string, frequency *string, day *int, hour *int, recipients *string, lastSent *sql.NullInt64) error {
+				*id = schedule.ID
+				*realm = schedule.RealmName
+				*frequency = schedule.Frequency
+				*day = schedule.Day
+				*hour = schedule.Hour
+				*recipients = `["admin@example.com"]`
+				*lastSent = sql.NullInt64{Int64: 1600000000, Valid: true}
+				return nil
+			}),
+			mockSQLRows.EXPECT().Next().Return(false),
+			mockSQLRows.EXPECT().Err().Return(nil),
+			mockSQLRows.EXPECT().Close(),
+		)
+		var schedules, err = configDBModule.GetReportSchedules(ctx, realmName)
+		assert.Nil(t, err)
+		assert.Len(t, schedules, 1)
+		assert.Equal(t, schedule.Recipients, schedules[0].Recipients)
+		assert.Equal(t, int64(1600000000), schedules[0].LastSent.Unix())
+	})
+
+	t.Run("INSERT-Fails", func(t *testing.T) {
+		mockDB.EXPECT().Exec(gomock.Any(), realmName, schedule.Frequency, schedule.Day, schedule.Hour, `["admin@example.com"]`).Return(nil, expectedError)
+		var _, err = configDBModule.CreateReportSchedule(ctx, schedule)
+		assert.Equal(t, expectedError, err)
+	})
+	t.Run("INSERT-Success", func(t *testing.T) {
+		mockDB.EXPECT().Exec(gomock.Any(), realmName, schedule.Frequency, schedule.Day, schedule.Hour, `["admin@example.com"]`).Return(sqlResult{id: 12}, nil)
+		var id, err = configDBModule.CreateReportSchedule(ctx, schedule)
+		assert.Nil(t, err)
+		assert.Equal(t, int64(12), id)
+	})
+
+	t.Run("UPDATE", func(t *testing.T) {
+		mockDB.EXPECT().Exec(gomock.Any(), schedule.Frequency, schedule.Day, schedule.Hour, `["admin@example.com"]`, realmName, schedule.ID).Return(nil, expectedError)
+		assert.Equal(t, expectedError, configDBModule.UpdateReportSchedule(ctx, schedule))
+	})
+	t.Run("Claim", func(t *testing.T) {
+		var now = time.Now()
+		var occurrence = now.Add(-time.Hour)
+
+		mockDB.EXPECT().Exec(gomock.Any(), now, schedule.ID, occurrence).Return(nil, expectedError)
+		var _, err = configDBModule.ClaimReportSchedule(ctx, schedule.ID, occurrence, now)
+		assert.Equal(t, expectedError, err)
+
+		mockDB.EXPECT().Exec(gomock.Any(), now, schedule.ID, occurrence).Return(sqlResult{}, nil)
+		claimed, err := configDBModule.ClaimReportSchedule(ctx, schedule.ID, occurrence, now)
+		assert.Nil(t, err)
+		assert.True(t, claimed)
+
+		mockDB.EXPECT().Exec(gomock.Any(), now, schedule.ID, occurrence).Return(sqlResultNoRows{}, nil)
+		claimed, err = configDBModule.ClaimReportSchedule(ctx, schedule.ID, occurrence, now)
+		assert.Nil(t, err)
+		assert.False(t, claimed)
+	})
+	t.Run("DELETE", func(t *testing.T) {
+		mockDB.EXPECT().Exec(gomock.Any(), realmName, schedule.ID).Return(nil, nil)
+		assert.Nil(t, configDBModule.DeleteReportSchedule(ctx, realmName, schedule.ID))
+	})
+}
+
+func TestConfigurationRevisions(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var mockDB = mock.NewCloudtrustDB(mockCtrl)
+	var mockSQLRow = mock.NewSQLRow(mockCtrl)
+	var mockSQLRows = mock.NewSQLRows(mockCtrl)
+
+	var configDBModule = NewConfigurationDBModule(mockDB, log.NewNopLogger())
+	var expectedError = errors.New("error")
+	var realmID = "my-realm"
+	var configType = dto.ConfigurationTypeCustom
+	var now = time.Unix(1600000000, 0).UTC()
+	var revision = dto.DBConfigurationRevision{
+		ID:             5,
+		RealmID:        realmID,
+		ConfigType:     configType,
+		Content:        `{"default_client_id":"my-client"}`,
+		AuthorID:       "author-id",
+		AuthorUsername: "author",
+		CreatedAt:      now,
+	}
+	var ctx = context.TODO()
+	var scanRevision = func(id *int64, realm *string, confType *string, content *string, authorID *string, authorUsername *string, createdAt *int64, rollbackOf *sql.NullInt64) error {
+		*id = revision.ID
+		*realm = revision.RealmID
+		*confType = revision.ConfigType
+		*content = revision.Content
+		*authorID = revision.AuthorID
+		*authorUsername = revision.AuthorUsername
+		*createdAt = now.Unix()
+		*rollbackOf = sql.NullInt64{Int64: 2, Valid: true}
+		return nil
+	}
+
+	t.Run("INSERT-Fails", func(t *testing.T) {
+		mockDB.EXPECT().Exec(insertConfigRevisionStmt, realmID, configType, revision.Content, revision.AuthorID, revision.AuthorUsername, now, nil).Return(nil, expectedError)
+		var _, err = configDBModule.CreateConfigurationRevision(ctx, revision)
+		assert.Equal(t, expectedError, err)
+	})
+	t.Run("INSERT-Success", func(t *testing.T) {
+		mockDB.EXPECT().Exec(insertConfigRevisionStmt, realmID, configType, revision.Content, revision.AuthorID, revision.AuthorUsername, now, nil).Return(sqlResult{id: 5}, nil)
+		var id, err = configDBModule.CreateConfigurationRevision(ctx, revision)
+		assert.Nil(t, err)
+		assert.Equal(t, int64(5), id)
+	})
+
+	t.Run("GET revisions-Query fails", func(t *testing.T) {
+		mockDB.EXPECT().Query(selectConfigRevisionsStmt, realmID, &configType, &configType).Return(nil, expectedError)
+		var _, err = configDBModule.GetConfigurationRevisions(ctx, realmID, &configType)
+		assert.Equal(t, expectedError, err)
+	})
+	t.Run("GET revisions-Scan fails", func(t *testing.T) {
+		mockDB.EXPECT().Query(selectConfigRevisionsStmt, realmID, nil, nil).Return(mockSQLRows, nil)
+		mockSQLRows.EXPECT().Next().Return(true)
+		mockSQLRows.EXPECT().Scan(gomock.Any()).Return(expectedError)
+		mockSQLRows.EXPECT().Close()
+		var _, err = configDBModule.GetConfigurationRevisions(ctx, realmID, nil)
+		assert.Equal(t, expectedError, err)
+	})
+	t.Run("GET revisions-Success", func(t *testing.T) {
+		gomock.InOrder(
+			mockDB.EXPECT().Query(selectConfigRevisionsStmt, realmID, nil, nil).Return(mockSQLRows, nil),
+			mockSQLRows.EXPECT().Next().Return(true),
+			mockSQLRows.EXPECT().Scan(gomock.Any()).DoAndReturn(scanRevision),
+			mockSQLRows.EXPECT().Next().Return(false),
+			mockSQLRows.EXPECT().Err().Return(nil),
+			mockSQLRows.EXPECT().Close(),
+		)
+		var revisions, err = configDBModule.GetConfigurationRevisions(ctx, realmID, nil)
+		assert.Nil(t, err)
+		assert.Len(t, revisions, 1)
+		assert.Equal(t, now, revisions[0].CreatedAt)
+		assert.Equal(t, int64(2), *revisions[0].RollbackOf)
+	})
+
+	t.Run("GET revision-Not found", func(t *testing.T) {
+		mockDB.EXPECT().QueryRow(selectConfigRevisionStmt, realmID, revision.ID).Return(mockSQLRow)
+		mockSQLRow.EXPECT().Scan(gomock.Any()).Return(sql.ErrNoRows)
+		var res, err = configDBModule.GetConfigurationRevision(ctx, realmID, revision.ID)
+		assert.Nil(t, err)
+		assert.Nil(t, res)
+	})
+	t.Run("GET revision-Fails", func(t *testing.T) {
+		mockDB.EXPECT().QueryRow(selectConfigRevisionStmt, realmID, revision.ID).Return(mockSQLRow)
+		mockSQLRow.EXPECT().Scan(gomock.Any()).Return(expectedError)
+		var _, err = configDBModule.GetConfigurationRevision(ctx, realmID, revision.ID)
+		assert.Equal(t, expectedError, err)
+	})
+	t.Run("GET revision-Success", func(t *testing.T) {
+		mockDB.EXPECT().QueryRow(selectConfigRevisionStmt, realmID, revision.ID).Return(mockSQLRow)
+		mockSQLRow.EXPECT().Scan(gomock.Any()).DoAndReturn(scanRevision)
+		var res, err = configDBModule.GetConfigurationRevision(ctx, realmID, revision.ID)
+		assert.Nil(t, err)
+		assert.Equal(t, revision.Content, res.Content)
+	})
+}
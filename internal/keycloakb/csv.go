@@ -0,0 +1,23 @@
+package keycloakb
+
+import "strings"
+
+// csvFormulaPrefixes are the first characters which make a spreadsheet evaluate a cell as a formula
+const csvFormulaPrefixes = "=+-@\t\r"
+
+// EscapeCSVCell prefixes with a quote the values a spreadsheet would evaluate as a formula when opening a CSV file
+func EscapeCSVCell(value string) string {
+	if value != "" && strings.IndexByte(csvFormulaPrefixes, value[0]) >= 0 {
+		return "'" + value
+	}
+	return value
+}
+
+// EscapeCSVRecord escapes all the cells of a CSV record
+func EscapeCSVRecord(record []string) []string {
+	var res = make([]string, len(record))
+	for i, value := range record {
+		res[i] = EscapeCSVCell(value)
+	}
+	return res
+}
@@ -0,0 +1,25 @@
+package keycloakb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEscapeCSVCell(t *testing.T) {
+	t.Run("Plain values are unchanged", func(t *testing.T) {
+		assert.Equal(t, "", EscapeCSVCell(""))
+		assert.Equal(t, "jdoe", EscapeCSVCell("jdoe"))
+		assert.Equal(t, "a=b", EscapeCSVCell("a=b"))
+	})
+	t.Run("Formulas are prefixed with a quote", func(t *testing.T) {
+		for _, value := range []string{"=1+1", "+41791234567", "-2+3", "@SUM(A1:A2)", "\t=1", "\r=1"} {
+			assert.Equal(t, "'"+value, EscapeCSVCell(value))
+		}
+	})
+	t.Run("Record", func(t *testing.T) {
+		var record = []string{"jdoe", "=HYPERLINK(\"http://evil\")"}
+		assert.Equal(t, []string{"jdoe", "'=HYPERLINK(\"http://evil\")"}, EscapeCSVRecord(record))
+		assert.Equal(t, "=HYPERLINK(\"http://evil\")", record[1])
+	})
+}
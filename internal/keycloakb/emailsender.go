@@ -0,0 +1,132 @@
+package keycloakb
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strconv"
+	"sync"
+)
+
+// Email senders which can be enabled by configuration
+const (
+	EmailSenderKeycloak = "keycloak"
+	EmailSenderSMTP     = "smtp"
+	EmailSenderFake     = "fake"
+)
+
+// Email is an email to be sent. Keycloak renders the template of the realm theme with the attributes while SMTP
+// sends the plain text body.
+type Email struct {
+	Recipient  string
+	Subject    string
+	Template   string
+	Attributes map[string]string
+	Body       string
+}
+
+// EmailSender is the interface of the email senders
+type EmailSender interface {
+	SendEmail(ctx context.Context, realmName string, email Email) error
+}
+
+// KeycloakEmailClient is the method of keycloak-client used to send emails
+type KeycloakEmailClient interface {
+	SendEmail(accessToken, realmName, template, subject string, recipient *string, attributes map[string]string) error
+}
+
+// TokenProvider is the interface to retrieve accessToken to access KC
+type TokenProvider interface {
+	ProvideToken(ctx context.Context) (string, error)
+}
+
+type keycloakEmailSender struct {
+	keycloakClient KeycloakEmailClient
+	tokenProvider  TokenProvider
+}
+
+// NewKeycloakEmailSender creates an email sender using the email facility of Keycloak. Keycloak is called with the
+// token of the technical user.
+func NewKeycloakEmailSender(keycloakClient KeycloakEmailClient, tokenProvider TokenProvider) EmailSender {
+	return &keycloakEmailSender{
+		keycloakClient: keycloakClient,
+		tokenProvider:  tokenProvider,
+	}
+}
+
+func (s *keycloakEmailSender) SendEmail(ctx context.Context, realmName string, email Email) error {
+	var accessToken, err = s.tokenProvider.ProvideToken(ctx)
+	if err != nil {
+		return err
+	}
+	var recipient = email.Recipient
+	return s.keycloakClient.SendEmail(accessToken, realmName, email.Template, email.Subject, &recipient, email.Attributes)
+}
+
+type smtpEmailSender struct {
+	addr     string
+	from     string
+	auth     smtp.Auth
+	sendMail func(addr string, a smtp.Auth, from string, to []string, msg []byte) error
+}
+
+// NewSMTPEmailSender creates an email sender using an SMTP server. Authentication is only used when a username is provided.
+func NewSMTPEmailSender(host string, port int, username, password, from string) EmailSender {
+	var auth smtp.Auth
+	if username != "" {
+		auth = smtp.PlainAuth("", username, password, host)
+	}
+	return &smtpEmailSender{
+		addr:     net.JoinHostPort(host, strconv.Itoa(port)),
+		from:     from,
+		auth:     auth,
+		sendMail: smtp.SendMail,
+	}
+}
+
+func (s *smtpEmailSender) SendEmail(_ context.Context, _ string, email Email) error {
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", s.from)
+	fmt.Fprintf(&msg, "To: %s\r\n", email.Recipient)
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", email.Subject))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	msg.WriteString("\r\n")
+	msg.WriteString(email.Body)
+
+	return s.sendMail(s.addr, s.auth, s.from, []string{email.Recipient}, msg.Bytes())
+}
+
+// FakeEmailSender keeps the emails in memory instead of sending them. It is intended for tests and local environments.
+type FakeEmailSender struct {
+	logger Logger
+	mutex  sync.Mutex
+	emails []Email
+}
+
+// NewFakeEmailSender creates a fake email sender
+func NewFakeEmailSender(logger Logger) *FakeEmailSender {
+	return &FakeEmailSender{
+		logger: logger,
+	}
+}
+
+// SendEmail records the email
+func (s *FakeEmailSender) SendEmail(ctx context.Context, realmName string, email Email) error {
+	s.logger.Info(ctx, "msg", "Email not sent (fake sender)", "realm", realmName, "recipient", email.Recipient, "subject", email.Subject)
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.emails = append(s.emails, email)
+	return nil
+}
+
+// Emails returns the recorded emails
+func (s *FakeEmailSender) Emails() []Email {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return append([]Email{}, s.emails...)
+}
@@ -0,0 +1,73 @@
+package keycloakb
+
+import (
+	"context"
+	"errors"
+	"net/smtp"
+	"strings"
+	"testing"
+
+	"github.com/cloudtrust/common-service/log"
+	"github.com/cloudtrust/keycloak-bridge/internal/keycloakb/mock"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeycloakEmailSender(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var mockKeycloakClient = mock.NewKeycloakEmailClient(mockCtrl)
+	var mockTokenProvider = mock.NewTokenProvider(mockCtrl)
+	var sender = NewKeycloakEmailSender(mockKeycloakClient, mockTokenProvider)
+
+	var ctx = context.TODO()
+	var realm = "my-realm"
+	var accessToken = "TOKEN=="
+	var email = Email{
+		Recipient:  "admin@example.com",
+		Subject:    "subject",
+		Template:   "template.ftl",
+		Attributes: map[string]string{"key": "value"},
+	}
+
+	t.Run("Can't get token", func(t *testing.T) {
+		var expectedErr = errors.New("no token")
+		mockTokenProvider.EXPECT().ProvideToken(ctx).Return("", expectedErr)
+		assert.Equal(t, expectedErr, sender.SendEmail(ctx, realm, email))
+	})
+
+	t.Run("Success", func(t *testing.T) {
+		mockTokenProvider.EXPECT().ProvideToken(ctx).Return(accessToken, nil)
+		mockKeycloakClient.EXPECT().SendEmail(accessToken, realm, email.Template, email.Subject, &email.Recipient, email.Attributes).Return(nil)
+		assert.Nil(t, sender.SendEmail(ctx, realm, email))
+	})
+}
+
+func TestSMTPEmailSender(t *testing.T) {
+	var sender = NewSMTPEmailSender("localhost", 25, "", "", "bridge@example.com").(*smtpEmailSender)
+	var sentTo []string
+	var sentMsg string
+	sender.sendMail = func(addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+		assert.Equal(t, "localhost:25", addr)
+		assert.Nil(t, a)
+		assert.Equal(t, "bridge@example.com", from)
+		sentTo = to
+		sentMsg = string(msg)
+		return nil
+	}
+
+	var err = sender.SendEmail(context.TODO(), "my-realm", Email{Recipient: "admin@example.com", Subject: "Report", Body: "Logins: 3"})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"admin@example.com"}, sentTo)
+	assert.True(t, strings.HasPrefix(sentMsg, "From: bridge@example.com\r\nTo: admin@example.com\r\nSubject: Report\r\n"))
+	assert.True(t, strings.HasSuffix(sentMsg, "\r\n\r\nLogins: 3"))
+}
+
+func TestFakeEmailSender(t *testing.T) {
+	var sender = NewFakeEmailSender(log.NewNopLogger())
+	var email = Email{Recipient: "admin@example.com"}
+
+	assert.Nil(t, sender.SendEmail(context.TODO(), "my-realm", email))
+	assert.Equal(t, []Email{email}, sender.Emails())
+}
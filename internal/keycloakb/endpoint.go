@@ -5,6 +5,7 @@ import (
 	"time"
 
 	cs "github.com/cloudtrust/common-service"
+	"github.com/cloudtrust/common-service/metrics"
 	"github.com/go-kit/kit/endpoint"
 	"github.com/go-kit/kit/ratelimit"
 	"golang.org/x/time/rate"
@@ -21,3 +22,28 @@ func ToGoKitEndpoint(e cs.Endpoint) endpoint.Endpoint {
 func LimitRate(e cs.Endpoint, limit int) endpoint.Endpoint {
 	return ratelimit.NewErroringLimiter(rate.NewLimiter(rate.Every(time.Second), limit))(ToGoKitEndpoint(e))
 }
+
+// MakeEndpointInstrumentingMW makes an instrumenting middleware at endpoint level. Durations are labelled with
+// the correlation ID and the realm targeted by the request
+func MakeEndpointInstrumentingMW(m metrics.Metrics, histoName string) func(cs.Endpoint) cs.Endpoint {
+	var h = m.NewHistogram(histoName)
+	return func(next cs.Endpoint) cs.Endpoint {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			defer func(begin time.Time) {
+				var correlationID, _ = ctx.Value(cs.CtContextCorrelationID).(string)
+				h.With(KeyCorrelationID, correlationID, KeyRealm, getTargetRealm(ctx, req)).Observe(time.Since(begin).Seconds())
+			}(time.Now())
+			return next(ctx, req)
+		}
+	}
+}
+
+func getTargetRealm(ctx context.Context, req interface{}) string {
+	if m, ok := req.(map[string]string); ok {
+		if realm, ok := m[KeyRealm]; ok {
+			return realm
+		}
+	}
+	var realm, _ = ctx.Value(cs.CtContextRealm).(string)
+	return realm
+}
@@ -5,6 +5,8 @@ import (
 	"testing"
 
 	cs "github.com/cloudtrust/common-service"
+	"github.com/cloudtrust/keycloak-bridge/internal/keycloakb/mock"
+	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -19,3 +21,32 @@ func TestLimitRate(t *testing.T) {
 	assert.Nil(t, err)
 	assert.Equal(t, "dummy", res)
 }
+
+func TestMakeEndpointInstrumentingMW(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var mockMetrics = mock.NewMetrics(mockCtrl)
+	var mockHistogram = mock.NewHistogram(mockCtrl)
+	var corrID = "corr-id"
+	var ctx = context.WithValue(context.Background(), cs.CtContextCorrelationID, corrID)
+	ctx = context.WithValue(ctx, cs.CtContextRealm, "master")
+
+	mockMetrics.EXPECT().NewHistogram("my_endpoint").Return(mockHistogram)
+	var e = MakeEndpointInstrumentingMW(mockMetrics, "my_endpoint")(makeDummyEndpoint())
+
+	t.Run("Target realm from request", func(t *testing.T) {
+		mockHistogram.EXPECT().With(KeyCorrelationID, corrID, KeyRealm, "target").Return(mockHistogram)
+		mockHistogram.EXPECT().Observe(gomock.Any())
+		var res, err = e(ctx, map[string]string{"realm": "target"})
+		assert.Nil(t, err)
+		assert.Equal(t, "dummy", res)
+	})
+
+	t.Run("Current realm when no realm in request", func(t *testing.T) {
+		mockHistogram.EXPECT().With(KeyCorrelationID, corrID, KeyRealm, "master").Return(mockHistogram)
+		mockHistogram.EXPECT().Observe(gomock.Any())
+		var _, err = e(ctx, nil)
+		assert.Nil(t, err)
+	})
+}
@@ -27,6 +27,10 @@ type EventsDBModule interface {
 	GetTotalConnectionsDaysCount(context.Context, string, *time.Location, int) ([][]int64, error)
 	GetTotalConnectionsMonthsCount(context.Context, string, *time.Location, int) ([][]int64, error)
 	GetLastConnections(context.Context, string, string) ([]api_stat.StatisticsConnectionRepresentation, error)
+	GetEventsCountByType(context.Context, string, time.Time, time.Time) (map[string]int64, error)
+	GetConnectionsCountByCountry(context.Context, string, time.Time) (map[string]int64, error)
+	GetConnectionsCountByDevice(context.Context, string, time.Time) ([]api_stat.StatisticsDeviceRepresentation, error)
+	GetUserConnections(context.Context, string, string, int) ([]api_stat.StatisticsUserConnectionRepresentation, error)
 }
 
 type eventsDBModule struct {
@@ -105,6 +109,33 @@ const (
 							ORDER BY audit_time DESC
 							LIMIT ?;
 				`
+	selectEventsCountByTypeStmt = `
+			SELECT ct_event_type, count(1)
+			FROM audit
+			WHERE realm_name=?
+			  AND audit_time >= ? AND audit_time < ?
+			GROUP BY ct_event_type
+	`
+	selectConnectionsCountByCountryStmt = `
+			SELECT country, count(1)
+			FROM audit_authentication
+			WHERE realm_name=? AND audit_time >= ?
+			GROUP BY country
+	`
+	selectConnectionsCountByDeviceStmt = `
+			SELECT device_class, os, browser, count(1)
+			FROM audit_authentication
+			WHERE realm_name=? AND audit_time >= ?
+			GROUP BY device_class, os, browser
+			ORDER BY count(1) DESC
+	`
+	selectUserConnectionsStmt = `
+			SELECT unix_timestamp(audit_time), ip_address, country, device_class, os, browser, new_device, new_country
+			FROM audit_authentication
+			WHERE realm_name=? AND user_id=?
+			ORDER BY audit_time DESC
+			LIMIT ?
+	`
 )
 
 func createAuditEventsParametersFromMap(m map[string]string) (selectAuditEventsParameters, error) {
@@ -335,6 +366,89 @@ func (cm *eventsDBModule) GetLastConnections(_ context.Context, realmName string
 	return res, err
 }
 
+// GetEventsCountByType gives the number of events of each type which occurred in the given realm during the given period (end excluded)
+func (cm *eventsDBModule) GetEventsCountByType(_ context.Context, realmName string, from time.Time, to time.Time) (map[string]int64, error) {
+	rows, err := cm.db.Query(selectEventsCountByTypeStmt, realmName, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res = map[string]int64{}
+	for rows.Next() {
+		var ctEventType string
+		var count int64
+		if err = rows.Scan(&ctEventType, &count); err != nil {
+			return nil, err
+		}
+		res[ctEventType] = count
+	}
+
+	return res, rows.Err()
+}
+
+// GetConnectionsCountByCountry gives the number of successful authentications per country since the given date. Unresolved
+// countries are counted with an empty key.
+func (cm *eventsDBModule) GetConnectionsCountByCountry(_ context.Context, realmName string, from time.Time) (map[string]int64, error) {
+	rows, err := cm.db.Query(selectConnectionsCountByCountryStmt, realmName, from)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res = map[string]int64{}
+	for rows.Next() {
+		var country string
+		var count int64
+		if err = rows.Scan(&country, &count); err != nil {
+			return nil, err
+		}
+		res[country] = count
+	}
+
+	return res, rows.Err()
+}
+
+// GetConnectionsCountByDevice gives the number of successful authentications per kind of device since the given date
+func (cm *eventsDBModule) GetConnectionsCountByDevice(_ context.Context, realmName string, from time.Time) ([]api_stat.StatisticsDeviceRepresentation, error) {
+	rows, err := cm.db.Query(selectConnectionsCountByDeviceStmt, realmName, from)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res = []api_stat.StatisticsDeviceRepresentation{}
+	for rows.Next() {
+		var device api_stat.StatisticsDeviceRepresentation
+		if err = rows.Scan(&device.DeviceClass, &device.OS, &device.Browser, &device.Count); err != nil {
+			return nil, err
+		}
+		res = append(res, device)
+	}
+
+	return res, rows.Err()
+}
+
+// GetUserConnections gives the last successful authentications of a user
+func (cm *eventsDBModule) GetUserConnections(_ context.Context, realmName string, userID string, max int) ([]api_stat.StatisticsUserConnectionRepresentation, error) {
+	rows, err := cm.db.Query(selectUserConnectionsStmt, realmName, userID, max)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res = []api_stat.StatisticsUserConnectionRepresentation{}
+	for rows.Next() {
+		var conn api_stat.StatisticsUserConnectionRepresentation
+		if err = rows.Scan(&conn.Date, &conn.IP, &conn.Country, &conn.DeviceClass, &conn.OS, &conn.Browser, &conn.NewDevice, &conn.NewCountry); err != nil {
+			return nil, err
+		}
+		res = append(res, conn)
+	}
+
+	return res, rows.Err()
+}
+
 func getSQLParam(m map[string]string, name string, defaultValue interface{}) interface{} {
 	if value, ok := m[name]; ok {
 		return value
@@ -3,7 +3,9 @@ package keycloakb
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"testing"
+	"time"
 
 	errorhandler "github.com/cloudtrust/common-service/errors"
 	api "github.com/cloudtrust/keycloak-bridge/api/events"
@@ -91,6 +93,50 @@ func TestModuleGetTotalConnectionsCount(t *testing.T) {
 	}
 }
 
+func TestModuleGetEventsCountByType(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	dbEvents := mock.NewDBEvents(mockCtrl)
+	module := NewEventsDBModule(dbEvents)
+
+	var to = time.Now()
+	var from = to.Add(-24 * time.Hour)
+	var expectedError = errors.New("db error")
+	dbEvents.EXPECT().Query(gomock.Any(), "realm", from, to).Return(nil, expectedError)
+
+	var _, err = module.GetEventsCountByType(context.TODO(), "realm", from, to)
+	assert.Equal(t, expectedError, err)
+}
+
+func TestModuleEnrichedConnections(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	dbEvents := mock.NewDBEvents(mockCtrl)
+	module := NewEventsDBModule(dbEvents)
+
+	var from = time.Now().Add(-24 * time.Hour)
+	var expectedError = errors.New("db error")
+	var ctx = context.TODO()
+
+	t.Run("By country", func(t *testing.T) {
+		dbEvents.EXPECT().Query(gomock.Any(), "realm", from).Return(nil, expectedError)
+		var _, err = module.GetConnectionsCountByCountry(ctx, "realm", from)
+		assert.Equal(t, expectedError, err)
+	})
+	t.Run("By device", func(t *testing.T) {
+		dbEvents.EXPECT().Query(gomock.Any(), "realm", from).Return(nil, expectedError)
+		var _, err = module.GetConnectionsCountByDevice(ctx, "realm", from)
+		assert.Equal(t, expectedError, err)
+	})
+	t.Run("User connections", func(t *testing.T) {
+		dbEvents.EXPECT().Query(gomock.Any(), "realm", "user-id", 10).Return(nil, expectedError)
+		var _, err = module.GetUserConnections(ctx, "realm", "user-id", 10)
+		assert.Equal(t, expectedError, err)
+	})
+}
+
 func TestCreateStats(t *testing.T) {
 	assert.Equal(t, [][]int64{{3, 0}, {2, 0}, {9, 0}, {8, 0}, {7, 0}}, createStats(5, 3, 2, 9, true))
 	assert.Equal(t, [][]int64{{7, 0}, {8, 0}, {9, 0}, {2, 0}, {3, 0}}, createStats(5, 3, 2, 9, false))
@@ -0,0 +1,45 @@
+package keycloakb
+
+import (
+	"net"
+
+	"github.com/oschwald/geoip2-golang"
+)
+
+// GeoIPLocator resolves IP addresses to the ISO code of their country
+type GeoIPLocator interface {
+	Country(ipAddress string) (string, error)
+	Close() error
+}
+
+type geoIPLocator struct {
+	reader *geoip2.Reader
+}
+
+// NewGeoIPLocator creates a locator using an offline GeoIP2 or GeoLite2 country database (mmdb file)
+func NewGeoIPLocator(databasePath string) (GeoIPLocator, error) {
+	var reader, err = geoip2.Open(databasePath)
+	if err != nil {
+		return nil, err
+	}
+	return &geoIPLocator{
+		reader: reader,
+	}, nil
+}
+
+// Country returns an empty string when the IP address is invalid or not found in the database (private networks, ...)
+func (l *geoIPLocator) Country(ipAddress string) (string, error) {
+	var ip = net.ParseIP(ipAddress)
+	if ip == nil {
+		return "", nil
+	}
+	var record, err = l.reader.Country(ip)
+	if err != nil {
+		return "", err
+	}
+	return record.Country.IsoCode, nil
+}
+
+func (l *geoIPLocator) Close() error {
+	return l.reader.Close()
+}
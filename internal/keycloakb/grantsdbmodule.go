@@ -0,0 +1,135 @@
+package keycloakb
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/cloudtrust/common-service/database/sqltypes"
+	"github.com/cloudtrust/common-service/log"
+	"github.com/cloudtrust/keycloak-bridge/internal/dto"
+)
+
+const (
+	insertTemporaryGrantStmt = `
+		INSERT INTO temporary_grants (realm_id, user_id, grant_type, target_id, client_id, name, expires_at, granted_by)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE client_id=?, name=?, expires_at=?, granted_by=?
+	`
+	selectUserTemporaryGrantsStmt = `
+		SELECT realm_id, user_id, grant_type, target_id, client_id, name, unix_timestamp(expires_at), granted_by
+		FROM temporary_grants
+		WHERE realm_id=? AND user_id=?
+		ORDER BY expires_at
+	`
+	selectExpiredTemporaryGrantsStmt = `
+		SELECT realm_id, user_id, grant_type, target_id, client_id, name, unix_timestamp(expires_at), granted_by
+		FROM temporary_grants
+		WHERE expires_at<=?
+		ORDER BY expires_at
+	`
+	deleteTemporaryGrantStmt  = `DELETE FROM temporary_grants WHERE realm_id=? AND user_id=? AND grant_type=? AND target_id=?;`
+	deleteTemporaryGrantsStmt = `DELETE FROM temporary_grants WHERE realm_id=? AND user_id=? AND grant_type=?;`
+)
+
+// TemporaryGrantsDBModule is the interface of the module storing the expiry of the temporary group memberships and role grants
+type TemporaryGrantsDBModule interface {
+	StoreTemporaryGrant(ctx context.Context, grant dto.DBTemporaryGrant) error
+	GetUserTemporaryGrants(ctx context.Context, realm string, userID string) ([]dto.DBTemporaryGrant, error)
+	GetExpiredTemporaryGrants(ctx context.Context, now time.Time) ([]dto.DBTemporaryGrant, error)
+	DeleteTemporaryGrant(ctx context.Context, realm string, userID string, grantType string, targetID string) error
+	DeleteTemporaryGrants(ctx context.Context, realm string, userID string, grantType string) error
+}
+
+type temporaryGrantsDBModule struct {
+	db     sqltypes.CloudtrustDB
+	logger log.Logger
+}
+
+// NewTemporaryGrantsDBModule returns a temporary grants DB module
+func NewTemporaryGrantsDBModule(db sqltypes.CloudtrustDB, logger log.Logger) TemporaryGrantsDBModule {
+	return &temporaryGrantsDBModule{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// StoreTemporaryGrant stores a grant or updates its expiry if it already exists
+func (c *temporaryGrantsDBModule) StoreTemporaryGrant(ctx context.Context, grant dto.DBTemporaryGrant) error {
+	var _, err = c.db.Exec(insertTemporaryGrantStmt, grant.RealmName, grant.UserID, grant.GrantType, grant.TargetID, grant.ClientID, grant.Name,
+		grant.ExpiresAt, grant.GrantedBy, grant.ClientID, grant.Name, grant.ExpiresAt, grant.GrantedBy)
+	if err != nil {
+		c.logger.Warn(ctx, "msg", "Can't store temporary grant", "error", err.Error(), "realmID", grant.RealmName, "userID", grant.UserID,
+			"type", grant.GrantType, "targetID", grant.TargetID)
+	}
+	return err
+}
+
+func (c *temporaryGrantsDBModule) GetUserTemporaryGrants(ctx context.Context, realm string, userID string) ([]dto.DBTemporaryGrant, error) {
+	var res, err = c.queryTemporaryGrants(selectUserTemporaryGrantsStmt, realm, userID)
+	if err != nil {
+		c.logger.Warn(ctx, "msg", "Can't get temporary grants of user", "error", err.Error(), "realmID", realm, "userID", userID)
+	}
+	return res, err
+}
+
+func (c *temporaryGrantsDBModule) GetExpiredTemporaryGrants(ctx context.Context, now time.Time) ([]dto.DBTemporaryGrant, error) {
+	var res, err = c.queryTemporaryGrants(selectExpiredTemporaryGrantsStmt, now)
+	if err != nil {
+		c.logger.Warn(ctx, "msg", "Can't get expired temporary grants", "error", err.Error())
+	}
+	return res, err
+}
+
+func (c *temporaryGrantsDBModule) queryTemporaryGrants(query string, args ...interface{}) ([]dto.DBTemporaryGrant, error) {
+	var rows, err = c.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res = make([]dto.DBTemporaryGrant, 0)
+	for rows.Next() {
+		var grant, err = c.scanTemporaryGrant(rows)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, grant)
+	}
+	return res, rows.Err()
+}
+
+func (c *temporaryGrantsDBModule) DeleteTemporaryGrant(ctx context.Context, realm string, userID string, grantType string, targetID string) error {
+	var _, err = c.db.Exec(deleteTemporaryGrantStmt, realm, userID, grantType, targetID)
+	if err != nil {
+		c.logger.Warn(ctx, "msg", "Can't delete temporary grant", "error", err.Error(), "realmID", realm, "userID", userID, "type", grantType,
+			"targetID", targetID)
+	}
+	return err
+}
+
+// DeleteTemporaryGrants deletes all the grants of the given type of a user
+func (c *temporaryGrantsDBModule) DeleteTemporaryGrants(ctx context.Context, realm string, userID string, grantType string) error {
+	var _, err = c.db.Exec(deleteTemporaryGrantsStmt, realm, userID, grantType)
+	if err != nil {
+		c.logger.Warn(ctx, "msg", "Can't delete temporary grants", "error", err.Error(), "realmID", realm, "userID", userID, "type", grantType)
+	}
+	return err
+}
+
+func (c *temporaryGrantsDBModule) scanTemporaryGrant(scanner Scanner) (dto.DBTemporaryGrant, error) {
+	var (
+		grant     dto.DBTemporaryGrant
+		clientID  sql.NullString
+		name      sql.NullString
+		expiresAt int64
+	)
+
+	if err := scanner.Scan(&grant.RealmName, &grant.UserID, &grant.GrantType, &grant.TargetID, &clientID, &name, &expiresAt, &grant.GrantedBy); err != nil {
+		return dto.DBTemporaryGrant{}, err
+	}
+	grant.ClientID = nullStringToPtr(clientID)
+	grant.Name = nullStringToPtr(name)
+	grant.ExpiresAt = time.Unix(expiresAt, 0).UTC()
+	return grant, nil
+}
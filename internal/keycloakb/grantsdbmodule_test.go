@@ -0,0 +1,90 @@
+package keycloakb
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/cloudtrust/common-service/log"
+	"github.com/cloudtrust/keycloak-bridge/internal/dto"
+	"github.com/cloudtrust/keycloak-bridge/internal/keycloakb/mock"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTemporaryGrantsDBModule(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+	var mockDB = mock.NewCloudtrustDB(mockCtrl)
+	var mockSQLRows = mock.NewSQLRows(mockCtrl)
+
+	var module = NewTemporaryGrantsDBModule(mockDB, log.NewNopLogger())
+	var ctx = context.TODO()
+	var realm = "my-realm"
+	var userID = "user-id"
+	var clientID = "client-id"
+	var roleName = "role"
+	var now = time.Unix(1591000000, 0).UTC()
+	var expectedError = errors.New("db error")
+	var scanGrant = func(dest ...interface{}) error {
+		*(dest[0].(*string)) = realm
+		*(dest[1].(*string)) = userID
+		*(dest[2].(*string)) = dto.GrantTypeClientRole
+		*(dest[3].(*string)) = "role-id"
+		*(dest[4].(*sql.NullString)) = sql.NullString{String: clientID, Valid: true}
+		*(dest[5].(*sql.NullString)) = sql.NullString{String: roleName, Valid: true}
+		*(dest[6].(*int64)) = now.Unix()
+		*(dest[7].(*string)) = "operator"
+		return nil
+	}
+
+	t.Run("Store temporary grant", func(t *testing.T) {
+		var grant = dto.DBTemporaryGrant{RealmName: realm, UserID: userID, GrantType: dto.GrantTypeGroup, TargetID: "group-id", ExpiresAt: now,
+			GrantedBy: "operator"}
+		mockDB.EXPECT().Exec(insertTemporaryGrantStmt, realm, userID, dto.GrantTypeGroup, "group-id", gomock.Nil(), gomock.Nil(), now, "operator",
+			gomock.Nil(), gomock.Nil(), now, "operator").Return(nil, expectedError)
+		assert.Equal(t, expectedError, module.StoreTemporaryGrant(ctx, grant))
+	})
+
+	t.Run("Get temporary grants of user", func(t *testing.T) {
+		mockDB.EXPECT().Query(selectUserTemporaryGrantsStmt, realm, userID).Return(nil, expectedError)
+		var _, err = module.GetUserTemporaryGrants(ctx, realm, userID)
+		assert.Equal(t, expectedError, err)
+
+		gomock.InOrder(
+			mockDB.EXPECT().Query(selectUserTemporaryGrantsStmt, realm, userID).Return(mockSQLRows, nil),
+			mockSQLRows.EXPECT().Next().Return(true),
+			mockSQLRows.EXPECT().Scan(gomock.Any()).DoAndReturn(scanGrant),
+			mockSQLRows.EXPECT().Next().Return(false),
+			mockSQLRows.EXPECT().Err().Return(nil),
+			mockSQLRows.EXPECT().Close(),
+		)
+		grants, err := module.GetUserTemporaryGrants(ctx, realm, userID)
+		assert.Nil(t, err)
+		assert.Len(t, grants, 1)
+		assert.Equal(t, clientID, *grants[0].ClientID)
+		assert.Equal(t, roleName, *grants[0].Name)
+		assert.Equal(t, now, grants[0].ExpiresAt)
+	})
+
+	t.Run("Get expired temporary grants", func(t *testing.T) {
+		gomock.InOrder(
+			mockDB.EXPECT().Query(selectExpiredTemporaryGrantsStmt, now).Return(mockSQLRows, nil),
+			mockSQLRows.EXPECT().Next().Return(true),
+			mockSQLRows.EXPECT().Scan(gomock.Any()).Return(expectedError),
+			mockSQLRows.EXPECT().Close(),
+		)
+		var _, err = module.GetExpiredTemporaryGrants(ctx, now)
+		assert.Equal(t, expectedError, err)
+	})
+
+	t.Run("Delete temporary grants", func(t *testing.T) {
+		mockDB.EXPECT().Exec(deleteTemporaryGrantStmt, realm, userID, dto.GrantTypeGroup, "group-id").Return(nil, nil)
+		assert.Nil(t, module.DeleteTemporaryGrant(ctx, realm, userID, dto.GrantTypeGroup, "group-id"))
+
+		mockDB.EXPECT().Exec(deleteTemporaryGrantsStmt, realm, userID, dto.GrantTypeTrustIDGroup).Return(nil, expectedError)
+		assert.Equal(t, expectedError, module.DeleteTemporaryGrants(ctx, realm, userID, dto.GrantTypeTrustIDGroup))
+	})
+}
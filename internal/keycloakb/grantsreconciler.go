@@ -0,0 +1,141 @@
+package keycloakb
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	cs "github.com/cloudtrust/common-service"
+	"github.com/cloudtrust/common-service/database"
+	"github.com/cloudtrust/common-service/idgenerator"
+	"github.com/cloudtrust/common-service/log"
+	"github.com/cloudtrust/keycloak-bridge/internal/constants"
+	"github.com/cloudtrust/keycloak-bridge/internal/dto"
+	kc "github.com/cloudtrust/keycloak-client"
+	"github.com/pkg/errors"
+)
+
+// GrantsKeycloakClient are methods from keycloak-client used to remove the expired grants
+type GrantsKeycloakClient interface {
+	GetUser(accessToken string, realmName, userID string) (kc.UserRepresentation, error)
+	UpdateUser(accessToken string, realmName, userID string, user kc.UserRepresentation) error
+	DeleteGroupFromUser(accessToken string, realmName, userID, groupID string) error
+	DeleteClientRolesFromUserRoleMapping(accessToken string, realmName, userID, clientID string, roles []kc.RoleRepresentation) error
+}
+
+// GrantsReconciler removes the temporary group memberships and role grants once they are expired.
+// Keycloak is called with the token of the technical user.
+type GrantsReconciler interface {
+	RemoveExpiredGrants(ctx context.Context, now time.Time) error
+	Run(c <-chan time.Time)
+}
+
+type grantsReconciler struct {
+	keycloakClient GrantsKeycloakClient
+	tokenProvider  TokenProvider
+	grantsDB       TemporaryGrantsDBModule
+	eventsDB       database.EventsDBModule
+	idGenerator    idgenerator.IDGenerator
+	logger         log.Logger
+}
+
+// NewGrantsReconciler creates the reconciler of the temporary grants
+func NewGrantsReconciler(keycloakClient GrantsKeycloakClient, tokenProvider TokenProvider, grantsDB TemporaryGrantsDBModule,
+	eventsDB database.EventsDBModule, idGenerator idgenerator.IDGenerator, logger log.Logger) GrantsReconciler {
+	return &grantsReconciler{
+		keycloakClient: keycloakClient,
+		tokenProvider:  tokenProvider,
+		grantsDB:       grantsDB,
+		eventsDB:       eventsDB,
+		idGenerator:    idGenerator,
+		logger:         logger,
+	}
+}
+
+// Run removes the expired grants at each tick of the given channel
+func (r *grantsReconciler) Run(c <-chan time.Time) {
+	for now := range c {
+		var ctx = context.WithValue(context.Background(), cs.CtContextCorrelationID, r.idGenerator.NextID())
+		if err := r.RemoveExpiredGrants(ctx, now); err != nil {
+			r.logger.Warn(ctx, "msg", "Can't remove expired grants", "err", err.Error())
+		}
+	}
+}
+
+// RemoveExpiredGrants removes the grants whose expiry is passed. A failing grant does not prevent the others from being removed,
+// it is retried at the next run.
+func (r *grantsReconciler) RemoveExpiredGrants(ctx context.Context, now time.Time) error {
+	var grants, err = r.grantsDB.GetExpiredTemporaryGrants(ctx, now)
+	if err != nil || len(grants) == 0 {
+		return err
+	}
+
+	var accessToken string
+	if accessToken, err = r.tokenProvider.ProvideToken(ctx); err != nil {
+		r.logger.Warn(ctx, "msg", "Can't get technical token", "err", err.Error())
+		return err
+	}
+
+	for _, grant := range grants {
+		if err := r.removeGrant(ctx, accessToken, grant); err != nil {
+			r.logger.Warn(ctx, "msg", "Can't remove expired grant", "err", err.Error(), "realm", grant.RealmName, "userID", grant.UserID,
+				"type", grant.GrantType, "targetID", grant.TargetID)
+		}
+	}
+	return nil
+}
+
+func (r *grantsReconciler) removeGrant(ctx context.Context, accessToken string, grant dto.DBTemporaryGrant) error {
+	var err error
+	switch grant.GrantType {
+	case dto.GrantTypeGroup:
+		err = r.keycloakClient.DeleteGroupFromUser(accessToken, grant.RealmName, grant.UserID, grant.TargetID)
+	case dto.GrantTypeTrustIDGroup:
+		err = r.removeTrustIDGroup(accessToken, grant)
+	case dto.GrantTypeClientRole:
+		var role = kc.RoleRepresentation{ID: &grant.TargetID, Name: grant.Name}
+		err = r.keycloakClient.DeleteClientRolesFromUserRoleMapping(accessToken, grant.RealmName, grant.UserID, *grant.ClientID, []kc.RoleRepresentation{role})
+	}
+	// The user, the group or the role may have been deleted in the meantime
+	if e, ok := errors.Cause(err).(kc.HTTPError); ok && e.HTTPStatus == http.StatusNotFound {
+		err = nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if err = r.grantsDB.DeleteTemporaryGrant(ctx, grant.RealmName, grant.UserID, grant.GrantType, grant.TargetID); err != nil {
+		return err
+	}
+
+	var additionalInfo = database.CreateAdditionalInfo("grant_type", grant.GrantType, "target_id", grant.TargetID, "granted_by", grant.GrantedBy)
+	var values = []string{database.CtEventRealmName, grant.RealmName, database.CtEventUserID, grant.UserID, database.CtEventAdditionalInfo, additionalInfo}
+	if errEvent := r.eventsDB.ReportEvent(ctx, "TEMPORARY_GRANT_EXPIRED", "back-office", values...); errEvent != nil {
+		LogUnrecordedEvent(ctx, r.logger, "TEMPORARY_GRANT_EXPIRED", errEvent.Error(), values...)
+	}
+	return nil
+}
+
+func (r *grantsReconciler) removeTrustIDGroup(accessToken string, grant dto.DBTemporaryGrant) error {
+	var user, err = r.keycloakClient.GetUser(accessToken, grant.RealmName, grant.UserID)
+	if err != nil {
+		return err
+	}
+	ConvertLegacyAttribute(&user)
+
+	var groups = []string{}
+	var found = false
+	for _, group := range user.GetAttribute(constants.AttrbTrustIDGroups) {
+		if group == "/"+grant.TargetID {
+			found = true
+		} else {
+			groups = append(groups, group)
+		}
+	}
+	if !found {
+		return nil
+	}
+
+	(*user.Attributes)[constants.AttrbTrustIDGroups] = groups
+	return r.keycloakClient.UpdateUser(accessToken, grant.RealmName, grant.UserID, user)
+}
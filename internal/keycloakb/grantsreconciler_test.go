@@ -0,0 +1,92 @@
+package keycloakb
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	cs "github.com/cloudtrust/common-service"
+	"github.com/cloudtrust/common-service/database"
+	"github.com/cloudtrust/common-service/log"
+	"github.com/cloudtrust/keycloak-bridge/internal/constants"
+	"github.com/cloudtrust/keycloak-bridge/internal/dto"
+	"github.com/cloudtrust/keycloak-bridge/internal/keycloakb/mock"
+	kc "github.com/cloudtrust/keycloak-client"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRemoveExpiredGrants(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+	var mockKeycloakClient = mock.NewGrantsKeycloakClient(mockCtrl)
+	var mockTokenProvider = mock.NewTokenProvider(mockCtrl)
+	var mockGrantsDB = mock.NewTemporaryGrantsDBModule(mockCtrl)
+	var mockEventsDB = mock.NewEventsDBModule(mockCtrl)
+	var mockIDGenerator = mock.NewIDGenerator(mockCtrl)
+
+	var reconciler = NewGrantsReconciler(mockKeycloakClient, mockTokenProvider, mockGrantsDB, mockEventsDB, mockIDGenerator, log.NewNopLogger())
+
+	var ctx = context.TODO()
+	var accessToken = "TOKEN=="
+	var realm = "my-realm"
+	var userID = "user-id"
+	var clientID = "client-id"
+	var roleName = "role"
+	var now = time.Now()
+	var groupGrant = dto.DBTemporaryGrant{RealmName: realm, UserID: userID, GrantType: dto.GrantTypeGroup, TargetID: "group-id"}
+	var trustIDGrant = dto.DBTemporaryGrant{RealmName: realm, UserID: userID, GrantType: dto.GrantTypeTrustIDGroup, TargetID: "l1_support_agent"}
+	var roleGrant = dto.DBTemporaryGrant{RealmName: realm, UserID: userID, GrantType: dto.GrantTypeClientRole, TargetID: "role-id", ClientID: &clientID,
+		Name: &roleName}
+
+	t.Run("Nothing to remove", func(t *testing.T) {
+		mockGrantsDB.EXPECT().GetExpiredTemporaryGrants(ctx, now).Return([]dto.DBTemporaryGrant{}, nil)
+		assert.Nil(t, reconciler.RemoveExpiredGrants(ctx, now))
+	})
+
+	t.Run("Can't get technical token", func(t *testing.T) {
+		var expectedError = errors.New("kc error")
+		mockGrantsDB.EXPECT().GetExpiredTemporaryGrants(ctx, now).Return([]dto.DBTemporaryGrant{groupGrant}, nil)
+		mockTokenProvider.EXPECT().ProvideToken(ctx).Return("", expectedError)
+		assert.Equal(t, expectedError, reconciler.RemoveExpiredGrants(ctx, now))
+	})
+
+	t.Run("Failing grant does not prevent the others from being removed", func(t *testing.T) {
+		var attributes = kc.Attributes{constants.AttrbTrustIDGroups: []string{"/l1_support_agent", "/registration_officer"}}
+		mockGrantsDB.EXPECT().GetExpiredTemporaryGrants(ctx, now).Return([]dto.DBTemporaryGrant{groupGrant, trustIDGrant, roleGrant}, nil)
+		mockTokenProvider.EXPECT().ProvideToken(ctx).Return(accessToken, nil)
+		mockKeycloakClient.EXPECT().DeleteGroupFromUser(accessToken, realm, userID, "group-id").Return(errors.New("kc error"))
+		mockKeycloakClient.EXPECT().GetUser(accessToken, realm, userID).Return(kc.UserRepresentation{Attributes: &attributes}, nil)
+		mockKeycloakClient.EXPECT().UpdateUser(accessToken, realm, userID, gomock.Any()).DoAndReturn(func(_, _, _ string, user kc.UserRepresentation) error {
+			assert.Equal(t, []string{"/registration_officer"}, user.GetAttribute(constants.AttrbTrustIDGroups))
+			return nil
+		})
+		mockGrantsDB.EXPECT().DeleteTemporaryGrant(ctx, realm, userID, dto.GrantTypeTrustIDGroup, "l1_support_agent").Return(nil)
+		mockKeycloakClient.EXPECT().DeleteClientRolesFromUserRoleMapping(accessToken, realm, userID, clientID, gomock.Any()).Return(kc.HTTPError{HTTPStatus: 404})
+		mockGrantsDB.EXPECT().DeleteTemporaryGrant(ctx, realm, userID, dto.GrantTypeClientRole, "role-id").Return(nil)
+		mockEventsDB.EXPECT().ReportEvent(ctx, "TEMPORARY_GRANT_EXPIRED", "back-office", database.CtEventRealmName, realm, database.CtEventUserID, userID,
+			database.CtEventAdditionalInfo, gomock.Any()).Return(nil).Times(2)
+		assert.Nil(t, reconciler.RemoveExpiredGrants(ctx, now))
+	})
+
+	t.Run("TrustID group already removed", func(t *testing.T) {
+		mockGrantsDB.EXPECT().GetExpiredTemporaryGrants(ctx, now).Return([]dto.DBTemporaryGrant{trustIDGrant}, nil)
+		mockTokenProvider.EXPECT().ProvideToken(ctx).Return(accessToken, nil)
+		mockKeycloakClient.EXPECT().GetUser(accessToken, realm, userID).Return(kc.UserRepresentation{}, nil)
+		mockGrantsDB.EXPECT().DeleteTemporaryGrant(ctx, realm, userID, dto.GrantTypeTrustIDGroup, "l1_support_agent").Return(nil)
+		mockEventsDB.EXPECT().ReportEvent(ctx, "TEMPORARY_GRANT_EXPIRED", "back-office", gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(),
+			gomock.Any(), gomock.Any()).Return(errors.New("db error"))
+		assert.Nil(t, reconciler.RemoveExpiredGrants(ctx, now))
+	})
+
+	t.Run("Run", func(t *testing.T) {
+		var c = make(chan time.Time, 1)
+		var ctxRun = context.WithValue(context.Background(), cs.CtContextCorrelationID, "corr-id")
+		mockIDGenerator.EXPECT().NextID().Return("corr-id")
+		mockGrantsDB.EXPECT().GetExpiredTemporaryGrants(ctxRun, now).Return(nil, errors.New("db error"))
+		c <- now
+		close(c)
+		reconciler.Run(c)
+	})
+}
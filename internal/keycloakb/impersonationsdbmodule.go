@@ -0,0 +1,93 @@
+package keycloakb
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/cloudtrust/common-service/database/sqltypes"
+	"github.com/cloudtrust/common-service/log"
+	"github.com/cloudtrust/keycloak-bridge/internal/dto"
+)
+
+const (
+	insertImpersonationStmt = `
+		INSERT INTO impersonations (realm_id, user_id, session_id, justification, started_at, expires_at, agent_realm, agent_id, agent_username)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	selectExpiredImpersonationsStmt = `
+		SELECT id, realm_id, user_id, session_id, justification, unix_timestamp(started_at), unix_timestamp(expires_at), agent_realm, agent_id, agent_username
+		FROM impersonations
+		WHERE expires_at<=?
+		ORDER BY expires_at, id
+	`
+	deleteImpersonationStmt = `DELETE FROM impersonations WHERE id=?;`
+)
+
+// ImpersonationsDBModule is the interface of the module storing the running impersonations
+type ImpersonationsDBModule interface {
+	CreateImpersonation(ctx context.Context, impersonation dto.DBImpersonation) (int64, error)
+	GetExpiredImpersonations(ctx context.Context, now time.Time) ([]dto.DBImpersonation, error)
+	DeleteImpersonation(ctx context.Context, impersonationID int64) error
+}
+
+type impersonationsDBModule struct {
+	db     sqltypes.CloudtrustDB
+	logger log.Logger
+}
+
+// NewImpersonationsDBModule returns an impersonations DB module
+func NewImpersonationsDBModule(db sqltypes.CloudtrustDB, logger log.Logger) ImpersonationsDBModule {
+	return &impersonationsDBModule{
+		db:     db,
+		logger: logger,
+	}
+}
+
+func (c *impersonationsDBModule) CreateImpersonation(ctx context.Context, impersonation dto.DBImpersonation) (int64, error) {
+	var res, err = c.db.Exec(insertImpersonationStmt, impersonation.RealmName, impersonation.UserID, impersonation.SessionID, impersonation.Justification,
+		impersonation.StartedAt, impersonation.ExpiresAt, impersonation.AgentRealm, impersonation.AgentID, impersonation.AgentUsername)
+	if err != nil {
+		c.logger.Warn(ctx, "msg", "Can't insert impersonation", "error", err.Error(), "realmID", impersonation.RealmName, "userID", impersonation.UserID)
+		return 0, err
+	}
+	return res.LastInsertId()
+}
+
+func (c *impersonationsDBModule) GetExpiredImpersonations(ctx context.Context, now time.Time) ([]dto.DBImpersonation, error) {
+	var rows, err = c.db.Query(selectExpiredImpersonationsStmt, now)
+	if err != nil {
+		c.logger.Warn(ctx, "msg", "Can't get expired impersonations", "error", err.Error())
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res = make([]dto.DBImpersonation, 0)
+	for rows.Next() {
+		var (
+			impersonation dto.DBImpersonation
+			sessionID     sql.NullString
+			startedAt     int64
+			expiresAt     int64
+		)
+		err = rows.Scan(&impersonation.ID, &impersonation.RealmName, &impersonation.UserID, &sessionID, &impersonation.Justification, &startedAt,
+			&expiresAt, &impersonation.AgentRealm, &impersonation.AgentID, &impersonation.AgentUsername)
+		if err != nil {
+			c.logger.Warn(ctx, "msg", "Can't get impersonation from the DB", "error", err.Error())
+			return nil, err
+		}
+		impersonation.SessionID = nullStringToPtr(sessionID)
+		impersonation.StartedAt = time.Unix(startedAt, 0).UTC()
+		impersonation.ExpiresAt = time.Unix(expiresAt, 0).UTC()
+		res = append(res, impersonation)
+	}
+	return res, rows.Err()
+}
+
+func (c *impersonationsDBModule) DeleteImpersonation(ctx context.Context, impersonationID int64) error {
+	var _, err = c.db.Exec(deleteImpersonationStmt, impersonationID)
+	if err != nil {
+		c.logger.Warn(ctx, "msg", "Can't delete impersonation", "error", err.Error(), "impersonationID", impersonationID)
+	}
+	return err
+}
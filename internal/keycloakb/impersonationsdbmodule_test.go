@@ -0,0 +1,87 @@
+package keycloakb
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/cloudtrust/common-service/log"
+	"github.com/cloudtrust/keycloak-bridge/internal/dto"
+	"github.com/cloudtrust/keycloak-bridge/internal/keycloakb/mock"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestImpersonationsDBModule(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+	var mockDB = mock.NewCloudtrustDB(mockCtrl)
+	var mockSQLRows = mock.NewSQLRows(mockCtrl)
+
+	var module = NewImpersonationsDBModule(mockDB, log.NewNopLogger())
+	var ctx = context.TODO()
+	var realm = "my-realm"
+	var userID = "user-id"
+	var sessionID = "session-id"
+	var now = time.Unix(1591000000, 0).UTC()
+	var expectedError = errors.New("db error")
+
+	t.Run("Create impersonation", func(t *testing.T) {
+		var impersonation = dto.DBImpersonation{RealmName: realm, UserID: userID, SessionID: &sessionID, Justification: "ticket #42",
+			StartedAt: now, ExpiresAt: now.Add(time.Hour), AgentRealm: "master", AgentID: "agent-id", AgentUsername: "agent"}
+		mockDB.EXPECT().Exec(insertImpersonationStmt, realm, userID, &sessionID, "ticket #42", now, now.Add(time.Hour), "master", "agent-id",
+			"agent").Return(nil, expectedError)
+		var _, err = module.CreateImpersonation(ctx, impersonation)
+		assert.Equal(t, expectedError, err)
+
+		mockDB.EXPECT().Exec(insertImpersonationStmt, gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(),
+			gomock.Any(), gomock.Any(), gomock.Any()).Return(sqlResult{id: 4}, nil)
+		id, err := module.CreateImpersonation(ctx, impersonation)
+		assert.Nil(t, err)
+		assert.Equal(t, int64(4), id)
+	})
+
+	t.Run("Get expired impersonations", func(t *testing.T) {
+		mockDB.EXPECT().Query(selectExpiredImpersonationsStmt, now).Return(nil, expectedError)
+		var _, err = module.GetExpiredImpersonations(ctx, now)
+		assert.Equal(t, expectedError, err)
+
+		gomock.InOrder(
+			mockDB.EXPECT().Query(selectExpiredImpersonationsStmt, now).Return(mockSQLRows, nil),
+			mockSQLRows.EXPECT().Next().Return(true),
+			mockSQLRows.EXPECT().Scan(gomock.Any()).DoAndReturn(func(dest ...interface{}) error {
+				*(dest[0].(*int64)) = 4
+				*(dest[1].(*string)) = realm
+				*(dest[2].(*string)) = userID
+				*(dest[3].(*sql.NullString)) = sql.NullString{String: sessionID, Valid: true}
+				*(dest[5].(*int64)) = now.Unix() - 3600
+				*(dest[6].(*int64)) = now.Unix()
+				*(dest[9].(*string)) = "agent"
+				return nil
+			}),
+			mockSQLRows.EXPECT().Next().Return(true),
+			mockSQLRows.EXPECT().Scan(gomock.Any()).Return(nil),
+			mockSQLRows.EXPECT().Next().Return(false),
+			mockSQLRows.EXPECT().Err().Return(nil),
+			mockSQLRows.EXPECT().Close(),
+		)
+		impersonations, err := module.GetExpiredImpersonations(ctx, now)
+		assert.Nil(t, err)
+		assert.Len(t, impersonations, 2)
+		assert.Equal(t, int64(4), impersonations[0].ID)
+		assert.Equal(t, sessionID, *impersonations[0].SessionID)
+		assert.Equal(t, now, impersonations[0].ExpiresAt)
+		assert.Equal(t, "agent", impersonations[0].AgentUsername)
+		assert.Nil(t, impersonations[1].SessionID)
+	})
+
+	t.Run("Delete impersonation", func(t *testing.T) {
+		mockDB.EXPECT().Exec(deleteImpersonationStmt, int64(4)).Return(nil, expectedError)
+		assert.Equal(t, expectedError, module.DeleteImpersonation(ctx, 4))
+
+		mockDB.EXPECT().Exec(deleteImpersonationStmt, int64(4)).Return(sqlResult{}, nil)
+		assert.Nil(t, module.DeleteImpersonation(ctx, 4))
+	})
+}
@@ -0,0 +1,125 @@
+package keycloakb
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	cs "github.com/cloudtrust/common-service"
+	"github.com/cloudtrust/common-service/idgenerator"
+)
+
+// Job status values
+const (
+	JobStatusRunning   = "running"
+	JobStatusSucceeded = "succeeded"
+	JobStatusFailed    = "failed"
+)
+
+// JobFunc is the work executed in background by a job. It can report its progress using the provided callback.
+type JobFunc func(ctx context.Context, progress func(done, total int)) (interface{}, error)
+
+// Job is a snapshot of the state of a background job
+type Job struct {
+	ID        string
+	Realm     string
+	Status    string
+	Done      int
+	Total     int
+	Result    interface{}
+	Err       error
+	StartedAt time.Time
+	EndedAt   *time.Time
+}
+
+// JobStore runs jobs in background and keeps their state in memory until they expire
+type JobStore interface {
+	Start(ctx context.Context, realm string, fn JobFunc) string
+	Get(realm string, jobID string) (Job, bool)
+}
+
+type jobStore struct {
+	idGenerator idgenerator.IDGenerator
+	retention   time.Duration
+	mutex       sync.RWMutex
+	jobs        map[string]*Job
+}
+
+// NewJobStore creates a job store. Finished jobs are forgotten once the retention delay is elapsed.
+func NewJobStore(idGenerator idgenerator.IDGenerator, retention time.Duration) JobStore {
+	return &jobStore{
+		idGenerator: idGenerator,
+		retention:   retention,
+		jobs:        map[string]*Job{},
+	}
+}
+
+// Start launches the given function in background and returns the identifier of the created job
+func (s *jobStore) Start(ctx context.Context, realm string, fn JobFunc) string {
+	var job = &Job{
+		ID:        s.idGenerator.NextID(),
+		Realm:     realm,
+		Status:    JobStatusRunning,
+		StartedAt: time.Now(),
+	}
+
+	s.mutex.Lock()
+	s.purgeExpired(job.StartedAt)
+	s.jobs[job.ID] = job
+	s.mutex.Unlock()
+
+	go func(ctx context.Context) {
+		var res, err = fn(ctx, func(done, total int) {
+			s.mutex.Lock()
+			defer s.mutex.Unlock()
+			job.Done = done
+			job.Total = total
+		})
+
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+		var now = time.Now()
+		job.EndedAt = &now
+		if err != nil {
+			job.Status = JobStatusFailed
+			job.Err = err
+		} else {
+			job.Status = JobStatusSucceeded
+			job.Result = res
+		}
+	}(DetachContext(ctx))
+
+	return job.ID
+}
+
+// Get returns a snapshot of the job. A job can only be accessed from the realm which created it.
+func (s *jobStore) Get(realm string, jobID string) (Job, bool) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	var job, ok = s.jobs[jobID]
+	if !ok || job.Realm != realm {
+		return Job{}, false
+	}
+	return *job, true
+}
+
+func (s *jobStore) purgeExpired(now time.Time) {
+	for id, job := range s.jobs {
+		if job.EndedAt != nil && job.EndedAt.Add(s.retention).Before(now) {
+			delete(s.jobs, id)
+		}
+	}
+}
+
+// DetachContext creates a context which is not cancelled when the incoming request ends but still holds
+// the values set by the HTTP middlewares (access token, realm, correlation ID, ...)
+func DetachContext(ctx context.Context) context.Context {
+	var res = context.Background()
+	for _, key := range []interface{}{cs.CtContextAccessToken, cs.CtContextRealm, cs.CtContextUserID, cs.CtContextUsername, cs.CtContextGroups, cs.CtContextCorrelationID} {
+		if value := ctx.Value(key); value != nil {
+			res = context.WithValue(res, key, value)
+		}
+	}
+	return res
+}
@@ -0,0 +1,105 @@
+package keycloakb
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	cs "github.com/cloudtrust/common-service"
+	"github.com/cloudtrust/keycloak-bridge/internal/keycloakb/mock"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func waitJob(t *testing.T, store JobStore, realm, jobID string) Job {
+	for i := 0; i < 100; i++ {
+		var job, ok = store.Get(realm, jobID)
+		assert.True(t, ok)
+		if job.Status != JobStatusRunning {
+			return job
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.Fail(t, "Job did not end")
+	return Job{}
+}
+
+func TestJobStore(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var mockIDGenerator = mock.NewIDGenerator(mockCtrl)
+	var store = NewJobStore(mockIDGenerator, time.Hour)
+	var realm = "my-realm"
+	var accessToken = "TOKEN=="
+	var ctx = context.WithValue(context.Background(), cs.CtContextAccessToken, accessToken)
+
+	t.Run("Job succeeds", func(t *testing.T) {
+		mockIDGenerator.EXPECT().NextID().Return("job-1")
+		var jobID = store.Start(ctx, realm, func(ctx context.Context, progress func(int, int)) (interface{}, error) {
+			progress(1, 2)
+			return ctx.Value(cs.CtContextAccessToken), nil
+		})
+		assert.Equal(t, "job-1", jobID)
+
+		var job = waitJob(t, store, realm, jobID)
+		assert.Equal(t, JobStatusSucceeded, job.Status)
+		assert.Equal(t, accessToken, job.Result)
+		assert.Equal(t, 1, job.Done)
+		assert.Equal(t, 2, job.Total)
+		assert.NotNil(t, job.EndedAt)
+	})
+
+	t.Run("Job fails", func(t *testing.T) {
+		var expectedErr = errors.New("failure")
+		mockIDGenerator.EXPECT().NextID().Return("job-2")
+		var jobID = store.Start(ctx, realm, func(ctx context.Context, progress func(int, int)) (interface{}, error) {
+			return nil, expectedErr
+		})
+
+		var job = waitJob(t, store, realm, jobID)
+		assert.Equal(t, JobStatusFailed, job.Status)
+		assert.Equal(t, expectedErr, job.Err)
+	})
+
+	t.Run("Unknown job or other realm", func(t *testing.T) {
+		var _, ok = store.Get(realm, "unknown")
+		assert.False(t, ok)
+		_, ok = store.Get("other-realm", "job-1")
+		assert.False(t, ok)
+	})
+}
+
+func TestJobStoreRetention(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var mockIDGenerator = mock.NewIDGenerator(mockCtrl)
+	var store = NewJobStore(mockIDGenerator, 0)
+	var realm = "my-realm"
+	var noop = func(ctx context.Context, progress func(int, int)) (interface{}, error) {
+		return nil, nil
+	}
+
+	mockIDGenerator.EXPECT().NextID().Return("job-1")
+	store.Start(context.Background(), realm, noop)
+	waitJob(t, store, realm, "job-1")
+
+	mockIDGenerator.EXPECT().NextID().Return("job-2")
+	store.Start(context.Background(), realm, noop)
+
+	var _, ok = store.Get(realm, "job-1")
+	assert.False(t, ok)
+}
+
+func TestDetachContext(t *testing.T) {
+	var ctx, cancel = context.WithCancel(context.Background())
+	ctx = context.WithValue(ctx, cs.CtContextRealm, "realm")
+	cancel()
+
+	var detached = DetachContext(ctx)
+	assert.Nil(t, detached.Err())
+	assert.Equal(t, "realm", detached.Value(cs.CtContextRealm))
+	assert.Nil(t, detached.Value(cs.CtContextAccessToken))
+}
@@ -2,6 +2,7 @@ package keycloakb
 
 import (
 	"context"
+	"strings"
 
 	"github.com/cloudtrust/common-service/middleware"
 	"github.com/cloudtrust/common-service/security"
@@ -32,6 +33,14 @@ func NewKeycloakAuthClient(client KeycloakClient, logger Logger) security.Keyclo
 	}
 }
 
+// SubtreeTargetGroupName is the target group name of the authorizations which apply to the group with the given path
+// and to its descendants
+func SubtreeTargetGroupName(groupPath string) string {
+	return groupPath + "/*"
+}
+
+// GetGroupNamesOfUser returns the names of the groups of the user. The subtree target group names of these groups and of
+// their ancestors are also returned: an authorization given on a subtree applies to the users of all its groups.
 func (k *kcAuthClient) GetGroupNamesOfUser(ctx context.Context, accessToken string, realmName, userID string) ([]string, error) {
 	grps, err := k.keycloak.GetGroupsOfUser(accessToken, realmName, userID)
 	if err != nil {
@@ -44,10 +53,26 @@ func (k *kcAuthClient) GetGroupNamesOfUser(ctx context.Context, accessToken stri
 	}
 
 	var res []string
+	var subtrees = make(map[string]bool)
 	for _, grp := range grps {
 		if grp.Name != nil {
 			res = append(res, *(grp.Name))
 		}
+		if grp.Path == nil {
+			continue
+		}
+		// "/a/b" gives "/a/*" and "/a/b/*"
+		var path = strings.TrimSuffix(*grp.Path, "/")
+		for i := 1; i <= len(path); i++ {
+			if i < len(path) && path[i] != '/' {
+				continue
+			}
+			var subtree = SubtreeTargetGroupName(path[:i])
+			if !subtrees[subtree] {
+				subtrees[subtree] = true
+				res = append(res, subtree)
+			}
+		}
 	}
 	return res, nil
 }
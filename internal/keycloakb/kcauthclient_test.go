@@ -62,6 +62,18 @@ func TestGetGroupNamesOfUserSuccess(t *testing.T) {
 	})
 }
 
+func TestGetGroupNamesOfUserSubtrees(t *testing.T) {
+	testKeycloakAuthClient(t, func(t *testing.T, mockKeycloak *mock.KeycloakClient, authClient security.KeycloakClient) {
+		var team, teamPath = "team", "/org/team"
+		var support, supportPath = "support", "/org/support"
+		var groups = []kc.GroupRepresentation{{Name: &team, Path: &teamPath}, {Name: &support, Path: &supportPath}}
+		mockKeycloak.EXPECT().GetGroupsOfUser(accessToken, realm, user).Return(groups, nil).Times(1)
+		res, err := authClient.GetGroupNamesOfUser(context.TODO(), accessToken, realm, user)
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"team", "/org/*", "/org/team/*", "support", "/org/support/*"}, res)
+	})
+}
+
 func TestGetGroupName(t *testing.T) {
 	t.Run("Error", func(t *testing.T) {
 		testKeycloakAuthClient(t, func(t *testing.T, mockKeycloak *mock.KeycloakClient, authClient security.KeycloakClient) {
@@ -0,0 +1,170 @@
+package keycloakb
+
+import (
+	"context"
+	"time"
+
+	"github.com/cloudtrust/common-service/metrics"
+	influx "github.com/influxdata/influxdb/client/v2"
+)
+
+// Metrics backends which can be enabled by configuration
+const (
+	MetricsBackendInflux     = "influx"
+	MetricsBackendPrometheus = "prometheus"
+)
+
+const (
+	// KeyRealm is histogram field for the target realm
+	KeyRealm = "realm"
+)
+
+type multiMetrics struct {
+	backends []metrics.Metrics
+}
+
+// NewMultiMetrics creates a Metrics which forwards all the measures to each of the given backends
+func NewMultiMetrics(backends ...metrics.Metrics) metrics.Metrics {
+	return &multiMetrics{
+		backends: backends,
+	}
+}
+
+func (m *multiMetrics) NewCounter(name string) metrics.Counter {
+	var res multiCounter
+	for _, backend := range m.backends {
+		res = append(res, backend.NewCounter(name))
+	}
+	return res
+}
+
+func (m *multiMetrics) NewGauge(name string) metrics.Gauge {
+	var res multiGauge
+	for _, backend := range m.backends {
+		res = append(res, backend.NewGauge(name))
+	}
+	return res
+}
+
+func (m *multiMetrics) NewHistogram(name string) metrics.Histogram {
+	var res multiHistogram
+	for _, backend := range m.backends {
+		res = append(res, backend.NewHistogram(name))
+	}
+	return res
+}
+
+func (m *multiMetrics) Write(bp influx.BatchPoints) error {
+	var res error
+	for _, backend := range m.backends {
+		if err := backend.Write(bp); err != nil && res == nil {
+			res = err
+		}
+	}
+	return res
+}
+
+func (m *multiMetrics) Stats(ctx context.Context, name string, tags map[string]string, fields map[string]interface{}) error {
+	var res error
+	for _, backend := range m.backends {
+		if err := backend.Stats(ctx, name, tags, fields); err != nil && res == nil {
+			res = err
+		}
+	}
+	return res
+}
+
+// WriteLoop forwards each tick to all the backends. A tick is skipped for a backend which is still busy or
+// which does not need to be triggered (its loop already returned)
+func (m *multiMetrics) WriteLoop(c <-chan time.Time) {
+	var channels []chan time.Time
+	for _, backend := range m.backends {
+		var backendChannel = make(chan time.Time)
+		channels = append(channels, backendChannel)
+		go backend.WriteLoop(backendChannel)
+	}
+	for tick := range c {
+		for _, backendChannel := range channels {
+			select {
+			case backendChannel <- tick:
+			default:
+			}
+		}
+	}
+	for _, backendChannel := range channels {
+		close(backendChannel)
+	}
+}
+
+func (m *multiMetrics) Ping(timeout time.Duration) (time.Duration, string, error) {
+	var duration time.Duration
+	var version string
+	for _, backend := range m.backends {
+		var d, v, err = backend.Ping(timeout)
+		if err != nil {
+			return d, v, err
+		}
+		duration, version = d, v
+	}
+	return duration, version, nil
+}
+
+func (m *multiMetrics) Close() {
+	for _, backend := range m.backends {
+		backend.Close()
+	}
+}
+
+type multiCounter []metrics.Counter
+
+func (c multiCounter) With(labelValues ...string) metrics.Counter {
+	var res multiCounter
+	for _, counter := range c {
+		res = append(res, counter.With(labelValues...))
+	}
+	return res
+}
+
+func (c multiCounter) Add(delta float64) {
+	for _, counter := range c {
+		counter.Add(delta)
+	}
+}
+
+type multiGauge []metrics.Gauge
+
+func (g multiGauge) With(labelValues ...string) metrics.Gauge {
+	var res multiGauge
+	for _, gauge := range g {
+		res = append(res, gauge.With(labelValues...))
+	}
+	return res
+}
+
+func (g multiGauge) Set(value float64) {
+	for _, gauge := range g {
+		gauge.Set(value)
+	}
+}
+
+func (g multiGauge) Add(delta float64) {
+	for _, gauge := range g {
+		gauge.Add(delta)
+	}
+}
+
+type multiHistogram []metrics.Histogram
+
+func (h multiHistogram) With(labelValues ...string) metrics.Histogram {
+	var res multiHistogram
+	for _, histogram := range h {
+		res = append(res, histogram.With(labelValues...))
+	}
+	return res
+}
+
+func (h multiHistogram) Observe(value float64) {
+	for _, histogram := range h {
+		histogram.Observe(value)
+	}
+}
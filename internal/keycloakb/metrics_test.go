@@ -0,0 +1,84 @@
+package keycloakb
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/cloudtrust/keycloak-bridge/internal/keycloakb/mock"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMultiMetrics(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var mockMetrics1 = mock.NewMetrics(mockCtrl)
+	var mockMetrics2 = mock.NewMetrics(mockCtrl)
+	var m = NewMultiMetrics(mockMetrics1, mockMetrics2)
+
+	t.Run("Histogram", func(t *testing.T) {
+		var mockHistogram1 = mock.NewHistogram(mockCtrl)
+		var mockHistogram2 = mock.NewHistogram(mockCtrl)
+		mockMetrics1.EXPECT().NewHistogram("histo").Return(mockHistogram1)
+		mockMetrics2.EXPECT().NewHistogram("histo").Return(mockHistogram2)
+		mockHistogram1.EXPECT().With(KeyRealm, "realm").Return(mockHistogram1)
+		mockHistogram2.EXPECT().With(KeyRealm, "realm").Return(mockHistogram2)
+		mockHistogram1.EXPECT().Observe(1.5)
+		mockHistogram2.EXPECT().Observe(1.5)
+		m.NewHistogram("histo").With(KeyRealm, "realm").Observe(1.5)
+	})
+
+	t.Run("Counter", func(t *testing.T) {
+		var mockCounter1 = mock.NewCounter(mockCtrl)
+		var mockCounter2 = mock.NewCounter(mockCtrl)
+		mockMetrics1.EXPECT().NewCounter("counter").Return(mockCounter1)
+		mockMetrics2.EXPECT().NewCounter("counter").Return(mockCounter2)
+		mockCounter1.EXPECT().Add(2.0)
+		mockCounter2.EXPECT().Add(2.0)
+		m.NewCounter("counter").Add(2)
+	})
+
+	t.Run("Gauge", func(t *testing.T) {
+		var mockGauge1 = mock.NewGauge(mockCtrl)
+		var mockGauge2 = mock.NewGauge(mockCtrl)
+		mockMetrics1.EXPECT().NewGauge("gauge").Return(mockGauge1)
+		mockMetrics2.EXPECT().NewGauge("gauge").Return(mockGauge2)
+		mockGauge1.EXPECT().Set(3.0)
+		mockGauge2.EXPECT().Set(3.0)
+		m.NewGauge("gauge").Set(3)
+	})
+
+	t.Run("Stats returns first error", func(t *testing.T) {
+		var expectedErr = errors.New("error")
+		var tags = map[string]string{"realm": "realm"}
+		mockMetrics1.EXPECT().Stats(gomock.Any(), "event_statistics", tags, gomock.Any()).Return(nil)
+		mockMetrics2.EXPECT().Stats(gomock.Any(), "event_statistics", tags, gomock.Any()).Return(expectedErr)
+		assert.Equal(t, expectedErr, m.Stats(context.TODO(), "event_statistics", tags, nil))
+	})
+
+	t.Run("Ping", func(t *testing.T) {
+		mockMetrics1.EXPECT().Ping(time.Second).Return(time.Millisecond, "1.0", nil)
+		mockMetrics2.EXPECT().Ping(time.Second).Return(time.Duration(0), "", nil)
+		var _, _, err = m.Ping(time.Second)
+		assert.Nil(t, err)
+	})
+
+	t.Run("WriteLoop", func(t *testing.T) {
+		var c = make(chan time.Time)
+		mockMetrics1.EXPECT().WriteLoop(gomock.Any())
+		mockMetrics2.EXPECT().WriteLoop(gomock.Any())
+		close(c)
+		m.WriteLoop(c)
+		// Backends loops are started in goroutines
+		time.Sleep(10 * time.Millisecond)
+	})
+
+	t.Run("Close", func(t *testing.T) {
+		mockMetrics1.EXPECT().Close()
+		mockMetrics2.EXPECT().Close()
+		m.Close()
+	})
+}
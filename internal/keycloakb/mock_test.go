@@ -3,5 +3,12 @@ package keycloakb
 //go:generate mockgen -destination=./mock/instrumenting.go -package=mock -mock_names=Histogram=Histogram github.com/cloudtrust/common-service/metrics Histogram
 //go:generate mockgen -destination=./mock/configdbinstrumenting.go -package=mock -mock_names=ConfigurationDBModule=ConfigurationDBModule,AccredsKeycloakClient=AccredsKeycloakClient github.com/cloudtrust/keycloak-bridge/internal/keycloakb ConfigurationDBModule,AccredsKeycloakClient
 //go:generate mockgen -destination=./mock/keycloak_client.go -package=mock -mock_names=KeycloakClient=KeycloakClient github.com/cloudtrust/keycloak-bridge/internal/keycloakb KeycloakClient
-//go:generate mockgen -destination=./mock/sqltypes.go -package=mock -mock_names=CloudtrustDB=CloudtrustDB,SQLRow=SQLRow,SQLRows=SQLRows github.com/cloudtrust/common-service/database/sqltypes CloudtrustDB,SQLRow,SQLRows
+//go:generate mockgen -destination=./mock/sqltypes.go -package=mock -mock_names=CloudtrustDB=CloudtrustDB,SQLRow=SQLRow,SQLRows=SQLRows,Transaction=Transaction github.com/cloudtrust/common-service/database/sqltypes CloudtrustDB,SQLRow,SQLRows,Transaction
 //go:generate mockgen -destination=./mock/security.go -package=mock -mock_names=EncrypterDecrypter=EncrypterDecrypter github.com/cloudtrust/common-service/security EncrypterDecrypter
+//go:generate mockgen -destination=./mock/idgenerator.go -package=mock -mock_names=IDGenerator=IDGenerator github.com/cloudtrust/common-service/idgenerator IDGenerator
+//go:generate mockgen -destination=./mock/metrics.go -package=mock -mock_names=Metrics=Metrics,Counter=Counter,Gauge=Gauge github.com/cloudtrust/common-service/metrics Metrics,Counter,Gauge
+//go:generate mockgen -destination=./mock/emailsender.go -package=mock -mock_names=KeycloakEmailClient=KeycloakEmailClient,TokenProvider=TokenProvider github.com/cloudtrust/keycloak-bridge/internal/keycloakb KeycloakEmailClient,TokenProvider
+//go:generate mockgen -destination=./mock/softdeletion.go -package=mock -mock_names=SoftDeletionKeycloakClient=SoftDeletionKeycloakClient,SoftDeletedUsersDBModule=SoftDeletedUsersDBModule,SoftDeletionUsersDBModule=SoftDeletionUsersDBModule github.com/cloudtrust/keycloak-bridge/internal/keycloakb SoftDeletionKeycloakClient,SoftDeletedUsersDBModule,SoftDeletionUsersDBModule
+//go:generate mockgen -destination=./mock/eventsdbmodule.go -package=mock -mock_names=EventsDBModule=EventsDBModule github.com/cloudtrust/common-service/database EventsDBModule
+//go:generate mockgen -destination=./mock/grants.go -package=mock -mock_names=GrantsKeycloakClient=GrantsKeycloakClient,TemporaryGrantsDBModule=TemporaryGrantsDBModule github.com/cloudtrust/keycloak-bridge/internal/keycloakb GrantsKeycloakClient,TemporaryGrantsDBModule
+//go:generate mockgen -destination=./mock/passwordpolicy.go -package=mock -mock_names=PasswordPolicyKeycloakClient=PasswordPolicyKeycloakClient github.com/cloudtrust/keycloak-bridge/internal/keycloakb PasswordPolicyKeycloakClient
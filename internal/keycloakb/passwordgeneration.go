@@ -4,9 +4,7 @@ import (
 	crand "crypto/rand"
 	"math/big"
 	mrand "math/rand"
-	"strconv"
 	"strings"
-	"unicode"
 )
 
 const (
@@ -58,44 +56,29 @@ func GeneratePasswordNoKeycloakPolicy(minLength int) string {
 
 // GeneratePasswordFromKeycloakPolicy generates a random password respecting the keycloak password policy
 func GeneratePasswordFromKeycloakPolicy(policy string) (string, error) {
-	// Keycloak password policy is a string of the form
-	// "forceExpiredPasswordChange(365) and specialChars(1) and upperCase(1) and lowerCase(1) and length(4) and digits(1) and notUsername(undefined)"
-	var pwdElems = make([]string, 0)
-	policyItems := strings.Split(policy, "and")
-
-	// generate a random password that corresponds to the password policy
-	//reg := regexp.MustCompile(`[a-zA-z]+[(]{1}[0-9]+[)]{1}`)
-	//pwdReq := string(reg.Find([]byte()))
-
-	f := func(c rune) bool {
-		return !unicode.IsLetter(c) && !unicode.IsNumber(c)
+	var passwordPolicy, err = ParsePasswordPolicy(policy)
+	if err != nil {
+		return "", err
 	}
 
-	for i := 0; i < len(policyItems); i++ {
-		keyValueItem := strings.FieldsFunc(policyItems[i], f)
-		minRequired, err := strconv.Atoi(keyValueItem[1])
-		switch keyValueItem[0] {
-		case "length", "lowerCase":
-			// make sure that the password has the minimum length required by choosing random lower case letters
-			pwdElems = appendCharacters(pwdElems, lowerCase, minRequired)
-		case "specialChars":
-			// pick randomly special characters from ?!#%$
-			pwdElems = appendCharacters(pwdElems, specialChars, minRequired)
-		case "upperCase":
-			pwdElems = appendCharacters(pwdElems, upperCase, minRequired)
-		case "digits":
-			pwdElems = appendCharacters(pwdElems, digits, minRequired)
-		default:
-			err = nil
-		}
-		if err != nil {
-			return "", err
+	var pwdElems = make([]string, 0)
+	var appendPolicyCharacters = func(alphabet string, minRequired *int) {
+		if minRequired != nil {
+			pwdElems = appendCharacters(pwdElems, alphabet, *minRequired)
 		}
 	}
+
+	// make sure that the password has the minimum length required by choosing random lower case letters
+	appendPolicyCharacters(lowerCase, passwordPolicy.Length)
+	appendPolicyCharacters(lowerCase, passwordPolicy.LowerCase)
+	// pick randomly special characters from ?!#%$
+	appendPolicyCharacters(specialChars, passwordPolicy.SpecialChars)
+	appendPolicyCharacters(upperCase, passwordPolicy.UpperCase)
+	appendPolicyCharacters(digits, passwordPolicy.Digits)
+
 	mrand.Shuffle(len(pwdElems), func(i, j int) { pwdElems[i], pwdElems[j] = pwdElems[j], pwdElems[i] })
 	pwd := strings.Join(pwdElems, "")
 	return pwd, nil
-
 }
 
 // GenerateInitialCode generates a code of the format UpperCase +  digits + LowerCase
@@ -0,0 +1,362 @@
+package keycloakb
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	kc "github.com/cloudtrust/keycloak-client"
+)
+
+// Items of the Keycloak password policies
+const (
+	PolicyLength                     = "length"
+	PolicyMaxLength                  = "maxLength"
+	PolicyDigits                     = "digits"
+	PolicyLowerCase                  = "lowerCase"
+	PolicyUpperCase                  = "upperCase"
+	PolicySpecialChars               = "specialChars"
+	PolicyNotUsername                = "notUsername"
+	PolicyNotEmail                   = "notEmail"
+	PolicyRegexPattern               = "regexPattern"
+	PolicyPasswordHistory            = "passwordHistory"
+	PolicyForceExpiredPasswordChange = "forceExpiredPasswordChange"
+	PolicyHashAlgorithm              = "hashAlgorithm"
+	PolicyHashIterations             = "hashIterations"
+	PolicyPasswordBlacklist          = "passwordBlacklist"
+
+	// PolicyBreached is not a Keycloak policy: it is reported when the password belongs to the local breached passwords list
+	PolicyBreached = "breached"
+
+	policyItemSeparator = " and "
+	policyUndefined     = "undefined"
+)
+
+// Values used by Keycloak when the value of an item is not given
+var policyDefaultValues = map[string]int{
+	PolicyLength:                     8,
+	PolicyMaxLength:                  64,
+	PolicyDigits:                     1,
+	PolicyLowerCase:                  1,
+	PolicyUpperCase:                  1,
+	PolicySpecialChars:               1,
+	PolicyPasswordHistory:            3,
+	PolicyForceExpiredPasswordChange: 365,
+	PolicyHashIterations:             27500,
+}
+
+// PasswordPolicy is the structured representation of a Keycloak password policy. The items unknown to the bridge are kept
+// in Others so that the policy can be serialized without loss.
+type PasswordPolicy struct {
+	Length                     *int
+	MaxLength                  *int
+	Digits                     *int
+	LowerCase                  *int
+	UpperCase                  *int
+	SpecialChars               *int
+	NotUsername                bool
+	NotEmail                   bool
+	RegexPattern               *string
+	PasswordHistory            *int
+	ForceExpiredPasswordChange *int
+	HashAlgorithm              *string
+	HashIterations             *int
+	PasswordBlacklist          *string
+	Others                     map[string]string
+}
+
+// PasswordPolicyFailure is a rule of the password policy which is not respected by a password. Value is the value of the
+// rule, if any.
+type PasswordPolicyFailure struct {
+	Rule  string
+	Value string
+}
+
+// ParsePasswordPolicy parses a Keycloak password policy like "length(8) and digits(1) and notUsername(undefined)"
+func ParsePasswordPolicy(policy string) (PasswordPolicy, error) {
+	var res = PasswordPolicy{Others: map[string]string{}}
+	if strings.TrimSpace(policy) == "" {
+		return res, nil
+	}
+
+	for _, item := range strings.Split(policy, policyItemSeparator) {
+		var name, value = splitPolicyItem(strings.TrimSpace(item))
+		if name == "" {
+			return PasswordPolicy{}, fmt.Errorf("invalid password policy item '%s'", item)
+		}
+
+		var err error
+		switch name {
+		case PolicyLength:
+			res.Length, err = parsePolicyInt(name, value)
+		case PolicyMaxLength:
+			res.MaxLength, err = parsePolicyInt(name, value)
+		case PolicyDigits:
+			res.Digits, err = parsePolicyInt(name, value)
+		case PolicyLowerCase:
+			res.LowerCase, err = parsePolicyInt(name, value)
+		case PolicyUpperCase:
+			res.UpperCase, err = parsePolicyInt(name, value)
+		case PolicySpecialChars:
+			res.SpecialChars, err = parsePolicyInt(name, value)
+		case PolicyPasswordHistory:
+			res.PasswordHistory, err = parsePolicyInt(name, value)
+		case PolicyForceExpiredPasswordChange:
+			res.ForceExpiredPasswordChange, err = parsePolicyInt(name, value)
+		case PolicyHashIterations:
+			res.HashIterations, err = parsePolicyInt(name, value)
+		case PolicyNotUsername:
+			res.NotUsername = true
+		case PolicyNotEmail:
+			res.NotEmail = true
+		case PolicyRegexPattern:
+			res.RegexPattern = &value
+		case PolicyHashAlgorithm:
+			res.HashAlgorithm = &value
+		case PolicyPasswordBlacklist:
+			res.PasswordBlacklist = &value
+		default:
+			res.Others[name] = value
+		}
+		if err != nil {
+			return PasswordPolicy{}, err
+		}
+	}
+	return res, nil
+}
+
+// splitPolicyItem splits an item like "regexPattern(^[a-z(]+$)": the value is everything between the first opening
+// parenthesis and the last closing one
+func splitPolicyItem(item string) (string, string) {
+	var start = strings.Index(item, "(")
+	if start < 0 {
+		return item, ""
+	}
+	var end = strings.LastIndex(item, ")")
+	if end < start {
+		return "", ""
+	}
+	return strings.TrimSpace(item[:start]), item[start+1 : end]
+}
+
+func parsePolicyInt(name, value string) (*int, error) {
+	if value == "" || value == policyUndefined {
+		var defaultValue = policyDefaultValues[name]
+		return &defaultValue, nil
+	}
+	var res, err = strconv.Atoi(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid value '%s' for password policy item %s", value, name)
+	}
+	return &res, nil
+}
+
+// String serializes the password policy in the Keycloak format
+func (p PasswordPolicy) String() string {
+	var items []string
+	var addInt = func(name string, value *int) {
+		if value != nil {
+			items = append(items, fmt.Sprintf("%s(%d)", name, *value))
+		}
+	}
+	var addString = func(name string, value *string) {
+		if value != nil {
+			items = append(items, fmt.Sprintf("%s(%s)", name, *value))
+		}
+	}
+	var addBool = func(name string, value bool) {
+		if value {
+			items = append(items, fmt.Sprintf("%s(%s)", name, policyUndefined))
+		}
+	}
+
+	addInt(PolicyLength, p.Length)
+	addInt(PolicyMaxLength, p.MaxLength)
+	addInt(PolicyDigits, p.Digits)
+	addInt(PolicyLowerCase, p.LowerCase)
+	addInt(PolicyUpperCase, p.UpperCase)
+	addInt(PolicySpecialChars, p.SpecialChars)
+	addBool(PolicyNotUsername, p.NotUsername)
+	addBool(PolicyNotEmail, p.NotEmail)
+	addString(PolicyRegexPattern, p.RegexPattern)
+	addInt(PolicyPasswordHistory, p.PasswordHistory)
+	addInt(PolicyForceExpiredPasswordChange, p.ForceExpiredPasswordChange)
+	addString(PolicyHashAlgorithm, p.HashAlgorithm)
+	addInt(PolicyHashIterations, p.HashIterations)
+	addString(PolicyPasswordBlacklist, p.PasswordBlacklist)
+
+	var others []string
+	for name := range p.Others {
+		others = append(others, name)
+	}
+	sort.Strings(others)
+	for _, name := range others {
+		var value = p.Others[name]
+		if value == "" {
+			items = append(items, name)
+		} else {
+			items = append(items, fmt.Sprintf("%s(%s)", name, value))
+		}
+	}
+
+	return strings.Join(items, policyItemSeparator)
+}
+
+// Check returns the rules of the policy which are not respected by the password. The rules which can only be checked by
+// Keycloak (password history, Keycloak blacklist) are ignored.
+func (p PasswordPolicy) Check(password, username, email string) []PasswordPolicyFailure {
+	var failures []PasswordPolicyFailure
+	var checkMin = func(name string, min *int, count int) {
+		if min != nil && count < *min {
+			failures = append(failures, PasswordPolicyFailure{Rule: name, Value: strconv.Itoa(*min)})
+		}
+	}
+
+	var nbDigits, nbLowerCase, nbUpperCase, nbSpecialChars int
+	for _, c := range password {
+		switch {
+		case unicode.IsDigit(c):
+			nbDigits++
+		case unicode.IsLower(c):
+			nbLowerCase++
+		case unicode.IsUpper(c):
+			nbUpperCase++
+		case !unicode.IsLetter(c):
+			nbSpecialChars++
+		}
+	}
+
+	var length = utf8.RuneCountInString(password)
+	checkMin(PolicyLength, p.Length, length)
+	if p.MaxLength != nil && length > *p.MaxLength {
+		failures = append(failures, PasswordPolicyFailure{Rule: PolicyMaxLength, Value: strconv.Itoa(*p.MaxLength)})
+	}
+	checkMin(PolicyDigits, p.Digits, nbDigits)
+	checkMin(PolicyLowerCase, p.LowerCase, nbLowerCase)
+	checkMin(PolicyUpperCase, p.UpperCase, nbUpperCase)
+	checkMin(PolicySpecialChars, p.SpecialChars, nbSpecialChars)
+
+	if p.NotUsername && username != "" && strings.EqualFold(password, username) {
+		failures = append(failures, PasswordPolicyFailure{Rule: PolicyNotUsername})
+	}
+	if p.NotEmail && email != "" && strings.EqualFold(password, email) {
+		failures = append(failures, PasswordPolicyFailure{Rule: PolicyNotEmail})
+	}
+	if p.RegexPattern != nil {
+		// Keycloak expects the whole password to match the pattern
+		var matched, err = regexp.MatchString("^(?:"+*p.RegexPattern+")$", password)
+		if err != nil || !matched {
+			failures = append(failures, PasswordPolicyFailure{Rule: PolicyRegexPattern, Value: *p.RegexPattern})
+		}
+	}
+
+	return failures
+}
+
+// BreachedPasswords is a list of passwords known to be compromised
+type BreachedPasswords interface {
+	Contains(password string) bool
+}
+
+type breachedPasswords map[string]bool
+
+// NewBreachedPasswords creates a breached passwords list. Passwords are compared case insensitively.
+func NewBreachedPasswords(passwords []string) BreachedPasswords {
+	var res = breachedPasswords{}
+	for _, password := range passwords {
+		res[strings.ToLower(password)] = true
+	}
+	return res
+}
+
+// LoadBreachedPasswords loads a breached passwords list from a file containing one password per line
+func LoadBreachedPasswords(filename string) (BreachedPasswords, error) {
+	var file, err = os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var passwords []string
+	var scanner = bufio.NewScanner(file)
+	for scanner.Scan() {
+		if line := strings.TrimRight(scanner.Text(), "\r"); line != "" {
+			passwords = append(passwords, line)
+		}
+	}
+	if err = scanner.Err(); err != nil {
+		return nil, err
+	}
+	return NewBreachedPasswords(passwords), nil
+}
+
+func (b breachedPasswords) Contains(password string) bool {
+	return b[strings.ToLower(password)]
+}
+
+// PasswordPolicyKeycloakClient is the interface of the keycloak client used to get the password policy of a realm
+type PasswordPolicyKeycloakClient interface {
+	GetRealm(accessToken string, realmName string) (kc.RealmRepresentation, error)
+}
+
+// PasswordValidator checks candidate passwords against the password policy of a realm, before they are submitted to Keycloak
+type PasswordValidator interface {
+	ValidatePassword(ctx context.Context, realmName, password, username, email string) ([]PasswordPolicyFailure, error)
+}
+
+type passwordValidator struct {
+	keycloakClient    PasswordPolicyKeycloakClient
+	tokenProvider     TokenProvider
+	breachedPasswords BreachedPasswords
+	logger            Logger
+}
+
+// NewPasswordValidator creates a password validator. The password policies are read with the technical user. When
+// breachedPasswords is not nil, the passwords of the list are refused.
+func NewPasswordValidator(keycloakClient PasswordPolicyKeycloakClient, tokenProvider TokenProvider, breachedPasswords BreachedPasswords,
+	logger Logger) PasswordValidator {
+	return &passwordValidator{
+		keycloakClient:    keycloakClient,
+		tokenProvider:     tokenProvider,
+		breachedPasswords: breachedPasswords,
+		logger:            logger,
+	}
+}
+
+func (v *passwordValidator) ValidatePassword(ctx context.Context, realmName, password, username, email string) ([]PasswordPolicyFailure, error) {
+	var accessToken, err = v.tokenProvider.ProvideToken(ctx)
+	if err != nil {
+		v.logger.Warn(ctx, "msg", "Can't get technical token", "err", err.Error())
+		return nil, err
+	}
+
+	var realm kc.RealmRepresentation
+	if realm, err = v.keycloakClient.GetRealm(accessToken, realmName); err != nil {
+		v.logger.Warn(ctx, "msg", "Can't get realm", "err", err.Error(), "realm", realmName)
+		return nil, err
+	}
+
+	var policy PasswordPolicy
+	if realm.PasswordPolicy != nil {
+		if policy, err = ParsePasswordPolicy(*realm.PasswordPolicy); err != nil {
+			v.logger.Warn(ctx, "msg", "Invalid password policy", "err", err.Error(), "realm", realmName)
+			return nil, err
+		}
+	}
+
+	var failures = policy.Check(password, username, email)
+	if v.breachedPasswords != nil && v.breachedPasswords.Contains(password) {
+		failures = append(failures, PasswordPolicyFailure{Rule: PolicyBreached})
+	}
+	if failures == nil {
+		failures = []PasswordPolicyFailure{}
+	}
+	return failures, nil
+}
@@ -0,0 +1,153 @@
+package keycloakb
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/cloudtrust/common-service/log"
+	"github.com/cloudtrust/keycloak-bridge/internal/keycloakb/mock"
+	kc "github.com/cloudtrust/keycloak-client"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParsePasswordPolicy(t *testing.T) {
+	t.Run("Empty policy", func(t *testing.T) {
+		var policy, err = ParsePasswordPolicy("")
+		assert.Nil(t, err)
+		assert.Equal(t, "", policy.String())
+	})
+
+	t.Run("Invalid value", func(t *testing.T) {
+		var _, err = ParsePasswordPolicy("length(8) and digits(one)")
+		assert.NotNil(t, err)
+	})
+
+	t.Run("Invalid item", func(t *testing.T) {
+		var _, err = ParsePasswordPolicy("length(8) and )digits(")
+		assert.NotNil(t, err)
+	})
+
+	t.Run("Full policy", func(t *testing.T) {
+		var policy, err = ParsePasswordPolicy("forceExpiredPasswordChange(365) and specialChars(1) and upperCase(2) and lowerCase(1) and length(10) and digits(undefined) " +
+			"and notUsername(undefined) and notEmail and passwordHistory(3) and regexPattern(^(?!.*(.)\\1).*$) and hashAlgorithm(pbkdf2-sha256) and customPolicy(42)")
+		assert.Nil(t, err)
+		assert.Equal(t, 10, *policy.Length)
+		assert.Equal(t, 2, *policy.UpperCase)
+		assert.Equal(t, 1, *policy.Digits)
+		assert.Equal(t, 3, *policy.PasswordHistory)
+		assert.True(t, policy.NotUsername)
+		assert.True(t, policy.NotEmail)
+		assert.Equal(t, "^(?!.*(.)\\1).*$", *policy.RegexPattern)
+		assert.Equal(t, "pbkdf2-sha256", *policy.HashAlgorithm)
+		assert.Equal(t, map[string]string{"customPolicy": "42"}, policy.Others)
+		assert.Nil(t, policy.MaxLength)
+
+		assert.Equal(t, "length(10) and digits(1) and lowerCase(1) and upperCase(2) and specialChars(1) and notUsername(undefined) and notEmail(undefined) "+
+			"and regexPattern(^(?!.*(.)\\1).*$) and passwordHistory(3) and forceExpiredPasswordChange(365) and hashAlgorithm(pbkdf2-sha256) and customPolicy(42)",
+			policy.String())
+	})
+
+	t.Run("Serialization is stable", func(t *testing.T) {
+		var serialized = "length(12) and maxLength(64) and notUsername(undefined) and hashIterations(27500)"
+		var policy, err = ParsePasswordPolicy(serialized)
+		assert.Nil(t, err)
+		assert.Equal(t, serialized, policy.String())
+	})
+}
+
+func TestCheckPasswordPolicy(t *testing.T) {
+	var policy, _ = ParsePasswordPolicy("length(8) and maxLength(20) and digits(2) and lowerCase(1) and upperCase(1) and specialChars(1) and notUsername and notEmail and regexPattern([^ ]+)")
+
+	t.Run("Valid password", func(t *testing.T) {
+		assert.Len(t, policy.Check("Secr3t!pa55", "jdoe", "jdoe@example.com"), 0)
+	})
+
+	t.Run("Rules are reported one by one", func(t *testing.T) {
+		var failures = policy.Check("abc def", "jdoe", "jdoe@example.com")
+		assert.Equal(t, []PasswordPolicyFailure{
+			{Rule: PolicyLength, Value: "8"},
+			{Rule: PolicyDigits, Value: "2"},
+			{Rule: PolicyUpperCase, Value: "1"},
+			{Rule: PolicyRegexPattern, Value: "[^ ]+"},
+		}, failures)
+	})
+
+	t.Run("Too long", func(t *testing.T) {
+		assert.Equal(t, []PasswordPolicyFailure{{Rule: PolicyMaxLength, Value: "20"}}, policy.Check("Secr3t!pa55-Secr3t!pa55", "jdoe", ""))
+	})
+
+	t.Run("Username and email", func(t *testing.T) {
+		var password = "JDoe-77@x.ch"
+		assert.Equal(t, []PasswordPolicyFailure{{Rule: PolicyNotUsername}}, policy.Check(password, "jdoe-77@x.ch", ""))
+		assert.Equal(t, []PasswordPolicyFailure{{Rule: PolicyNotEmail}}, policy.Check(password, "jdoe", "jdoe-77@x.ch"))
+	})
+}
+
+func TestBreachedPasswords(t *testing.T) {
+	t.Run("Unknown file", func(t *testing.T) {
+		var _, err = LoadBreachedPasswords("/does/not/exist")
+		assert.NotNil(t, err)
+	})
+
+	t.Run("Load file", func(t *testing.T) {
+		var file, _ = ioutil.TempFile("", "breached")
+		defer os.Remove(file.Name())
+		file.WriteString("123456\r\nPassword1\n\nqwerty\n")
+		file.Close()
+
+		var breached, err = LoadBreachedPasswords(file.Name())
+		assert.Nil(t, err)
+		assert.True(t, breached.Contains("password1"))
+		assert.True(t, breached.Contains("123456"))
+		assert.False(t, breached.Contains(""))
+		assert.False(t, breached.Contains("Secr3t!pa55"))
+	})
+}
+
+func TestPasswordValidator(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+	var mockKeycloakClient = mock.NewPasswordPolicyKeycloakClient(mockCtrl)
+	var mockTokenProvider = mock.NewTokenProvider(mockCtrl)
+
+	var validator = NewPasswordValidator(mockKeycloakClient, mockTokenProvider, NewBreachedPasswords([]string{"Passw0rd!"}), log.NewNopLogger())
+
+	var ctx = context.TODO()
+	var accessToken = "TOKEN=="
+	var realm = "my-realm"
+	var expectedError = errors.New("kc error")
+
+	t.Run("Can't get technical token", func(t *testing.T) {
+		mockTokenProvider.EXPECT().ProvideToken(ctx).Return("", expectedError)
+		var _, err = validator.ValidatePassword(ctx, realm, "password", "jdoe", "")
+		assert.Equal(t, expectedError, err)
+	})
+
+	t.Run("Can't get realm", func(t *testing.T) {
+		mockTokenProvider.EXPECT().ProvideToken(ctx).Return(accessToken, nil)
+		mockKeycloakClient.EXPECT().GetRealm(accessToken, realm).Return(kc.RealmRepresentation{}, expectedError)
+		var _, err = validator.ValidatePassword(ctx, realm, "password", "jdoe", "")
+		assert.Equal(t, expectedError, err)
+	})
+
+	t.Run("No password policy", func(t *testing.T) {
+		mockTokenProvider.EXPECT().ProvideToken(ctx).Return(accessToken, nil)
+		mockKeycloakClient.EXPECT().GetRealm(accessToken, realm).Return(kc.RealmRepresentation{}, nil)
+		var failures, err = validator.ValidatePassword(ctx, realm, "password", "jdoe", "")
+		assert.Nil(t, err)
+		assert.Len(t, failures, 0)
+	})
+
+	t.Run("Breached password", func(t *testing.T) {
+		var policy = "length(8) and digits(1)"
+		mockTokenProvider.EXPECT().ProvideToken(ctx).Return(accessToken, nil)
+		mockKeycloakClient.EXPECT().GetRealm(accessToken, realm).Return(kc.RealmRepresentation{PasswordPolicy: &policy}, nil)
+		var failures, err = validator.ValidatePassword(ctx, realm, "passw0rd!", "jdoe", "")
+		assert.Nil(t, err)
+		assert.Equal(t, []PasswordPolicyFailure{{Rule: PolicyBreached}}, failures)
+	})
+}
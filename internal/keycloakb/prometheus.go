@@ -0,0 +1,359 @@
+package keycloakb
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cloudtrust/common-service/metrics"
+	influx "github.com/influxdata/influxdb/client/v2"
+)
+
+const (
+	prometheusNamespace = "keycloak_bridge"
+	// Counters fed by Stats get their own prefix: their labels differ from the ones of the counters created by NewCounter
+	prometheusStatsPrefix = "stats_"
+
+	prometheusLabelEndpoint  = "endpoint"
+	prometheusLabelComponent = "component"
+	prometheusLabelRealm     = "realm"
+	prometheusLabelType      = "type"
+
+	prometheusTypeCounter   = "counter"
+	prometheusTypeGauge     = "gauge"
+	prometheusTypeHistogram = "histogram"
+
+	prometheusContentType = "text/plain; version=0.0.4; charset=utf-8"
+)
+
+var (
+	prometheusInvalidChars = regexp.MustCompile(`[^a-z0-9_]+`)
+	// Same default buckets as the official Prometheus clients
+	prometheusBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+	labelValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+)
+
+// PrometheusMetrics is a metrics backend exposing the measures in the Prometheus text format
+type PrometheusMetrics interface {
+	metrics.Metrics
+	Handler() http.Handler
+}
+
+type prometheusMetrics struct {
+	mutex    sync.Mutex
+	families map[string]*prometheusFamily
+}
+
+// prometheusFamily is a metric and its series, one per combination of label values
+type prometheusFamily struct {
+	help   string
+	kind   string
+	series map[string]*prometheusSeries
+}
+
+// prometheusSeries holds the value of a counter or a gauge, or the sum, the count and the buckets of a histogram
+type prometheusSeries struct {
+	value   float64
+	count   uint64
+	buckets []uint64
+}
+
+// NewPrometheusMetrics creates a Prometheus metrics backend. Instruments names are mapped to Prometheus conventions:
+// histograms of endpoints and components are grouped in keycloak_bridge_endpoint_duration_seconds and
+// keycloak_bridge_component_duration_seconds with the instrument name as label, correlation IDs are dropped and only
+// the realm is kept as label.
+func NewPrometheusMetrics() PrometheusMetrics {
+	return &prometheusMetrics{
+		families: map[string]*prometheusFamily{},
+	}
+}
+
+// Handler returns the HTTP handler to be exposed on /metrics
+func (m *prometheusMetrics) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		var buf bytes.Buffer
+		m.writeTo(&buf)
+		w.Header().Set("Content-Type", prometheusContentType)
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write(buf.Bytes())
+	})
+}
+
+// toPrometheusName converts an instrument name like "configDB_module" or "get_required-actions_endpoint" to snake case
+func toPrometheusName(name string) string {
+	return strings.Trim(prometheusInvalidChars.ReplaceAllString(strings.ToLower(name), "_"), "_")
+}
+
+// histogramDescription gives the Prometheus metric name, the label identifying the instrument and its value
+func histogramDescription(name string) (string, string, string) {
+	var value = toPrometheusName(name)
+	if strings.HasSuffix(value, "_module") || strings.HasSuffix(value, "_component") {
+		return "component_duration_seconds", prometheusLabelComponent, value
+	}
+	return "endpoint_duration_seconds", prometheusLabelEndpoint, strings.TrimSuffix(value, "_endpoint")
+}
+
+func (m *prometheusMetrics) NewHistogram(name string) metrics.Histogram {
+	var metricName, labelName, labelValue = histogramDescription(name)
+	return &prometheusHistogram{
+		metrics: m,
+		name:    metricName,
+		labels:  map[string]string{labelName: labelValue, prometheusLabelRealm: ""},
+	}
+}
+
+func (m *prometheusMetrics) NewCounter(name string) metrics.Counter {
+	return &prometheusCounter{
+		metrics: m,
+		name:    toPrometheusName(name) + "_total",
+		labels:  map[string]string{prometheusLabelRealm: ""},
+	}
+}
+
+func (m *prometheusMetrics) NewGauge(name string) metrics.Gauge {
+	return &prometheusGauge{
+		metrics: m,
+		name:    toPrometheusName(name),
+		labels:  map[string]string{prometheusLabelRealm: ""},
+	}
+}
+
+// Stats counts the points sent to Influx (event_statistics gives keycloak_bridge_stats_event_statistics_total). Only the
+// type and realm tags are kept as labels, other tags like user IDs would lead to an unbounded number of series.
+func (m *prometheusMetrics) Stats(_ context.Context, name string, tags map[string]string, _ map[string]interface{}) error {
+	m.add(prometheusStatsPrefix+toPrometheusName(name)+"_total", map[string]string{
+		prometheusLabelType:  tags[prometheusLabelType],
+		prometheusLabelRealm: tags[prometheusLabelRealm],
+	}, 1)
+	return nil
+}
+
+// Write is only meaningful for Influx
+func (m *prometheusMetrics) Write(bp influx.BatchPoints) error {
+	return nil
+}
+
+// WriteLoop does nothing as Prometheus scrapes the metrics
+func (m *prometheusMetrics) WriteLoop(c <-chan time.Time) {
+	for range c {
+	}
+}
+
+func (m *prometheusMetrics) Ping(timeout time.Duration) (time.Duration, string, error) {
+	return 0, "", nil
+}
+
+func (m *prometheusMetrics) Close() {}
+
+// series gets or creates the series of a metric. The caller must hold the mutex.
+func (m *prometheusMetrics) series(name, kind string, labels map[string]string) *prometheusSeries {
+	var family, ok = m.families[name]
+	if !ok {
+		var help = "Duration of the calls in seconds"
+		switch kind {
+		case prometheusTypeCounter:
+			help = "Counter " + name
+		case prometheusTypeGauge:
+			help = "Gauge " + name
+		}
+		family = &prometheusFamily{help: help, kind: kind, series: map[string]*prometheusSeries{}}
+		m.families[name] = family
+	}
+	var key = formatLabels(labels)
+	var series, found = family.series[key]
+	if !found {
+		series = &prometheusSeries{}
+		if kind == prometheusTypeHistogram {
+			series.buckets = make([]uint64, len(prometheusBuckets))
+		}
+		family.series[key] = series
+	}
+	return series
+}
+
+func (m *prometheusMetrics) add(name string, labels map[string]string, delta float64) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.series(name, prometheusTypeCounter, labels).value += delta
+}
+
+func (m *prometheusMetrics) set(name string, labels map[string]string, value float64, relative bool) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	var series = m.series(name, prometheusTypeGauge, labels)
+	if relative {
+		value += series.value
+	}
+	series.value = value
+}
+
+func (m *prometheusMetrics) observe(name string, labels map[string]string, value float64) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	var series = m.series(name, prometheusTypeHistogram, labels)
+	series.value += value
+	series.count++
+	for i, upperBound := range prometheusBuckets {
+		if value <= upperBound {
+			series.buckets[i]++
+		}
+	}
+}
+
+// writeTo writes the metrics in the Prometheus text exposition format, sorted by name and labels
+func (m *prometheusMetrics) writeTo(buf *bytes.Buffer) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	var names = make([]string, 0, len(m.families))
+	for name := range m.families {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		var family = m.families[name]
+		var fullName = prometheusNamespace + "_" + name
+		fmt.Fprintf(buf, "# HELP %s %s\n# TYPE %s %s\n", fullName, family.help, fullName, family.kind)
+
+		var keys = make([]string, 0, len(family.series))
+		for key := range family.series {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			var series = family.series[key]
+			if family.kind != prometheusTypeHistogram {
+				writeSample(buf, fullName, key, series.value)
+				continue
+			}
+			for i, upperBound := range prometheusBuckets {
+				writeSample(buf, fullName+"_bucket", withLabel(key, "le", formatValue(upperBound)), float64(series.buckets[i]))
+			}
+			writeSample(buf, fullName+"_bucket", withLabel(key, "le", "+Inf"), float64(series.count))
+			writeSample(buf, fullName+"_sum", key, series.value)
+			writeSample(buf, fullName+"_count", key, float64(series.count))
+		}
+	}
+
+	// Same names as the Go collector of the official client, so that the usual dashboards keep working
+	var memStats runtime.MemStats
+	runtime.ReadMemStats(&memStats)
+	buf.WriteString("# HELP go_goroutines Number of goroutines that currently exist.\n# TYPE go_goroutines gauge\n")
+	writeSample(buf, "go_goroutines", "", float64(runtime.NumGoroutine()))
+	buf.WriteString("# HELP go_memstats_alloc_bytes Number of bytes allocated and still in use.\n# TYPE go_memstats_alloc_bytes gauge\n")
+	writeSample(buf, "go_memstats_alloc_bytes", "", float64(memStats.Alloc))
+}
+
+func writeSample(buf *bytes.Buffer, name, labels string, value float64) {
+	buf.WriteString(name)
+	if labels != "" {
+		buf.WriteString("{" + labels + "}")
+	}
+	buf.WriteString(" " + formatValue(value) + "\n")
+}
+
+// formatLabels renders the label pairs sorted by name, e.g. endpoint="get_user",realm="master"
+func formatLabels(labels map[string]string) string {
+	var names = make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var pairs = make([]string, 0, len(names))
+	for _, name := range names {
+		pairs = append(pairs, name+`="`+labelValueEscaper.Replace(labels[name])+`"`)
+	}
+	return strings.Join(pairs, ",")
+}
+
+func withLabel(labels, name, value string) string {
+	var pair = name + `="` + value + `"`
+	if labels == "" {
+		return pair
+	}
+	return labels + "," + pair
+}
+
+func formatValue(value float64) string {
+	switch {
+	case math.IsInf(value, 1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	case math.IsNaN(value):
+		return "NaN"
+	}
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+// withRealm copies the labels, replacing the realm if it is part of the given key/value pairs
+func withRealm(labels map[string]string, labelValues []string) map[string]string {
+	var res = map[string]string{}
+	for k, v := range labels {
+		res[k] = v
+	}
+	for i := 0; i+1 < len(labelValues); i += 2 {
+		if labelValues[i] == KeyRealm {
+			res[prometheusLabelRealm] = labelValues[i+1]
+		}
+	}
+	return res
+}
+
+type prometheusHistogram struct {
+	metrics *prometheusMetrics
+	name    string
+	labels  map[string]string
+}
+
+func (h *prometheusHistogram) With(labelValues ...string) metrics.Histogram {
+	return &prometheusHistogram{metrics: h.metrics, name: h.name, labels: withRealm(h.labels, labelValues)}
+}
+
+func (h *prometheusHistogram) Observe(value float64) {
+	h.metrics.observe(h.name, h.labels, value)
+}
+
+type prometheusCounter struct {
+	metrics *prometheusMetrics
+	name    string
+	labels  map[string]string
+}
+
+func (c *prometheusCounter) With(labelValues ...string) metrics.Counter {
+	return &prometheusCounter{metrics: c.metrics, name: c.name, labels: withRealm(c.labels, labelValues)}
+}
+
+func (c *prometheusCounter) Add(delta float64) {
+	c.metrics.add(c.name, c.labels, delta)
+}
+
+type prometheusGauge struct {
+	metrics *prometheusMetrics
+	name    string
+	labels  map[string]string
+}
+
+func (g *prometheusGauge) With(labelValues ...string) metrics.Gauge {
+	return &prometheusGauge{metrics: g.metrics, name: g.name, labels: withRealm(g.labels, labelValues)}
+}
+
+func (g *prometheusGauge) Set(value float64) {
+	g.metrics.set(g.name, g.labels, value, false)
+}
+
+func (g *prometheusGauge) Add(delta float64) {
+	g.metrics.set(g.name, g.labels, delta, true)
+}
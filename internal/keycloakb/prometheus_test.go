@@ -0,0 +1,85 @@
+package keycloakb
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func scrape(t *testing.T, m PrometheusMetrics) string {
+	var ts = httptest.NewServer(m.Handler())
+	defer ts.Close()
+
+	var res, err = http.Get(ts.URL)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+
+	var buf = new(bytes.Buffer)
+	buf.ReadFrom(res.Body)
+	return buf.String()
+}
+
+func TestToPrometheusName(t *testing.T) {
+	assert.Equal(t, "configdb_module", toPrometheusName("configDB_module"))
+	assert.Equal(t, "get_required_actions_endpoint", toPrometheusName("get_required-actions_endpoint"))
+}
+
+func TestPrometheusHistograms(t *testing.T) {
+	var m = NewPrometheusMetrics()
+
+	m.NewHistogram("get_user_endpoint").With(KeyCorrelationID, "corr-id", KeyRealm, "master").Observe(0.2)
+	m.NewHistogram("get_users").With(KeyRealm, "other").Observe(0.1)
+	m.NewHistogram("configDB_module").With(KeyCorrelationID, "corr-id").Observe(0.3)
+
+	var res = scrape(t, m)
+	assert.Contains(t, res, `keycloak_bridge_endpoint_duration_seconds_count{endpoint="get_user",realm="master"} 1`)
+	assert.Contains(t, res, `keycloak_bridge_endpoint_duration_seconds_count{endpoint="get_users",realm="other"} 1`)
+	assert.Contains(t, res, `keycloak_bridge_component_duration_seconds_count{component="configdb_module",realm=""} 1`)
+	assert.NotContains(t, res, "corr-id")
+}
+
+func TestPrometheusCountersAndGauges(t *testing.T) {
+	var m = NewPrometheusMetrics()
+
+	m.NewCounter("sent_emails").With(KeyRealm, "master").Add(2)
+	m.NewGauge("running_jobs").Set(3)
+	assert.Nil(t, m.Stats(context.TODO(), "event_statistics", map[string]string{"type": "LOGIN", "realm": "master", "userId": "123"}, nil))
+	// A counter and stats with the same name must not conflict
+	m.NewCounter("event_statistics").Add(1)
+
+	var res = scrape(t, m)
+	assert.Contains(t, res, `keycloak_bridge_sent_emails_total{realm="master"} 2`)
+	assert.Contains(t, res, `keycloak_bridge_running_jobs{realm=""} 3`)
+	assert.Contains(t, res, `keycloak_bridge_stats_event_statistics_total{realm="master",type="LOGIN"} 1`)
+	assert.Contains(t, res, `keycloak_bridge_event_statistics_total{realm=""} 1`)
+	assert.NotContains(t, res, "userId")
+}
+
+func TestPrometheusExpositionFormat(t *testing.T) {
+	var m = NewPrometheusMetrics()
+
+	m.NewHistogram("get_user_endpoint").With(KeyRealm, "master").Observe(0.2)
+	m.NewCounter("sent_emails").With(KeyRealm, `my "realm"`).Add(1)
+
+	var ts = httptest.NewServer(m.Handler())
+	defer ts.Close()
+	var res, err = http.Get(ts.URL)
+	assert.Nil(t, err)
+	assert.Equal(t, "text/plain; version=0.0.4; charset=utf-8", res.Header.Get("Content-Type"))
+
+	var buf = new(bytes.Buffer)
+	buf.ReadFrom(res.Body)
+	var body = buf.String()
+	assert.Contains(t, body, "# TYPE keycloak_bridge_endpoint_duration_seconds histogram\n")
+	assert.Contains(t, body, `keycloak_bridge_endpoint_duration_seconds_bucket{endpoint="get_user",realm="master",le="0.1"} 0`)
+	assert.Contains(t, body, `keycloak_bridge_endpoint_duration_seconds_bucket{endpoint="get_user",realm="master",le="0.25"} 1`)
+	assert.Contains(t, body, `keycloak_bridge_endpoint_duration_seconds_bucket{endpoint="get_user",realm="master",le="+Inf"} 1`)
+	assert.Contains(t, body, `keycloak_bridge_endpoint_duration_seconds_sum{endpoint="get_user",realm="master"} 0.2`)
+	assert.Contains(t, body, "# TYPE keycloak_bridge_sent_emails_total counter\n")
+	assert.Contains(t, body, `keycloak_bridge_sent_emails_total{realm="my \"realm\""} 1`)
+	assert.Contains(t, body, "# TYPE go_goroutines gauge\n")
+}
@@ -0,0 +1,128 @@
+package keycloakb
+
+import (
+	"context"
+	"time"
+
+	"github.com/cloudtrust/common-service/database/sqltypes"
+	"github.com/cloudtrust/common-service/log"
+	"github.com/cloudtrust/keycloak-bridge/internal/dto"
+)
+
+const (
+	insertScheduledUserChangeStmt = `
+		INSERT INTO scheduled_user_changes (realm_id, user_id, action, apply_at, created_at, creator_realm, creator_id, creator_username)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	selectUserScheduledChangesStmt = `
+		SELECT id, realm_id, user_id, action, unix_timestamp(apply_at), unix_timestamp(created_at), creator_realm, creator_id, creator_username, attempts
+		FROM scheduled_user_changes
+		WHERE realm_id=? AND user_id=?
+		ORDER BY apply_at
+	`
+	selectDueScheduledChangesStmt = `
+		SELECT id, realm_id, user_id, action, unix_timestamp(apply_at), unix_timestamp(created_at), creator_realm, creator_id, creator_username, attempts
+		FROM scheduled_user_changes
+		WHERE apply_at<=?
+		ORDER BY apply_at, id
+	`
+	deleteScheduledUserChangeStmt         = `DELETE FROM scheduled_user_changes WHERE realm_id=? AND user_id=? AND id=?;`
+	updateScheduledUserChangeAttemptsStmt = `UPDATE scheduled_user_changes SET attempts=? WHERE id=?;`
+)
+
+// ScheduledUserChangesDBModule is the interface of the module storing the scheduled lock, unlock and expiration of users
+type ScheduledUserChangesDBModule interface {
+	CreateScheduledUserChange(ctx context.Context, change dto.DBScheduledUserChange) (int64, error)
+	GetScheduledUserChanges(ctx context.Context, realm string, userID string) ([]dto.DBScheduledUserChange, error)
+	GetDueScheduledUserChanges(ctx context.Context, now time.Time) ([]dto.DBScheduledUserChange, error)
+	DeleteScheduledUserChange(ctx context.Context, realm string, userID string, changeID int64) (bool, error)
+	UpdateScheduledUserChangeAttempts(ctx context.Context, changeID int64, attempts int) error
+}
+
+type scheduledUserChangesDBModule struct {
+	db     sqltypes.CloudtrustDB
+	logger log.Logger
+}
+
+// NewScheduledUserChangesDBModule returns a scheduled user changes DB module
+func NewScheduledUserChangesDBModule(db sqltypes.CloudtrustDB, logger log.Logger) ScheduledUserChangesDBModule {
+	return &scheduledUserChangesDBModule{
+		db:     db,
+		logger: logger,
+	}
+}
+
+func (c *scheduledUserChangesDBModule) CreateScheduledUserChange(ctx context.Context, change dto.DBScheduledUserChange) (int64, error) {
+	var res, err = c.db.Exec(insertScheduledUserChangeStmt, change.RealmName, change.UserID, change.Action, change.ApplyAt, change.CreatedAt,
+		change.CreatorRealm, change.CreatorID, change.CreatorUsername)
+	if err != nil {
+		c.logger.Warn(ctx, "msg", "Can't insert scheduled user change", "error", err.Error(), "realmID", change.RealmName, "userID", change.UserID)
+		return 0, err
+	}
+	return res.LastInsertId()
+}
+
+func (c *scheduledUserChangesDBModule) GetScheduledUserChanges(ctx context.Context, realm string, userID string) ([]dto.DBScheduledUserChange, error) {
+	var res, err = c.queryScheduledUserChanges(selectUserScheduledChangesStmt, realm, userID)
+	if err != nil {
+		c.logger.Warn(ctx, "msg", "Can't get scheduled user changes", "error", err.Error(), "realmID", realm, "userID", userID)
+	}
+	return res, err
+}
+
+func (c *scheduledUserChangesDBModule) GetDueScheduledUserChanges(ctx context.Context, now time.Time) ([]dto.DBScheduledUserChange, error) {
+	var res, err = c.queryScheduledUserChanges(selectDueScheduledChangesStmt, now)
+	if err != nil {
+		c.logger.Warn(ctx, "msg", "Can't get due scheduled user changes", "error", err.Error())
+	}
+	return res, err
+}
+
+func (c *scheduledUserChangesDBModule) queryScheduledUserChanges(query string, args ...interface{}) ([]dto.DBScheduledUserChange, error) {
+	var rows, err = c.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res = make([]dto.DBScheduledUserChange, 0)
+	for rows.Next() {
+		var (
+			change    dto.DBScheduledUserChange
+			applyAt   int64
+			createdAt int64
+		)
+		err = rows.Scan(&change.ID, &change.RealmName, &change.UserID, &change.Action, &applyAt, &createdAt, &change.CreatorRealm,
+			&change.CreatorID, &change.CreatorUsername, &change.Attempts)
+		if err != nil {
+			return nil, err
+		}
+		change.ApplyAt = time.Unix(applyAt, 0).UTC()
+		change.CreatedAt = time.Unix(createdAt, 0).UTC()
+		res = append(res, change)
+	}
+	return res, rows.Err()
+}
+
+// DeleteScheduledUserChange returns false when the change does not exist
+func (c *scheduledUserChangesDBModule) DeleteScheduledUserChange(ctx context.Context, realm string, userID string, changeID int64) (bool, error) {
+	var res, err = c.db.Exec(deleteScheduledUserChangeStmt, realm, userID, changeID)
+	if err != nil {
+		c.logger.Warn(ctx, "msg", "Can't delete scheduled user change", "error", err.Error(), "realmID", realm, "userID", userID, "changeID", changeID)
+		return false, err
+	}
+	var count int64
+	if count, err = res.RowsAffected(); err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// UpdateScheduledUserChangeAttempts stores the number of failed attempts to apply a change
+func (c *scheduledUserChangesDBModule) UpdateScheduledUserChangeAttempts(ctx context.Context, changeID int64, attempts int) error {
+	var _, err = c.db.Exec(updateScheduledUserChangeAttemptsStmt, attempts, changeID)
+	if err != nil {
+		c.logger.Warn(ctx, "msg", "Can't update attempts of scheduled user change", "error", err.Error(), "changeID", changeID)
+	}
+	return err
+}
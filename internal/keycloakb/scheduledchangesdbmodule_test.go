@@ -0,0 +1,108 @@
+package keycloakb
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/cloudtrust/common-service/log"
+	"github.com/cloudtrust/keycloak-bridge/internal/dto"
+	"github.com/cloudtrust/keycloak-bridge/internal/keycloakb/mock"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScheduledUserChangesDBModule(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+	var mockDB = mock.NewCloudtrustDB(mockCtrl)
+	var mockSQLRows = mock.NewSQLRows(mockCtrl)
+
+	var module = NewScheduledUserChangesDBModule(mockDB, log.NewNopLogger())
+	var ctx = context.TODO()
+	var realm = "my-realm"
+	var userID = "user-id"
+	var now = time.Unix(1591000000, 0).UTC()
+	var expectedError = errors.New("db error")
+	var scanChange = func(dest ...interface{}) error {
+		*(dest[0].(*int64)) = 7
+		*(dest[1].(*string)) = realm
+		*(dest[2].(*string)) = userID
+		*(dest[3].(*string)) = dto.ScheduledActionLock
+		*(dest[4].(*int64)) = now.Unix()
+		*(dest[5].(*int64)) = now.Unix() - 3600
+		*(dest[6].(*string)) = "master"
+		*(dest[7].(*string)) = "operator-id"
+		*(dest[8].(*string)) = "operator"
+		*(dest[9].(*int)) = 2
+		return nil
+	}
+
+	t.Run("Create scheduled user change", func(t *testing.T) {
+		var change = dto.DBScheduledUserChange{RealmName: realm, UserID: userID, Action: dto.ScheduledActionExpire, ApplyAt: now, CreatedAt: now,
+			CreatorRealm: "master", CreatorID: "operator-id", CreatorUsername: "operator"}
+		mockDB.EXPECT().Exec(insertScheduledUserChangeStmt, realm, userID, dto.ScheduledActionExpire, now, now, "master", "operator-id",
+			"operator").Return(nil, expectedError)
+		var _, err = module.CreateScheduledUserChange(ctx, change)
+		assert.Equal(t, expectedError, err)
+
+		mockDB.EXPECT().Exec(insertScheduledUserChangeStmt, gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(),
+			gomock.Any(), gomock.Any()).Return(sqlResult{id: 7}, nil)
+		id, err := module.CreateScheduledUserChange(ctx, change)
+		assert.Nil(t, err)
+		assert.Equal(t, int64(7), id)
+	})
+
+	t.Run("Get scheduled changes of user", func(t *testing.T) {
+		mockDB.EXPECT().Query(selectUserScheduledChangesStmt, realm, userID).Return(nil, expectedError)
+		var _, err = module.GetScheduledUserChanges(ctx, realm, userID)
+		assert.Equal(t, expectedError, err)
+
+		gomock.InOrder(
+			mockDB.EXPECT().Query(selectUserScheduledChangesStmt, realm, userID).Return(mockSQLRows, nil),
+			mockSQLRows.EXPECT().Next().Return(true),
+			mockSQLRows.EXPECT().Scan(gomock.Any()).DoAndReturn(scanChange),
+			mockSQLRows.EXPECT().Next().Return(false),
+			mockSQLRows.EXPECT().Err().Return(nil),
+			mockSQLRows.EXPECT().Close(),
+		)
+		changes, err := module.GetScheduledUserChanges(ctx, realm, userID)
+		assert.Nil(t, err)
+		assert.Len(t, changes, 1)
+		assert.Equal(t, int64(7), changes[0].ID)
+		assert.Equal(t, now, changes[0].ApplyAt)
+		assert.Equal(t, "operator", changes[0].CreatorUsername)
+		assert.Equal(t, 2, changes[0].Attempts)
+	})
+
+	t.Run("Get due scheduled changes", func(t *testing.T) {
+		gomock.InOrder(
+			mockDB.EXPECT().Query(selectDueScheduledChangesStmt, now).Return(mockSQLRows, nil),
+			mockSQLRows.EXPECT().Next().Return(true),
+			mockSQLRows.EXPECT().Scan(gomock.Any()).Return(expectedError),
+			mockSQLRows.EXPECT().Close(),
+		)
+		var _, err = module.GetDueScheduledUserChanges(ctx, now)
+		assert.Equal(t, expectedError, err)
+	})
+
+	t.Run("Delete scheduled change", func(t *testing.T) {
+		mockDB.EXPECT().Exec(deleteScheduledUserChangeStmt, realm, userID, int64(7)).Return(nil, expectedError)
+		var _, err = module.DeleteScheduledUserChange(ctx, realm, userID, 7)
+		assert.Equal(t, expectedError, err)
+
+		mockDB.EXPECT().Exec(deleteScheduledUserChangeStmt, realm, userID, int64(7)).Return(sqlResult{}, nil)
+		found, err := module.DeleteScheduledUserChange(ctx, realm, userID, 7)
+		assert.Nil(t, err)
+		assert.True(t, found)
+	})
+
+	t.Run("Update attempts of scheduled change", func(t *testing.T) {
+		mockDB.EXPECT().Exec(updateScheduledUserChangeAttemptsStmt, 3, int64(7)).Return(nil, expectedError)
+		assert.Equal(t, expectedError, module.UpdateScheduledUserChangeAttempts(ctx, 7, 3))
+
+		mockDB.EXPECT().Exec(updateScheduledUserChangeAttemptsStmt, 3, int64(7)).Return(sqlResult{}, nil)
+		assert.Nil(t, module.UpdateScheduledUserChangeAttempts(ctx, 7, 3))
+	})
+}
@@ -0,0 +1,120 @@
+package keycloakb
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/cloudtrust/common-service/database/sqltypes"
+	"github.com/cloudtrust/common-service/log"
+	"github.com/cloudtrust/keycloak-bridge/internal/dto"
+)
+
+const (
+	insertSoftDeletedUserStmt = `
+		INSERT INTO soft_deleted_users (realm_id, user_id, username, deleted_at, purge_at, was_enabled)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE username=?, deleted_at=?, purge_at=?, was_enabled=?
+	`
+	selectSoftDeletedUserStmt = `
+		SELECT realm_id, user_id, username, unix_timestamp(deleted_at), unix_timestamp(purge_at), was_enabled
+		FROM soft_deleted_users
+		WHERE realm_id=? AND user_id=?
+	`
+	selectUsersToPurgeStmt = `
+		SELECT realm_id, user_id, username, unix_timestamp(deleted_at), unix_timestamp(purge_at), was_enabled
+		FROM soft_deleted_users
+		WHERE purge_at<=?
+		ORDER BY purge_at
+	`
+	deleteSoftDeletedUserStmt = `DELETE FROM soft_deleted_users WHERE realm_id=? AND user_id=?;`
+)
+
+// SoftDeletedUsersDBModule is the interface of the module storing the soft deleted users until they are purged
+type SoftDeletedUsersDBModule interface {
+	StoreSoftDeletedUser(ctx context.Context, user dto.DBSoftDeletedUser) error
+	GetSoftDeletedUser(ctx context.Context, realm string, userID string) (*dto.DBSoftDeletedUser, error)
+	GetUsersToPurge(ctx context.Context, now time.Time) ([]dto.DBSoftDeletedUser, error)
+	DeleteSoftDeletedUser(ctx context.Context, realm string, userID string) error
+}
+
+type softDeletedUsersDBModule struct {
+	db     sqltypes.CloudtrustDB
+	logger log.Logger
+}
+
+// NewSoftDeletedUsersDBModule returns a soft deleted users DB module
+func NewSoftDeletedUsersDBModule(db sqltypes.CloudtrustDB, logger log.Logger) SoftDeletedUsersDBModule {
+	return &softDeletedUsersDBModule{
+		db:     db,
+		logger: logger,
+	}
+}
+
+func (c *softDeletedUsersDBModule) StoreSoftDeletedUser(ctx context.Context, user dto.DBSoftDeletedUser) error {
+	var _, err = c.db.Exec(insertSoftDeletedUserStmt, user.RealmName, user.UserID, user.Username, user.DeletedAt, user.PurgeAt, user.WasEnabled,
+		user.Username, user.DeletedAt, user.PurgeAt, user.WasEnabled)
+	if err != nil {
+		c.logger.Warn(ctx, "msg", "Can't store soft deleted user", "error", err.Error(), "realmID", user.RealmName, "userID", user.UserID)
+	}
+	return err
+}
+
+// GetSoftDeletedUser returns nil when the user is not soft deleted
+func (c *softDeletedUsersDBModule) GetSoftDeletedUser(ctx context.Context, realm string, userID string) (*dto.DBSoftDeletedUser, error) {
+	var row = c.db.QueryRow(selectSoftDeletedUserStmt, realm, userID)
+	var user, err = c.scanSoftDeletedUser(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		c.logger.Warn(ctx, "msg", "Can't get soft deleted user", "error", err.Error(), "realmID", realm, "userID", userID)
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (c *softDeletedUsersDBModule) GetUsersToPurge(ctx context.Context, now time.Time) ([]dto.DBSoftDeletedUser, error) {
+	var rows, err = c.db.Query(selectUsersToPurgeStmt, now)
+	if err != nil {
+		c.logger.Warn(ctx, "msg", "Can't get users to purge", "error", err.Error())
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res = make([]dto.DBSoftDeletedUser, 0)
+	for rows.Next() {
+		var user, err = c.scanSoftDeletedUser(rows)
+		if err != nil {
+			c.logger.Warn(ctx, "msg", "Can't get users to purge. Scan failed", "error", err.Error())
+			return nil, err
+		}
+		res = append(res, user)
+	}
+	return res, rows.Err()
+}
+
+func (c *softDeletedUsersDBModule) DeleteSoftDeletedUser(ctx context.Context, realm string, userID string) error {
+	var _, err = c.db.Exec(deleteSoftDeletedUserStmt, realm, userID)
+	if err != nil {
+		c.logger.Warn(ctx, "msg", "Can't delete soft deleted user", "error", err.Error(), "realmID", realm, "userID", userID)
+	}
+	return err
+}
+
+func (c *softDeletedUsersDBModule) scanSoftDeletedUser(scanner Scanner) (dto.DBSoftDeletedUser, error) {
+	var (
+		user      dto.DBSoftDeletedUser
+		username  sql.NullString
+		deletedAt int64
+		purgeAt   int64
+	)
+
+	if err := scanner.Scan(&user.RealmName, &user.UserID, &username, &deletedAt, &purgeAt, &user.WasEnabled); err != nil {
+		return dto.DBSoftDeletedUser{}, err
+	}
+	user.Username = nullStringToPtr(username)
+	user.DeletedAt = time.Unix(deletedAt, 0).UTC()
+	user.PurgeAt = time.Unix(purgeAt, 0).UTC()
+	return user, nil
+}
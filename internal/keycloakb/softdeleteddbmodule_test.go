@@ -0,0 +1,92 @@
+package keycloakb
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/cloudtrust/common-service/log"
+	"github.com/cloudtrust/keycloak-bridge/internal/dto"
+	"github.com/cloudtrust/keycloak-bridge/internal/keycloakb/mock"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSoftDeletedUsersDBModule(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+	var mockDB = mock.NewCloudtrustDB(mockCtrl)
+	var mockSQLRow = mock.NewSQLRow(mockCtrl)
+	var mockSQLRows = mock.NewSQLRows(mockCtrl)
+
+	var module = NewSoftDeletedUsersDBModule(mockDB, log.NewNopLogger())
+	var ctx = context.TODO()
+	var realm = "my-realm"
+	var userID = "user-id"
+	var username = "jdoe"
+	var now = time.Unix(1591000000, 0).UTC()
+	var expectedError = errors.New("db error")
+	var scanUser = func(dest ...interface{}) error {
+		*(dest[0].(*string)) = realm
+		*(dest[1].(*string)) = userID
+		*(dest[2].(*sql.NullString)) = sql.NullString{String: username, Valid: true}
+		*(dest[3].(*int64)) = now.Unix()
+		*(dest[4].(*int64)) = now.Add(time.Hour).Unix()
+		*(dest[5].(*bool)) = true
+		return nil
+	}
+
+	t.Run("Store soft deleted user", func(t *testing.T) {
+		var user = dto.DBSoftDeletedUser{RealmName: realm, UserID: userID, Username: &username, DeletedAt: now, PurgeAt: now, WasEnabled: true}
+		mockDB.EXPECT().Exec(insertSoftDeletedUserStmt, realm, userID, &username, now, now, true, &username, now, now, true).Return(nil, expectedError)
+		assert.Equal(t, expectedError, module.StoreSoftDeletedUser(ctx, user))
+	})
+
+	t.Run("Get soft deleted user", func(t *testing.T) {
+		mockDB.EXPECT().QueryRow(selectSoftDeletedUserStmt, realm, userID).Return(mockSQLRow)
+		mockSQLRow.EXPECT().Scan(gomock.Any()).Return(sql.ErrNoRows)
+		var user, err = module.GetSoftDeletedUser(ctx, realm, userID)
+		assert.Nil(t, err)
+		assert.Nil(t, user)
+
+		mockDB.EXPECT().QueryRow(selectSoftDeletedUserStmt, realm, userID).Return(mockSQLRow)
+		mockSQLRow.EXPECT().Scan(gomock.Any()).Return(expectedError)
+		_, err = module.GetSoftDeletedUser(ctx, realm, userID)
+		assert.Equal(t, expectedError, err)
+
+		mockDB.EXPECT().QueryRow(selectSoftDeletedUserStmt, realm, userID).Return(mockSQLRow)
+		mockSQLRow.EXPECT().Scan(gomock.Any()).DoAndReturn(scanUser)
+		user, err = module.GetSoftDeletedUser(ctx, realm, userID)
+		assert.Nil(t, err)
+		assert.Equal(t, username, *user.Username)
+		assert.Equal(t, now, user.DeletedAt)
+		assert.Equal(t, now.Add(time.Hour), user.PurgeAt)
+		assert.True(t, user.WasEnabled)
+	})
+
+	t.Run("Get users to purge", func(t *testing.T) {
+		mockDB.EXPECT().Query(selectUsersToPurgeStmt, now).Return(nil, expectedError)
+		var _, err = module.GetUsersToPurge(ctx, now)
+		assert.Equal(t, expectedError, err)
+
+		gomock.InOrder(
+			mockDB.EXPECT().Query(selectUsersToPurgeStmt, now).Return(mockSQLRows, nil),
+			mockSQLRows.EXPECT().Next().Return(true),
+			mockSQLRows.EXPECT().Scan(gomock.Any()).DoAndReturn(scanUser),
+			mockSQLRows.EXPECT().Next().Return(false),
+			mockSQLRows.EXPECT().Err().Return(nil),
+			mockSQLRows.EXPECT().Close(),
+		)
+		users, err := module.GetUsersToPurge(ctx, now)
+		assert.Nil(t, err)
+		assert.Len(t, users, 1)
+		assert.Equal(t, userID, users[0].UserID)
+	})
+
+	t.Run("Delete soft deleted user", func(t *testing.T) {
+		mockDB.EXPECT().Exec(deleteSoftDeletedUserStmt, realm, userID).Return(nil, nil)
+		assert.Nil(t, module.DeleteSoftDeletedUser(ctx, realm, userID))
+	})
+}
@@ -0,0 +1,206 @@
+package keycloakb
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	cs "github.com/cloudtrust/common-service"
+	"github.com/cloudtrust/common-service/database"
+	errorhandler "github.com/cloudtrust/common-service/errors"
+	"github.com/cloudtrust/common-service/idgenerator"
+	"github.com/cloudtrust/common-service/log"
+	"github.com/cloudtrust/keycloak-bridge/internal/constants"
+	"github.com/cloudtrust/keycloak-bridge/internal/dto"
+	kc "github.com/cloudtrust/keycloak-client"
+	"github.com/pkg/errors"
+)
+
+// SoftDeletionKeycloakClient are methods from keycloak-client used to soft delete users
+type SoftDeletionKeycloakClient interface {
+	GetUser(accessToken string, realmName, userID string) (kc.UserRepresentation, error)
+	UpdateUser(accessToken string, realmName, userID string, user kc.UserRepresentation) error
+	DeleteUser(accessToken string, realmName, userID string) error
+}
+
+// SoftDeletionUsersDBModule is the part of the users details module used to purge users
+type SoftDeletionUsersDBModule interface {
+	DeleteUserDetails(ctx context.Context, realm string, userID string) error
+}
+
+// UserSoftDeletion disables users marked for deletion and really deletes them once their grace period is elapsed.
+// Keycloak is called with the token of the technical user so that users can also soft delete their own account.
+type UserSoftDeletion interface {
+	SoftDeleteUser(ctx context.Context, realmName string, userID string) error
+	RestoreUser(ctx context.Context, realmName string, userID string) error
+	PurgeDueUsers(ctx context.Context, now time.Time) error
+	Run(c <-chan time.Time)
+}
+
+type userSoftDeletion struct {
+	keycloakClient SoftDeletionKeycloakClient
+	tokenProvider  TokenProvider
+	softDeletedDB  SoftDeletedUsersDBModule
+	usersDB        SoftDeletionUsersDBModule
+	eventsDB       database.EventsDBModule
+	gracePeriod    time.Duration
+	idGenerator    idgenerator.IDGenerator
+	logger         log.Logger
+}
+
+// NewUserSoftDeletion creates the soft deletion module. Users are purged once the grace period is elapsed.
+func NewUserSoftDeletion(keycloakClient SoftDeletionKeycloakClient, tokenProvider TokenProvider, softDeletedDB SoftDeletedUsersDBModule,
+	usersDB SoftDeletionUsersDBModule, eventsDB database.EventsDBModule, gracePeriod time.Duration, idGenerator idgenerator.IDGenerator,
+	logger log.Logger) UserSoftDeletion {
+	return &userSoftDeletion{
+		keycloakClient: keycloakClient,
+		tokenProvider:  tokenProvider,
+		softDeletedDB:  softDeletedDB,
+		usersDB:        usersDB,
+		eventsDB:       eventsDB,
+		gracePeriod:    gracePeriod,
+		idGenerator:    idGenerator,
+		logger:         logger,
+	}
+}
+
+// IsSoftDeleted returns true if the user is marked for deletion
+func IsSoftDeleted(user kc.UserRepresentation) bool {
+	return user.GetAttributeString(constants.AttrbSoftDeleted) != nil
+}
+
+// SoftDeleteUser disables the user and marks it for deletion. Its details are kept until it is purged.
+func (s *userSoftDeletion) SoftDeleteUser(ctx context.Context, realmName string, userID string) error {
+	var accessToken, err = s.tokenProvider.ProvideToken(ctx)
+	if err != nil {
+		s.logger.Warn(ctx, "msg", "Can't get technical token", "err", err.Error())
+		return err
+	}
+
+	var user kc.UserRepresentation
+	if user, err = s.keycloakClient.GetUser(accessToken, realmName, userID); err != nil {
+		s.logger.Warn(ctx, "err", err.Error())
+		return err
+	}
+	ConvertLegacyAttribute(&user)
+	if IsSoftDeleted(user) {
+		return nil
+	}
+
+	var now = time.Now()
+	var softDeleted = dto.DBSoftDeletedUser{
+		RealmName:  realmName,
+		UserID:     userID,
+		Username:   user.Username,
+		DeletedAt:  now,
+		PurgeAt:    now.Add(s.gracePeriod),
+		WasEnabled: user.Enabled == nil || *user.Enabled,
+	}
+	if err = s.softDeletedDB.StoreSoftDeletedUser(ctx, softDeleted); err != nil {
+		return err
+	}
+
+	var disabled = false
+	user.Enabled = &disabled
+	user.SetAttributeString(constants.AttrbSoftDeleted, strconv.FormatInt(now.UnixNano()/int64(time.Millisecond), 10))
+	if err = s.keycloakClient.UpdateUser(accessToken, realmName, userID, user); err != nil {
+		s.logger.Warn(ctx, "err", err.Error())
+		_ = s.softDeletedDB.DeleteSoftDeletedUser(ctx, realmName, userID)
+		return err
+	}
+
+	return nil
+}
+
+// RestoreUser removes the deletion mark of a user and enables it again if it was enabled when it was deleted
+func (s *userSoftDeletion) RestoreUser(ctx context.Context, realmName string, userID string) error {
+	var softDeleted, err = s.softDeletedDB.GetSoftDeletedUser(ctx, realmName, userID)
+	if err != nil {
+		return err
+	}
+	if softDeleted == nil {
+		s.logger.Warn(ctx, "msg", "User is not soft deleted", "userID", userID)
+		return errorhandler.CreateNotFoundError(constants.User)
+	}
+
+	var accessToken string
+	if accessToken, err = s.tokenProvider.ProvideToken(ctx); err != nil {
+		s.logger.Warn(ctx, "msg", "Can't get technical token", "err", err.Error())
+		return err
+	}
+
+	var user kc.UserRepresentation
+	if user, err = s.keycloakClient.GetUser(accessToken, realmName, userID); err != nil {
+		s.logger.Warn(ctx, "err", err.Error())
+		return err
+	}
+	ConvertLegacyAttribute(&user)
+
+	user.Enabled = &softDeleted.WasEnabled
+	if user.Attributes != nil {
+		delete(*user.Attributes, constants.AttrbSoftDeleted)
+	}
+	if err = s.keycloakClient.UpdateUser(accessToken, realmName, userID, user); err != nil {
+		s.logger.Warn(ctx, "err", err.Error())
+		return err
+	}
+
+	return s.softDeletedDB.DeleteSoftDeletedUser(ctx, realmName, userID)
+}
+
+// Run purges the due users at each tick of the given channel
+func (s *userSoftDeletion) Run(c <-chan time.Time) {
+	for now := range c {
+		var ctx = context.WithValue(context.Background(), cs.CtContextCorrelationID, s.idGenerator.NextID())
+		if err := s.PurgeDueUsers(ctx, now); err != nil {
+			s.logger.Warn(ctx, "msg", "Can't purge soft deleted users", "err", err.Error())
+		}
+	}
+}
+
+// PurgeDueUsers deletes the users whose grace period is elapsed. A failing user does not prevent the others from being purged.
+func (s *userSoftDeletion) PurgeDueUsers(ctx context.Context, now time.Time) error {
+	var users, err = s.softDeletedDB.GetUsersToPurge(ctx, now)
+	if err != nil || len(users) == 0 {
+		return err
+	}
+
+	var accessToken string
+	if accessToken, err = s.tokenProvider.ProvideToken(ctx); err != nil {
+		s.logger.Warn(ctx, "msg", "Can't get technical token", "err", err.Error())
+		return err
+	}
+
+	for _, user := range users {
+		if err := s.purgeUser(ctx, accessToken, user); err != nil {
+			s.logger.Warn(ctx, "msg", "Can't purge soft deleted user", "err", err.Error(), "realm", user.RealmName, "userID", user.UserID)
+		}
+	}
+	return nil
+}
+
+func (s *userSoftDeletion) purgeUser(ctx context.Context, accessToken string, user dto.DBSoftDeletedUser) error {
+	var err = s.keycloakClient.DeleteUser(accessToken, user.RealmName, user.UserID)
+	if e, ok := errors.Cause(err).(kc.HTTPError); ok && e.HTTPStatus == http.StatusNotFound {
+		err = nil
+	}
+	if err != nil {
+		return err
+	}
+	if err = s.usersDB.DeleteUserDetails(ctx, user.RealmName, user.UserID); err != nil {
+		return err
+	}
+	if err = s.softDeletedDB.DeleteSoftDeletedUser(ctx, user.RealmName, user.UserID); err != nil {
+		return err
+	}
+
+	var values = []string{database.CtEventRealmName, user.RealmName, database.CtEventUserID, user.UserID}
+	if user.Username != nil {
+		values = append(values, database.CtEventUsername, *user.Username)
+	}
+	if errEvent := s.eventsDB.ReportEvent(ctx, "ACCOUNT_PURGED", "back-office", values...); errEvent != nil {
+		LogUnrecordedEvent(ctx, s.logger, "ACCOUNT_PURGED", errEvent.Error(), values...)
+	}
+	return nil
+}
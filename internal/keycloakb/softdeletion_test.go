@@ -0,0 +1,162 @@
+package keycloakb
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	cs "github.com/cloudtrust/common-service"
+	"github.com/cloudtrust/common-service/database"
+	"github.com/cloudtrust/common-service/log"
+	"github.com/cloudtrust/keycloak-bridge/internal/constants"
+	"github.com/cloudtrust/keycloak-bridge/internal/dto"
+	"github.com/cloudtrust/keycloak-bridge/internal/keycloakb/mock"
+	kc "github.com/cloudtrust/keycloak-client"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSoftDeleteUser(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+	var mockKeycloakClient = mock.NewSoftDeletionKeycloakClient(mockCtrl)
+	var mockTokenProvider = mock.NewTokenProvider(mockCtrl)
+	var mockSoftDeletedDB = mock.NewSoftDeletedUsersDBModule(mockCtrl)
+	var mockUsersDB = mock.NewSoftDeletionUsersDBModule(mockCtrl)
+	var mockEventsDB = mock.NewEventsDBModule(mockCtrl)
+	var mockIDGenerator = mock.NewIDGenerator(mockCtrl)
+
+	var softDeletion = NewUserSoftDeletion(mockKeycloakClient, mockTokenProvider, mockSoftDeletedDB, mockUsersDB, mockEventsDB, 24*time.Hour, mockIDGenerator, log.NewNopLogger())
+
+	var ctx = context.TODO()
+	var accessToken = "TOKEN=="
+	var realm = "my-realm"
+	var userID = "user-id"
+	var username = "jdoe"
+	var enabled = true
+	var expectedError = errors.New("kc error")
+
+	t.Run("Can't get technical token", func(t *testing.T) {
+		mockTokenProvider.EXPECT().ProvideToken(ctx).Return("", expectedError)
+		assert.Equal(t, expectedError, softDeletion.SoftDeleteUser(ctx, realm, userID))
+	})
+
+	t.Run("User is already soft deleted", func(t *testing.T) {
+		var attributes = kc.Attributes{constants.AttrbSoftDeleted: []string{"1591000000000"}}
+		mockTokenProvider.EXPECT().ProvideToken(ctx).Return(accessToken, nil)
+		mockKeycloakClient.EXPECT().GetUser(accessToken, realm, userID).Return(kc.UserRepresentation{Attributes: &attributes}, nil)
+		assert.Nil(t, softDeletion.SoftDeleteUser(ctx, realm, userID))
+	})
+
+	t.Run("Keycloak update fails", func(t *testing.T) {
+		mockTokenProvider.EXPECT().ProvideToken(ctx).Return(accessToken, nil)
+		mockKeycloakClient.EXPECT().GetUser(accessToken, realm, userID).Return(kc.UserRepresentation{Username: &username, Enabled: &enabled}, nil)
+		mockSoftDeletedDB.EXPECT().StoreSoftDeletedUser(ctx, gomock.Any()).Return(nil)
+		mockKeycloakClient.EXPECT().UpdateUser(accessToken, realm, userID, gomock.Any()).Return(expectedError)
+		mockSoftDeletedDB.EXPECT().DeleteSoftDeletedUser(ctx, realm, userID).Return(nil)
+		assert.Equal(t, expectedError, softDeletion.SoftDeleteUser(ctx, realm, userID))
+	})
+
+	t.Run("User is disabled and marked", func(t *testing.T) {
+		mockTokenProvider.EXPECT().ProvideToken(ctx).Return(accessToken, nil)
+		mockKeycloakClient.EXPECT().GetUser(accessToken, realm, userID).Return(kc.UserRepresentation{Username: &username, Enabled: &enabled}, nil)
+		mockSoftDeletedDB.EXPECT().StoreSoftDeletedUser(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, user dto.DBSoftDeletedUser) error {
+			assert.Equal(t, username, *user.Username)
+			assert.True(t, user.WasEnabled)
+			assert.Equal(t, 24*time.Hour, user.PurgeAt.Sub(user.DeletedAt))
+			return nil
+		})
+		mockKeycloakClient.EXPECT().UpdateUser(accessToken, realm, userID, gomock.Any()).DoAndReturn(func(_, _, _ string, user kc.UserRepresentation) error {
+			assert.False(t, *user.Enabled)
+			assert.True(t, IsSoftDeleted(user))
+			return nil
+		})
+		assert.Nil(t, softDeletion.SoftDeleteUser(ctx, realm, userID))
+	})
+}
+
+func TestRestoreSoftDeletedUser(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+	var mockKeycloakClient = mock.NewSoftDeletionKeycloakClient(mockCtrl)
+	var mockTokenProvider = mock.NewTokenProvider(mockCtrl)
+	var mockSoftDeletedDB = mock.NewSoftDeletedUsersDBModule(mockCtrl)
+	var mockUsersDB = mock.NewSoftDeletionUsersDBModule(mockCtrl)
+	var mockEventsDB = mock.NewEventsDBModule(mockCtrl)
+	var mockIDGenerator = mock.NewIDGenerator(mockCtrl)
+
+	var softDeletion = NewUserSoftDeletion(mockKeycloakClient, mockTokenProvider, mockSoftDeletedDB, mockUsersDB, mockEventsDB, time.Hour, mockIDGenerator, log.NewNopLogger())
+
+	var ctx = context.TODO()
+	var accessToken = "TOKEN=="
+	var realm = "my-realm"
+	var userID = "user-id"
+	var disabled = false
+
+	t.Run("User is not soft deleted", func(t *testing.T) {
+		mockSoftDeletedDB.EXPECT().GetSoftDeletedUser(ctx, realm, userID).Return(nil, nil)
+		assert.NotNil(t, softDeletion.RestoreUser(ctx, realm, userID))
+	})
+
+	t.Run("User is restored", func(t *testing.T) {
+		var attributes = kc.Attributes{constants.AttrbSoftDeleted: []string{"1591000000000"}}
+		mockSoftDeletedDB.EXPECT().GetSoftDeletedUser(ctx, realm, userID).Return(&dto.DBSoftDeletedUser{RealmName: realm, UserID: userID, WasEnabled: true}, nil)
+		mockTokenProvider.EXPECT().ProvideToken(ctx).Return(accessToken, nil)
+		mockKeycloakClient.EXPECT().GetUser(accessToken, realm, userID).Return(kc.UserRepresentation{Enabled: &disabled, Attributes: &attributes}, nil)
+		mockKeycloakClient.EXPECT().UpdateUser(accessToken, realm, userID, gomock.Any()).DoAndReturn(func(_, _, _ string, user kc.UserRepresentation) error {
+			assert.True(t, *user.Enabled)
+			assert.False(t, IsSoftDeleted(user))
+			return nil
+		})
+		mockSoftDeletedDB.EXPECT().DeleteSoftDeletedUser(ctx, realm, userID).Return(nil)
+		assert.Nil(t, softDeletion.RestoreUser(ctx, realm, userID))
+	})
+}
+
+func TestPurgeDueUsers(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+	var mockKeycloakClient = mock.NewSoftDeletionKeycloakClient(mockCtrl)
+	var mockTokenProvider = mock.NewTokenProvider(mockCtrl)
+	var mockSoftDeletedDB = mock.NewSoftDeletedUsersDBModule(mockCtrl)
+	var mockUsersDB = mock.NewSoftDeletionUsersDBModule(mockCtrl)
+	var mockEventsDB = mock.NewEventsDBModule(mockCtrl)
+	var mockIDGenerator = mock.NewIDGenerator(mockCtrl)
+
+	var softDeletion = NewUserSoftDeletion(mockKeycloakClient, mockTokenProvider, mockSoftDeletedDB, mockUsersDB, mockEventsDB, time.Hour, mockIDGenerator, log.NewNopLogger())
+
+	var ctx = context.TODO()
+	var accessToken = "TOKEN=="
+	var realm = "my-realm"
+	var userID1, userID2 = "user-1", "user-2"
+	var username = "jdoe"
+	var now = time.Now()
+	var users = []dto.DBSoftDeletedUser{{RealmName: realm, UserID: userID1, Username: &username}, {RealmName: realm, UserID: userID2}}
+
+	t.Run("Nothing to purge", func(t *testing.T) {
+		mockSoftDeletedDB.EXPECT().GetUsersToPurge(ctx, now).Return([]dto.DBSoftDeletedUser{}, nil)
+		assert.Nil(t, softDeletion.PurgeDueUsers(ctx, now))
+	})
+
+	t.Run("Failing user does not prevent the others from being purged", func(t *testing.T) {
+		mockSoftDeletedDB.EXPECT().GetUsersToPurge(ctx, now).Return(users, nil)
+		mockTokenProvider.EXPECT().ProvideToken(ctx).Return(accessToken, nil)
+		mockKeycloakClient.EXPECT().DeleteUser(accessToken, realm, userID1).Return(errors.New("kc error"))
+		mockKeycloakClient.EXPECT().DeleteUser(accessToken, realm, userID2).Return(kc.HTTPError{HTTPStatus: 404})
+		mockUsersDB.EXPECT().DeleteUserDetails(ctx, realm, userID2).Return(nil)
+		mockSoftDeletedDB.EXPECT().DeleteSoftDeletedUser(ctx, realm, userID2).Return(nil)
+		mockEventsDB.EXPECT().ReportEvent(ctx, "ACCOUNT_PURGED", "back-office", database.CtEventRealmName, realm, database.CtEventUserID, userID2).Return(nil)
+		assert.Nil(t, softDeletion.PurgeDueUsers(ctx, now))
+	})
+
+	t.Run("Run", func(t *testing.T) {
+		var c = make(chan time.Time, 1)
+		var ctxRun = context.WithValue(context.Background(), cs.CtContextCorrelationID, "corr-id")
+		mockIDGenerator.EXPECT().NextID().Return("corr-id")
+		mockSoftDeletedDB.EXPECT().GetUsersToPurge(ctxRun, now).Return(nil, errors.New("db error"))
+		c <- now
+		close(c)
+		softDeletion.Run(c)
+	})
+}
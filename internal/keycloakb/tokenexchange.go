@@ -0,0 +1,94 @@
+package keycloakb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	kc "github.com/cloudtrust/keycloak-client"
+	"github.com/pkg/errors"
+)
+
+const (
+	grantTypeTokenExchange = "urn:ietf:params:oauth:grant-type:token-exchange"
+	tokenTypeRefreshToken  = "urn:ietf:params:oauth:token-type:refresh_token"
+	tokenEndpointTemplate  = "%s/auth/realms/%s/protocol/openid-connect/token"
+)
+
+// ImpersonationToken is the token response of Keycloak. SessionState is the ID of the session opened for the user.
+type ImpersonationToken struct {
+	AccessToken      string `json:"access_token"`
+	ExpiresIn        int64  `json:"expires_in"`
+	RefreshToken     string `json:"refresh_token"`
+	RefreshExpiresIn int64  `json:"refresh_expires_in"`
+	SessionState     string `json:"session_state"`
+}
+
+// TokenExchanger obtains the tokens of a user with the token exchange of Keycloak
+type TokenExchanger interface {
+	ImpersonateUser(ctx context.Context, realmName, userID string) (ImpersonationToken, error)
+}
+
+type tokenExchanger struct {
+	keycloakURL  string
+	clientID     string
+	clientSecret string
+	httpClient   *http.Client
+}
+
+// NewTokenExchanger creates a token exchanger. The client must exist in the realms of the impersonated users, be confidential
+// and be allowed to exchange tokens and to impersonate the users (direct naked impersonation).
+func NewTokenExchanger(keycloakURL, clientID, clientSecret string, timeout time.Duration) TokenExchanger {
+	return &tokenExchanger{
+		keycloakURL:  strings.TrimSuffix(keycloakURL, "/"),
+		clientID:     clientID,
+		clientSecret: clientSecret,
+		httpClient:   &http.Client{Timeout: timeout},
+	}
+}
+
+// ImpersonateUser opens a session on behalf of the user and returns its tokens
+func (e *tokenExchanger) ImpersonateUser(ctx context.Context, realmName, userID string) (ImpersonationToken, error) {
+	var parameters = url.Values{
+		"grant_type":           {grantTypeTokenExchange},
+		"client_id":            {e.clientID},
+		"client_secret":        {e.clientSecret},
+		"requested_subject":    {userID},
+		"requested_token_type": {tokenTypeRefreshToken},
+	}
+	var tokenURL = fmt.Sprintf(tokenEndpointTemplate, e.keycloakURL, url.PathEscape(realmName))
+	var req, err = http.NewRequest(http.MethodPost, tokenURL, strings.NewReader(parameters.Encode()))
+	if err != nil {
+		return ImpersonationToken{}, err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := e.httpClient.Do(req)
+	if err != nil {
+		return ImpersonationToken{}, errors.Wrap(err, "could not exchange token")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var oidcError struct {
+			Error            string `json:"error"`
+			ErrorDescription string `json:"error_description"`
+		}
+		_ = json.NewDecoder(resp.Body).Decode(&oidcError)
+		return ImpersonationToken{}, kc.HTTPError{
+			HTTPStatus: resp.StatusCode,
+			Message:    strings.TrimSpace(oidcError.Error + " " + oidcError.ErrorDescription),
+		}
+	}
+
+	var token ImpersonationToken
+	if err = json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return ImpersonationToken{}, errors.Wrap(err, "could not read exchanged token")
+	}
+	return token, nil
+}
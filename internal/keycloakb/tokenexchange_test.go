@@ -0,0 +1,57 @@
+package keycloakb
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	kc "github.com/cloudtrust/keycloak-client"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenExchangerImpersonateUser(t *testing.T) {
+	var realm = "DEP"
+	var userID = "f467ed7c-0a1d-4eee-9bb8-669c6f89c0ee"
+	var status = http.StatusOK
+	var body string
+
+	var ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/auth/realms/DEP/protocol/openid-connect/token", r.URL.Path)
+		assert.Nil(t, r.ParseForm())
+		assert.Equal(t, "urn:ietf:params:oauth:grant-type:token-exchange", r.PostForm.Get("grant_type"))
+		assert.Equal(t, "impersonation", r.PostForm.Get("client_id"))
+		assert.Equal(t, "secret", r.PostForm.Get("client_secret"))
+		assert.Equal(t, userID, r.PostForm.Get("requested_subject"))
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	}))
+	defer ts.Close()
+
+	var exchanger = NewTokenExchanger(ts.URL+"/", "impersonation", "secret", time.Second)
+
+	t.Run("Exchange refused", func(t *testing.T) {
+		status = http.StatusForbidden
+		body = `{"error":"access_denied","error_description":"Client not allowed to exchange"}`
+		var _, err = exchanger.ImpersonateUser(context.TODO(), realm, userID)
+		assert.Equal(t, http.StatusForbidden, err.(kc.HTTPError).HTTPStatus)
+		assert.Equal(t, "access_denied Client not allowed to exchange", err.(kc.HTTPError).Message)
+	})
+
+	t.Run("Invalid response", func(t *testing.T) {
+		status = http.StatusOK
+		body = "not json"
+		var _, err = exchanger.ImpersonateUser(context.TODO(), realm, userID)
+		assert.NotNil(t, err)
+	})
+
+	t.Run("Tokens of the user", func(t *testing.T) {
+		status = http.StatusOK
+		body = `{"access_token":"ACCESS","expires_in":300,"refresh_token":"REFRESH","refresh_expires_in":1800,"session_state":"session-id"}`
+		var token, err = exchanger.ImpersonateUser(context.TODO(), realm, userID)
+		assert.Nil(t, err)
+		assert.Equal(t, ImpersonationToken{AccessToken: "ACCESS", ExpiresIn: 300, RefreshToken: "REFRESH", RefreshExpiresIn: 1800, SessionState: "session-id"}, token)
+	})
+}
@@ -0,0 +1,94 @@
+package keycloakb
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Device classes deduced from a user agent
+const (
+	DeviceClassDesktop = "desktop"
+	DeviceClassMobile  = "mobile"
+	DeviceClassTablet  = "tablet"
+	DeviceClassBot     = "bot"
+)
+
+// UserAgentUnknown is used when a value can't be deduced from a user agent
+const UserAgentUnknown = "unknown"
+
+// UserAgentInfo is the information extracted from a user agent
+type UserAgentInfo struct {
+	Browser     string
+	OS          string
+	DeviceClass string
+}
+
+type uaRule struct {
+	name    string
+	pattern *regexp.Regexp
+}
+
+// Rules are evaluated in order: some browsers also advertise the names of the browsers they are based on
+// (Edge contains Chrome and Safari, Chrome contains Safari, ...)
+var (
+	uaBrowserRules = []uaRule{
+		{"Edge", regexp.MustCompile(`Edg(e|A|iOS)?/`)},
+		{"Opera", regexp.MustCompile(`(OPR|Opera)/`)},
+		{"Samsung Internet", regexp.MustCompile(`SamsungBrowser/`)},
+		{"Firefox", regexp.MustCompile(`(Firefox|FxiOS)/`)},
+		{"Chrome", regexp.MustCompile(`(Chrome|CriOS)/`)},
+		{"Internet Explorer", regexp.MustCompile(`(MSIE |Trident/)`)},
+		{"Safari", regexp.MustCompile(`Safari/`)},
+	}
+	uaOSRules = []uaRule{
+		{"Windows", regexp.MustCompile(`Windows`)},
+		{"iOS", regexp.MustCompile(`(iPhone|iPad|iPod)`)},
+		{"Android", regexp.MustCompile(`Android`)},
+		{"macOS", regexp.MustCompile(`Mac OS X|Macintosh`)},
+		{"Chrome OS", regexp.MustCompile(`CrOS`)},
+		{"Linux", regexp.MustCompile(`Linux`)},
+	}
+	uaBotPattern    = regexp.MustCompile(`(?i)(bot|crawler|spider|curl|wget|python-requests|java/)`)
+	uaMobilePattern = regexp.MustCompile(`(Mobile|iPhone|iPod|Windows Phone)`)
+)
+
+// ParseUserAgent extracts the browser, the operating system and the class of device from a user agent.
+// Unrecognized values are reported as unknown.
+func ParseUserAgent(userAgent string) UserAgentInfo {
+	var res = UserAgentInfo{
+		Browser:     UserAgentUnknown,
+		OS:          UserAgentUnknown,
+		DeviceClass: UserAgentUnknown,
+	}
+	if strings.TrimSpace(userAgent) == "" {
+		return res
+	}
+
+	res.Browser = matchUARule(uaBrowserRules, userAgent)
+	res.OS = matchUARule(uaOSRules, userAgent)
+
+	switch {
+	case uaBotPattern.MatchString(userAgent):
+		res.DeviceClass = DeviceClassBot
+	case strings.Contains(userAgent, "iPad") || strings.Contains(userAgent, "Tablet"):
+		res.DeviceClass = DeviceClassTablet
+	case uaMobilePattern.MatchString(userAgent):
+		res.DeviceClass = DeviceClassMobile
+	case res.OS == "Android":
+		// Android devices which don't advertise Mobile are tablets
+		res.DeviceClass = DeviceClassTablet
+	case res.OS != UserAgentUnknown:
+		res.DeviceClass = DeviceClassDesktop
+	}
+
+	return res
+}
+
+func matchUARule(rules []uaRule, userAgent string) string {
+	for _, rule := range rules {
+		if rule.pattern.MatchString(userAgent) {
+			return rule.name
+		}
+	}
+	return UserAgentUnknown
+}
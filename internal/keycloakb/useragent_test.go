@@ -0,0 +1,42 @@
+package keycloakb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseUserAgent(t *testing.T) {
+	var testCases = []struct {
+		name      string
+		userAgent string
+		expected  UserAgentInfo
+	}{
+		{"Empty", "", UserAgentInfo{Browser: UserAgentUnknown, OS: UserAgentUnknown, DeviceClass: UserAgentUnknown}},
+		{"Chrome on Windows", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/83.0.4103.61 Safari/537.36",
+			UserAgentInfo{Browser: "Chrome", OS: "Windows", DeviceClass: DeviceClassDesktop}},
+		{"Edge on Windows", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/83.0.4103.61 Safari/537.36 Edg/83.0.478.37",
+			UserAgentInfo{Browser: "Edge", OS: "Windows", DeviceClass: DeviceClassDesktop}},
+		{"Firefox on Linux", "Mozilla/5.0 (X11; Linux x86_64; rv:76.0) Gecko/20100101 Firefox/76.0",
+			UserAgentInfo{Browser: "Firefox", OS: "Linux", DeviceClass: DeviceClassDesktop}},
+		{"Safari on macOS", "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_4) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/13.1 Safari/605.1.15",
+			UserAgentInfo{Browser: "Safari", OS: "macOS", DeviceClass: DeviceClassDesktop}},
+		{"Safari on iPhone", "Mozilla/5.0 (iPhone; CPU iPhone OS 13_5 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/13.1.1 Mobile/15E148 Safari/604.1",
+			UserAgentInfo{Browser: "Safari", OS: "iOS", DeviceClass: DeviceClassMobile}},
+		{"Safari on iPad", "Mozilla/5.0 (iPad; CPU OS 13_5 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/13.1.1 Mobile/15E148 Safari/604.1",
+			UserAgentInfo{Browser: "Safari", OS: "iOS", DeviceClass: DeviceClassTablet}},
+		{"Chrome on Android phone", "Mozilla/5.0 (Linux; Android 10; SM-G973F) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/83.0.4103.101 Mobile Safari/537.36",
+			UserAgentInfo{Browser: "Chrome", OS: "Android", DeviceClass: DeviceClassMobile}},
+		{"Chrome on Android tablet", "Mozilla/5.0 (Linux; Android 9; SM-T820) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/83.0.4103.101 Safari/537.36",
+			UserAgentInfo{Browser: "Chrome", OS: "Android", DeviceClass: DeviceClassTablet}},
+		{"Bot", "Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)",
+			UserAgentInfo{Browser: UserAgentUnknown, OS: UserAgentUnknown, DeviceClass: DeviceClassBot}},
+		{"curl", "curl/7.64.1", UserAgentInfo{Browser: UserAgentUnknown, OS: UserAgentUnknown, DeviceClass: DeviceClassBot}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, ParseUserAgent(tc.userAgent))
+		})
+	}
+}
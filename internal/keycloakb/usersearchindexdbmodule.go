@@ -0,0 +1,92 @@
+package keycloakb
+
+import (
+	"context"
+	"strings"
+
+	"github.com/cloudtrust/common-service/database/sqltypes"
+	"github.com/cloudtrust/common-service/log"
+	"github.com/cloudtrust/keycloak-bridge/internal/dto"
+)
+
+const (
+	updateUserSearchIndexStmt = `INSERT INTO user_search_index (realm_id, user_id, phone_number_hash, birth_date_hash, id_document_number_hash)
+	  VALUES (?, ?, ?, ?, ?)
+	  ON DUPLICATE KEY UPDATE phone_number_hash=?, birth_date_hash=?, id_document_number_hash=?;`
+	deleteUserSearchIndexStmt = `DELETE FROM user_search_index WHERE realm_id=? AND user_id=?;`
+	selectUserSearchIndexStmt = `
+	  SELECT user_id
+	  FROM user_search_index
+	  WHERE realm_id=?`
+)
+
+// UsersSearchIndexDBModule interface
+type UsersSearchIndexDBModule interface {
+	StoreOrUpdateUserSearchIndex(ctx context.Context, realm string, index dto.DBUserSearchIndex) error
+	DeleteUserSearchIndex(ctx context.Context, realm string, userID string) error
+	SearchUserIDs(ctx context.Context, realm string, criteria dto.DBUserSearchIndex, max int) ([]string, error)
+}
+
+type usersSearchIndexDBModule struct {
+	db     sqltypes.CloudtrustDB
+	logger log.Logger
+}
+
+// NewUsersSearchIndexDBModule returns a users search index module
+func NewUsersSearchIndexDBModule(db sqltypes.CloudtrustDB, logger log.Logger) UsersSearchIndexDBModule {
+	return &usersSearchIndexDBModule{
+		db:     db,
+		logger: logger,
+	}
+}
+
+func (c *usersSearchIndexDBModule) StoreOrUpdateUserSearchIndex(ctx context.Context, realm string, index dto.DBUserSearchIndex) error {
+	var _, err = c.db.Exec(updateUserSearchIndexStmt, realm, index.UserID, index.PhoneNumberHash, index.BirthDateHash, index.IDDocumentNumberHash,
+		index.PhoneNumberHash, index.BirthDateHash, index.IDDocumentNumberHash)
+	if err != nil {
+		c.logger.Warn(ctx, "msg", "Can't update user search index", "error", err.Error(), "realmID", realm, "userID", index.UserID)
+	}
+	return err
+}
+
+func (c *usersSearchIndexDBModule) DeleteUserSearchIndex(ctx context.Context, realm string, userID string) error {
+	var _, err = c.db.Exec(deleteUserSearchIndexStmt, realm, userID)
+	if err != nil {
+		c.logger.Warn(ctx, "msg", "Can't delete user search index", "error", err.Error(), "realmID", realm, "userID", userID)
+	}
+	return err
+}
+
+// SearchUserIDs returns the identifiers of the first max users matching all the given hashes, ordered by user ID
+func (c *usersSearchIndexDBModule) SearchUserIDs(ctx context.Context, realm string, criteria dto.DBUserSearchIndex, max int) ([]string, error) {
+	var query strings.Builder
+	var args = []interface{}{realm}
+	query.WriteString(selectUserSearchIndexStmt)
+	var columns = []string{"phone_number_hash", "birth_date_hash", "id_document_number_hash"}
+	for i, hash := range []*string{criteria.PhoneNumberHash, criteria.BirthDateHash, criteria.IDDocumentNumberHash} {
+		if hash != nil {
+			query.WriteString(" AND " + columns[i] + "=?")
+			args = append(args, *hash)
+		}
+	}
+	query.WriteString(" ORDER BY user_id LIMIT ?;")
+	args = append(args, max)
+
+	var rows, err = c.db.Query(query.String(), args...)
+	if err != nil {
+		c.logger.Warn(ctx, "msg", "Can't search users index", "error", err.Error(), "realmID", realm)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res = make([]string, 0)
+	for rows.Next() {
+		var userID string
+		if err = rows.Scan(&userID); err != nil {
+			c.logger.Warn(ctx, "msg", "Can't search users index. Scan failed", "error", err.Error(), "realmID", realm)
+			return nil, err
+		}
+		res = append(res, userID)
+	}
+	return res, rows.Err()
+}
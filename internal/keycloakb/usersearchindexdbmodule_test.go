@@ -0,0 +1,66 @@
+package keycloakb
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/cloudtrust/common-service/log"
+	"github.com/cloudtrust/keycloak-bridge/internal/dto"
+	"github.com/cloudtrust/keycloak-bridge/internal/keycloakb/mock"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUsersSearchIndexDBModule(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+	var mockDB = mock.NewCloudtrustDB(mockCtrl)
+	var mockSQLRows = mock.NewSQLRows(mockCtrl)
+
+	var module = NewUsersSearchIndexDBModule(mockDB, log.NewNopLogger())
+	var ctx = context.TODO()
+	var realm = "my-realm"
+	var userID = "user-id"
+	var phoneHash = "phone-hash"
+	var docHash = "doc-hash"
+	var expectedError = errors.New("db error")
+
+	t.Run("Store index", func(t *testing.T) {
+		var index = dto.DBUserSearchIndex{UserID: userID, PhoneNumberHash: &phoneHash}
+		mockDB.EXPECT().Exec(updateUserSearchIndexStmt, realm, userID, &phoneHash, gomock.Nil(), gomock.Nil(), &phoneHash, gomock.Nil(), gomock.Nil()).Return(nil, expectedError)
+		assert.Equal(t, expectedError, module.StoreOrUpdateUserSearchIndex(ctx, realm, index))
+
+		mockDB.EXPECT().Exec(updateUserSearchIndexStmt, realm, userID, &phoneHash, gomock.Nil(), gomock.Nil(), &phoneHash, gomock.Nil(), gomock.Nil()).Return(nil, nil)
+		assert.Nil(t, module.StoreOrUpdateUserSearchIndex(ctx, realm, index))
+	})
+
+	t.Run("Delete index", func(t *testing.T) {
+		mockDB.EXPECT().Exec(deleteUserSearchIndexStmt, realm, userID).Return(nil, nil)
+		assert.Nil(t, module.DeleteUserSearchIndex(ctx, realm, userID))
+	})
+
+	t.Run("Search fails", func(t *testing.T) {
+		mockDB.EXPECT().Query(gomock.Any(), realm, phoneHash, 10).Return(nil, expectedError)
+		var _, err = module.SearchUserIDs(ctx, realm, dto.DBUserSearchIndex{PhoneNumberHash: &phoneHash}, 10)
+		assert.Equal(t, expectedError, err)
+	})
+
+	t.Run("Search with several hashes", func(t *testing.T) {
+		var query = selectUserSearchIndexStmt + " AND phone_number_hash=? AND id_document_number_hash=? ORDER BY user_id LIMIT ?;"
+		gomock.InOrder(
+			mockDB.EXPECT().Query(query, realm, phoneHash, docHash, 10).Return(mockSQLRows, nil),
+			mockSQLRows.EXPECT().Next().Return(true),
+			mockSQLRows.EXPECT().Scan(gomock.Any()).DoAndReturn(func(id *string) error {
+				*id = userID
+				return nil
+			}),
+			mockSQLRows.EXPECT().Next().Return(false),
+			mockSQLRows.EXPECT().Err().Return(nil),
+			mockSQLRows.EXPECT().Close(),
+		)
+		var userIDs, err = module.SearchUserIDs(ctx, realm, dto.DBUserSearchIndex{PhoneNumberHash: &phoneHash, IDDocumentNumberHash: &docHash}, 10)
+		assert.Nil(t, err)
+		assert.Equal(t, []string{userID}, userIDs)
+	})
+}
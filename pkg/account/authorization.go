@@ -26,6 +26,10 @@ const (
 	UpdateAccount             = "UpdateAccount"
 	DeleteAccount             = "DeleteAccount"
 	GetConfiguration          = "GetConfiguration"
+	GetSessions               = "GetSessions"
+	DeleteSession             = "DeleteSession"
+	DeleteSessions            = "DeleteSessions"
+	ValidatePassword          = "ValidatePassword"
 
 	infosAction       = "Action"
 	infosCurrentRealm = "currentRealm"
@@ -201,3 +205,23 @@ func (c *authorizationComponentMW) SendVerifyPhoneNumber(ctx context.Context) er
 func isEnabled(booleanPtr *bool) bool {
 	return booleanPtr != nil && *booleanPtr
 }
+
+func (c *authorizationComponentMW) GetSessions(ctx context.Context) ([]api.SessionRepresentation, error) {
+	// No restriction for this call
+	return c.next.GetSessions(ctx)
+}
+
+func (c *authorizationComponentMW) DeleteSession(ctx context.Context, sessionID string) error {
+	// No restriction for this call
+	return c.next.DeleteSession(ctx, sessionID)
+}
+
+func (c *authorizationComponentMW) DeleteSessions(ctx context.Context) error {
+	// No restriction for this call
+	return c.next.DeleteSessions(ctx)
+}
+
+func (c *authorizationComponentMW) ValidatePassword(ctx context.Context, password string) (api.PasswordValidationRepresentation, error) {
+	// No restriction for this call
+	return c.next.ValidatePassword(ctx, password)
+}
@@ -84,6 +84,30 @@ func TestNoRestrictions(t *testing.T) {
 			err = authorizationMW.SendVerifyPhoneNumber(ctx)
 			assert.Nil(t, err)
 		})
+
+		t.Run("GetSessions", func(t *testing.T) {
+			mockAccountComponent.EXPECT().GetSessions(ctx).Return([]api.SessionRepresentation{}, nil).Times(1)
+			_, err = authorizationMW.GetSessions(ctx)
+			assert.Nil(t, err)
+		})
+
+		t.Run("DeleteSession", func(t *testing.T) {
+			mockAccountComponent.EXPECT().DeleteSession(ctx, "session-id").Return(nil).Times(1)
+			err = authorizationMW.DeleteSession(ctx, "session-id")
+			assert.Nil(t, err)
+		})
+
+		t.Run("DeleteSessions", func(t *testing.T) {
+			mockAccountComponent.EXPECT().DeleteSessions(ctx).Return(nil).Times(1)
+			err = authorizationMW.DeleteSessions(ctx)
+			assert.Nil(t, err)
+		})
+
+		t.Run("ValidatePassword", func(t *testing.T) {
+			mockAccountComponent.EXPECT().ValidatePassword(ctx, "password").Return(api.PasswordValidationRepresentation{Valid: true}, nil).Times(1)
+			_, err = authorizationMW.ValidatePassword(ctx, "password")
+			assert.Nil(t, err)
+		})
 	}
 }
 
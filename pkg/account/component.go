@@ -46,6 +46,9 @@ type KeycloakAccountClient interface {
 	DeleteAccount(accessToken, realm string) error
 	ExecuteActionsEmail(accessToken string, realmName string, actions []string) error
 	SendEmail(accessToken, realmName, template, subject string, recipient *string, attributes map[string]string) error
+	GetSessions(accessToken, realmName string) ([]kc.SessionRepresentation, error)
+	DeleteSession(accessToken, realmName, sessionID string) error
+	DeleteSessions(accessToken, realmName string) error
 }
 
 // Component interface exposes methods used by the bridge API
@@ -62,6 +65,10 @@ type Component interface {
 	GetConfiguration(context.Context, string) (api.Configuration, error)
 	SendVerifyEmail(ctx context.Context) error
 	SendVerifyPhoneNumber(ctx context.Context) error
+	GetSessions(ctx context.Context) ([]api.SessionRepresentation, error)
+	DeleteSession(ctx context.Context, sessionID string) error
+	DeleteSessions(ctx context.Context) error
+	ValidatePassword(ctx context.Context, password string) (api.PasswordValidationRepresentation, error)
 }
 
 // UsersDetailsDBModule is the minimum required interface to access the users database
@@ -76,16 +83,18 @@ type component struct {
 	eventDBModule         database.EventsDBModule
 	configDBModule        keycloakb.ConfigurationDBModule
 	usersDBModule         UsersDetailsDBModule
+	passwordValidator     keycloakb.PasswordValidator
 	logger                internal.Logger
 }
 
 // NewComponent returns the self-service component.
-func NewComponent(keycloakAccountClient KeycloakAccountClient, eventDBModule database.EventsDBModule, configDBModule keycloakb.ConfigurationDBModule, usersDBModule UsersDetailsDBModule, logger internal.Logger) Component {
+func NewComponent(keycloakAccountClient KeycloakAccountClient, eventDBModule database.EventsDBModule, configDBModule keycloakb.ConfigurationDBModule, usersDBModule UsersDetailsDBModule, passwordValidator keycloakb.PasswordValidator, logger internal.Logger) Component {
 	return &component{
 		keycloakAccountClient: keycloakAccountClient,
 		eventDBModule:         eventDBModule,
 		configDBModule:        configDBModule,
 		usersDBModule:         usersDBModule,
+		passwordValidator:     passwordValidator,
 		logger:                logger,
 	}
 }
@@ -506,3 +515,84 @@ func (c *component) executeActions(ctx context.Context, actions []string) error
 
 	return err
 }
+
+func (c *component) GetSessions(ctx context.Context) ([]api.SessionRepresentation, error) {
+	var accessToken = ctx.Value(cs.CtContextAccessToken).(string)
+	var currentRealm = ctx.Value(cs.CtContextRealm).(string)
+
+	sessionsKc, err := c.keycloakAccountClient.GetSessions(accessToken, currentRealm)
+	if err != nil {
+		c.logger.Warn(ctx, "err", err.Error())
+		return nil, err
+	}
+
+	var sessionsRep = []api.SessionRepresentation{}
+	for _, sessionKc := range sessionsKc {
+		sessionsRep = append(sessionsRep, api.ConvertToAPISession(sessionKc))
+	}
+
+	return sessionsRep, nil
+}
+
+func (c *component) DeleteSession(ctx context.Context, sessionID string) error {
+	var accessToken = ctx.Value(cs.CtContextAccessToken).(string)
+	var currentRealm = ctx.Value(cs.CtContextRealm).(string)
+	var userID = ctx.Value(cs.CtContextUserID).(string)
+	var username = ctx.Value(cs.CtContextUsername).(string)
+
+	err := c.keycloakAccountClient.DeleteSession(accessToken, currentRealm, sessionID)
+	if err != nil {
+		c.logger.Warn(ctx, "err", err.Error())
+		return err
+	}
+
+	additionalInfos, _ := json.Marshal(map[string]string{PrmSessionID: sessionID})
+
+	//store the API call into the DB
+	c.reportEvent(ctx, "SELF_DELETE_SESSION", database.CtEventRealmName, currentRealm, database.CtEventUserID, userID, database.CtEventUsername, username, database.CtEventAdditionalInfo, string(additionalInfos))
+
+	return nil
+}
+
+// DeleteSessions logs the current user out of all the sessions but the current one
+func (c *component) DeleteSessions(ctx context.Context) error {
+	var accessToken = ctx.Value(cs.CtContextAccessToken).(string)
+	var currentRealm = ctx.Value(cs.CtContextRealm).(string)
+	var userID = ctx.Value(cs.CtContextUserID).(string)
+	var username = ctx.Value(cs.CtContextUsername).(string)
+
+	err := c.keycloakAccountClient.DeleteSessions(accessToken, currentRealm)
+	if err != nil {
+		c.logger.Warn(ctx, "err", err.Error())
+		return err
+	}
+
+	//store the API call into the DB
+	c.reportEvent(ctx, "SELF_DELETE_SESSIONS", database.CtEventRealmName, currentRealm, database.CtEventUserID, userID, database.CtEventUsername, username)
+
+	return nil
+}
+
+// ValidatePassword checks a candidate password of the current user against the password policy of the realm. The password
+// is not changed.
+func (c *component) ValidatePassword(ctx context.Context, password string) (api.PasswordValidationRepresentation, error) {
+	var accessToken = ctx.Value(cs.CtContextAccessToken).(string)
+	var currentRealm = ctx.Value(cs.CtContextRealm).(string)
+	var username = ctx.Value(cs.CtContextUsername).(string)
+
+	userKc, err := c.keycloakAccountClient.GetAccount(accessToken, currentRealm)
+	if err != nil {
+		c.logger.Warn(ctx, "err", err.Error())
+		return api.PasswordValidationRepresentation{}, err
+	}
+	var email string
+	if userKc.Email != nil {
+		email = *userKc.Email
+	}
+
+	failures, err := c.passwordValidator.ValidatePassword(ctx, currentRealm, password, username, email)
+	if err != nil {
+		return api.PasswordValidationRepresentation{}, err
+	}
+	return api.ConvertToAPIPasswordValidation(failures), nil
+}
@@ -21,6 +21,7 @@ import (
 
 	"github.com/cloudtrust/keycloak-bridge/internal/constants"
 	"github.com/cloudtrust/keycloak-bridge/internal/dto"
+	"github.com/cloudtrust/keycloak-bridge/internal/keycloakb"
 )
 
 func TestUpdatePassword(t *testing.T) {
@@ -32,7 +33,7 @@ func TestUpdatePassword(t *testing.T) {
 	mockConfigurationDBModule := mock.NewConfigurationDBModule(mockCtrl)
 	mockUsersDetailsDBModule := mock.NewUsersDetailsDBModule(mockCtrl)
 	mockLogger := log.NewNopLogger()
-	component := NewComponent(mockKeycloakAccountClient, mockEventDBModule, mockConfigurationDBModule, mockUsersDetailsDBModule, mockLogger)
+	component := NewComponent(mockKeycloakAccountClient, mockEventDBModule, mockConfigurationDBModule, mockUsersDetailsDBModule, nil, mockLogger)
 
 	accessToken := "access token"
 	realm := "sample realm"
@@ -85,7 +86,7 @@ func TestUpdatePasswordWrongPwd(t *testing.T) {
 	mockEventDBModule := mock.NewEventsDBModule(mockCtrl)
 	mockUsersDetailsDBModule := mock.NewUsersDetailsDBModule(mockCtrl)
 	mockConfigurationDBModule := mock.NewConfigurationDBModule(mockCtrl)
-	component := NewComponent(mockKeycloakAccountClient, mockEventDBModule, mockConfigurationDBModule, mockUsersDetailsDBModule, log.NewNopLogger())
+	component := NewComponent(mockKeycloakAccountClient, mockEventDBModule, mockConfigurationDBModule, mockUsersDetailsDBModule, nil, log.NewNopLogger())
 
 	accessToken := "access token"
 	realm := "sample realm"
@@ -127,7 +128,7 @@ func TestUpdateAccount(t *testing.T) {
 	mockConfigurationDBModule := mock.NewConfigurationDBModule(mockCtrl)
 	mockLogger := log.NewNopLogger()
 
-	var accountComponent = NewComponent(mockKeycloakAccountClient, mockEventDBModule, mockConfigurationDBModule, mockUsersDetailsDBModule, mockLogger)
+	var accountComponent = NewComponent(mockKeycloakAccountClient, mockEventDBModule, mockConfigurationDBModule, mockUsersDetailsDBModule, nil, mockLogger)
 
 	accessToken := "access token"
 	realmName := "master"
@@ -399,7 +400,7 @@ func TestGetUser(t *testing.T) {
 	mockUsersDetailsDBModule := mock.NewUsersDetailsDBModule(mockCtrl)
 	mockLogger := log.NewNopLogger()
 
-	var accountComponent = NewComponent(mockKeycloakAccountClient, mockEventDBModule, mockConfigurationDBModule, mockUsersDetailsDBModule, mockLogger)
+	var accountComponent = NewComponent(mockKeycloakAccountClient, mockEventDBModule, mockConfigurationDBModule, mockUsersDetailsDBModule, nil, mockLogger)
 
 	var accessToken = "TOKEN=="
 	var realmName = "master"
@@ -522,7 +523,7 @@ func TestDeleteUser(t *testing.T) {
 	mockConfigurationDBModule := mock.NewConfigurationDBModule(mockCtrl)
 	mockLogger := log.NewNopLogger()
 
-	var accountComponent = NewComponent(mockKeycloakAccountClient, mockEventDBModule, mockConfigurationDBModule, mockUsersDetailsDBModule, mockLogger)
+	var accountComponent = NewComponent(mockKeycloakAccountClient, mockEventDBModule, mockConfigurationDBModule, mockUsersDetailsDBModule, nil, mockLogger)
 
 	var accessToken = "TOKEN=="
 	var realmName = "master"
@@ -559,7 +560,7 @@ func TestGetCredentials(t *testing.T) {
 	mockUsersDetailsDBModule := mock.NewUsersDetailsDBModule(mockCtrl)
 	mockLogger := log.NewNopLogger()
 
-	component := NewComponent(mockKeycloakAccountClient, mockEventDBModule, mockConfigurationDBModule, mockUsersDetailsDBModule, mockLogger)
+	component := NewComponent(mockKeycloakAccountClient, mockEventDBModule, mockConfigurationDBModule, mockUsersDetailsDBModule, nil, mockLogger)
 
 	var accessToken = "TOKEN=="
 	var currentRealm = "master"
@@ -619,7 +620,7 @@ func TestGetCredentialRegistrators(t *testing.T) {
 	mockUsersDetailsDBModule := mock.NewUsersDetailsDBModule(mockCtrl)
 	mockLogger := log.NewNopLogger()
 
-	component := NewComponent(mockKeycloakAccountClient, mockEventDBModule, mockConfigurationDBModule, mockUsersDetailsDBModule, mockLogger)
+	component := NewComponent(mockKeycloakAccountClient, mockEventDBModule, mockConfigurationDBModule, mockUsersDetailsDBModule, nil, mockLogger)
 
 	var accessToken = "TOKEN=="
 	var currentRealm = "master"
@@ -666,7 +667,7 @@ func TestUpdateLabelCredential(t *testing.T) {
 	mockUsersDetailsDBModule := mock.NewUsersDetailsDBModule(mockCtrl)
 	mockLogger := log.NewNopLogger()
 
-	component := NewComponent(mockKeycloakAccountClient, mockEventDBModule, mockConfigurationDBModule, mockUsersDetailsDBModule, mockLogger)
+	component := NewComponent(mockKeycloakAccountClient, mockEventDBModule, mockConfigurationDBModule, mockUsersDetailsDBModule, nil, mockLogger)
 
 	accessToken := "access token"
 	realm := "sample realm"
@@ -708,7 +709,7 @@ func TestDeleteCredential(t *testing.T) {
 	mockUsersDetailsDBModule := mock.NewUsersDetailsDBModule(mockCtrl)
 	mockLogger := log.NewNopLogger()
 
-	component := NewComponent(mockKeycloakAccountClient, mockEventDBModule, mockConfigurationDBModule, mockUsersDetailsDBModule, mockLogger)
+	component := NewComponent(mockKeycloakAccountClient, mockEventDBModule, mockConfigurationDBModule, mockUsersDetailsDBModule, nil, mockLogger)
 
 	accessToken := "access token"
 	realm := "sample realm"
@@ -748,7 +749,7 @@ func TestMoveCredential(t *testing.T) {
 	mockUsersDetailsDBModule := mock.NewUsersDetailsDBModule(mockCtrl)
 	mockLogger := log.NewNopLogger()
 
-	component := NewComponent(mockKeycloakAccountClient, mockEventDBModule, mockConfigurationDBModule, mockUsersDetailsDBModule, mockLogger)
+	component := NewComponent(mockKeycloakAccountClient, mockEventDBModule, mockConfigurationDBModule, mockUsersDetailsDBModule, nil, mockLogger)
 
 	accessToken := "access token"
 	realm := "sample realm"
@@ -805,7 +806,7 @@ func TestGetConfiguration(t *testing.T) {
 	mockUsersDetailsDBModule := mock.NewUsersDetailsDBModule(mockCtrl)
 	mockLogger := log.NewNopLogger()
 
-	component := NewComponent(mockKeycloakAccountClient, mockEventDBModule, mockConfigurationDBModule, mockUsersDetailsDBModule, mockLogger)
+	component := NewComponent(mockKeycloakAccountClient, mockEventDBModule, mockConfigurationDBModule, mockUsersDetailsDBModule, nil, mockLogger)
 
 	var accessToken = "TOKEN=="
 	var currentRealm = "master"
@@ -903,7 +904,7 @@ func TestSendVerify(t *testing.T) {
 		mockUsersDetailsDBModule  = mock.NewUsersDetailsDBModule(mockCtrl)
 		mockLogger                = log.NewNopLogger()
 
-		component     = NewComponent(mockKeycloakAccountClient, mockEventDBModule, mockConfigurationDBModule, mockUsersDetailsDBModule, mockLogger)
+		component     = NewComponent(mockKeycloakAccountClient, mockEventDBModule, mockConfigurationDBModule, mockUsersDetailsDBModule, nil, mockLogger)
 		accessToken   = "TOKEN=="
 		currentRealm  = "master"
 		currentUserID = "1234-789"
@@ -948,3 +949,119 @@ func TestSendVerify(t *testing.T) {
 		assert.Nil(t, component.SendVerifyPhoneNumber(ctx))
 	})
 }
+
+func TestSessions(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var (
+		mockKeycloakAccountClient = mock.NewKeycloakAccountClient(mockCtrl)
+		mockEventDBModule         = mock.NewEventsDBModule(mockCtrl)
+		mockConfigurationDBModule = mock.NewConfigurationDBModule(mockCtrl)
+		mockUsersDetailsDBModule  = mock.NewUsersDetailsDBModule(mockCtrl)
+		mockLogger                = log.NewNopLogger()
+
+		component       = NewComponent(mockKeycloakAccountClient, mockEventDBModule, mockConfigurationDBModule, mockUsersDetailsDBModule, nil, mockLogger)
+		accessToken     = "TOKEN=="
+		currentRealm    = "master"
+		currentUserID   = "1234-789"
+		currentUsername = "username"
+		sessionID       = "f467ed7c-0a1d-4eee-9bb8-669c6f89c0ee"
+		expected        = errors.New("kc fails")
+		ctx             = context.TODO()
+	)
+
+	ctx = context.WithValue(ctx, cs.CtContextAccessToken, accessToken)
+	ctx = context.WithValue(ctx, cs.CtContextRealm, currentRealm)
+	ctx = context.WithValue(ctx, cs.CtContextUserID, currentUserID)
+	ctx = context.WithValue(ctx, cs.CtContextUsername, currentUsername)
+
+	t.Run("GetSessions - fails", func(t *testing.T) {
+		mockKeycloakAccountClient.EXPECT().GetSessions(accessToken, currentRealm).Return(nil, expected)
+		var _, err = component.GetSessions(ctx)
+		assert.Equal(t, expected, err)
+	})
+	t.Run("GetSessions - success", func(t *testing.T) {
+		mockKeycloakAccountClient.EXPECT().GetSessions(accessToken, currentRealm).Return([]kc.SessionRepresentation{{ID: &sessionID}}, nil)
+		var sessions, err = component.GetSessions(ctx)
+		assert.Nil(t, err)
+		assert.Len(t, sessions, 1)
+		assert.Equal(t, sessionID, *sessions[0].ID)
+	})
+
+	t.Run("DeleteSession - fails", func(t *testing.T) {
+		mockKeycloakAccountClient.EXPECT().DeleteSession(accessToken, currentRealm, sessionID).Return(expected)
+		assert.Equal(t, expected, component.DeleteSession(ctx, sessionID))
+	})
+	t.Run("DeleteSession - success", func(t *testing.T) {
+		mockKeycloakAccountClient.EXPECT().DeleteSession(accessToken, currentRealm, sessionID).Return(nil)
+		mockEventDBModule.EXPECT().ReportEvent(ctx, "SELF_DELETE_SESSION", "self-service", database.CtEventRealmName, currentRealm,
+			database.CtEventUserID, currentUserID, database.CtEventUsername, currentUsername, database.CtEventAdditionalInfo, gomock.Any())
+		assert.Nil(t, component.DeleteSession(ctx, sessionID))
+	})
+
+	t.Run("DeleteSessions - fails", func(t *testing.T) {
+		mockKeycloakAccountClient.EXPECT().DeleteSessions(accessToken, currentRealm).Return(expected)
+		assert.Equal(t, expected, component.DeleteSessions(ctx))
+	})
+	t.Run("DeleteSessions - success", func(t *testing.T) {
+		mockKeycloakAccountClient.EXPECT().DeleteSessions(accessToken, currentRealm).Return(nil)
+		mockEventDBModule.EXPECT().ReportEvent(ctx, "SELF_DELETE_SESSIONS", "self-service", database.CtEventRealmName, currentRealm,
+			database.CtEventUserID, currentUserID, database.CtEventUsername, currentUsername)
+		assert.Nil(t, component.DeleteSessions(ctx))
+	})
+}
+
+func TestValidatePassword(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var (
+		mockKeycloakAccountClient = mock.NewKeycloakAccountClient(mockCtrl)
+		mockEventDBModule         = mock.NewEventsDBModule(mockCtrl)
+		mockConfigurationDBModule = mock.NewConfigurationDBModule(mockCtrl)
+		mockUsersDetailsDBModule  = mock.NewUsersDetailsDBModule(mockCtrl)
+		mockPasswordValidator     = mock.NewPasswordValidator(mockCtrl)
+
+		component       = NewComponent(mockKeycloakAccountClient, mockEventDBModule, mockConfigurationDBModule, mockUsersDetailsDBModule, mockPasswordValidator, log.NewNopLogger())
+		accessToken     = "TOKEN=="
+		currentRealm    = "master"
+		currentUsername = "username"
+		email           = "user@example.com"
+		password        = "password"
+		expected        = errors.New("kc fails")
+		ctx             = context.TODO()
+	)
+
+	ctx = context.WithValue(ctx, cs.CtContextAccessToken, accessToken)
+	ctx = context.WithValue(ctx, cs.CtContextRealm, currentRealm)
+	ctx = context.WithValue(ctx, cs.CtContextUsername, currentUsername)
+
+	t.Run("GetAccount fails", func(t *testing.T) {
+		mockKeycloakAccountClient.EXPECT().GetAccount(accessToken, currentRealm).Return(kc.UserRepresentation{}, expected)
+		var _, err = component.ValidatePassword(ctx, password)
+		assert.Equal(t, expected, err)
+	})
+	t.Run("Validation fails", func(t *testing.T) {
+		mockKeycloakAccountClient.EXPECT().GetAccount(accessToken, currentRealm).Return(kc.UserRepresentation{Email: &email}, nil)
+		mockPasswordValidator.EXPECT().ValidatePassword(ctx, currentRealm, password, currentUsername, email).Return(nil, expected)
+		var _, err = component.ValidatePassword(ctx, password)
+		assert.Equal(t, expected, err)
+	})
+	t.Run("Invalid password", func(t *testing.T) {
+		mockKeycloakAccountClient.EXPECT().GetAccount(accessToken, currentRealm).Return(kc.UserRepresentation{}, nil)
+		mockPasswordValidator.EXPECT().ValidatePassword(ctx, currentRealm, password, currentUsername, "").
+			Return([]keycloakb.PasswordPolicyFailure{{Rule: keycloakb.PolicyLength, Value: "12"}}, nil)
+		var res, err = component.ValidatePassword(ctx, password)
+		assert.Nil(t, err)
+		assert.False(t, res.Valid)
+		assert.Equal(t, keycloakb.PolicyLength, res.Failures[0].Rule)
+	})
+	t.Run("Valid password", func(t *testing.T) {
+		mockKeycloakAccountClient.EXPECT().GetAccount(accessToken, currentRealm).Return(kc.UserRepresentation{Email: &email}, nil)
+		mockPasswordValidator.EXPECT().ValidatePassword(ctx, currentRealm, password, currentUsername, email).Return([]keycloakb.PasswordPolicyFailure{}, nil)
+		var res, err = component.ValidatePassword(ctx, password)
+		assert.Nil(t, err)
+		assert.True(t, res.Valid)
+	})
+}
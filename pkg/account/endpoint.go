@@ -25,6 +25,10 @@ type Endpoints struct {
 	GetConfiguration          endpoint.Endpoint
 	SendVerifyEmail           endpoint.Endpoint
 	SendVerifyPhoneNumber     endpoint.Endpoint
+	GetSessions               endpoint.Endpoint
+	DeleteSession             endpoint.Endpoint
+	DeleteSessions            endpoint.Endpoint
+	ValidatePassword          endpoint.Endpoint
 }
 
 // UpdatePasswordBody is the definition of the expected body content of UpdatePassword method
@@ -164,3 +168,45 @@ func MakeSendVerifyPhoneNumberEndpoint(component Component) cs.Endpoint {
 		return nil, component.SendVerifyPhoneNumber(ctx)
 	}
 }
+
+// MakeGetSessionsEndpoint makes the GetSessions endpoint to list the sessions of the current user.
+func MakeGetSessionsEndpoint(component Component) cs.Endpoint {
+	return func(ctx context.Context, _ interface{}) (interface{}, error) {
+		return component.GetSessions(ctx)
+	}
+}
+
+// MakeDeleteSessionEndpoint makes the DeleteSession endpoint to end a session of the current user.
+func MakeDeleteSessionEndpoint(component Component) cs.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		var m = req.(map[string]string)
+
+		return nil, component.DeleteSession(ctx, m[PrmSessionID])
+	}
+}
+
+// MakeDeleteSessionsEndpoint makes the DeleteSessions endpoint to end the other sessions of the current user.
+func MakeDeleteSessionsEndpoint(component Component) cs.Endpoint {
+	return func(ctx context.Context, _ interface{}) (interface{}, error) {
+		return nil, component.DeleteSessions(ctx)
+	}
+}
+
+// MakeValidatePasswordEndpoint makes the ValidatePassword endpoint to check a password against the policy of the realm.
+func MakeValidatePasswordEndpoint(component Component) cs.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		var m = req.(map[string]string)
+		var body api.PasswordValidationBody
+
+		err := json.Unmarshal([]byte(m[ReqBody]), &body)
+		if err != nil {
+			return nil, errrorhandler.CreateBadRequestError(msg.MsgErrInvalidParam + "." + msg.Body)
+		}
+
+		if err = body.Validate(); err != nil {
+			return nil, err
+		}
+
+		return component.ValidatePassword(ctx, body.Password)
+	}
+}
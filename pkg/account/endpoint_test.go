@@ -186,4 +186,37 @@ func TestSimpleEndpoints(t *testing.T) {
 		_, err := MakeSendVerifyPhoneNumberEndpoint(mockAccountComponent)(context.Background(), m)
 		assert.Nil(t, err)
 	})
+
+	t.Run("MakeGetSessionsEndpoint", func(t *testing.T) {
+		mockAccountComponent.EXPECT().GetSessions(gomock.Any()).Return([]account_api.SessionRepresentation{}, nil).Times(1)
+		_, err := MakeGetSessionsEndpoint(mockAccountComponent)(context.Background(), m)
+		assert.Nil(t, err)
+	})
+
+	t.Run("MakeDeleteSessionEndpoint", func(t *testing.T) {
+		mockAccountComponent.EXPECT().DeleteSession(gomock.Any(), "session-id").Return(nil).Times(1)
+		_, err := MakeDeleteSessionEndpoint(mockAccountComponent)(context.Background(), map[string]string{PrmSessionID: "session-id"})
+		assert.Nil(t, err)
+	})
+
+	t.Run("MakeDeleteSessionsEndpoint", func(t *testing.T) {
+		mockAccountComponent.EXPECT().DeleteSessions(gomock.Any()).Return(nil).Times(1)
+		_, err := MakeDeleteSessionsEndpoint(mockAccountComponent)(context.Background(), m)
+		assert.Nil(t, err)
+	})
+
+	t.Run("MakeValidatePasswordEndpoint", func(t *testing.T) {
+		var e = MakeValidatePasswordEndpoint(mockAccountComponent)
+
+		_, err := e(context.Background(), map[string]string{ReqBody: "{"})
+		assert.NotNil(t, err)
+
+		_, err = e(context.Background(), map[string]string{ReqBody: `{"password":""}`})
+		assert.NotNil(t, err)
+
+		mockAccountComponent.EXPECT().ValidatePassword(gomock.Any(), "P@ssw0rd").Return(account_api.PasswordValidationRepresentation{Valid: true}, nil).Times(1)
+		res, err := e(context.Background(), map[string]string{ReqBody: `{"password":"P@ssw0rd"}`})
+		assert.Nil(t, err)
+		assert.True(t, res.(account_api.PasswordValidationRepresentation).Valid)
+	})
 }
@@ -17,6 +17,7 @@ const (
 
 	PrmCredentialID     = "credentialID"
 	PrmPrevCredentialID = "previousCredentialID"
+	PrmSessionID        = "sessionID"
 
 	PrmQryRealmID = "realm_id"
 )
@@ -35,6 +36,7 @@ func decodeAccountRequest(ctx context.Context, req *http.Request) (interface{},
 	var pathParams = map[string]string{
 		PrmCredentialID:     account_api.RegExpID,
 		PrmPrevCredentialID: account_api.RegExpIDNullable,
+		PrmSessionID:        account_api.RegExpID,
 	}
 
 	var queryParams = map[string]string{
@@ -5,3 +5,5 @@ package account
 //go:generate mockgen -destination=./mock/eventsdbmodule.go -package=mock -mock_names=EventsDBModule=EventsDBModule github.com/cloudtrust/common-service/database EventsDBModule
 //go:generate mockgen -destination=./mock/component.go -package=mock -mock_names=Component=Component github.com/cloudtrust/keycloak-bridge/pkg/account Component
 //go:generate mockgen -destination=./mock/logger.go -package=mock -mock_names=Logger=Logger github.com/cloudtrust/keycloak-bridge/internal/keycloakb Logger
+//go:generate mockgen -destination=./mock/softdeletion.go -package=mock -mock_names=UserSoftDeletion=UserSoftDeletion github.com/cloudtrust/keycloak-bridge/pkg/account UserSoftDeletion
+//go:generate mockgen -destination=./mock/passwordpolicy.go -package=mock -mock_names=PasswordValidator=PasswordValidator github.com/cloudtrust/keycloak-bridge/internal/keycloakb PasswordValidator
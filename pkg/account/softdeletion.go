@@ -0,0 +1,52 @@
+package account
+
+import (
+	"context"
+
+	cs "github.com/cloudtrust/common-service"
+	"github.com/cloudtrust/common-service/database"
+	internal "github.com/cloudtrust/keycloak-bridge/internal/keycloakb"
+)
+
+// UserSoftDeletion is the interface of the soft deletion of users
+type UserSoftDeletion interface {
+	SoftDeleteUser(ctx context.Context, realmName string, userID string) error
+}
+
+type softDeletionComponentMW struct {
+	Component
+	softDeletion  UserSoftDeletion
+	eventDBModule database.EventsDBModule
+	logger        internal.Logger
+}
+
+// MakeSoftDeletionComponentMW replaces the deletion of the account by a soft deletion: the account is disabled and
+// can be restored by an administrator until its grace period is elapsed
+func MakeSoftDeletionComponentMW(softDeletion UserSoftDeletion, eventDBModule database.EventsDBModule, logger internal.Logger) func(Component) Component {
+	return func(next Component) Component {
+		return &softDeletionComponentMW{
+			Component:     next,
+			softDeletion:  softDeletion,
+			eventDBModule: eventDBModule,
+			logger:        logger,
+		}
+	}
+}
+
+func (m *softDeletionComponentMW) DeleteAccount(ctx context.Context) error {
+	var realm = ctx.Value(cs.CtContextRealm).(string)
+	var userID = ctx.Value(cs.CtContextUserID).(string)
+
+	if err := m.softDeletion.SoftDeleteUser(ctx, realm, userID); err != nil {
+		m.logger.Warn(ctx, "err", err.Error())
+		return err
+	}
+
+	//store the API call into the DB
+	var values = []string{database.CtEventRealmName, realm, database.CtEventUserID, userID}
+	if errEvent := m.eventDBModule.ReportEvent(ctx, "SELF_SOFT_DELETE_ACCOUNT", "self-service", values...); errEvent != nil {
+		internal.LogUnrecordedEvent(ctx, m.logger, "SELF_SOFT_DELETE_ACCOUNT", errEvent.Error(), values...)
+	}
+
+	return nil
+}
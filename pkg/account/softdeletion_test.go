@@ -0,0 +1,47 @@
+package account
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	cs "github.com/cloudtrust/common-service"
+	"github.com/cloudtrust/common-service/database"
+	"github.com/cloudtrust/common-service/log"
+	"github.com/cloudtrust/keycloak-bridge/pkg/account/mock"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSoftDeletionComponentMW(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var mockComponent = mock.NewComponent(mockCtrl)
+	var mockSoftDeletion = mock.NewUserSoftDeletion(mockCtrl)
+	var mockEventDBModule = mock.NewEventsDBModule(mockCtrl)
+	var component = MakeSoftDeletionComponentMW(mockSoftDeletion, mockEventDBModule, log.NewNopLogger())(mockComponent)
+
+	var realm = "master"
+	var userID = "123-456-789"
+	var ctx = context.WithValue(context.Background(), cs.CtContextRealm, realm)
+	ctx = context.WithValue(ctx, cs.CtContextUserID, userID)
+
+	t.Run("Soft deletion fails", func(t *testing.T) {
+		var expectedError = errors.New("kc error")
+		mockSoftDeletion.EXPECT().SoftDeleteUser(ctx, realm, userID).Return(expectedError)
+		assert.Equal(t, expectedError, component.DeleteAccount(ctx))
+	})
+
+	t.Run("Account is soft deleted", func(t *testing.T) {
+		mockSoftDeletion.EXPECT().SoftDeleteUser(ctx, realm, userID).Return(nil)
+		mockEventDBModule.EXPECT().ReportEvent(ctx, "SELF_SOFT_DELETE_ACCOUNT", "self-service", database.CtEventRealmName, realm, database.CtEventUserID, userID).Return(errors.New("db error"))
+		assert.Nil(t, component.DeleteAccount(ctx))
+	})
+
+	t.Run("Other calls are forwarded", func(t *testing.T) {
+		mockComponent.EXPECT().GetCredentials(ctx).Return(nil, nil)
+		var _, err = component.GetCredentials(ctx)
+		assert.Nil(t, err)
+	})
+}
@@ -53,14 +53,16 @@ type Component interface {
 }
 
 type component struct {
+	enricher  EventEnricher
 	fStdEvent []FuncEvent
 	fErrEvent []FuncEvent
 }
 
-// NewComponent returns an event component.
-func NewComponent(modulesToCallForStandardEvent []FuncEvent,
+// NewComponent returns an event component. Events are enriched before being given to the modules.
+func NewComponent(enricher EventEnricher, modulesToCallForStandardEvent []FuncEvent,
 	modulesToCallForErrorEvent []FuncEvent) Component {
 	return &component{
+		enricher:  enricher,
 		fStdEvent: modulesToCallForStandardEvent,
 		fErrEvent: modulesToCallForErrorEvent,
 	}
@@ -69,7 +71,7 @@ func NewComponent(modulesToCallForStandardEvent []FuncEvent,
 func (c *component) Event(ctx context.Context, event *fb.Event) error {
 	var eventType = int8(event.Type())
 	var eventTypeName = fb.EnumNamesEventType[eventType]
-	var eventMap = eventToMap(event)
+	var eventMap = c.enricher.Enrich(ctx, eventToMap(event))
 
 	if strings.HasSuffix(eventTypeName, "_ERROR") {
 		return apply(ctx, c.fErrEvent, eventMap)
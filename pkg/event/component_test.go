@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/cloudtrust/common-service/database"
+	"github.com/cloudtrust/common-service/log"
 	"github.com/cloudtrust/keycloak-bridge/api/event/fb"
 	flatbuffers "github.com/google/flatbuffers/go"
 	"github.com/stretchr/testify/assert"
@@ -30,7 +31,7 @@ func TestMuxComponent(t *testing.T) {
 	var tEvent = []FuncEvent{fnEvent}
 	var tAdminEvent = []FuncEvent{fnAdminEvent}
 
-	var eventComponent = NewComponent(tEvent, tEvent)
+	var eventComponent = NewComponent(NewEventEnricher(nil, log.NewNopLogger()), tEvent, tEvent)
 	var adminEventService = NewAdminComponent(tAdminEvent, tAdminEvent, tAdminEvent, tAdminEvent)
 
 	var muxComponent = NewMuxComponent(eventComponent, adminEventService)
@@ -58,7 +59,7 @@ func TestComponent(t *testing.T) {
 
 		var tStd = []FuncEvent{fnStd}
 		var tErr = []FuncEvent{fnErr}
-		eventComponent = NewComponent(tStd, tErr)
+		eventComponent = NewComponent(NewEventEnricher(nil, log.NewNopLogger()), tStd, tErr)
 	}
 
 	{
@@ -0,0 +1,139 @@
+package event
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/cloudtrust/common-service/database"
+	"github.com/cloudtrust/common-service/database/sqltypes"
+	"github.com/cloudtrust/common-service/log"
+	"github.com/cloudtrust/keycloak-bridge/internal/keycloakb"
+)
+
+// Keys of the additional info filled by the enrichment
+const (
+	AddInfoIPAddress   = "ip_address"
+	AddInfoUserAgent   = "user_agent"
+	AddInfoCountry     = "country"
+	AddInfoBrowser     = "browser"
+	AddInfoOS          = "os"
+	AddInfoDeviceClass = "device_class"
+)
+
+// GeoIPLocator is the interface of the GeoIP database
+type GeoIPLocator interface {
+	Country(ipAddress string) (string, error)
+}
+
+// EventEnricher adds to the events the information deduced from their IP address and user agent
+type EventEnricher interface {
+	Enrich(ctx context.Context, eventMap map[string]string) map[string]string
+}
+
+type eventEnricher struct {
+	geoIP  GeoIPLocator
+	logger log.Logger
+}
+
+// NewEventEnricher returns an event enricher. Countries are not resolved if geoIP is nil.
+func NewEventEnricher(geoIP GeoIPLocator, logger log.Logger) EventEnricher {
+	return &eventEnricher{
+		geoIP:  geoIP,
+		logger: logger,
+	}
+}
+
+func (e *eventEnricher) Enrich(ctx context.Context, eventMap map[string]string) map[string]string {
+	var addInfo map[string]string
+	if err := json.Unmarshal([]byte(eventMap[database.CtEventAdditionalInfo]), &addInfo); err != nil || addInfo == nil {
+		return eventMap
+	}
+
+	var userAgent = addInfo[AddInfoUserAgent]
+	if userAgent == "" {
+		userAgent = addInfo["userAgent"]
+	}
+	var uaInfo = keycloakb.ParseUserAgent(userAgent)
+	addInfo[AddInfoBrowser] = uaInfo.Browser
+	addInfo[AddInfoOS] = uaInfo.OS
+	addInfo[AddInfoDeviceClass] = uaInfo.DeviceClass
+
+	if ipAddress := addInfo[AddInfoIPAddress]; e.geoIP != nil && ipAddress != "" {
+		var country, err = e.geoIP.Country(ipAddress)
+		if err != nil {
+			e.logger.Warn(ctx, "msg", "Can't resolve the country of an IP address", "err", err.Error())
+		}
+		addInfo[AddInfoCountry] = country
+	}
+
+	// BE AWARE: error is not treated
+	infoJSON, _ := json.Marshal(addInfo)
+	eventMap[database.CtEventAdditionalInfo] = string(infoJSON)
+
+	return eventMap
+}
+
+// AuthenticationsDBModule is the interface of the module storing the enriched information of the authentications
+type AuthenticationsDBModule interface {
+	Store(context.Context, map[string]string) error
+}
+
+type authenticationsDBModule struct {
+	db sqltypes.CloudtrustDB
+}
+
+// NewAuthenticationsDBModule returns a module which stores the successful logins in the audit_authentication table.
+// Country and device are stored as indexed columns with flags telling whether the user ever logged in from them before.
+func NewAuthenticationsDBModule(db sqltypes.CloudtrustDB) AuthenticationsDBModule {
+	return &authenticationsDBModule{
+		db: db,
+	}
+}
+
+const (
+	selectKnownDeviceStmt = `
+		SELECT count(1), ifnull(sum(browser=? AND os=? AND device_class=?), 0), ifnull(sum(country=?), 0)
+		FROM audit_authentication
+		WHERE realm_name=? AND user_id=?
+	`
+	insertAuthenticationStmt = `
+		INSERT INTO audit_authentication (audit_time, realm_name, user_id, username, ip_address, country, browser, os, device_class, new_device, new_country)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+)
+
+// Store only keeps the successful logins. Devices and countries are not flagged as new for the first login of a user.
+func (m *authenticationsDBModule) Store(_ context.Context, eventMap map[string]string) error {
+	if eventMap[database.CtEventType] != "LOGON_OK" {
+		return nil
+	}
+
+	var addInfo map[string]string
+	_ = json.Unmarshal([]byte(eventMap[database.CtEventAdditionalInfo]), &addInfo)
+
+	var realmName = eventMap[database.CtEventRealmName]
+	var userID = eventMap[database.CtEventUserID]
+	var country = addInfo[AddInfoCountry]
+	var browser = valueOrUnknown(addInfo[AddInfoBrowser])
+	var os = valueOrUnknown(addInfo[AddInfoOS])
+	var deviceClass = valueOrUnknown(addInfo[AddInfoDeviceClass])
+
+	var previousLogins, knownDevice, knownCountry int64
+	var row = m.db.QueryRow(selectKnownDeviceStmt, browser, os, deviceClass, country, realmName, userID)
+	if err := row.Scan(&previousLogins, &knownDevice, &knownCountry); err != nil {
+		return err
+	}
+	var newDevice = previousLogins > 0 && knownDevice == 0
+	var newCountry = previousLogins > 0 && country != "" && knownCountry == 0
+
+	var _, err = m.db.Exec(insertAuthenticationStmt, eventMap[database.CtEventAuditTime], realmName, userID, eventMap[database.CtEventUsername],
+		addInfo[AddInfoIPAddress], country, browser, os, deviceClass, newDevice, newCountry)
+	return err
+}
+
+func valueOrUnknown(value string) string {
+	if value == "" {
+		return keycloakb.UserAgentUnknown
+	}
+	return value
+}
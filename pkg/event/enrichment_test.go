@@ -0,0 +1,138 @@
+package event
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/cloudtrust/common-service/database"
+	"github.com/cloudtrust/common-service/log"
+	"github.com/cloudtrust/keycloak-bridge/pkg/event/mock"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	testUserAgent = "Mozilla/5.0 (iPhone; CPU iPhone OS 13_5 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/13.1.1 Mobile/15E148 Safari/604.1"
+)
+
+func createEnrichmentEventMap(ctEventType string, addInfo map[string]string) map[string]string {
+	var infoJSON, _ = json.Marshal(addInfo)
+	return map[string]string{
+		database.CtEventType:           ctEventType,
+		database.CtEventAuditTime:      "2020-06-01 10:00:00.000",
+		database.CtEventRealmName:      "realm",
+		database.CtEventUserID:         "user-id",
+		database.CtEventUsername:       "username",
+		database.CtEventAdditionalInfo: string(infoJSON),
+	}
+}
+
+func getAddInfo(eventMap map[string]string) map[string]string {
+	var addInfo map[string]string
+	_ = json.Unmarshal([]byte(eventMap[database.CtEventAdditionalInfo]), &addInfo)
+	return addInfo
+}
+
+func TestEventEnricher(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var mockGeoIP = mock.NewGeoIPLocator(mockCtrl)
+	var ctx = context.TODO()
+	var ipAddress = "8.8.8.8"
+
+	t.Run("No additional info", func(t *testing.T) {
+		var enricher = NewEventEnricher(mockGeoIP, log.NewNopLogger())
+		var eventMap = map[string]string{database.CtEventType: "LOGON_OK"}
+		assert.Equal(t, eventMap, enricher.Enrich(ctx, eventMap))
+	})
+
+	t.Run("Without GeoIP database", func(t *testing.T) {
+		var enricher = NewEventEnricher(nil, log.NewNopLogger())
+		var eventMap = createEnrichmentEventMap("LOGON_OK", map[string]string{AddInfoIPAddress: ipAddress, AddInfoUserAgent: testUserAgent})
+
+		var addInfo = getAddInfo(enricher.Enrich(ctx, eventMap))
+		assert.Equal(t, "Safari", addInfo[AddInfoBrowser])
+		assert.Equal(t, "iOS", addInfo[AddInfoOS])
+		assert.Equal(t, "mobile", addInfo[AddInfoDeviceClass])
+		assert.NotContains(t, addInfo, AddInfoCountry)
+	})
+
+	t.Run("Country resolved", func(t *testing.T) {
+		var enricher = NewEventEnricher(mockGeoIP, log.NewNopLogger())
+		var eventMap = createEnrichmentEventMap("LOGON_OK", map[string]string{AddInfoIPAddress: ipAddress})
+		mockGeoIP.EXPECT().Country(ipAddress).Return("US", nil)
+
+		var addInfo = getAddInfo(enricher.Enrich(ctx, eventMap))
+		assert.Equal(t, "US", addInfo[AddInfoCountry])
+		assert.Equal(t, "unknown", addInfo[AddInfoBrowser])
+		assert.Equal(t, ipAddress, addInfo[AddInfoIPAddress])
+	})
+
+	t.Run("GeoIP failure", func(t *testing.T) {
+		var enricher = NewEventEnricher(mockGeoIP, log.NewNopLogger())
+		var eventMap = createEnrichmentEventMap("LOGON_OK", map[string]string{AddInfoIPAddress: ipAddress})
+		mockGeoIP.EXPECT().Country(ipAddress).Return("", errors.New("invalid database"))
+
+		var addInfo = getAddInfo(enricher.Enrich(ctx, eventMap))
+		assert.Equal(t, "", addInfo[AddInfoCountry])
+	})
+}
+
+func TestAuthenticationsDBModule(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var mockDB = mock.NewCloudtrustDB(mockCtrl)
+	var mockRow = mock.NewSQLRow(mockCtrl)
+	var module = NewAuthenticationsDBModule(mockDB)
+	var ctx = context.TODO()
+	var addInfo = map[string]string{AddInfoIPAddress: "8.8.8.8", AddInfoCountry: "US", AddInfoBrowser: "Safari", AddInfoOS: "iOS", AddInfoDeviceClass: "mobile"}
+	var eventMap = createEnrichmentEventMap("LOGON_OK", addInfo)
+
+	t.Run("Not a login", func(t *testing.T) {
+		assert.Nil(t, module.Store(ctx, createEnrichmentEventMap("LOGON_ERROR", addInfo)))
+	})
+
+	t.Run("Can't check known devices", func(t *testing.T) {
+		var expectedErr = errors.New("db error")
+		mockDB.EXPECT().QueryRow(gomock.Any(), "Safari", "iOS", "mobile", "US", "realm", "user-id").Return(mockRow)
+		mockRow.EXPECT().Scan(gomock.Any()).Return(expectedErr)
+
+		assert.Equal(t, expectedErr, module.Store(ctx, eventMap))
+	})
+
+	var mockScan = func(previousLogins, knownDevice, knownCountry int64) {
+		mockDB.EXPECT().QueryRow(gomock.Any(), "Safari", "iOS", "mobile", "US", "realm", "user-id").Return(mockRow)
+		mockRow.EXPECT().Scan(gomock.Any()).DoAndReturn(func(dest ...interface{}) error {
+			*(dest[0].(*int64)) = previousLogins
+			*(dest[1].(*int64)) = knownDevice
+			*(dest[2].(*int64)) = knownCountry
+			return nil
+		})
+	}
+
+	t.Run("First login of the user", func(t *testing.T) {
+		mockScan(0, 0, 0)
+		mockDB.EXPECT().Exec(gomock.Any(), "2020-06-01 10:00:00.000", "realm", "user-id", "username", "8.8.8.8", "US", "Safari", "iOS", "mobile", false, false).Return(nil, nil)
+
+		assert.Nil(t, module.Store(ctx, eventMap))
+	})
+
+	t.Run("New device and new country", func(t *testing.T) {
+		mockScan(5, 0, 0)
+		mockDB.EXPECT().Exec(gomock.Any(), "2020-06-01 10:00:00.000", "realm", "user-id", "username", "8.8.8.8", "US", "Safari", "iOS", "mobile", true, true).Return(nil, nil)
+
+		assert.Nil(t, module.Store(ctx, eventMap))
+	})
+
+	t.Run("Known device", func(t *testing.T) {
+		var expectedErr = errors.New("db error")
+		mockScan(5, 2, 3)
+		mockDB.EXPECT().Exec(gomock.Any(), "2020-06-01 10:00:00.000", "realm", "user-id", "username", "8.8.8.8", "US", "Safari", "iOS", "mobile", false, false).Return(nil, expectedErr)
+
+		assert.Equal(t, expectedErr, module.Store(ctx, eventMap))
+	})
+}
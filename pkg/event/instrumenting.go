@@ -165,3 +165,27 @@ func (m *eventsDBModuleInstrumentingMW) ReportEvent(ctx context.Context, apiCall
 	}(time.Now())
 	return m.next.ReportEvent(ctx, apiCall, origin, values...)
 }
+
+// Instrumenting middleware at module level.
+type authenticationsDBModuleInstrumentingMW struct {
+	h    metrics.Histogram
+	next AuthenticationsDBModule
+}
+
+// MakeAuthenticationsDBModuleInstrumentingMW makes an instrumenting middleware at module level.
+func MakeAuthenticationsDBModuleInstrumentingMW(h metrics.Histogram) func(AuthenticationsDBModule) AuthenticationsDBModule {
+	return func(next AuthenticationsDBModule) AuthenticationsDBModule {
+		return &authenticationsDBModuleInstrumentingMW{
+			h:    h,
+			next: next,
+		}
+	}
+}
+
+// authenticationsDBModuleInstrumentingMW implements AuthenticationsDBModule.
+func (m *authenticationsDBModuleInstrumentingMW) Store(ctx context.Context, mp map[string]string) error {
+	defer func(begin time.Time) {
+		m.h.With(KeyCorrelationID, ctx.Value(cs.CtContextCorrelationID).(string)).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	return m.next.Store(ctx, mp)
+}
@@ -157,3 +157,21 @@ func TestEventsDBModuleInstrumentingMW(t *testing.T) {
 	mockHistogram.EXPECT().Observe(gomock.Any()).Return().Times(1)
 	m.Store(ctx, mp)
 }
+
+func TestAuthenticationsDBModuleInstrumentingMW(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+	var mockAuthenticationsDBModule = mock.NewAuthenticationsDBModule(mockCtrl)
+	var mockHistogram = mock.NewHistogram(mockCtrl)
+
+	var m = MakeAuthenticationsDBModuleInstrumentingMW(mockHistogram)(mockAuthenticationsDBModule)
+
+	var corrID = strconv.FormatUint(rand.Uint64(), 10)
+	var ctx = context.WithValue(context.Background(), cs.CtContextCorrelationID, corrID)
+	var mp = map[string]string{"key": "val"}
+
+	mockAuthenticationsDBModule.EXPECT().Store(ctx, mp).Return(nil).Times(1)
+	mockHistogram.EXPECT().With("correlation_id", corrID).Return(mockHistogram).Times(1)
+	mockHistogram.EXPECT().Observe(gomock.Any()).Return().Times(1)
+	m.Store(ctx, mp)
+}
@@ -160,3 +160,27 @@ func (m *eventsDBModuleLoggingMW) ReportEvent(ctx context.Context, apiCall strin
 	}(time.Now())
 	return m.next.ReportEvent(ctx, apiCall, origin, values...)
 }
+
+// Logging middleware for the authentications module.
+type authenticationsDBModuleLoggingMW struct {
+	logger log.Logger
+	next   AuthenticationsDBModule
+}
+
+// MakeAuthenticationsDBModuleLoggingMW makes a logging middleware for the authentications module.
+func MakeAuthenticationsDBModuleLoggingMW(log log.Logger) func(AuthenticationsDBModule) AuthenticationsDBModule {
+	return func(next AuthenticationsDBModule) AuthenticationsDBModule {
+		return &authenticationsDBModuleLoggingMW{
+			logger: log,
+			next:   next,
+		}
+	}
+}
+
+// authenticationsDBModuleLoggingMW implements AuthenticationsDBModule.
+func (m *authenticationsDBModuleLoggingMW) Store(ctx context.Context, mp map[string]string) error {
+	defer func(begin time.Time) {
+		m.logger.Debug(ctx, "method", "Store", "args", mp, "took", time.Since(begin))
+	}(time.Now())
+	return m.next.Store(ctx, mp)
+}
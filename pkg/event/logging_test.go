@@ -150,3 +150,20 @@ func TestEventsDBModuleLoggingMW(t *testing.T) {
 	mockLogger.EXPECT().Debug(ctx, "method", "Store", "args", mp, "took", gomock.Any()).Times(1)
 	m.Store(ctx, mp)
 }
+
+func TestAuthenticationsDBModuleLoggingMW(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+	var mockAuthenticationsDBModule = mock.NewAuthenticationsDBModule(mockCtrl)
+	var mockLogger = mock.NewLogger(mockCtrl)
+
+	var m = MakeAuthenticationsDBModuleLoggingMW(mockLogger)(mockAuthenticationsDBModule)
+
+	var corrID = "store-corrid-123456789"
+	var ctx = context.WithValue(context.Background(), cs.CtContextCorrelationID, corrID)
+	var mp = map[string]string{"key": "val"}
+
+	mockAuthenticationsDBModule.EXPECT().Store(ctx, mp).Return(nil).Times(1)
+	mockLogger.EXPECT().Debug(ctx, "method", "Store", "args", mp, "took", gomock.Any()).Times(1)
+	m.Store(ctx, mp)
+}
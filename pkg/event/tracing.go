@@ -181,3 +181,30 @@ func (m *eventsDBModuleTracingMW) ReportEvent(ctx context.Context, apiCall strin
 
 	return m.next.ReportEvent(ctx, apiCall, origin, values...)
 }
+
+// Tracing middleware at module level.
+type authenticationsDBModuleTracingMW struct {
+	tracer tracing.OpentracingClient
+	next   AuthenticationsDBModule
+}
+
+// MakeAuthenticationsDBModuleTracingMW makes a tracing middleware at module level.
+func MakeAuthenticationsDBModuleTracingMW(tracer tracing.OpentracingClient) func(AuthenticationsDBModule) AuthenticationsDBModule {
+	return func(next AuthenticationsDBModule) AuthenticationsDBModule {
+		return &authenticationsDBModuleTracingMW{
+			tracer: tracer,
+			next:   next,
+		}
+	}
+}
+
+// authenticationsDBModuleTracingMW implements AuthenticationsDBModule.
+func (m *authenticationsDBModuleTracingMW) Store(ctx context.Context, mp map[string]string) error {
+	var f tracing.Finisher
+	ctx, f = m.tracer.TryStartSpanWithTag(ctx, "authenticationsDB_module", KeyCorrelationID, ctx.Value(cs.CtContextCorrelationID).(string))
+	if f != nil {
+		defer f.Finish()
+	}
+
+	return m.next.Store(ctx, mp)
+}
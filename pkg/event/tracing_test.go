@@ -162,3 +162,27 @@ func TestEventsDBModuleTracingMW(t *testing.T) {
 	mockTracer.EXPECT().TryStartSpanWithTag(ctx, "eventsDB_module", "correlation_id", corrID).Return(ctx, nil).Times(1)
 	m.Store(ctx, mp)
 }
+
+func TestAuthenticationsDBModuleTracingMW(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+	var mockAuthenticationsDBModule = mock.NewAuthenticationsDBModule(mockCtrl)
+	var mockTracer = mock.NewOpentracingClient(mockCtrl)
+	var mockFinisher = mock.NewFinisher(mockCtrl)
+
+	var m = MakeAuthenticationsDBModuleTracingMW(mockTracer)(mockAuthenticationsDBModule)
+	var corrID = strconv.FormatUint(rand.Uint64(), 10)
+	var ctx = context.WithValue(context.Background(), cs.CtContextCorrelationID, corrID)
+	var mp = map[string]string{"key": "val"}
+
+	// Spawn
+	mockAuthenticationsDBModule.EXPECT().Store(gomock.Any(), mp).Return(nil).Times(1)
+	mockTracer.EXPECT().TryStartSpanWithTag(ctx, "authenticationsDB_module", "correlation_id", corrID).Return(ctx, mockFinisher).Times(1)
+	mockFinisher.EXPECT().Finish().Times(1)
+	m.Store(ctx, mp)
+
+	// Not spawn
+	mockAuthenticationsDBModule.EXPECT().Store(gomock.Any(), mp).Return(nil).Times(1)
+	mockTracer.EXPECT().TryStartSpanWithTag(ctx, "authenticationsDB_module", "correlation_id", corrID).Return(ctx, nil).Times(1)
+	m.Store(ctx, mp)
+}
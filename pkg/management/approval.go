@@ -0,0 +1,494 @@
+package management
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	cs "github.com/cloudtrust/common-service"
+	"github.com/cloudtrust/common-service/database"
+	errorhandler "github.com/cloudtrust/common-service/errors"
+	"github.com/cloudtrust/common-service/security"
+	api "github.com/cloudtrust/keycloak-bridge/api/management"
+	"github.com/cloudtrust/keycloak-bridge/internal/constants"
+	"github.com/cloudtrust/keycloak-bridge/internal/dto"
+	"github.com/cloudtrust/keycloak-bridge/internal/keycloakb"
+)
+
+const (
+	emailTemplateApprovedPassword = "notif-approved-password.ftl"
+	emailSubjectApprovedPassword  = "notifApprovedPasswordSubject"
+)
+
+// ApprovalsDBModule is the interface of the approval policies and requests storage
+type ApprovalsDBModule interface {
+	GetApprovalPolicy(ctx context.Context, realm string) ([]string, error)
+	UpdateApprovalPolicy(ctx context.Context, realm string, actions []string) error
+	CreateApprovalRequest(ctx context.Context, request dto.DBApprovalRequest) (int64, error)
+	GetApprovalRequest(ctx context.Context, realm string, requestID int64) (*dto.DBApprovalRequest, error)
+	GetApprovalRequests(ctx context.Context, realm string, status *string) ([]dto.DBApprovalRequest, error)
+	UpdateApprovalRequestStatus(ctx context.Context, request dto.DBApprovalRequest, currentStatus string) (bool, error)
+}
+
+// ApprovalComponent is the interface of the four-eyes approval of sensitive actions
+type ApprovalComponent interface {
+	GetApprovalPolicy(ctx context.Context, realmName string) (api.ApprovalPolicyRepresentation, error)
+	UpdateApprovalPolicy(ctx context.Context, realmName string, policy api.ApprovalPolicyRepresentation) error
+	GetApprovalRequests(ctx context.Context, realmName string, status *string) ([]api.ApprovalRequestRepresentation, error)
+	ApproveRequest(ctx context.Context, realmName string, requestID int64, decision api.ApprovalDecisionRepresentation) (api.ApprovalRequestRepresentation, error)
+	RejectRequest(ctx context.Context, realmName string, requestID int64, decision api.ApprovalDecisionRepresentation) error
+}
+
+// ApprovalRequiredError is returned when an action has been stored as a pending approval request instead of being executed
+type ApprovalRequiredError struct {
+	Request api.ApprovalRequestRepresentation
+}
+
+func (e ApprovalRequiredError) Error() string {
+	return keycloakb.ComponentName + "." + constants.MsgErrApprovalRequired
+}
+
+// approvableActions are the actions which can require an approval
+var approvableActions = map[string]bool{
+	MGMTDeleteUser.String():                    true,
+	MGMTResetPassword.String():                 true,
+	MGMTUpdateAuthorizations.String():          true,
+	MGMTUpdateRealmAdminConfiguration.String(): true,
+	MGMTUpdateApprovalPolicy.String():          true,
+	MGMTRollbackConfiguration.String():         true,
+}
+
+type approvalRequester struct {
+	approvalsDB   ApprovalsDBModule
+	eventDBModule database.EventsDBModule
+	logger        keycloakb.Logger
+}
+
+func (r *approvalRequester) requiresApproval(ctx context.Context, realmName string, action string) (bool, error) {
+	var actions, err = r.approvalsDB.GetApprovalPolicy(ctx, realmName)
+	if err != nil {
+		return false, err
+	}
+	for _, value := range actions {
+		if value == action {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// requestApproval stores a pending request. It returns an ApprovalRequiredError when the request is created.
+func (r *approvalRequester) requestApproval(ctx context.Context, realmName string, action string, targetID *string, parameters interface{}) error {
+	var request = dto.DBApprovalRequest{
+		RealmName:         realmName,
+		Action:            action,
+		TargetID:          targetID,
+		RequesterID:       ctx.Value(cs.CtContextUserID).(string),
+		RequesterUsername: ctx.Value(cs.CtContextUsername).(string),
+		Status:            dto.ApprovalStatusPending,
+		CreatedAt:         time.Now(),
+	}
+	if parameters != nil {
+		var err error
+		if request.Payload, err = json.Marshal(parameters); err != nil {
+			r.logger.Warn(ctx, "msg", "Can't marshal approval request parameters", "err", err.Error())
+			return err
+		}
+	}
+
+	var err error
+	if request.ID, err = r.approvalsDB.CreateApprovalRequest(ctx, request); err != nil {
+		return err
+	}
+
+	reportEvent(ctx, r.eventDBModule, r.logger, "API_APPROVAL_REQUESTED", approvalEventValues(request)...)
+
+	return ApprovalRequiredError{Request: api.ConvertToAPIApprovalRequest(request)}
+}
+
+func approvalEventValues(request dto.DBApprovalRequest) []string {
+	var infos = []string{"approval_id", strconv.FormatInt(request.ID, 10), "action", request.Action, "requester_id", request.RequesterID}
+	var targetUserID *string
+	if request.TargetID != nil {
+		switch request.Action {
+		case MGMTUpdateAuthorizations.String():
+			infos = append(infos, "group_id", *request.TargetID)
+		case MGMTRollbackConfiguration.String():
+			infos = append(infos, "revision_id", *request.TargetID)
+		default:
+			targetUserID = request.TargetID
+		}
+	}
+	var values = []string{database.CtEventRealmName, request.RealmName, database.CtEventAdditionalInfo, database.CreateAdditionalInfo(infos...)}
+	if targetUserID != nil {
+		values = append(values, database.CtEventUserID, *targetUserID)
+	}
+	return values
+}
+
+type approvalComponentMW struct {
+	Component
+	approvalRequester
+}
+
+// MakeApprovalComponentMW replaces the execution of the actions which require an approval in the realm by the creation
+// of a pending request. The caller gets an ApprovalRequiredError.
+func MakeApprovalComponentMW(approvalsDB ApprovalsDBModule, eventDBModule database.EventsDBModule, logger keycloakb.Logger) func(Component) Component {
+	return func(next Component) Component {
+		return &approvalComponentMW{
+			Component: next,
+			approvalRequester: approvalRequester{
+				approvalsDB:   approvalsDB,
+				eventDBModule: eventDBModule,
+				logger:        logger,
+			},
+		}
+	}
+}
+
+func (m *approvalComponentMW) DeleteUser(ctx context.Context, realmName, userID string) error {
+	var required, err = m.requiresApproval(ctx, realmName, MGMTDeleteUser.String())
+	if err != nil {
+		return err
+	}
+	if required {
+		return m.requestApproval(ctx, realmName, MGMTDeleteUser.String(), &userID, nil)
+	}
+	return m.Component.DeleteUser(ctx, realmName, userID)
+}
+
+func (m *approvalComponentMW) ResetPassword(ctx context.Context, realmName string, userID string, password api.PasswordRepresentation) (string, error) {
+	var required, err = m.requiresApproval(ctx, realmName, MGMTResetPassword.String())
+	if err != nil {
+		return "", err
+	}
+	if required {
+		return "", m.requestApproval(ctx, realmName, MGMTResetPassword.String(), &userID, password)
+	}
+	return m.Component.ResetPassword(ctx, realmName, userID, password)
+}
+
+func (m *approvalComponentMW) UpdateAuthorizations(ctx context.Context, realmName string, groupID string, authorizations api.AuthorizationsRepresentation) error {
+	var required, err = m.requiresApproval(ctx, realmName, MGMTUpdateAuthorizations.String())
+	if err != nil {
+		return err
+	}
+	if required {
+		return m.requestApproval(ctx, realmName, MGMTUpdateAuthorizations.String(), &groupID, authorizations)
+	}
+	return m.Component.UpdateAuthorizations(ctx, realmName, groupID, authorizations)
+}
+
+func (m *approvalComponentMW) UpdateRealmAdminConfiguration(ctx context.Context, realmName string, adminConfig api.RealmAdminConfiguration) error {
+	var required, err = m.requiresApproval(ctx, realmName, MGMTUpdateRealmAdminConfiguration.String())
+	if err != nil {
+		return err
+	}
+	if required {
+		return m.requestApproval(ctx, realmName, MGMTUpdateRealmAdminConfiguration.String(), nil, adminConfig)
+	}
+	return m.Component.UpdateRealmAdminConfiguration(ctx, realmName, adminConfig)
+}
+
+// RollbackConfiguration requires an approval when rollbacks require one or when the restored revision is an admin
+// configuration and its updates require an approval
+func (m *approvalComponentMW) RollbackConfiguration(ctx context.Context, realmName string, revisionID int64) error {
+	var required, err = m.requiresApproval(ctx, realmName, MGMTRollbackConfiguration.String())
+	if err != nil {
+		return err
+	}
+	if !required {
+		if required, err = m.requiresAdminConfigurationApproval(ctx, realmName, revisionID); err != nil {
+			return err
+		}
+	}
+	if required {
+		var targetID = strconv.FormatInt(revisionID, 10)
+		return m.requestApproval(ctx, realmName, MGMTRollbackConfiguration.String(), &targetID, nil)
+	}
+	return m.Component.RollbackConfiguration(ctx, realmName, revisionID)
+}
+
+// requiresAdminConfigurationApproval tells if the revision restores an admin configuration while the updates of the
+// admin configuration require an approval
+func (m *approvalComponentMW) requiresAdminConfigurationApproval(ctx context.Context, realmName string, revisionID int64) (bool, error) {
+	var required, err = m.requiresApproval(ctx, realmName, MGMTUpdateRealmAdminConfiguration.String())
+	if err != nil || !required {
+		return false, err
+	}
+
+	var configType = dto.ConfigurationTypeAdmin
+	revisions, err := m.Component.GetConfigurationRevisions(ctx, realmName, &configType)
+	if err != nil {
+		return false, err
+	}
+	for _, revision := range revisions {
+		if revision.ID != nil && *revision.ID == revisionID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+type approvalComponent struct {
+	approvalRequester
+	component   Component
+	authManager security.AuthorizationManager
+	emailSender keycloakb.EmailSender
+}
+
+// NewApprovalComponent returns the approval component. The approved actions are executed by the given management
+// component which must not be wrapped by the approval middleware. The passwords generated by an approved password reset
+// are sent to the user with the email sender.
+func NewApprovalComponent(component Component, approvalsDB ApprovalsDBModule, authManager security.AuthorizationManager,
+	emailSender keycloakb.EmailSender, eventDBModule database.EventsDBModule, logger keycloakb.Logger) ApprovalComponent {
+	return &approvalComponent{
+		approvalRequester: approvalRequester{
+			approvalsDB:   approvalsDB,
+			eventDBModule: eventDBModule,
+			logger:        logger,
+		},
+		component:   component,
+		authManager: authManager,
+		emailSender: emailSender,
+	}
+}
+
+func (c *approvalComponent) GetApprovalPolicy(ctx context.Context, realmName string) (api.ApprovalPolicyRepresentation, error) {
+	var actions, err = c.approvalsDB.GetApprovalPolicy(ctx, realmName)
+	if err != nil {
+		return api.ApprovalPolicyRepresentation{}, err
+	}
+	return api.ApprovalPolicyRepresentation{Actions: actions}, nil
+}
+
+// UpdateApprovalPolicy changes the actions which require an approval. The change itself requires an approval when
+// MGMT_UpdateApprovalPolicy is part of the current policy.
+func (c *approvalComponent) UpdateApprovalPolicy(ctx context.Context, realmName string, policy api.ApprovalPolicyRepresentation) error {
+	for _, action := range policy.Actions {
+		if !approvableActions[action] {
+			return errorhandler.CreateBadRequestError(constants.MsgErrInvalidParam + "." + constants.Actions)
+		}
+	}
+
+	var required, err = c.requiresApproval(ctx, realmName, MGMTUpdateApprovalPolicy.String())
+	if err != nil {
+		return err
+	}
+	if required {
+		return c.requestApproval(ctx, realmName, MGMTUpdateApprovalPolicy.String(), nil, policy)
+	}
+	return c.updateApprovalPolicy(ctx, realmName, policy)
+}
+
+func (c *approvalComponent) updateApprovalPolicy(ctx context.Context, realmName string, policy api.ApprovalPolicyRepresentation) error {
+	var actions = policy.Actions
+	if actions == nil {
+		actions = []string{}
+	}
+	if err := c.approvalsDB.UpdateApprovalPolicy(ctx, realmName, actions); err != nil {
+		return err
+	}
+
+	reportEvent(ctx, c.eventDBModule, c.logger, "API_APPROVAL_POLICY_UPDATE", database.CtEventRealmName, realmName)
+
+	return nil
+}
+
+func (c *approvalComponent) GetApprovalRequests(ctx context.Context, realmName string, status *string) ([]api.ApprovalRequestRepresentation, error) {
+	var requests, err = c.approvalsDB.GetApprovalRequests(ctx, realmName, status)
+	if err != nil {
+		return nil, err
+	}
+
+	var res = []api.ApprovalRequestRepresentation{}
+	for _, request := range requests {
+		res = append(res, api.ConvertToAPIApprovalRequest(request))
+	}
+	return res, nil
+}
+
+// ApproveRequest executes a pending request on behalf of the approver. The approver can't be the requester and must be
+// allowed to execute the requested action.
+func (c *approvalComponent) ApproveRequest(ctx context.Context, realmName string, requestID int64, decision api.ApprovalDecisionRepresentation) (api.ApprovalRequestRepresentation, error) {
+	var request, err = c.getPendingRequest(ctx, realmName, requestID)
+	if err != nil {
+		return api.ApprovalRequestRepresentation{}, err
+	}
+	if err = c.checkActionAuthorization(ctx, *request); err != nil {
+		return api.ApprovalRequestRepresentation{}, err
+	}
+	if err = c.decide(ctx, request, dto.ApprovalStatusApproved, decision); err != nil {
+		return api.ApprovalRequestRepresentation{}, err
+	}
+
+	err = c.execute(ctx, *request)
+	// The payload has been erased from the storage by the decision
+	request.Payload = nil
+	if err != nil {
+		c.logger.Warn(ctx, "msg", "Approved request failed", "err", err.Error(), "realm", realmName, "requestID", requestID)
+		var failed = *request
+		failed.Status = dto.ApprovalStatusFailed
+		_, _ = c.approvalsDB.UpdateApprovalRequestStatus(ctx, failed, dto.ApprovalStatusApproved)
+		reportEvent(ctx, c.eventDBModule, c.logger, "API_APPROVAL_FAILED", approvalEventValues(failed)...)
+		return api.ApprovalRequestRepresentation{}, err
+	}
+
+	reportEvent(ctx, c.eventDBModule, c.logger, "API_APPROVAL_APPROVED", approvalEventValues(*request)...)
+
+	return api.ConvertToAPIApprovalRequest(*request), nil
+}
+
+func (c *approvalComponent) RejectRequest(ctx context.Context, realmName string, requestID int64, decision api.ApprovalDecisionRepresentation) error {
+	var request, err = c.getPendingRequest(ctx, realmName, requestID)
+	if err != nil {
+		return err
+	}
+	if err = c.decide(ctx, request, dto.ApprovalStatusRejected, decision); err != nil {
+		return err
+	}
+	request.Payload = nil
+
+	reportEvent(ctx, c.eventDBModule, c.logger, "API_APPROVAL_REJECTED", approvalEventValues(*request)...)
+
+	return nil
+}
+
+func (c *approvalComponent) getPendingRequest(ctx context.Context, realmName string, requestID int64) (*dto.DBApprovalRequest, error) {
+	var request, err = c.approvalsDB.GetApprovalRequest(ctx, realmName, requestID)
+	if err != nil {
+		return nil, err
+	}
+	if request == nil {
+		return nil, errorhandler.CreateNotFoundError(constants.ApprovalID)
+	}
+	if request.RequesterID == ctx.Value(cs.CtContextUserID).(string) {
+		return nil, errorhandler.Error{
+			Status:  http.StatusForbidden,
+			Message: keycloakb.ComponentName + "." + constants.MsgErrSelfApproval,
+		}
+	}
+	if request.Status != dto.ApprovalStatusPending {
+		return nil, approvalAlreadyDecidedError()
+	}
+	return request, nil
+}
+
+// decide stores the decision. It fails if another operator decided on the request in the meantime. The payload is
+// erased from the storage as it is not needed anymore once the request is decided.
+func (c *approvalComponent) decide(ctx context.Context, request *dto.DBApprovalRequest, status string, decision api.ApprovalDecisionRepresentation) error {
+	var deciderID = ctx.Value(cs.CtContextUserID).(string)
+	var deciderUsername = ctx.Value(cs.CtContextUsername).(string)
+	var now = time.Now()
+
+	request.Status = status
+	request.DeciderID = &deciderID
+	request.DeciderUsername = &deciderUsername
+	request.DecidedAt = &now
+	request.Comment = decision.Comment
+
+	var updated, err = c.approvalsDB.UpdateApprovalRequestStatus(ctx, *request, dto.ApprovalStatusPending)
+	if err != nil {
+		return err
+	}
+	if !updated {
+		return approvalAlreadyDecidedError()
+	}
+	return nil
+}
+
+func approvalAlreadyDecidedError() error {
+	return errorhandler.Error{
+		Status:  http.StatusConflict,
+		Message: keycloakb.ComponentName + "." + constants.MsgErrAlreadyDecided,
+	}
+}
+
+func (c *approvalComponent) checkActionAuthorization(ctx context.Context, request dto.DBApprovalRequest) error {
+	switch request.Action {
+	case MGMTDeleteUser.String(), MGMTResetPassword.String():
+		return c.authManager.CheckAuthorizationOnTargetUser(ctx, request.Action, request.RealmName, *request.TargetID)
+	case MGMTUpdateAuthorizations.String():
+		return c.authManager.CheckAuthorizationOnTargetGroupID(ctx, request.Action, request.RealmName, *request.TargetID)
+	default:
+		return c.authManager.CheckAuthorizationOnTargetRealm(ctx, request.Action, request.RealmName)
+	}
+}
+
+// execute runs the approved action
+func (c *approvalComponent) execute(ctx context.Context, request dto.DBApprovalRequest) error {
+	switch request.Action {
+	case MGMTDeleteUser.String():
+		return c.component.DeleteUser(ctx, request.RealmName, *request.TargetID)
+	case MGMTResetPassword.String():
+		var password api.PasswordRepresentation
+		if err := json.Unmarshal(request.Payload, &password); err != nil {
+			return err
+		}
+		return c.resetPassword(ctx, request.RealmName, *request.TargetID, password)
+	case MGMTUpdateAuthorizations.String():
+		var authorizations api.AuthorizationsRepresentation
+		if err := json.Unmarshal(request.Payload, &authorizations); err != nil {
+			return err
+		}
+		return c.component.UpdateAuthorizations(ctx, request.RealmName, *request.TargetID, authorizations)
+	case MGMTUpdateRealmAdminConfiguration.String():
+		var adminConfig api.RealmAdminConfiguration
+		if err := json.Unmarshal(request.Payload, &adminConfig); err != nil {
+			return err
+		}
+		return c.component.UpdateRealmAdminConfiguration(ctx, request.RealmName, adminConfig)
+	case MGMTUpdateApprovalPolicy.String():
+		var policy api.ApprovalPolicyRepresentation
+		if err := json.Unmarshal(request.Payload, &policy); err != nil {
+			return err
+		}
+		return c.updateApprovalPolicy(ctx, request.RealmName, policy)
+	case MGMTRollbackConfiguration.String():
+		var revisionID, err = strconv.ParseInt(*request.TargetID, 10, 64)
+		if err != nil {
+			return err
+		}
+		return c.component.RollbackConfiguration(ctx, request.RealmName, revisionID)
+	default:
+		return errorhandler.CreateBadRequestError(constants.MsgErrInvalidParam + "." + constants.Actions)
+	}
+}
+
+// resetPassword resets the password of the user. A generated password is never given to the approver: it is sent to
+// the user, who must have an email address.
+func (c *approvalComponent) resetPassword(ctx context.Context, realmName, userID string, password api.PasswordRepresentation) error {
+	if password.Value != nil {
+		var _, err = c.component.ResetPassword(ctx, realmName, userID, password)
+		return err
+	}
+
+	var user, err = c.component.GetUser(ctx, realmName, userID)
+	if err != nil {
+		return err
+	}
+	if user.Email == nil || *user.Email == "" {
+		return errorhandler.CreateBadRequestError(constants.MsgErrMissingParam + "." + constants.Email)
+	}
+
+	generated, err := c.component.ResetPassword(ctx, realmName, userID, password)
+	if err != nil {
+		return err
+	}
+
+	var email = keycloakb.Email{
+		Recipient: *user.Email,
+		Subject:   emailSubjectApprovedPassword,
+		Template:  emailTemplateApprovedPassword,
+		Attributes: map[string]string{
+			"password": generated,
+		},
+	}
+	if err = c.emailSender.SendEmail(ctx, realmName, email); err != nil {
+		c.logger.Warn(ctx, "msg", "Could not send the generated password", "err", err.Error(), "userID", userID)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,487 @@
+package management
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	cs "github.com/cloudtrust/common-service"
+	"github.com/cloudtrust/common-service/database"
+	errorhandler "github.com/cloudtrust/common-service/errors"
+	"github.com/cloudtrust/common-service/log"
+	api "github.com/cloudtrust/keycloak-bridge/api/management"
+	"github.com/cloudtrust/keycloak-bridge/internal/dto"
+	"github.com/cloudtrust/keycloak-bridge/internal/keycloakb"
+	"github.com/cloudtrust/keycloak-bridge/pkg/management/mock"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func createApprovalContext(userID, username string) context.Context {
+	var ctx = context.WithValue(context.TODO(), cs.CtContextUserID, userID)
+	return context.WithValue(ctx, cs.CtContextUsername, username)
+}
+
+func TestApprovalComponentMW(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+	var mockManagementComponent = mock.NewManagementComponent(mockCtrl)
+	var mockApprovalsDB = mock.NewApprovalsDBModule(mockCtrl)
+	var mockEventDBModule = mock.NewEventDBModule(mockCtrl)
+
+	var component = MakeApprovalComponentMW(mockApprovalsDB, mockEventDBModule, log.NewNopLogger())(mockManagementComponent)
+
+	var ctx = createApprovalContext("requester-id", "requester")
+	var realm = "DEP"
+	var userID = "f467ed7c-0a1d-4eee-9bb8-669c6f89c0ee"
+	var groupID = "a4b1c6d2-0a1d-4eee-9bb8-669c6f89c0ee"
+	var password = "P@ssw0rd"
+
+	t.Run("Can't get approval policy", func(t *testing.T) {
+		var expectedError = errors.New("db error")
+		mockApprovalsDB.EXPECT().GetApprovalPolicy(ctx, realm).Return(nil, expectedError)
+		assert.Equal(t, expectedError, component.DeleteUser(ctx, realm, userID))
+	})
+
+	t.Run("Action does not require an approval", func(t *testing.T) {
+		mockApprovalsDB.EXPECT().GetApprovalPolicy(ctx, realm).Return([]string{MGMTResetPassword.String()}, nil)
+		mockManagementComponent.EXPECT().DeleteUser(ctx, realm, userID).Return(nil)
+		assert.Nil(t, component.DeleteUser(ctx, realm, userID))
+	})
+
+	t.Run("Can't create approval request", func(t *testing.T) {
+		var expectedError = errors.New("db error")
+		mockApprovalsDB.EXPECT().GetApprovalPolicy(ctx, realm).Return([]string{MGMTDeleteUser.String()}, nil)
+		mockApprovalsDB.EXPECT().CreateApprovalRequest(ctx, gomock.Any()).Return(int64(0), expectedError)
+		assert.Equal(t, expectedError, component.DeleteUser(ctx, realm, userID))
+	})
+
+	t.Run("User deletion requires an approval", func(t *testing.T) {
+		mockApprovalsDB.EXPECT().GetApprovalPolicy(ctx, realm).Return([]string{MGMTDeleteUser.String()}, nil)
+		mockApprovalsDB.EXPECT().CreateApprovalRequest(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, request dto.DBApprovalRequest) (int64, error) {
+			assert.Equal(t, MGMTDeleteUser.String(), request.Action)
+			assert.Equal(t, userID, *request.TargetID)
+			assert.Equal(t, "requester-id", request.RequesterID)
+			assert.Equal(t, dto.ApprovalStatusPending, request.Status)
+			assert.Nil(t, request.Payload)
+			return 12, nil
+		})
+		mockEventDBModule.EXPECT().ReportEvent(ctx, "API_APPROVAL_REQUESTED", "back-office", database.CtEventRealmName, realm,
+			database.CtEventAdditionalInfo, gomock.Any(), database.CtEventUserID, userID).Return(nil)
+
+		var err = component.DeleteUser(ctx, realm, userID)
+		assert.IsType(t, ApprovalRequiredError{}, err)
+		assert.Equal(t, int64(12), *err.(ApprovalRequiredError).Request.ID)
+	})
+
+	t.Run("Password reset requires an approval", func(t *testing.T) {
+		mockApprovalsDB.EXPECT().GetApprovalPolicy(ctx, realm).Return([]string{MGMTResetPassword.String()}, nil)
+		mockApprovalsDB.EXPECT().CreateApprovalRequest(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, request dto.DBApprovalRequest) (int64, error) {
+			assert.Equal(t, `{"value":"P@ssw0rd"}`, string(request.Payload))
+			return 13, nil
+		})
+		mockEventDBModule.EXPECT().ReportEvent(ctx, "API_APPROVAL_REQUESTED", "back-office", gomock.Any(), gomock.Any(),
+			gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+
+		var res, err = component.ResetPassword(ctx, realm, userID, api.PasswordRepresentation{Value: &password})
+		assert.IsType(t, ApprovalRequiredError{}, err)
+		assert.Equal(t, "", res)
+	})
+
+	t.Run("Authorizations update requires an approval", func(t *testing.T) {
+		mockApprovalsDB.EXPECT().GetApprovalPolicy(ctx, realm).Return([]string{MGMTUpdateAuthorizations.String()}, nil)
+		mockApprovalsDB.EXPECT().CreateApprovalRequest(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, request dto.DBApprovalRequest) (int64, error) {
+			assert.Equal(t, groupID, *request.TargetID)
+			return 14, nil
+		})
+		mockEventDBModule.EXPECT().ReportEvent(ctx, "API_APPROVAL_REQUESTED", "back-office", database.CtEventRealmName, realm,
+			database.CtEventAdditionalInfo, gomock.Any()).Return(nil)
+
+		assert.IsType(t, ApprovalRequiredError{}, component.UpdateAuthorizations(ctx, realm, groupID, api.AuthorizationsRepresentation{}))
+	})
+
+	t.Run("Admin configuration update does not require an approval", func(t *testing.T) {
+		mockApprovalsDB.EXPECT().GetApprovalPolicy(ctx, realm).Return([]string{}, nil)
+		mockManagementComponent.EXPECT().UpdateRealmAdminConfiguration(ctx, realm, gomock.Any()).Return(nil)
+		assert.Nil(t, component.UpdateRealmAdminConfiguration(ctx, realm, api.RealmAdminConfiguration{}))
+	})
+
+	t.Run("Rollback does not require an approval", func(t *testing.T) {
+		mockApprovalsDB.EXPECT().GetApprovalPolicy(ctx, realm).Return([]string{}, nil).Times(2)
+		mockManagementComponent.EXPECT().RollbackConfiguration(ctx, realm, int64(4)).Return(nil)
+		assert.Nil(t, component.RollbackConfiguration(ctx, realm, 4))
+	})
+
+	t.Run("Rollback requires an approval", func(t *testing.T) {
+		mockApprovalsDB.EXPECT().GetApprovalPolicy(ctx, realm).Return([]string{MGMTRollbackConfiguration.String()}, nil)
+		mockApprovalsDB.EXPECT().CreateApprovalRequest(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, request dto.DBApprovalRequest) (int64, error) {
+			assert.Equal(t, MGMTRollbackConfiguration.String(), request.Action)
+			assert.Equal(t, "4", *request.TargetID)
+			return 15, nil
+		})
+		mockEventDBModule.EXPECT().ReportEvent(ctx, "API_APPROVAL_REQUESTED", "back-office", database.CtEventRealmName, realm,
+			database.CtEventAdditionalInfo, gomock.Any()).Return(nil)
+
+		assert.IsType(t, ApprovalRequiredError{}, component.RollbackConfiguration(ctx, realm, 4))
+	})
+
+	var revisionID = int64(4)
+	var adminRevisions = []api.ConfigurationRevisionRepresentation{{ID: &revisionID}}
+	var adminPolicy = []string{MGMTUpdateRealmAdminConfiguration.String()}
+
+	t.Run("Rollback of a custom configuration when admin configuration updates require an approval", func(t *testing.T) {
+		mockApprovalsDB.EXPECT().GetApprovalPolicy(ctx, realm).Return(adminPolicy, nil).Times(2)
+		mockManagementComponent.EXPECT().GetConfigurationRevisions(ctx, realm, gomock.Any()).Return(adminRevisions, nil)
+		mockManagementComponent.EXPECT().RollbackConfiguration(ctx, realm, int64(3)).Return(nil)
+		assert.Nil(t, component.RollbackConfiguration(ctx, realm, 3))
+	})
+
+	t.Run("Can't get the admin configuration revisions", func(t *testing.T) {
+		var expectedError = errors.New("db error")
+		mockApprovalsDB.EXPECT().GetApprovalPolicy(ctx, realm).Return(adminPolicy, nil).Times(2)
+		mockManagementComponent.EXPECT().GetConfigurationRevisions(ctx, realm, gomock.Any()).Return(nil, expectedError)
+		assert.Equal(t, expectedError, component.RollbackConfiguration(ctx, realm, revisionID))
+	})
+
+	t.Run("Rollback of an admin configuration requires an approval", func(t *testing.T) {
+		mockApprovalsDB.EXPECT().GetApprovalPolicy(ctx, realm).Return(adminPolicy, nil).Times(2)
+		mockManagementComponent.EXPECT().GetConfigurationRevisions(ctx, realm, gomock.Any()).DoAndReturn(func(_ context.Context, _ string, configType *string) ([]api.ConfigurationRevisionRepresentation, error) {
+			assert.Equal(t, dto.ConfigurationTypeAdmin, *configType)
+			return adminRevisions, nil
+		})
+		mockApprovalsDB.EXPECT().CreateApprovalRequest(ctx, gomock.Any()).Return(int64(16), nil)
+		mockEventDBModule.EXPECT().ReportEvent(ctx, "API_APPROVAL_REQUESTED", "back-office", database.CtEventRealmName, realm,
+			database.CtEventAdditionalInfo, gomock.Any()).Return(nil)
+
+		assert.IsType(t, ApprovalRequiredError{}, component.RollbackConfiguration(ctx, realm, revisionID))
+	})
+
+	t.Run("Other calls are forwarded", func(t *testing.T) {
+		mockManagementComponent.EXPECT().LockUser(ctx, realm, userID).Return(nil)
+		assert.Nil(t, component.LockUser(ctx, realm, userID))
+	})
+}
+
+func TestApprovalPolicy(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+	var mockManagementComponent = mock.NewManagementComponent(mockCtrl)
+	var mockApprovalsDB = mock.NewApprovalsDBModule(mockCtrl)
+	var mockAuthManager = mock.NewAuthorizationManager(mockCtrl)
+	var mockEventDBModule = mock.NewEventDBModule(mockCtrl)
+
+	var component = NewApprovalComponent(mockManagementComponent, mockApprovalsDB, mockAuthManager, nil, mockEventDBModule, log.NewNopLogger())
+
+	var ctx = createApprovalContext("requester-id", "requester")
+	var realm = "DEP"
+	var policy = api.ApprovalPolicyRepresentation{Actions: []string{MGMTDeleteUser.String()}}
+
+	t.Run("Get policy", func(t *testing.T) {
+		mockApprovalsDB.EXPECT().GetApprovalPolicy(ctx, realm).Return([]string{MGMTDeleteUser.String()}, nil)
+		var res, err = component.GetApprovalPolicy(ctx, realm)
+		assert.Nil(t, err)
+		assert.Equal(t, policy, res)
+	})
+
+	t.Run("Get policy fails", func(t *testing.T) {
+		mockApprovalsDB.EXPECT().GetApprovalPolicy(ctx, realm).Return(nil, errors.New("db error"))
+		var _, err = component.GetApprovalPolicy(ctx, realm)
+		assert.NotNil(t, err)
+	})
+
+	t.Run("Action can't require an approval", func(t *testing.T) {
+		var err = component.UpdateApprovalPolicy(ctx, realm, api.ApprovalPolicyRepresentation{Actions: []string{MGMTGetUser.String()}})
+		assert.IsType(t, errorhandler.Error{}, err)
+		assert.Equal(t, http.StatusBadRequest, err.(errorhandler.Error).Status)
+	})
+
+	t.Run("Policy is updated", func(t *testing.T) {
+		mockApprovalsDB.EXPECT().GetApprovalPolicy(ctx, realm).Return([]string{}, nil)
+		mockApprovalsDB.EXPECT().UpdateApprovalPolicy(ctx, realm, policy.Actions).Return(nil)
+		mockEventDBModule.EXPECT().ReportEvent(ctx, "API_APPROVAL_POLICY_UPDATE", "back-office", database.CtEventRealmName, realm).Return(nil)
+		assert.Nil(t, component.UpdateApprovalPolicy(ctx, realm, policy))
+	})
+
+	t.Run("Policy update requires an approval", func(t *testing.T) {
+		mockApprovalsDB.EXPECT().GetApprovalPolicy(ctx, realm).Return([]string{MGMTUpdateApprovalPolicy.String()}, nil)
+		mockApprovalsDB.EXPECT().CreateApprovalRequest(ctx, gomock.Any()).Return(int64(3), nil)
+		mockEventDBModule.EXPECT().ReportEvent(ctx, "API_APPROVAL_REQUESTED", "back-office", database.CtEventRealmName, realm,
+			database.CtEventAdditionalInfo, gomock.Any()).Return(nil)
+		assert.IsType(t, ApprovalRequiredError{}, component.UpdateApprovalPolicy(ctx, realm, policy))
+	})
+}
+
+func TestGetApprovalRequests(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+	var mockApprovalsDB = mock.NewApprovalsDBModule(mockCtrl)
+
+	var component = NewApprovalComponent(nil, mockApprovalsDB, nil, nil, nil, log.NewNopLogger())
+
+	var ctx = context.TODO()
+	var realm = "DEP"
+	var status = dto.ApprovalStatusPending
+
+	t.Run("Can't get requests", func(t *testing.T) {
+		mockApprovalsDB.EXPECT().GetApprovalRequests(ctx, realm, &status).Return(nil, errors.New("db error"))
+		var _, err = component.GetApprovalRequests(ctx, realm, &status)
+		assert.NotNil(t, err)
+	})
+
+	t.Run("Get requests", func(t *testing.T) {
+		mockApprovalsDB.EXPECT().GetApprovalRequests(ctx, realm, &status).Return([]dto.DBApprovalRequest{{ID: 4, Action: "MGMT_DeleteUser"}}, nil)
+		var res, err = component.GetApprovalRequests(ctx, realm, &status)
+		assert.Nil(t, err)
+		assert.Len(t, res, 1)
+		assert.Equal(t, int64(4), *res[0].ID)
+	})
+}
+
+func TestApproveRequest(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+	var mockManagementComponent = mock.NewManagementComponent(mockCtrl)
+	var mockApprovalsDB = mock.NewApprovalsDBModule(mockCtrl)
+	var mockAuthManager = mock.NewAuthorizationManager(mockCtrl)
+	var mockEventDBModule = mock.NewEventDBModule(mockCtrl)
+	var mockEmailSender = mock.NewEmailSender(mockCtrl)
+
+	var component = NewApprovalComponent(mockManagementComponent, mockApprovalsDB, mockAuthManager, mockEmailSender, mockEventDBModule, log.NewNopLogger())
+
+	var ctx = createApprovalContext("approver-id", "approver")
+	var realm = "DEP"
+	var requestID = int64(12)
+	var userID = "f467ed7c-0a1d-4eee-9bb8-669c6f89c0ee"
+	var comment = "checked by phone"
+	var decision = api.ApprovalDecisionRepresentation{Comment: &comment}
+	var newRequest = func(action string, payload string) *dto.DBApprovalRequest {
+		var request = &dto.DBApprovalRequest{
+			ID:          requestID,
+			RealmName:   realm,
+			Action:      action,
+			TargetID:    &userID,
+			RequesterID: "requester-id",
+			Status:      dto.ApprovalStatusPending,
+		}
+		if payload != "" {
+			request.Payload = []byte(payload)
+		}
+		return request
+	}
+
+	t.Run("Can't get request", func(t *testing.T) {
+		var expectedError = errors.New("db error")
+		mockApprovalsDB.EXPECT().GetApprovalRequest(ctx, realm, requestID).Return(nil, expectedError)
+		var _, err = component.ApproveRequest(ctx, realm, requestID, decision)
+		assert.Equal(t, expectedError, err)
+	})
+
+	t.Run("Request not found", func(t *testing.T) {
+		mockApprovalsDB.EXPECT().GetApprovalRequest(ctx, realm, requestID).Return(nil, nil)
+		var _, err = component.ApproveRequest(ctx, realm, requestID, decision)
+		assert.Equal(t, http.StatusNotFound, err.(errorhandler.Error).Status)
+	})
+
+	t.Run("Requester can't approve their own request", func(t *testing.T) {
+		var request = newRequest(MGMTDeleteUser.String(), "")
+		request.RequesterID = "approver-id"
+		mockApprovalsDB.EXPECT().GetApprovalRequest(ctx, realm, requestID).Return(request, nil)
+		var _, err = component.ApproveRequest(ctx, realm, requestID, decision)
+		assert.Equal(t, http.StatusForbidden, err.(errorhandler.Error).Status)
+	})
+
+	t.Run("Request already decided", func(t *testing.T) {
+		var request = newRequest(MGMTDeleteUser.String(), "")
+		request.Status = dto.ApprovalStatusRejected
+		mockApprovalsDB.EXPECT().GetApprovalRequest(ctx, realm, requestID).Return(request, nil)
+		var _, err = component.ApproveRequest(ctx, realm, requestID, decision)
+		assert.Equal(t, http.StatusConflict, err.(errorhandler.Error).Status)
+	})
+
+	t.Run("Approver is not allowed to execute the action", func(t *testing.T) {
+		var expectedError = errors.New("forbidden")
+		mockApprovalsDB.EXPECT().GetApprovalRequest(ctx, realm, requestID).Return(newRequest(MGMTDeleteUser.String(), ""), nil)
+		mockAuthManager.EXPECT().CheckAuthorizationOnTargetUser(ctx, MGMTDeleteUser.String(), realm, userID).Return(expectedError)
+		var _, err = component.ApproveRequest(ctx, realm, requestID, decision)
+		assert.Equal(t, expectedError, err)
+	})
+
+	t.Run("Request decided in the meantime", func(t *testing.T) {
+		mockApprovalsDB.EXPECT().GetApprovalRequest(ctx, realm, requestID).Return(newRequest(MGMTDeleteUser.String(), ""), nil)
+		mockAuthManager.EXPECT().CheckAuthorizationOnTargetUser(ctx, MGMTDeleteUser.String(), realm, userID).Return(nil)
+		mockApprovalsDB.EXPECT().UpdateApprovalRequestStatus(ctx, gomock.Any(), dto.ApprovalStatusPending).Return(false, nil)
+		var _, err = component.ApproveRequest(ctx, realm, requestID, decision)
+		assert.Equal(t, http.StatusConflict, err.(errorhandler.Error).Status)
+	})
+
+	t.Run("Execution fails", func(t *testing.T) {
+		var expectedError = errors.New("kc error")
+		mockApprovalsDB.EXPECT().GetApprovalRequest(ctx, realm, requestID).Return(newRequest(MGMTDeleteUser.String(), ""), nil)
+		mockAuthManager.EXPECT().CheckAuthorizationOnTargetUser(ctx, MGMTDeleteUser.String(), realm, userID).Return(nil)
+		mockApprovalsDB.EXPECT().UpdateApprovalRequestStatus(ctx, gomock.Any(), dto.ApprovalStatusPending).Return(true, nil)
+		mockManagementComponent.EXPECT().DeleteUser(ctx, realm, userID).Return(expectedError)
+		mockApprovalsDB.EXPECT().UpdateApprovalRequestStatus(ctx, gomock.Any(), dto.ApprovalStatusApproved).DoAndReturn(
+			func(_ context.Context, request dto.DBApprovalRequest, _ string) (bool, error) {
+				assert.Equal(t, dto.ApprovalStatusFailed, request.Status)
+				return true, nil
+			})
+		mockEventDBModule.EXPECT().ReportEvent(ctx, "API_APPROVAL_FAILED", "back-office", gomock.Any(), gomock.Any(),
+			gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+		var _, err = component.ApproveRequest(ctx, realm, requestID, decision)
+		assert.Equal(t, expectedError, err)
+	})
+
+	t.Run("User deletion is approved", func(t *testing.T) {
+		mockApprovalsDB.EXPECT().GetApprovalRequest(ctx, realm, requestID).Return(newRequest(MGMTDeleteUser.String(), ""), nil)
+		mockAuthManager.EXPECT().CheckAuthorizationOnTargetUser(ctx, MGMTDeleteUser.String(), realm, userID).Return(nil)
+		mockApprovalsDB.EXPECT().UpdateApprovalRequestStatus(ctx, gomock.Any(), dto.ApprovalStatusPending).DoAndReturn(
+			func(_ context.Context, request dto.DBApprovalRequest, _ string) (bool, error) {
+				assert.Equal(t, dto.ApprovalStatusApproved, request.Status)
+				assert.Equal(t, "approver-id", *request.DeciderID)
+				assert.Equal(t, comment, *request.Comment)
+				return true, nil
+			})
+		mockManagementComponent.EXPECT().DeleteUser(ctx, realm, userID).Return(nil)
+		mockEventDBModule.EXPECT().ReportEvent(ctx, "API_APPROVAL_APPROVED", "back-office", database.CtEventRealmName, realm,
+			database.CtEventAdditionalInfo, gomock.Any(), database.CtEventUserID, userID).Return(nil)
+
+		var res, err = component.ApproveRequest(ctx, realm, requestID, decision)
+		assert.Nil(t, err)
+		assert.Equal(t, dto.ApprovalStatusApproved, *res.Status)
+	})
+
+	t.Run("Password reset with a given password is approved", func(t *testing.T) {
+		var password = "P@ssw0rd1234"
+		mockApprovalsDB.EXPECT().GetApprovalRequest(ctx, realm, requestID).Return(newRequest(MGMTResetPassword.String(), `{"value":"P@ssw0rd1234"}`), nil)
+		mockAuthManager.EXPECT().CheckAuthorizationOnTargetUser(ctx, MGMTResetPassword.String(), realm, userID).Return(nil)
+		mockApprovalsDB.EXPECT().UpdateApprovalRequestStatus(ctx, gomock.Any(), dto.ApprovalStatusPending).Return(true, nil)
+		mockManagementComponent.EXPECT().ResetPassword(ctx, realm, userID, api.PasswordRepresentation{Value: &password}).Return("", nil)
+		mockEventDBModule.EXPECT().ReportEvent(ctx, "API_APPROVAL_APPROVED", "back-office", gomock.Any(), gomock.Any(),
+			gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+
+		var res, err = component.ApproveRequest(ctx, realm, requestID, decision)
+		assert.Nil(t, err)
+		assert.Nil(t, res.Parameters)
+	})
+
+	t.Run("Password can't be generated for a user without email", func(t *testing.T) {
+		mockApprovalsDB.EXPECT().GetApprovalRequest(ctx, realm, requestID).Return(newRequest(MGMTResetPassword.String(), `{}`), nil)
+		mockAuthManager.EXPECT().CheckAuthorizationOnTargetUser(ctx, MGMTResetPassword.String(), realm, userID).Return(nil)
+		mockApprovalsDB.EXPECT().UpdateApprovalRequestStatus(ctx, gomock.Any(), dto.ApprovalStatusPending).Return(true, nil)
+		mockManagementComponent.EXPECT().GetUser(ctx, realm, userID).Return(api.UserRepresentation{}, nil)
+		mockApprovalsDB.EXPECT().UpdateApprovalRequestStatus(ctx, gomock.Any(), dto.ApprovalStatusApproved).Return(true, nil)
+		mockEventDBModule.EXPECT().ReportEvent(ctx, "API_APPROVAL_FAILED", "back-office", gomock.Any(), gomock.Any(),
+			gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+
+		var _, err = component.ApproveRequest(ctx, realm, requestID, decision)
+		assert.Equal(t, http.StatusBadRequest, err.(errorhandler.Error).Status)
+	})
+
+	t.Run("Generated password is sent to the user, not to the approver", func(t *testing.T) {
+		var email = "jdoe@example.com"
+		mockApprovalsDB.EXPECT().GetApprovalRequest(ctx, realm, requestID).Return(newRequest(MGMTResetPassword.String(), `{}`), nil)
+		mockAuthManager.EXPECT().CheckAuthorizationOnTargetUser(ctx, MGMTResetPassword.String(), realm, userID).Return(nil)
+		mockApprovalsDB.EXPECT().UpdateApprovalRequestStatus(ctx, gomock.Any(), dto.ApprovalStatusPending).Return(true, nil)
+		mockManagementComponent.EXPECT().GetUser(ctx, realm, userID).Return(api.UserRepresentation{Email: &email}, nil)
+		mockManagementComponent.EXPECT().ResetPassword(ctx, realm, userID, api.PasswordRepresentation{}).Return("generated", nil)
+		mockEmailSender.EXPECT().SendEmail(ctx, realm, gomock.Any()).DoAndReturn(func(_ context.Context, _ string, mail keycloakb.Email) error {
+			assert.Equal(t, email, mail.Recipient)
+			assert.Equal(t, emailTemplateApprovedPassword, mail.Template)
+			assert.Equal(t, "generated", mail.Attributes["password"])
+			return nil
+		})
+		mockEventDBModule.EXPECT().ReportEvent(ctx, "API_APPROVAL_APPROVED", "back-office", gomock.Any(), gomock.Any(),
+			gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+
+		var res, err = component.ApproveRequest(ctx, realm, requestID, decision)
+		assert.Nil(t, err)
+		assert.Nil(t, res.Parameters)
+	})
+
+	t.Run("Generated password can't be sent", func(t *testing.T) {
+		var email = "jdoe@example.com"
+		var expectedError = errors.New("smtp error")
+		mockApprovalsDB.EXPECT().GetApprovalRequest(ctx, realm, requestID).Return(newRequest(MGMTResetPassword.String(), `{}`), nil)
+		mockAuthManager.EXPECT().CheckAuthorizationOnTargetUser(ctx, MGMTResetPassword.String(), realm, userID).Return(nil)
+		mockApprovalsDB.EXPECT().UpdateApprovalRequestStatus(ctx, gomock.Any(), dto.ApprovalStatusPending).Return(true, nil)
+		mockManagementComponent.EXPECT().GetUser(ctx, realm, userID).Return(api.UserRepresentation{Email: &email}, nil)
+		mockManagementComponent.EXPECT().ResetPassword(ctx, realm, userID, api.PasswordRepresentation{}).Return("generated", nil)
+		mockEmailSender.EXPECT().SendEmail(ctx, realm, gomock.Any()).Return(expectedError)
+		mockApprovalsDB.EXPECT().UpdateApprovalRequestStatus(ctx, gomock.Any(), dto.ApprovalStatusApproved).Return(true, nil)
+		mockEventDBModule.EXPECT().ReportEvent(ctx, "API_APPROVAL_FAILED", "back-office", gomock.Any(), gomock.Any(),
+			gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+
+		var _, err = component.ApproveRequest(ctx, realm, requestID, decision)
+		assert.Equal(t, expectedError, err)
+	})
+
+	t.Run("Admin configuration update is approved", func(t *testing.T) {
+		var request = newRequest(MGMTUpdateRealmAdminConfiguration.String(), `{"mode":"trustID"}`)
+		request.TargetID = nil
+		var mode = "trustID"
+		mockApprovalsDB.EXPECT().GetApprovalRequest(ctx, realm, requestID).Return(request, nil)
+		mockAuthManager.EXPECT().CheckAuthorizationOnTargetRealm(ctx, MGMTUpdateRealmAdminConfiguration.String(), realm).Return(nil)
+		mockApprovalsDB.EXPECT().UpdateApprovalRequestStatus(ctx, gomock.Any(), dto.ApprovalStatusPending).Return(true, nil)
+		mockManagementComponent.EXPECT().UpdateRealmAdminConfiguration(ctx, realm, api.RealmAdminConfiguration{Mode: &mode}).Return(nil)
+		mockEventDBModule.EXPECT().ReportEvent(ctx, "API_APPROVAL_APPROVED", "back-office", database.CtEventRealmName, realm,
+			database.CtEventAdditionalInfo, gomock.Any()).Return(nil)
+
+		var res, err = component.ApproveRequest(ctx, realm, requestID, decision)
+		assert.Nil(t, err)
+		// The parameters are not kept once the request is decided
+		assert.Nil(t, res.Parameters)
+	})
+
+	t.Run("Rollback is approved", func(t *testing.T) {
+		var request = newRequest(MGMTRollbackConfiguration.String(), "")
+		var revisionID = "4"
+		request.TargetID = &revisionID
+		mockApprovalsDB.EXPECT().GetApprovalRequest(ctx, realm, requestID).Return(request, nil)
+		mockAuthManager.EXPECT().CheckAuthorizationOnTargetRealm(ctx, MGMTRollbackConfiguration.String(), realm).Return(nil)
+		mockApprovalsDB.EXPECT().UpdateApprovalRequestStatus(ctx, gomock.Any(), dto.ApprovalStatusPending).Return(true, nil)
+		mockManagementComponent.EXPECT().RollbackConfiguration(ctx, realm, int64(4)).Return(nil)
+		mockEventDBModule.EXPECT().ReportEvent(ctx, "API_APPROVAL_APPROVED", "back-office", database.CtEventRealmName, realm,
+			database.CtEventAdditionalInfo, gomock.Any()).Return(nil)
+
+		var _, err = component.ApproveRequest(ctx, realm, requestID, decision)
+		assert.Nil(t, err)
+	})
+}
+
+func TestRejectRequest(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+	var mockApprovalsDB = mock.NewApprovalsDBModule(mockCtrl)
+	var mockEventDBModule = mock.NewEventDBModule(mockCtrl)
+
+	var component = NewApprovalComponent(nil, mockApprovalsDB, nil, nil, mockEventDBModule, log.NewNopLogger())
+
+	var ctx = createApprovalContext("approver-id", "approver")
+	var realm = "DEP"
+	var requestID = int64(12)
+	var decision = api.ApprovalDecisionRepresentation{}
+	var newRequest = func() *dto.DBApprovalRequest {
+		return &dto.DBApprovalRequest{ID: requestID, RealmName: realm, Action: MGMTUpdateRealmAdminConfiguration.String(),
+			RequesterID: "requester-id", Status: dto.ApprovalStatusPending}
+	}
+
+	t.Run("Can't store decision", func(t *testing.T) {
+		var expectedError = errors.New("db error")
+		mockApprovalsDB.EXPECT().GetApprovalRequest(ctx, realm, requestID).Return(newRequest(), nil)
+		mockApprovalsDB.EXPECT().UpdateApprovalRequestStatus(ctx, gomock.Any(), dto.ApprovalStatusPending).Return(false, expectedError)
+		assert.Equal(t, expectedError, component.RejectRequest(ctx, realm, requestID, decision))
+	})
+
+	t.Run("Request is rejected", func(t *testing.T) {
+		mockApprovalsDB.EXPECT().GetApprovalRequest(ctx, realm, requestID).Return(newRequest(), nil)
+		mockApprovalsDB.EXPECT().UpdateApprovalRequestStatus(ctx, gomock.Any(), dto.ApprovalStatusPending).DoAndReturn(
+			func(_ context.Context, request dto.DBApprovalRequest, _ string) (bool, error) {
+				assert.Equal(t, dto.ApprovalStatusRejected, request.Status)
+				return true, nil
+			})
+		mockEventDBModule.EXPECT().ReportEvent(ctx, "API_APPROVAL_REJECTED", "back-office", database.CtEventRealmName, realm,
+			database.CtEventAdditionalInfo, gomock.Any()).Return(nil)
+		assert.Nil(t, component.RejectRequest(ctx, realm, requestID, decision))
+	})
+}
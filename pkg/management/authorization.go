@@ -2,6 +2,7 @@ package management
 
 import (
 	"context"
+	"time"
 
 	cs "github.com/cloudtrust/common-service"
 	"github.com/cloudtrust/common-service/log"
@@ -74,6 +75,61 @@ var (
 	MGMTUpdateRealmBackOfficeConfiguration  = newAction("MGMT_UpdateRealmBackOfficeConfiguration", security.ScopeGroup)
 	MGMTGetUserRealmBackOfficeConfiguration = newAction("MGMT_GetUserRealmBackOfficeConfiguration", security.ScopeRealm)
 	MGMTLinkShadowUser                      = newAction("MGMT_LinkShadowUser", security.ScopeRealm)
+	MGMTGetStatisticsReportSchedules        = newAction("MGMT_GetStatisticsReportSchedules", security.ScopeRealm)
+	MGMTCreateStatisticsReportSchedule      = newAction("MGMT_CreateStatisticsReportSchedule", security.ScopeRealm)
+	MGMTUpdateStatisticsReportSchedule      = newAction("MGMT_UpdateStatisticsReportSchedule", security.ScopeRealm)
+	MGMTDeleteStatisticsReportSchedule      = newAction("MGMT_DeleteStatisticsReportSchedule", security.ScopeRealm)
+	MGMTImportUsers                         = newAction("MGMT_ImportUsers", security.ScopeRealm)
+	MGMTExportUsers                         = newAction("MGMT_ExportUsers", security.ScopeGroup)
+	MGMTExportUsersDetails                  = newAction("MGMT_ExportUsersDetails", security.ScopeGroup)
+	MGMTApplyBulkOperation                  = newAction("MGMT_ApplyBulkOperation", security.ScopeRealm)
+	MGMTGetBulkJob                          = newAction("MGMT_GetBulkJob", security.ScopeRealm)
+	MGMTSearchUsers                         = newAction("MGMT_SearchUsers", security.ScopeGroup)
+	MGMTIndexUsers                          = newAction("MGMT_IndexUsers", security.ScopeRealm)
+	MGMTRestoreUser                         = newAction("MGMT_RestoreUser", security.ScopeGroup)
+	MGMTGetApprovalPolicy                   = newAction("MGMT_GetApprovalPolicy", security.ScopeRealm)
+	MGMTUpdateApprovalPolicy                = newAction("MGMT_UpdateApprovalPolicy", security.ScopeRealm)
+	MGMTGetApprovalRequests                 = newAction("MGMT_GetApprovalRequests", security.ScopeRealm)
+	MGMTApproveRequest                      = newAction("MGMT_ApproveRequest", security.ScopeRealm)
+	MGMTRejectRequest                       = newAction("MGMT_RejectRequest", security.ScopeRealm)
+	MGMTGetScheduledUserChanges             = newAction("MGMT_GetScheduledUserChanges", security.ScopeGroup)
+	MGMTScheduleUserChange                  = newAction("MGMT_ScheduleUserChange", security.ScopeGroup)
+	MGMTCancelScheduledUserChange           = newAction("MGMT_CancelScheduledUserChange", security.ScopeGroup)
+	MGMTCreateSubGroup                      = newAction("MGMT_CreateSubGroup", security.ScopeGroup)
+	MGMTUpdateGroup                         = newAction("MGMT_UpdateGroup", security.ScopeGroup)
+	MGMTMoveGroup                           = newAction("MGMT_MoveGroup", security.ScopeGroup)
+	MGMTCreateRole                          = newAction("MGMT_CreateRole", security.ScopeRealm)
+	MGMTUpdateRole                          = newAction("MGMT_UpdateRole", security.ScopeRealm)
+	MGMTDeleteRole                          = newAction("MGMT_DeleteRole", security.ScopeRealm)
+	MGMTAddRoleToUser                       = newAction("MGMT_AddRoleToUser", security.ScopeGroup)
+	MGMTDeleteRoleForUser                   = newAction("MGMT_DeleteRoleForUser", security.ScopeGroup)
+	MGMTDeleteClientRoleForUser             = newAction("MGMT_DeleteClientRoleForUser", security.ScopeGroup)
+	MGMTGetUserSessions                     = newAction("MGMT_GetUserSessions", security.ScopeGroup)
+	MGMTRevokeUserSession                   = newAction("MGMT_RevokeUserSession", security.ScopeGroup)
+	MGMTRevokeUserSessions                  = newAction("MGMT_RevokeUserSessions", security.ScopeGroup)
+	MGMTImpersonateUser                     = newAction("MGMT_ImpersonateUser", security.ScopeGroup)
+	MGMTCreateClient                        = newAction("MGMT_CreateClient", security.ScopeRealm)
+	MGMTUpdateClient                        = newAction("MGMT_UpdateClient", security.ScopeRealm)
+	MGMTDeleteClient                        = newAction("MGMT_DeleteClient", security.ScopeRealm)
+	MGMTRegenerateClientSecret              = newAction("MGMT_RegenerateClientSecret", security.ScopeRealm)
+	MGMTUpdateClientRedirectURIs            = newAction("MGMT_UpdateClientRedirectURIs", security.ScopeRealm)
+	MGMTGetFederatedIdentities              = newAction("MGMT_GetFederatedIdentities", security.ScopeGroup)
+	MGMTDeleteFederatedIdentity             = newAction("MGMT_DeleteFederatedIdentity", security.ScopeGroup)
+	MGMTGetIdentityProviders                = newAction("MGMT_GetIdentityProviders", security.ScopeRealm)
+	MGMTCreateIdentityProvider              = newAction("MGMT_CreateIdentityProvider", security.ScopeRealm)
+	MGMTUpdateIdentityProvider              = newAction("MGMT_UpdateIdentityProvider", security.ScopeRealm)
+	MGMTDisableIdentityProvider             = newAction("MGMT_DisableIdentityProvider", security.ScopeRealm)
+	MGMTGetPasswordPolicy                   = newAction("MGMT_GetPasswordPolicy", security.ScopeRealm)
+	MGMTUpdatePasswordPolicy                = newAction("MGMT_UpdatePasswordPolicy", security.ScopeRealm)
+	MGMTUpdateLabelCredentialForUser        = newAction("MGMT_UpdateLabelCredentialForUser", security.ScopeGroup)
+	MGMTMoveCredentialForUser               = newAction("MGMT_MoveCredentialForUser", security.ScopeGroup)
+	MGMTGetCredentialRegistratorsForUser    = newAction("MGMT_GetCredentialRegistratorsForUser", security.ScopeGroup)
+	MGMTRequireReEnrollment                 = newAction("MGMT_RequireReEnrollment", security.ScopeGroup)
+	MGMTGetConfigurationRevisions           = newAction("MGMT_GetConfigurationRevisions", security.ScopeRealm)
+	MGMTRollbackConfiguration               = newAction("MGMT_RollbackConfiguration", security.ScopeRealm)
+	MGMTProvisionRealm                      = newAction("MGMT_ProvisionRealm", security.ScopeRealm)
+	MGMTGetUserAuthorizationsReport         = newAction("MGMT_GetUserAuthorizationsReport", security.ScopeGroup)
+	MGMTGetTargetAuthorizationsReport       = newAction("MGMT_GetTargetAuthorizationsReport", security.ScopeGroup)
 )
 
 // Tracking middleware at component level.
@@ -161,6 +217,61 @@ func (c *authorizationComponentMW) GetClients(ctx context.Context, realmName str
 	return c.next.GetClients(ctx, realmName)
 }
 
+func (c *authorizationComponentMW) CreateClient(ctx context.Context, realmName string, client api.ClientRepresentation) (string, error) {
+	var action = MGMTCreateClient.String()
+	var targetRealm = realmName
+
+	if err := c.authManager.CheckAuthorizationOnTargetRealm(ctx, action, targetRealm); err != nil {
+		return "", err
+	}
+
+	return c.next.CreateClient(ctx, realmName, client)
+}
+
+func (c *authorizationComponentMW) UpdateClient(ctx context.Context, realmName, idClient string, client api.ClientRepresentation) error {
+	var action = MGMTUpdateClient.String()
+	var targetRealm = realmName
+
+	if err := c.authManager.CheckAuthorizationOnTargetRealm(ctx, action, targetRealm); err != nil {
+		return err
+	}
+
+	return c.next.UpdateClient(ctx, realmName, idClient, client)
+}
+
+func (c *authorizationComponentMW) DeleteClient(ctx context.Context, realmName, idClient string) error {
+	var action = MGMTDeleteClient.String()
+	var targetRealm = realmName
+
+	if err := c.authManager.CheckAuthorizationOnTargetRealm(ctx, action, targetRealm); err != nil {
+		return err
+	}
+
+	return c.next.DeleteClient(ctx, realmName, idClient)
+}
+
+func (c *authorizationComponentMW) RegenerateClientSecret(ctx context.Context, realmName, idClient string) (api.ClientSecretRepresentation, error) {
+	var action = MGMTRegenerateClientSecret.String()
+	var targetRealm = realmName
+
+	if err := c.authManager.CheckAuthorizationOnTargetRealm(ctx, action, targetRealm); err != nil {
+		return api.ClientSecretRepresentation{}, err
+	}
+
+	return c.next.RegenerateClientSecret(ctx, realmName, idClient)
+}
+
+func (c *authorizationComponentMW) UpdateClientRedirectURIs(ctx context.Context, realmName, idClient string, redirectURIs []string) error {
+	var action = MGMTUpdateClientRedirectURIs.String()
+	var targetRealm = realmName
+
+	if err := c.authManager.CheckAuthorizationOnTargetRealm(ctx, action, targetRealm); err != nil {
+		return err
+	}
+
+	return c.next.UpdateClientRedirectURIs(ctx, realmName, idClient, redirectURIs)
+}
+
 func (c *authorizationComponentMW) GetRequiredActions(ctx context.Context, realmName string) ([]api.RequiredActionRepresentation, error) {
 	var action = MGMTGetRequiredActions.String()
 	var targetRealm = realmName
@@ -275,6 +386,28 @@ func (c *authorizationComponentMW) GetRolesOfUser(ctx context.Context, realmName
 	return c.next.GetRolesOfUser(ctx, realmName, userID)
 }
 
+func (c *authorizationComponentMW) AddRoleToUser(ctx context.Context, realmName, userID string, roleID string) error {
+	var action = MGMTAddRoleToUser.String()
+	var targetRealm = realmName
+
+	if err := c.authManager.CheckAuthorizationOnTargetUser(ctx, action, targetRealm, userID); err != nil {
+		return err
+	}
+
+	return c.next.AddRoleToUser(ctx, realmName, userID, roleID)
+}
+
+func (c *authorizationComponentMW) DeleteRoleForUser(ctx context.Context, realmName, userID string, roleID string) error {
+	var action = MGMTDeleteRoleForUser.String()
+	var targetRealm = realmName
+
+	if err := c.authManager.CheckAuthorizationOnTargetUser(ctx, action, targetRealm, userID); err != nil {
+		return err
+	}
+
+	return c.next.DeleteRoleForUser(ctx, realmName, userID, roleID)
+}
+
 func (c *authorizationComponentMW) GetGroupsOfUser(ctx context.Context, realmName, userID string) ([]api.GroupRepresentation, error) {
 	var action = MGMTGetGroupsOfUser.String()
 	var targetRealm = realmName
@@ -373,6 +506,17 @@ func (c *authorizationComponentMW) AddClientRolesToUser(ctx context.Context, rea
 	return c.next.AddClientRolesToUser(ctx, realmName, userID, clientID, roles)
 }
 
+func (c *authorizationComponentMW) DeleteClientRoleForUser(ctx context.Context, realmName, userID, clientID string, roleID string) error {
+	var action = MGMTDeleteClientRoleForUser.String()
+	var targetRealm = realmName
+
+	if err := c.authManager.CheckAuthorizationOnTargetUser(ctx, action, targetRealm, userID); err != nil {
+		return err
+	}
+
+	return c.next.DeleteClientRoleForUser(ctx, realmName, userID, clientID, roleID)
+}
+
 func (c *authorizationComponentMW) ResetPassword(ctx context.Context, realmName string, userID string, password api.PasswordRepresentation) (string, error) {
 	var action = MGMTResetPassword.String()
 	var targetRealm = realmName
@@ -472,6 +616,50 @@ func (c *authorizationComponentMW) ResetCredentialFailuresForUser(ctx context.Co
 	return c.next.ResetCredentialFailuresForUser(ctx, realmName, userID, credentialID)
 }
 
+func (c *authorizationComponentMW) UpdateLabelCredentialForUser(ctx context.Context, realmName string, userID string, credentialID string, label string) error {
+	var action = MGMTUpdateLabelCredentialForUser.String()
+	var targetRealm = realmName
+
+	if err := c.authManager.CheckAuthorizationOnTargetUser(ctx, action, targetRealm, userID); err != nil {
+		return err
+	}
+
+	return c.next.UpdateLabelCredentialForUser(ctx, realmName, userID, credentialID, label)
+}
+
+func (c *authorizationComponentMW) MoveCredentialForUser(ctx context.Context, realmName string, userID string, credentialID string, previousCredentialID string) error {
+	var action = MGMTMoveCredentialForUser.String()
+	var targetRealm = realmName
+
+	if err := c.authManager.CheckAuthorizationOnTargetUser(ctx, action, targetRealm, userID); err != nil {
+		return err
+	}
+
+	return c.next.MoveCredentialForUser(ctx, realmName, userID, credentialID, previousCredentialID)
+}
+
+func (c *authorizationComponentMW) GetCredentialRegistratorsForUser(ctx context.Context, realmName string, userID string) ([]string, error) {
+	var action = MGMTGetCredentialRegistratorsForUser.String()
+	var targetRealm = realmName
+
+	if err := c.authManager.CheckAuthorizationOnTargetUser(ctx, action, targetRealm, userID); err != nil {
+		return nil, err
+	}
+
+	return c.next.GetCredentialRegistratorsForUser(ctx, realmName, userID)
+}
+
+func (c *authorizationComponentMW) RequireReEnrollment(ctx context.Context, realmName string, userID string, credentialType string) error {
+	var action = MGMTRequireReEnrollment.String()
+	var targetRealm = realmName
+
+	if err := c.authManager.CheckAuthorizationOnTargetUser(ctx, action, targetRealm, userID); err != nil {
+		return err
+	}
+
+	return c.next.RequireReEnrollment(ctx, realmName, userID, credentialType)
+}
+
 func (c *authorizationComponentMW) ClearUserLoginFailures(ctx context.Context, realmName, userID string) error {
 	var action = MGMTClearUserLoginFailures.String()
 	var targetRealm = realmName
@@ -494,6 +682,39 @@ func (c *authorizationComponentMW) GetAttackDetectionStatus(ctx context.Context,
 	return c.next.GetAttackDetectionStatus(ctx, realmName, userID)
 }
 
+func (c *authorizationComponentMW) GetUserSessions(ctx context.Context, realmName, userID string) ([]api.UserSessionRepresentation, error) {
+	var action = MGMTGetUserSessions.String()
+	var targetRealm = realmName
+
+	if err := c.authManager.CheckAuthorizationOnTargetUser(ctx, action, targetRealm, userID); err != nil {
+		return nil, err
+	}
+
+	return c.next.GetUserSessions(ctx, realmName, userID)
+}
+
+func (c *authorizationComponentMW) RevokeUserSession(ctx context.Context, realmName, userID, sessionID string) error {
+	var action = MGMTRevokeUserSession.String()
+	var targetRealm = realmName
+
+	if err := c.authManager.CheckAuthorizationOnTargetUser(ctx, action, targetRealm, userID); err != nil {
+		return err
+	}
+
+	return c.next.RevokeUserSession(ctx, realmName, userID, sessionID)
+}
+
+func (c *authorizationComponentMW) RevokeUserSessions(ctx context.Context, realmName, userID string) error {
+	var action = MGMTRevokeUserSessions.String()
+	var targetRealm = realmName
+
+	if err := c.authManager.CheckAuthorizationOnTargetUser(ctx, action, targetRealm, userID); err != nil {
+		return err
+	}
+
+	return c.next.RevokeUserSessions(ctx, realmName, userID)
+}
+
 func (c *authorizationComponentMW) GetRoles(ctx context.Context, realmName string) ([]api.RoleRepresentation, error) {
 	var action = MGMTGetRoles.String()
 	var targetRealm = realmName
@@ -516,6 +737,39 @@ func (c *authorizationComponentMW) GetRole(ctx context.Context, realmName string
 	return c.next.GetRole(ctx, realmName, roleID)
 }
 
+func (c *authorizationComponentMW) CreateRole(ctx context.Context, realmName string, role api.RoleRepresentation) (string, error) {
+	var action = MGMTCreateRole.String()
+	var targetRealm = realmName
+
+	if err := c.authManager.CheckAuthorizationOnTargetRealm(ctx, action, targetRealm); err != nil {
+		return "", err
+	}
+
+	return c.next.CreateRole(ctx, realmName, role)
+}
+
+func (c *authorizationComponentMW) UpdateRole(ctx context.Context, realmName string, roleID string, role api.RoleRepresentation) error {
+	var action = MGMTUpdateRole.String()
+	var targetRealm = realmName
+
+	if err := c.authManager.CheckAuthorizationOnTargetRealm(ctx, action, targetRealm); err != nil {
+		return err
+	}
+
+	return c.next.UpdateRole(ctx, realmName, roleID, role)
+}
+
+func (c *authorizationComponentMW) DeleteRole(ctx context.Context, realmName string, roleID string) error {
+	var action = MGMTDeleteRole.String()
+	var targetRealm = realmName
+
+	if err := c.authManager.CheckAuthorizationOnTargetRealm(ctx, action, targetRealm); err != nil {
+		return err
+	}
+
+	return c.next.DeleteRole(ctx, realmName, roleID)
+}
+
 func (c *authorizationComponentMW) GetGroups(ctx context.Context, realmName string) ([]api.GroupRepresentation, error) {
 	var action = MGMTGetGroups.String()
 	var targetRealm = realmName
@@ -538,6 +792,48 @@ func (c *authorizationComponentMW) CreateGroup(ctx context.Context, realmName st
 	return c.next.CreateGroup(ctx, realmName, group)
 }
 
+func (c *authorizationComponentMW) CreateSubGroup(ctx context.Context, realmName string, parentGroupID string, group api.GroupRepresentation) (string, error) {
+	var action = MGMTCreateSubGroup.String()
+	var targetRealm = realmName
+
+	if err := c.authManager.CheckAuthorizationOnTargetGroupID(ctx, action, targetRealm, parentGroupID); err != nil {
+		return "", err
+	}
+
+	return c.next.CreateSubGroup(ctx, realmName, parentGroupID, group)
+}
+
+func (c *authorizationComponentMW) UpdateGroup(ctx context.Context, realmName string, groupID string, group api.GroupRepresentation) error {
+	var action = MGMTUpdateGroup.String()
+	var targetRealm = realmName
+
+	if err := c.authManager.CheckAuthorizationOnTargetGroupID(ctx, action, targetRealm, groupID); err != nil {
+		return err
+	}
+
+	return c.next.UpdateGroup(ctx, realmName, groupID, group)
+}
+
+func (c *authorizationComponentMW) MoveGroup(ctx context.Context, realmName string, groupID string, parent api.GroupParentRepresentation) error {
+	var action = MGMTMoveGroup.String()
+	var targetRealm = realmName
+
+	if err := c.authManager.CheckAuthorizationOnTargetGroupID(ctx, action, targetRealm, groupID); err != nil {
+		return err
+	}
+
+	// The destination must also be allowed: moving a group to the top-level is like creating a group
+	if parent.ParentID != nil {
+		if err := c.authManager.CheckAuthorizationOnTargetGroupID(ctx, action, targetRealm, *parent.ParentID); err != nil {
+			return err
+		}
+	} else if err := c.authManager.CheckAuthorizationOnTargetRealm(ctx, MGMTCreateGroup.String(), targetRealm); err != nil {
+		return err
+	}
+
+	return c.next.MoveGroup(ctx, realmName, groupID, parent)
+}
+
 func (c *authorizationComponentMW) DeleteGroup(ctx context.Context, realmName string, groupID string) error {
 	var action = MGMTDeleteGroup.String()
 	var targetRealm = realmName
@@ -677,3 +973,585 @@ func (c *authorizationComponentMW) LinkShadowUser(ctx context.Context, realmName
 
 	return c.next.LinkShadowUser(ctx, realmName, userID, provider, fedID)
 }
+
+func (c *authorizationComponentMW) GetFederatedIdentities(ctx context.Context, realmName string, userID string) ([]api.FederatedIdentityRepresentation, error) {
+	var action = MGMTGetFederatedIdentities.String()
+	var targetRealm = realmName
+	if err := c.authManager.CheckAuthorizationOnTargetUser(ctx, action, targetRealm, userID); err != nil {
+		return []api.FederatedIdentityRepresentation{}, err
+	}
+
+	return c.next.GetFederatedIdentities(ctx, realmName, userID)
+}
+
+func (c *authorizationComponentMW) DeleteFederatedIdentity(ctx context.Context, realmName string, userID string, provider string) error {
+	var action = MGMTDeleteFederatedIdentity.String()
+	var targetRealm = realmName
+	if err := c.authManager.CheckAuthorizationOnTargetUser(ctx, action, targetRealm, userID); err != nil {
+		return err
+	}
+
+	return c.next.DeleteFederatedIdentity(ctx, realmName, userID, provider)
+}
+
+func (c *authorizationComponentMW) GetIdentityProviders(ctx context.Context, realmName string) ([]api.IdentityProviderRepresentation, error) {
+	var action = MGMTGetIdentityProviders.String()
+	var targetRealm = realmName
+	if err := c.authManager.CheckAuthorizationOnTargetRealm(ctx, action, targetRealm); err != nil {
+		return []api.IdentityProviderRepresentation{}, err
+	}
+
+	return c.next.GetIdentityProviders(ctx, realmName)
+}
+
+func (c *authorizationComponentMW) CreateIdentityProvider(ctx context.Context, realmName string, idp api.IdentityProviderRepresentation) error {
+	var action = MGMTCreateIdentityProvider.String()
+	var targetRealm = realmName
+	if err := c.authManager.CheckAuthorizationOnTargetRealm(ctx, action, targetRealm); err != nil {
+		return err
+	}
+
+	return c.next.CreateIdentityProvider(ctx, realmName, idp)
+}
+
+func (c *authorizationComponentMW) UpdateIdentityProvider(ctx context.Context, realmName string, alias string, idp api.IdentityProviderRepresentation) error {
+	var action = MGMTUpdateIdentityProvider.String()
+	var targetRealm = realmName
+	if err := c.authManager.CheckAuthorizationOnTargetRealm(ctx, action, targetRealm); err != nil {
+		return err
+	}
+
+	return c.next.UpdateIdentityProvider(ctx, realmName, alias, idp)
+}
+
+func (c *authorizationComponentMW) DisableIdentityProvider(ctx context.Context, realmName string, alias string) error {
+	var action = MGMTDisableIdentityProvider.String()
+	var targetRealm = realmName
+	if err := c.authManager.CheckAuthorizationOnTargetRealm(ctx, action, targetRealm); err != nil {
+		return err
+	}
+
+	return c.next.DisableIdentityProvider(ctx, realmName, alias)
+}
+
+func (c *authorizationComponentMW) GetPasswordPolicy(ctx context.Context, realmName string) (api.PasswordPolicyRepresentation, error) {
+	var action = MGMTGetPasswordPolicy.String()
+	var targetRealm = realmName
+	if err := c.authManager.CheckAuthorizationOnTargetRealm(ctx, action, targetRealm); err != nil {
+		return api.PasswordPolicyRepresentation{}, err
+	}
+
+	return c.next.GetPasswordPolicy(ctx, realmName)
+}
+
+func (c *authorizationComponentMW) UpdatePasswordPolicy(ctx context.Context, realmName string, policy api.PasswordPolicyRepresentation) error {
+	var action = MGMTUpdatePasswordPolicy.String()
+	var targetRealm = realmName
+	if err := c.authManager.CheckAuthorizationOnTargetRealm(ctx, action, targetRealm); err != nil {
+		return err
+	}
+
+	return c.next.UpdatePasswordPolicy(ctx, realmName, policy)
+}
+
+func (c *authorizationComponentMW) GetConfigurationRevisions(ctx context.Context, realmName string, configType *string) ([]api.ConfigurationRevisionRepresentation, error) {
+	var action = MGMTGetConfigurationRevisions.String()
+	var targetRealm = realmName
+	if err := c.authManager.CheckAuthorizationOnTargetRealm(ctx, action, targetRealm); err != nil {
+		return nil, err
+	}
+
+	return c.next.GetConfigurationRevisions(ctx, realmName, configType)
+}
+
+func (c *authorizationComponentMW) DiffConfigurationRevisions(ctx context.Context, realmName string, fromRevisionID, toRevisionID int64) ([]api.ConfigurationDiffRepresentation, error) {
+	var action = MGMTGetConfigurationRevisions.String()
+	var targetRealm = realmName
+	if err := c.authManager.CheckAuthorizationOnTargetRealm(ctx, action, targetRealm); err != nil {
+		return nil, err
+	}
+
+	return c.next.DiffConfigurationRevisions(ctx, realmName, fromRevisionID, toRevisionID)
+}
+
+func (c *authorizationComponentMW) RollbackConfiguration(ctx context.Context, realmName string, revisionID int64) error {
+	var action = MGMTRollbackConfiguration.String()
+	var targetRealm = realmName
+	if err := c.authManager.CheckAuthorizationOnTargetRealm(ctx, action, targetRealm); err != nil {
+		return err
+	}
+
+	return c.next.RollbackConfiguration(ctx, realmName, revisionID)
+}
+
+func (c *authorizationComponentMW) GetStatisticsReportSchedules(ctx context.Context, realmName string) ([]api.StatisticsReportScheduleRepresentation, error) {
+	var action = MGMTGetStatisticsReportSchedules.String()
+	var targetRealm = realmName
+
+	if err := c.authManager.CheckAuthorizationOnTargetRealm(ctx, action, targetRealm); err != nil {
+		return []api.StatisticsReportScheduleRepresentation{}, err
+	}
+
+	return c.next.GetStatisticsReportSchedules(ctx, realmName)
+}
+
+func (c *authorizationComponentMW) CreateStatisticsReportSchedule(ctx context.Context, realmName string, schedule api.StatisticsReportScheduleRepresentation) (int64, error) {
+	var action = MGMTCreateStatisticsReportSchedule.String()
+	var targetRealm = realmName
+
+	if err := c.authManager.CheckAuthorizationOnTargetRealm(ctx, action, targetRealm); err != nil {
+		return 0, err
+	}
+
+	return c.next.CreateStatisticsReportSchedule(ctx, realmName, schedule)
+}
+
+func (c *authorizationComponentMW) UpdateStatisticsReportSchedule(ctx context.Context, realmName string, scheduleID int64, schedule api.StatisticsReportScheduleRepresentation) error {
+	var action = MGMTUpdateStatisticsReportSchedule.String()
+	var targetRealm = realmName
+
+	if err := c.authManager.CheckAuthorizationOnTargetRealm(ctx, action, targetRealm); err != nil {
+		return err
+	}
+
+	return c.next.UpdateStatisticsReportSchedule(ctx, realmName, scheduleID, schedule)
+}
+
+func (c *authorizationComponentMW) DeleteStatisticsReportSchedule(ctx context.Context, realmName string, scheduleID int64) error {
+	var action = MGMTDeleteStatisticsReportSchedule.String()
+	var targetRealm = realmName
+
+	if err := c.authManager.CheckAuthorizationOnTargetRealm(ctx, action, targetRealm); err != nil {
+		return err
+	}
+
+	return c.next.DeleteStatisticsReportSchedule(ctx, realmName, scheduleID)
+}
+
+// ExportUsers needs MGMT_ExportUsersDetails in addition to MGMT_ExportUsers when decrypted user details are exported.
+// Without group, the whole realm is exported.
+func (c *authorizationComponentMW) ExportUsers(ctx context.Context, realmName string, groupIDs []string, format string, columns []string) (UsersExport, error) {
+	var actions = []string{MGMTExportUsers.String()}
+	if api.HasUserDetailsColumns(columns) {
+		actions = append(actions, MGMTExportUsersDetails.String())
+	}
+	var targetRealm = realmName
+
+	for _, action := range actions {
+		if len(groupIDs) == 0 {
+			if err := c.authManager.CheckAuthorizationOnTargetRealm(ctx, action, targetRealm); err != nil {
+				return UsersExport{}, err
+			}
+		}
+		for _, groupID := range groupIDs {
+			if err := c.authManager.CheckAuthorizationOnTargetGroupID(ctx, action, targetRealm, groupID); err != nil {
+				return UsersExport{}, err
+			}
+		}
+	}
+
+	return c.next.ExportUsers(ctx, realmName, groupIDs, format, columns)
+}
+
+type authorizationBulkComponentMW struct {
+	authManager security.AuthorizationManager
+	logger      log.Logger
+	next        BulkComponent
+}
+
+// MakeAuthorizationBulkComponentMW checks authorization on the target realm. Authorizations on the users themselves are checked by the bulk component.
+func MakeAuthorizationBulkComponentMW(logger log.Logger, authorizationManager security.AuthorizationManager) func(BulkComponent) BulkComponent {
+	return func(next BulkComponent) BulkComponent {
+		return &authorizationBulkComponentMW{
+			authManager: authorizationManager,
+			logger:      logger,
+			next:        next,
+		}
+	}
+}
+
+func (c *authorizationBulkComponentMW) ImportUsers(ctx context.Context, realmName string, users []api.UserRepresentation, dryRun bool) (api.BulkJobRepresentation, error) {
+	var action = MGMTImportUsers.String()
+	var targetRealm = realmName
+
+	if err := c.authManager.CheckAuthorizationOnTargetRealm(ctx, action, targetRealm); err != nil {
+		return api.BulkJobRepresentation{}, err
+	}
+
+	return c.next.ImportUsers(ctx, realmName, users, dryRun)
+}
+
+func (c *authorizationBulkComponentMW) ApplyBulkOperation(ctx context.Context, realmName string, operation api.BulkUserOperationRepresentation) (api.BulkJobRepresentation, error) {
+	var action = MGMTApplyBulkOperation.String()
+	var targetRealm = realmName
+
+	if err := c.authManager.CheckAuthorizationOnTargetRealm(ctx, action, targetRealm); err != nil {
+		return api.BulkJobRepresentation{}, err
+	}
+
+	return c.next.ApplyBulkOperation(ctx, realmName, operation)
+}
+
+func (c *authorizationBulkComponentMW) GetBulkJob(ctx context.Context, realmName string, jobID string) (api.BulkJobRepresentation, error) {
+	var action = MGMTGetBulkJob.String()
+	var targetRealm = realmName
+
+	if err := c.authManager.CheckAuthorizationOnTargetRealm(ctx, action, targetRealm); err != nil {
+		return api.BulkJobRepresentation{}, err
+	}
+
+	return c.next.GetBulkJob(ctx, realmName, jobID)
+}
+
+type authorizationSearchComponentMW struct {
+	authManager security.AuthorizationManager
+	logger      log.Logger
+	next        SearchComponent
+}
+
+// MakeAuthorizationSearchComponentMW checks authorization and return an error if the action is not allowed.
+func MakeAuthorizationSearchComponentMW(logger log.Logger, authorizationManager security.AuthorizationManager) func(SearchComponent) SearchComponent {
+	return func(next SearchComponent) SearchComponent {
+		return &authorizationSearchComponentMW{
+			authManager: authorizationManager,
+			logger:      logger,
+			next:        next,
+		}
+	}
+}
+
+// SearchUsers checks the authorization on each searched group, or on the realm when the whole realm is searched
+func (c *authorizationSearchComponentMW) SearchUsers(ctx context.Context, realmName string, criteria api.UserSearchCriteria) (api.UsersPageRepresentation, error) {
+	var action = MGMTSearchUsers.String()
+	var targetRealm = realmName
+
+	if len(criteria.GroupIDs) == 0 {
+		if err := c.authManager.CheckAuthorizationOnTargetRealm(ctx, action, targetRealm); err != nil {
+			return api.UsersPageRepresentation{}, err
+		}
+	}
+	for _, groupID := range criteria.GroupIDs {
+		if err := c.authManager.CheckAuthorizationOnTargetGroupID(ctx, action, targetRealm, groupID); err != nil {
+			return api.UsersPageRepresentation{}, err
+		}
+	}
+
+	return c.next.SearchUsers(ctx, realmName, criteria)
+}
+
+func (c *authorizationSearchComponentMW) IndexUsers(ctx context.Context, realmName string) (api.BulkJobRepresentation, error) {
+	var action = MGMTIndexUsers.String()
+	var targetRealm = realmName
+
+	if err := c.authManager.CheckAuthorizationOnTargetRealm(ctx, action, targetRealm); err != nil {
+		return api.BulkJobRepresentation{}, err
+	}
+
+	return c.next.IndexUsers(ctx, realmName)
+}
+
+type authorizationSoftDeletionComponentMW struct {
+	authManager security.AuthorizationManager
+	logger      log.Logger
+	next        SoftDeletionComponent
+}
+
+// MakeAuthorizationSoftDeletionComponentMW checks authorization and return an error if the action is not allowed.
+func MakeAuthorizationSoftDeletionComponentMW(logger log.Logger, authorizationManager security.AuthorizationManager) func(SoftDeletionComponent) SoftDeletionComponent {
+	return func(next SoftDeletionComponent) SoftDeletionComponent {
+		return &authorizationSoftDeletionComponentMW{
+			authManager: authorizationManager,
+			logger:      logger,
+			next:        next,
+		}
+	}
+}
+
+func (c *authorizationSoftDeletionComponentMW) RestoreUser(ctx context.Context, realmName string, userID string) error {
+	var action = MGMTRestoreUser.String()
+	var targetRealm = realmName
+
+	if err := c.authManager.CheckAuthorizationOnTargetUser(ctx, action, targetRealm, userID); err != nil {
+		return err
+	}
+
+	return c.next.RestoreUser(ctx, realmName, userID)
+}
+
+type authorizationApprovalComponentMW struct {
+	authManager security.AuthorizationManager
+	logger      log.Logger
+	next        ApprovalComponent
+}
+
+// MakeAuthorizationApprovalComponentMW checks authorization and return an error if the action is not allowed.
+func MakeAuthorizationApprovalComponentMW(logger log.Logger, authorizationManager security.AuthorizationManager) func(ApprovalComponent) ApprovalComponent {
+	return func(next ApprovalComponent) ApprovalComponent {
+		return &authorizationApprovalComponentMW{
+			authManager: authorizationManager,
+			logger:      logger,
+			next:        next,
+		}
+	}
+}
+
+func (c *authorizationApprovalComponentMW) GetApprovalPolicy(ctx context.Context, realmName string) (api.ApprovalPolicyRepresentation, error) {
+	var action = MGMTGetApprovalPolicy.String()
+	var targetRealm = realmName
+
+	if err := c.authManager.CheckAuthorizationOnTargetRealm(ctx, action, targetRealm); err != nil {
+		return api.ApprovalPolicyRepresentation{}, err
+	}
+
+	return c.next.GetApprovalPolicy(ctx, realmName)
+}
+
+func (c *authorizationApprovalComponentMW) UpdateApprovalPolicy(ctx context.Context, realmName string, policy api.ApprovalPolicyRepresentation) error {
+	var action = MGMTUpdateApprovalPolicy.String()
+	var targetRealm = realmName
+
+	if err := c.authManager.CheckAuthorizationOnTargetRealm(ctx, action, targetRealm); err != nil {
+		return err
+	}
+
+	return c.next.UpdateApprovalPolicy(ctx, realmName, policy)
+}
+
+func (c *authorizationApprovalComponentMW) GetApprovalRequests(ctx context.Context, realmName string, status *string) ([]api.ApprovalRequestRepresentation, error) {
+	var action = MGMTGetApprovalRequests.String()
+	var targetRealm = realmName
+
+	if err := c.authManager.CheckAuthorizationOnTargetRealm(ctx, action, targetRealm); err != nil {
+		return nil, err
+	}
+
+	return c.next.GetApprovalRequests(ctx, realmName, status)
+}
+
+func (c *authorizationApprovalComponentMW) ApproveRequest(ctx context.Context, realmName string, requestID int64, decision api.ApprovalDecisionRepresentation) (api.ApprovalRequestRepresentation, error) {
+	var action = MGMTApproveRequest.String()
+	var targetRealm = realmName
+
+	if err := c.authManager.CheckAuthorizationOnTargetRealm(ctx, action, targetRealm); err != nil {
+		return api.ApprovalRequestRepresentation{}, err
+	}
+
+	return c.next.ApproveRequest(ctx, realmName, requestID, decision)
+}
+
+func (c *authorizationApprovalComponentMW) RejectRequest(ctx context.Context, realmName string, requestID int64, decision api.ApprovalDecisionRepresentation) error {
+	var action = MGMTRejectRequest.String()
+	var targetRealm = realmName
+
+	if err := c.authManager.CheckAuthorizationOnTargetRealm(ctx, action, targetRealm); err != nil {
+		return err
+	}
+
+	return c.next.RejectRequest(ctx, realmName, requestID, decision)
+}
+
+type authorizationGrantsComponentMW struct {
+	authManager security.AuthorizationManager
+	logger      log.Logger
+	next        GrantsComponent
+}
+
+// MakeAuthorizationGrantsComponentMW checks authorization and return an error if the action is not allowed.
+// Temporary grants require the same authorizations as the permanent ones.
+func MakeAuthorizationGrantsComponentMW(logger log.Logger, authorizationManager security.AuthorizationManager) func(GrantsComponent) GrantsComponent {
+	return func(next GrantsComponent) GrantsComponent {
+		return &authorizationGrantsComponentMW{
+			authManager: authorizationManager,
+			logger:      logger,
+			next:        next,
+		}
+	}
+}
+
+func (c *authorizationGrantsComponentMW) AddTemporaryGroupToUser(ctx context.Context, realmName, userID, groupID string, expiresAt time.Time) error {
+	var action = MGMTSetGroupsToUser.String()
+	var targetRealm = realmName
+
+	if err := c.authManager.CheckAuthorizationOnTargetUser(ctx, action, targetRealm, userID); err != nil {
+		return err
+	}
+
+	action = MGMTAssignableGroupsToUser.String()
+	if err := c.authManager.CheckAuthorizationOnTargetGroupID(ctx, action, targetRealm, groupID); err != nil {
+		return err
+	}
+
+	return c.next.AddTemporaryGroupToUser(ctx, realmName, userID, groupID, expiresAt)
+}
+
+func (c *authorizationGrantsComponentMW) SetTemporaryTrustIDGroupsToUser(ctx context.Context, realmName, userID string, groupNames []string, expiresAt time.Time) error {
+	var action = MGMTSetTrustIDGroups.String()
+	var targetRealm = realmName
+
+	if err := c.authManager.CheckAuthorizationOnTargetUser(ctx, action, targetRealm, userID); err != nil {
+		return err
+	}
+
+	return c.next.SetTemporaryTrustIDGroupsToUser(ctx, realmName, userID, groupNames, expiresAt)
+}
+
+func (c *authorizationGrantsComponentMW) AddTemporaryClientRolesToUser(ctx context.Context, realmName, userID, clientID string, roles []api.RoleRepresentation, expiresAt time.Time) error {
+	var action = MGMTAddClientRolesToUser.String()
+	var targetRealm = realmName
+
+	if err := c.authManager.CheckAuthorizationOnTargetUser(ctx, action, targetRealm, userID); err != nil {
+		return err
+	}
+
+	return c.next.AddTemporaryClientRolesToUser(ctx, realmName, userID, clientID, roles, expiresAt)
+}
+
+type authorizationScheduledChangesComponentMW struct {
+	authManager security.AuthorizationManager
+	logger      log.Logger
+	next        ScheduledChangesComponent
+}
+
+// MakeAuthorizationScheduledChangesComponentMW checks authorization and return an error if the action is not allowed.
+func MakeAuthorizationScheduledChangesComponentMW(logger log.Logger, authorizationManager security.AuthorizationManager) func(ScheduledChangesComponent) ScheduledChangesComponent {
+	return func(next ScheduledChangesComponent) ScheduledChangesComponent {
+		return &authorizationScheduledChangesComponentMW{
+			authManager: authorizationManager,
+			logger:      logger,
+			next:        next,
+		}
+	}
+}
+
+func (c *authorizationScheduledChangesComponentMW) GetScheduledUserChanges(ctx context.Context, realmName, userID string) ([]api.ScheduledUserChangeRepresentation, error) {
+	var action = MGMTGetScheduledUserChanges.String()
+	var targetRealm = realmName
+
+	if err := c.authManager.CheckAuthorizationOnTargetUser(ctx, action, targetRealm, userID); err != nil {
+		return nil, err
+	}
+
+	return c.next.GetScheduledUserChanges(ctx, realmName, userID)
+}
+
+func (c *authorizationScheduledChangesComponentMW) ScheduleUserChange(ctx context.Context, realmName, userID string, change api.ScheduledUserChangeRepresentation) (int64, error) {
+	var action = MGMTScheduleUserChange.String()
+	var targetRealm = realmName
+
+	if err := c.authManager.CheckAuthorizationOnTargetUser(ctx, action, targetRealm, userID); err != nil {
+		return 0, err
+	}
+
+	return c.next.ScheduleUserChange(ctx, realmName, userID, change)
+}
+
+func (c *authorizationScheduledChangesComponentMW) CancelScheduledUserChange(ctx context.Context, realmName, userID string, changeID int64) error {
+	var action = MGMTCancelScheduledUserChange.String()
+	var targetRealm = realmName
+
+	if err := c.authManager.CheckAuthorizationOnTargetUser(ctx, action, targetRealm, userID); err != nil {
+		return err
+	}
+
+	return c.next.CancelScheduledUserChange(ctx, realmName, userID, changeID)
+}
+
+type authorizationImpersonationComponentMW struct {
+	authManager security.AuthorizationManager
+	logger      log.Logger
+	next        ImpersonationComponent
+}
+
+// MakeAuthorizationImpersonationComponentMW checks authorization and return an error if the action is not allowed.
+func MakeAuthorizationImpersonationComponentMW(logger log.Logger, authorizationManager security.AuthorizationManager) func(ImpersonationComponent) ImpersonationComponent {
+	return func(next ImpersonationComponent) ImpersonationComponent {
+		return &authorizationImpersonationComponentMW{
+			authManager: authorizationManager,
+			logger:      logger,
+			next:        next,
+		}
+	}
+}
+
+func (c *authorizationImpersonationComponentMW) ImpersonateUser(ctx context.Context, realmName, userID string, request api.ImpersonationRequestRepresentation) (api.ImpersonationRepresentation, error) {
+	var action = MGMTImpersonateUser.String()
+	var targetRealm = realmName
+
+	if err := c.authManager.CheckAuthorizationOnTargetUser(ctx, action, targetRealm, userID); err != nil {
+		return api.ImpersonationRepresentation{}, err
+	}
+
+	return c.next.ImpersonateUser(ctx, realmName, userID, request)
+}
+
+type authorizationProvisioningComponentMW struct {
+	authManager security.AuthorizationManager
+	logger      log.Logger
+	next        ProvisioningComponent
+}
+
+// MakeAuthorizationProvisioningComponentMW checks authorization on the realm of the template. The realm may not exist yet.
+func MakeAuthorizationProvisioningComponentMW(logger log.Logger, authorizationManager security.AuthorizationManager) func(ProvisioningComponent) ProvisioningComponent {
+	return func(next ProvisioningComponent) ProvisioningComponent {
+		return &authorizationProvisioningComponentMW{
+			authManager: authorizationManager,
+			logger:      logger,
+			next:        next,
+		}
+	}
+}
+
+func (c *authorizationProvisioningComponentMW) ProvisionRealm(ctx context.Context, template api.RealmTemplateRepresentation, dryRun bool) (api.ProvisioningPlanRepresentation, error) {
+	var action = MGMTProvisionRealm.String()
+	var targetRealm = *template.Realm
+
+	if err := c.authManager.CheckAuthorizationOnTargetRealm(ctx, action, targetRealm); err != nil {
+		return api.ProvisioningPlanRepresentation{}, err
+	}
+
+	return c.next.ProvisionRealm(ctx, template, dryRun)
+}
+
+type authorizationAuthorizationsReportComponentMW struct {
+	authManager security.AuthorizationManager
+	logger      log.Logger
+	next        AuthorizationsReportComponent
+}
+
+// MakeAuthorizationAuthorizationsReportComponentMW checks authorization on the user or on the target of the report
+func MakeAuthorizationAuthorizationsReportComponentMW(logger log.Logger, authorizationManager security.AuthorizationManager) func(AuthorizationsReportComponent) AuthorizationsReportComponent {
+	return func(next AuthorizationsReportComponent) AuthorizationsReportComponent {
+		return &authorizationAuthorizationsReportComponentMW{
+			authManager: authorizationManager,
+			logger:      logger,
+			next:        next,
+		}
+	}
+}
+
+func (c *authorizationAuthorizationsReportComponentMW) GetUserAuthorizationsReport(ctx context.Context, realmName, userID string) (api.UserAuthorizationsReportRepresentation, error) {
+	var action = MGMTGetUserAuthorizationsReport.String()
+	var targetRealm = realmName
+
+	if err := c.authManager.CheckAuthorizationOnTargetUser(ctx, action, targetRealm, userID); err != nil {
+		return api.UserAuthorizationsReportRepresentation{}, err
+	}
+
+	return c.next.GetUserAuthorizationsReport(ctx, realmName, userID)
+}
+
+func (c *authorizationAuthorizationsReportComponentMW) GetTargetAuthorizationsReport(ctx context.Context, targetRealm string, targetGroupName *string) (api.TargetAuthorizationsReportRepresentation, error) {
+	var action = MGMTGetTargetAuthorizationsReport.String()
+
+	var err error
+	if targetGroupName != nil {
+		err = c.authManager.CheckAuthorizationOnTargetGroup(ctx, action, targetRealm, *targetGroupName)
+	} else {
+		err = c.authManager.CheckAuthorizationOnTargetRealm(ctx, action, targetRealm)
+	}
+	if err != nil {
+		return api.TargetAuthorizationsReportRepresentation{}, err
+	}
+
+	return c.next.GetTargetAuthorizationsReport(ctx, targetRealm, targetGroupName)
+}
@@ -3,6 +3,7 @@ package management
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/cloudtrust/common-service/configuration"
 
@@ -95,6 +96,9 @@ func TestDeny(t *testing.T) {
 		Username: &userUsername,
 	}
 
+	var scheduleID = int64(3)
+	var reportSchedule = api.StatisticsReportScheduleRepresentation{}
+
 	// Nothing allowed
 	{
 		var authorizations, err = security.NewAuthorizationManager(mockAuthorizationDBReader, mockKeycloakClient, log.NewNopLogger())
@@ -263,6 +267,18 @@ func TestDeny(t *testing.T) {
 
 		err = authorizationMW.LinkShadowUser(ctx, realmName, userID, provider, fedID)
 		assert.Equal(t, security.ForbiddenError{}, err)
+
+		_, err = authorizationMW.GetStatisticsReportSchedules(ctx, realmName)
+		assert.Equal(t, security.ForbiddenError{}, err)
+
+		_, err = authorizationMW.CreateStatisticsReportSchedule(ctx, realmName, reportSchedule)
+		assert.Equal(t, security.ForbiddenError{}, err)
+
+		err = authorizationMW.UpdateStatisticsReportSchedule(ctx, realmName, scheduleID, reportSchedule)
+		assert.Equal(t, security.ForbiddenError{}, err)
+
+		err = authorizationMW.DeleteStatisticsReportSchedule(ctx, realmName, scheduleID)
+		assert.Equal(t, security.ForbiddenError{}, err)
 	}
 }
 
@@ -339,6 +355,9 @@ func TestAllowed(t *testing.T) {
 		Username: &userUsername,
 	}
 
+	var scheduleID = int64(3)
+	var reportSchedule = api.StatisticsReportScheduleRepresentation{}
+
 	var authorizations = []configuration.Authorization{}
 	for _, action := range actions {
 		var action = string(action.Name)
@@ -574,5 +593,821 @@ func TestAllowed(t *testing.T) {
 		mockManagementComponent.EXPECT().LinkShadowUser(ctx, realmName, userID, provider, fedID).Return(nil).Times(1)
 		err = authorizationMW.LinkShadowUser(ctx, realmName, userID, provider, fedID)
 		assert.Nil(t, err)
+
+		mockManagementComponent.EXPECT().GetStatisticsReportSchedules(ctx, realmName).Return(nil, nil).Times(1)
+		_, err = authorizationMW.GetStatisticsReportSchedules(ctx, realmName)
+		assert.Nil(t, err)
+
+		mockManagementComponent.EXPECT().CreateStatisticsReportSchedule(ctx, realmName, reportSchedule).Return(scheduleID, nil).Times(1)
+		_, err = authorizationMW.CreateStatisticsReportSchedule(ctx, realmName, reportSchedule)
+		assert.Nil(t, err)
+
+		mockManagementComponent.EXPECT().UpdateStatisticsReportSchedule(ctx, realmName, scheduleID, reportSchedule).Return(nil).Times(1)
+		err = authorizationMW.UpdateStatisticsReportSchedule(ctx, realmName, scheduleID, reportSchedule)
+		assert.Nil(t, err)
+
+		mockManagementComponent.EXPECT().DeleteStatisticsReportSchedule(ctx, realmName, scheduleID).Return(nil).Times(1)
+		err = authorizationMW.DeleteStatisticsReportSchedule(ctx, realmName, scheduleID)
+		assert.Nil(t, err)
 	}
 }
+
+func TestBulkAuthorization(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var mockBulkComponent = mock.NewBulkComponent(mockCtrl)
+	var mockAuthManager = mock.NewAuthorizationManager(mockCtrl)
+	var authorizationMW = MakeAuthorizationBulkComponentMW(log.NewNopLogger(), mockAuthManager)(mockBulkComponent)
+
+	var ctx = context.TODO()
+	var realmName = "master"
+	var jobID = "job-id"
+	var users = []api.UserRepresentation{}
+	var job = api.BulkJobRepresentation{ID: jobID}
+
+	t.Run("Import users", func(t *testing.T) {
+		mockAuthManager.EXPECT().CheckAuthorizationOnTargetRealm(ctx, MGMTImportUsers.String(), realmName).Return(security.ForbiddenError{})
+		var _, err = authorizationMW.ImportUsers(ctx, realmName, users, true)
+		assert.Equal(t, security.ForbiddenError{}, err)
+
+		mockAuthManager.EXPECT().CheckAuthorizationOnTargetRealm(ctx, MGMTImportUsers.String(), realmName).Return(nil)
+		mockBulkComponent.EXPECT().ImportUsers(ctx, realmName, users, true).Return(job, nil)
+		_, err = authorizationMW.ImportUsers(ctx, realmName, users, true)
+		assert.Nil(t, err)
+	})
+
+	t.Run("Apply bulk operation", func(t *testing.T) {
+		var operation = api.BulkUserOperationRepresentation{}
+		mockAuthManager.EXPECT().CheckAuthorizationOnTargetRealm(ctx, MGMTApplyBulkOperation.String(), realmName).Return(security.ForbiddenError{})
+		var _, err = authorizationMW.ApplyBulkOperation(ctx, realmName, operation)
+		assert.Equal(t, security.ForbiddenError{}, err)
+
+		mockAuthManager.EXPECT().CheckAuthorizationOnTargetRealm(ctx, MGMTApplyBulkOperation.String(), realmName).Return(nil)
+		mockBulkComponent.EXPECT().ApplyBulkOperation(ctx, realmName, operation).Return(job, nil)
+		_, err = authorizationMW.ApplyBulkOperation(ctx, realmName, operation)
+		assert.Nil(t, err)
+	})
+
+	t.Run("Get job", func(t *testing.T) {
+		mockAuthManager.EXPECT().CheckAuthorizationOnTargetRealm(ctx, MGMTGetBulkJob.String(), realmName).Return(security.ForbiddenError{})
+		var _, err = authorizationMW.GetBulkJob(ctx, realmName, jobID)
+		assert.Equal(t, security.ForbiddenError{}, err)
+
+		mockAuthManager.EXPECT().CheckAuthorizationOnTargetRealm(ctx, MGMTGetBulkJob.String(), realmName).Return(nil)
+		mockBulkComponent.EXPECT().GetBulkJob(ctx, realmName, jobID).Return(job, nil)
+		_, err = authorizationMW.GetBulkJob(ctx, realmName, jobID)
+		assert.Nil(t, err)
+	})
+}
+
+func TestExportUsersAuthorization(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var mockManagementComponent = mock.NewManagementComponent(mockCtrl)
+	var mockAuthManager = mock.NewAuthorizationManager(mockCtrl)
+	var authorizationMW = MakeAuthorizationManagementComponentMW(log.NewNopLogger(), mockAuthManager)(mockManagementComponent)
+
+	var ctx = context.TODO()
+	var realmName = "master"
+	var groupID = "123-456-789"
+	var columns = []string{"id", "username"}
+	var detailsColumns = []string{"id", "idDocumentNumber"}
+
+	t.Run("Realm export forbidden", func(t *testing.T) {
+		mockAuthManager.EXPECT().CheckAuthorizationOnTargetRealm(ctx, MGMTExportUsers.String(), realmName).Return(security.ForbiddenError{})
+		var _, err = authorizationMW.ExportUsers(ctx, realmName, nil, api.ExportFormatCSV, columns)
+		assert.Equal(t, security.ForbiddenError{}, err)
+	})
+
+	t.Run("Group export allowed", func(t *testing.T) {
+		mockAuthManager.EXPECT().CheckAuthorizationOnTargetGroupID(ctx, MGMTExportUsers.String(), realmName, groupID).Return(nil)
+		mockManagementComponent.EXPECT().ExportUsers(ctx, realmName, []string{groupID}, api.ExportFormatCSV, columns).Return(UsersExport{}, nil)
+		var _, err = authorizationMW.ExportUsers(ctx, realmName, []string{groupID}, api.ExportFormatCSV, columns)
+		assert.Nil(t, err)
+	})
+
+	t.Run("User details need an additional action", func(t *testing.T) {
+		mockAuthManager.EXPECT().CheckAuthorizationOnTargetGroupID(ctx, MGMTExportUsers.String(), realmName, groupID).Return(nil)
+		mockAuthManager.EXPECT().CheckAuthorizationOnTargetGroupID(ctx, MGMTExportUsersDetails.String(), realmName, groupID).Return(security.ForbiddenError{})
+		var _, err = authorizationMW.ExportUsers(ctx, realmName, []string{groupID}, api.ExportFormatCSV, detailsColumns)
+		assert.Equal(t, security.ForbiddenError{}, err)
+	})
+}
+
+func TestSearchAuthorization(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var mockSearchComponent = mock.NewSearchComponent(mockCtrl)
+	var mockAuthManager = mock.NewAuthorizationManager(mockCtrl)
+	var authorizationMW = MakeAuthorizationSearchComponentMW(log.NewNopLogger(), mockAuthManager)(mockSearchComponent)
+
+	var ctx = context.TODO()
+	var realmName = "master"
+	var groupID = "123-456-789"
+
+	t.Run("Search in realm", func(t *testing.T) {
+		var criteria = api.UserSearchCriteria{}
+		mockAuthManager.EXPECT().CheckAuthorizationOnTargetRealm(ctx, MGMTSearchUsers.String(), realmName).Return(security.ForbiddenError{})
+		var _, err = authorizationMW.SearchUsers(ctx, realmName, criteria)
+		assert.Equal(t, security.ForbiddenError{}, err)
+	})
+
+	t.Run("Search in groups", func(t *testing.T) {
+		var criteria = api.UserSearchCriteria{GroupIDs: []string{groupID}}
+		mockAuthManager.EXPECT().CheckAuthorizationOnTargetGroupID(ctx, MGMTSearchUsers.String(), realmName, groupID).Return(nil)
+		mockSearchComponent.EXPECT().SearchUsers(ctx, realmName, criteria).Return(api.UsersPageRepresentation{}, nil)
+		var _, err = authorizationMW.SearchUsers(ctx, realmName, criteria)
+		assert.Nil(t, err)
+	})
+
+	t.Run("Index users", func(t *testing.T) {
+		mockAuthManager.EXPECT().CheckAuthorizationOnTargetRealm(ctx, MGMTIndexUsers.String(), realmName).Return(security.ForbiddenError{})
+		var _, err = authorizationMW.IndexUsers(ctx, realmName)
+		assert.Equal(t, security.ForbiddenError{}, err)
+
+		mockAuthManager.EXPECT().CheckAuthorizationOnTargetRealm(ctx, MGMTIndexUsers.String(), realmName).Return(nil)
+		mockSearchComponent.EXPECT().IndexUsers(ctx, realmName).Return(api.BulkJobRepresentation{}, nil)
+		_, err = authorizationMW.IndexUsers(ctx, realmName)
+		assert.Nil(t, err)
+	})
+}
+
+func TestSoftDeletionAuthorization(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var mockSoftDeletionComponent = mock.NewSoftDeletionComponent(mockCtrl)
+	var mockAuthManager = mock.NewAuthorizationManager(mockCtrl)
+	var authorizationMW = MakeAuthorizationSoftDeletionComponentMW(log.NewNopLogger(), mockAuthManager)(mockSoftDeletionComponent)
+
+	var ctx = context.TODO()
+	var realmName = "master"
+	var userID = "123-456-789"
+
+	mockAuthManager.EXPECT().CheckAuthorizationOnTargetUser(ctx, MGMTRestoreUser.String(), realmName, userID).Return(security.ForbiddenError{})
+	assert.Equal(t, security.ForbiddenError{}, authorizationMW.RestoreUser(ctx, realmName, userID))
+
+	mockAuthManager.EXPECT().CheckAuthorizationOnTargetUser(ctx, MGMTRestoreUser.String(), realmName, userID).Return(nil)
+	mockSoftDeletionComponent.EXPECT().RestoreUser(ctx, realmName, userID).Return(nil)
+	assert.Nil(t, authorizationMW.RestoreUser(ctx, realmName, userID))
+}
+
+func TestApprovalAuthorization(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var mockApprovalComponent = mock.NewApprovalComponent(mockCtrl)
+	var mockAuthManager = mock.NewAuthorizationManager(mockCtrl)
+	var authorizationMW = MakeAuthorizationApprovalComponentMW(log.NewNopLogger(), mockAuthManager)(mockApprovalComponent)
+
+	var ctx = context.TODO()
+	var realmName = "master"
+	var requestID = int64(12)
+	var policy = api.ApprovalPolicyRepresentation{}
+	var decision = api.ApprovalDecisionRepresentation{}
+
+	t.Run("Forbidden", func(t *testing.T) {
+		mockAuthManager.EXPECT().CheckAuthorizationOnTargetRealm(ctx, gomock.Any(), realmName).Return(security.ForbiddenError{}).Times(5)
+
+		_, err := authorizationMW.GetApprovalPolicy(ctx, realmName)
+		assert.Equal(t, security.ForbiddenError{}, err)
+		assert.Equal(t, security.ForbiddenError{}, authorizationMW.UpdateApprovalPolicy(ctx, realmName, policy))
+		_, err = authorizationMW.GetApprovalRequests(ctx, realmName, nil)
+		assert.Equal(t, security.ForbiddenError{}, err)
+		_, err = authorizationMW.ApproveRequest(ctx, realmName, requestID, decision)
+		assert.Equal(t, security.ForbiddenError{}, err)
+		assert.Equal(t, security.ForbiddenError{}, authorizationMW.RejectRequest(ctx, realmName, requestID, decision))
+	})
+
+	t.Run("Allowed", func(t *testing.T) {
+		mockAuthManager.EXPECT().CheckAuthorizationOnTargetRealm(ctx, MGMTGetApprovalPolicy.String(), realmName).Return(nil)
+		mockApprovalComponent.EXPECT().GetApprovalPolicy(ctx, realmName).Return(policy, nil)
+		_, err := authorizationMW.GetApprovalPolicy(ctx, realmName)
+		assert.Nil(t, err)
+
+		mockAuthManager.EXPECT().CheckAuthorizationOnTargetRealm(ctx, MGMTUpdateApprovalPolicy.String(), realmName).Return(nil)
+		mockApprovalComponent.EXPECT().UpdateApprovalPolicy(ctx, realmName, policy).Return(nil)
+		assert.Nil(t, authorizationMW.UpdateApprovalPolicy(ctx, realmName, policy))
+
+		mockAuthManager.EXPECT().CheckAuthorizationOnTargetRealm(ctx, MGMTGetApprovalRequests.String(), realmName).Return(nil)
+		mockApprovalComponent.EXPECT().GetApprovalRequests(ctx, realmName, nil).Return(nil, nil)
+		_, err = authorizationMW.GetApprovalRequests(ctx, realmName, nil)
+		assert.Nil(t, err)
+
+		mockAuthManager.EXPECT().CheckAuthorizationOnTargetRealm(ctx, MGMTApproveRequest.String(), realmName).Return(nil)
+		mockApprovalComponent.EXPECT().ApproveRequest(ctx, realmName, requestID, decision).Return(api.ApprovalRequestRepresentation{}, nil)
+		_, err = authorizationMW.ApproveRequest(ctx, realmName, requestID, decision)
+		assert.Nil(t, err)
+
+		mockAuthManager.EXPECT().CheckAuthorizationOnTargetRealm(ctx, MGMTRejectRequest.String(), realmName).Return(nil)
+		mockApprovalComponent.EXPECT().RejectRequest(ctx, realmName, requestID, decision).Return(nil)
+		assert.Nil(t, authorizationMW.RejectRequest(ctx, realmName, requestID, decision))
+	})
+}
+
+func TestGrantsAuthorization(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var mockGrantsComponent = mock.NewGrantsComponent(mockCtrl)
+	var mockAuthManager = mock.NewAuthorizationManager(mockCtrl)
+	var authorizationMW = MakeAuthorizationGrantsComponentMW(log.NewNopLogger(), mockAuthManager)(mockGrantsComponent)
+
+	var ctx = context.TODO()
+	var realmName = "master"
+	var userID = "123-456-789"
+	var groupID = "987-654-321"
+	var clientID = "client-id"
+	var groupNames = []string{"l1_support_agent"}
+	var roles = []api.RoleRepresentation{}
+	var expiresAt = time.Now().Add(time.Hour)
+
+	t.Run("Forbidden", func(t *testing.T) {
+		mockAuthManager.EXPECT().CheckAuthorizationOnTargetUser(ctx, MGMTSetGroupsToUser.String(), realmName, userID).Return(nil)
+		mockAuthManager.EXPECT().CheckAuthorizationOnTargetGroupID(ctx, MGMTAssignableGroupsToUser.String(), realmName, groupID).Return(security.ForbiddenError{})
+		assert.Equal(t, security.ForbiddenError{}, authorizationMW.AddTemporaryGroupToUser(ctx, realmName, userID, groupID, expiresAt))
+
+		mockAuthManager.EXPECT().CheckAuthorizationOnTargetUser(ctx, MGMTSetTrustIDGroups.String(), realmName, userID).Return(security.ForbiddenError{})
+		assert.Equal(t, security.ForbiddenError{}, authorizationMW.SetTemporaryTrustIDGroupsToUser(ctx, realmName, userID, groupNames, expiresAt))
+
+		mockAuthManager.EXPECT().CheckAuthorizationOnTargetUser(ctx, MGMTAddClientRolesToUser.String(), realmName, userID).Return(security.ForbiddenError{})
+		assert.Equal(t, security.ForbiddenError{}, authorizationMW.AddTemporaryClientRolesToUser(ctx, realmName, userID, clientID, roles, expiresAt))
+	})
+
+	t.Run("Allowed", func(t *testing.T) {
+		mockAuthManager.EXPECT().CheckAuthorizationOnTargetUser(ctx, MGMTSetGroupsToUser.String(), realmName, userID).Return(nil)
+		mockAuthManager.EXPECT().CheckAuthorizationOnTargetGroupID(ctx, MGMTAssignableGroupsToUser.String(), realmName, groupID).Return(nil)
+		mockGrantsComponent.EXPECT().AddTemporaryGroupToUser(ctx, realmName, userID, groupID, expiresAt).Return(nil)
+		assert.Nil(t, authorizationMW.AddTemporaryGroupToUser(ctx, realmName, userID, groupID, expiresAt))
+
+		mockAuthManager.EXPECT().CheckAuthorizationOnTargetUser(ctx, MGMTSetTrustIDGroups.String(), realmName, userID).Return(nil)
+		mockGrantsComponent.EXPECT().SetTemporaryTrustIDGroupsToUser(ctx, realmName, userID, groupNames, expiresAt).Return(nil)
+		assert.Nil(t, authorizationMW.SetTemporaryTrustIDGroupsToUser(ctx, realmName, userID, groupNames, expiresAt))
+
+		mockAuthManager.EXPECT().CheckAuthorizationOnTargetUser(ctx, MGMTAddClientRolesToUser.String(), realmName, userID).Return(nil)
+		mockGrantsComponent.EXPECT().AddTemporaryClientRolesToUser(ctx, realmName, userID, clientID, roles, expiresAt).Return(nil)
+		assert.Nil(t, authorizationMW.AddTemporaryClientRolesToUser(ctx, realmName, userID, clientID, roles, expiresAt))
+	})
+}
+
+func TestScheduledChangesAuthorization(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var mockScheduledChangesComponent = mock.NewScheduledChangesComponent(mockCtrl)
+	var mockAuthManager = mock.NewAuthorizationManager(mockCtrl)
+	var authorizationMW = MakeAuthorizationScheduledChangesComponentMW(log.NewNopLogger(), mockAuthManager)(mockScheduledChangesComponent)
+
+	var ctx = context.TODO()
+	var realmName = "master"
+	var userID = "123-456-789"
+	var changeID = int64(4)
+	var change = api.ScheduledUserChangeRepresentation{}
+
+	t.Run("Forbidden", func(t *testing.T) {
+		mockAuthManager.EXPECT().CheckAuthorizationOnTargetUser(ctx, MGMTGetScheduledUserChanges.String(), realmName, userID).Return(security.ForbiddenError{})
+		var _, err = authorizationMW.GetScheduledUserChanges(ctx, realmName, userID)
+		assert.Equal(t, security.ForbiddenError{}, err)
+
+		mockAuthManager.EXPECT().CheckAuthorizationOnTargetUser(ctx, MGMTScheduleUserChange.String(), realmName, userID).Return(security.ForbiddenError{})
+		_, err = authorizationMW.ScheduleUserChange(ctx, realmName, userID, change)
+		assert.Equal(t, security.ForbiddenError{}, err)
+
+		mockAuthManager.EXPECT().CheckAuthorizationOnTargetUser(ctx, MGMTCancelScheduledUserChange.String(), realmName, userID).Return(security.ForbiddenError{})
+		assert.Equal(t, security.ForbiddenError{}, authorizationMW.CancelScheduledUserChange(ctx, realmName, userID, changeID))
+	})
+
+	t.Run("Allowed", func(t *testing.T) {
+		mockAuthManager.EXPECT().CheckAuthorizationOnTargetUser(ctx, MGMTGetScheduledUserChanges.String(), realmName, userID).Return(nil)
+		mockScheduledChangesComponent.EXPECT().GetScheduledUserChanges(ctx, realmName, userID).Return(nil, nil)
+		var _, err = authorizationMW.GetScheduledUserChanges(ctx, realmName, userID)
+		assert.Nil(t, err)
+
+		mockAuthManager.EXPECT().CheckAuthorizationOnTargetUser(ctx, MGMTScheduleUserChange.String(), realmName, userID).Return(nil)
+		mockScheduledChangesComponent.EXPECT().ScheduleUserChange(ctx, realmName, userID, change).Return(changeID, nil)
+		id, err := authorizationMW.ScheduleUserChange(ctx, realmName, userID, change)
+		assert.Nil(t, err)
+		assert.Equal(t, changeID, id)
+
+		mockAuthManager.EXPECT().CheckAuthorizationOnTargetUser(ctx, MGMTCancelScheduledUserChange.String(), realmName, userID).Return(nil)
+		mockScheduledChangesComponent.EXPECT().CancelScheduledUserChange(ctx, realmName, userID, changeID).Return(nil)
+		assert.Nil(t, authorizationMW.CancelScheduledUserChange(ctx, realmName, userID, changeID))
+	})
+}
+
+func TestGroupHierarchyAuthorization(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var mockManagementComponent = mock.NewManagementComponent(mockCtrl)
+	var mockAuthManager = mock.NewAuthorizationManager(mockCtrl)
+	var authorizationMW = MakeAuthorizationManagementComponentMW(log.NewNopLogger(), mockAuthManager)(mockManagementComponent)
+
+	var ctx = context.TODO()
+	var realmName = "master"
+	var groupID = "123-789-454"
+	var parentID = "987-654-321"
+	var groupName = "team"
+	var group = api.GroupRepresentation{Name: &groupName}
+	var toParent = api.GroupParentRepresentation{ParentID: &parentID}
+	var toTopLevel = api.GroupParentRepresentation{}
+
+	t.Run("Forbidden", func(t *testing.T) {
+		mockAuthManager.EXPECT().CheckAuthorizationOnTargetGroupID(ctx, MGMTCreateSubGroup.String(), realmName, parentID).Return(security.ForbiddenError{})
+		var _, err = authorizationMW.CreateSubGroup(ctx, realmName, parentID, group)
+		assert.Equal(t, security.ForbiddenError{}, err)
+
+		mockAuthManager.EXPECT().CheckAuthorizationOnTargetGroupID(ctx, MGMTUpdateGroup.String(), realmName, groupID).Return(security.ForbiddenError{})
+		assert.Equal(t, security.ForbiddenError{}, authorizationMW.UpdateGroup(ctx, realmName, groupID, group))
+
+		mockAuthManager.EXPECT().CheckAuthorizationOnTargetGroupID(ctx, MGMTMoveGroup.String(), realmName, groupID).Return(security.ForbiddenError{})
+		assert.Equal(t, security.ForbiddenError{}, authorizationMW.MoveGroup(ctx, realmName, groupID, toParent))
+
+		mockAuthManager.EXPECT().CheckAuthorizationOnTargetGroupID(ctx, MGMTMoveGroup.String(), realmName, groupID).Return(nil)
+		mockAuthManager.EXPECT().CheckAuthorizationOnTargetGroupID(ctx, MGMTMoveGroup.String(), realmName, parentID).Return(security.ForbiddenError{})
+		assert.Equal(t, security.ForbiddenError{}, authorizationMW.MoveGroup(ctx, realmName, groupID, toParent))
+
+		mockAuthManager.EXPECT().CheckAuthorizationOnTargetGroupID(ctx, MGMTMoveGroup.String(), realmName, groupID).Return(nil)
+		mockAuthManager.EXPECT().CheckAuthorizationOnTargetRealm(ctx, MGMTCreateGroup.String(), realmName).Return(security.ForbiddenError{})
+		assert.Equal(t, security.ForbiddenError{}, authorizationMW.MoveGroup(ctx, realmName, groupID, toTopLevel))
+	})
+
+	t.Run("Allowed", func(t *testing.T) {
+		mockAuthManager.EXPECT().CheckAuthorizationOnTargetGroupID(ctx, MGMTCreateSubGroup.String(), realmName, parentID).Return(nil)
+		mockManagementComponent.EXPECT().CreateSubGroup(ctx, realmName, parentID, group).Return("", nil)
+		var _, err = authorizationMW.CreateSubGroup(ctx, realmName, parentID, group)
+		assert.Nil(t, err)
+
+		mockAuthManager.EXPECT().CheckAuthorizationOnTargetGroupID(ctx, MGMTUpdateGroup.String(), realmName, groupID).Return(nil)
+		mockManagementComponent.EXPECT().UpdateGroup(ctx, realmName, groupID, group).Return(nil)
+		assert.Nil(t, authorizationMW.UpdateGroup(ctx, realmName, groupID, group))
+
+		mockAuthManager.EXPECT().CheckAuthorizationOnTargetGroupID(ctx, MGMTMoveGroup.String(), realmName, groupID).Return(nil)
+		mockAuthManager.EXPECT().CheckAuthorizationOnTargetGroupID(ctx, MGMTMoveGroup.String(), realmName, parentID).Return(nil)
+		mockManagementComponent.EXPECT().MoveGroup(ctx, realmName, groupID, toParent).Return(nil)
+		assert.Nil(t, authorizationMW.MoveGroup(ctx, realmName, groupID, toParent))
+
+		mockAuthManager.EXPECT().CheckAuthorizationOnTargetGroupID(ctx, MGMTMoveGroup.String(), realmName, groupID).Return(nil)
+		mockAuthManager.EXPECT().CheckAuthorizationOnTargetRealm(ctx, MGMTCreateGroup.String(), realmName).Return(nil)
+		mockManagementComponent.EXPECT().MoveGroup(ctx, realmName, groupID, toTopLevel).Return(nil)
+		assert.Nil(t, authorizationMW.MoveGroup(ctx, realmName, groupID, toTopLevel))
+	})
+}
+
+func TestRolesAuthorization(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var mockManagementComponent = mock.NewManagementComponent(mockCtrl)
+	var mockAuthManager = mock.NewAuthorizationManager(mockCtrl)
+	var authorizationMW = MakeAuthorizationManagementComponentMW(log.NewNopLogger(), mockAuthManager)(mockManagementComponent)
+
+	var ctx = context.TODO()
+	var realmName = "master"
+	var userID = "123-456-789"
+	var roleID = "456-852-785"
+	var clientID = "789-789-741"
+	var roleName = "role"
+	var role = api.RoleRepresentation{Name: &roleName}
+
+	t.Run("Forbidden", func(t *testing.T) {
+		mockAuthManager.EXPECT().CheckAuthorizationOnTargetRealm(ctx, MGMTCreateRole.String(), realmName).Return(security.ForbiddenError{})
+		var _, err = authorizationMW.CreateRole(ctx, realmName, role)
+		assert.Equal(t, security.ForbiddenError{}, err)
+
+		mockAuthManager.EXPECT().CheckAuthorizationOnTargetRealm(ctx, MGMTUpdateRole.String(), realmName).Return(security.ForbiddenError{})
+		assert.Equal(t, security.ForbiddenError{}, authorizationMW.UpdateRole(ctx, realmName, roleID, role))
+
+		mockAuthManager.EXPECT().CheckAuthorizationOnTargetRealm(ctx, MGMTDeleteRole.String(), realmName).Return(security.ForbiddenError{})
+		assert.Equal(t, security.ForbiddenError{}, authorizationMW.DeleteRole(ctx, realmName, roleID))
+
+		mockAuthManager.EXPECT().CheckAuthorizationOnTargetUser(ctx, MGMTAddRoleToUser.String(), realmName, userID).Return(security.ForbiddenError{})
+		assert.Equal(t, security.ForbiddenError{}, authorizationMW.AddRoleToUser(ctx, realmName, userID, roleID))
+
+		mockAuthManager.EXPECT().CheckAuthorizationOnTargetUser(ctx, MGMTDeleteRoleForUser.String(), realmName, userID).Return(security.ForbiddenError{})
+		assert.Equal(t, security.ForbiddenError{}, authorizationMW.DeleteRoleForUser(ctx, realmName, userID, roleID))
+
+		mockAuthManager.EXPECT().CheckAuthorizationOnTargetUser(ctx, MGMTDeleteClientRoleForUser.String(), realmName, userID).Return(security.ForbiddenError{})
+		assert.Equal(t, security.ForbiddenError{}, authorizationMW.DeleteClientRoleForUser(ctx, realmName, userID, clientID, roleID))
+	})
+
+	t.Run("Allowed", func(t *testing.T) {
+		mockAuthManager.EXPECT().CheckAuthorizationOnTargetRealm(ctx, MGMTCreateRole.String(), realmName).Return(nil)
+		mockManagementComponent.EXPECT().CreateRole(ctx, realmName, role).Return("", nil)
+		var _, err = authorizationMW.CreateRole(ctx, realmName, role)
+		assert.Nil(t, err)
+
+		mockAuthManager.EXPECT().CheckAuthorizationOnTargetRealm(ctx, MGMTUpdateRole.String(), realmName).Return(nil)
+		mockManagementComponent.EXPECT().UpdateRole(ctx, realmName, roleID, role).Return(nil)
+		assert.Nil(t, authorizationMW.UpdateRole(ctx, realmName, roleID, role))
+
+		mockAuthManager.EXPECT().CheckAuthorizationOnTargetRealm(ctx, MGMTDeleteRole.String(), realmName).Return(nil)
+		mockManagementComponent.EXPECT().DeleteRole(ctx, realmName, roleID).Return(nil)
+		assert.Nil(t, authorizationMW.DeleteRole(ctx, realmName, roleID))
+
+		mockAuthManager.EXPECT().CheckAuthorizationOnTargetUser(ctx, MGMTAddRoleToUser.String(), realmName, userID).Return(nil)
+		mockManagementComponent.EXPECT().AddRoleToUser(ctx, realmName, userID, roleID).Return(nil)
+		assert.Nil(t, authorizationMW.AddRoleToUser(ctx, realmName, userID, roleID))
+
+		mockAuthManager.EXPECT().CheckAuthorizationOnTargetUser(ctx, MGMTDeleteRoleForUser.String(), realmName, userID).Return(nil)
+		mockManagementComponent.EXPECT().DeleteRoleForUser(ctx, realmName, userID, roleID).Return(nil)
+		assert.Nil(t, authorizationMW.DeleteRoleForUser(ctx, realmName, userID, roleID))
+
+		mockAuthManager.EXPECT().CheckAuthorizationOnTargetUser(ctx, MGMTDeleteClientRoleForUser.String(), realmName, userID).Return(nil)
+		mockManagementComponent.EXPECT().DeleteClientRoleForUser(ctx, realmName, userID, clientID, roleID).Return(nil)
+		assert.Nil(t, authorizationMW.DeleteClientRoleForUser(ctx, realmName, userID, clientID, roleID))
+	})
+}
+
+func TestUserSessionsAuthorization(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var mockManagementComponent = mock.NewManagementComponent(mockCtrl)
+	var mockAuthManager = mock.NewAuthorizationManager(mockCtrl)
+	var authorizationMW = MakeAuthorizationManagementComponentMW(log.NewNopLogger(), mockAuthManager)(mockManagementComponent)
+
+	var ctx = context.TODO()
+	var realmName = "master"
+	var userID = "123-456-789"
+	var sessionID = "456-852-785"
+
+	t.Run("Forbidden", func(t *testing.T) {
+		mockAuthManager.EXPECT().CheckAuthorizationOnTargetUser(ctx, MGMTGetUserSessions.String(), realmName, userID).Return(security.ForbiddenError{})
+		var _, err = authorizationMW.GetUserSessions(ctx, realmName, userID)
+		assert.Equal(t, security.ForbiddenError{}, err)
+
+		mockAuthManager.EXPECT().CheckAuthorizationOnTargetUser(ctx, MGMTRevokeUserSession.String(), realmName, userID).Return(security.ForbiddenError{})
+		assert.Equal(t, security.ForbiddenError{}, authorizationMW.RevokeUserSession(ctx, realmName, userID, sessionID))
+
+		mockAuthManager.EXPECT().CheckAuthorizationOnTargetUser(ctx, MGMTRevokeUserSessions.String(), realmName, userID).Return(security.ForbiddenError{})
+		assert.Equal(t, security.ForbiddenError{}, authorizationMW.RevokeUserSessions(ctx, realmName, userID))
+	})
+
+	t.Run("Allowed", func(t *testing.T) {
+		mockAuthManager.EXPECT().CheckAuthorizationOnTargetUser(ctx, MGMTGetUserSessions.String(), realmName, userID).Return(nil)
+		mockManagementComponent.EXPECT().GetUserSessions(ctx, realmName, userID).Return(nil, nil)
+		var _, err = authorizationMW.GetUserSessions(ctx, realmName, userID)
+		assert.Nil(t, err)
+
+		mockAuthManager.EXPECT().CheckAuthorizationOnTargetUser(ctx, MGMTRevokeUserSession.String(), realmName, userID).Return(nil)
+		mockManagementComponent.EXPECT().RevokeUserSession(ctx, realmName, userID, sessionID).Return(nil)
+		assert.Nil(t, authorizationMW.RevokeUserSession(ctx, realmName, userID, sessionID))
+
+		mockAuthManager.EXPECT().CheckAuthorizationOnTargetUser(ctx, MGMTRevokeUserSessions.String(), realmName, userID).Return(nil)
+		mockManagementComponent.EXPECT().RevokeUserSessions(ctx, realmName, userID).Return(nil)
+		assert.Nil(t, authorizationMW.RevokeUserSessions(ctx, realmName, userID))
+	})
+}
+
+func TestImpersonationAuthorization(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var mockImpersonationComponent = mock.NewImpersonationComponent(mockCtrl)
+	var mockAuthManager = mock.NewAuthorizationManager(mockCtrl)
+	var authorizationMW = MakeAuthorizationImpersonationComponentMW(log.NewNopLogger(), mockAuthManager)(mockImpersonationComponent)
+
+	var ctx = context.TODO()
+	var realmName = "master"
+	var userID = "123-456-789"
+	var request = api.ImpersonationRequestRepresentation{}
+
+	t.Run("Forbidden", func(t *testing.T) {
+		mockAuthManager.EXPECT().CheckAuthorizationOnTargetUser(ctx, MGMTImpersonateUser.String(), realmName, userID).Return(security.ForbiddenError{})
+		var _, err = authorizationMW.ImpersonateUser(ctx, realmName, userID, request)
+		assert.Equal(t, security.ForbiddenError{}, err)
+	})
+
+	t.Run("Allowed", func(t *testing.T) {
+		mockAuthManager.EXPECT().CheckAuthorizationOnTargetUser(ctx, MGMTImpersonateUser.String(), realmName, userID).Return(nil)
+		mockImpersonationComponent.EXPECT().ImpersonateUser(ctx, realmName, userID, request).Return(api.ImpersonationRepresentation{}, nil)
+		var _, err = authorizationMW.ImpersonateUser(ctx, realmName, userID, request)
+		assert.Nil(t, err)
+	})
+}
+
+func TestClientsAuthorization(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var mockManagementComponent = mock.NewManagementComponent(mockCtrl)
+	var mockAuthManager = mock.NewAuthorizationManager(mockCtrl)
+	var authorizationMW = MakeAuthorizationManagementComponentMW(log.NewNopLogger(), mockAuthManager)(mockManagementComponent)
+
+	var ctx = context.TODO()
+	var realmName = "master"
+	var idClient = "456-852-785"
+	var clientID = "onboarding-app"
+	var client = api.ClientRepresentation{ClientID: &clientID}
+	var redirectURIs = []string{"https://app.example.com/*"}
+
+	t.Run("Forbidden", func(t *testing.T) {
+		mockAuthManager.EXPECT().CheckAuthorizationOnTargetRealm(ctx, MGMTCreateClient.String(), realmName).Return(security.ForbiddenError{})
+		var _, err = authorizationMW.CreateClient(ctx, realmName, client)
+		assert.Equal(t, security.ForbiddenError{}, err)
+
+		mockAuthManager.EXPECT().CheckAuthorizationOnTargetRealm(ctx, MGMTUpdateClient.String(), realmName).Return(security.ForbiddenError{})
+		assert.Equal(t, security.ForbiddenError{}, authorizationMW.UpdateClient(ctx, realmName, idClient, client))
+
+		mockAuthManager.EXPECT().CheckAuthorizationOnTargetRealm(ctx, MGMTDeleteClient.String(), realmName).Return(security.ForbiddenError{})
+		assert.Equal(t, security.ForbiddenError{}, authorizationMW.DeleteClient(ctx, realmName, idClient))
+
+		mockAuthManager.EXPECT().CheckAuthorizationOnTargetRealm(ctx, MGMTRegenerateClientSecret.String(), realmName).Return(security.ForbiddenError{})
+		_, err = authorizationMW.RegenerateClientSecret(ctx, realmName, idClient)
+		assert.Equal(t, security.ForbiddenError{}, err)
+
+		mockAuthManager.EXPECT().CheckAuthorizationOnTargetRealm(ctx, MGMTUpdateClientRedirectURIs.String(), realmName).Return(security.ForbiddenError{})
+		assert.Equal(t, security.ForbiddenError{}, authorizationMW.UpdateClientRedirectURIs(ctx, realmName, idClient, redirectURIs))
+	})
+
+	t.Run("Allowed", func(t *testing.T) {
+		mockAuthManager.EXPECT().CheckAuthorizationOnTargetRealm(ctx, MGMTCreateClient.String(), realmName).Return(nil)
+		mockManagementComponent.EXPECT().CreateClient(ctx, realmName, client).Return("", nil)
+		var _, err = authorizationMW.CreateClient(ctx, realmName, client)
+		assert.Nil(t, err)
+
+		mockAuthManager.EXPECT().CheckAuthorizationOnTargetRealm(ctx, MGMTUpdateClient.String(), realmName).Return(nil)
+		mockManagementComponent.EXPECT().UpdateClient(ctx, realmName, idClient, client).Return(nil)
+		assert.Nil(t, authorizationMW.UpdateClient(ctx, realmName, idClient, client))
+
+		mockAuthManager.EXPECT().CheckAuthorizationOnTargetRealm(ctx, MGMTDeleteClient.String(), realmName).Return(nil)
+		mockManagementComponent.EXPECT().DeleteClient(ctx, realmName, idClient).Return(nil)
+		assert.Nil(t, authorizationMW.DeleteClient(ctx, realmName, idClient))
+
+		mockAuthManager.EXPECT().CheckAuthorizationOnTargetRealm(ctx, MGMTRegenerateClientSecret.String(), realmName).Return(nil)
+		mockManagementComponent.EXPECT().RegenerateClientSecret(ctx, realmName, idClient).Return(api.ClientSecretRepresentation{}, nil)
+		_, err = authorizationMW.RegenerateClientSecret(ctx, realmName, idClient)
+		assert.Nil(t, err)
+
+		mockAuthManager.EXPECT().CheckAuthorizationOnTargetRealm(ctx, MGMTUpdateClientRedirectURIs.String(), realmName).Return(nil)
+		mockManagementComponent.EXPECT().UpdateClientRedirectURIs(ctx, realmName, idClient, redirectURIs).Return(nil)
+		assert.Nil(t, authorizationMW.UpdateClientRedirectURIs(ctx, realmName, idClient, redirectURIs))
+	})
+}
+
+func TestIdentityProvidersAuthorization(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var mockManagementComponent = mock.NewManagementComponent(mockCtrl)
+	var mockAuthManager = mock.NewAuthorizationManager(mockCtrl)
+	var authorizationMW = MakeAuthorizationManagementComponentMW(log.NewNopLogger(), mockAuthManager)(mockManagementComponent)
+
+	var ctx = context.TODO()
+	var realmName = "master"
+	var userID = "123-456-789"
+	var alias = "partner-idp"
+	var idp = api.IdentityProviderRepresentation{Alias: &alias}
+
+	t.Run("Forbidden", func(t *testing.T) {
+		mockAuthManager.EXPECT().CheckAuthorizationOnTargetUser(ctx, MGMTGetFederatedIdentities.String(), realmName, userID).Return(security.ForbiddenError{})
+		var _, err = authorizationMW.GetFederatedIdentities(ctx, realmName, userID)
+		assert.Equal(t, security.ForbiddenError{}, err)
+
+		mockAuthManager.EXPECT().CheckAuthorizationOnTargetUser(ctx, MGMTDeleteFederatedIdentity.String(), realmName, userID).Return(security.ForbiddenError{})
+		assert.Equal(t, security.ForbiddenError{}, authorizationMW.DeleteFederatedIdentity(ctx, realmName, userID, alias))
+
+		mockAuthManager.EXPECT().CheckAuthorizationOnTargetRealm(ctx, MGMTGetIdentityProviders.String(), realmName).Return(security.ForbiddenError{})
+		_, err = authorizationMW.GetIdentityProviders(ctx, realmName)
+		assert.Equal(t, security.ForbiddenError{}, err)
+
+		mockAuthManager.EXPECT().CheckAuthorizationOnTargetRealm(ctx, MGMTCreateIdentityProvider.String(), realmName).Return(security.ForbiddenError{})
+		assert.Equal(t, security.ForbiddenError{}, authorizationMW.CreateIdentityProvider(ctx, realmName, idp))
+
+		mockAuthManager.EXPECT().CheckAuthorizationOnTargetRealm(ctx, MGMTUpdateIdentityProvider.String(), realmName).Return(security.ForbiddenError{})
+		assert.Equal(t, security.ForbiddenError{}, authorizationMW.UpdateIdentityProvider(ctx, realmName, alias, idp))
+
+		mockAuthManager.EXPECT().CheckAuthorizationOnTargetRealm(ctx, MGMTDisableIdentityProvider.String(), realmName).Return(security.ForbiddenError{})
+		assert.Equal(t, security.ForbiddenError{}, authorizationMW.DisableIdentityProvider(ctx, realmName, alias))
+	})
+
+	t.Run("Allowed", func(t *testing.T) {
+		mockAuthManager.EXPECT().CheckAuthorizationOnTargetUser(ctx, MGMTGetFederatedIdentities.String(), realmName, userID).Return(nil)
+		mockManagementComponent.EXPECT().GetFederatedIdentities(ctx, realmName, userID).Return([]api.FederatedIdentityRepresentation{}, nil)
+		var _, err = authorizationMW.GetFederatedIdentities(ctx, realmName, userID)
+		assert.Nil(t, err)
+
+		mockAuthManager.EXPECT().CheckAuthorizationOnTargetUser(ctx, MGMTDeleteFederatedIdentity.String(), realmName, userID).Return(nil)
+		mockManagementComponent.EXPECT().DeleteFederatedIdentity(ctx, realmName, userID, alias).Return(nil)
+		assert.Nil(t, authorizationMW.DeleteFederatedIdentity(ctx, realmName, userID, alias))
+
+		mockAuthManager.EXPECT().CheckAuthorizationOnTargetRealm(ctx, MGMTGetIdentityProviders.String(), realmName).Return(nil)
+		mockManagementComponent.EXPECT().GetIdentityProviders(ctx, realmName).Return([]api.IdentityProviderRepresentation{}, nil)
+		_, err = authorizationMW.GetIdentityProviders(ctx, realmName)
+		assert.Nil(t, err)
+
+		mockAuthManager.EXPECT().CheckAuthorizationOnTargetRealm(ctx, MGMTCreateIdentityProvider.String(), realmName).Return(nil)
+		mockManagementComponent.EXPECT().CreateIdentityProvider(ctx, realmName, idp).Return(nil)
+		assert.Nil(t, authorizationMW.CreateIdentityProvider(ctx, realmName, idp))
+
+		mockAuthManager.EXPECT().CheckAuthorizationOnTargetRealm(ctx, MGMTUpdateIdentityProvider.String(), realmName).Return(nil)
+		mockManagementComponent.EXPECT().UpdateIdentityProvider(ctx, realmName, alias, idp).Return(nil)
+		assert.Nil(t, authorizationMW.UpdateIdentityProvider(ctx, realmName, alias, idp))
+
+		mockAuthManager.EXPECT().CheckAuthorizationOnTargetRealm(ctx, MGMTDisableIdentityProvider.String(), realmName).Return(nil)
+		mockManagementComponent.EXPECT().DisableIdentityProvider(ctx, realmName, alias).Return(nil)
+		assert.Nil(t, authorizationMW.DisableIdentityProvider(ctx, realmName, alias))
+	})
+}
+
+func TestPasswordPolicyAuthorization(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var mockManagementComponent = mock.NewManagementComponent(mockCtrl)
+	var mockAuthManager = mock.NewAuthorizationManager(mockCtrl)
+	var authorizationMW = MakeAuthorizationManagementComponentMW(log.NewNopLogger(), mockAuthManager)(mockManagementComponent)
+
+	var ctx = context.TODO()
+	var realmName = "master"
+	var length = 12
+	var policy = api.PasswordPolicyRepresentation{Length: &length}
+
+	t.Run("Forbidden", func(t *testing.T) {
+		mockAuthManager.EXPECT().CheckAuthorizationOnTargetRealm(ctx, MGMTGetPasswordPolicy.String(), realmName).Return(security.ForbiddenError{})
+		var _, err = authorizationMW.GetPasswordPolicy(ctx, realmName)
+		assert.Equal(t, security.ForbiddenError{}, err)
+
+		mockAuthManager.EXPECT().CheckAuthorizationOnTargetRealm(ctx, MGMTUpdatePasswordPolicy.String(), realmName).Return(security.ForbiddenError{})
+		assert.Equal(t, security.ForbiddenError{}, authorizationMW.UpdatePasswordPolicy(ctx, realmName, policy))
+	})
+
+	t.Run("Allowed", func(t *testing.T) {
+		mockAuthManager.EXPECT().CheckAuthorizationOnTargetRealm(ctx, MGMTGetPasswordPolicy.String(), realmName).Return(nil)
+		mockManagementComponent.EXPECT().GetPasswordPolicy(ctx, realmName).Return(policy, nil)
+		var res, err = authorizationMW.GetPasswordPolicy(ctx, realmName)
+		assert.Nil(t, err)
+		assert.Equal(t, policy, res)
+
+		mockAuthManager.EXPECT().CheckAuthorizationOnTargetRealm(ctx, MGMTUpdatePasswordPolicy.String(), realmName).Return(nil)
+		mockManagementComponent.EXPECT().UpdatePasswordPolicy(ctx, realmName, policy).Return(nil)
+		assert.Nil(t, authorizationMW.UpdatePasswordPolicy(ctx, realmName, policy))
+	})
+}
+
+func TestCredentialManagementAuthorization(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var mockManagementComponent = mock.NewManagementComponent(mockCtrl)
+	var mockAuthManager = mock.NewAuthorizationManager(mockCtrl)
+	var authorizationMW = MakeAuthorizationManagementComponentMW(log.NewNopLogger(), mockAuthManager)(mockManagementComponent)
+
+	var ctx = context.TODO()
+	var realmName = "master"
+	var userID = "123-456-789"
+	var credentialID = "987-654-321"
+	var previousCredentialID = "null"
+	var label = "My phone"
+	var credentialType = "otp"
+
+	t.Run("Forbidden", func(t *testing.T) {
+		mockAuthManager.EXPECT().CheckAuthorizationOnTargetUser(ctx, MGMTUpdateLabelCredentialForUser.String(), realmName, userID).Return(security.ForbiddenError{})
+		assert.Equal(t, security.ForbiddenError{}, authorizationMW.UpdateLabelCredentialForUser(ctx, realmName, userID, credentialID, label))
+
+		mockAuthManager.EXPECT().CheckAuthorizationOnTargetUser(ctx, MGMTMoveCredentialForUser.String(), realmName, userID).Return(security.ForbiddenError{})
+		assert.Equal(t, security.ForbiddenError{}, authorizationMW.MoveCredentialForUser(ctx, realmName, userID, credentialID, previousCredentialID))
+
+		mockAuthManager.EXPECT().CheckAuthorizationOnTargetUser(ctx, MGMTGetCredentialRegistratorsForUser.String(), realmName, userID).Return(security.ForbiddenError{})
+		var _, err = authorizationMW.GetCredentialRegistratorsForUser(ctx, realmName, userID)
+		assert.Equal(t, security.ForbiddenError{}, err)
+
+		mockAuthManager.EXPECT().CheckAuthorizationOnTargetUser(ctx, MGMTRequireReEnrollment.String(), realmName, userID).Return(security.ForbiddenError{})
+		assert.Equal(t, security.ForbiddenError{}, authorizationMW.RequireReEnrollment(ctx, realmName, userID, credentialType))
+	})
+
+	t.Run("Allowed", func(t *testing.T) {
+		mockAuthManager.EXPECT().CheckAuthorizationOnTargetUser(ctx, MGMTUpdateLabelCredentialForUser.String(), realmName, userID).Return(nil)
+		mockManagementComponent.EXPECT().UpdateLabelCredentialForUser(ctx, realmName, userID, credentialID, label).Return(nil)
+		assert.Nil(t, authorizationMW.UpdateLabelCredentialForUser(ctx, realmName, userID, credentialID, label))
+
+		mockAuthManager.EXPECT().CheckAuthorizationOnTargetUser(ctx, MGMTMoveCredentialForUser.String(), realmName, userID).Return(nil)
+		mockManagementComponent.EXPECT().MoveCredentialForUser(ctx, realmName, userID, credentialID, previousCredentialID).Return(nil)
+		assert.Nil(t, authorizationMW.MoveCredentialForUser(ctx, realmName, userID, credentialID, previousCredentialID))
+
+		mockAuthManager.EXPECT().CheckAuthorizationOnTargetUser(ctx, MGMTGetCredentialRegistratorsForUser.String(), realmName, userID).Return(nil)
+		mockManagementComponent.EXPECT().GetCredentialRegistratorsForUser(ctx, realmName, userID).Return([]string{credentialType}, nil)
+		var res, err = authorizationMW.GetCredentialRegistratorsForUser(ctx, realmName, userID)
+		assert.Nil(t, err)
+		assert.Equal(t, []string{credentialType}, res)
+
+		mockAuthManager.EXPECT().CheckAuthorizationOnTargetUser(ctx, MGMTRequireReEnrollment.String(), realmName, userID).Return(nil)
+		mockManagementComponent.EXPECT().RequireReEnrollment(ctx, realmName, userID, credentialType).Return(nil)
+		assert.Nil(t, authorizationMW.RequireReEnrollment(ctx, realmName, userID, credentialType))
+	})
+}
+
+func TestConfigurationRevisionsAuthorization(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var mockManagementComponent = mock.NewManagementComponent(mockCtrl)
+	var mockAuthManager = mock.NewAuthorizationManager(mockCtrl)
+	var authorizationMW = MakeAuthorizationManagementComponentMW(log.NewNopLogger(), mockAuthManager)(mockManagementComponent)
+
+	var ctx = context.TODO()
+	var realmName = "master"
+	var revisionID = int64(4)
+
+	t.Run("Forbidden", func(t *testing.T) {
+		mockAuthManager.EXPECT().CheckAuthorizationOnTargetRealm(ctx, MGMTGetConfigurationRevisions.String(), realmName).Return(security.ForbiddenError{})
+		var _, err = authorizationMW.GetConfigurationRevisions(ctx, realmName, nil)
+		assert.Equal(t, security.ForbiddenError{}, err)
+
+		mockAuthManager.EXPECT().CheckAuthorizationOnTargetRealm(ctx, MGMTGetConfigurationRevisions.String(), realmName).Return(security.ForbiddenError{})
+		_, err = authorizationMW.DiffConfigurationRevisions(ctx, realmName, 1, revisionID)
+		assert.Equal(t, security.ForbiddenError{}, err)
+
+		mockAuthManager.EXPECT().CheckAuthorizationOnTargetRealm(ctx, MGMTRollbackConfiguration.String(), realmName).Return(security.ForbiddenError{})
+		assert.Equal(t, security.ForbiddenError{}, authorizationMW.RollbackConfiguration(ctx, realmName, revisionID))
+	})
+
+	t.Run("Allowed", func(t *testing.T) {
+		mockAuthManager.EXPECT().CheckAuthorizationOnTargetRealm(ctx, MGMTGetConfigurationRevisions.String(), realmName).Return(nil)
+		mockManagementComponent.EXPECT().GetConfigurationRevisions(ctx, realmName, nil).Return(nil, nil)
+		var _, err = authorizationMW.GetConfigurationRevisions(ctx, realmName, nil)
+		assert.Nil(t, err)
+
+		mockAuthManager.EXPECT().CheckAuthorizationOnTargetRealm(ctx, MGMTGetConfigurationRevisions.String(), realmName).Return(nil)
+		mockManagementComponent.EXPECT().DiffConfigurationRevisions(ctx, realmName, int64(1), revisionID).Return(nil, nil)
+		_, err = authorizationMW.DiffConfigurationRevisions(ctx, realmName, 1, revisionID)
+		assert.Nil(t, err)
+
+		mockAuthManager.EXPECT().CheckAuthorizationOnTargetRealm(ctx, MGMTRollbackConfiguration.String(), realmName).Return(nil)
+		mockManagementComponent.EXPECT().RollbackConfiguration(ctx, realmName, revisionID).Return(nil)
+		assert.Nil(t, authorizationMW.RollbackConfiguration(ctx, realmName, revisionID))
+	})
+}
+
+func TestProvisioningAuthorization(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var mockProvisioningComponent = mock.NewProvisioningComponent(mockCtrl)
+	var mockAuthManager = mock.NewAuthorizationManager(mockCtrl)
+	var authorizationMW = MakeAuthorizationProvisioningComponentMW(log.NewNopLogger(), mockAuthManager)(mockProvisioningComponent)
+
+	var ctx = context.TODO()
+	var realmName = "customer"
+	var template = api.RealmTemplateRepresentation{Realm: &realmName}
+
+	t.Run("Forbidden", func(t *testing.T) {
+		mockAuthManager.EXPECT().CheckAuthorizationOnTargetRealm(ctx, MGMTProvisionRealm.String(), realmName).Return(security.ForbiddenError{})
+		var _, err = authorizationMW.ProvisionRealm(ctx, template, false)
+		assert.Equal(t, security.ForbiddenError{}, err)
+	})
+
+	t.Run("Allowed", func(t *testing.T) {
+		mockAuthManager.EXPECT().CheckAuthorizationOnTargetRealm(ctx, MGMTProvisionRealm.String(), realmName).Return(nil)
+		mockProvisioningComponent.EXPECT().ProvisionRealm(ctx, template, true).Return(api.ProvisioningPlanRepresentation{Realm: realmName}, nil)
+		var plan, err = authorizationMW.ProvisionRealm(ctx, template, true)
+		assert.Nil(t, err)
+		assert.Equal(t, realmName, plan.Realm)
+	})
+}
+
+func TestAuthorizationsReportAuthorization(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var mockReportComponent = mock.NewAuthorizationsReportComponent(mockCtrl)
+	var mockAuthManager = mock.NewAuthorizationManager(mockCtrl)
+	var authorizationMW = MakeAuthorizationAuthorizationsReportComponentMW(log.NewNopLogger(), mockAuthManager)(mockReportComponent)
+
+	var ctx = context.TODO()
+	var realmName = "customer"
+	var userID = "b5c7a3f2-0a1d-4eee-9bb8-669c6f89c0ee"
+	var groupName = "operators"
+
+	t.Run("User report-Forbidden", func(t *testing.T) {
+		mockAuthManager.EXPECT().CheckAuthorizationOnTargetUser(ctx, MGMTGetUserAuthorizationsReport.String(), realmName, userID).Return(security.ForbiddenError{})
+		var _, err = authorizationMW.GetUserAuthorizationsReport(ctx, realmName, userID)
+		assert.Equal(t, security.ForbiddenError{}, err)
+	})
+
+	t.Run("User report-Allowed", func(t *testing.T) {
+		mockAuthManager.EXPECT().CheckAuthorizationOnTargetUser(ctx, MGMTGetUserAuthorizationsReport.String(), realmName, userID).Return(nil)
+		mockReportComponent.EXPECT().GetUserAuthorizationsReport(ctx, realmName, userID).Return(api.UserAuthorizationsReportRepresentation{UserID: userID}, nil)
+		var report, err = authorizationMW.GetUserAuthorizationsReport(ctx, realmName, userID)
+		assert.Nil(t, err)
+		assert.Equal(t, userID, report.UserID)
+	})
+
+	t.Run("Realm report-Forbidden", func(t *testing.T) {
+		mockAuthManager.EXPECT().CheckAuthorizationOnTargetRealm(ctx, MGMTGetTargetAuthorizationsReport.String(), realmName).Return(security.ForbiddenError{})
+		var _, err = authorizationMW.GetTargetAuthorizationsReport(ctx, realmName, nil)
+		assert.Equal(t, security.ForbiddenError{}, err)
+	})
+
+	t.Run("Group report-Allowed", func(t *testing.T) {
+		mockAuthManager.EXPECT().CheckAuthorizationOnTargetGroup(ctx, MGMTGetTargetAuthorizationsReport.String(), realmName, groupName).Return(nil)
+		mockReportComponent.EXPECT().GetTargetAuthorizationsReport(ctx, realmName, &groupName).Return(api.TargetAuthorizationsReportRepresentation{TargetRealm: realmName}, nil)
+		var report, err = authorizationMW.GetTargetAuthorizationsReport(ctx, realmName, &groupName)
+		assert.Nil(t, err)
+		assert.Equal(t, realmName, report.TargetRealm)
+	})
+}
@@ -0,0 +1,344 @@
+package management
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+
+	cs "github.com/cloudtrust/common-service"
+	"github.com/cloudtrust/common-service/configuration"
+	errorhandler "github.com/cloudtrust/common-service/errors"
+	"github.com/cloudtrust/common-service/security"
+	api "github.com/cloudtrust/keycloak-bridge/api/management"
+	"github.com/cloudtrust/keycloak-bridge/internal/constants"
+	"github.com/cloudtrust/keycloak-bridge/internal/keycloakb"
+	kc "github.com/cloudtrust/keycloak-client"
+)
+
+const reportMembersPageSize = 100
+
+// AuthorizationsReportComponent gives the inverse views of the authorizations matrix: the actions allowed to a user and
+// the users allowed to act on a realm or a group
+type AuthorizationsReportComponent interface {
+	GetUserAuthorizationsReport(ctx context.Context, realmName, userID string) (api.UserAuthorizationsReportRepresentation, error)
+	GetTargetAuthorizationsReport(ctx context.Context, targetRealm string, targetGroupName *string) (api.TargetAuthorizationsReportRepresentation, error)
+}
+
+type authorizationsReportComponent struct {
+	keycloakClient KeycloakClient
+	configDBModule keycloakb.ConfigurationDBModule
+	authManager    security.AuthorizationManager
+	logger         keycloakb.Logger
+}
+
+// NewAuthorizationsReportComponent returns an authorizations report component. The authorization manager restricts the
+// realms and the groups of the other realms listed by a report to the realms the caller is authorized on.
+func NewAuthorizationsReportComponent(keycloakClient KeycloakClient, configDBModule keycloakb.ConfigurationDBModule, authManager security.AuthorizationManager, logger keycloakb.Logger) AuthorizationsReportComponent {
+	return &authorizationsReportComponent{
+		keycloakClient: keycloakClient,
+		configDBModule: configDBModule,
+		authManager:    authManager,
+		logger:         logger,
+	}
+}
+
+// GetUserAuthorizationsReport lists the authorizations given to the groups of a user. The wildcards and the subtree targets
+// are expanded into the realms and the groups they stand for, as far as the caller is allowed to get the user authorizations
+// report of these realms.
+func (c *authorizationsReportComponent) GetUserAuthorizationsReport(ctx context.Context, realmName, userID string) (api.UserAuthorizationsReportRepresentation, error) {
+	var accessToken = ctx.Value(cs.CtContextAccessToken).(string)
+
+	user, err := c.keycloakClient.GetUser(accessToken, realmName, userID)
+	if err != nil {
+		c.logger.Warn(ctx, "err", err.Error())
+		return api.UserAuthorizationsReportRepresentation{}, err
+	}
+	groups, err := c.keycloakClient.GetGroupsOfUser(accessToken, realmName, userID)
+	if err != nil {
+		c.logger.Warn(ctx, "err", err.Error())
+		return api.UserAuthorizationsReportRepresentation{}, err
+	}
+
+	var groupsByRealm = make(map[string]map[string]kc.GroupRepresentation)
+	var realms []string
+	var allowedRealms = map[string]bool{realmName: true}
+	var reported = make(map[string]bool)
+	var report = api.UserAuthorizationsReportRepresentation{
+		Realm:          realmName,
+		UserID:         userID,
+		Username:       user.Username,
+		Groups:         []string{},
+		Authorizations: []api.EffectiveAuthorizationRepresentation{},
+	}
+	for _, group := range groups {
+		if group.Name == nil {
+			continue
+		}
+		report.Groups = append(report.Groups, *group.Name)
+
+		authorizations, err := c.configDBModule.GetAuthorizations(ctx, realmName, *group.Name)
+		if err != nil {
+			c.logger.Warn(ctx, "err", err.Error())
+			return api.UserAuthorizationsReportRepresentation{}, err
+		}
+		for _, authz := range authorizations {
+			expanded, err := c.expandTargets(ctx, accessToken, toEffectiveAuthorization(authz), &realms, allowedRealms, groupsByRealm)
+			if err != nil {
+				return api.UserAuthorizationsReportRepresentation{}, err
+			}
+			// The same action may be allowed on a target by several authorizations
+			for _, effective := range expanded {
+				if key := effectiveAuthorizationKey(effective); !reported[key] {
+					reported[key] = true
+					report.Authorizations = append(report.Authorizations, effective)
+				}
+			}
+		}
+	}
+	sort.Strings(report.Groups)
+	sortEffectiveAuthorizations(report.Authorizations)
+
+	return report, nil
+}
+
+// GetTargetAuthorizationsReport lists the groups, of any realm, whose authorizations apply to the target realm or, if a
+// group name is given, to this group of the target realm. The wildcards and the subtree targets are resolved. The groups
+// of another realm are only listed when the caller is allowed to get the target authorizations report of this realm.
+func (c *authorizationsReportComponent) GetTargetAuthorizationsReport(ctx context.Context, targetRealm string, targetGroupName *string) (api.TargetAuthorizationsReportRepresentation, error) {
+	var accessToken = ctx.Value(cs.CtContextAccessToken).(string)
+	var groupsByRealm = make(map[string]map[string]kc.GroupRepresentation)
+
+	var targetGroupPath string
+	if targetGroupName != nil {
+		var groups, err = c.getGroupsByName(ctx, accessToken, targetRealm, groupsByRealm)
+		if err != nil {
+			return api.TargetAuthorizationsReportRepresentation{}, err
+		}
+		var group, ok = groups[*targetGroupName]
+		if !ok {
+			c.logger.Warn(ctx, "msg", "Unknown target group", "realm", targetRealm, "group", *targetGroupName)
+			return api.TargetAuthorizationsReportRepresentation{}, errorhandler.CreateNotFoundError(constants.GroupName)
+		}
+		targetGroupPath = groupPath(group)
+	}
+
+	authorizations, err := c.configDBModule.GetAuthorizationsOnTargetRealm(ctx, targetRealm)
+	if err != nil {
+		c.logger.Warn(ctx, "err", err.Error())
+		return api.TargetAuthorizationsReportRepresentation{}, err
+	}
+
+	// Authorizations are grouped by the group which holds them
+	var holders = make(map[[2]string]*api.AuthorizedGroupRepresentation)
+	for _, authz := range authorizations {
+		if targetGroupName != nil && !appliesToGroup(authz.TargetGroupName, *targetGroupName, targetGroupPath) {
+			continue
+		}
+		var key = [2]string{*authz.RealmID, *authz.GroupName}
+		if _, ok := holders[key]; !ok {
+			holders[key] = &api.AuthorizedGroupRepresentation{Realm: key[0], Group: key[1]}
+		}
+		holders[key].Authorizations = append(holders[key].Authorizations, toEffectiveAuthorization(authz))
+	}
+
+	// The administrators of the other realms are only disclosed to the callers authorized on these realms
+	var allowedRealms = map[string]bool{targetRealm: true}
+	for key := range holders {
+		if !c.isAllowedOnRealm(ctx, MGMTGetTargetAuthorizationsReport, key[0], allowedRealms) {
+			delete(holders, key)
+		}
+	}
+
+	var report = api.TargetAuthorizationsReportRepresentation{
+		TargetRealm: targetRealm,
+		TargetGroup: targetGroupName,
+		Groups:      []api.AuthorizedGroupRepresentation{},
+	}
+	for _, holder := range holders {
+		sortEffectiveAuthorizations(holder.Authorizations)
+		report.Groups = append(report.Groups, *holder)
+	}
+	sort.Slice(report.Groups, func(i, j int) bool {
+		if report.Groups[i].Realm != report.Groups[j].Realm {
+			return report.Groups[i].Realm < report.Groups[j].Realm
+		}
+		return report.Groups[i].Group < report.Groups[j].Group
+	})
+	for i, group := range report.Groups {
+		if report.Groups[i].Members, err = c.getMembers(ctx, accessToken, group.Realm, group.Group, groupsByRealm); err != nil {
+			return api.TargetAuthorizationsReportRepresentation{}, err
+		}
+	}
+
+	return report, nil
+}
+
+// isAllowedOnRealm tells whether the caller is allowed to do the action on a realm. Answers are kept in allowedRealms.
+func (c *authorizationsReportComponent) isAllowedOnRealm(ctx context.Context, action security.Action, realmName string, allowedRealms map[string]bool) bool {
+	var allowed, ok = allowedRealms[realmName]
+	if !ok {
+		allowed = c.authManager.CheckAuthorizationOnTargetRealm(ctx, action.String(), realmName) == nil
+		allowedRealms[realmName] = allowed
+	}
+	return allowed
+}
+
+// expandTargets replaces the "*" target realm by the realms and the "*" and "/path/*" target groups by the groups they
+// stand for. The realms are read once, on the first wildcard met. Only the realms the caller is allowed on are expanded:
+// the authorization is kept as is for the others, so that neither their names nor their groups are disclosed.
+func (c *authorizationsReportComponent) expandTargets(ctx context.Context, accessToken string, authz api.EffectiveAuthorizationRepresentation, realms *[]string,
+	allowedRealms map[string]bool, groupsByRealm map[string]map[string]kc.GroupRepresentation) ([]api.EffectiveAuthorizationRepresentation, error) {
+	if authz.TargetRealm == nil {
+		return []api.EffectiveAuthorizationRepresentation{authz}, nil
+	}
+
+	var res []api.EffectiveAuthorizationRepresentation
+	var targetRealms = []string{*authz.TargetRealm}
+	if *authz.TargetRealm == "*" {
+		if *realms == nil {
+			kcRealms, err := c.keycloakClient.GetRealms(accessToken)
+			if err != nil {
+				c.logger.Warn(ctx, "err", err.Error())
+				return nil, err
+			}
+			*realms = []string{}
+			for _, realm := range kcRealms {
+				if realm.ID != nil {
+					*realms = append(*realms, *realm.ID)
+				}
+			}
+		}
+		targetRealms = *realms
+	}
+
+	var hidden = false
+	for _, targetRealm := range targetRealms {
+		var targetRealm = targetRealm
+		if !c.isAllowedOnRealm(ctx, MGMTGetUserAuthorizationsReport, targetRealm, allowedRealms) {
+			hidden = true
+			continue
+		}
+		var realmAuthz = authz
+		realmAuthz.TargetRealm = &targetRealm
+		if authz.TargetGroup == nil || (*authz.TargetGroup != "*" && !strings.HasSuffix(*authz.TargetGroup, "/*")) {
+			res = append(res, realmAuthz)
+			continue
+		}
+
+		groups, err := c.getGroupsByName(ctx, accessToken, targetRealm, groupsByRealm)
+		if err != nil {
+			return nil, err
+		}
+		for groupName, group := range groups {
+			if appliesToGroup(authz.TargetGroup, groupName, groupPath(group)) {
+				var groupName = groupName
+				var groupAuthz = realmAuthz
+				groupAuthz.TargetGroup = &groupName
+				res = append(res, groupAuthz)
+			}
+		}
+	}
+	if hidden {
+		res = append(res, authz)
+	}
+	return res, nil
+}
+
+// appliesToGroup tells whether an authorization with the given target group name applies to a group. Authorizations
+// without target group are given on the realm itself.
+func appliesToGroup(authzTargetGroup *string, groupName, groupPath string) bool {
+	if authzTargetGroup == nil {
+		return false
+	}
+	var target = *authzTargetGroup
+	if target == "*" || target == groupName {
+		return true
+	}
+	// Subtree targets apply to the group with the given path and to its descendants
+	return strings.HasSuffix(target, "/*") && strings.HasPrefix(groupPath+"/", strings.TrimSuffix(target, "*"))
+}
+
+// getMembers gets the users of a group. The group may have been deleted since the authorizations were given.
+func (c *authorizationsReportComponent) getMembers(ctx context.Context, accessToken, realmName, groupName string, groupsByRealm map[string]map[string]kc.GroupRepresentation) ([]api.AuthorizedUserRepresentation, error) {
+	var groups, err = c.getGroupsByName(ctx, accessToken, realmName, groupsByRealm)
+	if err != nil {
+		return nil, err
+	}
+	var members = []api.AuthorizedUserRepresentation{}
+	var group, ok = groups[groupName]
+	if !ok || group.ID == nil {
+		return members, nil
+	}
+
+	var ctxRealm = ctx.Value(cs.CtContextRealm).(string)
+	for first := 0; ; first += reportMembersPageSize {
+		page, err := c.keycloakClient.GetUsers(accessToken, ctxRealm, realmName, "groupId", *group.ID, prmQryFirst, strconv.Itoa(first), prmQryMax, strconv.Itoa(reportMembersPageSize))
+		if err != nil {
+			c.logger.Warn(ctx, "err", err.Error())
+			return nil, err
+		}
+		for _, user := range page.Users {
+			if user.ID != nil {
+				members = append(members, api.AuthorizedUserRepresentation{ID: *user.ID, Username: user.Username})
+			}
+		}
+		if len(page.Users) < reportMembersPageSize {
+			return members, nil
+		}
+	}
+}
+
+// getGroupsByName indexes the groups of a realm, subgroups included, by name. The groups are read once per realm.
+func (c *authorizationsReportComponent) getGroupsByName(ctx context.Context, accessToken, realmName string, groupsByRealm map[string]map[string]kc.GroupRepresentation) (map[string]kc.GroupRepresentation, error) {
+	if groups, ok := groupsByRealm[realmName]; ok {
+		return groups, nil
+	}
+	var groups, err = c.keycloakClient.GetGroups(accessToken, realmName)
+	if err != nil {
+		c.logger.Warn(ctx, "err", err.Error())
+		return nil, err
+	}
+	var res = make(map[string]kc.GroupRepresentation)
+	indexGroupsByName(groups, res)
+	groupsByRealm[realmName] = res
+	return res, nil
+}
+
+func indexGroupsByName(groups []kc.GroupRepresentation, res map[string]kc.GroupRepresentation) {
+	for _, group := range groups {
+		if group.Name != nil {
+			res[*group.Name] = group
+		}
+		if group.SubGroups != nil {
+			indexGroupsByName(*group.SubGroups, res)
+		}
+	}
+}
+
+func toEffectiveAuthorization(authz configuration.Authorization) api.EffectiveAuthorizationRepresentation {
+	return api.EffectiveAuthorizationRepresentation{
+		Realm:       *authz.RealmID,
+		Group:       *authz.GroupName,
+		Action:      *authz.Action,
+		TargetRealm: authz.TargetRealmID,
+		TargetGroup: authz.TargetGroupName,
+	}
+}
+
+func effectiveAuthorizationKey(authz api.EffectiveAuthorizationRepresentation) string {
+	var targetRealm, targetGroup string
+	if authz.TargetRealm != nil {
+		targetRealm = *authz.TargetRealm
+	}
+	if authz.TargetGroup != nil {
+		targetGroup = *authz.TargetGroup
+	}
+	return strings.Join([]string{authz.Group, authz.Action, targetRealm, targetGroup}, "\x00")
+}
+
+func sortEffectiveAuthorizations(authorizations []api.EffectiveAuthorizationRepresentation) {
+	sort.Slice(authorizations, func(i, j int) bool {
+		return effectiveAuthorizationKey(authorizations[i]) < effectiveAuthorizationKey(authorizations[j])
+	})
+}
@@ -0,0 +1,264 @@
+package management
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	cs "github.com/cloudtrust/common-service"
+	"github.com/cloudtrust/common-service/configuration"
+	"github.com/cloudtrust/common-service/log"
+	"github.com/cloudtrust/common-service/security"
+	api "github.com/cloudtrust/keycloak-bridge/api/management"
+	"github.com/cloudtrust/keycloak-bridge/pkg/management/mock"
+	kc "github.com/cloudtrust/keycloak-client"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func createAuthorization(realmID, groupName, action string, targetRealmID, targetGroupName *string) configuration.Authorization {
+	return configuration.Authorization{
+		RealmID:         &realmID,
+		GroupName:       &groupName,
+		Action:          &action,
+		TargetRealmID:   targetRealmID,
+		TargetGroupName: targetGroupName,
+	}
+}
+
+func TestGetUserAuthorizationsReport(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+	var mockKeycloakClient = mock.NewKeycloakClient(mockCtrl)
+	var mockConfigDBModule = mock.NewConfigurationDBModule(mockCtrl)
+	var mockAuthManager = mock.NewAuthorizationManager(mockCtrl)
+
+	var component = NewAuthorizationsReportComponent(mockKeycloakClient, mockConfigDBModule, mockAuthManager, log.NewNopLogger())
+
+	var accessToken = "TOKEN=="
+	var ctx = context.WithValue(context.Background(), cs.CtContextAccessToken, accessToken)
+	var realmName = "customer"
+	var userID = "b5c7a3f2-0a1d-4eee-9bb8-669c6f89c0ee"
+	var username = "jdoe"
+	var operators = "operators"
+	var auditors = "auditors"
+	var otherRealm = "other"
+	var anyTarget = "*"
+	var orgName, orgPath, orgSubtree = "org", "/org", "/org/*"
+	var supportName, supportPath = "support", "/org/support"
+	var auditorsPath = "/auditors"
+	var expectedError = errors.New("error")
+
+	var customerGroups = []kc.GroupRepresentation{
+		{Name: &orgName, Path: &orgPath, SubGroups: &[]kc.GroupRepresentation{{Name: &supportName, Path: &supportPath}}},
+		{Name: &auditors, Path: &auditorsPath},
+	}
+
+	t.Run("Can't get groups of user", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().GetUser(accessToken, realmName, userID).Return(kc.UserRepresentation{Username: &username}, nil)
+		mockKeycloakClient.EXPECT().GetGroupsOfUser(accessToken, realmName, userID).Return(nil, expectedError)
+		var _, err = component.GetUserAuthorizationsReport(ctx, realmName, userID)
+		assert.Equal(t, expectedError, err)
+	})
+
+	t.Run("Can't get authorizations", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().GetUser(accessToken, realmName, userID).Return(kc.UserRepresentation{Username: &username}, nil)
+		mockKeycloakClient.EXPECT().GetGroupsOfUser(accessToken, realmName, userID).Return([]kc.GroupRepresentation{{Name: &operators}}, nil)
+		mockConfigDBModule.EXPECT().GetAuthorizations(ctx, realmName, operators).Return(nil, expectedError)
+		var _, err = component.GetUserAuthorizationsReport(ctx, realmName, userID)
+		assert.Equal(t, expectedError, err)
+	})
+
+	t.Run("Can't get realms", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().GetUser(accessToken, realmName, userID).Return(kc.UserRepresentation{Username: &username}, nil)
+		mockKeycloakClient.EXPECT().GetGroupsOfUser(accessToken, realmName, userID).Return([]kc.GroupRepresentation{{Name: &auditors}}, nil)
+		mockConfigDBModule.EXPECT().GetAuthorizations(ctx, realmName, auditors).Return([]configuration.Authorization{
+			createAuthorization(realmName, auditors, "MGMT_GetRealm", &anyTarget, nil),
+		}, nil)
+		mockKeycloakClient.EXPECT().GetRealms(accessToken).Return(nil, expectedError)
+		var _, err = component.GetUserAuthorizationsReport(ctx, realmName, userID)
+		assert.Equal(t, expectedError, err)
+	})
+
+	t.Run("Can't get groups of target realm", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().GetUser(accessToken, realmName, userID).Return(kc.UserRepresentation{Username: &username}, nil)
+		mockKeycloakClient.EXPECT().GetGroupsOfUser(accessToken, realmName, userID).Return([]kc.GroupRepresentation{{Name: &operators}}, nil)
+		mockConfigDBModule.EXPECT().GetAuthorizations(ctx, realmName, operators).Return([]configuration.Authorization{
+			createAuthorization(realmName, operators, "MGMT_GetUsers", &realmName, &anyTarget),
+		}, nil)
+		mockKeycloakClient.EXPECT().GetGroups(accessToken, realmName).Return(nil, expectedError)
+		var _, err = component.GetUserAuthorizationsReport(ctx, realmName, userID)
+		assert.Equal(t, expectedError, err)
+	})
+
+	t.Run("Authorizations of all the groups", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().GetUser(accessToken, realmName, userID).Return(kc.UserRepresentation{Username: &username}, nil)
+		mockKeycloakClient.EXPECT().GetGroupsOfUser(accessToken, realmName, userID).Return([]kc.GroupRepresentation{{Name: &operators}, {Name: &auditors}}, nil)
+		mockConfigDBModule.EXPECT().GetAuthorizations(ctx, realmName, operators).Return([]configuration.Authorization{
+			createAuthorization(realmName, operators, "MGMT_UpdateUser", &realmName, &auditors),
+			createAuthorization(realmName, operators, "MGMT_GetUsers", &realmName, &anyTarget),
+			createAuthorization(realmName, operators, "MGMT_GetUsers", &realmName, &auditors),
+			createAuthorization(realmName, operators, "MGMT_GetUser", &realmName, &orgSubtree),
+		}, nil)
+		mockConfigDBModule.EXPECT().GetAuthorizations(ctx, realmName, auditors).Return([]configuration.Authorization{
+			createAuthorization(realmName, auditors, "MGMT_GetRealm", &anyTarget, nil),
+		}, nil)
+		mockKeycloakClient.EXPECT().GetGroups(accessToken, realmName).Return(customerGroups, nil)
+		mockKeycloakClient.EXPECT().GetRealms(accessToken).Return([]kc.RealmRepresentation{{ID: &realmName}, {ID: &otherRealm}}, nil)
+		mockAuthManager.EXPECT().CheckAuthorizationOnTargetRealm(ctx, "MGMT_GetUserAuthorizationsReport", otherRealm).Return(nil)
+
+		var report, err = component.GetUserAuthorizationsReport(ctx, realmName, userID)
+		assert.Nil(t, err)
+		assert.Equal(t, username, *report.Username)
+		assert.Equal(t, []string{auditors, operators}, report.Groups)
+		assert.Equal(t, []api.EffectiveAuthorizationRepresentation{
+			{Realm: realmName, Group: auditors, Action: "MGMT_GetRealm", TargetRealm: &realmName},
+			{Realm: realmName, Group: auditors, Action: "MGMT_GetRealm", TargetRealm: &otherRealm},
+			{Realm: realmName, Group: operators, Action: "MGMT_GetUser", TargetRealm: &realmName, TargetGroup: &orgName},
+			{Realm: realmName, Group: operators, Action: "MGMT_GetUser", TargetRealm: &realmName, TargetGroup: &supportName},
+			{Realm: realmName, Group: operators, Action: "MGMT_GetUsers", TargetRealm: &realmName, TargetGroup: &auditors},
+			{Realm: realmName, Group: operators, Action: "MGMT_GetUsers", TargetRealm: &realmName, TargetGroup: &orgName},
+			{Realm: realmName, Group: operators, Action: "MGMT_GetUsers", TargetRealm: &realmName, TargetGroup: &supportName},
+			{Realm: realmName, Group: operators, Action: "MGMT_UpdateUser", TargetRealm: &realmName, TargetGroup: &auditors},
+		}, report.Authorizations)
+	})
+
+	t.Run("Realms the caller is not allowed on are not expanded", func(t *testing.T) {
+		var thirdRealm = "third"
+		mockKeycloakClient.EXPECT().GetUser(accessToken, realmName, userID).Return(kc.UserRepresentation{Username: &username}, nil)
+		mockKeycloakClient.EXPECT().GetGroupsOfUser(accessToken, realmName, userID).Return([]kc.GroupRepresentation{{Name: &auditors}}, nil)
+		mockConfigDBModule.EXPECT().GetAuthorizations(ctx, realmName, auditors).Return([]configuration.Authorization{
+			createAuthorization(realmName, auditors, "MGMT_GetRealm", &anyTarget, nil),
+			createAuthorization(realmName, auditors, "MGMT_GetUsers", &otherRealm, &anyTarget),
+		}, nil)
+		mockKeycloakClient.EXPECT().GetRealms(accessToken).Return([]kc.RealmRepresentation{{ID: &realmName}, {ID: &otherRealm}, {ID: &thirdRealm}}, nil)
+		mockAuthManager.EXPECT().CheckAuthorizationOnTargetRealm(ctx, "MGMT_GetUserAuthorizationsReport", otherRealm).Return(security.ForbiddenError{})
+		mockAuthManager.EXPECT().CheckAuthorizationOnTargetRealm(ctx, "MGMT_GetUserAuthorizationsReport", thirdRealm).Return(nil)
+
+		var report, err = component.GetUserAuthorizationsReport(ctx, realmName, userID)
+		assert.Nil(t, err)
+		assert.Equal(t, []api.EffectiveAuthorizationRepresentation{
+			{Realm: realmName, Group: auditors, Action: "MGMT_GetRealm", TargetRealm: &anyTarget},
+			{Realm: realmName, Group: auditors, Action: "MGMT_GetRealm", TargetRealm: &realmName},
+			{Realm: realmName, Group: auditors, Action: "MGMT_GetRealm", TargetRealm: &thirdRealm},
+			{Realm: realmName, Group: auditors, Action: "MGMT_GetUsers", TargetRealm: &otherRealm, TargetGroup: &anyTarget},
+		}, report.Authorizations)
+	})
+}
+
+func TestGetTargetAuthorizationsReport(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+	var mockKeycloakClient = mock.NewKeycloakClient(mockCtrl)
+	var mockConfigDBModule = mock.NewConfigurationDBModule(mockCtrl)
+	var mockAuthManager = mock.NewAuthorizationManager(mockCtrl)
+
+	var component = NewAuthorizationsReportComponent(mockKeycloakClient, mockConfigDBModule, mockAuthManager, log.NewNopLogger())
+
+	var accessToken = "TOKEN=="
+	var ctxRealm = "master"
+	var ctx = context.WithValue(context.Background(), cs.CtContextAccessToken, accessToken)
+	ctx = context.WithValue(ctx, cs.CtContextRealm, ctxRealm)
+	var realmName = "customer"
+	var anyTarget = "*"
+	var orgID, orgName, orgPath, orgSubtree = "org-id", "org", "/org", "/org/*"
+	var supportID, supportName, supportPath = "support-id", "support", "/org/support"
+	var salesName = "sales"
+	var adminsID, adminsName = "admins-id", "admins"
+	var userID, username = "b5c7a3f2-0a1d-4eee-9bb8-669c6f89c0ee", "jdoe"
+	var expectedError = errors.New("error")
+
+	var customerGroups = []kc.GroupRepresentation{
+		{ID: &orgID, Name: &orgName, Path: &orgPath, SubGroups: &[]kc.GroupRepresentation{{ID: &supportID, Name: &supportName, Path: &supportPath}}},
+	}
+	var authorizations = []configuration.Authorization{
+		createAuthorization(ctxRealm, adminsName, "MGMT_GetUsers", &anyTarget, &anyTarget),
+		createAuthorization(realmName, orgName, "MGMT_UpdateUser", &realmName, &orgSubtree),
+		createAuthorization(realmName, orgName, "MGMT_GetRealm", &realmName, nil),
+		createAuthorization(realmName, supportName, "MGMT_GetUser", &realmName, &salesName),
+	}
+
+	t.Run("Unknown target group", func(t *testing.T) {
+		var unknown = "unknown"
+		mockKeycloakClient.EXPECT().GetGroups(accessToken, realmName).Return(customerGroups, nil)
+		var _, err = component.GetTargetAuthorizationsReport(ctx, realmName, &unknown)
+		assert.NotNil(t, err)
+	})
+
+	t.Run("Can't get authorizations", func(t *testing.T) {
+		mockConfigDBModule.EXPECT().GetAuthorizationsOnTargetRealm(ctx, realmName).Return(nil, expectedError)
+		var _, err = component.GetTargetAuthorizationsReport(ctx, realmName, nil)
+		assert.Equal(t, expectedError, err)
+	})
+
+	t.Run("Wildcards and subtrees are resolved for a group", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().GetGroups(accessToken, realmName).Return(customerGroups, nil)
+		mockConfigDBModule.EXPECT().GetAuthorizationsOnTargetRealm(ctx, realmName).Return(authorizations, nil)
+		mockAuthManager.EXPECT().CheckAuthorizationOnTargetRealm(ctx, MGMTGetTargetAuthorizationsReport.String(), ctxRealm).Return(nil)
+		mockKeycloakClient.EXPECT().GetGroups(accessToken, ctxRealm).Return([]kc.GroupRepresentation{{ID: &adminsID, Name: &adminsName}}, nil)
+		mockKeycloakClient.EXPECT().GetUsers(accessToken, ctxRealm, ctxRealm, "groupId", adminsID, "first", "0", "max", "100").
+			Return(kc.UsersPageRepresentation{Users: []kc.UserRepresentation{{ID: &userID, Username: &username}}}, nil)
+		mockKeycloakClient.EXPECT().GetUsers(accessToken, ctxRealm, realmName, "groupId", orgID, "first", "0", "max", "100").
+			Return(kc.UsersPageRepresentation{}, nil)
+
+		var report, err = component.GetTargetAuthorizationsReport(ctx, realmName, &supportName)
+		assert.Nil(t, err)
+		assert.Equal(t, []api.AuthorizedGroupRepresentation{
+			{
+				Realm:          realmName,
+				Group:          orgName,
+				Authorizations: []api.EffectiveAuthorizationRepresentation{{Realm: realmName, Group: orgName, Action: "MGMT_UpdateUser", TargetRealm: &realmName, TargetGroup: &orgSubtree}},
+				Members:        []api.AuthorizedUserRepresentation{},
+			},
+			{
+				Realm:          ctxRealm,
+				Group:          adminsName,
+				Authorizations: []api.EffectiveAuthorizationRepresentation{{Realm: ctxRealm, Group: adminsName, Action: "MGMT_GetUsers", TargetRealm: &anyTarget, TargetGroup: &anyTarget}},
+				Members:        []api.AuthorizedUserRepresentation{{ID: userID, Username: &username}},
+			},
+		}, report.Groups)
+	})
+
+	t.Run("Can't get members", func(t *testing.T) {
+		mockConfigDBModule.EXPECT().GetAuthorizationsOnTargetRealm(ctx, realmName).Return(authorizations, nil)
+		mockAuthManager.EXPECT().CheckAuthorizationOnTargetRealm(ctx, MGMTGetTargetAuthorizationsReport.String(), ctxRealm).Return(nil)
+		mockKeycloakClient.EXPECT().GetGroups(accessToken, realmName).Return(customerGroups, nil)
+		mockKeycloakClient.EXPECT().GetUsers(accessToken, ctxRealm, realmName, "groupId", orgID, "first", "0", "max", "100").Return(kc.UsersPageRepresentation{}, expectedError)
+
+		var _, err = component.GetTargetAuthorizationsReport(ctx, realmName, nil)
+		assert.Equal(t, expectedError, err)
+	})
+
+	t.Run("All the authorizations on the realm", func(t *testing.T) {
+		mockConfigDBModule.EXPECT().GetAuthorizationsOnTargetRealm(ctx, realmName).Return(authorizations, nil)
+		mockAuthManager.EXPECT().CheckAuthorizationOnTargetRealm(ctx, MGMTGetTargetAuthorizationsReport.String(), ctxRealm).Return(nil)
+		mockKeycloakClient.EXPECT().GetGroups(accessToken, realmName).Return(customerGroups, nil)
+		mockKeycloakClient.EXPECT().GetUsers(accessToken, ctxRealm, realmName, "groupId", orgID, "first", "0", "max", "100").Return(kc.UsersPageRepresentation{}, nil)
+		mockKeycloakClient.EXPECT().GetUsers(accessToken, ctxRealm, realmName, "groupId", supportID, "first", "0", "max", "100").Return(kc.UsersPageRepresentation{}, nil)
+		// the group holding the authorization has been deleted
+		mockKeycloakClient.EXPECT().GetGroups(accessToken, ctxRealm).Return(nil, nil)
+
+		var report, err = component.GetTargetAuthorizationsReport(ctx, realmName, nil)
+		assert.Nil(t, err)
+		assert.Len(t, report.Groups, 3)
+		assert.Len(t, report.Groups[0].Authorizations, 2)
+		assert.Equal(t, supportName, report.Groups[1].Group)
+		assert.Equal(t, adminsName, report.Groups[2].Group)
+		assert.Len(t, report.Groups[2].Members, 0)
+	})
+
+	t.Run("Groups of the realms the caller is not authorized on are hidden", func(t *testing.T) {
+		mockConfigDBModule.EXPECT().GetAuthorizationsOnTargetRealm(ctx, realmName).Return(authorizations, nil)
+		mockAuthManager.EXPECT().CheckAuthorizationOnTargetRealm(ctx, MGMTGetTargetAuthorizationsReport.String(), ctxRealm).Return(security.ForbiddenError{})
+		mockKeycloakClient.EXPECT().GetGroups(accessToken, realmName).Return(customerGroups, nil)
+		mockKeycloakClient.EXPECT().GetUsers(accessToken, ctxRealm, realmName, "groupId", orgID, "first", "0", "max", "100").Return(kc.UsersPageRepresentation{}, nil)
+		mockKeycloakClient.EXPECT().GetUsers(accessToken, ctxRealm, realmName, "groupId", supportID, "first", "0", "max", "100").Return(kc.UsersPageRepresentation{}, nil)
+
+		var report, err = component.GetTargetAuthorizationsReport(ctx, realmName, nil)
+		assert.Nil(t, err)
+		assert.Len(t, report.Groups, 2)
+		for _, group := range report.Groups {
+			assert.Equal(t, realmName, group.Realm)
+		}
+	})
+}
@@ -0,0 +1,292 @@
+package management
+
+import (
+	"context"
+	"regexp"
+	"strconv"
+
+	errorhandler "github.com/cloudtrust/common-service/errors"
+	"github.com/cloudtrust/common-service/security"
+	api "github.com/cloudtrust/keycloak-bridge/api/management"
+	msg "github.com/cloudtrust/keycloak-bridge/internal/constants"
+	"github.com/cloudtrust/keycloak-bridge/internal/keycloakb"
+)
+
+var idInLocation = regexp.MustCompile(`[0-9a-fA-F]{8}\-[0-9a-fA-F]{4}\-[0-9a-fA-F]{4}\-[0-9a-fA-F]{4}\-[0-9a-fA-F]{12}`)
+
+// Actions checked on each target user of a bulk operation
+var bulkOperationActions = map[string]security.Action{
+	api.BulkOperationLockUser:            MGMTLockUser,
+	api.BulkOperationUnlockUser:          MGMTUnlockUser,
+	api.BulkOperationDeleteUser:          MGMTDeleteUser,
+	api.BulkOperationAddGroupToUser:      MGMTSetGroupsToUser,
+	api.BulkOperationExecuteActionsEmail: MGMTExecuteActionsEmail,
+	api.BulkOperationSendReminderEmail:   MGMTSendReminderEmail,
+}
+
+const bulkSearchPageSize = 100
+
+// BulkComponent is the interface of the operations applied to many users at once. They are executed in background.
+type BulkComponent interface {
+	ImportUsers(ctx context.Context, realmName string, users []api.UserRepresentation, dryRun bool) (api.BulkJobRepresentation, error)
+	ApplyBulkOperation(ctx context.Context, realmName string, operation api.BulkUserOperationRepresentation) (api.BulkJobRepresentation, error)
+	GetBulkJob(ctx context.Context, realmName string, jobID string) (api.BulkJobRepresentation, error)
+}
+
+type bulkComponent struct {
+	component   Component
+	authManager security.AuthorizationManager
+	jobs        keycloakb.JobStore
+	logger      keycloakb.Logger
+}
+
+// NewBulkComponent returns the bulk operations component. The given management component must not check the authorizations:
+// they are checked for each user by the bulk component itself so that a forbidden user does not stop the whole operation.
+func NewBulkComponent(component Component, authManager security.AuthorizationManager, jobs keycloakb.JobStore, logger keycloakb.Logger) BulkComponent {
+	return &bulkComponent{
+		component:   component,
+		authManager: authManager,
+		jobs:        jobs,
+		logger:      logger,
+	}
+}
+
+// ImportUsers creates the given users in background. With dryRun, users are only validated and authorizations checked.
+func (c *bulkComponent) ImportUsers(ctx context.Context, realmName string, users []api.UserRepresentation, dryRun bool) (api.BulkJobRepresentation, error) {
+	if len(users) == 0 {
+		return api.BulkJobRepresentation{}, errorhandler.CreateBadRequestError(msg.MsgErrInvalidParam + "." + msg.Body)
+	}
+
+	var jobID = c.jobs.Start(ctx, realmName, func(ctx context.Context, progress func(int, int)) (interface{}, error) {
+		var report = api.UserImportReportRepresentation{
+			DryRun: dryRun,
+			Total:  len(users),
+			Rows:   []api.UserImportRowRepresentation{},
+		}
+		for i, user := range users {
+			var row = c.importUser(ctx, realmName, user, dryRun)
+			row.Line = i + 1
+			if row.Status == api.ImportRowCreated || row.Status == api.ImportRowValid {
+				report.Succeeded++
+			} else {
+				report.Failed++
+			}
+			report.Rows = append(report.Rows, row)
+			progress(i+1, len(users))
+		}
+		return report, nil
+	})
+
+	return api.BulkJobRepresentation{
+		ID:     jobID,
+		Status: keycloakb.JobStatusRunning,
+	}, nil
+}
+
+func (c *bulkComponent) importUser(ctx context.Context, realmName string, user api.UserRepresentation, dryRun bool) api.UserImportRowRepresentation {
+	var row = api.UserImportRowRepresentation{
+		Username: user.Username,
+	}
+
+	if err := user.Validate(); err != nil {
+		return rowError(row, api.ImportRowInvalid, err)
+	}
+	if user.Groups == nil || len(*user.Groups) == 0 {
+		return rowError(row, api.ImportRowInvalid, errorhandler.CreateMissingParameterError(msg.Groups))
+	}
+
+	// Authorizations are all checked before the creation so that a forbidden line leaves no partially imported user
+	var withTrustIDGroups = user.TrustIDGroups != nil && len(*user.TrustIDGroups) > 0
+	for _, groupID := range *user.Groups {
+		if err := c.authManager.CheckAuthorizationOnTargetGroupID(ctx, MGMTCreateUser.String(), realmName, groupID); err != nil {
+			return rowError(row, api.ImportRowForbidden, err)
+		}
+		if withTrustIDGroups {
+			if err := c.authManager.CheckAuthorizationOnTargetGroupID(ctx, MGMTSetTrustIDGroups.String(), realmName, groupID); err != nil {
+				return rowError(row, api.ImportRowForbidden, err)
+			}
+		}
+	}
+
+	if dryRun {
+		row.Status = api.ImportRowValid
+		return row
+	}
+
+	var location, err = c.component.CreateUser(ctx, realmName, user)
+	if err != nil {
+		c.logger.Warn(ctx, "msg", "Can't import user", "err", err.Error())
+		return rowError(row, api.ImportRowFailed, err)
+	}
+	var userID = idInLocation.FindString(location)
+	row.UserID = &userID
+
+	if withTrustIDGroups {
+		if err = c.component.SetTrustIDGroupsToUser(ctx, realmName, userID, *user.TrustIDGroups); err != nil {
+			c.logger.Warn(ctx, "msg", "Can't set trustID groups of imported user", "err", err.Error())
+			return rowError(row, api.ImportRowCreatedWithError, err)
+		}
+	}
+
+	row.Status = api.ImportRowCreated
+	return row
+}
+
+func rowError(row api.UserImportRowRepresentation, status string, err error) api.UserImportRowRepresentation {
+	var message = err.Error()
+	row.Status = status
+	row.Error = &message
+	return row
+}
+
+// ApplyBulkOperation applies an operation in background to the given users or to the users matching the filter.
+// Authorizations needed to search the users or to assign the group are checked before the job is started.
+func (c *bulkComponent) ApplyBulkOperation(ctx context.Context, realmName string, operation api.BulkUserOperationRepresentation) (api.BulkJobRepresentation, error) {
+	if operation.Filter != nil {
+		for _, groupID := range operation.Filter.GroupIDs {
+			if err := c.authManager.CheckAuthorizationOnTargetGroupID(ctx, MGMTGetUsers.String(), realmName, groupID); err != nil {
+				return api.BulkJobRepresentation{}, err
+			}
+		}
+	}
+	if *operation.Operation == api.BulkOperationAddGroupToUser {
+		if err := c.authManager.CheckAuthorizationOnTargetGroupID(ctx, MGMTAssignableGroupsToUser.String(), realmName, *operation.GroupID); err != nil {
+			return api.BulkJobRepresentation{}, err
+		}
+	}
+
+	var jobID = c.jobs.Start(ctx, realmName, func(ctx context.Context, progress func(int, int)) (interface{}, error) {
+		var userIDs []string
+		if operation.Filter != nil {
+			var err error
+			if userIDs, err = c.searchUsers(ctx, realmName, *operation.Filter); err != nil {
+				return nil, err
+			}
+		} else {
+			userIDs = *operation.UserIDs
+		}
+
+		var report = api.BulkOperationReportRepresentation{
+			Operation: *operation.Operation,
+			Total:     len(userIDs),
+			Users:     []api.BulkUserResultRepresentation{},
+		}
+		for i, userID := range userIDs {
+			var result = api.BulkUserResultRepresentation{
+				UserID: userID,
+				Status: api.BulkUserSucceeded,
+			}
+			var err = c.applyUserOperation(ctx, realmName, userID, operation)
+			if approvalErr, ok := err.(ApprovalRequiredError); ok {
+				// The operation is not applied to this user until the request is approved
+				result.Status = api.BulkUserPending
+				result.ApprovalID = approvalErr.Request.ID
+				report.Pending++
+			} else if err != nil {
+				var message = err.Error()
+				result.Status = api.BulkUserFailed
+				if _, ok := err.(security.ForbiddenError); ok {
+					result.Status = api.BulkUserForbidden
+				}
+				result.Error = &message
+				report.Failed++
+			} else {
+				report.Succeeded++
+			}
+			report.Users = append(report.Users, result)
+			progress(i+1, len(userIDs))
+		}
+		return report, nil
+	})
+
+	return api.BulkJobRepresentation{
+		ID:     jobID,
+		Status: keycloakb.JobStatusRunning,
+	}, nil
+}
+
+func (c *bulkComponent) searchUsers(ctx context.Context, realmName string, filter api.BulkUserFilterRepresentation) ([]string, error) {
+	var paramKV []string
+	var keys = []string{prmQrySearch, prmQryEmail, prmQryFirstName, prmQryLastName, prmQryUserName}
+	for i, value := range []*string{filter.Search, filter.Email, filter.FirstName, filter.LastName, filter.Username} {
+		if value != nil {
+			paramKV = append(paramKV, keys[i], *value)
+		}
+	}
+
+	var userIDs = []string{}
+	for first := 0; ; first += bulkSearchPageSize {
+		// Soft deleted users are skipped here: filtered by GetUsers, they would shorten the pages and end the search early
+		var pageKV = append([]string{prmQryFirst, strconv.Itoa(first), prmQryMax, strconv.Itoa(bulkSearchPageSize), prmQryIncludeDeleted, "true"}, paramKV...)
+		var page, err = c.component.GetUsers(ctx, realmName, filter.GroupIDs, pageKV...)
+		if err != nil {
+			c.logger.Warn(ctx, "msg", "Can't search the users of a bulk operation", "err", err.Error())
+			return nil, err
+		}
+		for _, user := range page.Users {
+			if user.ID != nil && user.DeletedTimestamp == nil {
+				userIDs = append(userIDs, *user.ID)
+			}
+		}
+		if len(page.Users) < bulkSearchPageSize {
+			return userIDs, nil
+		}
+	}
+}
+
+func (c *bulkComponent) applyUserOperation(ctx context.Context, realmName string, userID string, operation api.BulkUserOperationRepresentation) error {
+	var action = bulkOperationActions[*operation.Operation]
+	if err := c.authManager.CheckAuthorizationOnTargetUser(ctx, action.String(), realmName, userID); err != nil {
+		return err
+	}
+
+	var paramKV []string
+	if operation.ClientID != nil {
+		paramKV = append(paramKV, prmQryClientID, *operation.ClientID)
+	}
+	if operation.RedirectURI != nil {
+		paramKV = append(paramKV, prmQryRedirectURI, *operation.RedirectURI)
+	}
+	if operation.Lifespan != nil {
+		paramKV = append(paramKV, prmQryLifespan, strconv.Itoa(*operation.Lifespan))
+	}
+
+	switch *operation.Operation {
+	case api.BulkOperationLockUser:
+		return c.component.LockUser(ctx, realmName, userID)
+	case api.BulkOperationUnlockUser:
+		return c.component.UnlockUser(ctx, realmName, userID)
+	case api.BulkOperationDeleteUser:
+		return c.component.DeleteUser(ctx, realmName, userID)
+	case api.BulkOperationAddGroupToUser:
+		return c.component.AddGroupToUser(ctx, realmName, userID, *operation.GroupID)
+	case api.BulkOperationExecuteActionsEmail:
+		return c.component.ExecuteActionsEmail(ctx, realmName, userID, *operation.Actions, paramKV...)
+	default:
+		return c.component.SendReminderEmail(ctx, realmName, userID, paramKV...)
+	}
+}
+
+// GetBulkJob gives the status of a bulk operation and its report once available
+func (c *bulkComponent) GetBulkJob(ctx context.Context, realmName string, jobID string) (api.BulkJobRepresentation, error) {
+	var job, ok = c.jobs.Get(realmName, jobID)
+	if !ok {
+		c.logger.Warn(ctx, "err", "Unknown bulk job", "job", jobID)
+		return api.BulkJobRepresentation{}, errorhandler.CreateNotFoundError(msg.JobID)
+	}
+
+	var res = api.BulkJobRepresentation{
+		ID:     job.ID,
+		Status: job.Status,
+		Report: job.Result,
+	}
+	if job.Total > 0 {
+		var progress = job.Done * 100 / job.Total
+		res.Progress = &progress
+	}
+	if job.Err != nil {
+		var message = job.Err.Error()
+		res.Error = &message
+	}
+	return res, nil
+}
@@ -0,0 +1,318 @@
+package management
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	cs "github.com/cloudtrust/common-service"
+	"github.com/cloudtrust/common-service/log"
+	"github.com/cloudtrust/common-service/security"
+	api "github.com/cloudtrust/keycloak-bridge/api/management"
+	"github.com/cloudtrust/keycloak-bridge/internal/keycloakb"
+	"github.com/cloudtrust/keycloak-bridge/pkg/management/mock"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestImportUsers(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var mockComponent = mock.NewManagementComponent(mockCtrl)
+	var mockAuthManager = mock.NewAuthorizationManager(mockCtrl)
+	var mockJobStore = mock.NewJobStore(mockCtrl)
+	var component = NewBulkComponent(mockComponent, mockAuthManager, mockJobStore, log.NewNopLogger())
+
+	var realm = "the_realm_name"
+	var jobID = "job-id"
+	var userID = "f467ed7c-0a1d-4eee-9bb8-669c6f89c0ee"
+	var location = "https://keycloak/auth/admin/realms/" + realm + "/users/" + userID
+	var groupID = "7767ed7c-0a1d-4eee-9bb8-669c6f89c007"
+	var groups = []string{groupID}
+	var trustIDGroups = []string{"l1_support_agent"}
+	var ctx = context.WithValue(context.Background(), cs.CtContextRealm, realm)
+
+	var createUser = func(username string) api.UserRepresentation {
+		return api.UserRepresentation{Username: &username, Groups: &groups}
+	}
+	var importUsers = func(users []api.UserRepresentation, dryRun bool, expected func()) api.UserImportReportRepresentation {
+		var report api.UserImportReportRepresentation
+		mockJobStore.EXPECT().Start(ctx, realm, gomock.Any()).DoAndReturn(func(ctx context.Context, realm string, fn keycloakb.JobFunc) string {
+			expected()
+			var res, err = fn(ctx, func(int, int) {})
+			assert.Nil(t, err)
+			report = res.(api.UserImportReportRepresentation)
+			return jobID
+		})
+
+		var res, err = component.ImportUsers(ctx, realm, users, dryRun)
+		assert.Nil(t, err)
+		assert.Equal(t, jobID, res.ID)
+		assert.Equal(t, keycloakb.JobStatusRunning, res.Status)
+		return report
+	}
+
+	t.Run("No user", func(t *testing.T) {
+		var _, err = component.ImportUsers(ctx, realm, nil, false)
+		assert.NotNil(t, err)
+	})
+
+	t.Run("Dry run", func(t *testing.T) {
+		var invalidUsername = "in valid"
+		var users = []api.UserRepresentation{createUser("valid"), createUser("forbidden"), {Username: &invalidUsername, Groups: &groups}, {Username: &invalidUsername}}
+
+		var report = importUsers(users, true, func() {
+			gomock.InOrder(
+				mockAuthManager.EXPECT().CheckAuthorizationOnTargetGroupID(ctx, MGMTCreateUser.String(), realm, groupID).Return(nil),
+				mockAuthManager.EXPECT().CheckAuthorizationOnTargetGroupID(ctx, MGMTCreateUser.String(), realm, groupID).Return(security.ForbiddenError{}),
+			)
+		})
+		assert.True(t, report.DryRun)
+		assert.Equal(t, 4, report.Total)
+		assert.Equal(t, 1, report.Succeeded)
+		assert.Equal(t, 3, report.Failed)
+		assert.Equal(t, api.ImportRowValid, report.Rows[0].Status)
+		assert.Equal(t, api.ImportRowForbidden, report.Rows[1].Status)
+		assert.Equal(t, api.ImportRowInvalid, report.Rows[2].Status)
+		assert.Equal(t, api.ImportRowInvalid, report.Rows[3].Status)
+		assert.Equal(t, 4, report.Rows[3].Line)
+	})
+
+	t.Run("Import", func(t *testing.T) {
+		var created = createUser("created")
+		var failed = createUser("failed")
+		var withTrustIDGroups = createUser("trustid")
+		withTrustIDGroups.TrustIDGroups = &trustIDGroups
+
+		var report = importUsers([]api.UserRepresentation{created, failed, withTrustIDGroups}, false, func() {
+			mockAuthManager.EXPECT().CheckAuthorizationOnTargetGroupID(ctx, MGMTCreateUser.String(), realm, groupID).Return(nil).Times(3)
+			mockComponent.EXPECT().CreateUser(ctx, realm, created).Return(location, nil)
+			mockComponent.EXPECT().CreateUser(ctx, realm, failed).Return("", errors.New("conflict"))
+			mockAuthManager.EXPECT().CheckAuthorizationOnTargetGroupID(ctx, MGMTSetTrustIDGroups.String(), realm, groupID).Return(nil)
+			mockComponent.EXPECT().CreateUser(ctx, realm, withTrustIDGroups).Return(location, nil)
+			mockComponent.EXPECT().SetTrustIDGroupsToUser(ctx, realm, userID, trustIDGroups).Return(nil)
+		})
+		assert.False(t, report.DryRun)
+		assert.Equal(t, 2, report.Succeeded)
+		assert.Equal(t, 1, report.Failed)
+		assert.Equal(t, api.ImportRowCreated, report.Rows[0].Status)
+		assert.Equal(t, userID, *report.Rows[0].UserID)
+		assert.Equal(t, api.ImportRowFailed, report.Rows[1].Status)
+		assert.Equal(t, "conflict", *report.Rows[1].Error)
+		assert.Equal(t, api.ImportRowCreated, report.Rows[2].Status)
+	})
+
+	t.Run("Not allowed to set trustID groups", func(t *testing.T) {
+		var user = createUser("trustid")
+		user.TrustIDGroups = &trustIDGroups
+
+		// The user is not created
+		var report = importUsers([]api.UserRepresentation{user}, false, func() {
+			mockAuthManager.EXPECT().CheckAuthorizationOnTargetGroupID(ctx, MGMTCreateUser.String(), realm, groupID).Return(nil)
+			mockAuthManager.EXPECT().CheckAuthorizationOnTargetGroupID(ctx, MGMTSetTrustIDGroups.String(), realm, groupID).Return(security.ForbiddenError{})
+		})
+		assert.Equal(t, api.ImportRowForbidden, report.Rows[0].Status)
+		assert.Nil(t, report.Rows[0].UserID)
+	})
+
+	t.Run("TrustID groups can't be set", func(t *testing.T) {
+		var user = createUser("trustid")
+		user.TrustIDGroups = &trustIDGroups
+
+		var report = importUsers([]api.UserRepresentation{user}, false, func() {
+			mockAuthManager.EXPECT().CheckAuthorizationOnTargetGroupID(ctx, MGMTCreateUser.String(), realm, groupID).Return(nil)
+			mockAuthManager.EXPECT().CheckAuthorizationOnTargetGroupID(ctx, MGMTSetTrustIDGroups.String(), realm, groupID).Return(nil)
+			mockComponent.EXPECT().CreateUser(ctx, realm, user).Return(location, nil)
+			mockComponent.EXPECT().SetTrustIDGroupsToUser(ctx, realm, userID, trustIDGroups).Return(errors.New("error"))
+		})
+		assert.Equal(t, 1, report.Failed)
+		assert.Equal(t, api.ImportRowCreatedWithError, report.Rows[0].Status)
+		assert.Equal(t, userID, *report.Rows[0].UserID)
+	})
+}
+
+func TestApplyBulkOperation(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var mockComponent = mock.NewManagementComponent(mockCtrl)
+	var mockAuthManager = mock.NewAuthorizationManager(mockCtrl)
+	var mockJobStore = mock.NewJobStore(mockCtrl)
+	var component = NewBulkComponent(mockComponent, mockAuthManager, mockJobStore, log.NewNopLogger())
+
+	var realm = "the_realm_name"
+	var jobID = "job-id"
+	var userIDs = []string{"f467ed7c-0a1d-4eee-9bb8-669c6f89c0ee", "7767ed7c-0a1d-4eee-9bb8-669c6f89c007", "abcded7c-0a1d-4eee-9bb8-669c6f89c0ee"}
+	var groupID = "f467ed7c-0a1d-4eee-9bb8-669c6f89c0aa"
+	var ctx = context.WithValue(context.Background(), cs.CtContextRealm, realm)
+
+	var createOperation = func(operation string) api.BulkUserOperationRepresentation {
+		return api.BulkUserOperationRepresentation{Operation: &operation, UserIDs: &userIDs}
+	}
+	var applyOperation = func(operation api.BulkUserOperationRepresentation, expected func()) (api.BulkOperationReportRepresentation, error) {
+		var report api.BulkOperationReportRepresentation
+		var jobErr error
+		mockJobStore.EXPECT().Start(ctx, realm, gomock.Any()).DoAndReturn(func(ctx context.Context, realm string, fn keycloakb.JobFunc) string {
+			expected()
+			var res, err = fn(ctx, func(int, int) {})
+			if err == nil {
+				report = res.(api.BulkOperationReportRepresentation)
+			}
+			jobErr = err
+			return jobID
+		})
+
+		var res, err = component.ApplyBulkOperation(ctx, realm, operation)
+		assert.Nil(t, err)
+		assert.Equal(t, jobID, res.ID)
+		return report, jobErr
+	}
+
+	t.Run("Lock users", func(t *testing.T) {
+		var report, err = applyOperation(createOperation(api.BulkOperationLockUser), func() {
+			mockAuthManager.EXPECT().CheckAuthorizationOnTargetUser(ctx, MGMTLockUser.String(), realm, userIDs[0]).Return(nil)
+			mockAuthManager.EXPECT().CheckAuthorizationOnTargetUser(ctx, MGMTLockUser.String(), realm, userIDs[1]).Return(security.ForbiddenError{})
+			mockAuthManager.EXPECT().CheckAuthorizationOnTargetUser(ctx, MGMTLockUser.String(), realm, userIDs[2]).Return(nil)
+			mockComponent.EXPECT().LockUser(ctx, realm, userIDs[0]).Return(nil)
+			mockComponent.EXPECT().LockUser(ctx, realm, userIDs[2]).Return(errors.New("kc error"))
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, api.BulkOperationLockUser, report.Operation)
+		assert.Equal(t, 3, report.Total)
+		assert.Equal(t, 1, report.Succeeded)
+		assert.Equal(t, 2, report.Failed)
+		assert.Equal(t, api.BulkUserSucceeded, report.Users[0].Status)
+		assert.Equal(t, api.BulkUserForbidden, report.Users[1].Status)
+		assert.Equal(t, api.BulkUserFailed, report.Users[2].Status)
+		assert.Equal(t, "kc error", *report.Users[2].Error)
+	})
+
+	t.Run("Deletion requires an approval", func(t *testing.T) {
+		var approvalID = int64(12)
+		var operation = createOperation(api.BulkOperationDeleteUser)
+		operation.UserIDs = &[]string{userIDs[0]}
+		var report, err = applyOperation(operation, func() {
+			mockAuthManager.EXPECT().CheckAuthorizationOnTargetUser(ctx, MGMTDeleteUser.String(), realm, userIDs[0]).Return(nil)
+			mockComponent.EXPECT().DeleteUser(ctx, realm, userIDs[0]).Return(ApprovalRequiredError{Request: api.ApprovalRequestRepresentation{ID: &approvalID}})
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, 0, report.Succeeded)
+		assert.Equal(t, 0, report.Failed)
+		assert.Equal(t, 1, report.Pending)
+		assert.Equal(t, api.BulkUserPending, report.Users[0].Status)
+		assert.Equal(t, approvalID, *report.Users[0].ApprovalID)
+		assert.Nil(t, report.Users[0].Error)
+	})
+
+	t.Run("Execute actions email to the users matching a filter", func(t *testing.T) {
+		var search = "doe"
+		var lifespan = 3600
+		var actions = []api.RequiredAction{"VERIFY_EMAIL"}
+		var operation = createOperation(api.BulkOperationExecuteActionsEmail)
+		operation.UserIDs = nil
+		operation.Filter = &api.BulkUserFilterRepresentation{GroupIDs: []string{groupID}, Search: &search}
+		operation.Actions = &actions
+		operation.Lifespan = &lifespan
+
+		// A soft deleted user in the middle of a full page must neither be selected nor end the search
+		var deletedTimestamp int64 = 1591000000000
+		var firstPage = make([]api.UserRepresentation, bulkSearchPageSize)
+		for i := range firstPage {
+			firstPage[i].ID = &userIDs[0]
+		}
+		firstPage[bulkSearchPageSize/2].DeletedTimestamp = &deletedTimestamp
+		var secondPage = []api.UserRepresentation{{ID: &userIDs[1]}}
+
+		mockAuthManager.EXPECT().CheckAuthorizationOnTargetGroupID(ctx, MGMTGetUsers.String(), realm, groupID).Return(nil)
+		var report, err = applyOperation(operation, func() {
+			mockComponent.EXPECT().GetUsers(ctx, realm, []string{groupID}, prmQryFirst, "0", prmQryMax, "100", prmQryIncludeDeleted, "true", prmQrySearch, search).Return(api.UsersPageRepresentation{Users: firstPage}, nil)
+			mockComponent.EXPECT().GetUsers(ctx, realm, []string{groupID}, prmQryFirst, "100", prmQryMax, "100", prmQryIncludeDeleted, "true", prmQrySearch, search).Return(api.UsersPageRepresentation{Users: secondPage}, nil)
+			mockAuthManager.EXPECT().CheckAuthorizationOnTargetUser(ctx, MGMTExecuteActionsEmail.String(), realm, gomock.Any()).Return(nil).Times(bulkSearchPageSize)
+			mockComponent.EXPECT().ExecuteActionsEmail(ctx, realm, gomock.Any(), actions, prmQryLifespan, "3600").Return(nil).Times(bulkSearchPageSize)
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, bulkSearchPageSize, report.Succeeded)
+	})
+
+	t.Run("Search fails", func(t *testing.T) {
+		var operation = createOperation(api.BulkOperationSendReminderEmail)
+		operation.UserIDs = nil
+		operation.Filter = &api.BulkUserFilterRepresentation{GroupIDs: []string{groupID}}
+
+		mockAuthManager.EXPECT().CheckAuthorizationOnTargetGroupID(ctx, MGMTGetUsers.String(), realm, groupID).Return(nil)
+		var _, err = applyOperation(operation, func() {
+			mockComponent.EXPECT().GetUsers(ctx, realm, []string{groupID}, gomock.Any()).Return(api.UsersPageRepresentation{}, errors.New("kc error"))
+		})
+		assert.NotNil(t, err)
+	})
+
+	t.Run("Not allowed to search users", func(t *testing.T) {
+		var operation = createOperation(api.BulkOperationDeleteUser)
+		operation.Filter = &api.BulkUserFilterRepresentation{GroupIDs: []string{groupID}}
+
+		mockAuthManager.EXPECT().CheckAuthorizationOnTargetGroupID(ctx, MGMTGetUsers.String(), realm, groupID).Return(security.ForbiddenError{})
+		var _, err = component.ApplyBulkOperation(ctx, realm, operation)
+		assert.Equal(t, security.ForbiddenError{}, err)
+	})
+
+	t.Run("Add group to users", func(t *testing.T) {
+		var operation = createOperation(api.BulkOperationAddGroupToUser)
+		operation.GroupID = &groupID
+
+		mockAuthManager.EXPECT().CheckAuthorizationOnTargetGroupID(ctx, MGMTAssignableGroupsToUser.String(), realm, groupID).Return(security.ForbiddenError{})
+		var _, err = component.ApplyBulkOperation(ctx, realm, operation)
+		assert.Equal(t, security.ForbiddenError{}, err)
+
+		mockAuthManager.EXPECT().CheckAuthorizationOnTargetGroupID(ctx, MGMTAssignableGroupsToUser.String(), realm, groupID).Return(nil)
+		var report api.BulkOperationReportRepresentation
+		report, err = applyOperation(operation, func() {
+			mockAuthManager.EXPECT().CheckAuthorizationOnTargetUser(ctx, MGMTSetGroupsToUser.String(), realm, gomock.Any()).Return(nil).Times(3)
+			mockComponent.EXPECT().AddGroupToUser(ctx, realm, gomock.Any(), groupID).Return(nil).Times(3)
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, 3, report.Succeeded)
+	})
+}
+
+func TestGetBulkJob(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var mockJobStore = mock.NewJobStore(mockCtrl)
+	var component = NewBulkComponent(mock.NewManagementComponent(mockCtrl), mock.NewAuthorizationManager(mockCtrl), mockJobStore, log.NewNopLogger())
+
+	var realm = "the_realm_name"
+	var jobID = "job-id"
+	var ctx = context.TODO()
+
+	t.Run("Unknown job", func(t *testing.T) {
+		mockJobStore.EXPECT().Get(realm, jobID).Return(keycloakb.Job{}, false)
+		var _, err = component.GetBulkJob(ctx, realm, jobID)
+		assert.NotNil(t, err)
+	})
+
+	t.Run("Running job", func(t *testing.T) {
+		mockJobStore.EXPECT().Get(realm, jobID).Return(keycloakb.Job{ID: jobID, Status: keycloakb.JobStatusRunning, Done: 1, Total: 4}, true)
+		var res, err = component.GetBulkJob(ctx, realm, jobID)
+		assert.Nil(t, err)
+		assert.Equal(t, 25, *res.Progress)
+		assert.Nil(t, res.Report)
+	})
+
+	t.Run("Succeeded job", func(t *testing.T) {
+		var report = api.UserImportReportRepresentation{Total: 3}
+		mockJobStore.EXPECT().Get(realm, jobID).Return(keycloakb.Job{ID: jobID, Status: keycloakb.JobStatusSucceeded, Result: report}, true)
+		var res, err = component.GetBulkJob(ctx, realm, jobID)
+		assert.Nil(t, err)
+		assert.Equal(t, report, res.Report)
+		assert.Nil(t, res.Error)
+	})
+
+	t.Run("Failed job", func(t *testing.T) {
+		mockJobStore.EXPECT().Get(realm, jobID).Return(keycloakb.Job{ID: jobID, Status: keycloakb.JobStatusFailed, Err: errors.New("error")}, true)
+		var res, err = component.GetBulkJob(ctx, realm, jobID)
+		assert.Nil(t, err)
+		assert.Equal(t, "error", *res.Error)
+	})
+}
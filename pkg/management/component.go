@@ -3,7 +3,11 @@ package management
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"net/http"
 	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 
 	cs "github.com/cloudtrust/common-service"
@@ -19,9 +23,36 @@ import (
 )
 
 const (
-	initPasswordAction = "sms-password-set"
+	initPasswordAction    = "sms-password-set"
+	openIDConnectProtocol = "openid-connect"
 )
 
+// builtInClientIDs are the clients Keycloak needs to administrate a realm. They can't be deleted through the bridge.
+var builtInClientIDs = map[string]bool{
+	"realm-management":       true,
+	"account":                true,
+	"admin-cli":              true,
+	"security-admin-console": true,
+}
+
+// builtInRealmRoles are the realm roles created by Keycloak. They can't be updated or deleted through the bridge.
+var builtInRealmRoles = map[string]bool{
+	"offline_access":    true,
+	"uma_authorization": true,
+	"admin":             true,
+	"create-realm":      true,
+}
+
+// adminRealmRoles are the realm roles giving administration rights on Keycloak. They can't be assigned through the bridge.
+var adminRealmRoles = map[string]bool{
+	"admin":        true,
+	"create-realm": true,
+}
+
+func isBuiltInRealmRole(role kc.RoleRepresentation) bool {
+	return role.Name != nil && (builtInRealmRoles[*role.Name] || strings.HasPrefix(*role.Name, "default-roles-"))
+}
+
 // KeycloakClient are methods from keycloak-client used by this component
 type KeycloakClient interface {
 	GetRealms(accessToken string) ([]kc.RealmRepresentation, error)
@@ -29,6 +60,10 @@ type KeycloakClient interface {
 	GetRequiredActions(accessToken string, realmName string) ([]kc.RequiredActionProviderRepresentation, error)
 	GetClient(accessToken string, realmName, idClient string) (kc.ClientRepresentation, error)
 	GetClients(accessToken string, realmName string, paramKV ...string) ([]kc.ClientRepresentation, error)
+	CreateClient(accessToken string, realmName string, client kc.ClientRepresentation) (string, error)
+	UpdateClient(accessToken string, realmName, idClient string, client kc.ClientRepresentation) error
+	DeleteClient(accessToken string, realmName, idClient string) error
+	RegenerateClientSecret(accessToken string, realmName, idClient string) (kc.CredentialRepresentation, error)
 	DeleteUser(accessToken string, realmName, userID string) error
 	GetUser(accessToken string, realmName, userID string) (kc.UserRepresentation, error)
 	GetGroupsOfUser(accessToken string, realmName, userID string) ([]kc.GroupRepresentation, error)
@@ -39,7 +74,10 @@ type KeycloakClient interface {
 	CreateUser(accessToken string, realmName string, targetRealmName string, user kc.UserRepresentation) (string, error)
 	GetClientRoleMappings(accessToken string, realmName, userID, clientID string) ([]kc.RoleRepresentation, error)
 	AddClientRolesToUserRoleMapping(accessToken string, realmName, userID, clientID string, roles []kc.RoleRepresentation) error
+	DeleteClientRolesFromUserRoleMapping(accessToken string, realmName, userID, clientID string, roles []kc.RoleRepresentation) error
 	GetRealmLevelRoleMappings(accessToken string, realmName, userID string) ([]kc.RoleRepresentation, error)
+	AddRealmLevelRoleMappings(accessToken string, realmName, userID string, roles []kc.RoleRepresentation) error
+	DeleteRealmLevelRoleMappings(accessToken string, realmName, userID string, roles []kc.RoleRepresentation) error
 	ResetPassword(accessToken string, realmName string, userID string, cred kc.CredentialRepresentation) error
 	ExecuteActionsEmail(accessToken string, realmName string, userID string, actions []string, paramKV ...string) error
 	SendNewEnrolmentCode(accessToken string, realmName string, userID string) (kc.SmsCodeRepresentation, error)
@@ -47,11 +85,16 @@ type KeycloakClient interface {
 	SendReminderEmail(accessToken string, realmName string, userID string, paramKV ...string) error
 	GetRoles(accessToken string, realmName string) ([]kc.RoleRepresentation, error)
 	GetRole(accessToken string, realmName string, roleID string) (kc.RoleRepresentation, error)
+	CreateRole(accessToken string, realmName string, role kc.RoleRepresentation) (string, error)
+	UpdateRole(accessToken string, realmName string, roleID string, role kc.RoleRepresentation) error
+	DeleteRole(accessToken string, realmName string, roleID string) error
 	GetGroups(accessToken string, realmName string) ([]kc.GroupRepresentation, error)
 	GetClientRoles(accessToken string, realmName, idClient string) ([]kc.RoleRepresentation, error)
 	CreateClientRole(accessToken string, realmName, clientID string, role kc.RoleRepresentation) (string, error)
 	GetGroup(accessToken string, realmName, groupID string) (kc.GroupRepresentation, error)
 	CreateGroup(accessToken string, realmName string, group kc.GroupRepresentation) (string, error)
+	CreateGroupChild(accessToken string, realmName string, groupID string, group kc.GroupRepresentation) (string, error)
+	UpdateGroup(accessToken string, realmName string, groupID string, group kc.GroupRepresentation) error
 	DeleteGroup(accessToken string, realmName string, groupID string) error
 	AssignClientRole(accessToken string, realmName string, groupID string, clientID string, role []kc.RoleRepresentation) error
 	RemoveClientRole(accessToken string, realmName string, groupID string, clientID string, role []kc.RoleRepresentation) error
@@ -59,11 +102,26 @@ type KeycloakClient interface {
 	GetAvailableGroupClientRoles(accessToken string, realmName string, groupID string, clientID string) ([]kc.RoleRepresentation, error)
 	GetCredentials(accessToken string, realmName string, userID string) ([]kc.CredentialRepresentation, error)
 	UpdateLabelCredential(accessToken string, realmName string, userID string, credentialID string, label string) error
+	MoveToFirst(accessToken string, realmName string, userID string, credentialID string) error
+	MoveAfter(accessToken string, realmName string, userID string, credentialID string, previousCredentialID string) error
 	DeleteCredential(accessToken string, realmName string, userID string, credentialID string) error
 	ResetPapercardFailures(accessToken string, realmName string, userID string, credentialID string) error
 	LinkShadowUser(accessToken string, realmName string, userID string, provider string, fedID kc.FederatedIdentityRepresentation) error
+	GetFederatedIdentities(accessToken string, realmName string, userID string) ([]kc.FederatedIdentityRepresentation, error)
+	DeleteFederatedIdentity(accessToken string, realmName string, userID string, provider string) error
+	GetIdentityProviders(accessToken string, realmName string) ([]kc.IdentityProviderRepresentation, error)
+	GetIdentityProvider(accessToken string, realmName string, alias string) (kc.IdentityProviderRepresentation, error)
+	CreateIdentityProvider(accessToken string, realmName string, idp kc.IdentityProviderRepresentation) (string, error)
+	UpdateIdentityProvider(accessToken string, realmName string, alias string, idp kc.IdentityProviderRepresentation) error
+	UpdateRealm(accessToken string, realmName string, realm kc.RealmRepresentation) error
+	CreateRealm(accessToken string, realm kc.RealmRepresentation) (string, error)
+	DeleteRealm(accessToken string, realmName string) error
 	ClearUserLoginFailures(accessToken string, realmName, userID string) error
 	GetAttackDetectionStatus(accessToken string, realmName, userID string) (map[string]interface{}, error)
+	GetSessionsOfUser(accessToken string, realmName, userID string) ([]kc.UserSessionRepresentation, error)
+	GetOfflineSessionsOfUser(accessToken string, realmName, userID, clientID string) ([]kc.UserSessionRepresentation, error)
+	DeleteSession(accessToken string, realmName, sessionID string) error
+	LogoutUser(accessToken string, realmName, userID string) error
 }
 
 // UsersDetailsDBModule is the interface from the users module
@@ -81,6 +139,11 @@ type Component interface {
 	GetRealm(ctx context.Context, realmName string) (api.RealmRepresentation, error)
 	GetClient(ctx context.Context, realmName, idClient string) (api.ClientRepresentation, error)
 	GetClients(ctx context.Context, realmName string) ([]api.ClientRepresentation, error)
+	CreateClient(ctx context.Context, realmName string, client api.ClientRepresentation) (string, error)
+	UpdateClient(ctx context.Context, realmName, idClient string, client api.ClientRepresentation) error
+	DeleteClient(ctx context.Context, realmName, idClient string) error
+	RegenerateClientSecret(ctx context.Context, realmName, idClient string) (api.ClientSecretRepresentation, error)
+	UpdateClientRedirectURIs(ctx context.Context, realmName, idClient string, redirectURIs []string) error
 	GetRequiredActions(ctx context.Context, realmName string) ([]api.RequiredActionRepresentation, error)
 
 	DeleteUser(ctx context.Context, realmName, userID string) error
@@ -92,6 +155,8 @@ type Component interface {
 	CreateUser(ctx context.Context, realmName string, user api.UserRepresentation) (string, error)
 	GetUserAccountStatus(ctx context.Context, realmName, userID string) (map[string]bool, error)
 	GetRolesOfUser(ctx context.Context, realmName, userID string) ([]api.RoleRepresentation, error)
+	AddRoleToUser(ctx context.Context, realmName, userID string, roleID string) error
+	DeleteRoleForUser(ctx context.Context, realmName, userID string, roleID string) error
 	GetGroupsOfUser(ctx context.Context, realmName, userID string) ([]api.GroupRepresentation, error)
 	AddGroupToUser(ctx context.Context, realmName, userID string, groupID string) error
 	DeleteGroupForUser(ctx context.Context, realmName, userID string, groupID string) error
@@ -100,6 +165,7 @@ type Component interface {
 	SetTrustIDGroupsToUser(ctx context.Context, realmName, userID string, groupNames []string) error
 	GetClientRolesForUser(ctx context.Context, realmName, userID, clientID string) ([]api.RoleRepresentation, error)
 	AddClientRolesToUser(ctx context.Context, realmName, userID, clientID string, roles []api.RoleRepresentation) error
+	DeleteClientRoleForUser(ctx context.Context, realmName, userID, clientID string, roleID string) error
 
 	ResetPassword(ctx context.Context, realmName string, userID string, password api.PasswordRepresentation) (string, error)
 	ExecuteActionsEmail(ctx context.Context, realmName string, userID string, actions []api.RequiredAction, paramKV ...string) error
@@ -110,15 +176,28 @@ type Component interface {
 	GetCredentialsForUser(ctx context.Context, realmName string, userID string) ([]api.CredentialRepresentation, error)
 	DeleteCredentialsForUser(ctx context.Context, realmName string, userID string, credentialID string) error
 	ResetCredentialFailuresForUser(ctx context.Context, realmName string, userID string, credentialID string) error
+	UpdateLabelCredentialForUser(ctx context.Context, realmName string, userID string, credentialID string, label string) error
+	MoveCredentialForUser(ctx context.Context, realmName string, userID string, credentialID string, previousCredentialID string) error
+	GetCredentialRegistratorsForUser(ctx context.Context, realmName string, userID string) ([]string, error)
+	RequireReEnrollment(ctx context.Context, realmName string, userID string, credentialType string) error
 	ClearUserLoginFailures(ctx context.Context, realmName, userID string) error
 	GetAttackDetectionStatus(ctx context.Context, realmName, userID string) (api.AttackDetectionStatusRepresentation, error)
+	GetUserSessions(ctx context.Context, realmName, userID string) ([]api.UserSessionRepresentation, error)
+	RevokeUserSession(ctx context.Context, realmName, userID, sessionID string) error
+	RevokeUserSessions(ctx context.Context, realmName, userID string) error
 	GetRoles(ctx context.Context, realmName string) ([]api.RoleRepresentation, error)
 	GetRole(ctx context.Context, realmName string, roleID string) (api.RoleRepresentation, error)
+	CreateRole(ctx context.Context, realmName string, role api.RoleRepresentation) (string, error)
+	UpdateRole(ctx context.Context, realmName string, roleID string, role api.RoleRepresentation) error
+	DeleteRole(ctx context.Context, realmName string, roleID string) error
 	GetClientRoles(ctx context.Context, realmName, idClient string) ([]api.RoleRepresentation, error)
 	CreateClientRole(ctx context.Context, realmName, clientID string, role api.RoleRepresentation) (string, error)
 
 	GetGroups(ctx context.Context, realmName string) ([]api.GroupRepresentation, error)
 	CreateGroup(ctx context.Context, realmName string, group api.GroupRepresentation) (string, error)
+	CreateSubGroup(ctx context.Context, realmName string, parentGroupID string, group api.GroupRepresentation) (string, error)
+	UpdateGroup(ctx context.Context, realmName string, groupID string, group api.GroupRepresentation) error
+	MoveGroup(ctx context.Context, realmName string, groupID string, parent api.GroupParentRepresentation) error
 	DeleteGroup(ctx context.Context, realmName string, groupID string) error
 	GetAuthorizations(ctx context.Context, realmName string, groupID string) (api.AuthorizationsRepresentation, error)
 	UpdateAuthorizations(ctx context.Context, realmName string, groupID string, group api.AuthorizationsRepresentation) error
@@ -132,6 +211,26 @@ type Component interface {
 	GetUserRealmBackOfficeConfiguration(ctx context.Context, realmID string) (api.BackOfficeConfiguration, error)
 
 	LinkShadowUser(ctx context.Context, realmName string, userID string, provider string, fedID api.FederatedIdentityRepresentation) error
+	GetFederatedIdentities(ctx context.Context, realmName string, userID string) ([]api.FederatedIdentityRepresentation, error)
+	DeleteFederatedIdentity(ctx context.Context, realmName string, userID string, provider string) error
+
+	GetIdentityProviders(ctx context.Context, realmName string) ([]api.IdentityProviderRepresentation, error)
+	CreateIdentityProvider(ctx context.Context, realmName string, idp api.IdentityProviderRepresentation) error
+	UpdateIdentityProvider(ctx context.Context, realmName string, alias string, idp api.IdentityProviderRepresentation) error
+	DisableIdentityProvider(ctx context.Context, realmName string, alias string) error
+
+	GetPasswordPolicy(ctx context.Context, realmName string) (api.PasswordPolicyRepresentation, error)
+	UpdatePasswordPolicy(ctx context.Context, realmName string, policy api.PasswordPolicyRepresentation) error
+	GetConfigurationRevisions(ctx context.Context, realmName string, configType *string) ([]api.ConfigurationRevisionRepresentation, error)
+	DiffConfigurationRevisions(ctx context.Context, realmName string, fromRevisionID, toRevisionID int64) ([]api.ConfigurationDiffRepresentation, error)
+	RollbackConfiguration(ctx context.Context, realmName string, revisionID int64) error
+
+	GetStatisticsReportSchedules(ctx context.Context, realmName string) ([]api.StatisticsReportScheduleRepresentation, error)
+	CreateStatisticsReportSchedule(ctx context.Context, realmName string, schedule api.StatisticsReportScheduleRepresentation) (int64, error)
+	UpdateStatisticsReportSchedule(ctx context.Context, realmName string, scheduleID int64, schedule api.StatisticsReportScheduleRepresentation) error
+	DeleteStatisticsReportSchedule(ctx context.Context, realmName string, scheduleID int64) error
+
+	ExportUsers(ctx context.Context, realmName string, groupIDs []string, format string, columns []string) (UsersExport, error)
 }
 
 // Component is the management component.
@@ -164,10 +263,13 @@ func NewComponent(keycloakClient KeycloakClient, usersDBModule UsersDetailsDBMod
 }
 
 func (c *component) reportEvent(ctx context.Context, apiCall string, values ...string) {
-	errEvent := c.eventDBModule.ReportEvent(ctx, apiCall, "back-office", values...)
-	if errEvent != nil {
+	reportEvent(ctx, c.eventDBModule, c.logger, apiCall, values...)
+}
+
+func reportEvent(ctx context.Context, eventDBModule database.EventsDBModule, logger keycloakb.Logger, apiCall string, values ...string) {
+	if errEvent := eventDBModule.ReportEvent(ctx, apiCall, "back-office", values...); errEvent != nil {
 		//store in the logs also the event that failed to be stored in the DB
-		keycloakb.LogUnrecordedEvent(ctx, c.logger, apiCall, errEvent.Error(), values...)
+		keycloakb.LogUnrecordedEvent(ctx, logger, apiCall, errEvent.Error(), values...)
 	}
 }
 
@@ -219,7 +321,6 @@ func (c *component) GetRealm(ctx context.Context, realm string) (api.RealmRepres
 func (c *component) GetClient(ctx context.Context, realmName, idClient string) (api.ClientRepresentation, error) {
 	var accessToken = ctx.Value(cs.CtContextAccessToken).(string)
 
-	var clientRep api.ClientRepresentation
 	clientKc, err := c.keycloakClient.GetClient(accessToken, realmName, idClient)
 
 	if err != nil {
@@ -227,14 +328,184 @@ func (c *component) GetClient(ctx context.Context, realmName, idClient string) (
 		return api.ClientRepresentation{}, err
 	}
 
-	clientRep.ID = clientKc.ID
-	clientRep.Name = clientKc.Name
-	clientRep.BaseURL = clientKc.BaseURL
-	clientRep.ClientID = clientKc.ClientID
-	clientRep.Protocol = clientKc.Protocol
-	clientRep.Enabled = clientKc.Enabled
+	return api.ConvertToAPIClient(clientKc), nil
+}
+
+// CreateClient creates a client which complies with the client policy of the realm. The flows which are not explicitly
+// enabled are disabled.
+func (c *component) CreateClient(ctx context.Context, realmName string, client api.ClientRepresentation) (string, error) {
+	var accessToken = ctx.Value(cs.CtContextAccessToken).(string)
+
+	if client.ClientID == nil {
+		return "", errorhandler.CreateMissingParameterError(constants.ClientID)
+	}
+
+	var trueBool = true
+	var falseBool = false
+	var protocol = openIDConnectProtocol
+	var clientKc = kc.ClientRepresentation{
+		Protocol:                  &protocol,
+		Enabled:                   &trueBool,
+		PublicClient:              &falseBool,
+		StandardFlowEnabled:       &trueBool,
+		ImplicitFlowEnabled:       &falseBool,
+		DirectAccessGrantsEnabled: &falseBool,
+		ServiceAccountsEnabled:    &falseBool,
+	}
+	api.MergeIntoKCClient(client, &clientKc)
+
+	if err := c.checkClientPolicy(ctx, accessToken, realmName, clientKc); err != nil {
+		return "", err
+	}
+
+	locationURL, err := c.keycloakClient.CreateClient(accessToken, realmName, clientKc)
+	if err != nil {
+		c.logger.Warn(ctx, "err", err.Error())
+		return "", err
+	}
+
+	c.reportEvent(ctx, "API_CLIENT_CREATION", database.CtEventRealmName, realmName, database.CtEventAdditionalInfo, database.CreateAdditionalInfo("client_id", *client.ClientID))
+
+	return locationURL, nil
+}
+
+// UpdateClient updates the given settings of a client. The resulting client must comply with the client policy of the realm.
+func (c *component) UpdateClient(ctx context.Context, realmName, idClient string, client api.ClientRepresentation) error {
+	return c.updateClient(ctx, realmName, idClient, "API_CLIENT_UPDATE", func(clientKc *kc.ClientRepresentation) {
+		api.MergeIntoKCClient(client, clientKc)
+	})
+}
+
+// UpdateClientRedirectURIs replaces the redirect URIs of a client
+func (c *component) UpdateClientRedirectURIs(ctx context.Context, realmName, idClient string, redirectURIs []string) error {
+	return c.updateClient(ctx, realmName, idClient, "API_CLIENT_REDIRECT_URIS_UPDATE", func(clientKc *kc.ClientRepresentation) {
+		clientKc.RedirectUris = &redirectURIs
+	})
+}
+
+func (c *component) updateClient(ctx context.Context, realmName, idClient string, apiCall string, update func(*kc.ClientRepresentation)) error {
+	var accessToken = ctx.Value(cs.CtContextAccessToken).(string)
+
+	clientKc, err := c.keycloakClient.GetClient(accessToken, realmName, idClient)
+	if err != nil {
+		c.logger.Warn(ctx, "err", err.Error())
+		return err
+	}
+
+	update(&clientKc)
+	if err = c.checkClientPolicy(ctx, accessToken, realmName, clientKc); err != nil {
+		return err
+	}
+
+	if err = c.keycloakClient.UpdateClient(accessToken, realmName, idClient, clientKc); err != nil {
+		c.logger.Warn(ctx, "err", err.Error())
+		return err
+	}
+
+	c.reportEvent(ctx, apiCall, database.CtEventRealmName, realmName, database.CtEventAdditionalInfo, database.CreateAdditionalInfo("client_id", idClient))
+
+	return nil
+}
+
+func (c *component) DeleteClient(ctx context.Context, realmName, idClient string) error {
+	var accessToken = ctx.Value(cs.CtContextAccessToken).(string)
+
+	clientKc, err := c.keycloakClient.GetClient(accessToken, realmName, idClient)
+	if err != nil {
+		c.logger.Warn(ctx, "err", err.Error())
+		return err
+	}
+	if clientKc.ClientID != nil && builtInClientIDs[*clientKc.ClientID] {
+		return errorhandler.CreateBadRequestError(constants.MsgErrInvalidParam + "." + constants.ClientID)
+	}
+
+	if err = c.keycloakClient.DeleteClient(accessToken, realmName, idClient); err != nil {
+		c.logger.Warn(ctx, "err", err.Error())
+		return err
+	}
+
+	c.reportEvent(ctx, "API_CLIENT_DELETION", database.CtEventRealmName, realmName, database.CtEventAdditionalInfo, database.CreateAdditionalInfo("client_id", idClient))
+
+	return nil
+}
+
+// RegenerateClientSecret rotates the secret of a confidential client. The new secret is only returned once.
+func (c *component) RegenerateClientSecret(ctx context.Context, realmName, idClient string) (api.ClientSecretRepresentation, error) {
+	var accessToken = ctx.Value(cs.CtContextAccessToken).(string)
+
+	secret, err := c.keycloakClient.RegenerateClientSecret(accessToken, realmName, idClient)
+	if err != nil {
+		c.logger.Warn(ctx, "err", err.Error())
+		return api.ClientSecretRepresentation{}, err
+	}
+
+	c.reportEvent(ctx, "API_CLIENT_SECRET_ROTATION", database.CtEventRealmName, realmName, database.CtEventAdditionalInfo, database.CreateAdditionalInfo("client_id", idClient))
+
+	return api.ClientSecretRepresentation{Value: secret.Value}, nil
+}
+
+func (c *component) getClientPolicy(ctx context.Context, accessToken, realmName string) (string, dto.ClientPolicy, error) {
+	realmKc, err := c.keycloakClient.GetRealm(accessToken, realmName)
+	if err != nil {
+		c.logger.Warn(ctx, "err", err.Error())
+		return "", dto.ClientPolicy{}, err
+	}
+
+	policy, err := c.configDBModule.GetClientPolicy(ctx, *realmKc.ID)
+	if err != nil {
+		return "", dto.ClientPolicy{}, err
+	}
+	return *realmKc.ID, policy, nil
+}
+
+// checkClientPolicy returns a bad request error naming the first setting of the client which is not allowed by the policy
+func (c *component) checkClientPolicy(ctx context.Context, accessToken, realmName string, client kc.ClientRepresentation) error {
+	var _, policy, err = c.getClientPolicy(ctx, accessToken, realmName)
+	if err != nil {
+		return err
+	}
+
+	var isTrue = func(value *bool) bool {
+		return value != nil && *value
+	}
+	var invalidParam string
+	switch {
+	case isTrue(client.ImplicitFlowEnabled) && !policy.ImplicitFlowAllowed:
+		invalidParam = constants.ImplicitFlowEnabled
+	case isTrue(client.DirectAccessGrantsEnabled) && !policy.DirectAccessGrantsAllowed:
+		invalidParam = constants.DirectAccessGrantsEnabled
+	case isTrue(client.PublicClient) && !policy.PublicClientsAllowed:
+		invalidParam = constants.PublicClient
+	case client.RedirectUris != nil && !isRedirectURIsAllowed(policy, *client.RedirectUris):
+		invalidParam = constants.RedirectURIs
+	}
+
+	if invalidParam != "" {
+		c.logger.Warn(ctx, "msg", "Client settings are not allowed by the client policy", "realm", realmName, "param", invalidParam)
+		return errorhandler.CreateBadRequestError(constants.MsgErrInvalidParam + "." + invalidParam)
+	}
+	return nil
+}
+
+func isRedirectURIsAllowed(policy dto.ClientPolicy, redirectURIs []string) bool {
+	for _, uri := range redirectURIs {
+		if !policy.WildcardRedirectURIsAllowed && strings.Contains(uri, "*") {
+			return false
+		}
+		if len(policy.AllowedRedirectURIPrefixes) > 0 && !hasAnyPrefix(uri, policy.AllowedRedirectURIPrefixes) {
+			return false
+		}
+	}
+	return true
+}
 
-	return clientRep, nil
+func hasAnyPrefix(value string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(value, prefix) {
+			return true
+		}
+	}
+	return false
 }
 
 func (c *component) GetClients(ctx context.Context, realmName string) ([]api.ClientRepresentation, error) {
@@ -552,11 +823,22 @@ func (c *component) GetUsers(ctx context.Context, realmName string, groupIDs []s
 	var accessToken = ctx.Value(cs.CtContextAccessToken).(string)
 	var ctxRealm = ctx.Value(cs.CtContextRealm).(string)
 
+	// includeDeleted is handled by the bridge: it is not forwarded to Keycloak
+	var includeDeleted = false
+	var kcParamKV []string
+	for i := 0; i+1 < len(paramKV); i += 2 {
+		if paramKV[i] == prmQryIncludeDeleted {
+			includeDeleted = paramKV[i+1] == "true"
+		} else {
+			kcParamKV = append(kcParamKV, paramKV[i], paramKV[i+1])
+		}
+	}
+
 	for _, groupID := range groupIDs {
-		paramKV = append(paramKV, "groupId", groupID)
+		kcParamKV = append(kcParamKV, "groupId", groupID)
 	}
 
-	usersKc, err := c.keycloakClient.GetUsers(accessToken, ctxRealm, realmName, paramKV...)
+	usersKc, err := c.keycloakClient.GetUsers(accessToken, ctxRealm, realmName, kcParamKV...)
 
 	if err != nil {
 		c.logger.Warn(ctx, "err", err.Error())
@@ -566,7 +848,11 @@ func (c *component) GetUsers(ctx context.Context, realmName string, groupIDs []s
 	for i := 0; i < len(usersKc.Users); i++ {
 		keycloakb.ConvertLegacyAttribute(&usersKc.Users[i])
 	}
-	return api.ConvertToAPIUsersPage(ctx, usersKc, c.logger), nil
+	var page = api.ConvertToAPIUsersPage(ctx, usersKc, c.logger)
+	if includeDeleted {
+		return page, nil
+	}
+	return page.WithoutDeletedUsers(), nil
 }
 
 // GetUserAccountStatus gets the user status : user should be enabled in Keycloak and have multifactor activated
@@ -620,6 +906,65 @@ func (c *component) GetRolesOfUser(ctx context.Context, realmName, userID string
 	return rolesRep, nil
 }
 
+func (c *component) AddRoleToUser(ctx context.Context, realmName, userID string, roleID string) error {
+	var accessToken = ctx.Value(cs.CtContextAccessToken).(string)
+
+	role, err := c.getRealmRole(ctx, accessToken, realmName, roleID)
+	if err != nil {
+		return err
+	}
+	// A composite role would give the roles it contains, which are not checked here
+	if (role.Composite != nil && *role.Composite) || (role.Name != nil && adminRealmRoles[*role.Name]) {
+		c.logger.Warn(ctx, "msg", "Role can't be assigned", "roleID", roleID)
+		return errorhandler.CreateBadRequestError(constants.MsgErrInvalidParam + "." + constants.RoleID)
+	}
+
+	if err = c.keycloakClient.AddRealmLevelRoleMappings(accessToken, realmName, userID, []kc.RoleRepresentation{role}); err != nil {
+		c.logger.Warn(ctx, "err", err.Error())
+		return err
+	}
+
+	c.reportEvent(ctx, "API_ROLE_ASSIGNMENT", database.CtEventRealmName, realmName, database.CtEventUserID, userID,
+		database.CtEventAdditionalInfo, database.CreateAdditionalInfo("role_id", roleID, "role_name", *role.Name))
+
+	return nil
+}
+
+func (c *component) DeleteRoleForUser(ctx context.Context, realmName, userID string, roleID string) error {
+	var accessToken = ctx.Value(cs.CtContextAccessToken).(string)
+
+	role, err := c.getRealmRole(ctx, accessToken, realmName, roleID)
+	if err != nil {
+		return err
+	}
+
+	if err = c.keycloakClient.DeleteRealmLevelRoleMappings(accessToken, realmName, userID, []kc.RoleRepresentation{role}); err != nil {
+		c.logger.Warn(ctx, "err", err.Error())
+		return err
+	}
+
+	c.reportEvent(ctx, "API_ROLE_UNASSIGNMENT", database.CtEventRealmName, realmName, database.CtEventUserID, userID,
+		database.CtEventAdditionalInfo, database.CreateAdditionalInfo("role_id", roleID, "role_name", *role.Name))
+
+	return nil
+}
+
+// getRealmRole gets a role by its identifier and checks it is a realm role
+func (c *component) getRealmRole(ctx context.Context, accessToken, realmName, roleID string) (kc.RoleRepresentation, error) {
+	role, err := c.keycloakClient.GetRole(accessToken, realmName, roleID)
+	if err != nil {
+		c.logger.Warn(ctx, "err", err.Error())
+		return kc.RoleRepresentation{}, err
+	}
+
+	if role.ClientRole != nil && *role.ClientRole {
+		c.logger.Warn(ctx, "msg", "Role is not a realm role", "roleID", roleID)
+		return kc.RoleRepresentation{}, errorhandler.CreateBadRequestError(constants.MsgErrInvalidParam + "." + constants.RoleID)
+	}
+
+	return role, nil
+}
+
 func (c *component) GetGroupsOfUser(ctx context.Context, realmName, userID string) ([]api.GroupRepresentation, error) {
 	var accessToken = ctx.Value(cs.CtContextAccessToken).(string)
 
@@ -635,6 +980,7 @@ func (c *component) GetGroupsOfUser(ctx context.Context, realmName, userID strin
 		var groupRep api.GroupRepresentation
 		groupRep.ID = groupKc.ID
 		groupRep.Name = groupKc.Name
+		groupRep.Path = groupKc.Path
 
 		groupsRep = append(groupsRep, groupRep)
 	}
@@ -769,6 +1115,34 @@ func (c *component) AddClientRolesToUser(ctx context.Context, realmName, userID,
 	return err
 }
 
+func (c *component) DeleteClientRoleForUser(ctx context.Context, realmName, userID, clientID string, roleID string) error {
+	var accessToken = ctx.Value(cs.CtContextAccessToken).(string)
+
+	rolesKc, err := c.keycloakClient.GetClientRoleMappings(accessToken, realmName, userID, clientID)
+	if err != nil {
+		c.logger.Warn(ctx, "err", err.Error())
+		return err
+	}
+
+	// Only a role currently mapped to the user can be removed
+	for _, role := range rolesKc {
+		if role.ID == nil || *role.ID != roleID {
+			continue
+		}
+
+		if err = c.keycloakClient.DeleteClientRolesFromUserRoleMapping(accessToken, realmName, userID, clientID, []kc.RoleRepresentation{role}); err != nil {
+			c.logger.Warn(ctx, "err", err.Error())
+			return err
+		}
+
+		c.reportEvent(ctx, "API_CLIENT_ROLE_UNASSIGNMENT", database.CtEventRealmName, realmName, database.CtEventUserID, userID,
+			database.CtEventAdditionalInfo, database.CreateAdditionalInfo("client_id", clientID, "role_id", roleID, "role_name", *role.Name))
+		return nil
+	}
+
+	return errorhandler.CreateNotFoundError(constants.RoleID)
+}
+
 func (c *component) ResetPassword(ctx context.Context, realmName string, userID string, password api.PasswordRepresentation) (string, error) {
 	var accessToken = ctx.Value(cs.CtContextAccessToken).(string)
 
@@ -999,6 +1373,136 @@ func (c *component) ResetCredentialFailuresForUser(ctx context.Context, realmNam
 	return nil
 }
 
+// UpdateLabelCredentialForUser changes the label of a credential of the user
+func (c *component) UpdateLabelCredentialForUser(ctx context.Context, realmName string, userID string, credentialID string, label string) error {
+	var accessToken = ctx.Value(cs.CtContextAccessToken).(string)
+
+	if err := c.keycloakClient.UpdateLabelCredential(accessToken, realmName, userID, credentialID, label); err != nil {
+		c.logger.Warn(ctx, "err", err.Error())
+		return err
+	}
+
+	c.reportEvent(ctx, "API_CREDENTIAL_LABEL_UPDATE", database.CtEventRealmName, realmName, database.CtEventUserID, userID,
+		database.CtEventAdditionalInfo, database.CreateAdditionalInfo("credential_id", credentialID, "label", label))
+
+	return nil
+}
+
+// MoveCredentialForUser moves a credential of the user after previousCredentialID. If previousCredentialID is "null", the credential becomes the first one.
+func (c *component) MoveCredentialForUser(ctx context.Context, realmName string, userID string, credentialID string, previousCredentialID string) error {
+	var accessToken = ctx.Value(cs.CtContextAccessToken).(string)
+	var err error
+
+	if previousCredentialID == "null" {
+		err = c.keycloakClient.MoveToFirst(accessToken, realmName, userID, credentialID)
+	} else {
+		err = c.keycloakClient.MoveAfter(accessToken, realmName, userID, credentialID, previousCredentialID)
+	}
+
+	if err != nil {
+		c.logger.Warn(ctx, "err", err.Error())
+		return err
+	}
+
+	c.reportEvent(ctx, "API_CREDENTIAL_MOVE", database.CtEventRealmName, realmName, database.CtEventUserID, userID,
+		database.CtEventAdditionalInfo, database.CreateAdditionalInfo("credential_id", credentialID, "previous_credential_id", previousCredentialID))
+
+	return nil
+}
+
+// GetCredentialRegistratorsForUser returns the credential types a user can enroll, i.e. the ones whose registration required action is enabled in the realm
+func (c *component) GetCredentialRegistratorsForUser(ctx context.Context, realmName string, userID string) ([]string, error) {
+	var accessToken = ctx.Value(cs.CtContextAccessToken).(string)
+
+	var enabledActions, err = c.getEnabledRequiredActions(accessToken, realmName)
+	if err != nil {
+		c.logger.Warn(ctx, "err", err.Error())
+		return nil, err
+	}
+
+	var credentialTypes = []string{}
+	for credentialType, action := range api.CredentialTypeRequiredActions {
+		if enabledActions[action] {
+			credentialTypes = append(credentialTypes, credentialType)
+		}
+	}
+	sort.Strings(credentialTypes)
+
+	return credentialTypes, nil
+}
+
+// RequireReEnrollment adds the required action which makes the user enroll a credential of the given type again and notifies the user by email.
+// Existing credentials are left unchanged.
+func (c *component) RequireReEnrollment(ctx context.Context, realmName string, userID string, credentialType string) error {
+	var accessToken = ctx.Value(cs.CtContextAccessToken).(string)
+
+	var action, ok = api.CredentialTypeRequiredActions[credentialType]
+	if !ok {
+		return errorhandler.CreateBadRequestError(constants.MsgErrInvalidParam + "." + constants.Type)
+	}
+
+	var enabledActions, err = c.getEnabledRequiredActions(accessToken, realmName)
+	if err != nil {
+		c.logger.Warn(ctx, "err", err.Error())
+		return err
+	}
+	if !enabledActions[action] {
+		c.logger.Info(ctx, "msg", "Required action is not enabled in realm", "action", action, "realm", realmName)
+		return errorhandler.CreateBadRequestError(constants.MsgErrInvalidParam + "." + constants.Type)
+	}
+
+	userKc, err := c.keycloakClient.GetUser(accessToken, realmName, userID)
+	if err != nil {
+		c.logger.Warn(ctx, "err", err.Error())
+		return err
+	}
+	keycloakb.ConvertLegacyAttribute(&userKc)
+
+	var requiredActions = []string{}
+	var alreadyRequired = false
+	if userKc.RequiredActions != nil {
+		requiredActions = *userKc.RequiredActions
+	}
+	for _, requiredAction := range requiredActions {
+		alreadyRequired = alreadyRequired || requiredAction == action
+	}
+	if !alreadyRequired {
+		requiredActions = append(requiredActions, action)
+		userKc.RequiredActions = &requiredActions
+		if err = c.keycloakClient.UpdateUser(accessToken, realmName, userID, userKc); err != nil {
+			c.logger.Warn(ctx, "err", err.Error())
+			return err
+		}
+	}
+
+	c.reportEvent(ctx, "API_CREDENTIAL_RE_ENROLLMENT", database.CtEventRealmName, realmName, database.CtEventUserID, userID,
+		database.CtEventAdditionalInfo, database.CreateAdditionalInfo("credential_type", credentialType))
+
+	// The required action is set: a failure to notify the user must not fail the request
+	if userKc.Email != nil && *userKc.Email != "" {
+		if err = c.keycloakClient.ExecuteActionsEmail(accessToken, realmName, userID, []string{action}); err != nil {
+			c.logger.Warn(ctx, "msg", "Could not notify user of re-enrollment", "err", err.Error())
+		}
+	}
+
+	return nil
+}
+
+func (c *component) getEnabledRequiredActions(accessToken string, realmName string) (map[string]bool, error) {
+	var requiredActionsKc, err = c.keycloakClient.GetRequiredActions(accessToken, realmName)
+	if err != nil {
+		return nil, err
+	}
+
+	var enabled = map[string]bool{}
+	for _, requiredActionKc := range requiredActionsKc {
+		if requiredActionKc.Alias != nil && requiredActionKc.Enabled != nil && *requiredActionKc.Enabled {
+			enabled[*requiredActionKc.Alias] = true
+		}
+	}
+	return enabled, nil
+}
+
 func (c *component) getCredentialType(ctx context.Context, realmName, userID, credentialID string) (string, error) {
 	var accessToken = ctx.Value(cs.CtContextAccessToken).(string)
 	var creds, err = c.keycloakClient.GetCredentials(accessToken, realmName, userID)
@@ -1040,19 +1544,151 @@ func (c *component) GetAttackDetectionStatus(ctx context.Context, realmName, use
 	return api.ConvertAttackDetectionStatus(mapValues), nil
 }
 
-func (c *component) GetRoles(ctx context.Context, realmName string) ([]api.RoleRepresentation, error) {
+// GetUserSessions returns the active and offline sessions of a user
+func (c *component) GetUserSessions(ctx context.Context, realmName, userID string) ([]api.UserSessionRepresentation, error) {
 	var accessToken = ctx.Value(cs.CtContextAccessToken).(string)
 
-	rolesKc, err := c.keycloakClient.GetRoles(accessToken, realmName)
-
+	var sessions, err = c.getUserSessions(ctx, accessToken, realmName, userID)
 	if err != nil {
-		c.logger.Warn(ctx, "err", err.Error())
 		return nil, err
 	}
 
-	var rolesRep = []api.RoleRepresentation{}
-	for _, roleKc := range rolesKc {
-		var roleRep api.RoleRepresentation
+	var res = []api.UserSessionRepresentation{}
+	for _, session := range sessions {
+		res = append(res, api.ConvertToAPIUserSession(session.UserSessionRepresentation, session.offline))
+	}
+	return res, nil
+}
+
+// RevokeUserSession ends a session. The session must belong to the given user.
+func (c *component) RevokeUserSession(ctx context.Context, realmName, userID, sessionID string) error {
+	var accessToken = ctx.Value(cs.CtContextAccessToken).(string)
+
+	var sessions, err = c.getUserSessions(ctx, accessToken, realmName, userID)
+	if err != nil {
+		return err
+	}
+
+	for _, session := range sessions {
+		if session.ID == nil || *session.ID != sessionID {
+			continue
+		}
+
+		if err = c.keycloakClient.DeleteSession(accessToken, realmName, sessionID); err != nil {
+			c.logger.Warn(ctx, "err", err.Error())
+			return err
+		}
+
+		c.reportEvent(ctx, "API_REVOKE_USER_SESSION", database.CtEventRealmName, realmName, database.CtEventUserID, userID,
+			database.CtEventAdditionalInfo, database.CreateAdditionalInfo("session_id", sessionID, "offline", strconv.FormatBool(session.offline)))
+		return nil
+	}
+
+	return errorhandler.CreateNotFoundError(constants.SessionID)
+}
+
+// RevokeUserSessions logs the user out of all active sessions and ends the offline sessions
+func (c *component) RevokeUserSessions(ctx context.Context, realmName, userID string) error {
+	var accessToken = ctx.Value(cs.CtContextAccessToken).(string)
+
+	var sessions, err = c.getUserSessions(ctx, accessToken, realmName, userID)
+	if err != nil {
+		return err
+	}
+
+	if err = c.keycloakClient.LogoutUser(accessToken, realmName, userID); err != nil {
+		c.logger.Warn(ctx, "err", err.Error())
+		return err
+	}
+
+	var offlineSessions = 0
+	for _, session := range sessions {
+		if !session.offline {
+			continue
+		}
+		if err = c.keycloakClient.DeleteSession(accessToken, realmName, *session.ID); err != nil {
+			c.logger.Warn(ctx, "err", err.Error())
+			return err
+		}
+		offlineSessions++
+	}
+
+	c.reportEvent(ctx, "API_REVOKE_USER_SESSIONS", database.CtEventRealmName, realmName, database.CtEventUserID, userID,
+		database.CtEventAdditionalInfo, database.CreateAdditionalInfo("sessions", strconv.Itoa(len(sessions)), "offline_sessions", strconv.Itoa(offlineSessions)))
+
+	return nil
+}
+
+type userSession struct {
+	kc.UserSessionRepresentation
+	offline bool
+}
+
+// getUserSessions returns the active sessions then the offline sessions of a user. Keycloak only returns the offline sessions
+// client by client: an offline session used by several clients is returned once.
+func (c *component) getUserSessions(ctx context.Context, accessToken, realmName, userID string) ([]userSession, error) {
+	var res []userSession
+
+	sessions, err := c.keycloakClient.GetSessionsOfUser(accessToken, realmName, userID)
+	if err != nil {
+		c.logger.Warn(ctx, "err", err.Error())
+		return nil, err
+	}
+	for _, session := range sessions {
+		res = append(res, userSession{UserSessionRepresentation: session})
+	}
+
+	clients, err := c.keycloakClient.GetClients(accessToken, realmName)
+	if err != nil {
+		c.logger.Warn(ctx, "err", err.Error())
+		return nil, err
+	}
+
+	var offlineSessions = map[string]int{}
+	for _, client := range clients {
+		sessions, err = c.keycloakClient.GetOfflineSessionsOfUser(accessToken, realmName, userID, *client.ID)
+		if err != nil {
+			c.logger.Warn(ctx, "err", err.Error())
+			return nil, err
+		}
+		for _, session := range sessions {
+			if idx, ok := offlineSessions[*session.ID]; ok {
+				res[idx].Clients = mergeSessionClients(res[idx].Clients, session.Clients)
+				continue
+			}
+			offlineSessions[*session.ID] = len(res)
+			res = append(res, userSession{UserSessionRepresentation: session, offline: true})
+		}
+	}
+
+	return res, nil
+}
+
+func mergeSessionClients(clients *map[string]string, others *map[string]string) *map[string]string {
+	var res = map[string]string{}
+	for _, m := range []*map[string]string{clients, others} {
+		if m != nil {
+			for k, v := range *m {
+				res[k] = v
+			}
+		}
+	}
+	return &res
+}
+
+func (c *component) GetRoles(ctx context.Context, realmName string) ([]api.RoleRepresentation, error) {
+	var accessToken = ctx.Value(cs.CtContextAccessToken).(string)
+
+	rolesKc, err := c.keycloakClient.GetRoles(accessToken, realmName)
+
+	if err != nil {
+		c.logger.Warn(ctx, "err", err.Error())
+		return nil, err
+	}
+
+	var rolesRep = []api.RoleRepresentation{}
+	for _, roleKc := range rolesKc {
+		var roleRep api.RoleRepresentation
 		roleRep.ID = roleKc.ID
 		roleRep.Name = roleKc.Name
 		roleRep.Composite = roleKc.Composite
@@ -1087,6 +1723,83 @@ func (c *component) GetRole(ctx context.Context, realmName string, roleID string
 	return roleRep, nil
 }
 
+func (c *component) CreateRole(ctx context.Context, realmName string, role api.RoleRepresentation) (string, error) {
+	var accessToken = ctx.Value(cs.CtContextAccessToken).(string)
+
+	if role.Name == nil {
+		return "", errorhandler.CreateMissingParameterError(constants.Name)
+	}
+
+	var falseBool = false
+	var roleRep kc.RoleRepresentation
+	roleRep.Name = role.Name
+	roleRep.Description = role.Description
+	roleRep.ClientRole = &falseBool
+
+	locationURL, err := c.keycloakClient.CreateRole(accessToken, realmName, roleRep)
+	if err != nil {
+		c.logger.Warn(ctx, "err", err.Error())
+		return "", err
+	}
+
+	c.reportEvent(ctx, "API_ROLE_CREATION", database.CtEventRealmName, realmName, database.CtEventAdditionalInfo, database.CreateAdditionalInfo("role_name", *role.Name))
+
+	return locationURL, nil
+}
+
+// UpdateRole updates the name and/or the description of a realm role
+func (c *component) UpdateRole(ctx context.Context, realmName string, roleID string, role api.RoleRepresentation) error {
+	var accessToken = ctx.Value(cs.CtContextAccessToken).(string)
+
+	roleKc, err := c.getRealmRole(ctx, accessToken, realmName, roleID)
+	if err != nil {
+		return err
+	}
+	if isBuiltInRealmRole(roleKc) {
+		return errorhandler.CreateBadRequestError(constants.MsgErrInvalidParam + "." + constants.RoleID)
+	}
+
+	var oldName = *roleKc.Name
+	if role.Name != nil {
+		roleKc.Name = role.Name
+	}
+	if role.Description != nil {
+		roleKc.Description = role.Description
+	}
+
+	if err = c.keycloakClient.UpdateRole(accessToken, realmName, roleID, roleKc); err != nil {
+		c.logger.Warn(ctx, "err", err.Error())
+		return err
+	}
+
+	c.reportEvent(ctx, "API_ROLE_UPDATE", database.CtEventRealmName, realmName, database.CtEventAdditionalInfo,
+		database.CreateAdditionalInfo("role_id", roleID, "role_name", *roleKc.Name, "old_name", oldName))
+
+	return nil
+}
+
+func (c *component) DeleteRole(ctx context.Context, realmName string, roleID string) error {
+	var accessToken = ctx.Value(cs.CtContextAccessToken).(string)
+
+	roleKc, err := c.getRealmRole(ctx, accessToken, realmName, roleID)
+	if err != nil {
+		return err
+	}
+	if isBuiltInRealmRole(roleKc) {
+		return errorhandler.CreateBadRequestError(constants.MsgErrInvalidParam + "." + constants.RoleID)
+	}
+
+	if err = c.keycloakClient.DeleteRole(accessToken, realmName, roleID); err != nil {
+		c.logger.Warn(ctx, "err", err.Error())
+		return err
+	}
+
+	c.reportEvent(ctx, "API_ROLE_DELETION", database.CtEventRealmName, realmName, database.CtEventAdditionalInfo,
+		database.CreateAdditionalInfo("role_id", roleID, "role_name", *roleKc.Name))
+
+	return nil
+}
+
 func (c *component) GetGroups(ctx context.Context, realmName string) ([]api.GroupRepresentation, error) {
 	var accessToken = ctx.Value(cs.CtContextAccessToken).(string)
 
@@ -1099,11 +1812,7 @@ func (c *component) GetGroups(ctx context.Context, realmName string) ([]api.Grou
 
 	var groupsRep = []api.GroupRepresentation{}
 	for _, groupKc := range groupsKc {
-		var groupRep api.GroupRepresentation
-		groupRep.ID = groupKc.ID
-		groupRep.Name = groupKc.Name
-
-		groupsRep = append(groupsRep, groupRep)
+		groupsRep = append(groupsRep, api.ConvertToAPIGroup(groupKc))
 	}
 
 	return groupsRep, nil
@@ -1112,6 +1821,12 @@ func (c *component) GetGroups(ctx context.Context, realmName string) ([]api.Grou
 func (c *component) CreateGroup(ctx context.Context, realmName string, group api.GroupRepresentation) (string, error) {
 	var accessToken = ctx.Value(cs.CtContextAccessToken).(string)
 
+	if group.Name != nil {
+		if err := c.checkGroupNameIsUnique(ctx, accessToken, realmName, "", *group.Name); err != nil {
+			return "", err
+		}
+	}
+
 	var groupRep kc.GroupRepresentation
 	groupRep = api.ConvertToKCGroup(group)
 
@@ -1131,6 +1846,154 @@ func (c *component) CreateGroup(ctx context.Context, realmName string, group api
 	return locationURL, nil
 }
 
+func (c *component) CreateSubGroup(ctx context.Context, realmName string, parentGroupID string, group api.GroupRepresentation) (string, error) {
+	var accessToken = ctx.Value(cs.CtContextAccessToken).(string)
+
+	if group.Name == nil {
+		return "", errorhandler.CreateMissingParameterError(constants.Name)
+	}
+	if err := c.checkGroupNameIsUnique(ctx, accessToken, realmName, "", *group.Name); err != nil {
+		return "", err
+	}
+
+	locationURL, err := c.keycloakClient.CreateGroupChild(accessToken, realmName, parentGroupID, api.ConvertToKCGroup(group))
+	if err != nil {
+		c.logger.Warn(ctx, "err", err.Error())
+		return "", err
+	}
+
+	//retrieve the group ID, the location may also contain the ID of the parent
+	reg := regexp.MustCompile(`[0-9a-fA-F]{8}\-[0-9a-fA-F]{4}\-[0-9a-fA-F]{4}\-[0-9a-fA-F]{4}\-[0-9a-fA-F]{12}`)
+	var groupID string
+	if ids := reg.FindAllString(locationURL, -1); len(ids) > 0 {
+		groupID = ids[len(ids)-1]
+	}
+
+	c.reportEvent(ctx, "API_GROUP_CREATION", database.CtEventRealmName, realmName, database.CtEventGroupID, groupID, database.CtEventGroupName, *group.Name,
+		database.CtEventAdditionalInfo, database.CreateAdditionalInfo("parent_id", parentGroupID))
+
+	return locationURL, nil
+}
+
+// UpdateGroup renames a group. The authorizations and the back-office configuration given to the group or targeting it
+// follow the new name.
+func (c *component) UpdateGroup(ctx context.Context, realmName string, groupID string, group api.GroupRepresentation) error {
+	var accessToken = ctx.Value(cs.CtContextAccessToken).(string)
+
+	if group.Name == nil {
+		return errorhandler.CreateMissingParameterError(constants.Name)
+	}
+
+	groupKc, err := c.keycloakClient.GetGroup(accessToken, realmName, groupID)
+	if err != nil {
+		c.logger.Warn(ctx, "err", err.Error())
+		return err
+	}
+	if err = c.checkGroupNameIsUnique(ctx, accessToken, realmName, groupID, *group.Name); err != nil {
+		return err
+	}
+
+	var oldName, oldPath = *groupKc.Name, groupPath(groupKc)
+	var newPath = strings.TrimSuffix(oldPath, oldName) + *group.Name
+
+	groupKc.Name = group.Name
+	groupKc.SubGroups = nil
+	if err = c.keycloakClient.UpdateGroup(accessToken, realmName, groupID, groupKc); err != nil {
+		c.logger.Warn(ctx, "err", err.Error())
+		return err
+	}
+
+	if err = c.configDBModule.UpdateGroupReferences(ctx, realmName, oldName, *group.Name, oldPath, newPath); err != nil {
+		return err
+	}
+
+	c.reportEvent(ctx, "API_GROUP_UPDATE", database.CtEventRealmName, realmName, database.CtEventGroupID, groupID, database.CtEventGroupName, *group.Name,
+		database.CtEventAdditionalInfo, database.CreateAdditionalInfo("old_name", oldName))
+
+	return nil
+}
+
+// MoveGroup moves a group under another group, or to the top-level when no parent is given. The subtree authorizations
+// follow the new path.
+func (c *component) MoveGroup(ctx context.Context, realmName string, groupID string, parent api.GroupParentRepresentation) error {
+	var accessToken = ctx.Value(cs.CtContextAccessToken).(string)
+
+	groupKc, err := c.keycloakClient.GetGroup(accessToken, realmName, groupID)
+	if err != nil {
+		c.logger.Warn(ctx, "err", err.Error())
+		return err
+	}
+	var oldPath = groupPath(groupKc)
+
+	// Keycloak moves an existing group when it is created again with its identifier
+	var movedGroup = kc.GroupRepresentation{ID: groupKc.ID, Name: groupKc.Name}
+	if parent.ParentID == nil {
+		_, err = c.keycloakClient.CreateGroup(accessToken, realmName, movedGroup)
+	} else {
+		_, err = c.keycloakClient.CreateGroupChild(accessToken, realmName, *parent.ParentID, movedGroup)
+	}
+	if err != nil {
+		c.logger.Warn(ctx, "err", err.Error())
+		return err
+	}
+
+	if groupKc, err = c.keycloakClient.GetGroup(accessToken, realmName, groupID); err != nil {
+		c.logger.Warn(ctx, "err", err.Error())
+		return err
+	}
+
+	if err = c.configDBModule.UpdateGroupReferences(ctx, realmName, *groupKc.Name, *groupKc.Name, oldPath, groupPath(groupKc)); err != nil {
+		return err
+	}
+
+	var parentID = ""
+	if parent.ParentID != nil {
+		parentID = *parent.ParentID
+	}
+	c.reportEvent(ctx, "API_GROUP_MOVE", database.CtEventRealmName, realmName, database.CtEventGroupID, groupID, database.CtEventGroupName, *groupKc.Name,
+		database.CtEventAdditionalInfo, database.CreateAdditionalInfo("parent_id", parentID, "old_path", oldPath))
+
+	return nil
+}
+
+// checkGroupNameIsUnique makes sure that no other group of the realm, subgroups included, has the given name. The
+// authorizations are stored with the group names: two groups with the same name would share them.
+func (c *component) checkGroupNameIsUnique(ctx context.Context, accessToken, realmName, groupID, groupName string) error {
+	groups, err := c.keycloakClient.GetGroups(accessToken, realmName)
+	if err != nil {
+		c.logger.Warn(ctx, "err", err.Error())
+		return err
+	}
+	if hasOtherGroupNamed(groups, groupID, groupName) {
+		c.logger.Warn(ctx, "msg", "Group name is already used in the realm", "realm", realmName, "groupName", groupName)
+		return errorhandler.Error{
+			Status:  http.StatusConflict,
+			Message: keycloakb.ComponentName + "." + constants.MsgErrExistingValue + "." + constants.Name,
+		}
+	}
+	return nil
+}
+
+func hasOtherGroupNamed(groups []kc.GroupRepresentation, groupID, groupName string) bool {
+	for _, group := range groups {
+		if group.Name != nil && *group.Name == groupName && (group.ID == nil || *group.ID != groupID) {
+			return true
+		}
+		if group.SubGroups != nil && hasOtherGroupNamed(*group.SubGroups, groupID, groupName) {
+			return true
+		}
+	}
+	return false
+}
+
+// groupPath returns the path of a group. A group returned without path is a top-level group.
+func groupPath(group kc.GroupRepresentation) string {
+	if group.Path != nil {
+		return *group.Path
+	}
+	return "/" + *group.Name
+}
+
 func (c *component) DeleteGroup(ctx context.Context, realmName, groupID string) error {
 	var accessToken = ctx.Value(cs.CtContextAccessToken).(string)
 
@@ -1148,10 +2011,13 @@ func (c *component) DeleteGroup(ctx context.Context, realmName, groupID string)
 		return err
 	}
 
-	err = c.configDBModule.DeleteAllAuthorizationsWithGroup(ctx, realmName, groupName)
-	if err != nil {
-		c.logger.Warn(ctx, "err", err.Error())
-		return err
+	// Keycloak also deletes the subgroups
+	for _, targetGroupName := range groupTargetNames(group) {
+		err = c.configDBModule.DeleteAllAuthorizationsWithGroup(ctx, realmName, targetGroupName)
+		if err != nil {
+			c.logger.Warn(ctx, "err", err.Error())
+			return err
+		}
 	}
 
 	//store the API call into the DB
@@ -1160,6 +2026,20 @@ func (c *component) DeleteGroup(ctx context.Context, realmName, groupID string)
 	return nil
 }
 
+// groupTargetNames returns the names and the subtree target group names of a group and of its subgroups
+func groupTargetNames(group kc.GroupRepresentation) []string {
+	var res = []string{*group.Name}
+	if group.Path != nil {
+		res = append(res, keycloakb.SubtreeTargetGroupName(*group.Path))
+	}
+	if group.SubGroups != nil {
+		for _, subGroup := range *group.SubGroups {
+			res = append(res, groupTargetNames(subGroup)...)
+		}
+	}
+	return res
+}
+
 func (c *component) GetAuthorizations(ctx context.Context, realmName string, groupID string) (api.AuthorizationsRepresentation, error) {
 	var accessToken = ctx.Value(cs.CtContextAccessToken).(string)
 	group, err := c.keycloakClient.GetGroup(accessToken, realmName, groupID)
@@ -1266,7 +2146,9 @@ func (c *component) checkAllowedTargetRealmsAndGroupNames(ctx context.Context, r
 				}
 
 				for _, group := range groups {
-					allowedTargetRealmsAndGroupNames[realmID][*group.Name] = struct{}{}
+					for _, targetGroupName := range groupTargetNames(group) {
+						allowedTargetRealmsAndGroupNames[realmID][targetGroupName] = struct{}{}
+					}
 				}
 
 				allowedTargetRealmsAndGroupNames[realmID]["*"] = struct{}{}
@@ -1520,7 +2402,15 @@ func (c *component) UpdateRealmCustomConfiguration(ctx context.Context, realmNam
 
 	// from the realm ID, update the custom configuration in the DB
 	realmID := realmConfig.ID
-	return c.configDBModule.StoreOrUpdateConfiguration(ctx, *realmID, config)
+	if err = c.storeBaselineRevision(ctx, *realmID, dto.ConfigurationTypeCustom); err != nil {
+		return err
+	}
+	if err = c.configDBModule.StoreOrUpdateConfiguration(ctx, *realmID, config); err != nil {
+		return err
+	}
+
+	var content, _ = json.Marshal(config)
+	return c.storeConfigurationRevision(ctx, *realmID, dto.ConfigurationTypeCustom, string(content), nil)
 }
 
 func (c *component) matchClients(customConfig api.RealmCustomConfiguration, clients []kc.ClientRepresentation) bool {
@@ -1568,17 +2458,27 @@ func (c *component) GetRealmAdminConfiguration(ctx context.Context, realmName st
 		return api.RealmAdminConfiguration{}, err
 	}
 
+	var res api.RealmAdminConfiguration
 	var config configuration.RealmAdminConfiguration
 	config, err = c.configDBModule.GetAdminConfiguration(ctx, *realmConfig.ID)
-	if err != nil {
-		if err == sql.ErrNoRows {
-			return api.CreateDefaultRealmAdminConfiguration(), nil
-		}
+	if err == sql.ErrNoRows {
+		res = api.CreateDefaultRealmAdminConfiguration()
+	} else if err != nil {
 		c.logger.Warn(ctx, "err", err.Error())
 		return api.RealmAdminConfiguration{}, err
+	} else {
+		res = api.ConvertRealmAdminConfigurationFromDBStruct(config)
 	}
 
-	return api.ConvertRealmAdminConfigurationFromDBStruct(config), nil
+	// The client policy is stored with the admin configuration
+	policy, err := c.configDBModule.GetClientPolicy(ctx, *realmConfig.ID)
+	if err != nil {
+		return api.RealmAdminConfiguration{}, err
+	}
+	var clientPolicy = api.ConvertToAPIClientPolicy(policy)
+	res.ClientPolicy = &clientPolicy
+
+	return res, nil
 }
 
 // Update the configuration in the database
@@ -1592,13 +2492,25 @@ func (c *component) UpdateRealmAdminConfiguration(ctx context.Context, realmName
 		return err
 	}
 
-	err = c.configDBModule.StoreOrUpdateAdminConfiguration(ctx, *realmRepr.ID, adminConfig.ConvertToDBStruct())
+	var config = adminConfig.ConvertToDBStruct()
+	var clientPolicy dto.ClientPolicy
+	if adminConfig.ClientPolicy != nil {
+		clientPolicy = adminConfig.ClientPolicy.ConvertToDBStruct()
+	} else if clientPolicy, err = c.configDBModule.GetClientPolicy(ctx, *realmRepr.ID); err != nil {
+		return err
+	}
+
+	if err = c.storeBaselineRevision(ctx, *realmRepr.ID, dto.ConfigurationTypeAdmin); err != nil {
+		return err
+	}
+	err = c.configDBModule.StoreOrUpdateAdminConfiguration(ctx, *realmRepr.ID, config, clientPolicy)
 	if err != nil {
 		c.logger.Warn(ctx, "err", err.Error())
 		return err
 	}
 
-	return nil
+	var content, _ = dto.MarshalAdminConfiguration(config, clientPolicy)
+	return c.storeConfigurationRevision(ctx, *realmRepr.ID, dto.ConfigurationTypeAdmin, string(content), nil)
 }
 
 func (c *component) GetRealmBackOfficeConfiguration(ctx context.Context, realmID string, groupName string) (api.BackOfficeConfiguration, error) {
@@ -1725,6 +2637,231 @@ func (c *component) LinkShadowUser(ctx context.Context, realmName string, userID
 	return nil
 }
 
+func (c *component) GetFederatedIdentities(ctx context.Context, realmName string, userID string) ([]api.FederatedIdentityRepresentation, error) {
+	var accessToken = ctx.Value(cs.CtContextAccessToken).(string)
+
+	fedIDsKc, err := c.keycloakClient.GetFederatedIdentities(accessToken, realmName, userID)
+	if err != nil {
+		c.logger.Warn(ctx, "err", err.Error())
+		return nil, err
+	}
+
+	var fedIDs = []api.FederatedIdentityRepresentation{}
+	for _, fedIDKc := range fedIDsKc {
+		fedIDs = append(fedIDs, api.ConvertToAPIFedID(fedIDKc))
+	}
+	return fedIDs, nil
+}
+
+// DeleteFederatedIdentity unlinks the identity of the user in the given identity provider
+func (c *component) DeleteFederatedIdentity(ctx context.Context, realmName string, userID string, provider string) error {
+	var accessToken = ctx.Value(cs.CtContextAccessToken).(string)
+
+	if err := c.keycloakClient.DeleteFederatedIdentity(accessToken, realmName, userID, provider); err != nil {
+		c.logger.Warn(ctx, "err", err.Error())
+		return err
+	}
+
+	c.reportEvent(ctx, "API_FEDERATED_IDENTITY_REMOVAL", database.CtEventRealmName, realmName, database.CtEventUserID, userID,
+		database.CtEventAdditionalInfo, database.CreateAdditionalInfo("identity_provider", provider))
+
+	return nil
+}
+
+func (c *component) GetIdentityProviders(ctx context.Context, realmName string) ([]api.IdentityProviderRepresentation, error) {
+	var accessToken = ctx.Value(cs.CtContextAccessToken).(string)
+
+	idpsKc, err := c.keycloakClient.GetIdentityProviders(accessToken, realmName)
+	if err != nil {
+		c.logger.Warn(ctx, "err", err.Error())
+		return nil, err
+	}
+
+	var idps = []api.IdentityProviderRepresentation{}
+	for _, idpKc := range idpsKc {
+		idps = append(idps, api.ConvertToAPIIdentityProvider(idpKc))
+	}
+	return idps, nil
+}
+
+// CreateIdentityProvider creates an identity provider. It is disabled unless explicitly enabled.
+func (c *component) CreateIdentityProvider(ctx context.Context, realmName string, idp api.IdentityProviderRepresentation) error {
+	var accessToken = ctx.Value(cs.CtContextAccessToken).(string)
+
+	if idp.Alias == nil {
+		return errorhandler.CreateMissingParameterError(constants.Alias)
+	}
+	if idp.ProviderID == nil {
+		return errorhandler.CreateMissingParameterError(constants.ProviderID)
+	}
+
+	var falseBool = false
+	var idpKc = kc.IdentityProviderRepresentation{
+		Alias:   idp.Alias,
+		Enabled: &falseBool,
+	}
+	api.MergeIntoKCIdentityProvider(idp, &idpKc)
+
+	if _, err := c.keycloakClient.CreateIdentityProvider(accessToken, realmName, idpKc); err != nil {
+		c.logger.Warn(ctx, "err", err.Error())
+		return err
+	}
+
+	c.reportEvent(ctx, "API_IDENTITY_PROVIDER_CREATION", database.CtEventRealmName, realmName, database.CtEventAdditionalInfo,
+		database.CreateAdditionalInfo("identity_provider", *idp.Alias, "provider_id", *idp.ProviderID))
+
+	return nil
+}
+
+// UpdateIdentityProvider updates the given settings of an identity provider
+func (c *component) UpdateIdentityProvider(ctx context.Context, realmName string, alias string, idp api.IdentityProviderRepresentation) error {
+	return c.updateIdentityProvider(ctx, realmName, alias, "API_IDENTITY_PROVIDER_UPDATE", func(idpKc *kc.IdentityProviderRepresentation) {
+		api.MergeIntoKCIdentityProvider(idp, idpKc)
+	})
+}
+
+// DisableIdentityProvider disables an identity provider. The federated identities of the users are kept.
+func (c *component) DisableIdentityProvider(ctx context.Context, realmName string, alias string) error {
+	return c.updateIdentityProvider(ctx, realmName, alias, "API_IDENTITY_PROVIDER_DISABLING", func(idpKc *kc.IdentityProviderRepresentation) {
+		var falseBool = false
+		idpKc.Enabled = &falseBool
+	})
+}
+
+func (c *component) updateIdentityProvider(ctx context.Context, realmName string, alias string, apiCall string, update func(*kc.IdentityProviderRepresentation)) error {
+	var accessToken = ctx.Value(cs.CtContextAccessToken).(string)
+
+	idpKc, err := c.keycloakClient.GetIdentityProvider(accessToken, realmName, alias)
+	if err != nil {
+		c.logger.Warn(ctx, "err", err.Error())
+		return err
+	}
+
+	update(&idpKc)
+	if err = c.keycloakClient.UpdateIdentityProvider(accessToken, realmName, alias, idpKc); err != nil {
+		c.logger.Warn(ctx, "err", err.Error())
+		return err
+	}
+
+	c.reportEvent(ctx, apiCall, database.CtEventRealmName, realmName, database.CtEventAdditionalInfo, database.CreateAdditionalInfo("identity_provider", alias))
+
+	return nil
+}
+
+func (c *component) GetPasswordPolicy(ctx context.Context, realmName string) (api.PasswordPolicyRepresentation, error) {
+	var accessToken = ctx.Value(cs.CtContextAccessToken).(string)
+
+	realmKc, err := c.keycloakClient.GetRealm(accessToken, realmName)
+	if err != nil {
+		c.logger.Warn(ctx, "err", err.Error())
+		return api.PasswordPolicyRepresentation{}, err
+	}
+	if realmKc.PasswordPolicy == nil {
+		return api.PasswordPolicyRepresentation{}, nil
+	}
+
+	policy, err := keycloakb.ParsePasswordPolicy(*realmKc.PasswordPolicy)
+	if err != nil {
+		c.logger.Warn(ctx, "msg", "Invalid password policy", "err", err.Error(), "realm", realmName)
+		return api.PasswordPolicyRepresentation{}, err
+	}
+	return api.ConvertToAPIPasswordPolicy(policy), nil
+}
+
+// UpdatePasswordPolicy replaces the password policy of the realm. The other settings of the realm are left unchanged.
+func (c *component) UpdatePasswordPolicy(ctx context.Context, realmName string, policy api.PasswordPolicyRepresentation) error {
+	var accessToken = ctx.Value(cs.CtContextAccessToken).(string)
+
+	var serializedPolicy = policy.ConvertToKeycloakbStruct().String()
+	if err := c.keycloakClient.UpdateRealm(accessToken, realmName, kc.RealmRepresentation{PasswordPolicy: &serializedPolicy}); err != nil {
+		c.logger.Warn(ctx, "err", err.Error())
+		return err
+	}
+
+	c.reportEvent(ctx, "API_PASSWORD_POLICY_UPDATE", database.CtEventRealmName, realmName, database.CtEventAdditionalInfo,
+		database.CreateAdditionalInfo("password_policy", serializedPolicy))
+
+	return nil
+}
+
+func (c *component) GetStatisticsReportSchedules(ctx context.Context, realmName string) ([]api.StatisticsReportScheduleRepresentation, error) {
+	var schedules, err = c.configDBModule.GetReportSchedules(ctx, realmName)
+	if err != nil {
+		c.logger.Warn(ctx, "err", err.Error())
+		return nil, err
+	}
+
+	var res = []api.StatisticsReportScheduleRepresentation{}
+	for _, schedule := range schedules {
+		res = append(res, api.ConvertToAPIReportSchedule(schedule))
+	}
+	return res, nil
+}
+
+func (c *component) CreateStatisticsReportSchedule(ctx context.Context, realmName string, schedule api.StatisticsReportScheduleRepresentation) (int64, error) {
+	var scheduleID, err = c.configDBModule.CreateReportSchedule(ctx, schedule.ConvertToDBStruct(realmName))
+	if err != nil {
+		c.logger.Warn(ctx, "err", err.Error())
+		return 0, err
+	}
+
+	//store the API call into the DB
+	var additionalInfo = database.CreateAdditionalInfo("schedule_id", strconv.FormatInt(scheduleID, 10), "frequency", *schedule.Frequency)
+	c.reportEvent(ctx, "API_REPORT_SCHEDULE_CREATION", database.CtEventRealmName, realmName, database.CtEventAdditionalInfo, additionalInfo)
+
+	return scheduleID, nil
+}
+
+func (c *component) UpdateStatisticsReportSchedule(ctx context.Context, realmName string, scheduleID int64, schedule api.StatisticsReportScheduleRepresentation) error {
+	if err := c.checkReportScheduleExists(ctx, realmName, scheduleID); err != nil {
+		return err
+	}
+
+	var dbSchedule = schedule.ConvertToDBStruct(realmName)
+	dbSchedule.ID = scheduleID
+	if err := c.configDBModule.UpdateReportSchedule(ctx, dbSchedule); err != nil {
+		c.logger.Warn(ctx, "err", err.Error())
+		return err
+	}
+
+	//store the API call into the DB
+	var additionalInfo = database.CreateAdditionalInfo("schedule_id", strconv.FormatInt(scheduleID, 10), "frequency", *schedule.Frequency)
+	c.reportEvent(ctx, "API_REPORT_SCHEDULE_UPDATE", database.CtEventRealmName, realmName, database.CtEventAdditionalInfo, additionalInfo)
+
+	return nil
+}
+
+func (c *component) DeleteStatisticsReportSchedule(ctx context.Context, realmName string, scheduleID int64) error {
+	if err := c.checkReportScheduleExists(ctx, realmName, scheduleID); err != nil {
+		return err
+	}
+
+	if err := c.configDBModule.DeleteReportSchedule(ctx, realmName, scheduleID); err != nil {
+		c.logger.Warn(ctx, "err", err.Error())
+		return err
+	}
+
+	//store the API call into the DB
+	var additionalInfo = database.CreateAdditionalInfo("schedule_id", strconv.FormatInt(scheduleID, 10))
+	c.reportEvent(ctx, "API_REPORT_SCHEDULE_DELETION", database.CtEventRealmName, realmName, database.CtEventAdditionalInfo, additionalInfo)
+
+	return nil
+}
+
+func (c *component) checkReportScheduleExists(ctx context.Context, realmName string, scheduleID int64) error {
+	var schedules, err = c.configDBModule.GetReportSchedules(ctx, realmName)
+	if err != nil {
+		c.logger.Warn(ctx, "err", err.Error())
+		return err
+	}
+	for _, schedule := range schedules {
+		if schedule.ID == scheduleID {
+			return nil
+		}
+	}
+	return errorhandler.CreateNotFoundError(constants.ReportSchedule)
+}
+
 func stringInSlice(a string, list []string) bool {
 	for _, b := range list {
 		if b == a {
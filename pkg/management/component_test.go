@@ -2,9 +2,11 @@ package management
 
 import (
 	"context"
+	"database/sql"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"net/http"
 	"strconv"
 	"testing"
 	"time"
@@ -320,6 +322,141 @@ func TestGetClients(t *testing.T) {
 	}
 }
 
+func TestClientLifecycle(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var mockKeycloakClient = mock.NewKeycloakClient(mockCtrl)
+	var mockUsersDetailsDBModule = mock.NewUsersDetailsDBModule(mockCtrl)
+	var mockEventDBModule = mock.NewEventDBModule(mockCtrl)
+	var mockConfigurationDBModule = mock.NewConfigurationDBModule(mockCtrl)
+
+	var component = NewComponent(mockKeycloakClient, mockUsersDetailsDBModule, mockEventDBModule, mockConfigurationDBModule, []string{}, log.NewNopLogger())
+
+	var accessToken = "TOKEN=="
+	var realmName = "master"
+	var realmID = "master-id"
+	var idClient = "1245-1245-4578"
+	var clientID = "my-app"
+	var trueBool = true
+	var expectedError = errors.New("kc error")
+	var ctx = context.WithValue(context.Background(), cs.CtContextAccessToken, accessToken)
+	var realm = kc.RealmRepresentation{ID: &realmID}
+	var policy = dto.ClientPolicy{AllowedRedirectURIPrefixes: []string{"https://app.example.com/"}}
+
+	t.Run("Create client without client ID", func(t *testing.T) {
+		var _, err = component.CreateClient(ctx, realmName, api.ClientRepresentation{})
+		assert.Equal(t, http.StatusBadRequest, err.(errorhandler.Error).Status)
+	})
+	t.Run("Create client not allowed by the policy", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().GetRealm(accessToken, realmName).Return(realm, nil)
+		mockConfigurationDBModule.EXPECT().GetClientPolicy(ctx, realmID).Return(policy, nil)
+		var _, err = component.CreateClient(ctx, realmName, api.ClientRepresentation{ClientID: &clientID, ImplicitFlowEnabled: &trueBool})
+		assert.Equal(t, http.StatusBadRequest, err.(errorhandler.Error).Status)
+		assert.Contains(t, err.Error(), constants.ImplicitFlowEnabled)
+	})
+	t.Run("Create client with wildcard redirect URI", func(t *testing.T) {
+		var redirectURIs = []string{"https://app.example.com/*"}
+		mockKeycloakClient.EXPECT().GetRealm(accessToken, realmName).Return(realm, nil)
+		mockConfigurationDBModule.EXPECT().GetClientPolicy(ctx, realmID).Return(policy, nil)
+		var _, err = component.CreateClient(ctx, realmName, api.ClientRepresentation{ClientID: &clientID, RedirectURIs: &redirectURIs})
+		assert.Equal(t, http.StatusBadRequest, err.(errorhandler.Error).Status)
+		assert.Contains(t, err.Error(), constants.RedirectURIs)
+	})
+	t.Run("Create client", func(t *testing.T) {
+		var redirectURIs = []string{"https://app.example.com/callback"}
+		mockKeycloakClient.EXPECT().GetRealm(accessToken, realmName).Return(realm, nil)
+		mockConfigurationDBModule.EXPECT().GetClientPolicy(ctx, realmID).Return(policy, nil)
+		mockKeycloakClient.EXPECT().CreateClient(accessToken, realmName, gomock.Any()).DoAndReturn(func(_, _ string, client kc.ClientRepresentation) (string, error) {
+			assert.Equal(t, clientID, *client.ClientID)
+			assert.Equal(t, openIDConnectProtocol, *client.Protocol)
+			assert.True(t, *client.StandardFlowEnabled)
+			assert.False(t, *client.ImplicitFlowEnabled)
+			assert.False(t, *client.DirectAccessGrantsEnabled)
+			assert.Equal(t, redirectURIs, *client.RedirectUris)
+			return "location", nil
+		})
+		mockEventDBModule.EXPECT().ReportEvent(ctx, "API_CLIENT_CREATION", "back-office", database.CtEventRealmName, realmName, database.CtEventAdditionalInfo, gomock.Any()).Return(nil)
+		var location, err = component.CreateClient(ctx, realmName, api.ClientRepresentation{ClientID: &clientID, RedirectURIs: &redirectURIs})
+		assert.Nil(t, err)
+		assert.Equal(t, "location", location)
+	})
+
+	t.Run("Update unknown client", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().GetClient(accessToken, realmName, idClient).Return(kc.ClientRepresentation{}, expectedError)
+		assert.Equal(t, expectedError, component.UpdateClient(ctx, realmName, idClient, api.ClientRepresentation{}))
+	})
+	t.Run("Update client not allowed by the policy", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().GetClient(accessToken, realmName, idClient).Return(kc.ClientRepresentation{ClientID: &clientID}, nil)
+		mockKeycloakClient.EXPECT().GetRealm(accessToken, realmName).Return(realm, nil)
+		mockConfigurationDBModule.EXPECT().GetClientPolicy(ctx, realmID).Return(policy, nil)
+		var err = component.UpdateClient(ctx, realmName, idClient, api.ClientRepresentation{PublicClient: &trueBool})
+		assert.Equal(t, http.StatusBadRequest, err.(errorhandler.Error).Status)
+	})
+	t.Run("Update client", func(t *testing.T) {
+		var name = "My application"
+		mockKeycloakClient.EXPECT().GetClient(accessToken, realmName, idClient).Return(kc.ClientRepresentation{ClientID: &clientID}, nil)
+		mockKeycloakClient.EXPECT().GetRealm(accessToken, realmName).Return(realm, nil)
+		mockConfigurationDBModule.EXPECT().GetClientPolicy(ctx, realmID).Return(policy, nil)
+		mockKeycloakClient.EXPECT().UpdateClient(accessToken, realmName, idClient, kc.ClientRepresentation{ClientID: &clientID, Name: &name}).Return(nil)
+		mockEventDBModule.EXPECT().ReportEvent(ctx, "API_CLIENT_UPDATE", "back-office", database.CtEventRealmName, realmName, database.CtEventAdditionalInfo, gomock.Any()).Return(nil)
+		assert.Nil(t, component.UpdateClient(ctx, realmName, idClient, api.ClientRepresentation{Name: &name}))
+	})
+	t.Run("Update redirect URIs outside of the allowed prefixes", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().GetClient(accessToken, realmName, idClient).Return(kc.ClientRepresentation{ClientID: &clientID}, nil)
+		mockKeycloakClient.EXPECT().GetRealm(accessToken, realmName).Return(realm, nil)
+		mockConfigurationDBModule.EXPECT().GetClientPolicy(ctx, realmID).Return(policy, nil)
+		var err = component.UpdateClientRedirectURIs(ctx, realmName, idClient, []string{"https://evil.example.com/"})
+		assert.Equal(t, http.StatusBadRequest, err.(errorhandler.Error).Status)
+	})
+	t.Run("Update redirect URIs", func(t *testing.T) {
+		var redirectURIs = []string{"https://app.example.com/login"}
+		mockKeycloakClient.EXPECT().GetClient(accessToken, realmName, idClient).Return(kc.ClientRepresentation{ClientID: &clientID}, nil)
+		mockKeycloakClient.EXPECT().GetRealm(accessToken, realmName).Return(realm, nil)
+		mockConfigurationDBModule.EXPECT().GetClientPolicy(ctx, realmID).Return(policy, nil)
+		mockKeycloakClient.EXPECT().UpdateClient(accessToken, realmName, idClient, kc.ClientRepresentation{ClientID: &clientID, RedirectUris: &redirectURIs}).Return(nil)
+		mockEventDBModule.EXPECT().ReportEvent(ctx, "API_CLIENT_REDIRECT_URIS_UPDATE", "back-office", database.CtEventRealmName, realmName, database.CtEventAdditionalInfo, gomock.Any()).Return(nil)
+		assert.Nil(t, component.UpdateClientRedirectURIs(ctx, realmName, idClient, redirectURIs))
+	})
+
+	t.Run("Delete client", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().GetClient(accessToken, realmName, idClient).Return(kc.ClientRepresentation{}, expectedError)
+		assert.Equal(t, expectedError, component.DeleteClient(ctx, realmName, idClient))
+
+		mockKeycloakClient.EXPECT().GetClient(accessToken, realmName, idClient).Return(kc.ClientRepresentation{ClientID: &clientID}, nil)
+		mockKeycloakClient.EXPECT().DeleteClient(accessToken, realmName, idClient).Return(expectedError)
+		assert.Equal(t, expectedError, component.DeleteClient(ctx, realmName, idClient))
+
+		mockKeycloakClient.EXPECT().GetClient(accessToken, realmName, idClient).Return(kc.ClientRepresentation{ClientID: &clientID}, nil)
+		mockKeycloakClient.EXPECT().DeleteClient(accessToken, realmName, idClient).Return(nil)
+		mockEventDBModule.EXPECT().ReportEvent(ctx, "API_CLIENT_DELETION", "back-office", database.CtEventRealmName, realmName, database.CtEventAdditionalInfo, gomock.Any()).Return(nil)
+		assert.Nil(t, component.DeleteClient(ctx, realmName, idClient))
+	})
+
+	t.Run("Delete a built-in client", func(t *testing.T) {
+		for _, builtInClientID := range []string{"realm-management", "account", "admin-cli", "security-admin-console"} {
+			var builtInClientID = builtInClientID
+			mockKeycloakClient.EXPECT().GetClient(accessToken, realmName, idClient).Return(kc.ClientRepresentation{ClientID: &builtInClientID}, nil)
+			var err = component.DeleteClient(ctx, realmName, idClient)
+			assert.Equal(t, http.StatusBadRequest, err.(errorhandler.Error).Status)
+		}
+	})
+
+	t.Run("Regenerate client secret", func(t *testing.T) {
+		var secret = "s3cr3t"
+		mockKeycloakClient.EXPECT().RegenerateClientSecret(accessToken, realmName, idClient).Return(kc.CredentialRepresentation{}, expectedError)
+		var _, err = component.RegenerateClientSecret(ctx, realmName, idClient)
+		assert.Equal(t, expectedError, err)
+
+		mockKeycloakClient.EXPECT().RegenerateClientSecret(accessToken, realmName, idClient).Return(kc.CredentialRepresentation{Value: &secret}, nil)
+		mockEventDBModule.EXPECT().ReportEvent(ctx, "API_CLIENT_SECRET_ROTATION", "back-office", database.CtEventRealmName, realmName, database.CtEventAdditionalInfo, gomock.Any()).Return(nil)
+		res, err := component.RegenerateClientSecret(ctx, realmName, idClient)
+		assert.Nil(t, err)
+		assert.Equal(t, secret, *res.Value)
+	})
+
+}
+
 func TestGetRequiredActions(t *testing.T) {
 	var mockCtrl = gomock.NewController(t)
 	defer mockCtrl.Finish()
@@ -1453,6 +1590,34 @@ func TestGetUsers(t *testing.T) {
 
 		assert.NotNil(t, err)
 	}
+
+	// Soft deleted users
+	{
+		var id1, id2 = "1234-7454-4516", "1234-7454-4517"
+		var attributes = make(kc.Attributes)
+		attributes.SetString(constants.AttrbSoftDeleted, "1591000000000")
+		var count = 2
+		var kcUsersRep = kc.UsersPageRepresentation{
+			Count: &count,
+			Users: []kc.UserRepresentation{{ID: &id1}, {ID: &id2, Attributes: &attributes}},
+		}
+
+		var ctx = context.WithValue(context.Background(), cs.CtContextAccessToken, accessToken)
+		ctx = context.WithValue(ctx, cs.CtContextRealm, "master")
+
+		mockKeycloakClient.EXPECT().GetUsers(accessToken, realmName, targetRealmName, prmQryMax, "10", "groupId", "123-456-789").Return(kcUsersRep, nil)
+		apiUsersRep, err := managementComponent.GetUsers(ctx, "DEP", []string{"123-456-789"}, prmQryMax, "10")
+		assert.Nil(t, err)
+		assert.Len(t, apiUsersRep.Users, 1)
+		// The count of Keycloak is kept so that the pagination is not ended before the last users
+		assert.Equal(t, 2, *apiUsersRep.Count)
+
+		mockKeycloakClient.EXPECT().GetUsers(accessToken, realmName, targetRealmName, prmQryMax, "10", "groupId", "123-456-789").Return(kcUsersRep, nil)
+		apiUsersRep, err = managementComponent.GetUsers(ctx, "DEP", []string{"123-456-789"}, prmQryIncludeDeleted, "true", prmQryMax, "10")
+		assert.Nil(t, err)
+		assert.Len(t, apiUsersRep.Users, 2)
+		assert.Equal(t, int64(1591000000000), *apiUsersRep.Users[1].DeletedTimestamp)
+	}
 }
 
 func TestGetUserAccountStatus(t *testing.T) {
@@ -2687,6 +2852,97 @@ func TestClearUserLoginFailures(t *testing.T) {
 	})
 }
 
+func TestUserSessions(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+	var mockKeycloakClient = mock.NewKeycloakClient(mockCtrl)
+	var mockUsersDetailsDBModule = mock.NewUsersDetailsDBModule(mockCtrl)
+	var mockEventDBModule = mock.NewEventDBModule(mockCtrl)
+	var mockConfigurationDBModule = mock.NewConfigurationDBModule(mockCtrl)
+
+	var managementComponent = NewComponent(mockKeycloakClient, mockUsersDetailsDBModule, mockEventDBModule, mockConfigurationDBModule, []string{}, log.NewNopLogger())
+
+	var accessToken = "TOKEN=="
+	var realmName = "DEP"
+	var userID = "41dbf4a8-32a9-4000-8c17-edc854c31231"
+	var client1, client2 = "client-uuid-1", "client-uuid-2"
+	var clients = []kc.ClientRepresentation{{ID: &client1}, {ID: &client2}}
+	var onlineID, offlineID = "online-session", "offline-session"
+	var online = kc.UserSessionRepresentation{ID: &onlineID, Clients: &map[string]string{client1: "portal"}}
+	var offline1 = kc.UserSessionRepresentation{ID: &offlineID, Clients: &map[string]string{client1: "portal"}}
+	var offline2 = kc.UserSessionRepresentation{ID: &offlineID, Clients: &map[string]string{client2: "mobile"}}
+	var ctx = context.WithValue(context.Background(), cs.CtContextAccessToken, accessToken)
+	var expectedError = errors.New("kc error")
+
+	var expectSessions = func() {
+		mockKeycloakClient.EXPECT().GetSessionsOfUser(accessToken, realmName, userID).Return([]kc.UserSessionRepresentation{online}, nil)
+		mockKeycloakClient.EXPECT().GetClients(accessToken, realmName).Return(clients, nil)
+		mockKeycloakClient.EXPECT().GetOfflineSessionsOfUser(accessToken, realmName, userID, client1).Return([]kc.UserSessionRepresentation{offline1}, nil)
+		mockKeycloakClient.EXPECT().GetOfflineSessionsOfUser(accessToken, realmName, userID, client2).Return([]kc.UserSessionRepresentation{offline2}, nil)
+	}
+
+	t.Run("Can't get sessions", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().GetSessionsOfUser(accessToken, realmName, userID).Return(nil, expectedError)
+		var _, err = managementComponent.GetUserSessions(ctx, realmName, userID)
+		assert.Equal(t, expectedError, err)
+	})
+
+	t.Run("Can't get offline sessions", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().GetSessionsOfUser(accessToken, realmName, userID).Return([]kc.UserSessionRepresentation{online}, nil)
+		mockKeycloakClient.EXPECT().GetClients(accessToken, realmName).Return(clients, nil)
+		mockKeycloakClient.EXPECT().GetOfflineSessionsOfUser(accessToken, realmName, userID, client1).Return(nil, expectedError)
+		var _, err = managementComponent.GetUserSessions(ctx, realmName, userID)
+		assert.Equal(t, expectedError, err)
+	})
+
+	t.Run("Get sessions", func(t *testing.T) {
+		expectSessions()
+		var sessions, err = managementComponent.GetUserSessions(ctx, realmName, userID)
+		assert.Nil(t, err)
+		assert.Len(t, sessions, 2)
+		assert.Equal(t, onlineID, *sessions[0].ID)
+		assert.False(t, *sessions[0].Offline)
+		assert.Equal(t, offlineID, *sessions[1].ID)
+		assert.True(t, *sessions[1].Offline)
+		assert.Equal(t, []string{"mobile", "portal"}, *sessions[1].Clients)
+	})
+
+	t.Run("Revoke session of another user", func(t *testing.T) {
+		expectSessions()
+		var err = managementComponent.RevokeUserSession(ctx, realmName, userID, "unknown-session")
+		assert.Equal(t, http.StatusNotFound, err.(errorhandler.Error).Status)
+	})
+
+	t.Run("Revoke session fails", func(t *testing.T) {
+		expectSessions()
+		mockKeycloakClient.EXPECT().DeleteSession(accessToken, realmName, offlineID).Return(expectedError)
+		assert.Equal(t, expectedError, managementComponent.RevokeUserSession(ctx, realmName, userID, offlineID))
+	})
+
+	t.Run("Revoke session", func(t *testing.T) {
+		expectSessions()
+		mockKeycloakClient.EXPECT().DeleteSession(accessToken, realmName, onlineID).Return(nil)
+		mockEventDBModule.EXPECT().ReportEvent(ctx, "API_REVOKE_USER_SESSION", "back-office", database.CtEventRealmName, realmName, database.CtEventUserID, userID,
+			database.CtEventAdditionalInfo, gomock.Any()).Return(nil)
+		assert.Nil(t, managementComponent.RevokeUserSession(ctx, realmName, userID, onlineID))
+	})
+
+	t.Run("Logout fails", func(t *testing.T) {
+		expectSessions()
+		mockKeycloakClient.EXPECT().LogoutUser(accessToken, realmName, userID).Return(expectedError)
+		assert.Equal(t, expectedError, managementComponent.RevokeUserSessions(ctx, realmName, userID))
+	})
+
+	t.Run("Revoke all sessions", func(t *testing.T) {
+		expectSessions()
+		mockKeycloakClient.EXPECT().LogoutUser(accessToken, realmName, userID).Return(nil)
+		mockKeycloakClient.EXPECT().DeleteSession(accessToken, realmName, offlineID).Return(nil)
+		mockEventDBModule.EXPECT().ReportEvent(ctx, "API_REVOKE_USER_SESSIONS", "back-office", database.CtEventRealmName, realmName, database.CtEventUserID, userID,
+			database.CtEventAdditionalInfo, gomock.Any()).Return(nil)
+		assert.Nil(t, managementComponent.RevokeUserSessions(ctx, realmName, userID))
+	})
+}
+
 func TestGetAttackDetectionStatus(t *testing.T) {
 	var mockCtrl = gomock.NewController(t)
 	defer mockCtrl.Finish()
@@ -2940,6 +3196,7 @@ func TestCreateGroup(t *testing.T) {
 			Name: &name,
 		}
 
+		mockKeycloakClient.EXPECT().GetGroups(accessToken, targetRealmName).Return(nil, nil).Times(1)
 		mockKeycloakClient.EXPECT().CreateGroup(accessToken, targetRealmName, kcGroupRep).Return(locationURL, nil).Times(1)
 
 		var ctx = context.WithValue(context.Background(), cs.CtContextAccessToken, accessToken)
@@ -2964,6 +3221,7 @@ func TestCreateGroup(t *testing.T) {
 			Name: &name,
 		}
 
+		mockKeycloakClient.EXPECT().GetGroups(accessToken, targetRealmName).Return(nil, nil).Times(1)
 		mockKeycloakClient.EXPECT().CreateGroup(accessToken, targetRealmName, kcGroupRep).Return(locationURL, nil).Times(1)
 
 		var ctx = context.WithValue(context.Background(), cs.CtContextAccessToken, accessToken)
@@ -3003,6 +3261,200 @@ func TestCreateGroup(t *testing.T) {
 		assert.NotNil(t, err)
 		assert.Equal(t, "", location)
 	}
+
+	// Name used by a subgroup
+	{
+		var otherID = "b4ae2a8e-d30c-4ca6-a5d2-54ef11e3f1c4"
+		var parentName = "parent"
+		mockKeycloakClient.EXPECT().GetGroups(accessToken, targetRealmName).Return([]kc.GroupRepresentation{
+			{Name: &parentName, SubGroups: &[]kc.GroupRepresentation{{ID: &otherID, Name: &name}}},
+		}, nil).Times(1)
+
+		var ctx = context.WithValue(context.Background(), cs.CtContextAccessToken, accessToken)
+		mockLogger.EXPECT().Warn(ctx, "msg", gomock.Any(), "realm", targetRealmName, "groupName", name)
+
+		var _, err = managementComponent.CreateGroup(ctx, targetRealmName, api.GroupRepresentation{Name: &name})
+
+		assert.Equal(t, http.StatusConflict, err.(errorhandler.Error).Status)
+	}
+}
+
+func TestCreateSubGroup(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+	var mockKeycloakClient = mock.NewKeycloakClient(mockCtrl)
+	var mockUsersDetailsDBModule = mock.NewUsersDetailsDBModule(mockCtrl)
+	var mockEventDBModule = mock.NewEventDBModule(mockCtrl)
+	var mockConfigurationDBModule = mock.NewConfigurationDBModule(mockCtrl)
+
+	var managementComponent = NewComponent(mockKeycloakClient, mockUsersDetailsDBModule, mockEventDBModule, mockConfigurationDBModule, []string{}, log.NewNopLogger())
+
+	var accessToken = "TOKEN=="
+	var name = "team"
+	var targetRealmName = "DEP"
+	var parentID = "b4ae2a8e-d30c-4ca6-a5d2-54ef11e3f1c4"
+	var groupID = "41dbf4a8-32a9-4000-8c17-edc854c31231"
+	var locationURL = "http://toto.com/realms/DEP/groups/" + groupID
+	var ctx = context.WithValue(context.Background(), cs.CtContextAccessToken, accessToken)
+	var groupRep = api.GroupRepresentation{Name: &name}
+	var otherID = "7c0bd5a8-0a1d-4eee-9bb8-669c6f89c0ee"
+
+	t.Run("Missing name", func(t *testing.T) {
+		var _, err = managementComponent.CreateSubGroup(ctx, targetRealmName, parentID, api.GroupRepresentation{})
+		assert.NotNil(t, err)
+	})
+
+	t.Run("Can't get groups", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().GetGroups(accessToken, targetRealmName).Return(nil, errors.New("kc error"))
+		var _, err = managementComponent.CreateSubGroup(ctx, targetRealmName, parentID, groupRep)
+		assert.NotNil(t, err)
+	})
+
+	t.Run("Name already used in the realm", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().GetGroups(accessToken, targetRealmName).Return([]kc.GroupRepresentation{{ID: &otherID, Name: &name}}, nil)
+		var _, err = managementComponent.CreateSubGroup(ctx, targetRealmName, parentID, groupRep)
+		assert.Equal(t, http.StatusConflict, err.(errorhandler.Error).Status)
+	})
+
+	t.Run("Keycloak error", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().GetGroups(accessToken, targetRealmName).Return(nil, nil)
+		mockKeycloakClient.EXPECT().CreateGroupChild(accessToken, targetRealmName, parentID, kc.GroupRepresentation{Name: &name}).Return("", errors.New("kc error"))
+		var _, err = managementComponent.CreateSubGroup(ctx, targetRealmName, parentID, groupRep)
+		assert.NotNil(t, err)
+	})
+
+	t.Run("Success", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().GetGroups(accessToken, targetRealmName).Return(nil, nil)
+		mockKeycloakClient.EXPECT().CreateGroupChild(accessToken, targetRealmName, parentID, kc.GroupRepresentation{Name: &name}).Return(locationURL, nil)
+		mockEventDBModule.EXPECT().ReportEvent(ctx, "API_GROUP_CREATION", "back-office", database.CtEventRealmName, targetRealmName, database.CtEventGroupID, groupID,
+			database.CtEventGroupName, name, database.CtEventAdditionalInfo, gomock.Any()).Return(nil)
+		var location, err = managementComponent.CreateSubGroup(ctx, targetRealmName, parentID, groupRep)
+		assert.Nil(t, err)
+		assert.Equal(t, locationURL, location)
+	})
+}
+
+func TestUpdateGroup(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+	var mockKeycloakClient = mock.NewKeycloakClient(mockCtrl)
+	var mockUsersDetailsDBModule = mock.NewUsersDetailsDBModule(mockCtrl)
+	var mockEventDBModule = mock.NewEventDBModule(mockCtrl)
+	var mockConfigurationDBModule = mock.NewConfigurationDBModule(mockCtrl)
+
+	var managementComponent = NewComponent(mockKeycloakClient, mockUsersDetailsDBModule, mockEventDBModule, mockConfigurationDBModule, []string{}, log.NewNopLogger())
+
+	var accessToken = "TOKEN=="
+	var targetRealmName = "DEP"
+	var groupID = "41dbf4a8-32a9-4000-8c17-edc854c31231"
+	var oldName = "team"
+	var newName = "squad"
+	var path = "/org/team"
+	var subGroups = []kc.GroupRepresentation{{Name: &newName}}
+	var ctx = context.WithValue(context.Background(), cs.CtContextAccessToken, accessToken)
+	var expectedError = errors.New("error")
+
+	t.Run("Missing name", func(t *testing.T) {
+		var err = managementComponent.UpdateGroup(ctx, targetRealmName, groupID, api.GroupRepresentation{})
+		assert.NotNil(t, err)
+	})
+
+	t.Run("Can't get group", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().GetGroup(accessToken, targetRealmName, groupID).Return(kc.GroupRepresentation{}, expectedError)
+		var err = managementComponent.UpdateGroup(ctx, targetRealmName, groupID, api.GroupRepresentation{Name: &newName})
+		assert.Equal(t, expectedError, err)
+	})
+
+	t.Run("Name already used in the realm", func(t *testing.T) {
+		var otherID = "7c0bd5a8-0a1d-4eee-9bb8-669c6f89c0ee"
+		mockKeycloakClient.EXPECT().GetGroup(accessToken, targetRealmName, groupID).Return(kc.GroupRepresentation{ID: &groupID, Name: &oldName, Path: &path}, nil)
+		mockKeycloakClient.EXPECT().GetGroups(accessToken, targetRealmName).Return([]kc.GroupRepresentation{{ID: &groupID, Name: &oldName}, {ID: &otherID, Name: &newName}}, nil)
+		var err = managementComponent.UpdateGroup(ctx, targetRealmName, groupID, api.GroupRepresentation{Name: &newName})
+		assert.Equal(t, http.StatusConflict, err.(errorhandler.Error).Status)
+	})
+
+	t.Run("Can't update authorizations", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().GetGroup(accessToken, targetRealmName, groupID).Return(kc.GroupRepresentation{ID: &groupID, Name: &oldName, Path: &path}, nil)
+		mockKeycloakClient.EXPECT().GetGroups(accessToken, targetRealmName).Return([]kc.GroupRepresentation{{ID: &groupID, Name: &oldName}}, nil)
+		mockKeycloakClient.EXPECT().UpdateGroup(accessToken, targetRealmName, groupID, gomock.Any()).Return(nil)
+		mockConfigurationDBModule.EXPECT().UpdateGroupReferences(ctx, targetRealmName, oldName, newName, path, "/org/squad").Return(expectedError)
+		var err = managementComponent.UpdateGroup(ctx, targetRealmName, groupID, api.GroupRepresentation{Name: &newName})
+		assert.Equal(t, expectedError, err)
+	})
+
+	t.Run("Success", func(t *testing.T) {
+		var oldGroupName = oldName
+		mockKeycloakClient.EXPECT().GetGroup(accessToken, targetRealmName, groupID).Return(kc.GroupRepresentation{ID: &groupID, Name: &oldGroupName, Path: &path,
+			SubGroups: &subGroups}, nil)
+		// The group itself keeps its name in the realm
+		mockKeycloakClient.EXPECT().GetGroups(accessToken, targetRealmName).Return([]kc.GroupRepresentation{{ID: &groupID, Name: &newName}}, nil)
+		mockKeycloakClient.EXPECT().UpdateGroup(accessToken, targetRealmName, groupID, gomock.Any()).DoAndReturn(func(_, _, _ string, group kc.GroupRepresentation) error {
+			assert.Equal(t, newName, *group.Name)
+			assert.Nil(t, group.SubGroups)
+			return nil
+		})
+		mockConfigurationDBModule.EXPECT().UpdateGroupReferences(ctx, targetRealmName, oldName, newName, path, "/org/squad").Return(nil)
+		mockEventDBModule.EXPECT().ReportEvent(ctx, "API_GROUP_UPDATE", "back-office", database.CtEventRealmName, targetRealmName, database.CtEventGroupID, groupID,
+			database.CtEventGroupName, newName, database.CtEventAdditionalInfo, gomock.Any()).Return(nil)
+		var err = managementComponent.UpdateGroup(ctx, targetRealmName, groupID, api.GroupRepresentation{Name: &newName})
+		assert.Nil(t, err)
+	})
+}
+
+func TestMoveGroup(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+	var mockKeycloakClient = mock.NewKeycloakClient(mockCtrl)
+	var mockUsersDetailsDBModule = mock.NewUsersDetailsDBModule(mockCtrl)
+	var mockEventDBModule = mock.NewEventDBModule(mockCtrl)
+	var mockConfigurationDBModule = mock.NewConfigurationDBModule(mockCtrl)
+
+	var managementComponent = NewComponent(mockKeycloakClient, mockUsersDetailsDBModule, mockEventDBModule, mockConfigurationDBModule, []string{}, log.NewNopLogger())
+
+	var accessToken = "TOKEN=="
+	var targetRealmName = "DEP"
+	var groupID = "41dbf4a8-32a9-4000-8c17-edc854c31231"
+	var parentID = "b4ae2a8e-d30c-4ca6-a5d2-54ef11e3f1c4"
+	var name = "team"
+	var oldPath = "/org/team"
+	var newPath = "/support/team"
+	var topLevelPath = "/team"
+	var movedGroup = kc.GroupRepresentation{ID: &groupID, Name: &name}
+	var ctx = context.WithValue(context.Background(), cs.CtContextAccessToken, accessToken)
+	var expectedError = errors.New("error")
+
+	t.Run("Can't move group", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().GetGroup(accessToken, targetRealmName, groupID).Return(kc.GroupRepresentation{ID: &groupID, Name: &name, Path: &oldPath}, nil)
+		mockKeycloakClient.EXPECT().CreateGroupChild(accessToken, targetRealmName, parentID, movedGroup).Return("", expectedError)
+		var err = managementComponent.MoveGroup(ctx, targetRealmName, groupID, api.GroupParentRepresentation{ParentID: &parentID})
+		assert.Equal(t, expectedError, err)
+	})
+
+	t.Run("Move under another group", func(t *testing.T) {
+		gomock.InOrder(
+			mockKeycloakClient.EXPECT().GetGroup(accessToken, targetRealmName, groupID).Return(kc.GroupRepresentation{ID: &groupID, Name: &name, Path: &oldPath}, nil),
+			mockKeycloakClient.EXPECT().CreateGroupChild(accessToken, targetRealmName, parentID, movedGroup).Return("", nil),
+			mockKeycloakClient.EXPECT().GetGroup(accessToken, targetRealmName, groupID).Return(kc.GroupRepresentation{ID: &groupID, Name: &name, Path: &newPath}, nil),
+		)
+		mockConfigurationDBModule.EXPECT().UpdateGroupReferences(ctx, targetRealmName, name, name, oldPath, newPath).Return(nil)
+		mockEventDBModule.EXPECT().ReportEvent(ctx, "API_GROUP_MOVE", "back-office", database.CtEventRealmName, targetRealmName, database.CtEventGroupID, groupID,
+			database.CtEventGroupName, name, database.CtEventAdditionalInfo, gomock.Any()).Return(nil)
+		var err = managementComponent.MoveGroup(ctx, targetRealmName, groupID, api.GroupParentRepresentation{ParentID: &parentID})
+		assert.Nil(t, err)
+	})
+
+	t.Run("Move to top-level", func(t *testing.T) {
+		gomock.InOrder(
+			mockKeycloakClient.EXPECT().GetGroup(accessToken, targetRealmName, groupID).Return(kc.GroupRepresentation{ID: &groupID, Name: &name, Path: &newPath}, nil),
+			mockKeycloakClient.EXPECT().CreateGroup(accessToken, targetRealmName, movedGroup).Return("", nil),
+			mockKeycloakClient.EXPECT().GetGroup(accessToken, targetRealmName, groupID).Return(kc.GroupRepresentation{ID: &groupID, Name: &name, Path: &topLevelPath}, nil),
+		)
+		mockConfigurationDBModule.EXPECT().UpdateGroupReferences(ctx, targetRealmName, name, name, newPath, topLevelPath).Return(nil)
+		mockEventDBModule.EXPECT().ReportEvent(ctx, "API_GROUP_MOVE", "back-office", database.CtEventRealmName, targetRealmName, database.CtEventGroupID, groupID,
+			database.CtEventGroupName, name, database.CtEventAdditionalInfo, gomock.Any()).Return(nil)
+		var err = managementComponent.MoveGroup(ctx, targetRealmName, groupID, api.GroupParentRepresentation{})
+		assert.Nil(t, err)
+	})
 }
 
 func TestDeleteGroup(t *testing.T) {
@@ -3046,6 +3498,34 @@ func TestDeleteGroup(t *testing.T) {
 		assert.Nil(t, err)
 	}
 
+	// Delete group with subgroups
+	{
+		var path = "/" + groupName
+		var subGroupName = "subGroupName"
+		var subGroupPath = path + "/" + subGroupName
+		var groupWithSubGroups = kc.GroupRepresentation{
+			ID:        &groupID,
+			Name:      &groupName,
+			Path:      &path,
+			SubGroups: &[]kc.GroupRepresentation{{Name: &subGroupName, Path: &subGroupPath}},
+		}
+		mockKeycloakClient.EXPECT().GetGroup(accessToken, targetRealmName, groupID).Return(groupWithSubGroups, nil).Times(1)
+		mockKeycloakClient.EXPECT().DeleteGroup(accessToken, targetRealmName, groupID).Return(nil).Times(1)
+
+		var ctx = context.WithValue(context.Background(), cs.CtContextAccessToken, accessToken)
+		ctx = context.WithValue(ctx, cs.CtContextRealm, realmName)
+		ctx = context.WithValue(ctx, cs.CtContextUsername, username)
+
+		for _, targetGroupName := range []string{groupName, "/groupName/*", subGroupName, "/groupName/subGroupName/*"} {
+			mockConfigurationDBModule.EXPECT().DeleteAllAuthorizationsWithGroup(ctx, targetRealmName, targetGroupName).Return(nil).Times(1)
+		}
+		mockEventDBModule.EXPECT().ReportEvent(ctx, "API_GROUP_DELETION", "back-office", gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).Times(1)
+
+		err := managementComponent.DeleteGroup(ctx, targetRealmName, groupID)
+
+		assert.Nil(t, err)
+	}
+
 	// Delete group with success but having an error when storing the event in the DB
 	{
 		mockKeycloakClient.EXPECT().DeleteGroup(accessToken, targetRealmName, groupID).Return(nil).Times(1)
@@ -3726,6 +4206,192 @@ func TestGetClientRoles(t *testing.T) {
 	}
 }
 
+func TestRealmRoles(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+	var mockKeycloakClient = mock.NewKeycloakClient(mockCtrl)
+	var mockUsersDetailsDBModule = mock.NewUsersDetailsDBModule(mockCtrl)
+	var mockEventDBModule = mock.NewEventDBModule(mockCtrl)
+	var mockConfigurationDBModule = mock.NewConfigurationDBModule(mockCtrl)
+
+	var managementComponent = NewComponent(mockKeycloakClient, mockUsersDetailsDBModule, mockEventDBModule, mockConfigurationDBModule, []string{}, log.NewNopLogger())
+
+	var accessToken = "TOKEN=="
+	var realmName = "DEP"
+	var userID = "41dbf4a8-32a9-4000-8c17-edc854c31231"
+	var roleID = "b4ae2a8e-d30c-4ca6-a5d2-54ef11e3f1c4"
+	var roleName = "auditor"
+	var description = "Reads the audit logs"
+	var falseBool = false
+	var trueBool = true
+	var realmRole = kc.RoleRepresentation{ID: &roleID, Name: &roleName, ClientRole: &falseBool}
+	var clientRole = kc.RoleRepresentation{ID: &roleID, Name: &roleName, ClientRole: &trueBool}
+	var ctx = context.WithValue(context.Background(), cs.CtContextAccessToken, accessToken)
+	var expectedError = errors.New("kc error")
+
+	t.Run("Create role without name", func(t *testing.T) {
+		var _, err = managementComponent.CreateRole(ctx, realmName, api.RoleRepresentation{Description: &description})
+		assert.NotNil(t, err)
+	})
+
+	t.Run("Create role fails", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().CreateRole(accessToken, realmName, gomock.Any()).Return("", expectedError)
+		var _, err = managementComponent.CreateRole(ctx, realmName, api.RoleRepresentation{Name: &roleName})
+		assert.Equal(t, expectedError, err)
+	})
+
+	t.Run("Create role", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().CreateRole(accessToken, realmName, kc.RoleRepresentation{Name: &roleName, Description: &description,
+			ClientRole: &falseBool}).Return("location", nil)
+		mockEventDBModule.EXPECT().ReportEvent(ctx, "API_ROLE_CREATION", "back-office", database.CtEventRealmName, realmName,
+			database.CtEventAdditionalInfo, gomock.Any()).Return(nil)
+		var location, err = managementComponent.CreateRole(ctx, realmName, api.RoleRepresentation{Name: &roleName, Description: &description})
+		assert.Nil(t, err)
+		assert.Equal(t, "location", location)
+	})
+
+	t.Run("Update client role", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().GetRole(accessToken, realmName, roleID).Return(clientRole, nil)
+		var err = managementComponent.UpdateRole(ctx, realmName, roleID, api.RoleRepresentation{Description: &description})
+		assert.Equal(t, http.StatusBadRequest, err.(errorhandler.Error).Status)
+	})
+
+	t.Run("Update built-in role", func(t *testing.T) {
+		var builtInName = "default-roles-dep"
+		mockKeycloakClient.EXPECT().GetRole(accessToken, realmName, roleID).Return(kc.RoleRepresentation{ID: &roleID, Name: &builtInName,
+			ClientRole: &falseBool}, nil)
+		var err = managementComponent.UpdateRole(ctx, realmName, roleID, api.RoleRepresentation{Description: &description})
+		assert.Equal(t, http.StatusBadRequest, err.(errorhandler.Error).Status)
+	})
+
+	t.Run("Update role", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().GetRole(accessToken, realmName, roleID).Return(realmRole, nil)
+		mockKeycloakClient.EXPECT().UpdateRole(accessToken, realmName, roleID, kc.RoleRepresentation{ID: &roleID, Name: &roleName, Description: &description,
+			ClientRole: &falseBool}).Return(nil)
+		mockEventDBModule.EXPECT().ReportEvent(ctx, "API_ROLE_UPDATE", "back-office", database.CtEventRealmName, realmName,
+			database.CtEventAdditionalInfo, gomock.Any()).Return(nil)
+		assert.Nil(t, managementComponent.UpdateRole(ctx, realmName, roleID, api.RoleRepresentation{Description: &description}))
+	})
+
+	t.Run("Delete built-in role", func(t *testing.T) {
+		var builtInName = "offline_access"
+		mockKeycloakClient.EXPECT().GetRole(accessToken, realmName, roleID).Return(kc.RoleRepresentation{ID: &roleID, Name: &builtInName,
+			ClientRole: &falseBool}, nil)
+		var err = managementComponent.DeleteRole(ctx, realmName, roleID)
+		assert.Equal(t, http.StatusBadRequest, err.(errorhandler.Error).Status)
+	})
+
+	t.Run("Delete role fails", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().GetRole(accessToken, realmName, roleID).Return(realmRole, nil)
+		mockKeycloakClient.EXPECT().DeleteRole(accessToken, realmName, roleID).Return(expectedError)
+		assert.Equal(t, expectedError, managementComponent.DeleteRole(ctx, realmName, roleID))
+	})
+
+	t.Run("Delete role", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().GetRole(accessToken, realmName, roleID).Return(realmRole, nil)
+		mockKeycloakClient.EXPECT().DeleteRole(accessToken, realmName, roleID).Return(nil)
+		mockEventDBModule.EXPECT().ReportEvent(ctx, "API_ROLE_DELETION", "back-office", database.CtEventRealmName, realmName,
+			database.CtEventAdditionalInfo, gomock.Any()).Return(nil)
+		assert.Nil(t, managementComponent.DeleteRole(ctx, realmName, roleID))
+	})
+
+	t.Run("Add unknown role to user", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().GetRole(accessToken, realmName, roleID).Return(kc.RoleRepresentation{}, expectedError)
+		assert.Equal(t, expectedError, managementComponent.AddRoleToUser(ctx, realmName, userID, roleID))
+	})
+
+	t.Run("Add client role as realm role", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().GetRole(accessToken, realmName, roleID).Return(clientRole, nil)
+		var err = managementComponent.AddRoleToUser(ctx, realmName, userID, roleID)
+		assert.Equal(t, http.StatusBadRequest, err.(errorhandler.Error).Status)
+	})
+
+	t.Run("Add composite role to user", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().GetRole(accessToken, realmName, roleID).Return(kc.RoleRepresentation{ID: &roleID, Name: &roleName,
+			ClientRole: &falseBool, Composite: &trueBool}, nil)
+		var err = managementComponent.AddRoleToUser(ctx, realmName, userID, roleID)
+		assert.Equal(t, http.StatusBadRequest, err.(errorhandler.Error).Status)
+	})
+
+	t.Run("Add admin role to user", func(t *testing.T) {
+		var adminName = "admin"
+		mockKeycloakClient.EXPECT().GetRole(accessToken, realmName, roleID).Return(kc.RoleRepresentation{ID: &roleID, Name: &adminName,
+			ClientRole: &falseBool, Composite: &falseBool}, nil)
+		var err = managementComponent.AddRoleToUser(ctx, realmName, userID, roleID)
+		assert.Equal(t, http.StatusBadRequest, err.(errorhandler.Error).Status)
+	})
+
+	t.Run("Add role to user", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().GetRole(accessToken, realmName, roleID).Return(realmRole, nil)
+		mockKeycloakClient.EXPECT().AddRealmLevelRoleMappings(accessToken, realmName, userID, []kc.RoleRepresentation{realmRole}).Return(nil)
+		mockEventDBModule.EXPECT().ReportEvent(ctx, "API_ROLE_ASSIGNMENT", "back-office", database.CtEventRealmName, realmName, database.CtEventUserID, userID,
+			database.CtEventAdditionalInfo, gomock.Any()).Return(nil)
+		assert.Nil(t, managementComponent.AddRoleToUser(ctx, realmName, userID, roleID))
+	})
+
+	t.Run("Delete role for user fails", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().GetRole(accessToken, realmName, roleID).Return(realmRole, nil)
+		mockKeycloakClient.EXPECT().DeleteRealmLevelRoleMappings(accessToken, realmName, userID, []kc.RoleRepresentation{realmRole}).Return(expectedError)
+		assert.Equal(t, expectedError, managementComponent.DeleteRoleForUser(ctx, realmName, userID, roleID))
+	})
+
+	t.Run("Delete role for user", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().GetRole(accessToken, realmName, roleID).Return(realmRole, nil)
+		mockKeycloakClient.EXPECT().DeleteRealmLevelRoleMappings(accessToken, realmName, userID, []kc.RoleRepresentation{realmRole}).Return(nil)
+		mockEventDBModule.EXPECT().ReportEvent(ctx, "API_ROLE_UNASSIGNMENT", "back-office", database.CtEventRealmName, realmName, database.CtEventUserID, userID,
+			database.CtEventAdditionalInfo, gomock.Any()).Return(nil)
+		assert.Nil(t, managementComponent.DeleteRoleForUser(ctx, realmName, userID, roleID))
+	})
+}
+
+func TestDeleteClientRoleForUser(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+	var mockKeycloakClient = mock.NewKeycloakClient(mockCtrl)
+	var mockUsersDetailsDBModule = mock.NewUsersDetailsDBModule(mockCtrl)
+	var mockEventDBModule = mock.NewEventDBModule(mockCtrl)
+	var mockConfigurationDBModule = mock.NewConfigurationDBModule(mockCtrl)
+
+	var managementComponent = NewComponent(mockKeycloakClient, mockUsersDetailsDBModule, mockEventDBModule, mockConfigurationDBModule, []string{}, log.NewNopLogger())
+
+	var accessToken = "TOKEN=="
+	var realmName = "DEP"
+	var userID = "41dbf4a8-32a9-4000-8c17-edc854c31231"
+	var clientID = "60be66a5-e007-464c-9b74-0e0e6a5b2c6c"
+	var roleID = "b4ae2a8e-d30c-4ca6-a5d2-54ef11e3f1c4"
+	var otherRoleID = "c3a3c3a2-d30c-4ca6-a5d2-54ef11e3f1c4"
+	var roleName = "viewer"
+	var role = kc.RoleRepresentation{ID: &roleID, Name: &roleName}
+	var otherRole = kc.RoleRepresentation{ID: &otherRoleID, Name: &roleName}
+	var ctx = context.WithValue(context.Background(), cs.CtContextAccessToken, accessToken)
+	var expectedError = errors.New("kc error")
+
+	t.Run("Can't get role mappings", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().GetClientRoleMappings(accessToken, realmName, userID, clientID).Return(nil, expectedError)
+		assert.Equal(t, expectedError, managementComponent.DeleteClientRoleForUser(ctx, realmName, userID, clientID, roleID))
+	})
+
+	t.Run("Role not mapped to user", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().GetClientRoleMappings(accessToken, realmName, userID, clientID).Return([]kc.RoleRepresentation{otherRole}, nil)
+		var err = managementComponent.DeleteClientRoleForUser(ctx, realmName, userID, clientID, roleID)
+		assert.Equal(t, http.StatusNotFound, err.(errorhandler.Error).Status)
+	})
+
+	t.Run("Keycloak error", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().GetClientRoleMappings(accessToken, realmName, userID, clientID).Return([]kc.RoleRepresentation{otherRole, role}, nil)
+		mockKeycloakClient.EXPECT().DeleteClientRolesFromUserRoleMapping(accessToken, realmName, userID, clientID, []kc.RoleRepresentation{role}).Return(expectedError)
+		assert.Equal(t, expectedError, managementComponent.DeleteClientRoleForUser(ctx, realmName, userID, clientID, roleID))
+	})
+
+	t.Run("Success", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().GetClientRoleMappings(accessToken, realmName, userID, clientID).Return([]kc.RoleRepresentation{otherRole, role}, nil)
+		mockKeycloakClient.EXPECT().DeleteClientRolesFromUserRoleMapping(accessToken, realmName, userID, clientID, []kc.RoleRepresentation{role}).Return(nil)
+		mockEventDBModule.EXPECT().ReportEvent(ctx, "API_CLIENT_ROLE_UNASSIGNMENT", "back-office", database.CtEventRealmName, realmName, database.CtEventUserID,
+			userID, database.CtEventAdditionalInfo, gomock.Any()).Return(nil)
+		assert.Nil(t, managementComponent.DeleteClientRoleForUser(ctx, realmName, userID, clientID, roleID))
+	})
+}
+
 func TestCreateClientRole(t *testing.T) {
 	var mockCtrl = gomock.NewController(t)
 	defer mockCtrl.Finish()
@@ -3983,7 +4649,57 @@ func TestUpdateRealmCustomConfiguration(t *testing.T) {
 	{
 		mockKeycloakClient.EXPECT().GetRealm(accessToken, realmID).Return(kcRealmRep, nil).Times(1)
 		mockKeycloakClient.EXPECT().GetClients(accessToken, realmID).Return(clients, nil).Times(1)
+		mockConfigurationDBModule.EXPECT().GetConfigurationRevisions(ctx, realmID, gomock.Any()).Return([]dto.DBConfigurationRevision{{ID: 1}}, nil)
 		mockConfigurationDBModule.EXPECT().StoreOrUpdateConfiguration(ctx, realmID, gomock.Any()).Return(nil).Times(1)
+		mockConfigurationDBModule.EXPECT().CreateConfigurationRevision(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, revision dto.DBConfigurationRevision) (int64, error) {
+			assert.Equal(t, dto.ConfigurationTypeCustom, revision.ConfigType)
+			assert.Contains(t, revision.Content, clientID)
+			return 2, nil
+		})
+		err := managementComponent.UpdateRealmCustomConfiguration(ctx, realmID, configInit)
+
+		assert.Nil(t, err)
+	}
+
+	// Can't get the revisions of the configuration
+	{
+		mockKeycloakClient.EXPECT().GetRealm(accessToken, realmID).Return(kcRealmRep, nil).Times(1)
+		mockKeycloakClient.EXPECT().GetClients(accessToken, realmID).Return(clients, nil).Times(1)
+		mockConfigurationDBModule.EXPECT().GetConfigurationRevisions(ctx, realmID, gomock.Any()).Return(nil, errors.New("db error"))
+		err := managementComponent.UpdateRealmCustomConfiguration(ctx, realmID, configInit)
+
+		assert.NotNil(t, err)
+	}
+
+	// First update of a realm with a stored configuration: the current configuration is stored as baseline revision
+	{
+		var previousClientID = "clientID2"
+		mockKeycloakClient.EXPECT().GetRealm(accessToken, realmID).Return(kcRealmRep, nil).Times(1)
+		mockKeycloakClient.EXPECT().GetClients(accessToken, realmID).Return(clients, nil).Times(1)
+		mockConfigurationDBModule.EXPECT().GetConfigurationRevisions(ctx, realmID, gomock.Any()).Return([]dto.DBConfigurationRevision{}, nil)
+		mockConfigurationDBModule.EXPECT().GetConfiguration(ctx, realmID).Return(configuration.RealmConfiguration{DefaultClientID: &previousClientID}, nil)
+		gomock.InOrder(
+			mockConfigurationDBModule.EXPECT().CreateConfigurationRevision(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, revision dto.DBConfigurationRevision) (int64, error) {
+				assert.Contains(t, revision.Content, previousClientID)
+				assert.Equal(t, "", revision.AuthorID)
+				return 1, nil
+			}),
+			mockConfigurationDBModule.EXPECT().StoreOrUpdateConfiguration(ctx, realmID, gomock.Any()).Return(nil),
+			mockConfigurationDBModule.EXPECT().CreateConfigurationRevision(ctx, gomock.Any()).Return(int64(2), nil),
+		)
+		err := managementComponent.UpdateRealmCustomConfiguration(ctx, realmID, configInit)
+
+		assert.Nil(t, err)
+	}
+
+	// First update of a realm without stored configuration: there is no baseline revision
+	{
+		mockKeycloakClient.EXPECT().GetRealm(accessToken, realmID).Return(kcRealmRep, nil).Times(1)
+		mockKeycloakClient.EXPECT().GetClients(accessToken, realmID).Return(clients, nil).Times(1)
+		mockConfigurationDBModule.EXPECT().GetConfigurationRevisions(ctx, realmID, gomock.Any()).Return([]dto.DBConfigurationRevision{}, nil)
+		mockConfigurationDBModule.EXPECT().GetConfiguration(ctx, realmID).Return(configuration.RealmConfiguration{}, errorhandler.CreateNotFoundError("realmConfiguration"))
+		mockConfigurationDBModule.EXPECT().StoreOrUpdateConfiguration(ctx, realmID, gomock.Any()).Return(nil)
+		mockConfigurationDBModule.EXPECT().CreateConfigurationRevision(ctx, gomock.Any()).Return(int64(1), nil)
 		err := managementComponent.UpdateRealmCustomConfiguration(ctx, realmID, configInit)
 
 		assert.Nil(t, err)
@@ -4080,7 +4796,10 @@ func TestGetRealmAdminConfiguration(t *testing.T) {
 	var accessToken = "acce-ssto-ken"
 	var expectedError = errors.New("expectedError")
 	var dbAdminConfig configuration.RealmAdminConfiguration
+	var policy = dto.ClientPolicy{AllowedRedirectURIPrefixes: []string{"https://app.example.com/"}}
+	var apiPolicy = api.ConvertToAPIClientPolicy(policy)
 	var apiAdminConfig = api.ConvertRealmAdminConfigurationFromDBStruct(dbAdminConfig)
+	apiAdminConfig.ClientPolicy = &apiPolicy
 	var ctx = context.WithValue(context.TODO(), cs.CtContextAccessToken, accessToken)
 
 	var component = NewComponent(mockKeycloakClient, mockUsersDetailsDBModule, mockEventDBModule, mockConfigurationDBModule, allowedTrustIDGroups, logger)
@@ -4099,6 +4818,24 @@ func TestGetRealmAdminConfiguration(t *testing.T) {
 	t.Run("Success", func(t *testing.T) {
 		mockKeycloakClient.EXPECT().GetRealm(accessToken, realmName).Return(kc.RealmRepresentation{ID: &realmID}, nil)
 		mockConfigurationDBModule.EXPECT().GetAdminConfiguration(ctx, realmID).Return(dbAdminConfig, nil)
+		mockConfigurationDBModule.EXPECT().GetClientPolicy(ctx, realmID).Return(dto.ClientPolicy{}, expectedError)
+		var _, err = component.GetRealmAdminConfiguration(ctx, realmName)
+		assert.Equal(t, expectedError, err)
+	})
+	t.Run("No admin configuration", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().GetRealm(accessToken, realmName).Return(kc.RealmRepresentation{ID: &realmID}, nil)
+		mockConfigurationDBModule.EXPECT().GetAdminConfiguration(ctx, realmID).Return(dbAdminConfig, sql.ErrNoRows)
+		mockConfigurationDBModule.EXPECT().GetClientPolicy(ctx, realmID).Return(dto.ClientPolicy{}, nil)
+		var res, err = component.GetRealmAdminConfiguration(ctx, realmName)
+		assert.Nil(t, err)
+		assert.Equal(t, api.CreateDefaultRealmAdminConfiguration().Mode, res.Mode)
+		assert.NotNil(t, res.ClientPolicy)
+		assert.False(t, *res.ClientPolicy.PublicClientsAllowed)
+	})
+	t.Run("Success", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().GetRealm(accessToken, realmName).Return(kc.RealmRepresentation{ID: &realmID}, nil)
+		mockConfigurationDBModule.EXPECT().GetAdminConfiguration(ctx, realmID).Return(dbAdminConfig, nil)
+		mockConfigurationDBModule.EXPECT().GetClientPolicy(ctx, realmID).Return(policy, nil)
 		var res, err = component.GetRealmAdminConfiguration(ctx, realmName)
 		assert.Nil(t, err)
 		assert.Equal(t, apiAdminConfig, res)
@@ -4122,6 +4859,8 @@ func TestUpdateRealmAdminConfiguration(t *testing.T) {
 	var expectedError = errors.New("expectedError")
 	var ctx = context.WithValue(context.TODO(), cs.CtContextAccessToken, accessToken)
 	var adminConfig api.RealmAdminConfiguration
+	var currentPolicy = dto.ClientPolicy{AllowedRedirectURIPrefixes: []string{"https://app.example.com/"}}
+	var revisions = []dto.DBConfigurationRevision{{ID: 1}}
 
 	var component = NewComponent(mockKeycloakClient, mockUsersDetailsDBModule, mockEventDBModule, mockConfigurationDBModule, allowedTrustIDGroups, logger)
 
@@ -4130,15 +4869,88 @@ func TestUpdateRealmAdminConfiguration(t *testing.T) {
 		var err = component.UpdateRealmAdminConfiguration(ctx, realmName, adminConfig)
 		assert.Equal(t, expectedError, err)
 	})
+	t.Run("Can't get the current client policy", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().GetRealm(accessToken, realmName).Return(kc.RealmRepresentation{ID: &realmID}, nil)
+		mockConfigurationDBModule.EXPECT().GetClientPolicy(ctx, realmID).Return(dto.ClientPolicy{}, expectedError)
+		var err = component.UpdateRealmAdminConfiguration(ctx, realmName, adminConfig)
+		assert.Equal(t, expectedError, err)
+	})
+	t.Run("Can't get the revisions", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().GetRealm(accessToken, realmName).Return(kc.RealmRepresentation{ID: &realmID}, nil)
+		mockConfigurationDBModule.EXPECT().GetClientPolicy(ctx, realmID).Return(currentPolicy, nil)
+		mockConfigurationDBModule.EXPECT().GetConfigurationRevisions(ctx, realmID, gomock.Any()).Return(nil, expectedError)
+		var err = component.UpdateRealmAdminConfiguration(ctx, realmName, adminConfig)
+		assert.Equal(t, expectedError, err)
+	})
 	t.Run("Request to database fails", func(t *testing.T) {
 		mockKeycloakClient.EXPECT().GetRealm(accessToken, realmName).Return(kc.RealmRepresentation{ID: &realmID}, nil)
-		mockConfigurationDBModule.EXPECT().StoreOrUpdateAdminConfiguration(ctx, realmID, gomock.Any()).Return(expectedError)
+		mockConfigurationDBModule.EXPECT().GetClientPolicy(ctx, realmID).Return(currentPolicy, nil)
+		mockConfigurationDBModule.EXPECT().GetConfigurationRevisions(ctx, realmID, gomock.Any()).Return(revisions, nil)
+		mockConfigurationDBModule.EXPECT().StoreOrUpdateAdminConfiguration(ctx, realmID, gomock.Any(), currentPolicy).Return(expectedError)
 		var err = component.UpdateRealmAdminConfiguration(ctx, realmName, adminConfig)
 		assert.Equal(t, expectedError, err)
 	})
-	t.Run("Success", func(t *testing.T) {
+	t.Run("Can't store revision", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().GetRealm(accessToken, realmName).Return(kc.RealmRepresentation{ID: &realmID}, nil)
+		mockConfigurationDBModule.EXPECT().GetClientPolicy(ctx, realmID).Return(currentPolicy, nil)
+		mockConfigurationDBModule.EXPECT().GetConfigurationRevisions(ctx, realmID, gomock.Any()).Return(revisions, nil)
+		mockConfigurationDBModule.EXPECT().StoreOrUpdateAdminConfiguration(ctx, realmID, gomock.Any(), currentPolicy).Return(nil)
+		mockConfigurationDBModule.EXPECT().CreateConfigurationRevision(ctx, gomock.Any()).Return(int64(0), expectedError)
+		var err = component.UpdateRealmAdminConfiguration(ctx, realmName, adminConfig)
+		assert.Equal(t, expectedError, err)
+	})
+	t.Run("Success, current client policy is kept", func(t *testing.T) {
 		mockKeycloakClient.EXPECT().GetRealm(accessToken, realmName).Return(kc.RealmRepresentation{ID: &realmID}, nil)
-		mockConfigurationDBModule.EXPECT().StoreOrUpdateAdminConfiguration(ctx, realmID, gomock.Any()).Return(nil)
+		mockConfigurationDBModule.EXPECT().GetClientPolicy(ctx, realmID).Return(currentPolicy, nil)
+		mockConfigurationDBModule.EXPECT().GetConfigurationRevisions(ctx, realmID, gomock.Any()).Return(revisions, nil)
+		mockConfigurationDBModule.EXPECT().StoreOrUpdateAdminConfiguration(ctx, realmID, gomock.Any(), currentPolicy).Return(nil)
+		mockConfigurationDBModule.EXPECT().CreateConfigurationRevision(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, revision dto.DBConfigurationRevision) (int64, error) {
+			var _, revisionPolicy, err = dto.UnmarshalAdminConfiguration([]byte(revision.Content))
+			assert.Nil(t, err)
+			assert.Equal(t, currentPolicy, revisionPolicy)
+			return 1, nil
+		})
+		var err = component.UpdateRealmAdminConfiguration(ctx, realmName, adminConfig)
+		assert.Nil(t, err)
+	})
+	t.Run("Success, client policy is updated", func(t *testing.T) {
+		var trueBool = true
+		var adminConfig = api.RealmAdminConfiguration{ClientPolicy: &api.ClientPolicyRepresentation{PublicClientsAllowed: &trueBool}}
+		mockKeycloakClient.EXPECT().GetRealm(accessToken, realmName).Return(kc.RealmRepresentation{ID: &realmID}, nil)
+		mockConfigurationDBModule.EXPECT().GetConfigurationRevisions(ctx, realmID, gomock.Any()).Return(revisions, nil)
+		mockConfigurationDBModule.EXPECT().StoreOrUpdateAdminConfiguration(ctx, realmID, gomock.Any(), dto.ClientPolicy{PublicClientsAllowed: true}).Return(nil)
+		mockConfigurationDBModule.EXPECT().CreateConfigurationRevision(ctx, gomock.Any()).Return(int64(1), nil)
+		var err = component.UpdateRealmAdminConfiguration(ctx, realmName, adminConfig)
+		assert.Nil(t, err)
+	})
+	t.Run("First update, the current configuration is stored as baseline revision", func(t *testing.T) {
+		var mode = "trustID"
+		mockKeycloakClient.EXPECT().GetRealm(accessToken, realmName).Return(kc.RealmRepresentation{ID: &realmID}, nil)
+		mockConfigurationDBModule.EXPECT().GetClientPolicy(ctx, realmID).Return(currentPolicy, nil).Times(2)
+		mockConfigurationDBModule.EXPECT().GetConfigurationRevisions(ctx, realmID, gomock.Any()).Return([]dto.DBConfigurationRevision{}, nil)
+		mockConfigurationDBModule.EXPECT().GetAdminConfiguration(ctx, realmID).Return(configuration.RealmAdminConfiguration{Mode: &mode}, nil)
+		gomock.InOrder(
+			mockConfigurationDBModule.EXPECT().CreateConfigurationRevision(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, revision dto.DBConfigurationRevision) (int64, error) {
+				var config, revisionPolicy, err = dto.UnmarshalAdminConfiguration([]byte(revision.Content))
+				assert.Nil(t, err)
+				assert.Equal(t, mode, *config.Mode)
+				assert.Equal(t, currentPolicy, revisionPolicy)
+				assert.Equal(t, dto.ConfigurationTypeAdmin, revision.ConfigType)
+				return 1, nil
+			}),
+			mockConfigurationDBModule.EXPECT().StoreOrUpdateAdminConfiguration(ctx, realmID, gomock.Any(), currentPolicy).Return(nil),
+			mockConfigurationDBModule.EXPECT().CreateConfigurationRevision(ctx, gomock.Any()).Return(int64(2), nil),
+		)
+		var err = component.UpdateRealmAdminConfiguration(ctx, realmName, adminConfig)
+		assert.Nil(t, err)
+	})
+	t.Run("First update without stored configuration", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().GetRealm(accessToken, realmName).Return(kc.RealmRepresentation{ID: &realmID}, nil)
+		mockConfigurationDBModule.EXPECT().GetClientPolicy(ctx, realmID).Return(currentPolicy, nil)
+		mockConfigurationDBModule.EXPECT().GetConfigurationRevisions(ctx, realmID, gomock.Any()).Return([]dto.DBConfigurationRevision{}, nil)
+		mockConfigurationDBModule.EXPECT().GetAdminConfiguration(ctx, realmID).Return(configuration.RealmAdminConfiguration{}, sql.ErrNoRows)
+		mockConfigurationDBModule.EXPECT().StoreOrUpdateAdminConfiguration(ctx, realmID, gomock.Any(), currentPolicy).Return(nil)
+		mockConfigurationDBModule.EXPECT().CreateConfigurationRevision(ctx, gomock.Any()).Return(int64(1), nil)
 		var err = component.UpdateRealmAdminConfiguration(ctx, realmName, adminConfig)
 		assert.Nil(t, err)
 	})
@@ -4302,3 +5114,396 @@ func TestLinkShadowUser(t *testing.T) {
 		assert.NotNil(t, err)
 	})
 }
+
+func TestFederatedIdentities(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var mockKeycloakClient = mock.NewKeycloakClient(mockCtrl)
+	var mockUsersDetailsDBModule = mock.NewUsersDetailsDBModule(mockCtrl)
+	var mockEventDBModule = mock.NewEventDBModule(mockCtrl)
+	var mockConfigurationDBModule = mock.NewConfigurationDBModule(mockCtrl)
+
+	var component = NewComponent(mockKeycloakClient, mockUsersDetailsDBModule, mockEventDBModule, mockConfigurationDBModule, []string{}, log.NewNopLogger())
+
+	var accessToken = "TOKEN=="
+	var realmName = "master"
+	var userID = "41dbf4a8-32a9-4000-8c17-edc854c31231"
+	var provider = "partner-idp"
+	var federatedUsername = "jdoe@partner.com"
+	var expectedError = errors.New("kc error")
+	var ctx = context.WithValue(context.Background(), cs.CtContextAccessToken, accessToken)
+
+	t.Run("Get federated identities", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().GetFederatedIdentities(accessToken, realmName, userID).Return(nil, expectedError)
+		var _, err = component.GetFederatedIdentities(ctx, realmName, userID)
+		assert.Equal(t, expectedError, err)
+
+		mockKeycloakClient.EXPECT().GetFederatedIdentities(accessToken, realmName, userID).Return([]kc.FederatedIdentityRepresentation{
+			{IdentityProvider: &provider, UserName: &federatedUsername},
+		}, nil)
+		res, err := component.GetFederatedIdentities(ctx, realmName, userID)
+		assert.Nil(t, err)
+		assert.Len(t, res, 1)
+		assert.Equal(t, provider, *res[0].IdentityProvider)
+		assert.Equal(t, federatedUsername, *res[0].Username)
+	})
+
+	t.Run("Delete federated identity", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().DeleteFederatedIdentity(accessToken, realmName, userID, provider).Return(expectedError)
+		assert.Equal(t, expectedError, component.DeleteFederatedIdentity(ctx, realmName, userID, provider))
+
+		mockKeycloakClient.EXPECT().DeleteFederatedIdentity(accessToken, realmName, userID, provider).Return(nil)
+		mockEventDBModule.EXPECT().ReportEvent(ctx, "API_FEDERATED_IDENTITY_REMOVAL", "back-office", database.CtEventRealmName, realmName, database.CtEventUserID, userID,
+			database.CtEventAdditionalInfo, gomock.Any()).Return(nil)
+		assert.Nil(t, component.DeleteFederatedIdentity(ctx, realmName, userID, provider))
+	})
+}
+
+func TestIdentityProviders(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var mockKeycloakClient = mock.NewKeycloakClient(mockCtrl)
+	var mockUsersDetailsDBModule = mock.NewUsersDetailsDBModule(mockCtrl)
+	var mockEventDBModule = mock.NewEventDBModule(mockCtrl)
+	var mockConfigurationDBModule = mock.NewConfigurationDBModule(mockCtrl)
+
+	var component = NewComponent(mockKeycloakClient, mockUsersDetailsDBModule, mockEventDBModule, mockConfigurationDBModule, []string{}, log.NewNopLogger())
+
+	var accessToken = "TOKEN=="
+	var realmName = "master"
+	var alias = "partner-idp"
+	var providerID = "oidc"
+	var trueBool = true
+	var expectedError = errors.New("kc error")
+	var ctx = context.WithValue(context.Background(), cs.CtContextAccessToken, accessToken)
+
+	t.Run("Get identity providers", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().GetIdentityProviders(accessToken, realmName).Return(nil, expectedError)
+		var _, err = component.GetIdentityProviders(ctx, realmName)
+		assert.Equal(t, expectedError, err)
+
+		mockKeycloakClient.EXPECT().GetIdentityProviders(accessToken, realmName).Return([]kc.IdentityProviderRepresentation{{Alias: &alias, ProviderID: &providerID}}, nil)
+		res, err := component.GetIdentityProviders(ctx, realmName)
+		assert.Nil(t, err)
+		assert.Len(t, res, 1)
+		assert.Equal(t, alias, *res[0].Alias)
+	})
+
+	t.Run("Create identity provider without provider ID", func(t *testing.T) {
+		var err = component.CreateIdentityProvider(ctx, realmName, api.IdentityProviderRepresentation{Alias: &alias})
+		assert.Equal(t, http.StatusBadRequest, err.(errorhandler.Error).Status)
+	})
+	t.Run("Create identity provider fails", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().CreateIdentityProvider(accessToken, realmName, gomock.Any()).Return("", expectedError)
+		var err = component.CreateIdentityProvider(ctx, realmName, api.IdentityProviderRepresentation{Alias: &alias, ProviderID: &providerID})
+		assert.Equal(t, expectedError, err)
+	})
+	t.Run("Create identity provider", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().CreateIdentityProvider(accessToken, realmName, gomock.Any()).DoAndReturn(func(_, _ string, idp kc.IdentityProviderRepresentation) (string, error) {
+			assert.Equal(t, alias, *idp.Alias)
+			assert.Equal(t, providerID, *idp.ProviderID)
+			assert.False(t, *idp.Enabled)
+			return "location", nil
+		})
+		mockEventDBModule.EXPECT().ReportEvent(ctx, "API_IDENTITY_PROVIDER_CREATION", "back-office", database.CtEventRealmName, realmName,
+			database.CtEventAdditionalInfo, gomock.Any()).Return(nil)
+		assert.Nil(t, component.CreateIdentityProvider(ctx, realmName, api.IdentityProviderRepresentation{Alias: &alias, ProviderID: &providerID}))
+	})
+
+	t.Run("Update unknown identity provider", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().GetIdentityProvider(accessToken, realmName, alias).Return(kc.IdentityProviderRepresentation{}, expectedError)
+		assert.Equal(t, expectedError, component.UpdateIdentityProvider(ctx, realmName, alias, api.IdentityProviderRepresentation{}))
+	})
+	t.Run("Update identity provider", func(t *testing.T) {
+		var config = map[string]string{"clientId": "bridge", "clientSecret": "**********"}
+		var newConfig = map[string]string{"clientId": "bridge-v2"}
+		mockKeycloakClient.EXPECT().GetIdentityProvider(accessToken, realmName, alias).Return(kc.IdentityProviderRepresentation{Alias: &alias, Config: &config}, nil)
+		mockKeycloakClient.EXPECT().UpdateIdentityProvider(accessToken, realmName, alias, gomock.Any()).DoAndReturn(func(_, _, _ string, idp kc.IdentityProviderRepresentation) error {
+			assert.True(t, *idp.Enabled)
+			assert.Equal(t, map[string]string{"clientId": "bridge-v2", "clientSecret": "**********"}, *idp.Config)
+			return nil
+		})
+		mockEventDBModule.EXPECT().ReportEvent(ctx, "API_IDENTITY_PROVIDER_UPDATE", "back-office", database.CtEventRealmName, realmName,
+			database.CtEventAdditionalInfo, gomock.Any()).Return(nil)
+		assert.Nil(t, component.UpdateIdentityProvider(ctx, realmName, alias, api.IdentityProviderRepresentation{Enabled: &trueBool, Config: &newConfig}))
+	})
+
+	t.Run("Disable identity provider", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().GetIdentityProvider(accessToken, realmName, alias).Return(kc.IdentityProviderRepresentation{Alias: &alias, Enabled: &trueBool}, nil)
+		mockKeycloakClient.EXPECT().UpdateIdentityProvider(accessToken, realmName, alias, gomock.Any()).Return(expectedError)
+		assert.Equal(t, expectedError, component.DisableIdentityProvider(ctx, realmName, alias))
+
+		mockKeycloakClient.EXPECT().GetIdentityProvider(accessToken, realmName, alias).Return(kc.IdentityProviderRepresentation{Alias: &alias, Enabled: &trueBool}, nil)
+		mockKeycloakClient.EXPECT().UpdateIdentityProvider(accessToken, realmName, alias, gomock.Any()).DoAndReturn(func(_, _, _ string, idp kc.IdentityProviderRepresentation) error {
+			assert.False(t, *idp.Enabled)
+			return nil
+		})
+		mockEventDBModule.EXPECT().ReportEvent(ctx, "API_IDENTITY_PROVIDER_DISABLING", "back-office", database.CtEventRealmName, realmName,
+			database.CtEventAdditionalInfo, gomock.Any()).Return(nil)
+		assert.Nil(t, component.DisableIdentityProvider(ctx, realmName, alias))
+	})
+}
+
+func TestPasswordPolicy(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var mockKeycloakClient = mock.NewKeycloakClient(mockCtrl)
+	var mockUsersDetailsDBModule = mock.NewUsersDetailsDBModule(mockCtrl)
+	var mockEventDBModule = mock.NewEventDBModule(mockCtrl)
+	var mockConfigurationDBModule = mock.NewConfigurationDBModule(mockCtrl)
+
+	var component = NewComponent(mockKeycloakClient, mockUsersDetailsDBModule, mockEventDBModule, mockConfigurationDBModule, []string{}, log.NewNopLogger())
+
+	var accessToken = "TOKEN=="
+	var realmName = "master"
+	var expectedError = errors.New("kc error")
+	var ctx = context.WithValue(context.Background(), cs.CtContextAccessToken, accessToken)
+
+	t.Run("Get password policy fails", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().GetRealm(accessToken, realmName).Return(kc.RealmRepresentation{}, expectedError)
+		var _, err = component.GetPasswordPolicy(ctx, realmName)
+		assert.Equal(t, expectedError, err)
+	})
+	t.Run("Get invalid password policy", func(t *testing.T) {
+		var policy = "length(abc)"
+		mockKeycloakClient.EXPECT().GetRealm(accessToken, realmName).Return(kc.RealmRepresentation{PasswordPolicy: &policy}, nil)
+		var _, err = component.GetPasswordPolicy(ctx, realmName)
+		assert.NotNil(t, err)
+	})
+	t.Run("Get undefined password policy", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().GetRealm(accessToken, realmName).Return(kc.RealmRepresentation{}, nil)
+		var res, err = component.GetPasswordPolicy(ctx, realmName)
+		assert.Nil(t, err)
+		assert.Equal(t, api.PasswordPolicyRepresentation{}, res)
+	})
+	t.Run("Get password policy", func(t *testing.T) {
+		var policy = "length(12) and notEmail"
+		mockKeycloakClient.EXPECT().GetRealm(accessToken, realmName).Return(kc.RealmRepresentation{PasswordPolicy: &policy}, nil)
+		var res, err = component.GetPasswordPolicy(ctx, realmName)
+		assert.Nil(t, err)
+		assert.Equal(t, 12, *res.Length)
+		assert.True(t, *res.NotEmail)
+		assert.Nil(t, res.NotUsername)
+	})
+
+	var length = 12
+	var notUsername = true
+	var policy = api.PasswordPolicyRepresentation{Length: &length, NotUsername: &notUsername}
+
+	t.Run("Update password policy fails", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().UpdateRealm(accessToken, realmName, gomock.Any()).Return(expectedError)
+		assert.Equal(t, expectedError, component.UpdatePasswordPolicy(ctx, realmName, policy))
+	})
+	t.Run("Update password policy", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().UpdateRealm(accessToken, realmName, gomock.Any()).DoAndReturn(func(_, _ string, realm kc.RealmRepresentation) error {
+			assert.Equal(t, "length(12) and notUsername(undefined)", *realm.PasswordPolicy)
+			assert.Nil(t, realm.Realm)
+			return nil
+		})
+		mockEventDBModule.EXPECT().ReportEvent(ctx, "API_PASSWORD_POLICY_UPDATE", "back-office", database.CtEventRealmName, realmName,
+			database.CtEventAdditionalInfo, gomock.Any()).Return(nil)
+		assert.Nil(t, component.UpdatePasswordPolicy(ctx, realmName, policy))
+	})
+}
+
+func TestStatisticsReportSchedules(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var mockKeycloakClient = mock.NewKeycloakClient(mockCtrl)
+	var mockUsersDetailsDBModule = mock.NewUsersDetailsDBModule(mockCtrl)
+	var mockEventDBModule = mock.NewEventDBModule(mockCtrl)
+	var mockConfigurationDBModule = mock.NewConfigurationDBModule(mockCtrl)
+	var logger = log.NewNopLogger()
+
+	var realmName = "myrealm"
+	var scheduleID = int64(3)
+	var expectedError = errors.New("expectedError")
+	var ctx = context.TODO()
+	var dbSchedule = dto.DBReportSchedule{ID: scheduleID, RealmName: realmName, Frequency: dto.ReportFrequencyDaily, Hour: 6, Recipients: []string{"admin@example.com"}}
+	var apiSchedule = api.ConvertToAPIReportSchedule(dbSchedule)
+	apiSchedule.ID = nil
+
+	var component = NewComponent(mockKeycloakClient, mockUsersDetailsDBModule, mockEventDBModule, mockConfigurationDBModule, []string{}, logger)
+
+	t.Run("Get schedules fails", func(t *testing.T) {
+		mockConfigurationDBModule.EXPECT().GetReportSchedules(ctx, realmName).Return(nil, expectedError)
+		var _, err = component.GetStatisticsReportSchedules(ctx, realmName)
+		assert.Equal(t, expectedError, err)
+	})
+	t.Run("Get schedules", func(t *testing.T) {
+		mockConfigurationDBModule.EXPECT().GetReportSchedules(ctx, realmName).Return([]dto.DBReportSchedule{dbSchedule}, nil)
+		var res, err = component.GetStatisticsReportSchedules(ctx, realmName)
+		assert.Nil(t, err)
+		assert.Len(t, res, 1)
+		assert.Equal(t, scheduleID, *res[0].ID)
+	})
+
+	t.Run("Create schedule fails", func(t *testing.T) {
+		mockConfigurationDBModule.EXPECT().CreateReportSchedule(ctx, gomock.Any()).Return(int64(0), expectedError)
+		var _, err = component.CreateStatisticsReportSchedule(ctx, realmName, apiSchedule)
+		assert.Equal(t, expectedError, err)
+	})
+	t.Run("Create schedule", func(t *testing.T) {
+		var expectedSchedule = dbSchedule
+		expectedSchedule.ID = 0
+		mockConfigurationDBModule.EXPECT().CreateReportSchedule(ctx, expectedSchedule).Return(scheduleID, nil)
+		mockEventDBModule.EXPECT().ReportEvent(ctx, "API_REPORT_SCHEDULE_CREATION", "back-office", database.CtEventRealmName, realmName, database.CtEventAdditionalInfo, gomock.Any()).Return(nil)
+		var res, err = component.CreateStatisticsReportSchedule(ctx, realmName, apiSchedule)
+		assert.Nil(t, err)
+		assert.Equal(t, scheduleID, res)
+	})
+
+	t.Run("Update unknown schedule", func(t *testing.T) {
+		mockConfigurationDBModule.EXPECT().GetReportSchedules(ctx, realmName).Return([]dto.DBReportSchedule{}, nil)
+		var err = component.UpdateStatisticsReportSchedule(ctx, realmName, scheduleID, apiSchedule)
+		assert.Equal(t, errorhandler.CreateNotFoundError(constants.ReportSchedule), err)
+	})
+	t.Run("Update schedule fails", func(t *testing.T) {
+		mockConfigurationDBModule.EXPECT().GetReportSchedules(ctx, realmName).Return([]dto.DBReportSchedule{dbSchedule}, nil)
+		mockConfigurationDBModule.EXPECT().UpdateReportSchedule(ctx, dbSchedule).Return(expectedError)
+		var err = component.UpdateStatisticsReportSchedule(ctx, realmName, scheduleID, apiSchedule)
+		assert.Equal(t, expectedError, err)
+	})
+	t.Run("Update schedule", func(t *testing.T) {
+		mockConfigurationDBModule.EXPECT().GetReportSchedules(ctx, realmName).Return([]dto.DBReportSchedule{dbSchedule}, nil)
+		mockConfigurationDBModule.EXPECT().UpdateReportSchedule(ctx, dbSchedule).Return(nil)
+		mockEventDBModule.EXPECT().ReportEvent(ctx, "API_REPORT_SCHEDULE_UPDATE", "back-office", database.CtEventRealmName, realmName, database.CtEventAdditionalInfo, gomock.Any()).Return(nil)
+		var err = component.UpdateStatisticsReportSchedule(ctx, realmName, scheduleID, apiSchedule)
+		assert.Nil(t, err)
+	})
+
+	t.Run("Delete schedule: can't check existence", func(t *testing.T) {
+		mockConfigurationDBModule.EXPECT().GetReportSchedules(ctx, realmName).Return(nil, expectedError)
+		var err = component.DeleteStatisticsReportSchedule(ctx, realmName, scheduleID)
+		assert.Equal(t, expectedError, err)
+	})
+	t.Run("Delete schedule", func(t *testing.T) {
+		mockConfigurationDBModule.EXPECT().GetReportSchedules(ctx, realmName).Return([]dto.DBReportSchedule{dbSchedule}, nil)
+		mockConfigurationDBModule.EXPECT().DeleteReportSchedule(ctx, realmName, scheduleID).Return(nil)
+		mockEventDBModule.EXPECT().ReportEvent(ctx, "API_REPORT_SCHEDULE_DELETION", "back-office", database.CtEventRealmName, realmName, database.CtEventAdditionalInfo, gomock.Any()).Return(nil)
+		var err = component.DeleteStatisticsReportSchedule(ctx, realmName, scheduleID)
+		assert.Nil(t, err)
+	})
+}
+
+func TestCredentialManagement(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var mockKeycloakClient = mock.NewKeycloakClient(mockCtrl)
+	var mockUsersDetailsDBModule = mock.NewUsersDetailsDBModule(mockCtrl)
+	var mockEventDBModule = mock.NewEventDBModule(mockCtrl)
+	var mockConfigurationDBModule = mock.NewConfigurationDBModule(mockCtrl)
+
+	var component = NewComponent(mockKeycloakClient, mockUsersDetailsDBModule, mockEventDBModule, mockConfigurationDBModule, []string{}, log.NewNopLogger())
+
+	var accessToken = "TOKEN=="
+	var realmName = "master"
+	var userID = "123-456-789"
+	var credentialID = "987-654-321"
+	var previousCredentialID = "654-321-987"
+	var expectedError = errors.New("kc error")
+	var ctx = context.WithValue(context.Background(), cs.CtContextAccessToken, accessToken)
+
+	t.Run("Update label of credential fails", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().UpdateLabelCredential(accessToken, realmName, userID, credentialID, "label").Return(expectedError)
+		assert.Equal(t, expectedError, component.UpdateLabelCredentialForUser(ctx, realmName, userID, credentialID, "label"))
+	})
+	t.Run("Update label of credential", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().UpdateLabelCredential(accessToken, realmName, userID, credentialID, "label").Return(nil)
+		mockEventDBModule.EXPECT().ReportEvent(ctx, "API_CREDENTIAL_LABEL_UPDATE", "back-office", database.CtEventRealmName, realmName,
+			database.CtEventUserID, userID, database.CtEventAdditionalInfo, gomock.Any()).Return(nil)
+		assert.Nil(t, component.UpdateLabelCredentialForUser(ctx, realmName, userID, credentialID, "label"))
+	})
+
+	t.Run("Move credential fails", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().MoveAfter(accessToken, realmName, userID, credentialID, previousCredentialID).Return(expectedError)
+		assert.Equal(t, expectedError, component.MoveCredentialForUser(ctx, realmName, userID, credentialID, previousCredentialID))
+	})
+	t.Run("Move credential after another one", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().MoveAfter(accessToken, realmName, userID, credentialID, previousCredentialID).Return(nil)
+		mockEventDBModule.EXPECT().ReportEvent(ctx, "API_CREDENTIAL_MOVE", "back-office", database.CtEventRealmName, realmName,
+			database.CtEventUserID, userID, database.CtEventAdditionalInfo, gomock.Any()).Return(nil)
+		assert.Nil(t, component.MoveCredentialForUser(ctx, realmName, userID, credentialID, previousCredentialID))
+	})
+	t.Run("Move credential to first position", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().MoveToFirst(accessToken, realmName, userID, credentialID).Return(nil)
+		mockEventDBModule.EXPECT().ReportEvent(ctx, "API_CREDENTIAL_MOVE", "back-office", database.CtEventRealmName, realmName,
+			database.CtEventUserID, userID, database.CtEventAdditionalInfo, gomock.Any()).Return(nil)
+		assert.Nil(t, component.MoveCredentialForUser(ctx, realmName, userID, credentialID, "null"))
+	})
+
+	var enabled = true
+	var disabled = false
+	var aliasOTP = "CONFIGURE_TOTP"
+	var aliasPassword = "UPDATE_PASSWORD"
+	var aliasWebAuthn = "webauthn-register"
+	var aliasTerms = "terms_and_conditions"
+	var requiredActions = []kc.RequiredActionProviderRepresentation{
+		{Alias: &aliasOTP, Enabled: &enabled},
+		{Alias: &aliasPassword, Enabled: &enabled},
+		{Alias: &aliasWebAuthn, Enabled: &disabled},
+		{Alias: &aliasTerms, Enabled: &enabled},
+	}
+
+	t.Run("Get credential registrators fails", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().GetRequiredActions(accessToken, realmName).Return(nil, expectedError)
+		var _, err = component.GetCredentialRegistratorsForUser(ctx, realmName, userID)
+		assert.Equal(t, expectedError, err)
+	})
+	t.Run("Get credential registrators", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().GetRequiredActions(accessToken, realmName).Return(requiredActions, nil)
+		var res, err = component.GetCredentialRegistratorsForUser(ctx, realmName, userID)
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"otp", "password"}, res)
+	})
+
+	var email = "john.doe@domain.ch"
+
+	t.Run("Require re-enrollment of unknown type", func(t *testing.T) {
+		assert.NotNil(t, component.RequireReEnrollment(ctx, realmName, userID, "unknown"))
+	})
+	t.Run("Require re-enrollment. Can't get required actions", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().GetRequiredActions(accessToken, realmName).Return(nil, expectedError)
+		assert.Equal(t, expectedError, component.RequireReEnrollment(ctx, realmName, userID, "otp"))
+	})
+	t.Run("Require re-enrollment. Required action disabled in realm", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().GetRequiredActions(accessToken, realmName).Return(requiredActions, nil)
+		assert.NotNil(t, component.RequireReEnrollment(ctx, realmName, userID, "webauthn"))
+	})
+	t.Run("Require re-enrollment. Can't get user", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().GetRequiredActions(accessToken, realmName).Return(requiredActions, nil)
+		mockKeycloakClient.EXPECT().GetUser(accessToken, realmName, userID).Return(kc.UserRepresentation{}, expectedError)
+		assert.Equal(t, expectedError, component.RequireReEnrollment(ctx, realmName, userID, "otp"))
+	})
+	t.Run("Require re-enrollment. Can't update user", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().GetRequiredActions(accessToken, realmName).Return(requiredActions, nil)
+		mockKeycloakClient.EXPECT().GetUser(accessToken, realmName, userID).Return(kc.UserRepresentation{}, nil)
+		mockKeycloakClient.EXPECT().UpdateUser(accessToken, realmName, userID, gomock.Any()).Return(expectedError)
+		assert.Equal(t, expectedError, component.RequireReEnrollment(ctx, realmName, userID, "otp"))
+	})
+	t.Run("Require re-enrollment. Notification fails", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().GetRequiredActions(accessToken, realmName).Return(requiredActions, nil)
+		mockKeycloakClient.EXPECT().GetUser(accessToken, realmName, userID).Return(kc.UserRepresentation{Email: &email}, nil)
+		mockKeycloakClient.EXPECT().UpdateUser(accessToken, realmName, userID, gomock.Any()).DoAndReturn(func(_, _, _ string, user kc.UserRepresentation) error {
+			assert.Equal(t, []string{aliasOTP}, *user.RequiredActions)
+			return nil
+		})
+		mockEventDBModule.EXPECT().ReportEvent(ctx, "API_CREDENTIAL_RE_ENROLLMENT", "back-office", database.CtEventRealmName, realmName,
+			database.CtEventUserID, userID, database.CtEventAdditionalInfo, gomock.Any()).Return(nil)
+		mockKeycloakClient.EXPECT().ExecuteActionsEmail(accessToken, realmName, userID, []string{aliasOTP}).Return(expectedError)
+		assert.Nil(t, component.RequireReEnrollment(ctx, realmName, userID, "otp"))
+	})
+	t.Run("Require re-enrollment. Action already required and user without email", func(t *testing.T) {
+		var userActions = []string{aliasPassword}
+		mockKeycloakClient.EXPECT().GetRequiredActions(accessToken, realmName).Return(requiredActions, nil)
+		mockKeycloakClient.EXPECT().GetUser(accessToken, realmName, userID).Return(kc.UserRepresentation{RequiredActions: &userActions}, nil)
+		mockEventDBModule.EXPECT().ReportEvent(ctx, "API_CREDENTIAL_RE_ENROLLMENT", "back-office", database.CtEventRealmName, realmName,
+			database.CtEventUserID, userID, database.CtEventAdditionalInfo, gomock.Any()).Return(nil)
+		assert.Nil(t, component.RequireReEnrollment(ctx, realmName, userID, "password"))
+	})
+}
@@ -0,0 +1,225 @@
+package management
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strconv"
+	"time"
+
+	cs "github.com/cloudtrust/common-service"
+	"github.com/cloudtrust/common-service/configuration"
+	"github.com/cloudtrust/common-service/database"
+	errorhandler "github.com/cloudtrust/common-service/errors"
+	api "github.com/cloudtrust/keycloak-bridge/api/management"
+	"github.com/cloudtrust/keycloak-bridge/internal/constants"
+	"github.com/cloudtrust/keycloak-bridge/internal/dto"
+	"github.com/pkg/errors"
+)
+
+// GetConfigurationRevisions returns the revisions of the custom and admin configurations of a realm, most recent first
+func (c *component) GetConfigurationRevisions(ctx context.Context, realmName string, configType *string) ([]api.ConfigurationRevisionRepresentation, error) {
+	var realmID, err = c.getRealmID(ctx, realmName)
+	if err != nil {
+		return nil, err
+	}
+
+	revisions, err := c.configDBModule.GetConfigurationRevisions(ctx, realmID, configType)
+	if err != nil {
+		return nil, err
+	}
+
+	var res = []api.ConfigurationRevisionRepresentation{}
+	for _, revision := range revisions {
+		res = append(res, api.ConvertToAPIConfigurationRevision(revision))
+	}
+	return res, nil
+}
+
+// DiffConfigurationRevisions returns the settings which differ between two revisions of the same configuration type
+func (c *component) DiffConfigurationRevisions(ctx context.Context, realmName string, fromRevisionID, toRevisionID int64) ([]api.ConfigurationDiffRepresentation, error) {
+	var realmID, err = c.getRealmID(ctx, realmName)
+	if err != nil {
+		return nil, err
+	}
+
+	from, err := c.getConfigurationRevision(ctx, realmID, fromRevisionID)
+	if err != nil {
+		return nil, err
+	}
+	to, err := c.getConfigurationRevision(ctx, realmID, toRevisionID)
+	if err != nil {
+		return nil, err
+	}
+	if from.ConfigType != to.ConfigType {
+		c.logger.Info(ctx, "msg", "Can't compare revisions of different configuration types", "from", fromRevisionID, "to", toRevisionID)
+		return nil, errorhandler.CreateBadRequestError(constants.MsgErrInvalidParam + "." + constants.RevisionID)
+	}
+
+	var fromSettings, toSettings map[string]interface{}
+	if err = json.Unmarshal([]byte(from.Content), &fromSettings); err != nil {
+		c.logger.Warn(ctx, "msg", "Can't unmarshal configuration revision", "err", err.Error(), "revisionID", fromRevisionID)
+		return nil, err
+	}
+	if err = json.Unmarshal([]byte(to.Content), &toSettings); err != nil {
+		c.logger.Warn(ctx, "msg", "Can't unmarshal configuration revision", "err", err.Error(), "revisionID", toRevisionID)
+		return nil, err
+	}
+
+	return diffSettings(fromSettings, toSettings), nil
+}
+
+// diffSettings returns the settings which differ, sorted by name
+func diffSettings(from, to map[string]interface{}) []api.ConfigurationDiffRepresentation {
+	var names = []string{}
+	for name := range from {
+		names = append(names, name)
+	}
+	for name := range to {
+		if _, ok := from[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var res = []api.ConfigurationDiffRepresentation{}
+	for _, name := range names {
+		if !reflect.DeepEqual(from[name], to[name]) {
+			res = append(res, api.ConfigurationDiffRepresentation{Setting: name, From: from[name], To: to[name]})
+		}
+	}
+	return res
+}
+
+// RollbackConfiguration restores the configuration stored by a revision. The rollback is stored as a new revision.
+func (c *component) RollbackConfiguration(ctx context.Context, realmName string, revisionID int64) error {
+	var realmID, err = c.getRealmID(ctx, realmName)
+	if err != nil {
+		return err
+	}
+
+	revision, err := c.getConfigurationRevision(ctx, realmID, revisionID)
+	if err != nil {
+		return err
+	}
+
+	switch revision.ConfigType {
+	case dto.ConfigurationTypeCustom:
+		var config configuration.RealmConfiguration
+		if err = json.Unmarshal([]byte(revision.Content), &config); err == nil {
+			err = c.configDBModule.StoreOrUpdateConfiguration(ctx, realmID, config)
+		}
+	case dto.ConfigurationTypeAdmin:
+		// The client policy is restored with the admin configuration
+		var config configuration.RealmAdminConfiguration
+		var clientPolicy dto.ClientPolicy
+		if config, clientPolicy, err = dto.UnmarshalAdminConfiguration([]byte(revision.Content)); err == nil {
+			err = c.configDBModule.StoreOrUpdateAdminConfiguration(ctx, realmID, config, clientPolicy)
+		}
+	default:
+		c.logger.Warn(ctx, "msg", "Unknown configuration type", "type", revision.ConfigType, "revisionID", revisionID)
+		return errorhandler.CreateInternalServerError("unknownConfigurationType")
+	}
+	if err != nil {
+		c.logger.Warn(ctx, "msg", "Can't restore configuration revision", "err", err.Error(), "revisionID", revisionID)
+		return err
+	}
+
+	if err = c.storeConfigurationRevision(ctx, realmID, revision.ConfigType, revision.Content, &revisionID); err != nil {
+		return err
+	}
+
+	c.reportEvent(ctx, "API_CONFIGURATION_ROLLBACK", database.CtEventRealmName, realmName, database.CtEventAdditionalInfo,
+		database.CreateAdditionalInfo("revision_id", strconv.FormatInt(revisionID, 10), "config_type", revision.ConfigType))
+
+	return nil
+}
+
+// storeBaselineRevision stores the current content of a configuration as a revision without author when the realm has
+// no revision of this type yet. The content which precedes the first update can then be restored.
+func (c *component) storeBaselineRevision(ctx context.Context, realmID, configType string) error {
+	var revisions, err = c.configDBModule.GetConfigurationRevisions(ctx, realmID, &configType)
+	if err != nil || len(revisions) > 0 {
+		return err
+	}
+
+	var content []byte
+	switch configType {
+	case dto.ConfigurationTypeCustom:
+		var config configuration.RealmConfiguration
+		if config, err = c.configDBModule.GetConfiguration(ctx, realmID); err != nil {
+			if _, ok := errors.Cause(err).(errorhandler.Error); ok {
+				// No configuration stored yet
+				return nil
+			}
+			return err
+		}
+		content, err = json.Marshal(config)
+	case dto.ConfigurationTypeAdmin:
+		var config configuration.RealmAdminConfiguration
+		if config, err = c.configDBModule.GetAdminConfiguration(ctx, realmID); err != nil {
+			if err == sql.ErrNoRows {
+				// No configuration stored yet
+				return nil
+			}
+			return err
+		}
+		var clientPolicy dto.ClientPolicy
+		if clientPolicy, err = c.configDBModule.GetClientPolicy(ctx, realmID); err != nil {
+			return err
+		}
+		content, err = dto.MarshalAdminConfiguration(config, clientPolicy)
+	}
+	if err != nil {
+		return err
+	}
+
+	_, err = c.configDBModule.CreateConfigurationRevision(ctx, dto.DBConfigurationRevision{
+		RealmID:    realmID,
+		ConfigType: configType,
+		Content:    string(content),
+		CreatedAt:  time.Now().UTC(),
+	})
+	return err
+}
+
+// storeConfigurationRevision stores the configuration content of a realm with the connected user as author
+func (c *component) storeConfigurationRevision(ctx context.Context, realmID, configType, content string, rollbackOf *int64) error {
+	var authorID, _ = ctx.Value(cs.CtContextUserID).(string)
+	var authorUsername, _ = ctx.Value(cs.CtContextUsername).(string)
+
+	var _, err = c.configDBModule.CreateConfigurationRevision(ctx, dto.DBConfigurationRevision{
+		RealmID:        realmID,
+		ConfigType:     configType,
+		Content:        content,
+		AuthorID:       authorID,
+		AuthorUsername: authorUsername,
+		CreatedAt:      time.Now().UTC(),
+		RollbackOf:     rollbackOf,
+	})
+	return err
+}
+
+func (c *component) getConfigurationRevision(ctx context.Context, realmID string, revisionID int64) (*dto.DBConfigurationRevision, error) {
+	var revision, err = c.configDBModule.GetConfigurationRevision(ctx, realmID, revisionID)
+	if err != nil {
+		return nil, err
+	}
+	if revision == nil {
+		return nil, errorhandler.CreateNotFoundError(constants.RevisionID)
+	}
+	return revision, nil
+}
+
+func (c *component) getRealmID(ctx context.Context, realmName string) (string, error) {
+	var accessToken = ctx.Value(cs.CtContextAccessToken).(string)
+
+	var realmKc, err = c.keycloakClient.GetRealm(accessToken, realmName)
+	if err != nil {
+		c.logger.Warn(ctx, "err", err.Error())
+		return "", err
+	}
+	return *realmKc.ID, nil
+}
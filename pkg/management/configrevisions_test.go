@@ -0,0 +1,134 @@
+package management
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	cs "github.com/cloudtrust/common-service"
+	"github.com/cloudtrust/common-service/configuration"
+	"github.com/cloudtrust/common-service/database"
+	"github.com/cloudtrust/common-service/log"
+	api "github.com/cloudtrust/keycloak-bridge/api/management"
+	"github.com/cloudtrust/keycloak-bridge/internal/dto"
+	"github.com/cloudtrust/keycloak-bridge/pkg/management/mock"
+	kc "github.com/cloudtrust/keycloak-client"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfigurationRevisions(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+	var mockKeycloakClient = mock.NewKeycloakClient(mockCtrl)
+	var mockUsersDetailsDBModule = mock.NewUsersDetailsDBModule(mockCtrl)
+	var mockEventDBModule = mock.NewEventDBModule(mockCtrl)
+	var mockConfigurationDBModule = mock.NewConfigurationDBModule(mockCtrl)
+
+	var component = NewComponent(mockKeycloakClient, mockUsersDetailsDBModule, mockEventDBModule, mockConfigurationDBModule, nil, log.NewNopLogger())
+
+	var accessToken = "TOKEN=="
+	var realmName = "master"
+	var realmID = "master-id"
+	var authorID = "author-id"
+	var authorUsername = "author"
+	var expectedError = errors.New("db error")
+	var ctx = context.WithValue(context.Background(), cs.CtContextAccessToken, accessToken)
+	ctx = context.WithValue(ctx, cs.CtContextUserID, authorID)
+	ctx = context.WithValue(ctx, cs.CtContextUsername, authorUsername)
+
+	var customRevision1 = dto.DBConfigurationRevision{ID: 1, RealmID: realmID, ConfigType: dto.ConfigurationTypeCustom,
+		Content: `{"default_client_id":"client-a","show_password_tab":true}`}
+	var customRevision2 = dto.DBConfigurationRevision{ID: 2, RealmID: realmID, ConfigType: dto.ConfigurationTypeCustom,
+		Content: `{"default_client_id":"client-b","barcode_type":"CH"}`}
+	var clientA = "client-a"
+	var customContent, _ = json.Marshal(configuration.RealmConfiguration{DefaultClientID: &clientA})
+	var customRevision3 = dto.DBConfigurationRevision{ID: 3, RealmID: realmID, ConfigType: dto.ConfigurationTypeCustom, Content: string(customContent)}
+	var adminRevision = dto.DBConfigurationRevision{ID: 4, RealmID: realmID, ConfigType: dto.ConfigurationTypeAdmin,
+		Content: `{"mode":"trustID","client_policy":{"publicClientsAllowed":true}}`}
+
+	t.Run("Get revisions. Can't get realm", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().GetRealm(accessToken, realmName).Return(kc.RealmRepresentation{}, expectedError)
+		var _, err = component.GetConfigurationRevisions(ctx, realmName, nil)
+		assert.Equal(t, expectedError, err)
+	})
+	t.Run("Get revisions. Database fails", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().GetRealm(accessToken, realmName).Return(kc.RealmRepresentation{ID: &realmID}, nil)
+		mockConfigurationDBModule.EXPECT().GetConfigurationRevisions(ctx, realmID, nil).Return(nil, expectedError)
+		var _, err = component.GetConfigurationRevisions(ctx, realmName, nil)
+		assert.Equal(t, expectedError, err)
+	})
+	t.Run("Get revisions", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().GetRealm(accessToken, realmName).Return(kc.RealmRepresentation{ID: &realmID}, nil)
+		mockConfigurationDBModule.EXPECT().GetConfigurationRevisions(ctx, realmID, nil).Return([]dto.DBConfigurationRevision{customRevision2, customRevision1}, nil)
+		var res, err = component.GetConfigurationRevisions(ctx, realmName, nil)
+		assert.Nil(t, err)
+		assert.Len(t, res, 2)
+		assert.Equal(t, int64(2), *res[0].ID)
+	})
+
+	t.Run("Diff. Unknown revision", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().GetRealm(accessToken, realmName).Return(kc.RealmRepresentation{ID: &realmID}, nil)
+		mockConfigurationDBModule.EXPECT().GetConfigurationRevision(ctx, realmID, int64(1)).Return(nil, nil)
+		var _, err = component.DiffConfigurationRevisions(ctx, realmName, 1, 2)
+		assert.NotNil(t, err)
+	})
+	t.Run("Diff. Different configuration types", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().GetRealm(accessToken, realmName).Return(kc.RealmRepresentation{ID: &realmID}, nil)
+		mockConfigurationDBModule.EXPECT().GetConfigurationRevision(ctx, realmID, int64(1)).Return(&customRevision1, nil)
+		mockConfigurationDBModule.EXPECT().GetConfigurationRevision(ctx, realmID, int64(4)).Return(&adminRevision, nil)
+		var _, err = component.DiffConfigurationRevisions(ctx, realmName, 1, 4)
+		assert.NotNil(t, err)
+	})
+	t.Run("Diff", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().GetRealm(accessToken, realmName).Return(kc.RealmRepresentation{ID: &realmID}, nil)
+		mockConfigurationDBModule.EXPECT().GetConfigurationRevision(ctx, realmID, int64(1)).Return(&customRevision1, nil)
+		mockConfigurationDBModule.EXPECT().GetConfigurationRevision(ctx, realmID, int64(2)).Return(&customRevision2, nil)
+		var res, err = component.DiffConfigurationRevisions(ctx, realmName, 1, 2)
+		assert.Nil(t, err)
+		assert.Equal(t, []api.ConfigurationDiffRepresentation{
+			{Setting: "barcode_type", To: "CH"},
+			{Setting: "default_client_id", From: "client-a", To: "client-b"},
+			{Setting: "show_password_tab", From: true},
+		}, res)
+	})
+
+	t.Run("Rollback. Unknown revision", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().GetRealm(accessToken, realmName).Return(kc.RealmRepresentation{ID: &realmID}, nil)
+		mockConfigurationDBModule.EXPECT().GetConfigurationRevision(ctx, realmID, int64(1)).Return(nil, nil)
+		assert.NotNil(t, component.RollbackConfiguration(ctx, realmName, 1))
+	})
+	t.Run("Rollback. Can't store configuration", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().GetRealm(accessToken, realmName).Return(kc.RealmRepresentation{ID: &realmID}, nil)
+		mockConfigurationDBModule.EXPECT().GetConfigurationRevision(ctx, realmID, int64(1)).Return(&customRevision1, nil)
+		mockConfigurationDBModule.EXPECT().StoreOrUpdateConfiguration(ctx, realmID, gomock.Any()).Return(expectedError)
+		assert.Equal(t, expectedError, component.RollbackConfiguration(ctx, realmName, 1))
+	})
+	t.Run("Rollback custom configuration", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().GetRealm(accessToken, realmName).Return(kc.RealmRepresentation{ID: &realmID}, nil)
+		mockConfigurationDBModule.EXPECT().GetConfigurationRevision(ctx, realmID, int64(3)).Return(&customRevision3, nil)
+		mockConfigurationDBModule.EXPECT().StoreOrUpdateConfiguration(ctx, realmID, gomock.Any()).DoAndReturn(func(_ context.Context, _ string, config configuration.RealmConfiguration) error {
+			assert.Equal(t, "client-a", *config.DefaultClientID)
+			return nil
+		})
+		mockConfigurationDBModule.EXPECT().CreateConfigurationRevision(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, revision dto.DBConfigurationRevision) (int64, error) {
+			assert.Equal(t, customRevision3.Content, revision.Content)
+			assert.Equal(t, authorUsername, revision.AuthorUsername)
+			assert.Equal(t, int64(3), *revision.RollbackOf)
+			return 5, nil
+		})
+		mockEventDBModule.EXPECT().ReportEvent(ctx, "API_CONFIGURATION_ROLLBACK", "back-office", database.CtEventRealmName, realmName,
+			database.CtEventAdditionalInfo, gomock.Any()).Return(nil)
+		assert.Nil(t, component.RollbackConfiguration(ctx, realmName, 3))
+	})
+	t.Run("Rollback admin configuration", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().GetRealm(accessToken, realmName).Return(kc.RealmRepresentation{ID: &realmID}, nil)
+		mockConfigurationDBModule.EXPECT().GetConfigurationRevision(ctx, realmID, int64(4)).Return(&adminRevision, nil)
+		mockConfigurationDBModule.EXPECT().StoreOrUpdateAdminConfiguration(ctx, realmID, gomock.Any(), dto.ClientPolicy{PublicClientsAllowed: true}).Return(nil)
+		mockConfigurationDBModule.EXPECT().CreateConfigurationRevision(ctx, gomock.Any()).Return(int64(6), nil)
+		mockEventDBModule.EXPECT().ReportEvent(ctx, "API_CONFIGURATION_ROLLBACK", "back-office", database.CtEventRealmName, realmName,
+			database.CtEventAdditionalInfo, gomock.Any()).Return(nil)
+		assert.Nil(t, component.RollbackConfiguration(ctx, realmName, 4))
+	})
+}
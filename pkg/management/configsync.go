@@ -0,0 +1,278 @@
+package management
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"strings"
+
+	errorhandler "github.com/cloudtrust/common-service/errors"
+	api "github.com/cloudtrust/keycloak-bridge/api/management"
+	"github.com/cloudtrust/keycloak-bridge/internal/constants"
+	"github.com/cloudtrust/keycloak-bridge/internal/keycloakb"
+)
+
+// Prefixes of the settings compared by the configuration synchronizer
+const (
+	realmStateConfiguration           = "configuration."
+	realmStateAdminConfiguration      = "admin_configuration."
+	realmStateAuthorizations          = "authorizations."
+	realmStateBackOfficeConfiguration = "backoffice_configuration."
+)
+
+// ConfigSynchronizer keeps the state of the realms owned by the bridge in line with a declared state: custom and admin
+// configurations, authorizations and back-office configurations of the groups.
+type ConfigSynchronizer interface {
+	ExportRealmState(ctx context.Context, realmName string) (api.RealmStateRepresentation, error)
+	DiffRealmState(ctx context.Context, state api.RealmStateRepresentation) ([]api.ConfigurationDiffRepresentation, error)
+	SyncRealmState(ctx context.Context, state api.RealmStateRepresentation) ([]api.ConfigurationDiffRepresentation, error)
+}
+
+type configSynchronizer struct {
+	component Component
+	logger    keycloakb.Logger
+}
+
+// NewConfigSynchronizer returns a configuration synchronizer. The changes are done by the given management component,
+// the access token of the context must allow to read and update the realms.
+func NewConfigSynchronizer(component Component, logger keycloakb.Logger) ConfigSynchronizer {
+	return &configSynchronizer{
+		component: component,
+		logger:    logger,
+	}
+}
+
+// ExportRealmState gets the state of a realm. Only the groups having authorizations or a back-office configuration are
+// listed.
+func (s *configSynchronizer) ExportRealmState(ctx context.Context, realmName string) (api.RealmStateRepresentation, error) {
+	var state, _, err = s.getRealmState(ctx, realmName)
+	return state, err
+}
+
+func (s *configSynchronizer) getRealmState(ctx context.Context, realmName string) (api.RealmStateRepresentation, map[string]string, error) {
+	customConfig, err := s.component.GetRealmCustomConfiguration(ctx, realmName)
+	if err != nil {
+		return api.RealmStateRepresentation{}, nil, err
+	}
+	adminConfig, err := s.component.GetRealmAdminConfiguration(ctx, realmName)
+	if err != nil {
+		return api.RealmStateRepresentation{}, nil, err
+	}
+	groups, err := s.component.GetGroups(ctx, realmName)
+	if err != nil {
+		return api.RealmStateRepresentation{}, nil, err
+	}
+
+	var state = api.RealmStateRepresentation{
+		Realm:                   &realmName,
+		Configuration:           &customConfig,
+		AdminConfiguration:      &adminConfig,
+		Authorizations:          map[string]api.AuthorizationsRepresentation{},
+		BackOfficeConfiguration: map[string]api.BackOfficeConfiguration{},
+	}
+	var groupIDs = make(map[string]string)
+	addGroupIDs(groups, groupIDs)
+
+	for groupName, groupID := range groupIDs {
+		authorizations, err := s.component.GetAuthorizations(ctx, realmName, groupID)
+		if err != nil {
+			return api.RealmStateRepresentation{}, nil, err
+		}
+		if authorizations.Matrix != nil && len(*authorizations.Matrix) > 0 {
+			state.Authorizations[groupName] = authorizations
+		}
+
+		boConf, err := s.component.GetRealmBackOfficeConfiguration(ctx, realmName, groupName)
+		if err != nil {
+			return api.RealmStateRepresentation{}, nil, err
+		}
+		if len(boConf) > 0 {
+			state.BackOfficeConfiguration[groupName] = boConf
+		}
+	}
+
+	return state, groupIDs, nil
+}
+
+// addGroupIDs indexes the identifiers of the groups and of their subgroups by name
+func addGroupIDs(groups []api.GroupRepresentation, groupIDs map[string]string) {
+	for _, group := range groups {
+		if group.Name != nil && group.ID != nil {
+			groupIDs[*group.Name] = *group.ID
+		}
+		if group.SubGroups != nil {
+			addGroupIDs(*group.SubGroups, groupIDs)
+		}
+	}
+}
+
+// DiffRealmState lists the settings of the realm which differ from the given state. The parts missing from the state
+// are not compared.
+func (s *configSynchronizer) DiffRealmState(ctx context.Context, state api.RealmStateRepresentation) ([]api.ConfigurationDiffRepresentation, error) {
+	var diffs, _, err = s.diffRealmState(ctx, state)
+	return diffs, err
+}
+
+func (s *configSynchronizer) diffRealmState(ctx context.Context, state api.RealmStateRepresentation) ([]api.ConfigurationDiffRepresentation, map[string]string, error) {
+	var current, groupIDs, err = s.getRealmState(ctx, *state.Realm)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return diffSettings(flattenRealmState(current, state), flattenRealmState(state, state)), groupIDs, nil
+}
+
+// flattenRealmState gives the settings of the parts of a realm state which are managed by the declared state
+func flattenRealmState(state api.RealmStateRepresentation, declared api.RealmStateRepresentation) map[string]interface{} {
+	var res = make(map[string]interface{})
+	if declared.Configuration != nil {
+		var settings map[string]interface{}
+		toGenericValue(state.Configuration, &settings)
+		for name, value := range settings {
+			res[realmStateConfiguration+name] = value
+		}
+	}
+	if declared.AdminConfiguration != nil {
+		var settings map[string]interface{}
+		toGenericValue(state.AdminConfiguration, &settings)
+		for name, value := range settings {
+			res[realmStateAdminConfiguration+name] = value
+		}
+		if declared.AdminConfiguration.ClientPolicy == nil {
+			// A declared admin configuration without client policy keeps the current one
+			delete(res, realmStateAdminConfiguration+"client-policy")
+		}
+	}
+	if declared.Authorizations != nil {
+		for groupName, authorizations := range state.Authorizations {
+			if authorizations.Matrix != nil && len(*authorizations.Matrix) > 0 {
+				var matrix interface{}
+				toGenericValue(authorizations.Matrix, &matrix)
+				res[realmStateAuthorizations+groupName] = matrix
+			}
+		}
+	}
+	if declared.BackOfficeConfiguration != nil {
+		for groupName, boConf := range state.BackOfficeConfiguration {
+			if len(boConf) > 0 {
+				var value interface{}
+				toGenericValue(sortedBackOfficeConfiguration(boConf), &value)
+				res[realmStateBackOfficeConfiguration+groupName] = value
+			}
+		}
+	}
+	return res
+}
+
+// toGenericValue converts a value to its JSON generic form so that values read from different sources can be compared
+func toGenericValue(value interface{}, res interface{}) {
+	var content, _ = json.Marshal(value)
+	_ = json.Unmarshal(content, res)
+}
+
+// sortedBackOfficeConfiguration sorts the groups of a back-office configuration as their order is not significant
+func sortedBackOfficeConfiguration(boConf api.BackOfficeConfiguration) api.BackOfficeConfiguration {
+	var res = api.BackOfficeConfiguration{}
+	for realmName, realmConf := range boConf {
+		res[realmName] = make(map[string][]string)
+		for confType, groupNames := range realmConf {
+			var sorted = append([]string{}, groupNames...)
+			sort.Strings(sorted)
+			res[realmName][confType] = sorted
+		}
+	}
+	return res
+}
+
+// SyncRealmState applies the differences between the realm and the given state and returns them. The authorizations
+// and the back-office configurations of the groups missing from the state are removed when the state manages them.
+// The updates which require an approval in the realm are left pending: their differences get the approval request.
+func (s *configSynchronizer) SyncRealmState(ctx context.Context, state api.RealmStateRepresentation) ([]api.ConfigurationDiffRepresentation, error) {
+	var realmName = *state.Realm
+
+	var diffs, groupIDs, err = s.diffRealmState(ctx, state)
+	if err != nil {
+		return nil, err
+	}
+
+	var configChanged, adminConfigChanged bool
+	var authorizationsChanged, boConfChanged = map[string]bool{}, map[string]bool{}
+	for _, diff := range diffs {
+		switch {
+		case strings.HasPrefix(diff.Setting, realmStateConfiguration):
+			configChanged = true
+		case strings.HasPrefix(diff.Setting, realmStateAdminConfiguration):
+			adminConfigChanged = true
+		case strings.HasPrefix(diff.Setting, realmStateAuthorizations):
+			authorizationsChanged[strings.TrimPrefix(diff.Setting, realmStateAuthorizations)] = true
+		case strings.HasPrefix(diff.Setting, realmStateBackOfficeConfiguration):
+			boConfChanged[strings.TrimPrefix(diff.Setting, realmStateBackOfficeConfiguration)] = true
+		}
+	}
+
+	// Checks the groups before changing anything
+	for _, changedGroups := range []map[string]bool{authorizationsChanged, boConfChanged} {
+		for groupName := range changedGroups {
+			if _, ok := groupIDs[groupName]; !ok {
+				s.logger.Warn(ctx, "msg", "Unknown group in realm state", "realm", realmName, "group", groupName)
+				return nil, errorhandler.CreateNotFoundError(constants.GroupName)
+			}
+		}
+	}
+
+	// Approval request of the pending updates by part of the realm state
+	var pending = map[string]*int64{}
+	var checkPending = func(part string, err error) error {
+		if approvalErr, ok := err.(ApprovalRequiredError); ok {
+			pending[part] = approvalErr.Request.ID
+			return nil
+		}
+		return err
+	}
+
+	if configChanged {
+		if err = checkPending(realmStateConfiguration, s.component.UpdateRealmCustomConfiguration(ctx, realmName, *state.Configuration)); err != nil {
+			return nil, err
+		}
+	}
+	if adminConfigChanged {
+		if err = checkPending(realmStateAdminConfiguration, s.component.UpdateRealmAdminConfiguration(ctx, realmName, *state.AdminConfiguration)); err != nil {
+			return nil, err
+		}
+	}
+	for groupName := range authorizationsChanged {
+		var authorizations, ok = state.Authorizations[groupName]
+		if !ok || authorizations.Matrix == nil {
+			var matrix = map[string]map[string]map[string]struct{}{}
+			authorizations = api.AuthorizationsRepresentation{Matrix: &matrix}
+		}
+		if err = checkPending(realmStateAuthorizations+groupName, s.component.UpdateAuthorizations(ctx, realmName, groupIDs[groupName], authorizations)); err != nil {
+			return nil, err
+		}
+	}
+	for groupName := range boConfChanged {
+		var boConf, ok = state.BackOfficeConfiguration[groupName]
+		if !ok {
+			boConf = api.BackOfficeConfiguration{}
+		}
+		if err = s.component.UpdateRealmBackOfficeConfiguration(ctx, realmName, groupName, boConf); err != nil {
+			return nil, err
+		}
+	}
+
+	for i, diff := range diffs {
+		diffs[i].ApprovalID = pending[realmStatePart(diff.Setting)]
+	}
+	return diffs, nil
+}
+
+// realmStatePart returns the part of the realm state a setting belongs to: the configuration or the admin
+// configuration, or the group of an authorizations or back-office configuration setting
+func realmStatePart(setting string) string {
+	for _, prefix := range []string{realmStateConfiguration, realmStateAdminConfiguration} {
+		if strings.HasPrefix(setting, prefix) {
+			return prefix
+		}
+	}
+	return setting
+}
@@ -0,0 +1,183 @@
+package management
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/cloudtrust/common-service/log"
+	api "github.com/cloudtrust/keycloak-bridge/api/management"
+	"github.com/cloudtrust/keycloak-bridge/pkg/management/mock"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfigSynchronizer(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+	var mockManagementComponent = mock.NewManagementComponent(mockCtrl)
+
+	var synchronizer = NewConfigSynchronizer(mockManagementComponent, log.NewNopLogger())
+
+	var ctx = context.Background()
+	var realmName = "customer"
+	var groupName = "operators"
+	var groupID = "a4b1c6d2-0a1d-4eee-9bb8-669c6f89c0ee"
+	var subGroupName = "auditors"
+	var subGroupID = "7c0bd5a8-0a1d-4eee-9bb8-669c6f89c0ee"
+	var enabled = true
+	var disabled = false
+	var mode = "corporate"
+	var customConf = api.RealmCustomConfiguration{ShowPasswordTab: &enabled}
+	var adminConf = api.RealmAdminConfiguration{Mode: &mode}
+	var matrix = map[string]map[string]map[string]struct{}{"MGMT_GetUsers": {realmName: {groupName: {}}}}
+	var authorizations = api.AuthorizationsRepresentation{Matrix: &matrix}
+	var emptyMatrix = map[string]map[string]map[string]struct{}{}
+	var noAuthorizations = api.AuthorizationsRepresentation{Matrix: &emptyMatrix}
+	var boConf = api.BackOfficeConfiguration{realmName: {api.BOConfKeyCustomers: []string{subGroupName, groupName}}}
+	var groups = []api.GroupRepresentation{{ID: &groupID, Name: &groupName, SubGroups: &[]api.GroupRepresentation{{ID: &subGroupID, Name: &subGroupName}}}}
+	var expectedError = errors.New("component error")
+
+	var expectRealmState = func() {
+		mockManagementComponent.EXPECT().GetRealmCustomConfiguration(ctx, realmName).Return(customConf, nil)
+		mockManagementComponent.EXPECT().GetRealmAdminConfiguration(ctx, realmName).Return(adminConf, nil)
+		mockManagementComponent.EXPECT().GetGroups(ctx, realmName).Return(groups, nil)
+		mockManagementComponent.EXPECT().GetAuthorizations(ctx, realmName, groupID).Return(authorizations, nil)
+		mockManagementComponent.EXPECT().GetAuthorizations(ctx, realmName, subGroupID).Return(noAuthorizations, nil)
+		mockManagementComponent.EXPECT().GetRealmBackOfficeConfiguration(ctx, realmName, groupName).Return(boConf, nil)
+		mockManagementComponent.EXPECT().GetRealmBackOfficeConfiguration(ctx, realmName, subGroupName).Return(api.BackOfficeConfiguration{}, nil)
+	}
+	var currentState = api.RealmStateRepresentation{
+		Realm:                   &realmName,
+		Configuration:           &customConf,
+		AdminConfiguration:      &adminConf,
+		Authorizations:          map[string]api.AuthorizationsRepresentation{groupName: authorizations},
+		BackOfficeConfiguration: map[string]api.BackOfficeConfiguration{groupName: boConf},
+	}
+
+	t.Run("Export fails", func(t *testing.T) {
+		mockManagementComponent.EXPECT().GetRealmCustomConfiguration(ctx, realmName).Return(customConf, nil)
+		mockManagementComponent.EXPECT().GetRealmAdminConfiguration(ctx, realmName).Return(adminConf, nil)
+		mockManagementComponent.EXPECT().GetGroups(ctx, realmName).Return(nil, expectedError)
+
+		var _, err = synchronizer.ExportRealmState(ctx, realmName)
+		assert.Equal(t, expectedError, err)
+	})
+
+	t.Run("Export lists the configured groups only", func(t *testing.T) {
+		expectRealmState()
+
+		var state, err = synchronizer.ExportRealmState(ctx, realmName)
+		assert.Nil(t, err)
+		assert.Equal(t, currentState, state)
+	})
+
+	t.Run("No drift", func(t *testing.T) {
+		expectRealmState()
+
+		// The order of the groups of a back-office configuration is not significant
+		var state = currentState
+		state.BackOfficeConfiguration = map[string]api.BackOfficeConfiguration{groupName: {realmName: {api.BOConfKeyCustomers: []string{groupName, subGroupName}}}}
+		var diffs, err = synchronizer.DiffRealmState(ctx, state)
+		assert.Nil(t, err)
+		assert.Len(t, diffs, 0)
+	})
+
+	t.Run("Parts missing from the state are not compared", func(t *testing.T) {
+		expectRealmState()
+
+		var diffs, err = synchronizer.DiffRealmState(ctx, api.RealmStateRepresentation{Realm: &realmName})
+		assert.Nil(t, err)
+		assert.Len(t, diffs, 0)
+	})
+
+	t.Run("Client policy missing from the state is not compared", func(t *testing.T) {
+		var adminConfWithPolicy = adminConf
+		adminConfWithPolicy.ClientPolicy = &api.ClientPolicyRepresentation{PublicClientsAllowed: &enabled}
+		mockManagementComponent.EXPECT().GetRealmCustomConfiguration(ctx, realmName).Return(customConf, nil)
+		mockManagementComponent.EXPECT().GetRealmAdminConfiguration(ctx, realmName).Return(adminConfWithPolicy, nil)
+		mockManagementComponent.EXPECT().GetGroups(ctx, realmName).Return(groups, nil)
+		mockManagementComponent.EXPECT().GetAuthorizations(ctx, realmName, groupID).Return(authorizations, nil)
+		mockManagementComponent.EXPECT().GetAuthorizations(ctx, realmName, subGroupID).Return(noAuthorizations, nil)
+		mockManagementComponent.EXPECT().GetRealmBackOfficeConfiguration(ctx, realmName, groupName).Return(boConf, nil)
+		mockManagementComponent.EXPECT().GetRealmBackOfficeConfiguration(ctx, realmName, subGroupName).Return(api.BackOfficeConfiguration{}, nil)
+
+		var diffs, err = synchronizer.DiffRealmState(ctx, currentState)
+		assert.Nil(t, err)
+		assert.Len(t, diffs, 0)
+	})
+
+	t.Run("Drift is detected", func(t *testing.T) {
+		expectRealmState()
+
+		var state = currentState
+		state.Configuration = &api.RealmCustomConfiguration{ShowPasswordTab: &disabled}
+		state.Authorizations = map[string]api.AuthorizationsRepresentation{}
+		var diffs, err = synchronizer.DiffRealmState(ctx, state)
+		assert.Nil(t, err)
+		assert.Len(t, diffs, 2)
+		assert.Equal(t, "authorizations."+groupName, diffs[0].Setting)
+		assert.Nil(t, diffs[0].To)
+		assert.Equal(t, api.ConfigurationDiffRepresentation{Setting: "configuration.show_password_tab", From: true, To: false}, diffs[1])
+	})
+
+	t.Run("Sync applies the changed parts", func(t *testing.T) {
+		var newCustomConf = api.RealmCustomConfiguration{ShowPasswordTab: &disabled}
+		var state = currentState
+		state.Configuration = &newCustomConf
+		state.Authorizations = map[string]api.AuthorizationsRepresentation{subGroupName: authorizations}
+
+		expectRealmState()
+		mockManagementComponent.EXPECT().UpdateRealmCustomConfiguration(ctx, realmName, newCustomConf).Return(nil)
+		mockManagementComponent.EXPECT().UpdateAuthorizations(ctx, realmName, groupID, noAuthorizations).Return(nil)
+		mockManagementComponent.EXPECT().UpdateAuthorizations(ctx, realmName, subGroupID, authorizations).Return(nil)
+
+		var diffs, err = synchronizer.SyncRealmState(ctx, state)
+		assert.Nil(t, err)
+		assert.Len(t, diffs, 3)
+	})
+
+	t.Run("Sync leaves the updates requiring an approval pending", func(t *testing.T) {
+		var approvalID = int64(21)
+		var newCustomConf = api.RealmCustomConfiguration{ShowPasswordTab: &disabled}
+		var state = currentState
+		state.Configuration = &newCustomConf
+		state.Authorizations = map[string]api.AuthorizationsRepresentation{groupName: authorizations, subGroupName: authorizations}
+
+		expectRealmState()
+		mockManagementComponent.EXPECT().UpdateRealmCustomConfiguration(ctx, realmName, newCustomConf).Return(nil)
+		mockManagementComponent.EXPECT().UpdateAuthorizations(ctx, realmName, subGroupID, authorizations).Return(ApprovalRequiredError{Request: api.ApprovalRequestRepresentation{ID: &approvalID}})
+
+		var diffs, err = synchronizer.SyncRealmState(ctx, state)
+		assert.Nil(t, err)
+		assert.Len(t, diffs, 2)
+		for _, diff := range diffs {
+			if diff.Setting == "authorizations."+subGroupName {
+				assert.Equal(t, approvalID, *diff.ApprovalID)
+			} else {
+				assert.Nil(t, diff.ApprovalID)
+			}
+		}
+	})
+
+	t.Run("Sync fails", func(t *testing.T) {
+		var state = currentState
+		state.AdminConfiguration = &api.RealmAdminConfiguration{}
+
+		expectRealmState()
+		mockManagementComponent.EXPECT().UpdateRealmAdminConfiguration(ctx, realmName, api.RealmAdminConfiguration{}).Return(expectedError)
+
+		var _, err = synchronizer.SyncRealmState(ctx, state)
+		assert.Equal(t, expectedError, err)
+	})
+
+	t.Run("Sync with an unknown group", func(t *testing.T) {
+		var state = currentState
+		state.BackOfficeConfiguration = map[string]api.BackOfficeConfiguration{"unknown": boConf}
+
+		expectRealmState()
+
+		var _, err = synchronizer.SyncRealmState(ctx, state)
+		assert.NotNil(t, err)
+	})
+}
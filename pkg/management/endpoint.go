@@ -5,7 +5,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"regexp"
+	"strconv"
 	"strings"
+	"time"
 
 	cs "github.com/cloudtrust/common-service"
 	errorhandler "github.com/cloudtrust/common-service/errors"
@@ -23,6 +25,12 @@ type Endpoints struct {
 	GetClients         endpoint.Endpoint
 	GetRequiredActions endpoint.Endpoint
 
+	CreateClient             endpoint.Endpoint
+	UpdateClient             endpoint.Endpoint
+	DeleteClient             endpoint.Endpoint
+	RegenerateClientSecret   endpoint.Endpoint
+	UpdateClientRedirectURIs endpoint.Endpoint
+
 	DeleteUser                endpoint.Endpoint
 	GetUser                   endpoint.Endpoint
 	UpdateUser                endpoint.Endpoint
@@ -31,6 +39,8 @@ type Endpoints struct {
 	GetUsers                  endpoint.Endpoint
 	CreateUser                endpoint.Endpoint
 	GetRolesOfUser            endpoint.Endpoint
+	AddRoleToUser             endpoint.Endpoint
+	DeleteRoleForUser         endpoint.Endpoint
 	GetGroupsOfUser           endpoint.Endpoint
 	AddGroupToUser            endpoint.Endpoint
 	DeleteGroupForUser        endpoint.Endpoint
@@ -40,26 +50,40 @@ type Endpoints struct {
 	GetUserAccountStatus      endpoint.Endpoint
 	GetClientRoleForUser      endpoint.Endpoint
 	AddClientRoleToUser       endpoint.Endpoint
-
-	ResetPassword                  endpoint.Endpoint
-	ExecuteActionsEmail            endpoint.Endpoint
-	SendNewEnrolmentCode           endpoint.Endpoint
-	SendReminderEmail              endpoint.Endpoint
-	ResetSmsCounter                endpoint.Endpoint
-	CreateRecoveryCode             endpoint.Endpoint
-	GetCredentialsForUser          endpoint.Endpoint
-	DeleteCredentialsForUser       endpoint.Endpoint
-	ResetCredentialFailuresForUser endpoint.Endpoint
-	ClearUserLoginFailures         endpoint.Endpoint
-	GetAttackDetectionStatus       endpoint.Endpoint
+	DeleteClientRoleForUser   endpoint.Endpoint
+
+	ResetPassword                    endpoint.Endpoint
+	ExecuteActionsEmail              endpoint.Endpoint
+	SendNewEnrolmentCode             endpoint.Endpoint
+	SendReminderEmail                endpoint.Endpoint
+	ResetSmsCounter                  endpoint.Endpoint
+	CreateRecoveryCode               endpoint.Endpoint
+	GetCredentialsForUser            endpoint.Endpoint
+	DeleteCredentialsForUser         endpoint.Endpoint
+	ResetCredentialFailuresForUser   endpoint.Endpoint
+	UpdateLabelCredentialForUser     endpoint.Endpoint
+	MoveCredentialForUser            endpoint.Endpoint
+	GetCredentialRegistratorsForUser endpoint.Endpoint
+	RequireReEnrollment              endpoint.Endpoint
+	ClearUserLoginFailures           endpoint.Endpoint
+	GetAttackDetectionStatus         endpoint.Endpoint
+	GetUserSessions                  endpoint.Endpoint
+	RevokeUserSession                endpoint.Endpoint
+	RevokeUserSessions               endpoint.Endpoint
 
 	GetRoles         endpoint.Endpoint
 	GetRole          endpoint.Endpoint
+	CreateRole       endpoint.Endpoint
+	UpdateRole       endpoint.Endpoint
+	DeleteRole       endpoint.Endpoint
 	GetClientRoles   endpoint.Endpoint
 	CreateClientRole endpoint.Endpoint
 
 	GetGroups            endpoint.Endpoint
 	CreateGroup          endpoint.Endpoint
+	CreateSubGroup       endpoint.Endpoint
+	UpdateGroup          endpoint.Endpoint
+	MoveGroup            endpoint.Endpoint
 	DeleteGroup          endpoint.Endpoint
 	GetAuthorizations    endpoint.Endpoint
 	UpdateAuthorizations endpoint.Endpoint
@@ -73,7 +97,57 @@ type Endpoints struct {
 	UpdateRealmBackOfficeConfiguration  endpoint.Endpoint
 	GetUserRealmBackOfficeConfiguration endpoint.Endpoint
 
-	LinkShadowUser endpoint.Endpoint
+	LinkShadowUser          endpoint.Endpoint
+	GetFederatedIdentities  endpoint.Endpoint
+	DeleteFederatedIdentity endpoint.Endpoint
+
+	GetIdentityProviders    endpoint.Endpoint
+	CreateIdentityProvider  endpoint.Endpoint
+	UpdateIdentityProvider  endpoint.Endpoint
+	DisableIdentityProvider endpoint.Endpoint
+
+	GetPasswordPolicy    endpoint.Endpoint
+	UpdatePasswordPolicy endpoint.Endpoint
+
+	GetConfigurationRevisions  endpoint.Endpoint
+	DiffConfigurationRevisions endpoint.Endpoint
+	RollbackConfiguration      endpoint.Endpoint
+
+	GetStatisticsReportSchedules   endpoint.Endpoint
+	CreateStatisticsReportSchedule endpoint.Endpoint
+	UpdateStatisticsReportSchedule endpoint.Endpoint
+	DeleteStatisticsReportSchedule endpoint.Endpoint
+
+	ExportUsers        endpoint.Endpoint
+	ImportUsers        endpoint.Endpoint
+	ApplyBulkOperation endpoint.Endpoint
+	GetBulkJob         endpoint.Endpoint
+
+	SearchUsers endpoint.Endpoint
+	IndexUsers  endpoint.Endpoint
+
+	RestoreUser endpoint.Endpoint
+
+	GetApprovalPolicy    endpoint.Endpoint
+	UpdateApprovalPolicy endpoint.Endpoint
+	GetApprovalRequests  endpoint.Endpoint
+	ApproveRequest       endpoint.Endpoint
+	RejectRequest        endpoint.Endpoint
+
+	AddTemporaryGroupToUser         endpoint.Endpoint
+	SetTemporaryTrustIDGroupsToUser endpoint.Endpoint
+	AddTemporaryClientRolesToUser   endpoint.Endpoint
+
+	GetScheduledUserChanges   endpoint.Endpoint
+	ScheduleUserChange        endpoint.Endpoint
+	CancelScheduledUserChange endpoint.Endpoint
+
+	ImpersonateUser endpoint.Endpoint
+
+	ProvisionRealm endpoint.Endpoint
+
+	GetUserAuthorizationsReport   endpoint.Endpoint
+	GetTargetAuthorizationsReport endpoint.Endpoint
 }
 
 // MakeGetRealmsEndpoint makes the Realms endpoint to retrieve all available realms.
@@ -110,6 +184,98 @@ func MakeGetClientsEndpoint(component Component) cs.Endpoint {
 	}
 }
 
+// MakeCreateClientEndpoint creates an endpoint for CreateClient
+func MakeCreateClientEndpoint(component Component, logger keycloakb.Logger) cs.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		var m = req.(map[string]string)
+		var err error
+
+		var client api.ClientRepresentation
+
+		if err = json.Unmarshal([]byte(m[reqBody]), &client); err != nil {
+			return nil, errorhandler.CreateBadRequestError(msg.MsgErrInvalidParam + "." + msg.Body)
+		}
+
+		if err = client.Validate(); err != nil {
+			return nil, err
+		}
+
+		var keycloakLocation string
+		keycloakLocation, err = component.CreateClient(ctx, m[prmRealm], client)
+
+		if err != nil {
+			return nil, err
+		}
+
+		url, err := convertLocationURL(keycloakLocation, m[reqScheme], m[reqHost])
+		if err != nil {
+			logger.Warn(ctx, "msg", "Invalid location", "location", keycloakLocation, "err", err.Error())
+		}
+
+		return LocationHeader{
+			URL: url,
+		}, nil
+	}
+}
+
+// MakeUpdateClientEndpoint creates an endpoint for UpdateClient
+func MakeUpdateClientEndpoint(component Component) cs.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		var m = req.(map[string]string)
+		var err error
+
+		var client api.ClientRepresentation
+
+		if err = json.Unmarshal([]byte(m[reqBody]), &client); err != nil {
+			return nil, errorhandler.CreateBadRequestError(msg.MsgErrInvalidParam + "." + msg.Body)
+		}
+
+		if err = client.Validate(); err != nil {
+			return nil, err
+		}
+
+		return nil, component.UpdateClient(ctx, m[prmRealm], m[prmClientID], client)
+	}
+}
+
+// MakeDeleteClientEndpoint creates an endpoint for DeleteClient
+func MakeDeleteClientEndpoint(component Component) cs.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		var m = req.(map[string]string)
+
+		return nil, component.DeleteClient(ctx, m[prmRealm], m[prmClientID])
+	}
+}
+
+// MakeRegenerateClientSecretEndpoint creates an endpoint for RegenerateClientSecret
+func MakeRegenerateClientSecretEndpoint(component Component) cs.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		var m = req.(map[string]string)
+
+		return component.RegenerateClientSecret(ctx, m[prmRealm], m[prmClientID])
+	}
+}
+
+// MakeUpdateClientRedirectURIsEndpoint creates an endpoint for UpdateClientRedirectURIs
+func MakeUpdateClientRedirectURIsEndpoint(component Component) cs.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		var m = req.(map[string]string)
+		var err error
+
+		var redirectURIs []string
+
+		if err = json.Unmarshal([]byte(m[reqBody]), &redirectURIs); err != nil {
+			return nil, errorhandler.CreateBadRequestError(msg.MsgErrInvalidParam + "." + msg.Body)
+		}
+
+		if err = api.ValidateRedirectURIs(redirectURIs); err != nil {
+			return nil, err
+		}
+
+		return nil, component.UpdateClientRedirectURIs(ctx, m[prmRealm], m[prmClientID], redirectURIs)
+	}
+}
+
 // MakeGetRequiredActionsEndpoint creates an endpoint for GetRequiredActions
 func MakeGetRequiredActionsEndpoint(component Component) cs.Endpoint {
 	return func(ctx context.Context, req interface{}) (interface{}, error) {
@@ -218,7 +384,7 @@ func MakeGetUsersEndpoint(component Component) cs.Endpoint {
 		var m = req.(map[string]string)
 
 		var paramKV []string
-		for _, key := range []string{prmQryEmail, prmQryFirstName, prmQryLastName, prmQryUserName, prmQrySearch, prmQryFirst, prmQryMax} {
+		for _, key := range []string{prmQryEmail, prmQryFirstName, prmQryLastName, prmQryUserName, prmQrySearch, prmQryFirst, prmQryMax, prmQryIncludeDeleted} {
 			if m[key] != "" {
 				paramKV = append(paramKV, key, m[key])
 			}
@@ -244,6 +410,24 @@ func MakeGetRolesOfUserEndpoint(component Component) cs.Endpoint {
 	}
 }
 
+// MakeAddRoleToUserEndpoint creates an endpoint for AddRoleToUser
+func MakeAddRoleToUserEndpoint(component Component) cs.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		var m = req.(map[string]string)
+
+		return nil, component.AddRoleToUser(ctx, m[prmRealm], m[prmUserID], m[prmRoleID])
+	}
+}
+
+// MakeDeleteRoleForUserEndpoint creates an endpoint for DeleteRoleForUser
+func MakeDeleteRoleForUserEndpoint(component Component) cs.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		var m = req.(map[string]string)
+
+		return nil, component.DeleteRoleForUser(ctx, m[prmRealm], m[prmUserID], m[prmRoleID])
+	}
+}
+
 // MakeGetGroupsOfUserEndpoint creates an endpoint for GetGroupsOfUser
 func MakeGetGroupsOfUserEndpoint(component Component) cs.Endpoint {
 	return func(ctx context.Context, req interface{}) (interface{}, error) {
@@ -344,6 +528,15 @@ func MakeAddClientRolesToUserEndpoint(component Component) cs.Endpoint {
 	}
 }
 
+// MakeDeleteClientRoleForUserEndpoint creates an endpoint for DeleteClientRoleForUser
+func MakeDeleteClientRoleForUserEndpoint(component Component) cs.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		var m = req.(map[string]string)
+
+		return nil, component.DeleteClientRoleForUser(ctx, m[prmRealm], m[prmUserID], m[prmClientID], m[prmRoleID])
+	}
+}
+
 // MakeResetPasswordEndpoint creates an endpoint for ResetPassword
 func MakeResetPasswordEndpoint(component Component) cs.Endpoint {
 	return func(ctx context.Context, req interface{}) (interface{}, error) {
@@ -469,6 +662,68 @@ func MakeResetCredentialFailuresForUserEndpoint(component Component) cs.Endpoint
 	}
 }
 
+// MakeUpdateLabelCredentialForUserEndpoint creates an endpoint for UpdateLabelCredentialForUser
+func MakeUpdateLabelCredentialForUserEndpoint(component Component) cs.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		var m = req.(map[string]string)
+		var err error
+
+		var credential api.CredentialRepresentation
+
+		if err = json.Unmarshal([]byte(m[reqBody]), &credential); err != nil {
+			return nil, errorhandler.CreateBadRequestError(msg.MsgErrInvalidParam + "." + msg.Body)
+		}
+
+		if err = credential.Validate(); err != nil {
+			return nil, err
+		}
+
+		if credential.UserLabel == nil {
+			return nil, errorhandler.CreateBadRequestError(msg.MsgErrMissingParam + "." + msg.UserLabel)
+		}
+
+		return nil, component.UpdateLabelCredentialForUser(ctx, m[prmRealm], m[prmUserID], m[prmCredentialID], *credential.UserLabel)
+	}
+}
+
+// MakeMoveCredentialForUserEndpoint creates an endpoint for MoveCredentialForUser
+func MakeMoveCredentialForUserEndpoint(component Component) cs.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		var m = req.(map[string]string)
+
+		return nil, component.MoveCredentialForUser(ctx, m[prmRealm], m[prmUserID], m[prmCredentialID], m[prmPrevCredentialID])
+	}
+}
+
+// MakeGetCredentialRegistratorsForUserEndpoint creates an endpoint for GetCredentialRegistratorsForUser
+func MakeGetCredentialRegistratorsForUserEndpoint(component Component) cs.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		var m = req.(map[string]string)
+
+		return component.GetCredentialRegistratorsForUser(ctx, m[prmRealm], m[prmUserID])
+	}
+}
+
+// MakeRequireReEnrollmentEndpoint creates an endpoint for RequireReEnrollment
+func MakeRequireReEnrollmentEndpoint(component Component) cs.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		var m = req.(map[string]string)
+		var err error
+
+		var request api.ReEnrollmentRequestRepresentation
+
+		if err = json.Unmarshal([]byte(m[reqBody]), &request); err != nil {
+			return nil, errorhandler.CreateBadRequestError(msg.MsgErrInvalidParam + "." + msg.Body)
+		}
+
+		if err = request.Validate(); err != nil {
+			return nil, err
+		}
+
+		return nil, component.RequireReEnrollment(ctx, m[prmRealm], m[prmUserID], *request.Type)
+	}
+}
+
 // MakeClearUserLoginFailures creates an endpoint for ClearUserLoginFailures
 func MakeClearUserLoginFailures(component Component) cs.Endpoint {
 	return func(ctx context.Context, req interface{}) (interface{}, error) {
@@ -487,6 +742,33 @@ func MakeGetAttackDetectionStatus(component Component) cs.Endpoint {
 	}
 }
 
+// MakeGetUserSessionsEndpoint creates an endpoint for GetUserSessions
+func MakeGetUserSessionsEndpoint(component Component) cs.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		var m = req.(map[string]string)
+
+		return component.GetUserSessions(ctx, m[prmRealm], m[prmUserID])
+	}
+}
+
+// MakeRevokeUserSessionEndpoint creates an endpoint for RevokeUserSession
+func MakeRevokeUserSessionEndpoint(component Component) cs.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		var m = req.(map[string]string)
+
+		return nil, component.RevokeUserSession(ctx, m[prmRealm], m[prmUserID], m[prmSessionID])
+	}
+}
+
+// MakeRevokeUserSessionsEndpoint creates an endpoint for RevokeUserSessions
+func MakeRevokeUserSessionsEndpoint(component Component) cs.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		var m = req.(map[string]string)
+
+		return nil, component.RevokeUserSessions(ctx, m[prmRealm], m[prmUserID])
+	}
+}
+
 // MakeGetRolesEndpoint creates an endpoint for GetRoles
 func MakeGetRolesEndpoint(component Component) cs.Endpoint {
 	return func(ctx context.Context, req interface{}) (interface{}, error) {
@@ -505,6 +787,69 @@ func MakeGetRoleEndpoint(component Component) cs.Endpoint {
 	}
 }
 
+// MakeCreateRoleEndpoint creates an endpoint for CreateRole
+func MakeCreateRoleEndpoint(component Component, logger keycloakb.Logger) cs.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		var m = req.(map[string]string)
+		var err error
+
+		var role api.RoleRepresentation
+
+		if err = json.Unmarshal([]byte(m[reqBody]), &role); err != nil {
+			return nil, errorhandler.CreateBadRequestError(msg.MsgErrInvalidParam + "." + msg.Body)
+		}
+
+		if err = role.Validate(); err != nil {
+			return nil, err
+		}
+
+		var keycloakLocation string
+		keycloakLocation, err = component.CreateRole(ctx, m[prmRealm], role)
+
+		if err != nil {
+			return nil, err
+		}
+
+		url, err := convertLocationURL(keycloakLocation, m[reqScheme], m[reqHost])
+		if err != nil {
+			logger.Warn(ctx, "msg", "Invalid location", "location", keycloakLocation, "err", err.Error())
+		}
+
+		return LocationHeader{
+			URL: url,
+		}, nil
+	}
+}
+
+// MakeUpdateRoleEndpoint creates an endpoint for UpdateRole
+func MakeUpdateRoleEndpoint(component Component) cs.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		var m = req.(map[string]string)
+		var err error
+
+		var role api.RoleRepresentation
+
+		if err = json.Unmarshal([]byte(m[reqBody]), &role); err != nil {
+			return nil, errorhandler.CreateBadRequestError(msg.MsgErrInvalidParam + "." + msg.Body)
+		}
+
+		if err = role.Validate(); err != nil {
+			return nil, err
+		}
+
+		return nil, component.UpdateRole(ctx, m[prmRealm], m[prmRoleID], role)
+	}
+}
+
+// MakeDeleteRoleEndpoint creates an endpoint for DeleteRole
+func MakeDeleteRoleEndpoint(component Component) cs.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		var m = req.(map[string]string)
+
+		return nil, component.DeleteRole(ctx, m[prmRealm], m[prmRoleID])
+	}
+}
+
 // MakeGetClientRolesEndpoint creates an endpoint for GetClientRoles
 func MakeGetClientRolesEndpoint(component Component) cs.Endpoint {
 	return func(ctx context.Context, req interface{}) (interface{}, error) {
@@ -591,38 +936,112 @@ func MakeCreateGroupEndpoint(component Component, logger keycloakb.Logger) cs.En
 	}
 }
 
-// MakeDeleteGroupEndpoint creates an endpoint for DeleteGroup
-func MakeDeleteGroupEndpoint(component Component) cs.Endpoint {
+// MakeCreateSubGroupEndpoint makes the endpoint to create a group as child of another group.
+func MakeCreateSubGroupEndpoint(component Component, logger keycloakb.Logger) cs.Endpoint {
 	return func(ctx context.Context, req interface{}) (interface{}, error) {
 		var m = req.(map[string]string)
+		var err error
 
-		return nil, component.DeleteGroup(ctx, m[prmRealm], m[prmGroupID])
-	}
-}
+		var group api.GroupRepresentation
 
-// MakeGetAuthorizationsEndpoint creates an endpoint for GetAuthorizations
-func MakeGetAuthorizationsEndpoint(component Component) cs.Endpoint {
-	return func(ctx context.Context, req interface{}) (interface{}, error) {
-		var m = req.(map[string]string)
+		if err = json.Unmarshal([]byte(m[reqBody]), &group); err != nil {
+			return nil, errorhandler.CreateBadRequestError(msg.MsgErrInvalidParam + "." + msg.Body)
+		}
 
-		return component.GetAuthorizations(ctx, m[prmRealm], m[prmGroupID])
+		if err = group.Validate(); err != nil {
+			return nil, err
+		}
+
+		var keycloakLocation string
+		keycloakLocation, err = component.CreateSubGroup(ctx, m[prmRealm], m[prmGroupID], group)
+
+		if err != nil {
+			return nil, err
+		}
+
+		url, err := convertLocationURL(keycloakLocation, m[reqScheme], m[reqHost])
+		if err != nil {
+			logger.Warn(ctx, "msg", "Invalid location", "location", keycloakLocation, "err", err.Error())
+		}
+
+		return LocationHeader{
+			URL: url,
+		}, nil
 	}
 }
 
-// MakeUpdateAuthorizationsEndpoint creates an endpoint for UpdateAuthorizations
-func MakeUpdateAuthorizationsEndpoint(component Component) cs.Endpoint {
+// MakeUpdateGroupEndpoint creates an endpoint for UpdateGroup
+func MakeUpdateGroupEndpoint(component Component) cs.Endpoint {
 	return func(ctx context.Context, req interface{}) (interface{}, error) {
 		var m = req.(map[string]string)
 		var err error
 
-		var authorizations api.AuthorizationsRepresentation
+		var group api.GroupRepresentation
 
-		if err = json.Unmarshal([]byte(m[reqBody]), &authorizations); err != nil {
+		if err = json.Unmarshal([]byte(m[reqBody]), &group); err != nil {
 			return nil, errorhandler.CreateBadRequestError(msg.MsgErrInvalidParam + "." + msg.Body)
 		}
 
-		return nil, component.UpdateAuthorizations(ctx, m[prmRealm], m[prmGroupID], authorizations)
-	}
+		if err = group.Validate(); err != nil {
+			return nil, err
+		}
+
+		return nil, component.UpdateGroup(ctx, m[prmRealm], m[prmGroupID], group)
+	}
+}
+
+// MakeMoveGroupEndpoint creates an endpoint for MoveGroup
+func MakeMoveGroupEndpoint(component Component) cs.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		var m = req.(map[string]string)
+		var err error
+
+		var parent api.GroupParentRepresentation
+
+		if err = json.Unmarshal([]byte(m[reqBody]), &parent); err != nil {
+			return nil, errorhandler.CreateBadRequestError(msg.MsgErrInvalidParam + "." + msg.Body)
+		}
+
+		if err = parent.Validate(); err != nil {
+			return nil, err
+		}
+
+		return nil, component.MoveGroup(ctx, m[prmRealm], m[prmGroupID], parent)
+	}
+}
+
+// MakeDeleteGroupEndpoint creates an endpoint for DeleteGroup
+func MakeDeleteGroupEndpoint(component Component) cs.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		var m = req.(map[string]string)
+
+		return nil, component.DeleteGroup(ctx, m[prmRealm], m[prmGroupID])
+	}
+}
+
+// MakeGetAuthorizationsEndpoint creates an endpoint for GetAuthorizations
+func MakeGetAuthorizationsEndpoint(component Component) cs.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		var m = req.(map[string]string)
+
+		return component.GetAuthorizations(ctx, m[prmRealm], m[prmGroupID])
+	}
+}
+
+// MakeUpdateAuthorizationsEndpoint creates an endpoint for UpdateAuthorizations
+func MakeUpdateAuthorizationsEndpoint(component Component) cs.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		var m = req.(map[string]string)
+		var err error
+
+		var authorizations api.AuthorizationsRepresentation
+
+		if err = json.Unmarshal([]byte(m[reqBody]), &authorizations); err != nil {
+			return nil, errorhandler.CreateBadRequestError(msg.MsgErrInvalidParam + "." + msg.Body)
+		}
+
+		return nil, component.UpdateAuthorizations(ctx, m[prmRealm], m[prmGroupID], authorizations)
+	}
 }
 
 // MakeGetActionsEndpoint creates an endpoint for GetActions
@@ -758,6 +1177,691 @@ func MakeLinkShadowUserEndpoint(component Component) cs.Endpoint {
 	}
 }
 
+// MakeGetFederatedIdentitiesEndpoint creates an endpoint for GetFederatedIdentities
+func MakeGetFederatedIdentitiesEndpoint(component Component) cs.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		var m = req.(map[string]string)
+
+		return component.GetFederatedIdentities(ctx, m[prmRealm], m[prmUserID])
+	}
+}
+
+// MakeDeleteFederatedIdentityEndpoint creates an endpoint for DeleteFederatedIdentity
+func MakeDeleteFederatedIdentityEndpoint(component Component) cs.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		var m = req.(map[string]string)
+
+		return nil, component.DeleteFederatedIdentity(ctx, m[prmRealm], m[prmUserID], m[prmProvider])
+	}
+}
+
+// MakeGetIdentityProvidersEndpoint creates an endpoint for GetIdentityProviders
+func MakeGetIdentityProvidersEndpoint(component Component) cs.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		var m = req.(map[string]string)
+
+		return component.GetIdentityProviders(ctx, m[prmRealm])
+	}
+}
+
+// MakeCreateIdentityProviderEndpoint creates an endpoint for CreateIdentityProvider
+func MakeCreateIdentityProviderEndpoint(component Component) cs.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		var m = req.(map[string]string)
+		var err error
+
+		var idp api.IdentityProviderRepresentation
+
+		if err = json.Unmarshal([]byte(m[reqBody]), &idp); err != nil {
+			return nil, errorhandler.CreateBadRequestError(msg.MsgErrInvalidParam + "." + msg.Body)
+		}
+
+		if err = idp.Validate(); err != nil {
+			return nil, err
+		}
+
+		if err = component.CreateIdentityProvider(ctx, m[prmRealm], idp); err != nil {
+			return nil, err
+		}
+
+		return LocationHeader{
+			URL: fmt.Sprintf("%s://%s/management/realms/%s/identity-providers/%s", m[reqScheme], m[reqHost], m[prmRealm], *idp.Alias),
+		}, nil
+	}
+}
+
+// MakeUpdateIdentityProviderEndpoint creates an endpoint for UpdateIdentityProvider
+func MakeUpdateIdentityProviderEndpoint(component Component) cs.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		var m = req.(map[string]string)
+		var err error
+
+		var idp api.IdentityProviderRepresentation
+
+		if err = json.Unmarshal([]byte(m[reqBody]), &idp); err != nil {
+			return nil, errorhandler.CreateBadRequestError(msg.MsgErrInvalidParam + "." + msg.Body)
+		}
+
+		if err = idp.Validate(); err != nil {
+			return nil, err
+		}
+
+		return nil, component.UpdateIdentityProvider(ctx, m[prmRealm], m[prmProvider], idp)
+	}
+}
+
+// MakeDisableIdentityProviderEndpoint creates an endpoint for DisableIdentityProvider
+func MakeDisableIdentityProviderEndpoint(component Component) cs.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		var m = req.(map[string]string)
+
+		return nil, component.DisableIdentityProvider(ctx, m[prmRealm], m[prmProvider])
+	}
+}
+
+// MakeGetPasswordPolicyEndpoint creates an endpoint for GetPasswordPolicy
+func MakeGetPasswordPolicyEndpoint(component Component) cs.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		var m = req.(map[string]string)
+
+		return component.GetPasswordPolicy(ctx, m[prmRealm])
+	}
+}
+
+// MakeUpdatePasswordPolicyEndpoint creates an endpoint for UpdatePasswordPolicy
+func MakeUpdatePasswordPolicyEndpoint(component Component) cs.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		var m = req.(map[string]string)
+		var err error
+
+		var policy api.PasswordPolicyRepresentation
+
+		if err = json.Unmarshal([]byte(m[reqBody]), &policy); err != nil {
+			return nil, errorhandler.CreateBadRequestError(msg.MsgErrInvalidParam + "." + msg.Body)
+		}
+
+		if err = policy.Validate(); err != nil {
+			return nil, err
+		}
+
+		return nil, component.UpdatePasswordPolicy(ctx, m[prmRealm], policy)
+	}
+}
+
+// MakeGetConfigurationRevisionsEndpoint creates an endpoint for GetConfigurationRevisions
+func MakeGetConfigurationRevisionsEndpoint(component Component) cs.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		var m = req.(map[string]string)
+
+		var configType *string
+		if value, ok := m[prmQryConfigType]; ok && value != "" {
+			configType = &value
+		}
+
+		return component.GetConfigurationRevisions(ctx, m[prmRealm], configType)
+	}
+}
+
+// MakeDiffConfigurationRevisionsEndpoint creates an endpoint for DiffConfigurationRevisions
+func MakeDiffConfigurationRevisionsEndpoint(component Component) cs.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		var m = req.(map[string]string)
+
+		var fromRevisionID, err = strconv.ParseInt(m[prmQryFromRevision], 10, 64)
+		if err != nil {
+			return nil, errorhandler.CreateBadRequestError(msg.MsgErrInvalidParam + "." + msg.RevisionID)
+		}
+		toRevisionID, err := strconv.ParseInt(m[prmQryToRevision], 10, 64)
+		if err != nil {
+			return nil, errorhandler.CreateBadRequestError(msg.MsgErrInvalidParam + "." + msg.RevisionID)
+		}
+
+		return component.DiffConfigurationRevisions(ctx, m[prmRealm], fromRevisionID, toRevisionID)
+	}
+}
+
+// MakeRollbackConfigurationEndpoint creates an endpoint for RollbackConfiguration
+func MakeRollbackConfigurationEndpoint(component Component) cs.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		var m = req.(map[string]string)
+
+		var revisionID, err = strconv.ParseInt(m[prmRevisionID], 10, 64)
+		if err != nil {
+			return nil, errorhandler.CreateBadRequestError(msg.MsgErrInvalidParam + "." + msg.RevisionID)
+		}
+
+		return nil, component.RollbackConfiguration(ctx, m[prmRealm], revisionID)
+	}
+}
+
+// MakeGetStatisticsReportSchedulesEndpoint creates an endpoint for GetStatisticsReportSchedules
+func MakeGetStatisticsReportSchedulesEndpoint(component Component) cs.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		var m = req.(map[string]string)
+
+		return component.GetStatisticsReportSchedules(ctx, m[prmRealm])
+	}
+}
+
+// MakeCreateStatisticsReportScheduleEndpoint creates an endpoint for CreateStatisticsReportSchedule
+func MakeCreateStatisticsReportScheduleEndpoint(component Component) cs.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		var m = req.(map[string]string)
+		var err error
+
+		var schedule api.StatisticsReportScheduleRepresentation
+
+		if err = json.Unmarshal([]byte(m[reqBody]), &schedule); err != nil {
+			return nil, errorhandler.CreateBadRequestError(msg.MsgErrInvalidParam + "." + msg.Body)
+		}
+
+		if err = schedule.Validate(); err != nil {
+			return nil, err
+		}
+
+		var scheduleID int64
+		scheduleID, err = component.CreateStatisticsReportSchedule(ctx, m[prmRealm], schedule)
+		if err != nil {
+			return nil, err
+		}
+
+		return LocationHeader{
+			URL: fmt.Sprintf("%s://%s/management/realms/%s/statistics-reports/%d", m[reqScheme], m[reqHost], m[prmRealm], scheduleID),
+		}, nil
+	}
+}
+
+// MakeUpdateStatisticsReportScheduleEndpoint creates an endpoint for UpdateStatisticsReportSchedule
+func MakeUpdateStatisticsReportScheduleEndpoint(component Component) cs.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		var m = req.(map[string]string)
+
+		var scheduleID, err = strconv.ParseInt(m[prmScheduleID], 10, 64)
+		if err != nil {
+			return nil, errorhandler.CreateBadRequestError(msg.MsgErrInvalidParam + "." + msg.ScheduleID)
+		}
+
+		var schedule api.StatisticsReportScheduleRepresentation
+
+		if err = json.Unmarshal([]byte(m[reqBody]), &schedule); err != nil {
+			return nil, errorhandler.CreateBadRequestError(msg.MsgErrInvalidParam + "." + msg.Body)
+		}
+
+		if err = schedule.Validate(); err != nil {
+			return nil, err
+		}
+
+		return nil, component.UpdateStatisticsReportSchedule(ctx, m[prmRealm], scheduleID, schedule)
+	}
+}
+
+// MakeDeleteStatisticsReportScheduleEndpoint creates an endpoint for DeleteStatisticsReportSchedule
+func MakeDeleteStatisticsReportScheduleEndpoint(component Component) cs.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		var m = req.(map[string]string)
+
+		var scheduleID, err = strconv.ParseInt(m[prmScheduleID], 10, 64)
+		if err != nil {
+			return nil, errorhandler.CreateBadRequestError(msg.MsgErrInvalidParam + "." + msg.ScheduleID)
+		}
+
+		return nil, component.DeleteStatisticsReportSchedule(ctx, m[prmRealm], scheduleID)
+	}
+}
+
+// MakeExportUsersEndpoint creates an endpoint for ExportUsers
+func MakeExportUsersEndpoint(component Component) cs.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		var m = req.(map[string]string)
+
+		var groupIDs []string
+		if m[prmQryGroupIDs] != "" {
+			groupIDs = strings.Split(m[prmQryGroupIDs], ",")
+		}
+
+		var columns []string
+		if m[prmQryColumns] != "" {
+			columns = strings.Split(m[prmQryColumns], ",")
+		}
+
+		var format = m[prmQryFormat]
+		if format == "" {
+			format = api.ExportFormatCSV
+		}
+
+		return component.ExportUsers(ctx, m[prmRealm], groupIDs, format, columns)
+	}
+}
+
+// MakeImportUsersEndpoint creates an endpoint for ImportUsers. Body is a CSV file or JSON lines according to the format parameter.
+func MakeImportUsersEndpoint(component BulkComponent) cs.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		var m = req.(map[string]string)
+
+		var format = m[prmQryFormat]
+		if format == "" {
+			format = api.ImportFormatJSON
+		}
+
+		var users, err = api.ParseUsersImport(format, m[reqBody])
+		if err != nil {
+			return nil, err
+		}
+
+		return component.ImportUsers(ctx, m[prmRealm], users, m[prmQryDryRun] == "true")
+	}
+}
+
+// MakeApplyBulkOperationEndpoint creates an endpoint for ApplyBulkOperation
+func MakeApplyBulkOperationEndpoint(component BulkComponent) cs.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		var m = req.(map[string]string)
+
+		var operation api.BulkUserOperationRepresentation
+
+		if err := json.Unmarshal([]byte(m[reqBody]), &operation); err != nil {
+			return nil, errorhandler.CreateBadRequestError(msg.MsgErrInvalidParam + "." + msg.Body)
+		}
+
+		if err := operation.Validate(); err != nil {
+			return nil, err
+		}
+
+		return component.ApplyBulkOperation(ctx, m[prmRealm], operation)
+	}
+}
+
+// MakeGetBulkJobEndpoint creates an endpoint for GetBulkJob
+func MakeGetBulkJobEndpoint(component BulkComponent) cs.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		var m = req.(map[string]string)
+
+		return component.GetBulkJob(ctx, m[prmRealm], m[prmJobID])
+	}
+}
+
+// MakeSearchUsersEndpoint creates an endpoint for SearchUsers
+func MakeSearchUsersEndpoint(component SearchComponent) cs.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		var m = req.(map[string]string)
+		var err error
+
+		var criteria = api.UserSearchCriteria{
+			PhoneNumber:       searchParam(m, prmQryPhoneNumber),
+			BirthDate:         searchParam(m, prmQryBirthDate),
+			IDDocumentNumber:  searchParam(m, prmQryIDDocumentNumber),
+			AccreditationType: searchParam(m, prmQryAccreditationType),
+			First:             0,
+			Max:               searchPageSize,
+		}
+		for _, key := range []string{prmQryEmail, prmQryFirstName, prmQryLastName, prmQryUserName, prmQrySearch} {
+			if m[key] != "" {
+				criteria.KeycloakParams = append(criteria.KeycloakParams, key, m[key])
+			}
+		}
+		if m[prmQryGroupIDs] != "" {
+			criteria.GroupIDs = strings.Split(m[prmQryGroupIDs], ",")
+		}
+		if m[prmQryEnabled] != "" {
+			var enabled = m[prmQryEnabled] == "true"
+			criteria.Enabled = &enabled
+		}
+		criteria.IncludeDeleted = m[prmQryIncludeDeleted] == "true"
+		if m[prmQryFirst] != "" {
+			criteria.First, _ = strconv.Atoi(m[prmQryFirst])
+		}
+		if m[prmQryMax] != "" {
+			criteria.Max, _ = strconv.Atoi(m[prmQryMax])
+		}
+
+		for key, value := range map[string]**time.Time{
+			prmQryCreatedAfter:               &criteria.CreatedAfter,
+			prmQryCreatedBefore:              &criteria.CreatedBefore,
+			prmQryAccreditationExpiresAfter:  &criteria.AccreditationExpiresAfter,
+			prmQryAccreditationExpiresBefore: &criteria.AccreditationExpiresBefore,
+		} {
+			if *value, err = searchDateParam(m, key); err != nil {
+				return nil, err
+			}
+		}
+
+		return component.SearchUsers(ctx, m[prmRealm], criteria)
+	}
+}
+
+func searchParam(m map[string]string, key string) *string {
+	if value, ok := m[key]; ok && value != "" {
+		return &value
+	}
+	return nil
+}
+
+func searchDateParam(m map[string]string, key string) (*time.Time, error) {
+	if m[key] == "" {
+		return nil, nil
+	}
+	for _, layout := range msg.SupportedDateLayouts {
+		if date, err := time.Parse(layout, m[key]); err == nil {
+			return &date, nil
+		}
+	}
+	return nil, errorhandler.CreateInvalidQueryParameterError(key)
+}
+
+// MakeIndexUsersEndpoint creates an endpoint for IndexUsers
+func MakeIndexUsersEndpoint(component SearchComponent) cs.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		var m = req.(map[string]string)
+
+		return component.IndexUsers(ctx, m[prmRealm])
+	}
+}
+
+// MakeRestoreUserEndpoint creates an endpoint for RestoreUser
+func MakeRestoreUserEndpoint(component SoftDeletionComponent) cs.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		var m = req.(map[string]string)
+
+		return nil, component.RestoreUser(ctx, m[prmRealm], m[prmUserID])
+	}
+}
+
+// MakeGetApprovalPolicyEndpoint creates an endpoint for GetApprovalPolicy
+func MakeGetApprovalPolicyEndpoint(component ApprovalComponent) cs.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		var m = req.(map[string]string)
+
+		return component.GetApprovalPolicy(ctx, m[prmRealm])
+	}
+}
+
+// MakeUpdateApprovalPolicyEndpoint creates an endpoint for UpdateApprovalPolicy
+func MakeUpdateApprovalPolicyEndpoint(component ApprovalComponent) cs.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		var m = req.(map[string]string)
+
+		var policy api.ApprovalPolicyRepresentation
+		if err := json.Unmarshal([]byte(m[reqBody]), &policy); err != nil {
+			return nil, errorhandler.CreateBadRequestError(msg.MsgErrInvalidParam + "." + msg.Body)
+		}
+
+		return nil, component.UpdateApprovalPolicy(ctx, m[prmRealm], policy)
+	}
+}
+
+// MakeGetApprovalRequestsEndpoint creates an endpoint for GetApprovalRequests
+func MakeGetApprovalRequestsEndpoint(component ApprovalComponent) cs.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		var m = req.(map[string]string)
+
+		return component.GetApprovalRequests(ctx, m[prmRealm], searchParam(m, prmQryStatus))
+	}
+}
+
+// MakeApproveRequestEndpoint creates an endpoint for ApproveRequest
+func MakeApproveRequestEndpoint(component ApprovalComponent) cs.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		var m = req.(map[string]string)
+
+		var requestID, decision, err = approvalDecisionParams(m)
+		if err != nil {
+			return nil, err
+		}
+
+		return component.ApproveRequest(ctx, m[prmRealm], requestID, decision)
+	}
+}
+
+// MakeRejectRequestEndpoint creates an endpoint for RejectRequest
+func MakeRejectRequestEndpoint(component ApprovalComponent) cs.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		var m = req.(map[string]string)
+
+		var requestID, decision, err = approvalDecisionParams(m)
+		if err != nil {
+			return nil, err
+		}
+
+		return nil, component.RejectRequest(ctx, m[prmRealm], requestID, decision)
+	}
+}
+
+// approvalDecisionParams gets the request identifier and the optional decision body
+func approvalDecisionParams(m map[string]string) (int64, api.ApprovalDecisionRepresentation, error) {
+	var decision api.ApprovalDecisionRepresentation
+
+	var requestID, err = strconv.ParseInt(m[prmApprovalID], 10, 64)
+	if err != nil {
+		return 0, decision, errorhandler.CreateBadRequestError(msg.MsgErrInvalidParam + "." + msg.ApprovalID)
+	}
+
+	if body := m[reqBody]; body != "" {
+		if err = json.Unmarshal([]byte(body), &decision); err != nil {
+			return 0, decision, errorhandler.CreateBadRequestError(msg.MsgErrInvalidParam + "." + msg.Body)
+		}
+		if err = decision.Validate(); err != nil {
+			return 0, decision, err
+		}
+	}
+
+	return requestID, decision, nil
+}
+
+// MakeAddTemporaryGroupToUserEndpoint creates an endpoint for AddTemporaryGroupToUser
+func MakeAddTemporaryGroupToUserEndpoint(component GrantsComponent) cs.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		var m = req.(map[string]string)
+
+		var expiresAt, err = expiryParam(m)
+		if err != nil {
+			return nil, err
+		}
+
+		return nil, component.AddTemporaryGroupToUser(ctx, m[prmRealm], m[prmUserID], m[prmGroupID], expiresAt)
+	}
+}
+
+// MakeSetTemporaryTrustIDGroupsToUserEndpoint creates an endpoint for SetTemporaryTrustIDGroupsToUser
+func MakeSetTemporaryTrustIDGroupsToUserEndpoint(component GrantsComponent) cs.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		var m = req.(map[string]string)
+
+		var expiresAt, err = expiryParam(m)
+		if err != nil {
+			return nil, err
+		}
+
+		var groupNames []string
+		if err = json.Unmarshal([]byte(m[reqBody]), &groupNames); err != nil {
+			return nil, errorhandler.CreateBadRequestError(msg.MsgErrInvalidParam + "." + msg.Body)
+		}
+
+		return nil, component.SetTemporaryTrustIDGroupsToUser(ctx, m[prmRealm], m[prmUserID], groupNames, expiresAt)
+	}
+}
+
+// MakeAddTemporaryClientRolesToUserEndpoint creates an endpoint for AddTemporaryClientRolesToUser
+func MakeAddTemporaryClientRolesToUserEndpoint(component GrantsComponent) cs.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		var m = req.(map[string]string)
+
+		var expiresAt, err = expiryParam(m)
+		if err != nil {
+			return nil, err
+		}
+
+		var roles []api.RoleRepresentation
+		if err = json.Unmarshal([]byte(m[reqBody]), &roles); err != nil {
+			return nil, errorhandler.CreateBadRequestError(msg.MsgErrInvalidParam + "." + msg.Body)
+		}
+
+		for _, role := range roles {
+			if err = role.Validate(); err != nil {
+				return nil, err
+			}
+		}
+
+		return nil, component.AddTemporaryClientRolesToUser(ctx, m[prmRealm], m[prmUserID], m[prmClientID], roles, expiresAt)
+	}
+}
+
+// MakeGetScheduledUserChangesEndpoint creates an endpoint for GetScheduledUserChanges
+func MakeGetScheduledUserChangesEndpoint(component ScheduledChangesComponent) cs.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		var m = req.(map[string]string)
+
+		return component.GetScheduledUserChanges(ctx, m[prmRealm], m[prmUserID])
+	}
+}
+
+// MakeScheduleUserChangeEndpoint creates an endpoint for ScheduleUserChange
+func MakeScheduleUserChangeEndpoint(component ScheduledChangesComponent) cs.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		var m = req.(map[string]string)
+		var err error
+
+		var change api.ScheduledUserChangeRepresentation
+		if err = json.Unmarshal([]byte(m[reqBody]), &change); err != nil {
+			return nil, errorhandler.CreateBadRequestError(msg.MsgErrInvalidParam + "." + msg.Body)
+		}
+
+		if err = change.Validate(); err != nil {
+			return nil, err
+		}
+
+		var changeID int64
+		changeID, err = component.ScheduleUserChange(ctx, m[prmRealm], m[prmUserID], change)
+		if err != nil {
+			return nil, err
+		}
+
+		return LocationHeader{
+			URL: fmt.Sprintf("%s://%s/management/realms/%s/users/%s/scheduled-changes/%d", m[reqScheme], m[reqHost], m[prmRealm], m[prmUserID], changeID),
+		}, nil
+	}
+}
+
+// MakeCancelScheduledUserChangeEndpoint creates an endpoint for CancelScheduledUserChange
+func MakeCancelScheduledUserChangeEndpoint(component ScheduledChangesComponent) cs.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		var m = req.(map[string]string)
+
+		var changeID, err = strconv.ParseInt(m[prmChangeID], 10, 64)
+		if err != nil {
+			return nil, errorhandler.CreateBadRequestError(msg.MsgErrInvalidParam + "." + msg.ChangeID)
+		}
+
+		return nil, component.CancelScheduledUserChange(ctx, m[prmRealm], m[prmUserID], changeID)
+	}
+}
+
+// MakeImpersonateUserEndpoint creates an endpoint for ImpersonateUser
+func MakeImpersonateUserEndpoint(component ImpersonationComponent) cs.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		var m = req.(map[string]string)
+
+		var request api.ImpersonationRequestRepresentation
+		if err := json.Unmarshal([]byte(m[reqBody]), &request); err != nil {
+			return nil, errorhandler.CreateBadRequestError(msg.MsgErrInvalidParam + "." + msg.Body)
+		}
+
+		if err := request.Validate(); err != nil {
+			return nil, err
+		}
+
+		return component.ImpersonateUser(ctx, m[prmRealm], m[prmUserID], request)
+	}
+}
+
+// MakeProvisionRealmEndpoint creates an endpoint for ProvisionRealm
+func MakeProvisionRealmEndpoint(component ProvisioningComponent) cs.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		var m = req.(map[string]string)
+
+		var template api.RealmTemplateRepresentation
+		if err := json.Unmarshal([]byte(m[reqBody]), &template); err != nil {
+			return nil, errorhandler.CreateBadRequestError(msg.MsgErrInvalidParam + "." + msg.Body)
+		}
+
+		if err := template.Validate(); err != nil {
+			return nil, err
+		}
+
+		return component.ProvisionRealm(ctx, template, m[prmQryDryRun] == "true")
+	}
+}
+
+// MakeGetUserAuthorizationsReportEndpoint creates an endpoint for GetUserAuthorizationsReport. The report is exported as
+// a CSV file with format csv.
+func MakeGetUserAuthorizationsReportEndpoint(component AuthorizationsReportComponent) cs.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		var m = req.(map[string]string)
+
+		var format, err = reportFormatParam(m)
+		if err != nil {
+			return nil, err
+		}
+
+		report, err := component.GetUserAuthorizationsReport(ctx, m[prmRealm], m[prmUserID])
+		if err != nil {
+			return nil, err
+		}
+		if format == api.ReportFormatCSV {
+			return newCSVExport(fmt.Sprintf("authorizations-%s-%s.csv", m[prmRealm], m[prmUserID]), report.CSVRecords()), nil
+		}
+		return report, nil
+	}
+}
+
+// MakeGetTargetAuthorizationsReportEndpoint creates an endpoint for GetTargetAuthorizationsReport. The target is the realm
+// or, if the groupName parameter is given, a group of the realm. The report is exported as a CSV file with format csv.
+func MakeGetTargetAuthorizationsReportEndpoint(component AuthorizationsReportComponent) cs.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		var m = req.(map[string]string)
+
+		var format, err = reportFormatParam(m)
+		if err != nil {
+			return nil, err
+		}
+
+		var targetGroupName *string
+		var filename = fmt.Sprintf("authorizations-on-%s.csv", m[prmRealm])
+		if groupName, ok := m[prmQryGroupName]; ok && groupName != "" {
+			targetGroupName = &groupName
+			filename = fmt.Sprintf("authorizations-on-%s-%s.csv", m[prmRealm], groupName)
+		}
+
+		report, err := component.GetTargetAuthorizationsReport(ctx, m[prmRealm], targetGroupName)
+		if err != nil {
+			return nil, err
+		}
+		if format == api.ReportFormatCSV {
+			return newCSVExport(filename, report.CSVRecords()), nil
+		}
+		return report, nil
+	}
+}
+
+func reportFormatParam(m map[string]string) (string, error) {
+	switch m[prmQryFormat] {
+	case "", api.ReportFormatJSON:
+		return api.ReportFormatJSON, nil
+	case api.ReportFormatCSV:
+		return api.ReportFormatCSV, nil
+	default:
+		return "", errorhandler.CreateInvalidQueryParameterError(msg.Format)
+	}
+}
+
+// expiryParam gets the expiry of a temporary grant, given as a Unix timestamp in seconds
+func expiryParam(m map[string]string) (time.Time, error) {
+	var expiresAt, err = strconv.ParseInt(m[prmQryExpiresAt], 10, 64)
+	if err != nil {
+		return time.Time{}, errorhandler.CreateBadRequestError(msg.MsgErrInvalidParam + "." + msg.ExpiresAt)
+	}
+	return time.Unix(expiresAt, 0), nil
+}
+
 // LocationHeader type
 type LocationHeader struct {
 	URL string
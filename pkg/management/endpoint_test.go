@@ -1,11 +1,13 @@
 package management
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/cloudtrust/common-service/log"
 	api "github.com/cloudtrust/keycloak-bridge/api/management"
@@ -106,6 +108,159 @@ func TestGetClientsEndpoint(t *testing.T) {
 	assert.NotNil(t, res)
 }
 
+func TestCreateClientEndpoint(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var mockManagementComponent = mock.NewManagementComponent(mockCtrl)
+
+	var e = MakeCreateClientEndpoint(mockManagementComponent, log.NewNopLogger())
+
+	var realm = "master"
+	var clientID = "my-app"
+	var location = "https://location.url/auth/admin/master/clients/1234-4567-7895"
+	var ctx = context.Background()
+	var req = make(map[string]string)
+	req[reqScheme] = "https"
+	req[reqHost] = "elca.ch"
+	req[prmRealm] = realm
+
+	t.Run("Invalid body", func(t *testing.T) {
+		req[reqBody] = "JSON"
+		var _, err = e(ctx, req)
+		assert.NotNil(t, err)
+	})
+
+	t.Run("Invalid protocol", func(t *testing.T) {
+		req[reqBody] = `{"clientId":"my-app","protocol":"cas"}`
+		var _, err = e(ctx, req)
+		assert.NotNil(t, err)
+	})
+
+	t.Run("Invalid redirect URI", func(t *testing.T) {
+		req[reqBody] = `{"clientId":"my-app","redirectUris":["not a URI"]}`
+		var _, err = e(ctx, req)
+		assert.NotNil(t, err)
+	})
+
+	req[reqBody] = `{"clientId":"my-app"}`
+
+	t.Run("Component error", func(t *testing.T) {
+		mockManagementComponent.EXPECT().CreateClient(ctx, realm, api.ClientRepresentation{ClientID: &clientID}).Return("", fmt.Errorf("Error"))
+		var _, err = e(ctx, req)
+		assert.NotNil(t, err)
+	})
+
+	t.Run("Success", func(t *testing.T) {
+		mockManagementComponent.EXPECT().CreateClient(ctx, realm, api.ClientRepresentation{ClientID: &clientID}).Return(location, nil)
+		var res, err = e(ctx, req)
+		assert.Nil(t, err)
+		assert.Equal(t, "https://elca.ch/management/master/clients/1234-4567-7895", res.(LocationHeader).URL)
+	})
+}
+
+func TestUpdateClientEndpoint(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var mockManagementComponent = mock.NewManagementComponent(mockCtrl)
+
+	var e = MakeUpdateClientEndpoint(mockManagementComponent)
+
+	var realm = "master"
+	var clientID = "1234-4567-7895"
+	var name = "My application"
+	var ctx = context.Background()
+	var req = make(map[string]string)
+	req[prmRealm] = realm
+	req[prmClientID] = clientID
+
+	t.Run("Invalid body", func(t *testing.T) {
+		req[reqBody] = "JSON"
+		var _, err = e(ctx, req)
+		assert.NotNil(t, err)
+	})
+
+	t.Run("Success", func(t *testing.T) {
+		req[reqBody] = `{"name":"My application"}`
+		mockManagementComponent.EXPECT().UpdateClient(ctx, realm, clientID, api.ClientRepresentation{Name: &name}).Return(nil)
+		var _, err = e(ctx, req)
+		assert.Nil(t, err)
+	})
+}
+
+func TestDeleteClientEndpoint(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var mockManagementComponent = mock.NewManagementComponent(mockCtrl)
+
+	var e = MakeDeleteClientEndpoint(mockManagementComponent)
+
+	var realm = "master"
+	var clientID = "1234-4567-7895"
+	var ctx = context.Background()
+	var req = map[string]string{prmRealm: realm, prmClientID: clientID}
+
+	mockManagementComponent.EXPECT().DeleteClient(ctx, realm, clientID).Return(nil)
+	var res, err = e(ctx, req)
+	assert.Nil(t, err)
+	assert.Nil(t, res)
+}
+
+func TestRegenerateClientSecretEndpoint(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var mockManagementComponent = mock.NewManagementComponent(mockCtrl)
+
+	var e = MakeRegenerateClientSecretEndpoint(mockManagementComponent)
+
+	var realm = "master"
+	var clientID = "1234-4567-7895"
+	var secret = "s3cr3t"
+	var ctx = context.Background()
+	var req = map[string]string{prmRealm: realm, prmClientID: clientID}
+
+	mockManagementComponent.EXPECT().RegenerateClientSecret(ctx, realm, clientID).Return(api.ClientSecretRepresentation{Value: &secret}, nil)
+	var res, err = e(ctx, req)
+	assert.Nil(t, err)
+	assert.Equal(t, secret, *res.(api.ClientSecretRepresentation).Value)
+}
+
+func TestUpdateClientRedirectURIsEndpoint(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var mockManagementComponent = mock.NewManagementComponent(mockCtrl)
+
+	var e = MakeUpdateClientRedirectURIsEndpoint(mockManagementComponent)
+
+	var realm = "master"
+	var clientID = "1234-4567-7895"
+	var ctx = context.Background()
+	var req = map[string]string{prmRealm: realm, prmClientID: clientID}
+
+	t.Run("Invalid body", func(t *testing.T) {
+		req[reqBody] = `{"redirectUris":[]}`
+		var _, err = e(ctx, req)
+		assert.NotNil(t, err)
+	})
+
+	t.Run("Invalid redirect URI", func(t *testing.T) {
+		req[reqBody] = `["https://app.example.com/*", "not a URI"]`
+		var _, err = e(ctx, req)
+		assert.NotNil(t, err)
+	})
+
+	t.Run("Success", func(t *testing.T) {
+		req[reqBody] = `["https://app.example.com/*"]`
+		mockManagementComponent.EXPECT().UpdateClientRedirectURIs(ctx, realm, clientID, []string{"https://app.example.com/*"}).Return(nil)
+		var _, err = e(ctx, req)
+		assert.Nil(t, err)
+	})
+}
+
 func TestGetRequiredActionsEndpoint(t *testing.T) {
 	var mockCtrl = gomock.NewController(t)
 	defer mockCtrl.Finish()
@@ -959,6 +1114,38 @@ func TestBruteForceEndpoints(t *testing.T) {
 	})
 }
 
+func TestUserSessionsEndpoints(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var mockManagementComponent = mock.NewManagementComponent(mockCtrl)
+
+	var realm = "master"
+	var userID = "123-456-789"
+	var sessionID = "456-852-785"
+	var ctx = context.Background()
+	var req = map[string]string{prmRealm: realm, prmUserID: userID, prmSessionID: sessionID}
+
+	t.Run("MakeGetUserSessionsEndpoint", func(t *testing.T) {
+		mockManagementComponent.EXPECT().GetUserSessions(ctx, realm, userID).Return([]api.UserSessionRepresentation{}, nil)
+		var res, err = MakeGetUserSessionsEndpoint(mockManagementComponent)(ctx, req)
+		assert.Nil(t, err)
+		assert.NotNil(t, res)
+	})
+
+	t.Run("MakeRevokeUserSessionEndpoint", func(t *testing.T) {
+		mockManagementComponent.EXPECT().RevokeUserSession(ctx, realm, userID, sessionID).Return(nil)
+		var _, err = MakeRevokeUserSessionEndpoint(mockManagementComponent)(ctx, req)
+		assert.Nil(t, err)
+	})
+
+	t.Run("MakeRevokeUserSessionsEndpoint", func(t *testing.T) {
+		mockManagementComponent.EXPECT().RevokeUserSessions(ctx, realm, userID).Return(nil)
+		var _, err = MakeRevokeUserSessionsEndpoint(mockManagementComponent)(ctx, req)
+		assert.Nil(t, err)
+	})
+}
+
 func TestGetRolesEndpoint(t *testing.T) {
 	var mockCtrl = gomock.NewController(t)
 	defer mockCtrl.Finish()
@@ -1005,6 +1192,124 @@ func TestGetRoleEndpoint(t *testing.T) {
 	}
 }
 
+func TestCreateRoleEndpoint(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var mockManagementComponent = mock.NewManagementComponent(mockCtrl)
+
+	var e = MakeCreateRoleEndpoint(mockManagementComponent, log.NewNopLogger())
+
+	var realm = "master"
+	var name = "role_name"
+	var location = "https://location.url/auth/admin/master/roles/role_name"
+	var ctx = context.Background()
+	var req = make(map[string]string)
+	req[reqScheme] = "https"
+	req[reqHost] = "elca.ch"
+	req[prmRealm] = realm
+
+	t.Run("Invalid body", func(t *testing.T) {
+		req[reqBody] = "JSON"
+		var _, err = e(ctx, req)
+		assert.NotNil(t, err)
+	})
+
+	t.Run("Invalid name", func(t *testing.T) {
+		req[reqBody] = `{"name":"role name?"}`
+		var _, err = e(ctx, req)
+		assert.NotNil(t, err)
+	})
+
+	req[reqBody] = `{"name":"role_name"}`
+
+	t.Run("Keycloak error", func(t *testing.T) {
+		mockManagementComponent.EXPECT().CreateRole(ctx, realm, api.RoleRepresentation{Name: &name}).Return("", fmt.Errorf("Error"))
+		var _, err = e(ctx, req)
+		assert.NotNil(t, err)
+	})
+
+	t.Run("Success", func(t *testing.T) {
+		mockManagementComponent.EXPECT().CreateRole(ctx, realm, api.RoleRepresentation{Name: &name}).Return(location, nil)
+		var res, err = e(ctx, req)
+		assert.Nil(t, err)
+		assert.Equal(t, "https://elca.ch/management/master/roles/role_name", res.(LocationHeader).URL)
+	})
+}
+
+func TestUpdateRoleEndpoint(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var mockManagementComponent = mock.NewManagementComponent(mockCtrl)
+
+	var e = MakeUpdateRoleEndpoint(mockManagementComponent)
+
+	var realm = "master"
+	var roleID = "f467ed7c-0a1d-4eee-9bb8-669c6f89c0ee"
+	var description = "new description"
+	var ctx = context.Background()
+	var req = make(map[string]string)
+	req[prmRealm] = realm
+	req[prmRoleID] = roleID
+
+	t.Run("Invalid body", func(t *testing.T) {
+		req[reqBody] = "JSON"
+		var _, err = e(ctx, req)
+		assert.NotNil(t, err)
+	})
+
+	t.Run("Success", func(t *testing.T) {
+		req[reqBody] = `{"description":"new description"}`
+		mockManagementComponent.EXPECT().UpdateRole(ctx, realm, roleID, api.RoleRepresentation{Description: &description}).Return(nil)
+		var res, err = e(ctx, req)
+		assert.Nil(t, err)
+		assert.Nil(t, res)
+	})
+}
+
+func TestRoleDeletionEndpoints(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var mockManagementComponent = mock.NewManagementComponent(mockCtrl)
+
+	var realm = "master"
+	var userID = "123-456-789"
+	var clientID = "789-789-741"
+	var roleID = "456-852-785"
+	var ctx = context.Background()
+	var req = map[string]string{prmRealm: realm, prmUserID: userID, prmClientID: clientID, prmRoleID: roleID}
+
+	t.Run("DeleteRole", func(t *testing.T) {
+		mockManagementComponent.EXPECT().DeleteRole(ctx, realm, roleID).Return(nil)
+		var res, err = MakeDeleteRoleEndpoint(mockManagementComponent)(ctx, req)
+		assert.Nil(t, err)
+		assert.Nil(t, res)
+	})
+
+	t.Run("AddRoleToUser", func(t *testing.T) {
+		mockManagementComponent.EXPECT().AddRoleToUser(ctx, realm, userID, roleID).Return(nil)
+		var res, err = MakeAddRoleToUserEndpoint(mockManagementComponent)(ctx, req)
+		assert.Nil(t, err)
+		assert.Nil(t, res)
+	})
+
+	t.Run("DeleteRoleForUser", func(t *testing.T) {
+		mockManagementComponent.EXPECT().DeleteRoleForUser(ctx, realm, userID, roleID).Return(nil)
+		var res, err = MakeDeleteRoleForUserEndpoint(mockManagementComponent)(ctx, req)
+		assert.Nil(t, err)
+		assert.Nil(t, res)
+	})
+
+	t.Run("DeleteClientRoleForUser", func(t *testing.T) {
+		mockManagementComponent.EXPECT().DeleteClientRoleForUser(ctx, realm, userID, clientID, roleID).Return(nil)
+		var res, err = MakeDeleteClientRoleForUserEndpoint(mockManagementComponent)(ctx, req)
+		assert.Nil(t, err)
+		assert.Nil(t, res)
+	})
+}
+
 func TestGetGroupsEndpoint(t *testing.T) {
 	var mockCtrl = gomock.NewController(t)
 	defer mockCtrl.Finish()
@@ -1082,71 +1387,196 @@ func TestCreateGroupEndpoint(t *testing.T) {
 	}
 }
 
-func TestDeleteGroupEndpoint(t *testing.T) {
+func TestCreateSubGroupEndpoint(t *testing.T) {
 	var mockCtrl = gomock.NewController(t)
 	defer mockCtrl.Finish()
 
 	var mockManagementComponent = mock.NewManagementComponent(mockCtrl)
 
-	var e = MakeDeleteGroupEndpoint(mockManagementComponent)
+	var e = MakeCreateSubGroupEndpoint(mockManagementComponent, log.NewNopLogger())
 
 	var realm = "master"
-	var groupID = "1234-452-4578"
+	var parentID = "1234-452-4578"
+	var location = "https://location.url/auth/admin/master/groups/123456"
 	var ctx = context.Background()
+	var name = "name"
 	var req = make(map[string]string)
+	req[reqScheme] = "https"
+	req[reqHost] = "elca.ch"
 	req[prmRealm] = realm
-	req[prmGroupID] = groupID
-
-	mockManagementComponent.EXPECT().DeleteGroup(ctx, realm, groupID).Return(nil).Times(1)
-	var res, err = e(ctx, req)
-	assert.Nil(t, err)
-	assert.Nil(t, res)
-}
+	req[prmGroupID] = parentID
 
-func TestGetAuthorizationsEndpoint(t *testing.T) {
-	var mockCtrl = gomock.NewController(t)
-	defer mockCtrl.Finish()
+	t.Run("Invalid body", func(t *testing.T) {
+		req[reqBody] = "JSON"
+		var _, err = e(ctx, req)
+		assert.NotNil(t, err)
+	})
 
-	var mockManagementComponent = mock.NewManagementComponent(mockCtrl)
+	t.Run("Invalid name", func(t *testing.T) {
+		var invalidName = "my group!"
+		groupJSON, _ := json.Marshal(api.GroupRepresentation{Name: &invalidName})
+		req[reqBody] = string(groupJSON)
+		var _, err = e(ctx, req)
+		assert.NotNil(t, err)
+	})
 
-	var e = MakeGetAuthorizationsEndpoint(mockManagementComponent)
+	groupJSON, _ := json.Marshal(api.GroupRepresentation{Name: &name})
+	req[reqBody] = string(groupJSON)
 
-	// No error
-	{
-		var realm = "master"
-		var groupID = "123456"
-		var ctx = context.Background()
-		var req = make(map[string]string)
-		req[prmRealm] = realm
-		req[prmGroupID] = groupID
+	t.Run("Keycloak error", func(t *testing.T) {
+		mockManagementComponent.EXPECT().CreateSubGroup(ctx, realm, parentID, api.GroupRepresentation{Name: &name}).Return("", fmt.Errorf("Error"))
+		var _, err = e(ctx, req)
+		assert.NotNil(t, err)
+	})
 
-		mockManagementComponent.EXPECT().GetAuthorizations(ctx, realm, groupID).Return(api.AuthorizationsRepresentation{}, nil).Times(1)
+	t.Run("Success", func(t *testing.T) {
+		mockManagementComponent.EXPECT().CreateSubGroup(ctx, realm, parentID, api.GroupRepresentation{Name: &name}).Return(location, nil)
 		var res, err = e(ctx, req)
 		assert.Nil(t, err)
-		assert.NotNil(t, res)
-	}
+		assert.Equal(t, "https://elca.ch/management/master/groups/123456", res.(LocationHeader).URL)
+	})
 }
 
-func TestGetClientRolesEndpoint(t *testing.T) {
+func TestUpdateGroupEndpoint(t *testing.T) {
 	var mockCtrl = gomock.NewController(t)
 	defer mockCtrl.Finish()
 
 	var mockManagementComponent = mock.NewManagementComponent(mockCtrl)
 
-	var e = MakeGetClientRolesEndpoint(mockManagementComponent)
-
-	// No error
-	{
-		var realm = "master"
-		var clientID = "123456"
-		var ctx = context.Background()
-		var req = make(map[string]string)
-		req[prmRealm] = realm
-		req[prmClientID] = clientID
+	var e = MakeUpdateGroupEndpoint(mockManagementComponent)
 
-		mockManagementComponent.EXPECT().GetClientRoles(ctx, realm, clientID).Return([]api.RoleRepresentation{}, nil).Times(1)
-		var res, err = e(ctx, req)
-		assert.Nil(t, err)
+	var realm = "master"
+	var groupID = "1234-452-4578"
+	var name = "new_name"
+	var ctx = context.Background()
+	var req = make(map[string]string)
+	req[prmRealm] = realm
+	req[prmGroupID] = groupID
+
+	t.Run("Invalid body", func(t *testing.T) {
+		req[reqBody] = "JSON"
+		var _, err = e(ctx, req)
+		assert.NotNil(t, err)
+	})
+
+	t.Run("Success", func(t *testing.T) {
+		groupJSON, _ := json.Marshal(api.GroupRepresentation{Name: &name})
+		req[reqBody] = string(groupJSON)
+		mockManagementComponent.EXPECT().UpdateGroup(ctx, realm, groupID, api.GroupRepresentation{Name: &name}).Return(nil)
+		var res, err = e(ctx, req)
+		assert.Nil(t, err)
+		assert.Nil(t, res)
+	})
+}
+
+func TestMoveGroupEndpoint(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var mockManagementComponent = mock.NewManagementComponent(mockCtrl)
+
+	var e = MakeMoveGroupEndpoint(mockManagementComponent)
+
+	var realm = "master"
+	var groupID = "1234-452-4578"
+	var parentID = "f467ed7c-0a1d-4eee-9bb8-669c6f89c0ee"
+	var ctx = context.Background()
+	var req = make(map[string]string)
+	req[prmRealm] = realm
+	req[prmGroupID] = groupID
+
+	t.Run("Invalid body", func(t *testing.T) {
+		req[reqBody] = "JSON"
+		var _, err = e(ctx, req)
+		assert.NotNil(t, err)
+	})
+
+	t.Run("Invalid parent", func(t *testing.T) {
+		req[reqBody] = `{"parentId":"../groups"}`
+		var _, err = e(ctx, req)
+		assert.NotNil(t, err)
+	})
+
+	t.Run("Move to top-level", func(t *testing.T) {
+		req[reqBody] = `{}`
+		mockManagementComponent.EXPECT().MoveGroup(ctx, realm, groupID, api.GroupParentRepresentation{}).Return(nil)
+		var _, err = e(ctx, req)
+		assert.Nil(t, err)
+	})
+
+	t.Run("Move under a group", func(t *testing.T) {
+		req[reqBody] = `{"parentId":"` + parentID + `"}`
+		mockManagementComponent.EXPECT().MoveGroup(ctx, realm, groupID, api.GroupParentRepresentation{ParentID: &parentID}).Return(nil)
+		var _, err = e(ctx, req)
+		assert.Nil(t, err)
+	})
+}
+
+func TestDeleteGroupEndpoint(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var mockManagementComponent = mock.NewManagementComponent(mockCtrl)
+
+	var e = MakeDeleteGroupEndpoint(mockManagementComponent)
+
+	var realm = "master"
+	var groupID = "1234-452-4578"
+	var ctx = context.Background()
+	var req = make(map[string]string)
+	req[prmRealm] = realm
+	req[prmGroupID] = groupID
+
+	mockManagementComponent.EXPECT().DeleteGroup(ctx, realm, groupID).Return(nil).Times(1)
+	var res, err = e(ctx, req)
+	assert.Nil(t, err)
+	assert.Nil(t, res)
+}
+
+func TestGetAuthorizationsEndpoint(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var mockManagementComponent = mock.NewManagementComponent(mockCtrl)
+
+	var e = MakeGetAuthorizationsEndpoint(mockManagementComponent)
+
+	// No error
+	{
+		var realm = "master"
+		var groupID = "123456"
+		var ctx = context.Background()
+		var req = make(map[string]string)
+		req[prmRealm] = realm
+		req[prmGroupID] = groupID
+
+		mockManagementComponent.EXPECT().GetAuthorizations(ctx, realm, groupID).Return(api.AuthorizationsRepresentation{}, nil).Times(1)
+		var res, err = e(ctx, req)
+		assert.Nil(t, err)
+		assert.NotNil(t, res)
+	}
+}
+
+func TestGetClientRolesEndpoint(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var mockManagementComponent = mock.NewManagementComponent(mockCtrl)
+
+	var e = MakeGetClientRolesEndpoint(mockManagementComponent)
+
+	// No error
+	{
+		var realm = "master"
+		var clientID = "123456"
+		var ctx = context.Background()
+		var req = make(map[string]string)
+		req[prmRealm] = realm
+		req[prmClientID] = clientID
+
+		mockManagementComponent.EXPECT().GetClientRoles(ctx, realm, clientID).Return([]api.RoleRepresentation{}, nil).Times(1)
+		var res, err = e(ctx, req)
+		assert.Nil(t, err)
 		assert.NotNil(t, res)
 	}
 }
@@ -1439,6 +1869,239 @@ func TestLinkShadowUserEndpoint(t *testing.T) {
 	})
 }
 
+func TestFederatedIdentitiesEndpoints(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var mockManagementComponent = mock.NewManagementComponent(mockCtrl)
+
+	var realm = "master"
+	var userID = "f467ed7c-0a1d-4eee-9bb8-669c6f89c0ee"
+	var provider = "partner-idp"
+	var ctx = context.Background()
+	var req = map[string]string{prmRealm: realm, prmUserID: userID, prmProvider: provider}
+
+	t.Run("Get federated identities", func(t *testing.T) {
+		mockManagementComponent.EXPECT().GetFederatedIdentities(ctx, realm, userID).Return([]api.FederatedIdentityRepresentation{}, nil)
+		var res, err = MakeGetFederatedIdentitiesEndpoint(mockManagementComponent)(ctx, req)
+		assert.Nil(t, err)
+		assert.NotNil(t, res)
+	})
+
+	t.Run("Delete federated identity", func(t *testing.T) {
+		mockManagementComponent.EXPECT().DeleteFederatedIdentity(ctx, realm, userID, provider).Return(nil)
+		var res, err = MakeDeleteFederatedIdentityEndpoint(mockManagementComponent)(ctx, req)
+		assert.Nil(t, err)
+		assert.Nil(t, res)
+	})
+}
+
+func TestIdentityProvidersEndpoints(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var mockManagementComponent = mock.NewManagementComponent(mockCtrl)
+
+	var realm = "master"
+	var alias = "partner-idp"
+	var providerID = "oidc"
+	var ctx = context.Background()
+
+	t.Run("Get identity providers", func(t *testing.T) {
+		mockManagementComponent.EXPECT().GetIdentityProviders(ctx, realm).Return([]api.IdentityProviderRepresentation{}, nil)
+		var res, err = MakeGetIdentityProvidersEndpoint(mockManagementComponent)(ctx, map[string]string{prmRealm: realm})
+		assert.Nil(t, err)
+		assert.NotNil(t, res)
+	})
+
+	t.Run("Create identity provider", func(t *testing.T) {
+		var e = MakeCreateIdentityProviderEndpoint(mockManagementComponent)
+		var req = map[string]string{prmRealm: realm, reqScheme: "https", reqHost: "elca.ch"}
+
+		req[reqBody] = "JSON"
+		var _, err = e(ctx, req)
+		assert.NotNil(t, err)
+
+		req[reqBody] = `{"alias":"partner idp","providerId":"oidc"}`
+		_, err = e(ctx, req)
+		assert.NotNil(t, err)
+
+		req[reqBody] = `{"alias":"partner-idp","providerId":"oidc"}`
+		mockManagementComponent.EXPECT().CreateIdentityProvider(ctx, realm, api.IdentityProviderRepresentation{Alias: &alias, ProviderID: &providerID}).Return(fmt.Errorf("error"))
+		_, err = e(ctx, req)
+		assert.NotNil(t, err)
+
+		mockManagementComponent.EXPECT().CreateIdentityProvider(ctx, realm, api.IdentityProviderRepresentation{Alias: &alias, ProviderID: &providerID}).Return(nil)
+		res, err := e(ctx, req)
+		assert.Nil(t, err)
+		assert.Equal(t, "https://elca.ch/management/realms/master/identity-providers/partner-idp", res.(LocationHeader).URL)
+	})
+
+	t.Run("Update identity provider", func(t *testing.T) {
+		var e = MakeUpdateIdentityProviderEndpoint(mockManagementComponent)
+		var displayName = "Partner"
+		var req = map[string]string{prmRealm: realm, prmProvider: alias}
+
+		req[reqBody] = "JSON"
+		var _, err = e(ctx, req)
+		assert.NotNil(t, err)
+
+		req[reqBody] = `{"displayName":"Partner"}`
+		mockManagementComponent.EXPECT().UpdateIdentityProvider(ctx, realm, alias, api.IdentityProviderRepresentation{DisplayName: &displayName}).Return(nil)
+		_, err = e(ctx, req)
+		assert.Nil(t, err)
+	})
+
+	t.Run("Disable identity provider", func(t *testing.T) {
+		mockManagementComponent.EXPECT().DisableIdentityProvider(ctx, realm, alias).Return(nil)
+		var _, err = MakeDisableIdentityProviderEndpoint(mockManagementComponent)(ctx, map[string]string{prmRealm: realm, prmProvider: alias})
+		assert.Nil(t, err)
+	})
+}
+
+func TestPasswordPolicyEndpoints(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var mockManagementComponent = mock.NewManagementComponent(mockCtrl)
+
+	var realm = "master"
+	var length = 12
+	var ctx = context.Background()
+
+	t.Run("Get password policy", func(t *testing.T) {
+		mockManagementComponent.EXPECT().GetPasswordPolicy(ctx, realm).Return(api.PasswordPolicyRepresentation{Length: &length}, nil)
+		var res, err = MakeGetPasswordPolicyEndpoint(mockManagementComponent)(ctx, map[string]string{prmRealm: realm})
+		assert.Nil(t, err)
+		assert.Equal(t, api.PasswordPolicyRepresentation{Length: &length}, res)
+	})
+
+	t.Run("Update password policy", func(t *testing.T) {
+		var e = MakeUpdatePasswordPolicyEndpoint(mockManagementComponent)
+		var req = map[string]string{prmRealm: realm}
+
+		req[reqBody] = "JSON"
+		var _, err = e(ctx, req)
+		assert.NotNil(t, err)
+
+		req[reqBody] = `{"length":-1}`
+		_, err = e(ctx, req)
+		assert.NotNil(t, err)
+
+		req[reqBody] = `{"length":12}`
+		mockManagementComponent.EXPECT().UpdatePasswordPolicy(ctx, realm, api.PasswordPolicyRepresentation{Length: &length}).Return(nil)
+		_, err = e(ctx, req)
+		assert.Nil(t, err)
+	})
+}
+
+func TestCredentialManagementEndpoints(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var mockManagementComponent = mock.NewManagementComponent(mockCtrl)
+
+	var realm = "master"
+	var userID = "123-456-789"
+	var credentialID = "987-654-321"
+	var ctx = context.Background()
+
+	t.Run("Update label of credential", func(t *testing.T) {
+		var e = MakeUpdateLabelCredentialForUserEndpoint(mockManagementComponent)
+		var req = map[string]string{prmRealm: realm, prmUserID: userID, prmCredentialID: credentialID}
+
+		req[reqBody] = "JSON"
+		var _, err = e(ctx, req)
+		assert.NotNil(t, err)
+
+		req[reqBody] = `{}`
+		_, err = e(ctx, req)
+		assert.NotNil(t, err)
+
+		req[reqBody] = `{"userLabel":"My phone"}`
+		mockManagementComponent.EXPECT().UpdateLabelCredentialForUser(ctx, realm, userID, credentialID, "My phone").Return(nil)
+		_, err = e(ctx, req)
+		assert.Nil(t, err)
+	})
+
+	t.Run("Move credential", func(t *testing.T) {
+		var req = map[string]string{prmRealm: realm, prmUserID: userID, prmCredentialID: credentialID, prmPrevCredentialID: "null"}
+		mockManagementComponent.EXPECT().MoveCredentialForUser(ctx, realm, userID, credentialID, "null").Return(nil)
+		var _, err = MakeMoveCredentialForUserEndpoint(mockManagementComponent)(ctx, req)
+		assert.Nil(t, err)
+	})
+
+	t.Run("Get credential registrators", func(t *testing.T) {
+		var req = map[string]string{prmRealm: realm, prmUserID: userID}
+		mockManagementComponent.EXPECT().GetCredentialRegistratorsForUser(ctx, realm, userID).Return([]string{"otp"}, nil)
+		var res, err = MakeGetCredentialRegistratorsForUserEndpoint(mockManagementComponent)(ctx, req)
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"otp"}, res)
+	})
+
+	t.Run("Require re-enrollment", func(t *testing.T) {
+		var e = MakeRequireReEnrollmentEndpoint(mockManagementComponent)
+		var req = map[string]string{prmRealm: realm, prmUserID: userID}
+
+		req[reqBody] = "JSON"
+		var _, err = e(ctx, req)
+		assert.NotNil(t, err)
+
+		req[reqBody] = `{"type":"unknown"}`
+		_, err = e(ctx, req)
+		assert.NotNil(t, err)
+
+		req[reqBody] = `{"type":"otp"}`
+		mockManagementComponent.EXPECT().RequireReEnrollment(ctx, realm, userID, "otp").Return(nil)
+		_, err = e(ctx, req)
+		assert.Nil(t, err)
+	})
+}
+
+func TestConfigurationRevisionsEndpoints(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var mockManagementComponent = mock.NewManagementComponent(mockCtrl)
+
+	var realm = "master"
+	var ctx = context.Background()
+
+	t.Run("Get revisions", func(t *testing.T) {
+		var configType = "configuration"
+		var revisions = []api.ConfigurationRevisionRepresentation{{Type: &configType}}
+		mockManagementComponent.EXPECT().GetConfigurationRevisions(ctx, realm, &configType).Return(revisions, nil)
+		var res, err = MakeGetConfigurationRevisionsEndpoint(mockManagementComponent)(ctx, map[string]string{prmRealm: realm, prmQryConfigType: configType})
+		assert.Nil(t, err)
+		assert.Equal(t, revisions, res)
+	})
+
+	t.Run("Diff revisions", func(t *testing.T) {
+		var e = MakeDiffConfigurationRevisionsEndpoint(mockManagementComponent)
+
+		var _, err = e(ctx, map[string]string{prmRealm: realm, prmQryToRevision: "2"})
+		assert.NotNil(t, err)
+
+		_, err = e(ctx, map[string]string{prmRealm: realm, prmQryFromRevision: "1"})
+		assert.NotNil(t, err)
+
+		mockManagementComponent.EXPECT().DiffConfigurationRevisions(ctx, realm, int64(1), int64(2)).Return([]api.ConfigurationDiffRepresentation{}, nil)
+		_, err = e(ctx, map[string]string{prmRealm: realm, prmQryFromRevision: "1", prmQryToRevision: "2"})
+		assert.Nil(t, err)
+	})
+
+	t.Run("Rollback", func(t *testing.T) {
+		var e = MakeRollbackConfigurationEndpoint(mockManagementComponent)
+
+		var _, err = e(ctx, map[string]string{prmRealm: realm})
+		assert.NotNil(t, err)
+
+		mockManagementComponent.EXPECT().RollbackConfiguration(ctx, realm, int64(3)).Return(nil)
+		_, err = e(ctx, map[string]string{prmRealm: realm, prmRevisionID: "3"})
+		assert.Nil(t, err)
+	})
+}
+
 func TestConvertLocationUrl(t *testing.T) {
 
 	res, err := convertLocationURL("http://localhost:8080/auth/realms/master/api/admin/realms/dep/users/1522-4245245-4542545/credentials", "https", "ct-bridge.services.com")
@@ -1454,3 +2117,583 @@ func TestConvertLocationUrl(t *testing.T) {
 	assert.Equal(t, ConvertLocationError{Location: "http://localhost:8080/toto"}, err)
 
 }
+
+func TestStatisticsReportScheduleEndpoints(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var mockManagementComponent = mock.NewManagementComponent(mockCtrl)
+
+	var ctx = context.Background()
+	var realmName = "master"
+	var frequency = "weekly"
+	var day = 1
+	var hour = 8
+	var schedule = api.StatisticsReportScheduleRepresentation{Frequency: &frequency, Day: &day, Hour: &hour, Recipients: []string{"admin@example.com"}}
+	var scheduleJSON, _ = json.Marshal(schedule)
+	var expectedError = errors.New("component error")
+
+	t.Run("Get schedules", func(t *testing.T) {
+		var e = MakeGetStatisticsReportSchedulesEndpoint(mockManagementComponent)
+		var req = map[string]string{prmRealm: realmName}
+
+		mockManagementComponent.EXPECT().GetStatisticsReportSchedules(ctx, realmName).Return([]api.StatisticsReportScheduleRepresentation{schedule}, nil)
+		var res, err = e(ctx, req)
+		assert.Nil(t, err)
+		assert.Len(t, res, 1)
+	})
+
+	t.Run("Create schedule", func(t *testing.T) {
+		var e = MakeCreateStatisticsReportScheduleEndpoint(mockManagementComponent)
+		var req = map[string]string{prmRealm: realmName, reqScheme: "https", reqHost: "elca.ch", reqBody: string(scheduleJSON)}
+
+		mockManagementComponent.EXPECT().CreateStatisticsReportSchedule(ctx, realmName, schedule).Return(int64(4), nil)
+		var res, err = e(ctx, req)
+		assert.Nil(t, err)
+		assert.Equal(t, "https://elca.ch/management/realms/master/statistics-reports/4", res.(LocationHeader).URL)
+
+		mockManagementComponent.EXPECT().CreateStatisticsReportSchedule(ctx, realmName, schedule).Return(int64(0), expectedError)
+		_, err = e(ctx, req)
+		assert.Equal(t, expectedError, err)
+
+		req[reqBody] = "{"
+		_, err = e(ctx, req)
+		assert.NotNil(t, err)
+
+		req[reqBody] = `{"frequency":"weekly","hour":8,"recipients":["admin@example.com"]}`
+		_, err = e(ctx, req)
+		assert.NotNil(t, err)
+	})
+
+	t.Run("Update schedule", func(t *testing.T) {
+		var e = MakeUpdateStatisticsReportScheduleEndpoint(mockManagementComponent)
+		var req = map[string]string{prmRealm: realmName, prmScheduleID: "4", reqBody: string(scheduleJSON)}
+
+		mockManagementComponent.EXPECT().UpdateStatisticsReportSchedule(ctx, realmName, int64(4), schedule).Return(nil)
+		var _, err = e(ctx, req)
+		assert.Nil(t, err)
+
+		req[reqBody] = `{"frequency":"hourly","hour":8,"recipients":["admin@example.com"]}`
+		_, err = e(ctx, req)
+		assert.NotNil(t, err)
+
+		req[prmScheduleID] = "99999999999999999999"
+		_, err = e(ctx, req)
+		assert.NotNil(t, err)
+	})
+
+	t.Run("Delete schedule", func(t *testing.T) {
+		var e = MakeDeleteStatisticsReportScheduleEndpoint(mockManagementComponent)
+		var req = map[string]string{prmRealm: realmName, prmScheduleID: "4"}
+
+		mockManagementComponent.EXPECT().DeleteStatisticsReportSchedule(ctx, realmName, int64(4)).Return(expectedError)
+		var _, err = e(ctx, req)
+		assert.Equal(t, expectedError, err)
+	})
+}
+
+func TestBulkEndpoints(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var mockBulkComponent = mock.NewBulkComponent(mockCtrl)
+
+	var ctx = context.Background()
+	var realmName = "master"
+	var jobID = "job-id"
+	var username = "jdoe"
+	var groups = []string{"f467ed7c-0a1d-4eee-9bb8-669c6f89c0ee"}
+	var users = []api.UserRepresentation{{Username: &username, Groups: &groups}}
+	var job = api.BulkJobRepresentation{ID: jobID, Status: "running"}
+
+	t.Run("Import users from JSON lines", func(t *testing.T) {
+		var e = MakeImportUsersEndpoint(mockBulkComponent)
+		var req = map[string]string{prmRealm: realmName, reqBody: `{"username":"jdoe","groups":["f467ed7c-0a1d-4eee-9bb8-669c6f89c0ee"]}` + "\n"}
+
+		mockBulkComponent.EXPECT().ImportUsers(ctx, realmName, users, false).Return(job, nil)
+		var res, err = e(ctx, req)
+		assert.Nil(t, err)
+		assert.Equal(t, job, res)
+	})
+
+	t.Run("Import users from CSV with dry-run", func(t *testing.T) {
+		var e = MakeImportUsersEndpoint(mockBulkComponent)
+		var req = map[string]string{prmRealm: realmName, prmQryFormat: "csv", prmQryDryRun: "true", reqBody: "username,groups\njdoe,f467ed7c-0a1d-4eee-9bb8-669c6f89c0ee\n"}
+
+		mockBulkComponent.EXPECT().ImportUsers(ctx, realmName, users, true).Return(job, nil)
+		var _, err = e(ctx, req)
+		assert.Nil(t, err)
+	})
+
+	t.Run("Invalid import", func(t *testing.T) {
+		var e = MakeImportUsersEndpoint(mockBulkComponent)
+		var req = map[string]string{prmRealm: realmName, prmQryFormat: "csv", reqBody: "unknown\nvalue\n"}
+
+		var _, err = e(ctx, req)
+		assert.NotNil(t, err)
+	})
+
+	t.Run("Apply bulk operation", func(t *testing.T) {
+		var e = MakeApplyBulkOperationEndpoint(mockBulkComponent)
+		var operation = api.BulkOperationUnlockUser
+		var userIDs = []string{"f467ed7c-0a1d-4eee-9bb8-669c6f89c0ee"}
+		var req = map[string]string{prmRealm: realmName, reqBody: `{"operation":"UnlockUser","userIds":["f467ed7c-0a1d-4eee-9bb8-669c6f89c0ee"]}`}
+
+		mockBulkComponent.EXPECT().ApplyBulkOperation(ctx, realmName, api.BulkUserOperationRepresentation{Operation: &operation, UserIDs: &userIDs}).Return(job, nil)
+		var res, err = e(ctx, req)
+		assert.Nil(t, err)
+		assert.Equal(t, job, res)
+
+		req[reqBody] = `{"operation":"UnlockUser"}`
+		_, err = e(ctx, req)
+		assert.NotNil(t, err)
+
+		req[reqBody] = "{"
+		_, err = e(ctx, req)
+		assert.NotNil(t, err)
+	})
+
+	t.Run("Get job", func(t *testing.T) {
+		var e = MakeGetBulkJobEndpoint(mockBulkComponent)
+		var req = map[string]string{prmRealm: realmName, prmJobID: jobID}
+
+		mockBulkComponent.EXPECT().GetBulkJob(ctx, realmName, jobID).Return(job, nil)
+		var res, err = e(ctx, req)
+		assert.Nil(t, err)
+		assert.Equal(t, job, res)
+	})
+}
+
+func TestExportUsersEndpoint(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var mockManagementComponent = mock.NewManagementComponent(mockCtrl)
+
+	var e = MakeExportUsersEndpoint(mockManagementComponent)
+	var ctx = context.Background()
+	var realm = "master"
+	var export = UsersExport{Filename: "users-master.csv", ContentType: "text/csv"}
+
+	t.Run("Default format and columns", func(t *testing.T) {
+		var req = map[string]string{prmRealm: realm}
+		mockManagementComponent.EXPECT().ExportUsers(ctx, realm, nil, api.ExportFormatCSV, nil).Return(export, nil)
+		var res, err = e(ctx, req)
+		assert.Nil(t, err)
+		assert.NotNil(t, res)
+	})
+
+	t.Run("With groups, format and columns", func(t *testing.T) {
+		var req = map[string]string{
+			prmRealm:       realm,
+			prmQryGroupIDs: "grp1,grp2",
+			prmQryFormat:   api.ExportFormatNDJSON,
+			prmQryColumns:  "id,username,birthLocation",
+		}
+		mockManagementComponent.EXPECT().ExportUsers(ctx, realm, []string{"grp1", "grp2"}, api.ExportFormatNDJSON, []string{"id", "username", "birthLocation"}).Return(export, nil)
+		var res, err = e(ctx, req)
+		assert.Nil(t, err)
+		assert.NotNil(t, res)
+	})
+}
+
+func TestSearchEndpoints(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var mockSearchComponent = mock.NewSearchComponent(mockCtrl)
+
+	var ctx = context.Background()
+	var realm = "master"
+
+	t.Run("Search users", func(t *testing.T) {
+		var e = MakeSearchUsersEndpoint(mockSearchComponent)
+		var req = map[string]string{
+			prmRealm:                realm,
+			prmQryEmail:             "a@b.ch",
+			prmQryGroupIDs:          "grp1,grp2",
+			prmQryIDDocumentNumber:  "AB123456",
+			prmQryEnabled:           "false",
+			prmQryCreatedAfter:      "01.02.2020",
+			prmQryAccreditationType: "SHADOW",
+			prmQryFirst:             "20",
+			prmQryMax:               "10",
+			prmQryIncludeDeleted:    "true",
+		}
+		mockSearchComponent.EXPECT().SearchUsers(ctx, realm, gomock.Any()).DoAndReturn(func(ctx context.Context, realm string, criteria api.UserSearchCriteria) (api.UsersPageRepresentation, error) {
+			assert.Equal(t, []string{"grp1", "grp2"}, criteria.GroupIDs)
+			assert.Equal(t, []string{prmQryEmail, "a@b.ch"}, criteria.KeycloakParams)
+			assert.Equal(t, "AB123456", *criteria.IDDocumentNumber)
+			assert.False(t, *criteria.Enabled)
+			assert.Equal(t, "2020-02-01", criteria.CreatedAfter.Format("2006-01-02"))
+			assert.Nil(t, criteria.CreatedBefore)
+			assert.Equal(t, "SHADOW", *criteria.AccreditationType)
+			assert.Equal(t, 20, criteria.First)
+			assert.Equal(t, 10, criteria.Max)
+			assert.True(t, criteria.IncludeDeleted)
+			return api.UsersPageRepresentation{}, nil
+		})
+		var _, err = e(ctx, req)
+		assert.Nil(t, err)
+	})
+
+	t.Run("Search users with invalid date", func(t *testing.T) {
+		var e = MakeSearchUsersEndpoint(mockSearchComponent)
+		var _, err = e(ctx, map[string]string{prmRealm: realm, prmQryCreatedBefore: "31.02.2020"})
+		assert.NotNil(t, err)
+	})
+
+	t.Run("Index users", func(t *testing.T) {
+		var e = MakeIndexUsersEndpoint(mockSearchComponent)
+		var job = api.BulkJobRepresentation{ID: "job-id"}
+		mockSearchComponent.EXPECT().IndexUsers(ctx, realm).Return(job, nil)
+		var res, err = e(ctx, map[string]string{prmRealm: realm})
+		assert.Nil(t, err)
+		assert.Equal(t, job, res)
+	})
+}
+
+func TestRestoreUserEndpoint(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var mockSoftDeletionComponent = mock.NewSoftDeletionComponent(mockCtrl)
+
+	var e = MakeRestoreUserEndpoint(mockSoftDeletionComponent)
+
+	var ctx = context.Background()
+	var realm = "master"
+	var userID = "1234-452-4578"
+
+	mockSoftDeletionComponent.EXPECT().RestoreUser(ctx, realm, userID).Return(nil)
+	var res, err = e(ctx, map[string]string{prmRealm: realm, prmUserID: userID})
+	assert.Nil(t, err)
+	assert.Nil(t, res)
+}
+
+func TestApprovalEndpoints(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var mockApprovalComponent = mock.NewApprovalComponent(mockCtrl)
+
+	var ctx = context.Background()
+	var realm = "master"
+	var status = "PENDING"
+
+	t.Run("GetApprovalPolicy", func(t *testing.T) {
+		var policy = api.ApprovalPolicyRepresentation{Actions: []string{"MGMT_DeleteUser"}}
+		mockApprovalComponent.EXPECT().GetApprovalPolicy(ctx, realm).Return(policy, nil)
+		var res, err = MakeGetApprovalPolicyEndpoint(mockApprovalComponent)(ctx, map[string]string{prmRealm: realm})
+		assert.Nil(t, err)
+		assert.Equal(t, policy, res)
+	})
+
+	t.Run("UpdateApprovalPolicy", func(t *testing.T) {
+		var e = MakeUpdateApprovalPolicyEndpoint(mockApprovalComponent)
+
+		var _, err = e(ctx, map[string]string{prmRealm: realm, reqBody: "{"})
+		assert.NotNil(t, err)
+
+		mockApprovalComponent.EXPECT().UpdateApprovalPolicy(ctx, realm, api.ApprovalPolicyRepresentation{Actions: []string{"MGMT_DeleteUser"}}).Return(nil)
+		_, err = e(ctx, map[string]string{prmRealm: realm, reqBody: `{"actions":["MGMT_DeleteUser"]}`})
+		assert.Nil(t, err)
+	})
+
+	t.Run("GetApprovalRequests", func(t *testing.T) {
+		mockApprovalComponent.EXPECT().GetApprovalRequests(ctx, realm, &status).Return([]api.ApprovalRequestRepresentation{}, nil)
+		var _, err = MakeGetApprovalRequestsEndpoint(mockApprovalComponent)(ctx, map[string]string{prmRealm: realm, prmQryStatus: status})
+		assert.Nil(t, err)
+
+		mockApprovalComponent.EXPECT().GetApprovalRequests(ctx, realm, nil).Return([]api.ApprovalRequestRepresentation{}, nil)
+		_, err = MakeGetApprovalRequestsEndpoint(mockApprovalComponent)(ctx, map[string]string{prmRealm: realm})
+		assert.Nil(t, err)
+	})
+
+	t.Run("ApproveRequest", func(t *testing.T) {
+		var e = MakeApproveRequestEndpoint(mockApprovalComponent)
+		var comment = "ok"
+
+		var _, err = e(ctx, map[string]string{prmRealm: realm, prmApprovalID: "abc"})
+		assert.NotNil(t, err)
+
+		_, err = e(ctx, map[string]string{prmRealm: realm, prmApprovalID: "12", reqBody: "{"})
+		assert.NotNil(t, err)
+
+		mockApprovalComponent.EXPECT().ApproveRequest(ctx, realm, int64(12), api.ApprovalDecisionRepresentation{}).Return(api.ApprovalRequestRepresentation{}, nil)
+		_, err = e(ctx, map[string]string{prmRealm: realm, prmApprovalID: "12"})
+		assert.Nil(t, err)
+
+		mockApprovalComponent.EXPECT().ApproveRequest(ctx, realm, int64(12), api.ApprovalDecisionRepresentation{Comment: &comment}).Return(api.ApprovalRequestRepresentation{}, nil)
+		_, err = e(ctx, map[string]string{prmRealm: realm, prmApprovalID: "12", reqBody: `{"comment":"ok"}`})
+		assert.Nil(t, err)
+	})
+
+	t.Run("RejectRequest", func(t *testing.T) {
+		mockApprovalComponent.EXPECT().RejectRequest(ctx, realm, int64(12), api.ApprovalDecisionRepresentation{}).Return(nil)
+		var res, err = MakeRejectRequestEndpoint(mockApprovalComponent)(ctx, map[string]string{prmRealm: realm, prmApprovalID: "12"})
+		assert.Nil(t, err)
+		assert.Nil(t, res)
+	})
+}
+
+func TestTemporaryGrantsEndpoints(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var mockGrantsComponent = mock.NewGrantsComponent(mockCtrl)
+
+	var ctx = context.Background()
+	var realm = "master"
+	var userID = "123-456-789"
+	var groupID = "987-654-321"
+	var clientID = "client-id"
+	var expiresAt = time.Unix(1591000000, 0)
+
+	t.Run("AddTemporaryGroupToUser", func(t *testing.T) {
+		var e = MakeAddTemporaryGroupToUserEndpoint(mockGrantsComponent)
+
+		var _, err = e(ctx, map[string]string{prmRealm: realm, prmUserID: userID, prmGroupID: groupID, prmQryExpiresAt: "tomorrow"})
+		assert.NotNil(t, err)
+
+		mockGrantsComponent.EXPECT().AddTemporaryGroupToUser(ctx, realm, userID, groupID, expiresAt).Return(nil)
+		_, err = e(ctx, map[string]string{prmRealm: realm, prmUserID: userID, prmGroupID: groupID, prmQryExpiresAt: "1591000000"})
+		assert.Nil(t, err)
+	})
+
+	t.Run("SetTemporaryTrustIDGroupsToUser", func(t *testing.T) {
+		var e = MakeSetTemporaryTrustIDGroupsToUserEndpoint(mockGrantsComponent)
+
+		var _, err = e(ctx, map[string]string{prmRealm: realm, prmUserID: userID, prmQryExpiresAt: "1591000000", reqBody: "{"})
+		assert.NotNil(t, err)
+
+		mockGrantsComponent.EXPECT().SetTemporaryTrustIDGroupsToUser(ctx, realm, userID, []string{"l1_support_agent"}, expiresAt).Return(nil)
+		_, err = e(ctx, map[string]string{prmRealm: realm, prmUserID: userID, prmQryExpiresAt: "1591000000", reqBody: `["l1_support_agent"]`})
+		assert.Nil(t, err)
+	})
+
+	t.Run("AddTemporaryClientRolesToUser", func(t *testing.T) {
+		var e = MakeAddTemporaryClientRolesToUserEndpoint(mockGrantsComponent)
+		var roleID, roleName = "f467ed7c-0a1d-4eee-9bb8-669c6f89c0ee", "support"
+		var roles = []api.RoleRepresentation{{ID: &roleID, Name: &roleName}}
+		var body, _ = json.Marshal(roles)
+
+		var _, err = e(ctx, map[string]string{prmRealm: realm, prmUserID: userID, prmClientID: clientID, reqBody: string(body)})
+		assert.NotNil(t, err)
+
+		mockGrantsComponent.EXPECT().AddTemporaryClientRolesToUser(ctx, realm, userID, clientID, roles, expiresAt).Return(nil)
+		_, err = e(ctx, map[string]string{prmRealm: realm, prmUserID: userID, prmClientID: clientID, prmQryExpiresAt: "1591000000", reqBody: string(body)})
+		assert.Nil(t, err)
+	})
+}
+
+func TestScheduledUserChangesEndpoints(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var mockScheduledChangesComponent = mock.NewScheduledChangesComponent(mockCtrl)
+
+	var ctx = context.Background()
+	var realm = "master"
+	var userID = "123-456-789"
+	var expectedError = errors.New("error")
+
+	t.Run("GetScheduledUserChanges", func(t *testing.T) {
+		var e = MakeGetScheduledUserChangesEndpoint(mockScheduledChangesComponent)
+
+		mockScheduledChangesComponent.EXPECT().GetScheduledUserChanges(ctx, realm, userID).Return([]api.ScheduledUserChangeRepresentation{}, nil)
+		var res, err = e(ctx, map[string]string{prmRealm: realm, prmUserID: userID})
+		assert.Nil(t, err)
+		assert.NotNil(t, res)
+	})
+
+	t.Run("ScheduleUserChange", func(t *testing.T) {
+		var e = MakeScheduleUserChangeEndpoint(mockScheduledChangesComponent)
+		var action = "LOCK"
+		var applyAt = int64(1591000000)
+		var change = api.ScheduledUserChangeRepresentation{Action: &action, ApplyAt: &applyAt}
+		var req = map[string]string{prmRealm: realm, prmUserID: userID, reqScheme: "https", reqHost: "elca.ch", reqBody: `{"action":"LOCK","applyAt":1591000000}`}
+
+		mockScheduledChangesComponent.EXPECT().ScheduleUserChange(ctx, realm, userID, change).Return(int64(6), nil)
+		var res, err = e(ctx, req)
+		assert.Nil(t, err)
+		assert.Equal(t, "https://elca.ch/management/realms/master/users/123-456-789/scheduled-changes/6", res.(LocationHeader).URL)
+
+		mockScheduledChangesComponent.EXPECT().ScheduleUserChange(ctx, realm, userID, change).Return(int64(0), expectedError)
+		_, err = e(ctx, req)
+		assert.Equal(t, expectedError, err)
+
+		req[reqBody] = "{"
+		_, err = e(ctx, req)
+		assert.NotNil(t, err)
+
+		req[reqBody] = `{"action":"DELETE","applyAt":1591000000}`
+		_, err = e(ctx, req)
+		assert.NotNil(t, err)
+	})
+
+	t.Run("CancelScheduledUserChange", func(t *testing.T) {
+		var e = MakeCancelScheduledUserChangeEndpoint(mockScheduledChangesComponent)
+
+		var _, err = e(ctx, map[string]string{prmRealm: realm, prmUserID: userID, prmChangeID: "six"})
+		assert.NotNil(t, err)
+
+		mockScheduledChangesComponent.EXPECT().CancelScheduledUserChange(ctx, realm, userID, int64(6)).Return(nil)
+		_, err = e(ctx, map[string]string{prmRealm: realm, prmUserID: userID, prmChangeID: "6"})
+		assert.Nil(t, err)
+	})
+}
+
+func TestImpersonateUserEndpoint(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var mockImpersonationComponent = mock.NewImpersonationComponent(mockCtrl)
+	var e = MakeImpersonateUserEndpoint(mockImpersonationComponent)
+
+	var ctx = context.Background()
+	var realm = "master"
+	var userID = "123-456-789"
+	var justification = "Ticket #1234: documents are missing"
+	var duration = int64(600)
+	var request = api.ImpersonationRequestRepresentation{Justification: &justification, Duration: &duration}
+	var req = map[string]string{prmRealm: realm, prmUserID: userID, reqBody: `{"justification":"Ticket #1234: documents are missing","duration":600}`}
+
+	t.Run("Invalid body", func(t *testing.T) {
+		var _, err = e(ctx, map[string]string{prmRealm: realm, prmUserID: userID, reqBody: "{"})
+		assert.NotNil(t, err)
+	})
+
+	t.Run("Missing justification", func(t *testing.T) {
+		var _, err = e(ctx, map[string]string{prmRealm: realm, prmUserID: userID, reqBody: `{"duration":600}`})
+		assert.NotNil(t, err)
+	})
+
+	t.Run("Success", func(t *testing.T) {
+		var expiresAt = int64(1591000600)
+		mockImpersonationComponent.EXPECT().ImpersonateUser(ctx, realm, userID, request).Return(api.ImpersonationRepresentation{ExpiresAt: &expiresAt}, nil)
+		var res, err = e(ctx, req)
+		assert.Nil(t, err)
+		assert.Equal(t, expiresAt, *res.(api.ImpersonationRepresentation).ExpiresAt)
+	})
+}
+
+func TestProvisionRealmEndpoint(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var mockProvisioningComponent = mock.NewProvisioningComponent(mockCtrl)
+	var e = MakeProvisionRealmEndpoint(mockProvisioningComponent)
+
+	var ctx = context.Background()
+	var realm = "customer"
+	var groupName = "operators"
+	var template = api.RealmTemplateRepresentation{Realm: &realm, Groups: []api.GroupTemplateRepresentation{{Name: &groupName}}}
+	var body = `{"realm":"customer","groups":[{"name":"operators"}]}`
+
+	t.Run("Invalid body", func(t *testing.T) {
+		var _, err = e(ctx, map[string]string{reqBody: "{"})
+		assert.NotNil(t, err)
+	})
+
+	t.Run("Missing realm", func(t *testing.T) {
+		var _, err = e(ctx, map[string]string{reqBody: `{"groups":[{"name":"operators"}]}`})
+		assert.NotNil(t, err)
+	})
+
+	t.Run("Dry-run", func(t *testing.T) {
+		var plan = api.ProvisioningPlanRepresentation{Realm: realm}
+		mockProvisioningComponent.EXPECT().ProvisionRealm(ctx, template, true).Return(plan, nil)
+		var res, err = e(ctx, map[string]string{reqBody: body, prmQryDryRun: "true"})
+		assert.Nil(t, err)
+		assert.Equal(t, plan, res)
+	})
+
+	t.Run("Apply", func(t *testing.T) {
+		var plan = api.ProvisioningPlanRepresentation{Realm: realm, Applied: true}
+		mockProvisioningComponent.EXPECT().ProvisionRealm(ctx, template, false).Return(plan, nil)
+		var res, err = e(ctx, map[string]string{reqBody: body})
+		assert.Nil(t, err)
+		assert.Equal(t, plan, res)
+	})
+}
+
+func TestGetUserAuthorizationsReportEndpoint(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var mockReportComponent = mock.NewAuthorizationsReportComponent(mockCtrl)
+	var e = MakeGetUserAuthorizationsReportEndpoint(mockReportComponent)
+
+	var ctx = context.Background()
+	var realm = "customer"
+	var userID = "b5c7a3f2-0a1d-4eee-9bb8-669c6f89c0ee"
+	var username = "jdoe"
+	var targetRealm = "customer"
+	var report = api.UserAuthorizationsReportRepresentation{
+		Realm:          realm,
+		UserID:         userID,
+		Username:       &username,
+		Groups:         []string{"operators"},
+		Authorizations: []api.EffectiveAuthorizationRepresentation{{Realm: realm, Group: "operators", Action: "MGMT_GetUsers", TargetRealm: &targetRealm}},
+	}
+
+	t.Run("Invalid format", func(t *testing.T) {
+		var _, err = e(ctx, map[string]string{prmRealm: realm, prmUserID: userID, prmQryFormat: api.ExportFormatNDJSON})
+		assert.NotNil(t, err)
+	})
+
+	t.Run("Component fails", func(t *testing.T) {
+		var expectedError = errors.New("component error")
+		mockReportComponent.EXPECT().GetUserAuthorizationsReport(ctx, realm, userID).Return(api.UserAuthorizationsReportRepresentation{}, expectedError)
+		var _, err = e(ctx, map[string]string{prmRealm: realm, prmUserID: userID})
+		assert.Equal(t, expectedError, err)
+	})
+
+	t.Run("JSON", func(t *testing.T) {
+		mockReportComponent.EXPECT().GetUserAuthorizationsReport(ctx, realm, userID).Return(report, nil)
+		var res, err = e(ctx, map[string]string{prmRealm: realm, prmUserID: userID})
+		assert.Nil(t, err)
+		assert.Equal(t, report, res)
+	})
+
+	t.Run("CSV", func(t *testing.T) {
+		mockReportComponent.EXPECT().GetUserAuthorizationsReport(ctx, realm, userID).Return(report, nil)
+		var res, err = e(ctx, map[string]string{prmRealm: realm, prmUserID: userID, prmQryFormat: api.ReportFormatCSV})
+		assert.Nil(t, err)
+
+		var export = res.(UsersExport)
+		assert.Equal(t, "text/csv", export.ContentType)
+		var buffer bytes.Buffer
+		assert.Nil(t, export.Write(&buffer))
+		assert.Equal(t, "realm,userId,username,group,action,targetRealm,targetGroup\n"+
+			"customer,b5c7a3f2-0a1d-4eee-9bb8-669c6f89c0ee,jdoe,operators,MGMT_GetUsers,customer,\n", buffer.String())
+	})
+}
+
+func TestGetTargetAuthorizationsReportEndpoint(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var mockReportComponent = mock.NewAuthorizationsReportComponent(mockCtrl)
+	var e = MakeGetTargetAuthorizationsReportEndpoint(mockReportComponent)
+
+	var ctx = context.Background()
+	var realm = "customer"
+	var groupName = "operators"
+	var report = api.TargetAuthorizationsReportRepresentation{TargetRealm: realm, TargetGroup: &groupName}
+
+	t.Run("Realm", func(t *testing.T) {
+		mockReportComponent.EXPECT().GetTargetAuthorizationsReport(ctx, realm, nil).Return(report, nil)
+		var res, err = e(ctx, map[string]string{prmRealm: realm})
+		assert.Nil(t, err)
+		assert.Equal(t, report, res)
+	})
+
+	t.Run("Group as CSV", func(t *testing.T) {
+		mockReportComponent.EXPECT().GetTargetAuthorizationsReport(ctx, realm, &groupName).Return(report, nil)
+		var res, err = e(ctx, map[string]string{prmRealm: realm, prmQryGroupName: groupName, prmQryFormat: api.ReportFormatCSV})
+		assert.Nil(t, err)
+		assert.Equal(t, "authorizations-on-customer-operators.csv", res.(UsersExport).Filename)
+	})
+}
@@ -0,0 +1,213 @@
+package management
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/cloudtrust/common-service/database"
+	errorhandler "github.com/cloudtrust/common-service/errors"
+	api "github.com/cloudtrust/keycloak-bridge/api/management"
+	msg "github.com/cloudtrust/keycloak-bridge/internal/constants"
+	"github.com/cloudtrust/keycloak-bridge/internal/dto"
+	"github.com/cloudtrust/keycloak-bridge/internal/keycloakb"
+)
+
+const exportPageSize = 100
+
+// UsersExport is a users export streamed to the client. Users are read page by page while they are written.
+type UsersExport struct {
+	Filename    string
+	ContentType string
+	Write       func(w io.Writer) error
+}
+
+// newCSVExport returns a CSV file made of the given records
+func newCSVExport(filename string, records [][]string) UsersExport {
+	return UsersExport{
+		Filename:    filename,
+		ContentType: "text/csv",
+		Write: func(w io.Writer) error {
+			var writer = csv.NewWriter(w)
+			for _, record := range records {
+				if err := writer.Write(keycloakb.EscapeCSVRecord(record)); err != nil {
+					return err
+				}
+			}
+			writer.Flush()
+			return writer.Error()
+		},
+	}
+}
+
+// usersExportWriter writes the selected columns of the users in a given format
+type usersExportWriter interface {
+	Write(user api.UserRepresentation) error
+	Flush() error
+}
+
+// ExportUsers exports the users of a realm, or of the given groups, with the selected columns. Columns of the user details
+// are decrypted from the users database.
+func (c *component) ExportUsers(ctx context.Context, realmName string, groupIDs []string, format string, columns []string) (UsersExport, error) {
+	if len(columns) == 0 {
+		columns = api.DefaultExportColumns
+	}
+	if err := api.ValidateExportColumns(columns); err != nil {
+		return UsersExport{}, err
+	}
+
+	var contentType string
+	switch format {
+	case api.ExportFormatCSV:
+		contentType = "text/csv"
+	case api.ExportFormatNDJSON:
+		contentType = "application/x-ndjson"
+	default:
+		c.logger.Warn(ctx, "err", "Invalid parameter format")
+		return UsersExport{}, errorhandler.CreateInvalidQueryParameterError(msg.Format)
+	}
+
+	//store the API call into the DB
+	var additionalInfo = database.CreateAdditionalInfo("format", format, "columns", strings.Join(columns, ","), "group_ids", strings.Join(groupIDs, ","))
+	c.reportEvent(ctx, "API_USERS_EXPORT", database.CtEventRealmName, realmName, database.CtEventAdditionalInfo, additionalInfo)
+
+	return UsersExport{
+		Filename:    fmt.Sprintf("users-%s.%s", realmName, format),
+		ContentType: contentType,
+		Write: func(w io.Writer) error {
+			var err = c.writeUsersExport(ctx, w, realmName, groupIDs, format, columns)
+			if err != nil {
+				c.logger.Warn(ctx, "msg", "Users export interrupted", "err", err.Error())
+			}
+			return err
+		},
+	}, nil
+}
+
+func (c *component) writeUsersExport(ctx context.Context, w io.Writer, realmName string, groupIDs []string, format string, columns []string) error {
+	var writer, err = newUsersExportWriter(w, format, columns)
+	if err != nil {
+		return err
+	}
+	var withDetails = api.HasUserDetailsColumns(columns)
+
+	for first := 0; ; first += exportPageSize {
+		var page api.UsersPageRepresentation
+		// Soft deleted users are skipped here: filtered by GetUsers, they would shorten the pages and end the export early
+		page, err = c.GetUsers(ctx, realmName, groupIDs, prmQryFirst, strconv.Itoa(first), prmQryMax, strconv.Itoa(exportPageSize), prmQryIncludeDeleted, "true")
+		if err != nil {
+			return err
+		}
+
+		for _, user := range page.Users {
+			if user.DeletedTimestamp != nil {
+				continue
+			}
+			if withDetails && user.ID != nil {
+				var dbUser dto.DBUser
+				if dbUser, err = c.usersDBModule.GetUserDetails(ctx, realmName, *user.ID); err != nil {
+					return err
+				}
+				user.BirthLocation = dbUser.BirthLocation
+				user.IDDocumentType = dbUser.IDDocumentType
+				user.IDDocumentNumber = dbUser.IDDocumentNumber
+				user.IDDocumentExpiration = dbUser.IDDocumentExpiration
+			}
+			if err = writer.Write(user); err != nil {
+				return err
+			}
+		}
+
+		if len(page.Users) < exportPageSize {
+			return writer.Flush()
+		}
+	}
+}
+
+func newUsersExportWriter(w io.Writer, format string, columns []string) (usersExportWriter, error) {
+	if format == api.ExportFormatNDJSON {
+		return &ndjsonExportWriter{encoder: json.NewEncoder(w), columns: columns}, nil
+	}
+
+	var writer = &csvExportWriter{writer: csv.NewWriter(w), columns: columns}
+	if err := writer.writer.Write(columns); err != nil {
+		return nil, err
+	}
+	return writer, nil
+}
+
+// selectColumns returns the values of the selected columns of a user, nil when a value is not set
+func selectColumns(user api.UserRepresentation, columns []string) map[string]interface{} {
+	var fields map[string]interface{}
+	var bytes, _ = json.Marshal(user)
+	_ = json.Unmarshal(bytes, &fields)
+
+	var res = map[string]interface{}{}
+	for _, column := range columns {
+		res[column] = fields[column]
+	}
+	return res
+}
+
+type csvExportWriter struct {
+	writer  *csv.Writer
+	columns []string
+}
+
+// Write formats lists of strings as comma separated values and other structured values as JSON
+func (e *csvExportWriter) Write(user api.UserRepresentation) error {
+	var fields = selectColumns(user, e.columns)
+	var record []string
+	for _, column := range e.columns {
+		record = append(record, csvCell(fields[column]))
+	}
+	return e.writer.Write(record)
+}
+
+func (e *csvExportWriter) Flush() error {
+	e.writer.Flush()
+	return e.writer.Error()
+}
+
+// csvCell formats a value as a CSV cell which can't be evaluated as a formula by a spreadsheet
+func csvCell(value interface{}) string {
+	return keycloakb.EscapeCSVCell(csvValue(value))
+}
+
+func csvValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case []interface{}:
+		var values []string
+		for _, item := range v {
+			if str, ok := item.(string); ok {
+				values = append(values, str)
+			}
+		}
+		if len(values) == len(v) {
+			return strings.Join(values, ",")
+		}
+	}
+	var bytes, _ = json.Marshal(value)
+	return string(bytes)
+}
+
+type ndjsonExportWriter struct {
+	encoder *json.Encoder
+	columns []string
+}
+
+func (e *ndjsonExportWriter) Write(user api.UserRepresentation) error {
+	return e.encoder.Encode(selectColumns(user, e.columns))
+}
+
+func (e *ndjsonExportWriter) Flush() error {
+	return nil
+}
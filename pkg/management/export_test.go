@@ -0,0 +1,139 @@
+package management
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	cs "github.com/cloudtrust/common-service"
+	"github.com/cloudtrust/common-service/database"
+	"github.com/cloudtrust/common-service/log"
+	api "github.com/cloudtrust/keycloak-bridge/api/management"
+	"github.com/cloudtrust/keycloak-bridge/internal/constants"
+	"github.com/cloudtrust/keycloak-bridge/internal/dto"
+	"github.com/cloudtrust/keycloak-bridge/pkg/management/mock"
+	kc "github.com/cloudtrust/keycloak-client"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExportUsers(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+	var mockKeycloakClient = mock.NewKeycloakClient(mockCtrl)
+	var mockUsersDetailsDBModule = mock.NewUsersDetailsDBModule(mockCtrl)
+	var mockEventDBModule = mock.NewEventDBModule(mockCtrl)
+	var mockConfigurationDBModule = mock.NewConfigurationDBModule(mockCtrl)
+
+	var managementComponent = NewComponent(mockKeycloakClient, mockUsersDetailsDBModule, mockEventDBModule, mockConfigurationDBModule, nil, log.NewNopLogger())
+
+	var accessToken = "TOKEN=="
+	var realmName = "master"
+	var targetRealmName = "DEP"
+	var groupID = "123-456-789"
+	var ctx = context.WithValue(context.Background(), cs.CtContextAccessToken, accessToken)
+	ctx = context.WithValue(ctx, cs.CtContextRealm, realmName)
+
+	var id = "1234-7454-4516"
+	var username = "jdoe"
+	var enabled = true
+	var birthLocation = "Lausanne"
+	var attributes = make(kc.Attributes)
+	attributes.SetString(constants.AttrbPhoneNumber, "+41789456")
+	attributes.SetBool(constants.AttrbPhoneNumberVerified, true)
+	attributes.Set(constants.AttrbTrustIDGroups, []string{"grp1", "grp2"})
+	var count = 1
+	var kcUsersPage = kc.UsersPageRepresentation{
+		Count: &count,
+		Users: []kc.UserRepresentation{{ID: &id, Username: &username, Enabled: &enabled, Attributes: &attributes}},
+	}
+
+	t.Run("Invalid column", func(t *testing.T) {
+		var _, err = managementComponent.ExportUsers(ctx, targetRealmName, nil, api.ExportFormatCSV, []string{"username", "password"})
+		assert.NotNil(t, err)
+	})
+
+	t.Run("Invalid format", func(t *testing.T) {
+		var _, err = managementComponent.ExportUsers(ctx, targetRealmName, nil, "xlsx", nil)
+		assert.NotNil(t, err)
+	})
+
+	t.Run("CSV export with user details", func(t *testing.T) {
+		var columns = []string{"username", "enabled", "phoneNumberVerified", "trustIdGroups", "birthLocation", "idDocumentNumber"}
+		mockEventDBModule.EXPECT().ReportEvent(ctx, "API_USERS_EXPORT", "back-office", database.CtEventRealmName, targetRealmName, database.CtEventAdditionalInfo, gomock.Any()).Return(nil)
+		var export, err = managementComponent.ExportUsers(ctx, targetRealmName, []string{groupID}, api.ExportFormatCSV, columns)
+		assert.Nil(t, err)
+		assert.Equal(t, "text/csv", export.ContentType)
+		assert.Equal(t, "users-DEP.csv", export.Filename)
+
+		mockKeycloakClient.EXPECT().GetUsers(accessToken, realmName, targetRealmName, prmQryFirst, "0", prmQryMax, "100", "groupId", groupID).Return(kcUsersPage, nil)
+		mockUsersDetailsDBModule.EXPECT().GetUserDetails(ctx, targetRealmName, id).Return(dto.DBUser{UserID: &id, BirthLocation: &birthLocation}, nil)
+		var buffer bytes.Buffer
+		assert.Nil(t, export.Write(&buffer))
+		assert.Equal(t, "username,enabled,phoneNumberVerified,trustIdGroups,birthLocation,idDocumentNumber\njdoe,true,true,\"grp1,grp2\",Lausanne,\n", buffer.String())
+	})
+
+	t.Run("CSV cells which could be evaluated as formulas are escaped", func(t *testing.T) {
+		var formula = "=HYPERLINK(\"http://evil.com\")"
+		var formulaPage = kc.UsersPageRepresentation{
+			Count: &count,
+			Users: []kc.UserRepresentation{{ID: &id, Username: &formula, Enabled: &enabled, Attributes: &attributes}},
+		}
+		mockEventDBModule.EXPECT().ReportEvent(ctx, "API_USERS_EXPORT", "back-office", database.CtEventRealmName, targetRealmName, database.CtEventAdditionalInfo, gomock.Any()).Return(nil)
+		var export, err = managementComponent.ExportUsers(ctx, targetRealmName, nil, api.ExportFormatCSV, []string{"username", "phoneNumber"})
+		assert.Nil(t, err)
+
+		mockKeycloakClient.EXPECT().GetUsers(accessToken, realmName, targetRealmName, prmQryFirst, "0", prmQryMax, "100").Return(formulaPage, nil)
+		var buffer bytes.Buffer
+		assert.Nil(t, export.Write(&buffer))
+		assert.Equal(t, "username,phoneNumber\n\"'=HYPERLINK(\"\"http://evil.com\"\")\",'+41789456\n", buffer.String())
+	})
+
+	t.Run("NDJSON export of a realm", func(t *testing.T) {
+		mockEventDBModule.EXPECT().ReportEvent(ctx, "API_USERS_EXPORT", "back-office", database.CtEventRealmName, targetRealmName, database.CtEventAdditionalInfo, gomock.Any()).Return(nil)
+		var export, err = managementComponent.ExportUsers(ctx, targetRealmName, nil, api.ExportFormatNDJSON, []string{"id", "email"})
+		assert.Nil(t, err)
+
+		mockKeycloakClient.EXPECT().GetUsers(accessToken, realmName, targetRealmName, prmQryFirst, "0", prmQryMax, "100").Return(kcUsersPage, nil)
+		var buffer bytes.Buffer
+		assert.Nil(t, export.Write(&buffer))
+		assert.Equal(t, `{"email":null,"id":"1234-7454-4516"}`+"\n", buffer.String())
+	})
+
+	t.Run("Soft deleted user in the middle of a full page", func(t *testing.T) {
+		mockEventDBModule.EXPECT().ReportEvent(ctx, "API_USERS_EXPORT", "back-office", database.CtEventRealmName, targetRealmName, database.CtEventAdditionalInfo, gomock.Any()).Return(nil)
+		var export, err = managementComponent.ExportUsers(ctx, targetRealmName, nil, api.ExportFormatCSV, []string{"username"})
+		assert.Nil(t, err)
+
+		var deletedAttributes = make(kc.Attributes)
+		deletedAttributes.SetString(constants.AttrbSoftDeleted, "1591000000000")
+		var deletedUsername = "deleted"
+		var lastUsername = "last"
+		var total = exportPageSize + 1
+		var firstPage = kc.UsersPageRepresentation{Count: &total, Users: make([]kc.UserRepresentation, exportPageSize)}
+		for i := range firstPage.Users {
+			firstPage.Users[i].Username = &username
+		}
+		firstPage.Users[exportPageSize/2] = kc.UserRepresentation{Username: &deletedUsername, Attributes: &deletedAttributes}
+		var secondPage = kc.UsersPageRepresentation{Count: &total, Users: []kc.UserRepresentation{{Username: &lastUsername}}}
+
+		mockKeycloakClient.EXPECT().GetUsers(accessToken, realmName, targetRealmName, prmQryFirst, "0", prmQryMax, "100").Return(firstPage, nil)
+		mockKeycloakClient.EXPECT().GetUsers(accessToken, realmName, targetRealmName, prmQryFirst, "100", prmQryMax, "100").Return(secondPage, nil)
+		var buffer bytes.Buffer
+		assert.Nil(t, export.Write(&buffer))
+		assert.Equal(t, "username\n"+strings.Repeat("jdoe\n", exportPageSize-1)+"last\n", buffer.String())
+	})
+
+	t.Run("Keycloak error while streaming", func(t *testing.T) {
+		var kcError = errors.New("kc error")
+		mockEventDBModule.EXPECT().ReportEvent(ctx, "API_USERS_EXPORT", "back-office", database.CtEventRealmName, targetRealmName, database.CtEventAdditionalInfo, gomock.Any()).Return(nil)
+		var export, err = managementComponent.ExportUsers(ctx, targetRealmName, nil, api.ExportFormatCSV, nil)
+		assert.Nil(t, err)
+
+		mockKeycloakClient.EXPECT().GetUsers(accessToken, realmName, targetRealmName, gomock.Any()).Return(kc.UsersPageRepresentation{}, kcError)
+		var buffer bytes.Buffer
+		assert.Equal(t, kcError, export.Write(&buffer))
+	})
+}
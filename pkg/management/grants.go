@@ -0,0 +1,309 @@
+package management
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	cs "github.com/cloudtrust/common-service"
+	"github.com/cloudtrust/common-service/database"
+	errorhandler "github.com/cloudtrust/common-service/errors"
+	api "github.com/cloudtrust/keycloak-bridge/api/management"
+	"github.com/cloudtrust/keycloak-bridge/internal/constants"
+	"github.com/cloudtrust/keycloak-bridge/internal/dto"
+	"github.com/cloudtrust/keycloak-bridge/internal/keycloakb"
+)
+
+// TemporaryGrantsDBModule is the interface of the temporary grants storage
+type TemporaryGrantsDBModule interface {
+	StoreTemporaryGrant(ctx context.Context, grant dto.DBTemporaryGrant) error
+	GetUserTemporaryGrants(ctx context.Context, realm string, userID string) ([]dto.DBTemporaryGrant, error)
+	DeleteTemporaryGrant(ctx context.Context, realm string, userID string, grantType string, targetID string) error
+	DeleteTemporaryGrants(ctx context.Context, realm string, userID string, grantType string) error
+}
+
+// GrantsComponent is the interface of the temporary group memberships and role grants. They are removed by the grants
+// reconciler once expired.
+type GrantsComponent interface {
+	AddTemporaryGroupToUser(ctx context.Context, realmName, userID, groupID string, expiresAt time.Time) error
+	SetTemporaryTrustIDGroupsToUser(ctx context.Context, realmName, userID string, groupNames []string, expiresAt time.Time) error
+	AddTemporaryClientRolesToUser(ctx context.Context, realmName, userID, clientID string, roles []api.RoleRepresentation, expiresAt time.Time) error
+}
+
+type grantsComponent struct {
+	component     Component
+	grantsDB      TemporaryGrantsDBModule
+	eventDBModule database.EventsDBModule
+	logger        keycloakb.Logger
+}
+
+// NewGrantsComponent returns the temporary grants component. The grants are done by the given management component
+// which must be wrapped by the temporary grants middleware.
+func NewGrantsComponent(component Component, grantsDB TemporaryGrantsDBModule, eventDBModule database.EventsDBModule, logger keycloakb.Logger) GrantsComponent {
+	return &grantsComponent{
+		component:     component,
+		grantsDB:      grantsDB,
+		eventDBModule: eventDBModule,
+		logger:        logger,
+	}
+}
+
+// AddTemporaryGroupToUser adds a group to a user. If the user is already a member of the group, the membership is kept
+// unchanged: a permanent membership does not become temporary and a temporary one keeps its expiry.
+func (c *grantsComponent) AddTemporaryGroupToUser(ctx context.Context, realmName, userID, groupID string, expiresAt time.Time) error {
+	if err := c.validateExpiry(ctx, expiresAt); err != nil {
+		return err
+	}
+	currentGroups, err := c.component.GetGroupsOfUser(ctx, realmName, userID)
+	if err != nil {
+		return err
+	}
+	for _, group := range currentGroups {
+		if group.ID != nil && *group.ID == groupID {
+			c.logger.Info(ctx, "msg", "User is already a member of the group", "userID", userID, "groupID", groupID)
+			return nil
+		}
+	}
+	if err := c.component.AddGroupToUser(ctx, realmName, userID, groupID); err != nil {
+		return err
+	}
+	return c.storeGrant(ctx, dto.DBTemporaryGrant{RealmName: realmName, UserID: userID, GrantType: dto.GrantTypeGroup, TargetID: groupID,
+		ExpiresAt: expiresAt})
+}
+
+// SetTemporaryTrustIDGroupsToUser sets the trustID groups of a user. Only the added groups are temporary: the groups
+// the user already had keep their previous expiry, if any.
+func (c *grantsComponent) SetTemporaryTrustIDGroupsToUser(ctx context.Context, realmName, userID string, groupNames []string, expiresAt time.Time) error {
+	if err := c.validateExpiry(ctx, expiresAt); err != nil {
+		return err
+	}
+	currentGroups, err := c.component.GetTrustIDGroupsOfUser(ctx, realmName, userID)
+	if err != nil {
+		return err
+	}
+	grants, err := c.grantsDB.GetUserTemporaryGrants(ctx, realmName, userID)
+	if err != nil {
+		return err
+	}
+	var previousGrants = make(map[string]dto.DBTemporaryGrant)
+	for _, grant := range grants {
+		if grant.GrantType == dto.GrantTypeTrustIDGroup {
+			previousGrants[grant.TargetID] = grant
+		}
+	}
+	var current = make(map[string]bool)
+	for _, groupName := range currentGroups {
+		current[groupName] = true
+	}
+
+	// Setting the groups forgets all the trustID groups expiries
+	if err := c.component.SetTrustIDGroupsToUser(ctx, realmName, userID, groupNames); err != nil {
+		return err
+	}
+	for _, groupName := range groupNames {
+		if current[groupName] {
+			if grant, ok := previousGrants[groupName]; ok {
+				if err := c.grantsDB.StoreTemporaryGrant(ctx, grant); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		var grant = dto.DBTemporaryGrant{RealmName: realmName, UserID: userID, GrantType: dto.GrantTypeTrustIDGroup, TargetID: groupName,
+			ExpiresAt: expiresAt}
+		if err := c.storeGrant(ctx, grant); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AddTemporaryClientRolesToUser grants client roles to a user. The roles must be given with their identifier and their name
+// as both are needed to remove them. Only the roles the user does not already have are temporary.
+func (c *grantsComponent) AddTemporaryClientRolesToUser(ctx context.Context, realmName, userID, clientID string, roles []api.RoleRepresentation, expiresAt time.Time) error {
+	if err := c.validateExpiry(ctx, expiresAt); err != nil {
+		return err
+	}
+	for _, role := range roles {
+		if role.ID == nil || role.Name == nil {
+			return errorhandler.CreateMissingParameterError(constants.RoleID)
+		}
+	}
+	currentRoles, err := c.component.GetClientRolesForUser(ctx, realmName, userID, clientID)
+	if err != nil {
+		return err
+	}
+	var current = make(map[string]bool)
+	for _, role := range currentRoles {
+		if role.ID != nil {
+			current[*role.ID] = true
+		}
+	}
+	var addedRoles = []api.RoleRepresentation{}
+	for _, role := range roles {
+		if !current[*role.ID] {
+			addedRoles = append(addedRoles, role)
+		}
+	}
+	if len(addedRoles) == 0 {
+		return nil
+	}
+
+	if err := c.component.AddClientRolesToUser(ctx, realmName, userID, clientID, addedRoles); err != nil {
+		return err
+	}
+	for _, role := range addedRoles {
+		var grant = dto.DBTemporaryGrant{RealmName: realmName, UserID: userID, GrantType: dto.GrantTypeClientRole, TargetID: *role.ID,
+			ClientID: &clientID, Name: role.Name, ExpiresAt: expiresAt}
+		if err := c.storeGrant(ctx, grant); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *grantsComponent) validateExpiry(ctx context.Context, expiresAt time.Time) error {
+	if !expiresAt.After(time.Now()) {
+		c.logger.Warn(ctx, "msg", "Expiry of temporary grant is not in the future", "expiresAt", expiresAt.String())
+		return errorhandler.CreateBadRequestError(constants.MsgErrInvalidParam + "." + constants.ExpiresAt)
+	}
+	return nil
+}
+
+func (c *grantsComponent) storeGrant(ctx context.Context, grant dto.DBTemporaryGrant) error {
+	grant.GrantedBy = ctx.Value(cs.CtContextUsername).(string)
+	if err := c.grantsDB.StoreTemporaryGrant(ctx, grant); err != nil {
+		return err
+	}
+
+	var additionalInfo = database.CreateAdditionalInfo("grant_type", grant.GrantType, "target_id", grant.TargetID,
+		"expires_at", strconv.FormatInt(grant.ExpiresAt.Unix(), 10))
+	reportEvent(ctx, c.eventDBModule, c.logger, "API_TEMPORARY_GRANT", database.CtEventRealmName, grant.RealmName, database.CtEventUserID, grant.UserID,
+		database.CtEventAdditionalInfo, additionalInfo)
+
+	return nil
+}
+
+type grantsComponentMW struct {
+	Component
+	grantsDB TemporaryGrantsDBModule
+	logger   keycloakb.Logger
+}
+
+// MakeTemporaryGrantsComponentMW adds the expiry of the temporary grants to the user, its groups and its client roles.
+// A permanent grant or a removal replaces the temporary grant: its expiry is forgotten.
+func MakeTemporaryGrantsComponentMW(grantsDB TemporaryGrantsDBModule, logger keycloakb.Logger) func(Component) Component {
+	return func(next Component) Component {
+		return &grantsComponentMW{
+			Component: next,
+			grantsDB:  grantsDB,
+			logger:    logger,
+		}
+	}
+}
+
+func (m *grantsComponentMW) GetUser(ctx context.Context, realmName, userID string) (api.UserRepresentation, error) {
+	var user, err = m.Component.GetUser(ctx, realmName, userID)
+	if err != nil {
+		return user, err
+	}
+
+	var grants []dto.DBTemporaryGrant
+	if grants, err = m.grantsDB.GetUserTemporaryGrants(ctx, realmName, userID); err != nil {
+		return api.UserRepresentation{}, err
+	}
+	if len(grants) > 0 {
+		var temporaryGrants = []api.GrantRepresentation{}
+		for _, grant := range grants {
+			temporaryGrants = append(temporaryGrants, api.ConvertToAPIGrant(grant))
+		}
+		user.TemporaryGrants = &temporaryGrants
+	}
+	return user, nil
+}
+
+func (m *grantsComponentMW) GetGroupsOfUser(ctx context.Context, realmName, userID string) ([]api.GroupRepresentation, error) {
+	var groups, err = m.Component.GetGroupsOfUser(ctx, realmName, userID)
+	if err != nil {
+		return groups, err
+	}
+
+	var expiries map[string]int64
+	if expiries, err = m.getExpiries(ctx, realmName, userID, dto.GrantTypeGroup); err != nil {
+		return nil, err
+	}
+	for i, group := range groups {
+		if expiresAt, ok := expiries[*group.ID]; ok {
+			groups[i].ExpiresAt = &expiresAt
+		}
+	}
+	return groups, nil
+}
+
+func (m *grantsComponentMW) GetClientRolesForUser(ctx context.Context, realmName, userID, clientID string) ([]api.RoleRepresentation, error) {
+	var roles, err = m.Component.GetClientRolesForUser(ctx, realmName, userID, clientID)
+	if err != nil {
+		return roles, err
+	}
+
+	var expiries map[string]int64
+	if expiries, err = m.getExpiries(ctx, realmName, userID, dto.GrantTypeClientRole); err != nil {
+		return nil, err
+	}
+	for i, role := range roles {
+		if expiresAt, ok := expiries[*role.ID]; ok {
+			roles[i].ExpiresAt = &expiresAt
+		}
+	}
+	return roles, nil
+}
+
+func (m *grantsComponentMW) getExpiries(ctx context.Context, realmName, userID string, grantType string) (map[string]int64, error) {
+	var grants, err = m.grantsDB.GetUserTemporaryGrants(ctx, realmName, userID)
+	if err != nil {
+		return nil, err
+	}
+	var res = make(map[string]int64)
+	for _, grant := range grants {
+		if grant.GrantType == grantType {
+			res[grant.TargetID] = grant.ExpiresAt.Unix()
+		}
+	}
+	return res, nil
+}
+
+func (m *grantsComponentMW) AddGroupToUser(ctx context.Context, realmName, userID string, groupID string) error {
+	if err := m.Component.AddGroupToUser(ctx, realmName, userID, groupID); err != nil {
+		return err
+	}
+	return m.grantsDB.DeleteTemporaryGrant(ctx, realmName, userID, dto.GrantTypeGroup, groupID)
+}
+
+func (m *grantsComponentMW) DeleteGroupForUser(ctx context.Context, realmName, userID string, groupID string) error {
+	if err := m.Component.DeleteGroupForUser(ctx, realmName, userID, groupID); err != nil {
+		return err
+	}
+	return m.grantsDB.DeleteTemporaryGrant(ctx, realmName, userID, dto.GrantTypeGroup, groupID)
+}
+
+func (m *grantsComponentMW) SetTrustIDGroupsToUser(ctx context.Context, realmName, userID string, groupNames []string) error {
+	if err := m.Component.SetTrustIDGroupsToUser(ctx, realmName, userID, groupNames); err != nil {
+		return err
+	}
+	return m.grantsDB.DeleteTemporaryGrants(ctx, realmName, userID, dto.GrantTypeTrustIDGroup)
+}
+
+func (m *grantsComponentMW) AddClientRolesToUser(ctx context.Context, realmName, userID, clientID string, roles []api.RoleRepresentation) error {
+	if err := m.Component.AddClientRolesToUser(ctx, realmName, userID, clientID, roles); err != nil {
+		return err
+	}
+	for _, role := range roles {
+		if role.ID == nil {
+			continue
+		}
+		if err := m.grantsDB.DeleteTemporaryGrant(ctx, realmName, userID, dto.GrantTypeClientRole, *role.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
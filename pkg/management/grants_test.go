@@ -0,0 +1,244 @@
+package management
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	cs "github.com/cloudtrust/common-service"
+	"github.com/cloudtrust/common-service/database"
+	errorhandler "github.com/cloudtrust/common-service/errors"
+	"github.com/cloudtrust/common-service/log"
+	api "github.com/cloudtrust/keycloak-bridge/api/management"
+	"github.com/cloudtrust/keycloak-bridge/internal/dto"
+	"github.com/cloudtrust/keycloak-bridge/pkg/management/mock"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGrantsComponent(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+	var mockManagementComponent = mock.NewManagementComponent(mockCtrl)
+	var mockGrantsDB = mock.NewTemporaryGrantsDBModule(mockCtrl)
+	var mockEventDBModule = mock.NewEventDBModule(mockCtrl)
+
+	var component = NewGrantsComponent(mockManagementComponent, mockGrantsDB, mockEventDBModule, log.NewNopLogger())
+
+	var ctx = context.WithValue(context.TODO(), cs.CtContextUsername, "operator")
+	var realm = "DEP"
+	var userID = "f467ed7c-0a1d-4eee-9bb8-669c6f89c0ee"
+	var groupID = "a4b1c6d2-0a1d-4eee-9bb8-669c6f89c0ee"
+	var clientID = "c8e2a4b6-0a1d-4eee-9bb8-669c6f89c0ee"
+	var roleID = "d7f3b5c1-0a1d-4eee-9bb8-669c6f89c0ee"
+	var roleName = "support"
+	var expiresAt = time.Now().Add(time.Hour)
+	var expectedError = errors.New("kc error")
+
+	t.Run("Expiry in the past", func(t *testing.T) {
+		var err = component.AddTemporaryGroupToUser(ctx, realm, userID, groupID, time.Now().Add(-time.Minute))
+		assert.Equal(t, http.StatusBadRequest, err.(errorhandler.Error).Status)
+	})
+
+	t.Run("Current groups can't be read", func(t *testing.T) {
+		mockManagementComponent.EXPECT().GetGroupsOfUser(ctx, realm, userID).Return(nil, expectedError)
+		assert.Equal(t, expectedError, component.AddTemporaryGroupToUser(ctx, realm, userID, groupID, expiresAt))
+	})
+
+	t.Run("User is already a member of the group", func(t *testing.T) {
+		var otherGroupID = "other-group-id"
+		mockManagementComponent.EXPECT().GetGroupsOfUser(ctx, realm, userID).Return([]api.GroupRepresentation{{ID: &otherGroupID}, {ID: &groupID}}, nil)
+		assert.Nil(t, component.AddTemporaryGroupToUser(ctx, realm, userID, groupID, expiresAt))
+	})
+
+	t.Run("Group can't be added", func(t *testing.T) {
+		mockManagementComponent.EXPECT().GetGroupsOfUser(ctx, realm, userID).Return([]api.GroupRepresentation{}, nil)
+		mockManagementComponent.EXPECT().AddGroupToUser(ctx, realm, userID, groupID).Return(expectedError)
+		assert.Equal(t, expectedError, component.AddTemporaryGroupToUser(ctx, realm, userID, groupID, expiresAt))
+	})
+
+	t.Run("Group is added temporarily", func(t *testing.T) {
+		mockManagementComponent.EXPECT().GetGroupsOfUser(ctx, realm, userID).Return([]api.GroupRepresentation{}, nil)
+		mockManagementComponent.EXPECT().AddGroupToUser(ctx, realm, userID, groupID).Return(nil)
+		mockGrantsDB.EXPECT().StoreTemporaryGrant(ctx, dto.DBTemporaryGrant{RealmName: realm, UserID: userID, GrantType: dto.GrantTypeGroup,
+			TargetID: groupID, ExpiresAt: expiresAt, GrantedBy: "operator"}).Return(nil)
+		mockEventDBModule.EXPECT().ReportEvent(ctx, "API_TEMPORARY_GRANT", "back-office", database.CtEventRealmName, realm, database.CtEventUserID, userID,
+			database.CtEventAdditionalInfo, gomock.Any()).Return(nil)
+		assert.Nil(t, component.AddTemporaryGroupToUser(ctx, realm, userID, groupID, expiresAt))
+	})
+
+	t.Run("Current trustID groups can't be read", func(t *testing.T) {
+		mockManagementComponent.EXPECT().GetTrustIDGroupsOfUser(ctx, realm, userID).Return(nil, expectedError)
+		assert.Equal(t, expectedError, component.SetTemporaryTrustIDGroupsToUser(ctx, realm, userID, []string{"l1_support_agent"}, expiresAt))
+	})
+
+	t.Run("Grant can't be stored", func(t *testing.T) {
+		var dbError = errors.New("db error")
+		mockManagementComponent.EXPECT().GetTrustIDGroupsOfUser(ctx, realm, userID).Return([]string{}, nil)
+		mockGrantsDB.EXPECT().GetUserTemporaryGrants(ctx, realm, userID).Return(nil, nil)
+		mockManagementComponent.EXPECT().SetTrustIDGroupsToUser(ctx, realm, userID, []string{"l1_support_agent"}).Return(nil)
+		mockGrantsDB.EXPECT().StoreTemporaryGrant(ctx, gomock.Any()).Return(dbError)
+		assert.Equal(t, dbError, component.SetTemporaryTrustIDGroupsToUser(ctx, realm, userID, []string{"l1_support_agent"}, expiresAt))
+	})
+
+	t.Run("TrustID groups are set temporarily", func(t *testing.T) {
+		var groupNames = []string{"l1_support_agent", "registration_officer"}
+		mockManagementComponent.EXPECT().GetTrustIDGroupsOfUser(ctx, realm, userID).Return([]string{}, nil)
+		mockGrantsDB.EXPECT().GetUserTemporaryGrants(ctx, realm, userID).Return(nil, nil)
+		mockManagementComponent.EXPECT().SetTrustIDGroupsToUser(ctx, realm, userID, groupNames).Return(nil)
+		mockGrantsDB.EXPECT().StoreTemporaryGrant(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, grant dto.DBTemporaryGrant) error {
+			assert.Equal(t, dto.GrantTypeTrustIDGroup, grant.GrantType)
+			return nil
+		}).Times(2)
+		mockEventDBModule.EXPECT().ReportEvent(ctx, "API_TEMPORARY_GRANT", "back-office", gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(),
+			gomock.Any(), gomock.Any()).Return(nil).Times(2)
+		assert.Nil(t, component.SetTemporaryTrustIDGroupsToUser(ctx, realm, userID, groupNames, expiresAt))
+	})
+
+	t.Run("Only the added trustID groups are temporary", func(t *testing.T) {
+		var previousExpiry = time.Now().Add(time.Minute)
+		var previousGrant = dto.DBTemporaryGrant{RealmName: realm, UserID: userID, GrantType: dto.GrantTypeTrustIDGroup, TargetID: "registration_officer",
+			ExpiresAt: previousExpiry, GrantedBy: "other"}
+		var groupNames = []string{"l1_support_agent", "registration_officer", "end_user"}
+		mockManagementComponent.EXPECT().GetTrustIDGroupsOfUser(ctx, realm, userID).Return([]string{"l1_support_agent", "registration_officer"}, nil)
+		mockGrantsDB.EXPECT().GetUserTemporaryGrants(ctx, realm, userID).Return([]dto.DBTemporaryGrant{previousGrant}, nil)
+		mockManagementComponent.EXPECT().SetTrustIDGroupsToUser(ctx, realm, userID, groupNames).Return(nil)
+		// l1_support_agent stays permanent and registration_officer keeps its expiry
+		mockGrantsDB.EXPECT().StoreTemporaryGrant(ctx, previousGrant).Return(nil)
+		mockGrantsDB.EXPECT().StoreTemporaryGrant(ctx, dto.DBTemporaryGrant{RealmName: realm, UserID: userID, GrantType: dto.GrantTypeTrustIDGroup,
+			TargetID: "end_user", ExpiresAt: expiresAt, GrantedBy: "operator"}).Return(nil)
+		mockEventDBModule.EXPECT().ReportEvent(ctx, "API_TEMPORARY_GRANT", "back-office", gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(),
+			gomock.Any(), gomock.Any()).Return(nil)
+		assert.Nil(t, component.SetTemporaryTrustIDGroupsToUser(ctx, realm, userID, groupNames, expiresAt))
+	})
+
+	t.Run("Role without identifier", func(t *testing.T) {
+		var err = component.AddTemporaryClientRolesToUser(ctx, realm, userID, clientID, []api.RoleRepresentation{{Name: &roleName}}, expiresAt)
+		assert.Equal(t, http.StatusBadRequest, err.(errorhandler.Error).Status)
+	})
+
+	t.Run("Current client roles can't be read", func(t *testing.T) {
+		var roles = []api.RoleRepresentation{{ID: &roleID, Name: &roleName}}
+		mockManagementComponent.EXPECT().GetClientRolesForUser(ctx, realm, userID, clientID).Return(nil, expectedError)
+		assert.Equal(t, expectedError, component.AddTemporaryClientRolesToUser(ctx, realm, userID, clientID, roles, expiresAt))
+	})
+
+	t.Run("User already has the client roles", func(t *testing.T) {
+		var roles = []api.RoleRepresentation{{ID: &roleID, Name: &roleName}}
+		mockManagementComponent.EXPECT().GetClientRolesForUser(ctx, realm, userID, clientID).Return([]api.RoleRepresentation{{ID: &roleID}}, nil)
+		assert.Nil(t, component.AddTemporaryClientRolesToUser(ctx, realm, userID, clientID, roles, expiresAt))
+	})
+
+	t.Run("Only the added client roles are temporary", func(t *testing.T) {
+		var otherRoleID = "e8a4c6d2-0a1d-4eee-9bb8-669c6f89c0ee"
+		var otherRoleName = "auditor"
+		var roles = []api.RoleRepresentation{{ID: &roleID, Name: &roleName}, {ID: &otherRoleID, Name: &otherRoleName}}
+		var addedRoles = []api.RoleRepresentation{{ID: &otherRoleID, Name: &otherRoleName}}
+		mockManagementComponent.EXPECT().GetClientRolesForUser(ctx, realm, userID, clientID).Return([]api.RoleRepresentation{{ID: &roleID}}, nil)
+		mockManagementComponent.EXPECT().AddClientRolesToUser(ctx, realm, userID, clientID, addedRoles).Return(nil)
+		mockGrantsDB.EXPECT().StoreTemporaryGrant(ctx, dto.DBTemporaryGrant{RealmName: realm, UserID: userID, GrantType: dto.GrantTypeClientRole,
+			TargetID: otherRoleID, ClientID: &clientID, Name: &otherRoleName, ExpiresAt: expiresAt, GrantedBy: "operator"}).Return(nil)
+		mockEventDBModule.EXPECT().ReportEvent(ctx, "API_TEMPORARY_GRANT", "back-office", gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(),
+			gomock.Any(), gomock.Any()).Return(nil)
+		assert.Nil(t, component.AddTemporaryClientRolesToUser(ctx, realm, userID, clientID, roles, expiresAt))
+	})
+
+	t.Run("Client roles are added temporarily", func(t *testing.T) {
+		var roles = []api.RoleRepresentation{{ID: &roleID, Name: &roleName}}
+		mockManagementComponent.EXPECT().GetClientRolesForUser(ctx, realm, userID, clientID).Return([]api.RoleRepresentation{}, nil)
+		mockManagementComponent.EXPECT().AddClientRolesToUser(ctx, realm, userID, clientID, roles).Return(nil)
+		mockGrantsDB.EXPECT().StoreTemporaryGrant(ctx, dto.DBTemporaryGrant{RealmName: realm, UserID: userID, GrantType: dto.GrantTypeClientRole,
+			TargetID: roleID, ClientID: &clientID, Name: &roleName, ExpiresAt: expiresAt, GrantedBy: "operator"}).Return(nil)
+		mockEventDBModule.EXPECT().ReportEvent(ctx, "API_TEMPORARY_GRANT", "back-office", gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(),
+			gomock.Any(), gomock.Any()).Return(nil)
+		assert.Nil(t, component.AddTemporaryClientRolesToUser(ctx, realm, userID, clientID, roles, expiresAt))
+	})
+}
+
+func TestTemporaryGrantsComponentMW(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+	var mockManagementComponent = mock.NewManagementComponent(mockCtrl)
+	var mockGrantsDB = mock.NewTemporaryGrantsDBModule(mockCtrl)
+
+	var component = MakeTemporaryGrantsComponentMW(mockGrantsDB, log.NewNopLogger())(mockManagementComponent)
+
+	var ctx = context.TODO()
+	var realm = "DEP"
+	var userID = "f467ed7c-0a1d-4eee-9bb8-669c6f89c0ee"
+	var groupID1, groupID2 = "group-1", "group-2"
+	var clientID = "client-id"
+	var roleID = "role-id"
+	var expiresAt = time.Unix(1591000000, 0)
+	var grants = []dto.DBTemporaryGrant{
+		{RealmName: realm, UserID: userID, GrantType: dto.GrantTypeGroup, TargetID: groupID1, ExpiresAt: expiresAt},
+		{RealmName: realm, UserID: userID, GrantType: dto.GrantTypeClientRole, TargetID: roleID, ClientID: &clientID, ExpiresAt: expiresAt},
+	}
+	var dbError = errors.New("db error")
+
+	t.Run("GetUser", func(t *testing.T) {
+		mockManagementComponent.EXPECT().GetUser(ctx, realm, userID).Return(api.UserRepresentation{}, nil)
+		mockGrantsDB.EXPECT().GetUserTemporaryGrants(ctx, realm, userID).Return(nil, dbError)
+		var _, err = component.GetUser(ctx, realm, userID)
+		assert.Equal(t, dbError, err)
+
+		mockManagementComponent.EXPECT().GetUser(ctx, realm, userID).Return(api.UserRepresentation{}, nil)
+		mockGrantsDB.EXPECT().GetUserTemporaryGrants(ctx, realm, userID).Return([]dto.DBTemporaryGrant{}, nil)
+		user, err := component.GetUser(ctx, realm, userID)
+		assert.Nil(t, err)
+		assert.Nil(t, user.TemporaryGrants)
+
+		mockManagementComponent.EXPECT().GetUser(ctx, realm, userID).Return(api.UserRepresentation{}, nil)
+		mockGrantsDB.EXPECT().GetUserTemporaryGrants(ctx, realm, userID).Return(grants, nil)
+		user, err = component.GetUser(ctx, realm, userID)
+		assert.Nil(t, err)
+		assert.Len(t, *user.TemporaryGrants, 2)
+		assert.Equal(t, expiresAt.Unix(), *(*user.TemporaryGrants)[0].ExpiresAt)
+	})
+
+	t.Run("GetGroupsOfUser", func(t *testing.T) {
+		var groups = []api.GroupRepresentation{{ID: &groupID1}, {ID: &groupID2}}
+		mockManagementComponent.EXPECT().GetGroupsOfUser(ctx, realm, userID).Return(groups, nil)
+		mockGrantsDB.EXPECT().GetUserTemporaryGrants(ctx, realm, userID).Return(grants, nil)
+		var res, err = component.GetGroupsOfUser(ctx, realm, userID)
+		assert.Nil(t, err)
+		assert.Equal(t, expiresAt.Unix(), *res[0].ExpiresAt)
+		assert.Nil(t, res[1].ExpiresAt)
+	})
+
+	t.Run("GetClientRolesForUser", func(t *testing.T) {
+		var roles = []api.RoleRepresentation{{ID: &roleID}}
+		mockManagementComponent.EXPECT().GetClientRolesForUser(ctx, realm, userID, clientID).Return(roles, nil)
+		mockGrantsDB.EXPECT().GetUserTemporaryGrants(ctx, realm, userID).Return(grants, nil)
+		var res, err = component.GetClientRolesForUser(ctx, realm, userID, clientID)
+		assert.Nil(t, err)
+		assert.Equal(t, expiresAt.Unix(), *res[0].ExpiresAt)
+	})
+
+	t.Run("Permanent grants replace the temporary ones", func(t *testing.T) {
+		mockManagementComponent.EXPECT().AddGroupToUser(ctx, realm, userID, groupID1).Return(nil)
+		mockGrantsDB.EXPECT().DeleteTemporaryGrant(ctx, realm, userID, dto.GrantTypeGroup, groupID1).Return(nil)
+		assert.Nil(t, component.AddGroupToUser(ctx, realm, userID, groupID1))
+
+		mockManagementComponent.EXPECT().SetTrustIDGroupsToUser(ctx, realm, userID, []string{}).Return(nil)
+		mockGrantsDB.EXPECT().DeleteTemporaryGrants(ctx, realm, userID, dto.GrantTypeTrustIDGroup).Return(nil)
+		assert.Nil(t, component.SetTrustIDGroupsToUser(ctx, realm, userID, []string{}))
+
+		var roles = []api.RoleRepresentation{{ID: &roleID}, {}}
+		mockManagementComponent.EXPECT().AddClientRolesToUser(ctx, realm, userID, clientID, roles).Return(nil)
+		mockGrantsDB.EXPECT().DeleteTemporaryGrant(ctx, realm, userID, dto.GrantTypeClientRole, roleID).Return(dbError)
+		assert.Equal(t, dbError, component.AddClientRolesToUser(ctx, realm, userID, clientID, roles))
+	})
+
+	t.Run("Removal forgets the temporary grant", func(t *testing.T) {
+		var kcError = errors.New("kc error")
+		mockManagementComponent.EXPECT().DeleteGroupForUser(ctx, realm, userID, groupID1).Return(kcError)
+		assert.Equal(t, kcError, component.DeleteGroupForUser(ctx, realm, userID, groupID1))
+
+		mockManagementComponent.EXPECT().DeleteGroupForUser(ctx, realm, userID, groupID1).Return(nil)
+		mockGrantsDB.EXPECT().DeleteTemporaryGrant(ctx, realm, userID, dto.GrantTypeGroup, groupID1).Return(nil)
+		assert.Nil(t, component.DeleteGroupForUser(ctx, realm, userID, groupID1))
+	})
+}
@@ -2,6 +2,8 @@ package management
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 	"net/http"
 
 	commonhttp "github.com/cloudtrust/common-service/http"
@@ -22,13 +24,20 @@ const (
 	reqScheme = "scheme"
 	reqHost   = "host"
 
-	prmRealm        = "realm"
-	prmUserID       = "userID"
-	prmClientID     = "clientID"
-	prmRoleID       = "roleID"
-	prmGroupID      = "groupID"
-	prmCredentialID = "credentialID"
-	prmProvider     = "provider"
+	prmRealm            = "realm"
+	prmUserID           = "userID"
+	prmClientID         = "clientID"
+	prmRoleID           = "roleID"
+	prmGroupID          = "groupID"
+	prmCredentialID     = "credentialID"
+	prmPrevCredentialID = "previousCredentialID"
+	prmProvider         = "provider"
+	prmScheduleID       = "scheduleID"
+	prmJobID            = "jobID"
+	prmApprovalID       = "approvalID"
+	prmChangeID         = "changeID"
+	prmSessionID        = "sessionID"
+	prmRevisionID       = "revisionID"
 
 	prmQryEmail       = "email"
 	prmQryFirstName   = "firstName"
@@ -42,6 +51,26 @@ const (
 	prmQryFirst       = "first"
 	prmQryMax         = "max"
 	prmQryGroupName   = "groupName"
+	prmQryFormat      = "format"
+	prmQryDryRun      = "dryRun"
+	prmQryColumns     = "columns"
+
+	prmQryIncludeDeleted = "includeDeleted"
+	prmQryStatus         = "status"
+	prmQryExpiresAt      = "expiresAt"
+	prmQryConfigType     = "type"
+	prmQryFromRevision   = "from"
+	prmQryToRevision     = "to"
+
+	prmQryPhoneNumber                = "phoneNumber"
+	prmQryBirthDate                  = "birthDate"
+	prmQryIDDocumentNumber           = "idDocumentNumber"
+	prmQryEnabled                    = "enabled"
+	prmQryCreatedAfter               = "createdAfter"
+	prmQryCreatedBefore              = "createdBefore"
+	prmQryAccreditationType          = "accreditationType"
+	prmQryAccreditationExpiresAfter  = "accreditationExpiresAfter"
+	prmQryAccreditationExpiresBefore = "accreditationExpiresBefore"
 )
 
 // MakeManagementHandler make an HTTP handler for a Management endpoint.
@@ -56,13 +85,20 @@ func MakeManagementHandler(e endpoint.Endpoint, logger log.Logger) *http_transpo
 // decodeEventsRequest gets the HTTP parameters and body content
 func decodeManagementRequest(ctx context.Context, req *http.Request) (interface{}, error) {
 	var pathParams = map[string]string{
-		prmRealm:        api.RegExpRealmName,
-		prmUserID:       api.RegExpID,
-		prmClientID:     api.RegExpClientID,
-		prmRoleID:       api.RegExpID,
-		prmGroupID:      api.RegExpID,
-		prmCredentialID: api.RegExpID,
-		prmProvider:     api.RegExpName,
+		prmRealm:            api.RegExpRealmName,
+		prmUserID:           api.RegExpID,
+		prmClientID:         api.RegExpClientID,
+		prmRoleID:           api.RegExpID,
+		prmGroupID:          api.RegExpID,
+		prmCredentialID:     api.RegExpID,
+		prmPrevCredentialID: api.RegExpIDNullable,
+		prmProvider:         api.RegExpName,
+		prmScheduleID:       api.RegExpNumber,
+		prmJobID:            api.RegExpJobID,
+		prmApprovalID:       api.RegExpNumber,
+		prmChangeID:         api.RegExpNumber,
+		prmSessionID:        api.RegExpID,
+		prmRevisionID:       api.RegExpNumber,
 	}
 
 	var queryParams = map[string]string{
@@ -78,6 +114,26 @@ func decodeManagementRequest(ctx context.Context, req *http.Request) (interface{
 		prmQryFirst:       api.RegExpNumber,
 		prmQryMax:         api.RegExpNumber,
 		prmQryGroupName:   api.RegExpName,
+		prmQryFormat:      api.RegExpFormat,
+		prmQryDryRun:      api.RegExpBoolean,
+		prmQryColumns:     api.RegExpColumns,
+
+		prmQryIncludeDeleted: api.RegExpBoolean,
+		prmQryStatus:         api.RegExpApprovalStatus,
+		prmQryExpiresAt:      api.RegExpNumber,
+		prmQryConfigType:     api.RegExpConfigType,
+		prmQryFromRevision:   api.RegExpNumber,
+		prmQryToRevision:     api.RegExpNumber,
+
+		prmQryPhoneNumber:                api.RegExpPhoneNumber,
+		prmQryBirthDate:                  api.RegExpDate,
+		prmQryIDDocumentNumber:           api.RegExpIDDocumentNumber,
+		prmQryEnabled:                    api.RegExpBoolean,
+		prmQryCreatedAfter:               api.RegExpDate,
+		prmQryCreatedBefore:              api.RegExpDate,
+		prmQryAccreditationType:          api.RegExpName,
+		prmQryAccreditationExpiresAfter:  api.RegExpDate,
+		prmQryAccreditationExpiresBefore: api.RegExpDate,
 	}
 
 	return commonhttp.DecodeRequest(ctx, req, pathParams, queryParams)
@@ -90,6 +146,11 @@ func encodeManagementReply(ctx context.Context, w http.ResponseWriter, rep inter
 		w.Header().Set("Location", r.URL)
 		w.WriteHeader(http.StatusCreated)
 		return nil
+	case UsersExport:
+		w.Header().Set("Content-Type", r.ContentType)
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", r.Filename))
+		w.WriteHeader(http.StatusOK)
+		return r.Write(w)
 	default:
 		return commonhttp.EncodeReply(ctx, w, rep)
 	}
@@ -103,6 +164,10 @@ func managementErrorHandler(logger log.Logger) func(context.Context, error, http
 		case kc_client.HTTPError:
 			w.WriteHeader(e.HTTPStatus)
 			w.Write([]byte(keycloakb.ComponentName + "." + msg.MsgErrUnknown))
+		case ApprovalRequiredError:
+			w.Header().Set("Content-Type", "application/json; charset=utf-8")
+			w.WriteHeader(http.StatusAccepted)
+			json.NewEncoder(w).Encode(e.Request)
 		default:
 			defaultHandler(ctx, err, w)
 
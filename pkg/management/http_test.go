@@ -218,6 +218,23 @@ func TestHTTPErrorHandler(t *testing.T) {
 		assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
 		assert.NotEqual(t, http.NoBody, res.Body)
 	}
+
+	// Approval required
+	{
+		var requestID = int64(12)
+		var approvalError = ApprovalRequiredError{Request: api.ApprovalRequestRepresentation{ID: &requestID}}
+		mockComponent.EXPECT().CreateUser(gomock.Any(), "master", user).Return("", approvalError).Times(1)
+
+		var body = strings.NewReader(string(userJSON))
+		res, err := http.Post(ts.URL+"/realms/master/users", "application/json", body)
+
+		assert.Nil(t, err)
+		assert.Equal(t, http.StatusAccepted, res.StatusCode)
+
+		var request api.ApprovalRequestRepresentation
+		assert.Nil(t, json.NewDecoder(res.Body).Decode(&request))
+		assert.Equal(t, requestID, *request.ID)
+	}
 }
 
 func TestHTTPXForwardHeaderHandler(t *testing.T) {
@@ -0,0 +1,261 @@
+package management
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	cs "github.com/cloudtrust/common-service"
+	"github.com/cloudtrust/common-service/database"
+	errorhandler "github.com/cloudtrust/common-service/errors"
+	"github.com/cloudtrust/common-service/idgenerator"
+	api "github.com/cloudtrust/keycloak-bridge/api/management"
+	"github.com/cloudtrust/keycloak-bridge/internal/constants"
+	"github.com/cloudtrust/keycloak-bridge/internal/dto"
+	"github.com/cloudtrust/keycloak-bridge/internal/keycloakb"
+	kc "github.com/cloudtrust/keycloak-client"
+	"github.com/pkg/errors"
+)
+
+const (
+	emailTemplateImpersonation = "notif-impersonation.ftl"
+	emailSubjectImpersonation  = "notifImpersonationSubject"
+)
+
+// ImpersonationsDBModule is the interface of the running impersonations storage
+type ImpersonationsDBModule interface {
+	CreateImpersonation(ctx context.Context, impersonation dto.DBImpersonation) (int64, error)
+	GetExpiredImpersonations(ctx context.Context, now time.Time) ([]dto.DBImpersonation, error)
+	DeleteImpersonation(ctx context.Context, impersonationID int64) error
+}
+
+// ImpersonationComponent is the interface of the impersonation of users by support agents. The impersonation sessions are
+// ended by the impersonation terminator once expired.
+type ImpersonationComponent interface {
+	ImpersonateUser(ctx context.Context, realmName, userID string, request api.ImpersonationRequestRepresentation) (api.ImpersonationRepresentation, error)
+}
+
+type impersonationComponent struct {
+	keycloakClient   KeycloakClient
+	tokenExchanger   keycloakb.TokenExchanger
+	impersonationsDB ImpersonationsDBModule
+	emailSender      keycloakb.EmailSender
+	eventDBModule    database.EventsDBModule
+	maxDuration      time.Duration
+	logger           keycloakb.Logger
+}
+
+// NewImpersonationComponent returns the impersonation component. The sessions are opened by token exchange and an
+// impersonation can't last longer than maxDuration.
+func NewImpersonationComponent(keycloakClient KeycloakClient, tokenExchanger keycloakb.TokenExchanger, impersonationsDB ImpersonationsDBModule,
+	emailSender keycloakb.EmailSender, eventDBModule database.EventsDBModule, maxDuration time.Duration, logger keycloakb.Logger) ImpersonationComponent {
+	return &impersonationComponent{
+		keycloakClient:   keycloakClient,
+		tokenExchanger:   tokenExchanger,
+		impersonationsDB: impersonationsDB,
+		emailSender:      emailSender,
+		eventDBModule:    eventDBModule,
+		maxDuration:      maxDuration,
+		logger:           logger,
+	}
+}
+
+// ImpersonateUser opens a session on behalf of the user and gives its tokens to the agent. The user is notified by email.
+func (c *impersonationComponent) ImpersonateUser(ctx context.Context, realmName, userID string, request api.ImpersonationRequestRepresentation) (api.ImpersonationRepresentation, error) {
+	var duration = c.maxDuration
+	if request.Duration != nil {
+		// Compared in seconds as a huge duration would overflow once converted
+		if *request.Duration > int64(c.maxDuration/time.Second) {
+			c.logger.Warn(ctx, "msg", "Impersonation duration exceeds the maximum", "duration", strconv.FormatInt(*request.Duration, 10), "max", c.maxDuration.String())
+			return api.ImpersonationRepresentation{}, errorhandler.CreateBadRequestError(constants.MsgErrInvalidParam + "." + constants.Duration)
+		}
+		duration = time.Duration(*request.Duration) * time.Second
+	}
+
+	var accessToken = ctx.Value(cs.CtContextAccessToken).(string)
+	var user, err = c.keycloakClient.GetUser(accessToken, realmName, userID)
+	if err != nil {
+		c.logger.Warn(ctx, "err", err.Error())
+		return api.ImpersonationRepresentation{}, err
+	}
+
+	token, err := c.tokenExchanger.ImpersonateUser(ctx, realmName, userID)
+	if err != nil {
+		c.logger.Warn(ctx, "err", err.Error())
+		return api.ImpersonationRepresentation{}, err
+	}
+
+	var now = time.Now().UTC()
+	var impersonation = dto.DBImpersonation{
+		RealmName:     realmName,
+		UserID:        userID,
+		Justification: *request.Justification,
+		StartedAt:     now,
+		ExpiresAt:     now.Add(duration),
+		AgentRealm:    ctx.Value(cs.CtContextRealm).(string),
+		AgentID:       ctx.Value(cs.CtContextUserID).(string),
+		AgentUsername: ctx.Value(cs.CtContextUsername).(string),
+	}
+	if token.SessionState != "" {
+		impersonation.SessionID = &token.SessionState
+	} else {
+		c.logger.Warn(ctx, "msg", "Keycloak did not return the impersonation session, all the sessions of the user will be ended at expiry", "realm", realmName, "userID", userID)
+	}
+	if _, err = c.impersonationsDB.CreateImpersonation(ctx, impersonation); err != nil {
+		// An impersonation which is not stored would never be ended
+		c.endSession(ctx, accessToken, impersonation)
+		return api.ImpersonationRepresentation{}, err
+	}
+
+	reportEvent(ctx, c.eventDBModule, c.logger, "API_IMPERSONATION_START", impersonationEventValues(impersonation, user.Username)...)
+	c.notifyUser(ctx, user, impersonation)
+
+	var expiresAt = impersonation.ExpiresAt.Unix()
+	return api.ImpersonationRepresentation{
+		AccessToken:  &token.AccessToken,
+		RefreshToken: &token.RefreshToken,
+		SessionID:    impersonation.SessionID,
+		ExpiresAt:    &expiresAt,
+	}, nil
+}
+
+func (c *impersonationComponent) endSession(ctx context.Context, accessToken string, impersonation dto.DBImpersonation) {
+	var err error
+	if impersonation.SessionID != nil {
+		err = c.keycloakClient.DeleteSession(accessToken, impersonation.RealmName, *impersonation.SessionID)
+	} else {
+		err = c.keycloakClient.LogoutUser(accessToken, impersonation.RealmName, impersonation.UserID)
+	}
+	if err != nil {
+		c.logger.Warn(ctx, "msg", "Can't end the impersonation session", "err", err.Error(), "realm", impersonation.RealmName, "userID", impersonation.UserID)
+	}
+}
+
+func (c *impersonationComponent) notifyUser(ctx context.Context, user kc.UserRepresentation, impersonation dto.DBImpersonation) {
+	if user.Email == nil || *user.Email == "" {
+		c.logger.Info(ctx, "msg", "Impersonated user has no email address", "userID", impersonation.UserID)
+		return
+	}
+
+	var email = keycloakb.Email{
+		Recipient: *user.Email,
+		Subject:   emailSubjectImpersonation,
+		Template:  emailTemplateImpersonation,
+		Attributes: map[string]string{
+			"agentUsername": impersonation.AgentUsername,
+			"justification": impersonation.Justification,
+			"startedAt":     strconv.FormatInt(impersonation.StartedAt.Unix(), 10),
+			"expiresAt":     strconv.FormatInt(impersonation.ExpiresAt.Unix(), 10),
+		},
+	}
+	if err := c.emailSender.SendEmail(ctx, impersonation.RealmName, email); err != nil {
+		c.logger.Warn(ctx, "msg", "Could not send impersonation notification", "err", err.Error(), "userID", impersonation.UserID)
+	}
+}
+
+func impersonationEventValues(impersonation dto.DBImpersonation, username *string) []string {
+	var sessionID string
+	if impersonation.SessionID != nil {
+		sessionID = *impersonation.SessionID
+	}
+	var additionalInfo = database.CreateAdditionalInfo("agent_realm", impersonation.AgentRealm, "agent_id", impersonation.AgentID,
+		"agent_username", impersonation.AgentUsername, "justification", impersonation.Justification, "session_id", sessionID,
+		"expires_at", strconv.FormatInt(impersonation.ExpiresAt.Unix(), 10))
+	var values = []string{database.CtEventRealmName, impersonation.RealmName, database.CtEventUserID, impersonation.UserID}
+	if username != nil {
+		values = append(values, database.CtEventUsername, *username)
+	}
+	return append(values, database.CtEventAdditionalInfo, additionalInfo)
+}
+
+// ImpersonationTerminator ends the impersonation sessions once they are expired
+type ImpersonationTerminator interface {
+	EndExpiredImpersonations(ctx context.Context, now time.Time) error
+	Run(c <-chan time.Time)
+}
+
+type impersonationTerminator struct {
+	keycloakClient   KeycloakClient
+	impersonationsDB ImpersonationsDBModule
+	tokenProvider    keycloakb.TokenProvider
+	eventDBModule    database.EventsDBModule
+	idGenerator      idgenerator.IDGenerator
+	logger           keycloakb.Logger
+}
+
+// NewImpersonationTerminator creates the terminator of the impersonations. Keycloak is called with the token of the technical user.
+func NewImpersonationTerminator(keycloakClient KeycloakClient, impersonationsDB ImpersonationsDBModule, tokenProvider keycloakb.TokenProvider,
+	eventDBModule database.EventsDBModule, idGenerator idgenerator.IDGenerator, logger keycloakb.Logger) ImpersonationTerminator {
+	return &impersonationTerminator{
+		keycloakClient:   keycloakClient,
+		impersonationsDB: impersonationsDB,
+		tokenProvider:    tokenProvider,
+		eventDBModule:    eventDBModule,
+		idGenerator:      idGenerator,
+		logger:           logger,
+	}
+}
+
+// Run ends the expired impersonations at each tick of the given channel
+func (t *impersonationTerminator) Run(c <-chan time.Time) {
+	for now := range c {
+		var ctx = context.WithValue(context.Background(), cs.CtContextCorrelationID, t.idGenerator.NextID())
+		if err := t.EndExpiredImpersonations(ctx, now); err != nil {
+			t.logger.Warn(ctx, "msg", "Can't end expired impersonations", "err", err.Error())
+		}
+	}
+}
+
+// EndExpiredImpersonations ends the impersonations whose expiry is passed. A failing impersonation does not prevent the others
+// from being ended, it is retried at the next run.
+func (t *impersonationTerminator) EndExpiredImpersonations(ctx context.Context, now time.Time) error {
+	var impersonations, err = t.impersonationsDB.GetExpiredImpersonations(ctx, now)
+	if err != nil || len(impersonations) == 0 {
+		return err
+	}
+
+	var accessToken string
+	if accessToken, err = t.tokenProvider.ProvideToken(ctx); err != nil {
+		t.logger.Warn(ctx, "msg", "Can't get technical token", "err", err.Error())
+		return err
+	}
+
+	for _, impersonation := range impersonations {
+		if err := t.endImpersonation(ctx, accessToken, impersonation); err != nil {
+			t.logger.Warn(ctx, "msg", "Can't end expired impersonation", "err", err.Error(), "realm", impersonation.RealmName,
+				"userID", impersonation.UserID, "impersonationID", impersonation.ID)
+		}
+	}
+	return nil
+}
+
+// endImpersonation ends the impersonation session. When the session could not be identified, all the sessions of the user
+// are ended: the impersonation must not outlive its expiry.
+func (t *impersonationTerminator) endImpersonation(ctx context.Context, accessToken string, impersonation dto.DBImpersonation) error {
+	var err error
+	if impersonation.SessionID != nil {
+		err = t.keycloakClient.DeleteSession(accessToken, impersonation.RealmName, *impersonation.SessionID)
+	} else {
+		err = t.keycloakClient.LogoutUser(accessToken, impersonation.RealmName, impersonation.UserID)
+	}
+	// The session may have been ended in the meantime
+	if e, ok := errors.Cause(err).(kc.HTTPError); ok && e.HTTPStatus == http.StatusNotFound {
+		err = nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if err = t.impersonationsDB.DeleteImpersonation(ctx, impersonation.ID); err != nil {
+		return err
+	}
+
+	// The end of the impersonation is reported on behalf of the agent
+	ctx = context.WithValue(ctx, cs.CtContextRealm, impersonation.AgentRealm)
+	ctx = context.WithValue(ctx, cs.CtContextUserID, impersonation.AgentID)
+	ctx = context.WithValue(ctx, cs.CtContextUsername, impersonation.AgentUsername)
+	reportEvent(ctx, t.eventDBModule, t.logger, "IMPERSONATION_END", impersonationEventValues(impersonation, nil)...)
+
+	return nil
+}
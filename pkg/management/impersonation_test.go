@@ -0,0 +1,210 @@
+package management
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"testing"
+	"time"
+
+	cs "github.com/cloudtrust/common-service"
+	"github.com/cloudtrust/common-service/database"
+	errorhandler "github.com/cloudtrust/common-service/errors"
+	"github.com/cloudtrust/common-service/log"
+	api "github.com/cloudtrust/keycloak-bridge/api/management"
+	"github.com/cloudtrust/keycloak-bridge/internal/dto"
+	"github.com/cloudtrust/keycloak-bridge/internal/keycloakb"
+	"github.com/cloudtrust/keycloak-bridge/pkg/management/mock"
+	kc "github.com/cloudtrust/keycloak-client"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestImpersonationComponent(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+	var mockKeycloakClient = mock.NewKeycloakClient(mockCtrl)
+	var mockTokenExchanger = mock.NewTokenExchanger(mockCtrl)
+	var mockImpersonationsDB = mock.NewImpersonationsDBModule(mockCtrl)
+	var mockEmailSender = mock.NewEmailSender(mockCtrl)
+	var mockEventDBModule = mock.NewEventDBModule(mockCtrl)
+
+	var component = NewImpersonationComponent(mockKeycloakClient, mockTokenExchanger, mockImpersonationsDB, mockEmailSender, mockEventDBModule, time.Hour, log.NewNopLogger())
+
+	var accessToken = "TOKEN=="
+	var ctx = context.WithValue(context.TODO(), cs.CtContextAccessToken, accessToken)
+	ctx = context.WithValue(ctx, cs.CtContextRealm, "master")
+	ctx = context.WithValue(ctx, cs.CtContextUserID, "agent-id")
+	ctx = context.WithValue(ctx, cs.CtContextUsername, "agent")
+	var realm = "DEP"
+	var userID = "f467ed7c-0a1d-4eee-9bb8-669c6f89c0ee"
+	var username = "jdoe"
+	var email = "jdoe@example.com"
+	var user = kc.UserRepresentation{ID: &userID, Username: &username, Email: &email}
+	var justification = "Ticket #1234: documents are missing"
+	var request = api.ImpersonationRequestRepresentation{Justification: &justification}
+	var sessionID = "impersonation-session"
+	var token = keycloakb.ImpersonationToken{AccessToken: "USER-ACCESS", RefreshToken: "USER-REFRESH", ExpiresIn: 300, SessionState: sessionID}
+	var expectedError = errors.New("kc error")
+
+	t.Run("Duration exceeds the maximum", func(t *testing.T) {
+		var duration = int64(7200)
+		var _, err = component.ImpersonateUser(ctx, realm, userID, api.ImpersonationRequestRepresentation{Justification: &justification, Duration: &duration})
+		assert.Equal(t, http.StatusBadRequest, err.(errorhandler.Error).Status)
+	})
+
+	t.Run("Duration overflows", func(t *testing.T) {
+		var duration = int64(math.MaxInt64 / 100)
+		var _, err = component.ImpersonateUser(ctx, realm, userID, api.ImpersonationRequestRepresentation{Justification: &justification, Duration: &duration})
+		assert.Equal(t, http.StatusBadRequest, err.(errorhandler.Error).Status)
+	})
+
+	t.Run("Can't get user", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().GetUser(accessToken, realm, userID).Return(kc.UserRepresentation{}, expectedError)
+		var _, err = component.ImpersonateUser(ctx, realm, userID, request)
+		assert.Equal(t, expectedError, err)
+	})
+
+	t.Run("Token exchange fails", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().GetUser(accessToken, realm, userID).Return(user, nil)
+		mockTokenExchanger.EXPECT().ImpersonateUser(ctx, realm, userID).Return(keycloakb.ImpersonationToken{}, expectedError)
+		var _, err = component.ImpersonateUser(ctx, realm, userID, request)
+		assert.Equal(t, expectedError, err)
+	})
+
+	t.Run("Impersonation can't be stored: the session is ended", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().GetUser(accessToken, realm, userID).Return(user, nil)
+		mockTokenExchanger.EXPECT().ImpersonateUser(ctx, realm, userID).Return(token, nil)
+		mockImpersonationsDB.EXPECT().CreateImpersonation(ctx, gomock.Any()).Return(int64(0), expectedError)
+		mockKeycloakClient.EXPECT().DeleteSession(accessToken, realm, sessionID).Return(errors.New("kc error"))
+		var _, err = component.ImpersonateUser(ctx, realm, userID, request)
+		assert.Equal(t, expectedError, err)
+	})
+
+	t.Run("User is impersonated and notified", func(t *testing.T) {
+		var duration = int64(600)
+		mockKeycloakClient.EXPECT().GetUser(accessToken, realm, userID).Return(user, nil)
+		mockTokenExchanger.EXPECT().ImpersonateUser(ctx, realm, userID).Return(token, nil)
+		mockImpersonationsDB.EXPECT().CreateImpersonation(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, impersonation dto.DBImpersonation) (int64, error) {
+			assert.Equal(t, sessionID, *impersonation.SessionID)
+			assert.Equal(t, justification, impersonation.Justification)
+			assert.Equal(t, 10*time.Minute, impersonation.ExpiresAt.Sub(impersonation.StartedAt))
+			assert.Equal(t, "agent", impersonation.AgentUsername)
+			return 3, nil
+		})
+		mockEventDBModule.EXPECT().ReportEvent(ctx, "API_IMPERSONATION_START", "back-office", database.CtEventRealmName, realm, database.CtEventUserID, userID,
+			database.CtEventUsername, username, database.CtEventAdditionalInfo, gomock.Any()).Return(nil)
+		mockEmailSender.EXPECT().SendEmail(ctx, realm, gomock.Any()).DoAndReturn(func(_ context.Context, _ string, mail keycloakb.Email) error {
+			assert.Equal(t, email, mail.Recipient)
+			assert.Equal(t, emailTemplateImpersonation, mail.Template)
+			assert.Equal(t, "agent", mail.Attributes["agentUsername"])
+			return errors.New("smtp error")
+		})
+		var res, err = component.ImpersonateUser(ctx, realm, userID, api.ImpersonationRequestRepresentation{Justification: &justification, Duration: &duration})
+		assert.Nil(t, err)
+		// The agent receives the tokens of the session opened on behalf of the user
+		assert.Equal(t, "USER-ACCESS", *res.AccessToken)
+		assert.Equal(t, "USER-REFRESH", *res.RefreshToken)
+		assert.Equal(t, sessionID, *res.SessionID)
+		assert.NotNil(t, res.ExpiresAt)
+	})
+
+	t.Run("Session is not returned and user has no email", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().GetUser(accessToken, realm, userID).Return(kc.UserRepresentation{ID: &userID, Username: &username}, nil)
+		mockTokenExchanger.EXPECT().ImpersonateUser(ctx, realm, userID).Return(keycloakb.ImpersonationToken{AccessToken: "USER-ACCESS", RefreshToken: "USER-REFRESH"}, nil)
+		mockImpersonationsDB.EXPECT().CreateImpersonation(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, impersonation dto.DBImpersonation) (int64, error) {
+			assert.Nil(t, impersonation.SessionID)
+			assert.Equal(t, time.Hour, impersonation.ExpiresAt.Sub(impersonation.StartedAt))
+			return 4, nil
+		})
+		mockEventDBModule.EXPECT().ReportEvent(ctx, "API_IMPERSONATION_START", "back-office", gomock.Any()).Return(nil)
+		var res, err = component.ImpersonateUser(ctx, realm, userID, request)
+		assert.Nil(t, err)
+		assert.Equal(t, "USER-ACCESS", *res.AccessToken)
+		assert.Nil(t, res.SessionID)
+	})
+}
+
+func TestImpersonationTerminator(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+	var mockKeycloakClient = mock.NewKeycloakClient(mockCtrl)
+	var mockImpersonationsDB = mock.NewImpersonationsDBModule(mockCtrl)
+	var mockTokenProvider = mock.NewTokenProvider(mockCtrl)
+	var mockEventDBModule = mock.NewEventDBModule(mockCtrl)
+	var mockIDGenerator = mock.NewIDGenerator(mockCtrl)
+
+	var terminator = NewImpersonationTerminator(mockKeycloakClient, mockImpersonationsDB, mockTokenProvider, mockEventDBModule, mockIDGenerator, log.NewNopLogger())
+
+	var ctx = context.TODO()
+	var accessToken = "TOKEN=="
+	var realm = "DEP"
+	var userID = "f467ed7c-0a1d-4eee-9bb8-669c6f89c0ee"
+	var sessionID = "impersonation-session"
+	var now = time.Now()
+	var first = dto.DBImpersonation{ID: 1, RealmName: realm, UserID: userID, SessionID: &sessionID, AgentRealm: "master", AgentID: "agent-id",
+		AgentUsername: "agent"}
+	var second = dto.DBImpersonation{ID: 2, RealmName: realm, UserID: userID, SessionID: &sessionID}
+	var third = dto.DBImpersonation{ID: 3, RealmName: realm, UserID: userID}
+
+	t.Run("Nothing to end", func(t *testing.T) {
+		mockImpersonationsDB.EXPECT().GetExpiredImpersonations(ctx, now).Return([]dto.DBImpersonation{}, nil)
+		assert.Nil(t, terminator.EndExpiredImpersonations(ctx, now))
+	})
+
+	t.Run("Can't get technical token", func(t *testing.T) {
+		var expectedError = errors.New("kc error")
+		mockImpersonationsDB.EXPECT().GetExpiredImpersonations(ctx, now).Return([]dto.DBImpersonation{first}, nil)
+		mockTokenProvider.EXPECT().ProvideToken(ctx).Return("", expectedError)
+		assert.Equal(t, expectedError, terminator.EndExpiredImpersonations(ctx, now))
+	})
+
+	t.Run("Failing impersonation does not prevent the others from being ended", func(t *testing.T) {
+		mockImpersonationsDB.EXPECT().GetExpiredImpersonations(ctx, now).Return([]dto.DBImpersonation{first, second, third}, nil)
+		mockTokenProvider.EXPECT().ProvideToken(ctx).Return(accessToken, nil)
+		mockKeycloakClient.EXPECT().DeleteSession(accessToken, realm, sessionID).Return(errors.New("kc error"))
+		mockKeycloakClient.EXPECT().DeleteSession(accessToken, realm, sessionID).Return(kc.HTTPError{HTTPStatus: 404})
+		mockImpersonationsDB.EXPECT().DeleteImpersonation(ctx, int64(2)).Return(nil)
+		mockEventDBModule.EXPECT().ReportEvent(gomock.Any(), "IMPERSONATION_END", "back-office", database.CtEventRealmName, realm,
+			database.CtEventUserID, userID, database.CtEventAdditionalInfo, gomock.Any()).Return(nil)
+		// The session of the third impersonation is unknown: all the sessions of the user are ended
+		mockKeycloakClient.EXPECT().LogoutUser(accessToken, realm, userID).Return(nil)
+		mockImpersonationsDB.EXPECT().DeleteImpersonation(ctx, int64(3)).Return(nil)
+		mockEventDBModule.EXPECT().ReportEvent(gomock.Any(), "IMPERSONATION_END", "back-office", database.CtEventRealmName, realm,
+			database.CtEventUserID, userID, database.CtEventAdditionalInfo, gomock.Any()).Return(errors.New("db error"))
+		assert.Nil(t, terminator.EndExpiredImpersonations(ctx, now))
+	})
+
+	t.Run("Sessions of the user can't be ended", func(t *testing.T) {
+		mockImpersonationsDB.EXPECT().GetExpiredImpersonations(ctx, now).Return([]dto.DBImpersonation{third}, nil)
+		mockTokenProvider.EXPECT().ProvideToken(ctx).Return(accessToken, nil)
+		// The impersonation is kept to be retried at the next run
+		mockKeycloakClient.EXPECT().LogoutUser(accessToken, realm, userID).Return(errors.New("kc error"))
+		assert.Nil(t, terminator.EndExpiredImpersonations(ctx, now))
+	})
+
+	t.Run("End is reported on behalf of the agent", func(t *testing.T) {
+		mockImpersonationsDB.EXPECT().GetExpiredImpersonations(ctx, now).Return([]dto.DBImpersonation{first}, nil)
+		mockTokenProvider.EXPECT().ProvideToken(ctx).Return(accessToken, nil)
+		mockKeycloakClient.EXPECT().DeleteSession(accessToken, realm, sessionID).Return(nil)
+		mockImpersonationsDB.EXPECT().DeleteImpersonation(ctx, int64(1)).Return(nil)
+		mockEventDBModule.EXPECT().ReportEvent(gomock.Any(), "IMPERSONATION_END", "back-office", gomock.Any()).DoAndReturn(
+			func(ctx context.Context, _, _ string, _ ...string) error {
+				assert.Equal(t, "agent", ctx.Value(cs.CtContextUsername))
+				assert.Equal(t, "master", ctx.Value(cs.CtContextRealm))
+				return nil
+			})
+		assert.Nil(t, terminator.EndExpiredImpersonations(ctx, now))
+	})
+
+	t.Run("Run", func(t *testing.T) {
+		var c = make(chan time.Time, 1)
+		var ctxRun = context.WithValue(context.Background(), cs.CtContextCorrelationID, "corr-id")
+		mockIDGenerator.EXPECT().NextID().Return("corr-id")
+		mockImpersonationsDB.EXPECT().GetExpiredImpersonations(ctxRun, now).Return(nil, errors.New("db error"))
+		c <- now
+		close(c)
+		terminator.Run(c)
+	})
+}
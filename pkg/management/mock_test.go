@@ -10,3 +10,19 @@ package management
 //go:generate mockgen -destination=./mock/database.go -package=mock -mock_names=Transaction=Transaction github.com/cloudtrust/common-service/database/sqltypes Transaction
 //go:generate mockgen -destination=./mock/authentication_db_reader.go -package=mock -mock_names=AuthorizationDBReader=AuthorizationDBReader github.com/cloudtrust/common-service/security AuthorizationDBReader
 //go:generate mockgen -destination=./mock/usersdbmodule.go -package=mock -mock_names=UsersDetailsDBModule=UsersDetailsDBModule github.com/cloudtrust/keycloak-bridge/pkg/management UsersDetailsDBModule
+//go:generate mockgen -destination=./mock/bulk.go -package=mock -mock_names=BulkComponent=BulkComponent github.com/cloudtrust/keycloak-bridge/pkg/management BulkComponent
+//go:generate mockgen -destination=./mock/jobs.go -package=mock -mock_names=JobStore=JobStore github.com/cloudtrust/keycloak-bridge/internal/keycloakb JobStore
+//go:generate mockgen -destination=./mock/security.go -package=mock -mock_names=AuthorizationManager=AuthorizationManager github.com/cloudtrust/common-service/security AuthorizationManager
+//go:generate mockgen -destination=./mock/search.go -package=mock -mock_names=SearchComponent=SearchComponent,UsersIndexer=UsersIndexer,UsersSearchIndexDBModule=UsersSearchIndexDBModule github.com/cloudtrust/keycloak-bridge/pkg/management SearchComponent,UsersIndexer,UsersSearchIndexDBModule
+//go:generate mockgen -destination=./mock/blindindex.go -package=mock -mock_names=BlindIndexer=BlindIndexer github.com/cloudtrust/keycloak-bridge/internal/keycloakb BlindIndexer
+//go:generate mockgen -destination=./mock/softdeletion.go -package=mock -mock_names=SoftDeletionComponent=SoftDeletionComponent,UserSoftDeletion=UserSoftDeletion github.com/cloudtrust/keycloak-bridge/pkg/management SoftDeletionComponent,UserSoftDeletion
+//go:generate mockgen -destination=./mock/approval.go -package=mock -mock_names=ApprovalComponent=ApprovalComponent,ApprovalsDBModule=ApprovalsDBModule github.com/cloudtrust/keycloak-bridge/pkg/management ApprovalComponent,ApprovalsDBModule
+//go:generate mockgen -destination=./mock/grants.go -package=mock -mock_names=GrantsComponent=GrantsComponent,TemporaryGrantsDBModule=TemporaryGrantsDBModule github.com/cloudtrust/keycloak-bridge/pkg/management GrantsComponent,TemporaryGrantsDBModule
+//go:generate mockgen -destination=./mock/scheduledchanges.go -package=mock -mock_names=ScheduledChangesComponent=ScheduledChangesComponent,ScheduledUserChangesDBModule=ScheduledUserChangesDBModule github.com/cloudtrust/keycloak-bridge/pkg/management ScheduledChangesComponent,ScheduledUserChangesDBModule
+//go:generate mockgen -destination=./mock/impersonation.go -package=mock -mock_names=ImpersonationComponent=ImpersonationComponent,ImpersonationsDBModule=ImpersonationsDBModule github.com/cloudtrust/keycloak-bridge/pkg/management ImpersonationComponent,ImpersonationsDBModule
+//go:generate mockgen -destination=./mock/emailsender.go -package=mock -mock_names=EmailSender=EmailSender github.com/cloudtrust/keycloak-bridge/internal/keycloakb EmailSender
+//go:generate mockgen -destination=./mock/tokenexchanger.go -package=mock -mock_names=TokenExchanger=TokenExchanger github.com/cloudtrust/keycloak-bridge/internal/keycloakb TokenExchanger
+//go:generate mockgen -destination=./mock/tokenprovider.go -package=mock -mock_names=TokenProvider=TokenProvider github.com/cloudtrust/keycloak-bridge/internal/keycloakb TokenProvider
+//go:generate mockgen -destination=./mock/idgenerator.go -package=mock -mock_names=IDGenerator=IDGenerator github.com/cloudtrust/common-service/idgenerator IDGenerator
+//go:generate mockgen -destination=./mock/provisioning.go -package=mock -mock_names=ProvisioningComponent=ProvisioningComponent github.com/cloudtrust/keycloak-bridge/pkg/management ProvisioningComponent
+//go:generate mockgen -destination=./mock/authorizationsreport.go -package=mock -mock_names=AuthorizationsReportComponent=AuthorizationsReportComponent github.com/cloudtrust/keycloak-bridge/pkg/management AuthorizationsReportComponent
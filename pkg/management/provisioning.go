@@ -0,0 +1,422 @@
+package management
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"strconv"
+
+	cs "github.com/cloudtrust/common-service"
+	"github.com/cloudtrust/common-service/database"
+	errorhandler "github.com/cloudtrust/common-service/errors"
+	api "github.com/cloudtrust/keycloak-bridge/api/management"
+	"github.com/cloudtrust/keycloak-bridge/internal/constants"
+	"github.com/cloudtrust/keycloak-bridge/internal/keycloakb"
+	kc "github.com/cloudtrust/keycloak-client"
+	"github.com/pkg/errors"
+)
+
+// ProvisioningComponent is the interface of the realm provisioning. A realm template is applied in one operation.
+type ProvisioningComponent interface {
+	ProvisionRealm(ctx context.Context, template api.RealmTemplateRepresentation, dryRun bool) (api.ProvisioningPlanRepresentation, error)
+}
+
+type provisioningComponent struct {
+	keycloakClient KeycloakClient
+	component      Component
+	approvalsDB    ApprovalsDBModule
+	eventDBModule  database.EventsDBModule
+	logger         keycloakb.Logger
+}
+
+// provisioningStepActions are the management actions applied by the provisioning steps which can require an approval
+var provisioningStepActions = map[string]string{
+	api.ProvisioningStepAdminConfiguration: MGMTUpdateRealmAdminConfiguration.String(),
+	api.ProvisioningStepAuthorizations:     MGMTUpdateAuthorizations.String(),
+}
+
+// provisioningStep is a step of a provisioning plan. undo reverts apply when a later step fails.
+type provisioningStep struct {
+	api.ProvisioningStepRepresentation
+	apply func() error
+	undo  func() error
+}
+
+// realmProvisioning is the state shared by the steps of the provisioning of a realm. The identifiers of the groups are
+// known once their step is applied.
+type realmProvisioning struct {
+	accessToken string
+	realmName   string
+	realmExists bool
+	groupIDs    map[string]string
+	steps       []provisioningStep
+}
+
+func (p *realmProvisioning) addStep(stepType, name, action string, apply, undo func() error) {
+	p.steps = append(p.steps, provisioningStep{
+		ProvisioningStepRepresentation: api.ProvisioningStepRepresentation{Type: stepType, Name: name, Action: action},
+		apply:                          apply,
+		undo:                           undo,
+	})
+}
+
+func noProvisioningUndo() error {
+	return nil
+}
+
+// NewProvisioningComponent returns the realm provisioning component. The realm settings are provisioned with the given
+// Keycloak client, the other parts with the given management component which must not check authorizations: the
+// operator is authorized once for the whole template.
+func NewProvisioningComponent(keycloakClient KeycloakClient, component Component, approvalsDB ApprovalsDBModule, eventDBModule database.EventsDBModule, logger keycloakb.Logger) ProvisioningComponent {
+	return &provisioningComponent{
+		keycloakClient: keycloakClient,
+		component:      component,
+		approvalsDB:    approvalsDB,
+		eventDBModule:  eventDBModule,
+		logger:         logger,
+	}
+}
+
+// ProvisionRealm compares the template with the current state of the realm and applies the differences. Applying the
+// same template twice changes nothing. With dryRun, the plan is returned without being applied. When a step fails,
+// the steps already applied are reverted in reverse order. A template which changes a part of the realm whose updates
+// require an approval is refused: the pending request could not be reverted with the other steps.
+func (c *provisioningComponent) ProvisionRealm(ctx context.Context, template api.RealmTemplateRepresentation, dryRun bool) (api.ProvisioningPlanRepresentation, error) {
+	var p = &realmProvisioning{
+		accessToken: ctx.Value(cs.CtContextAccessToken).(string),
+		realmName:   *template.Realm,
+		groupIDs:    make(map[string]string),
+	}
+
+	for _, planStep := range []func(context.Context, *realmProvisioning, api.RealmTemplateRepresentation) error{
+		c.planRealm, c.planClients, c.planConfigurations, c.planGroups,
+	} {
+		if err := planStep(ctx, p, template); err != nil {
+			return api.ProvisioningPlanRepresentation{}, err
+		}
+	}
+	if err := c.checkApprovalPolicy(ctx, p); err != nil {
+		return api.ProvisioningPlanRepresentation{}, err
+	}
+
+	var plan = api.ProvisioningPlanRepresentation{Realm: p.realmName, Steps: []api.ProvisioningStepRepresentation{}}
+	for _, step := range p.steps {
+		plan.Steps = append(plan.Steps, step.ProvisioningStepRepresentation)
+	}
+	if dryRun {
+		return plan, nil
+	}
+
+	var applied []provisioningStep
+	for _, step := range p.steps {
+		if step.Action == api.ProvisioningActionUnchanged {
+			continue
+		}
+		if err := step.apply(); err != nil {
+			c.logger.Warn(ctx, "msg", "Provisioning step failed", "err", err.Error(), "realm", p.realmName, "type", step.Type, "name", step.Name)
+			c.rollback(ctx, p.realmName, applied)
+			return api.ProvisioningPlanRepresentation{}, err
+		}
+		applied = append(applied, step)
+	}
+	plan.Applied = true
+
+	if len(applied) == 0 {
+		return plan, nil
+	}
+	reportEvent(ctx, c.eventDBModule, c.logger, "API_REALM_PROVISIONING", database.CtEventRealmName, p.realmName, database.CtEventAdditionalInfo,
+		database.CreateAdditionalInfo("applied_steps", strconv.Itoa(len(applied))))
+
+	return plan, nil
+}
+
+// checkApprovalPolicy refuses the plan when one of its changes requires an approval in the realm
+func (c *provisioningComponent) checkApprovalPolicy(ctx context.Context, p *realmProvisioning) error {
+	if !p.realmExists {
+		return nil
+	}
+
+	var policy map[string]bool
+	for _, step := range p.steps {
+		var action, ok = provisioningStepActions[step.Type]
+		if !ok || step.Action == api.ProvisioningActionUnchanged {
+			continue
+		}
+		if policy == nil {
+			var actions, err = c.approvalsDB.GetApprovalPolicy(ctx, p.realmName)
+			if err != nil {
+				return err
+			}
+			policy = make(map[string]bool)
+			for _, value := range actions {
+				policy[value] = true
+			}
+		}
+		if policy[action] {
+			c.logger.Warn(ctx, "msg", "Provisioning step requires an approval", "realm", p.realmName, "type", step.Type, "name", step.Name)
+			return errorhandler.Error{
+				Status:  http.StatusConflict,
+				Message: keycloakb.ComponentName + "." + constants.MsgErrApprovalRequired + "." + step.Type,
+			}
+		}
+	}
+	return nil
+}
+
+func (c *provisioningComponent) rollback(ctx context.Context, realmName string, applied []provisioningStep) {
+	for i := len(applied) - 1; i >= 0; i-- {
+		var step = applied[i]
+		if err := step.undo(); err != nil {
+			c.logger.Error(ctx, "msg", "Can't roll back provisioning step", "err", err.Error(), "realm", realmName, "type", step.Type, "name", step.Name)
+		}
+	}
+}
+
+func (c *provisioningComponent) planRealm(ctx context.Context, p *realmProvisioning, template api.RealmTemplateRepresentation) error {
+	var desired = kc.RealmRepresentation{Realm: template.Realm, DisplayName: template.DisplayName, Enabled: template.Enabled}
+	if template.PasswordPolicy != nil {
+		var policy = template.PasswordPolicy.ConvertToKeycloakbStruct().String()
+		desired.PasswordPolicy = &policy
+	}
+
+	current, err := c.keycloakClient.GetRealm(p.accessToken, p.realmName)
+	if err != nil {
+		if e, ok := errors.Cause(err).(kc.HTTPError); !ok || e.HTTPStatus != http.StatusNotFound {
+			c.logger.Warn(ctx, "err", err.Error())
+			return err
+		}
+		p.addStep(api.ProvisioningStepRealm, p.realmName, api.ProvisioningActionCreate, func() error {
+			_, err := c.keycloakClient.CreateRealm(p.accessToken, desired)
+			return err
+		}, func() error {
+			return c.keycloakClient.DeleteRealm(p.accessToken, p.realmName)
+		})
+		return nil
+	}
+	p.realmExists = true
+
+	var action = api.ProvisioningActionUnchanged
+	if isProvisionedValueChanged(desired.DisplayName, current.DisplayName) || isProvisionedValueChanged(desired.Enabled, current.Enabled) ||
+		isPasswordPolicyChanged(desired.PasswordPolicy, current.PasswordPolicy) {
+		action = api.ProvisioningActionUpdate
+	}
+	var previous = kc.RealmRepresentation{DisplayName: current.DisplayName, Enabled: current.Enabled, PasswordPolicy: current.PasswordPolicy}
+	p.addStep(api.ProvisioningStepRealm, p.realmName, action, func() error {
+		return c.keycloakClient.UpdateRealm(p.accessToken, p.realmName, desired)
+	}, func() error {
+		return c.keycloakClient.UpdateRealm(p.accessToken, p.realmName, previous)
+	})
+	return nil
+}
+
+// isProvisionedValueChanged returns true when a value given by the template differs from the current one
+func isProvisionedValueChanged(desired interface{}, current interface{}) bool {
+	return !reflect.ValueOf(desired).IsNil() && !reflect.DeepEqual(desired, current)
+}
+
+func isPasswordPolicyChanged(desired *string, current *string) bool {
+	if desired == nil {
+		return false
+	}
+	if current == nil {
+		return *desired != ""
+	}
+	// Keycloak does not keep the order of the policies
+	var policy, err = keycloakb.ParsePasswordPolicy(*current)
+	return err != nil || policy.String() != *desired
+}
+
+func (c *provisioningComponent) planClients(ctx context.Context, p *realmProvisioning, template api.RealmTemplateRepresentation) error {
+	var currentClients = make(map[string]kc.ClientRepresentation)
+	if p.realmExists && len(template.Clients) > 0 {
+		clients, err := c.keycloakClient.GetClients(p.accessToken, p.realmName)
+		if err != nil {
+			c.logger.Warn(ctx, "err", err.Error())
+			return err
+		}
+		for _, client := range clients {
+			if client.ClientID != nil {
+				currentClients[*client.ClientID] = client
+			}
+		}
+	}
+
+	for _, templateClient := range template.Clients {
+		var client = templateClient
+		var clientID = *client.ClientID
+		current, ok := currentClients[clientID]
+		if !ok {
+			var id string
+			p.addStep(api.ProvisioningStepClient, clientID, api.ProvisioningActionCreate, func() error {
+				location, err := c.component.CreateClient(ctx, p.realmName, client)
+				id = idInLocation.FindString(location)
+				return err
+			}, func() error {
+				return c.keycloakClient.DeleteClient(p.accessToken, p.realmName, id)
+			})
+			continue
+		}
+
+		var desired = current
+		api.MergeIntoKCClient(client, &desired)
+		var action = api.ProvisioningActionUnchanged
+		if !reflect.DeepEqual(desired, current) {
+			action = api.ProvisioningActionUpdate
+		}
+		p.addStep(api.ProvisioningStepClient, clientID, action, func() error {
+			return c.component.UpdateClient(ctx, p.realmName, *current.ID, client)
+		}, func() error {
+			return c.keycloakClient.UpdateClient(p.accessToken, p.realmName, *current.ID, current)
+		})
+	}
+	return nil
+}
+
+// planConfigurations plans the custom and the admin configurations. The configurations of a created realm are not
+// reverted: they are bound to the identifier of the deleted realm.
+func (c *provisioningComponent) planConfigurations(ctx context.Context, p *realmProvisioning, template api.RealmTemplateRepresentation) error {
+	if template.CustomConfiguration != nil {
+		var desired = *template.CustomConfiguration
+		var action, undo = api.ProvisioningActionCreate, noProvisioningUndo
+		if p.realmExists {
+			current, err := c.component.GetRealmCustomConfiguration(ctx, p.realmName)
+			if err != nil {
+				return err
+			}
+			action = provisioningAction(reflect.DeepEqual(desired, current))
+			undo = func() error {
+				return c.component.UpdateRealmCustomConfiguration(ctx, p.realmName, current)
+			}
+		}
+		p.addStep(api.ProvisioningStepCustomConfiguration, p.realmName, action, func() error {
+			return c.component.UpdateRealmCustomConfiguration(ctx, p.realmName, desired)
+		}, undo)
+	}
+
+	if template.AdminConfiguration != nil {
+		var desired = *template.AdminConfiguration
+		var action, undo = api.ProvisioningActionCreate, noProvisioningUndo
+		if p.realmExists {
+			current, err := c.component.GetRealmAdminConfiguration(ctx, p.realmName)
+			if err != nil {
+				return err
+			}
+			action = provisioningAction(reflect.DeepEqual(desired, current))
+			undo = func() error {
+				return c.component.UpdateRealmAdminConfiguration(ctx, p.realmName, current)
+			}
+		}
+		p.addStep(api.ProvisioningStepAdminConfiguration, p.realmName, action, func() error {
+			return c.component.UpdateRealmAdminConfiguration(ctx, p.realmName, desired)
+		}, undo)
+	}
+	return nil
+}
+
+func provisioningAction(unchanged bool) string {
+	if unchanged {
+		return api.ProvisioningActionUnchanged
+	}
+	return api.ProvisioningActionUpdate
+}
+
+// planGroups plans all the groups before their authorizations as authorizations can target the other groups of the
+// template
+func (c *provisioningComponent) planGroups(ctx context.Context, p *realmProvisioning, template api.RealmTemplateRepresentation) error {
+	var existingGroups = make(map[string]bool)
+	if p.realmExists && len(template.Groups) > 0 {
+		groups, err := c.keycloakClient.GetGroups(p.accessToken, p.realmName)
+		if err != nil {
+			c.logger.Warn(ctx, "err", err.Error())
+			return err
+		}
+		for _, group := range groups {
+			if group.Name != nil && group.ID != nil {
+				p.groupIDs[*group.Name] = *group.ID
+				existingGroups[*group.Name] = true
+			}
+		}
+	}
+
+	for _, templateGroup := range template.Groups {
+		var groupName = *templateGroup.Name
+		if existingGroups[groupName] {
+			p.addStep(api.ProvisioningStepGroup, groupName, api.ProvisioningActionUnchanged, nil, nil)
+			continue
+		}
+		p.addStep(api.ProvisioningStepGroup, groupName, api.ProvisioningActionCreate, func() error {
+			location, err := c.component.CreateGroup(ctx, p.realmName, api.GroupRepresentation{Name: &groupName})
+			p.groupIDs[groupName] = idInLocation.FindString(location)
+			return err
+		}, func() error {
+			// Deleting the group also deletes its authorizations
+			return c.component.DeleteGroup(ctx, p.realmName, p.groupIDs[groupName])
+		})
+	}
+
+	for _, templateGroup := range template.Groups {
+		if err := c.planGroupAuthorizations(ctx, p, templateGroup, existingGroups[*templateGroup.Name]); err != nil {
+			return err
+		}
+		if err := c.planGroupBackOfficeConfiguration(ctx, p, templateGroup); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *provisioningComponent) planGroupAuthorizations(ctx context.Context, p *realmProvisioning, group api.GroupTemplateRepresentation, groupExists bool) error {
+	if group.Authorizations == nil {
+		return nil
+	}
+
+	var groupName = *group.Name
+	var desired = *group.Authorizations
+	var action, undo = api.ProvisioningActionCreate, noProvisioningUndo
+	if groupExists {
+		current, err := c.component.GetAuthorizations(ctx, p.realmName, p.groupIDs[groupName])
+		if err != nil {
+			return err
+		}
+		action = provisioningAction(reflect.DeepEqual(desired, current))
+		undo = func() error {
+			return c.component.UpdateAuthorizations(ctx, p.realmName, p.groupIDs[groupName], current)
+		}
+	}
+	p.addStep(api.ProvisioningStepAuthorizations, groupName, action, func() error {
+		return c.component.UpdateAuthorizations(ctx, p.realmName, p.groupIDs[groupName], desired)
+	}, undo)
+	return nil
+}
+
+func (c *provisioningComponent) planGroupBackOfficeConfiguration(ctx context.Context, p *realmProvisioning, group api.GroupTemplateRepresentation) error {
+	if group.BackOfficeConfiguration == nil {
+		return nil
+	}
+
+	var groupName = *group.Name
+	var desired = group.BackOfficeConfiguration
+	var current = api.BackOfficeConfiguration{}
+	if p.realmExists {
+		var err error
+		if current, err = c.component.GetRealmBackOfficeConfiguration(ctx, p.realmName, groupName); err != nil {
+			return err
+		}
+	}
+
+	var action string
+	switch {
+	case len(desired) == 0 && len(current) == 0:
+		action = api.ProvisioningActionUnchanged
+	case len(current) == 0:
+		action = api.ProvisioningActionCreate
+	default:
+		action = provisioningAction(reflect.DeepEqual(desired, current))
+	}
+	p.addStep(api.ProvisioningStepBackOfficeConfiguration, groupName, action, func() error {
+		return c.component.UpdateRealmBackOfficeConfiguration(ctx, p.realmName, groupName, desired)
+	}, func() error {
+		return c.component.UpdateRealmBackOfficeConfiguration(ctx, p.realmName, groupName, current)
+	})
+	return nil
+}
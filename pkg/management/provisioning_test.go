@@ -0,0 +1,202 @@
+package management
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	cs "github.com/cloudtrust/common-service"
+	"github.com/cloudtrust/common-service/database"
+	errorhandler "github.com/cloudtrust/common-service/errors"
+	"github.com/cloudtrust/common-service/log"
+	api "github.com/cloudtrust/keycloak-bridge/api/management"
+	"github.com/cloudtrust/keycloak-bridge/pkg/management/mock"
+	kc "github.com/cloudtrust/keycloak-client"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProvisionRealm(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+	var mockKeycloakClient = mock.NewKeycloakClient(mockCtrl)
+	var mockManagementComponent = mock.NewManagementComponent(mockCtrl)
+	var mockApprovalsDB = mock.NewApprovalsDBModule(mockCtrl)
+	var mockEventDBModule = mock.NewEventDBModule(mockCtrl)
+
+	var component = NewProvisioningComponent(mockKeycloakClient, mockManagementComponent, mockApprovalsDB, mockEventDBModule, log.NewNopLogger())
+
+	var accessToken = "TOKEN=="
+	var ctx = context.WithValue(context.Background(), cs.CtContextAccessToken, accessToken)
+	var realmName = "customer"
+	var displayName = "Customer"
+	var enabled = true
+	var clientID = "portal"
+	var clientUUID = "3e0bd5a8-0a1d-4eee-9bb8-669c6f89c0ee"
+	var redirectURIs = []string{"https://portal.customer.com/*"}
+	var groupName = "operators"
+	var groupID = "a4b1c6d2-0a1d-4eee-9bb8-669c6f89c0ee"
+	var matrix = map[string]map[string]map[string]struct{}{"MGMT_GetUsers": {realmName: {groupName: {}}}}
+	var authorizations = api.AuthorizationsRepresentation{Matrix: &matrix}
+	var boConf = api.BackOfficeConfiguration{realmName: {api.BOConfKeyCustomers: []string{groupName}}}
+	var customConf = api.RealmCustomConfiguration{ShowPasswordTab: &enabled}
+	var expectedError = errors.New("kc error")
+
+	var template = api.RealmTemplateRepresentation{
+		Realm:               &realmName,
+		DisplayName:         &displayName,
+		Enabled:             &enabled,
+		Clients:             []api.ClientRepresentation{{ClientID: &clientID, RedirectURIs: &redirectURIs}},
+		CustomConfiguration: &customConf,
+		Groups:              []api.GroupTemplateRepresentation{{Name: &groupName, Authorizations: &authorizations, BackOfficeConfiguration: boConf}},
+	}
+	var createPlan = func(realmAction, otherAction string) []api.ProvisioningStepRepresentation {
+		return []api.ProvisioningStepRepresentation{
+			{Type: api.ProvisioningStepRealm, Name: realmName, Action: realmAction},
+			{Type: api.ProvisioningStepClient, Name: clientID, Action: otherAction},
+			{Type: api.ProvisioningStepCustomConfiguration, Name: realmName, Action: otherAction},
+			{Type: api.ProvisioningStepGroup, Name: groupName, Action: otherAction},
+			{Type: api.ProvisioningStepAuthorizations, Name: groupName, Action: otherAction},
+			{Type: api.ProvisioningStepBackOfficeConfiguration, Name: groupName, Action: otherAction},
+		}
+	}
+	var realmNotFound = kc.HTTPError{HTTPStatus: 404}
+	var existingRealm = kc.RealmRepresentation{Realm: &realmName, DisplayName: &displayName, Enabled: &enabled}
+	var existingClient = kc.ClientRepresentation{ID: &clientUUID, ClientID: &clientID, RedirectUris: &redirectURIs}
+	var existingGroup = kc.GroupRepresentation{ID: &groupID, Name: &groupName}
+
+	t.Run("Can't get realm", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().GetRealm(accessToken, realmName).Return(kc.RealmRepresentation{}, expectedError)
+		var _, err = component.ProvisionRealm(ctx, template, true)
+		assert.Equal(t, expectedError, err)
+	})
+
+	t.Run("Dry-run for a new realm", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().GetRealm(accessToken, realmName).Return(kc.RealmRepresentation{}, realmNotFound)
+		var plan, err = component.ProvisionRealm(ctx, template, true)
+		assert.Nil(t, err)
+		assert.False(t, plan.Applied)
+		assert.Equal(t, createPlan(api.ProvisioningActionCreate, api.ProvisioningActionCreate), plan.Steps)
+	})
+
+	t.Run("New realm is provisioned", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().GetRealm(accessToken, realmName).Return(kc.RealmRepresentation{}, realmNotFound)
+		mockKeycloakClient.EXPECT().CreateRealm(accessToken, gomock.Any()).DoAndReturn(func(_ string, realm kc.RealmRepresentation) (string, error) {
+			assert.Equal(t, realmName, *realm.Realm)
+			assert.Equal(t, displayName, *realm.DisplayName)
+			return "https://keycloak/admin/realms/" + realmName, nil
+		})
+		mockManagementComponent.EXPECT().CreateClient(ctx, realmName, template.Clients[0]).Return("https://keycloak/admin/realms/customer/clients/"+clientUUID, nil)
+		mockManagementComponent.EXPECT().UpdateRealmCustomConfiguration(ctx, realmName, customConf).Return(nil)
+		mockManagementComponent.EXPECT().CreateGroup(ctx, realmName, api.GroupRepresentation{Name: &groupName}).Return("https://keycloak/admin/realms/customer/groups/"+groupID, nil)
+		mockManagementComponent.EXPECT().UpdateAuthorizations(ctx, realmName, groupID, authorizations).Return(nil)
+		mockManagementComponent.EXPECT().UpdateRealmBackOfficeConfiguration(ctx, realmName, groupName, boConf).Return(nil)
+		mockEventDBModule.EXPECT().ReportEvent(ctx, "API_REALM_PROVISIONING", "back-office", database.CtEventRealmName, realmName,
+			database.CtEventAdditionalInfo, gomock.Any()).Return(nil)
+
+		var plan, err = component.ProvisionRealm(ctx, template, false)
+		assert.Nil(t, err)
+		assert.True(t, plan.Applied)
+		assert.Equal(t, createPlan(api.ProvisioningActionCreate, api.ProvisioningActionCreate), plan.Steps)
+	})
+
+	t.Run("Failure rolls back the applied steps", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().GetRealm(accessToken, realmName).Return(kc.RealmRepresentation{}, realmNotFound)
+		gomock.InOrder(
+			mockKeycloakClient.EXPECT().CreateRealm(accessToken, gomock.Any()).Return("", nil),
+			mockManagementComponent.EXPECT().CreateClient(ctx, realmName, gomock.Any()).Return("https://keycloak/admin/realms/customer/clients/"+clientUUID, nil),
+			mockManagementComponent.EXPECT().UpdateRealmCustomConfiguration(ctx, realmName, customConf).Return(nil),
+			mockManagementComponent.EXPECT().CreateGroup(ctx, realmName, gomock.Any()).Return("https://keycloak/admin/realms/customer/groups/"+groupID, nil),
+			mockManagementComponent.EXPECT().UpdateAuthorizations(ctx, realmName, groupID, authorizations).Return(expectedError),
+			mockManagementComponent.EXPECT().DeleteGroup(ctx, realmName, groupID).Return(nil),
+			mockKeycloakClient.EXPECT().DeleteClient(accessToken, realmName, clientUUID).Return(errors.New("ignored")),
+			mockKeycloakClient.EXPECT().DeleteRealm(accessToken, realmName).Return(nil),
+		)
+
+		var _, err = component.ProvisionRealm(ctx, template, false)
+		assert.Equal(t, expectedError, err)
+	})
+
+	var expectExistingRealm = func(realm kc.RealmRepresentation, client kc.ClientRepresentation) {
+		mockKeycloakClient.EXPECT().GetRealm(accessToken, realmName).Return(realm, nil)
+		mockKeycloakClient.EXPECT().GetClients(accessToken, realmName).Return([]kc.ClientRepresentation{client}, nil)
+		mockManagementComponent.EXPECT().GetRealmCustomConfiguration(ctx, realmName).Return(customConf, nil)
+		mockKeycloakClient.EXPECT().GetGroups(accessToken, realmName).Return([]kc.GroupRepresentation{existingGroup}, nil)
+		mockManagementComponent.EXPECT().GetAuthorizations(ctx, realmName, groupID).Return(authorizations, nil)
+		mockManagementComponent.EXPECT().GetRealmBackOfficeConfiguration(ctx, realmName, groupName).Return(boConf, nil)
+	}
+
+	t.Run("Provisioning is idempotent", func(t *testing.T) {
+		expectExistingRealm(existingRealm, existingClient)
+
+		var plan, err = component.ProvisionRealm(ctx, template, false)
+		assert.Nil(t, err)
+		assert.True(t, plan.Applied)
+		assert.Equal(t, createPlan(api.ProvisioningActionUnchanged, api.ProvisioningActionUnchanged), plan.Steps)
+	})
+
+	t.Run("Existing realm is updated", func(t *testing.T) {
+		var previousDisplayName = "Old customer"
+		var previousRedirectURIs = []string{"https://old.customer.com/*"}
+		var realm = existingRealm
+		realm.DisplayName = &previousDisplayName
+		var client = existingClient
+		client.RedirectUris = &previousRedirectURIs
+		expectExistingRealm(realm, client)
+
+		var plan, err = component.ProvisionRealm(ctx, template, true)
+		assert.Nil(t, err)
+		assert.Equal(t, api.ProvisioningActionUpdate, plan.Steps[0].Action)
+		assert.Equal(t, api.ProvisioningActionUpdate, plan.Steps[1].Action)
+		assert.Equal(t, api.ProvisioningActionUnchanged, plan.Steps[2].Action)
+
+		expectExistingRealm(realm, client)
+		mockKeycloakClient.EXPECT().UpdateRealm(accessToken, realmName, gomock.Any()).Return(nil)
+		mockManagementComponent.EXPECT().UpdateClient(ctx, realmName, clientUUID, template.Clients[0]).Return(expectedError)
+		mockKeycloakClient.EXPECT().UpdateRealm(accessToken, realmName, kc.RealmRepresentation{DisplayName: &previousDisplayName, Enabled: &enabled}).Return(nil)
+
+		_, err = component.ProvisionRealm(ctx, template, false)
+		assert.Equal(t, expectedError, err)
+	})
+
+	var otherMatrix = map[string]map[string]map[string]struct{}{"MGMT_GetUser": {realmName: {groupName: {}}}}
+	var otherAuthorizations = api.AuthorizationsRepresentation{Matrix: &otherMatrix}
+	var expectChangedAuthorizations = func() {
+		mockKeycloakClient.EXPECT().GetRealm(accessToken, realmName).Return(existingRealm, nil)
+		mockKeycloakClient.EXPECT().GetClients(accessToken, realmName).Return([]kc.ClientRepresentation{existingClient}, nil)
+		mockManagementComponent.EXPECT().GetRealmCustomConfiguration(ctx, realmName).Return(customConf, nil)
+		mockKeycloakClient.EXPECT().GetGroups(accessToken, realmName).Return([]kc.GroupRepresentation{existingGroup}, nil)
+		mockManagementComponent.EXPECT().GetAuthorizations(ctx, realmName, groupID).Return(otherAuthorizations, nil)
+		mockManagementComponent.EXPECT().GetRealmBackOfficeConfiguration(ctx, realmName, groupName).Return(boConf, nil)
+	}
+
+	t.Run("Can't get approval policy", func(t *testing.T) {
+		expectChangedAuthorizations()
+		mockApprovalsDB.EXPECT().GetApprovalPolicy(ctx, realmName).Return(nil, expectedError)
+
+		var _, err = component.ProvisionRealm(ctx, template, true)
+		assert.Equal(t, expectedError, err)
+	})
+
+	t.Run("Change requiring an approval is refused", func(t *testing.T) {
+		expectChangedAuthorizations()
+		mockApprovalsDB.EXPECT().GetApprovalPolicy(ctx, realmName).Return([]string{MGMTUpdateAuthorizations.String()}, nil)
+
+		var _, err = component.ProvisionRealm(ctx, template, false)
+		assert.IsType(t, errorhandler.Error{}, err)
+		assert.Equal(t, http.StatusConflict, err.(errorhandler.Error).Status)
+	})
+
+	t.Run("Change not requiring an approval is applied", func(t *testing.T) {
+		expectChangedAuthorizations()
+		mockApprovalsDB.EXPECT().GetApprovalPolicy(ctx, realmName).Return([]string{MGMTDeleteUser.String()}, nil)
+		mockManagementComponent.EXPECT().UpdateAuthorizations(ctx, realmName, groupID, authorizations).Return(nil)
+		mockEventDBModule.EXPECT().ReportEvent(ctx, "API_REALM_PROVISIONING", "back-office", database.CtEventRealmName, realmName,
+			database.CtEventAdditionalInfo, gomock.Any()).Return(nil)
+
+		var plan, err = component.ProvisionRealm(ctx, template, false)
+		assert.Nil(t, err)
+		assert.True(t, plan.Applied)
+	})
+}
@@ -0,0 +1,287 @@
+package management
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	cs "github.com/cloudtrust/common-service"
+	"github.com/cloudtrust/common-service/database"
+	errorhandler "github.com/cloudtrust/common-service/errors"
+	"github.com/cloudtrust/common-service/idgenerator"
+	"github.com/cloudtrust/common-service/security"
+	api "github.com/cloudtrust/keycloak-bridge/api/management"
+	"github.com/cloudtrust/keycloak-bridge/internal/constants"
+	"github.com/cloudtrust/keycloak-bridge/internal/dto"
+	"github.com/cloudtrust/keycloak-bridge/internal/keycloakb"
+	kc "github.com/cloudtrust/keycloak-client"
+	"github.com/pkg/errors"
+)
+
+// ScheduledUserChangesDBModule is the interface of the scheduled user changes storage
+type ScheduledUserChangesDBModule interface {
+	CreateScheduledUserChange(ctx context.Context, change dto.DBScheduledUserChange) (int64, error)
+	GetScheduledUserChanges(ctx context.Context, realm string, userID string) ([]dto.DBScheduledUserChange, error)
+	GetDueScheduledUserChanges(ctx context.Context, now time.Time) ([]dto.DBScheduledUserChange, error)
+	DeleteScheduledUserChange(ctx context.Context, realm string, userID string, changeID int64) (bool, error)
+	UpdateScheduledUserChangeAttempts(ctx context.Context, changeID int64, attempts int) error
+}
+
+// maxScheduledChangeAttempts is the number of runs of the scheduler failing to apply a change after which the change is dropped
+const maxScheduledChangeAttempts = 5
+
+// ScheduledChangesComponent is the interface of the scheduled lock, unlock and expiration of users. The changes are applied
+// by the user changes scheduler.
+type ScheduledChangesComponent interface {
+	GetScheduledUserChanges(ctx context.Context, realmName, userID string) ([]api.ScheduledUserChangeRepresentation, error)
+	ScheduleUserChange(ctx context.Context, realmName, userID string, change api.ScheduledUserChangeRepresentation) (int64, error)
+	CancelScheduledUserChange(ctx context.Context, realmName, userID string, changeID int64) error
+}
+
+type scheduledChangesComponent struct {
+	changesDB     ScheduledUserChangesDBModule
+	eventDBModule database.EventsDBModule
+	logger        keycloakb.Logger
+}
+
+// NewScheduledChangesComponent returns the scheduled user changes component
+func NewScheduledChangesComponent(changesDB ScheduledUserChangesDBModule, eventDBModule database.EventsDBModule, logger keycloakb.Logger) ScheduledChangesComponent {
+	return &scheduledChangesComponent{
+		changesDB:     changesDB,
+		eventDBModule: eventDBModule,
+		logger:        logger,
+	}
+}
+
+func (c *scheduledChangesComponent) GetScheduledUserChanges(ctx context.Context, realmName, userID string) ([]api.ScheduledUserChangeRepresentation, error) {
+	var changes, err = c.changesDB.GetScheduledUserChanges(ctx, realmName, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var res = []api.ScheduledUserChangeRepresentation{}
+	for _, change := range changes {
+		res = append(res, api.ConvertToAPIScheduledUserChange(change))
+	}
+	return res, nil
+}
+
+// ScheduleUserChange stores a change which is applied on behalf of the current user once its date is reached
+func (c *scheduledChangesComponent) ScheduleUserChange(ctx context.Context, realmName, userID string, change api.ScheduledUserChangeRepresentation) (int64, error) {
+	var now = time.Now()
+	var applyAt = time.Unix(*change.ApplyAt, 0).UTC()
+	if !applyAt.After(now) {
+		c.logger.Warn(ctx, "msg", "Scheduled user change is not in the future", "applyAt", applyAt.String())
+		return 0, errorhandler.CreateBadRequestError(constants.MsgErrInvalidParam + "." + constants.ApplyAt)
+	}
+
+	var dbChange = dto.DBScheduledUserChange{
+		RealmName:       realmName,
+		UserID:          userID,
+		Action:          *change.Action,
+		ApplyAt:         applyAt,
+		CreatedAt:       now.UTC(),
+		CreatorRealm:    ctx.Value(cs.CtContextRealm).(string),
+		CreatorID:       ctx.Value(cs.CtContextUserID).(string),
+		CreatorUsername: ctx.Value(cs.CtContextUsername).(string),
+	}
+	var id, err = c.changesDB.CreateScheduledUserChange(ctx, dbChange)
+	if err != nil {
+		return 0, err
+	}
+
+	var additionalInfo = database.CreateAdditionalInfo("change_id", strconv.FormatInt(id, 10), "action", dbChange.Action,
+		"apply_at", strconv.FormatInt(*change.ApplyAt, 10))
+	reportEvent(ctx, c.eventDBModule, c.logger, "API_SCHEDULE_USER_CHANGE", database.CtEventRealmName, realmName, database.CtEventUserID, userID,
+		database.CtEventAdditionalInfo, additionalInfo)
+
+	return id, nil
+}
+
+func (c *scheduledChangesComponent) CancelScheduledUserChange(ctx context.Context, realmName, userID string, changeID int64) error {
+	var found, err = c.changesDB.DeleteScheduledUserChange(ctx, realmName, userID, changeID)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return errorhandler.CreateNotFoundError(constants.ChangeID)
+	}
+
+	var additionalInfo = database.CreateAdditionalInfo("change_id", strconv.FormatInt(changeID, 10))
+	reportEvent(ctx, c.eventDBModule, c.logger, "API_CANCEL_SCHEDULED_USER_CHANGE", database.CtEventRealmName, realmName, database.CtEventUserID, userID,
+		database.CtEventAdditionalInfo, additionalInfo)
+
+	return nil
+}
+
+// UserChangesScheduler applies the scheduled user changes once their date is reached
+type UserChangesScheduler interface {
+	ApplyDueChanges(ctx context.Context, now time.Time) error
+	Run(c <-chan time.Time)
+}
+
+type userChangesScheduler struct {
+	component      Component
+	keycloakClient KeycloakClient
+	authManager    security.AuthorizationManager
+	changesDB      ScheduledUserChangesDBModule
+	tokenProvider  keycloakb.TokenProvider
+	eventDBModule  database.EventsDBModule
+	idGenerator    idgenerator.IDGenerator
+	logger         keycloakb.Logger
+}
+
+// NewUserChangesScheduler creates the scheduler of the user changes. The changes are applied by the given management component
+// which must not be wrapped by the authorization middleware: Keycloak is called with the token of the technical user. The
+// authorizations of the user who scheduled a change are checked again with the given authorization manager when it is applied.
+func NewUserChangesScheduler(component Component, keycloakClient KeycloakClient, authManager security.AuthorizationManager,
+	changesDB ScheduledUserChangesDBModule, tokenProvider keycloakb.TokenProvider, eventDBModule database.EventsDBModule,
+	idGenerator idgenerator.IDGenerator, logger keycloakb.Logger) UserChangesScheduler {
+	return &userChangesScheduler{
+		component:      component,
+		keycloakClient: keycloakClient,
+		authManager:    authManager,
+		changesDB:      changesDB,
+		tokenProvider:  tokenProvider,
+		eventDBModule:  eventDBModule,
+		idGenerator:    idGenerator,
+		logger:         logger,
+	}
+}
+
+// Run applies the due changes at each tick of the given channel
+func (s *userChangesScheduler) Run(c <-chan time.Time) {
+	for now := range c {
+		var ctx = context.WithValue(context.Background(), cs.CtContextCorrelationID, s.idGenerator.NextID())
+		if err := s.ApplyDueChanges(ctx, now); err != nil {
+			s.logger.Warn(ctx, "msg", "Can't apply scheduled user changes", "err", err.Error())
+		}
+	}
+}
+
+// ApplyDueChanges applies the changes whose date is reached. A failing change does not prevent the others from being applied,
+// it is retried at the next runs and dropped after maxScheduledChangeAttempts failures.
+func (s *userChangesScheduler) ApplyDueChanges(ctx context.Context, now time.Time) error {
+	var changes, err = s.changesDB.GetDueScheduledUserChanges(ctx, now)
+	if err != nil || len(changes) == 0 {
+		return err
+	}
+
+	var accessToken string
+	if accessToken, err = s.tokenProvider.ProvideToken(ctx); err != nil {
+		s.logger.Warn(ctx, "msg", "Can't get technical token", "err", err.Error())
+		return err
+	}
+
+	for _, change := range changes {
+		if err := s.applyChange(ctx, accessToken, change); err != nil {
+			s.logger.Warn(ctx, "msg", "Can't apply scheduled user change", "err", err.Error(), "realm", change.RealmName, "userID", change.UserID,
+				"changeID", change.ID, "attempts", change.Attempts+1)
+			s.recordFailure(ctx, change, err)
+		}
+	}
+	return nil
+}
+
+func (s *userChangesScheduler) applyChange(ctx context.Context, accessToken string, change dto.DBScheduledUserChange) error {
+	// The change is applied on behalf of the user who scheduled it
+	ctx = context.WithValue(ctx, cs.CtContextAccessToken, accessToken)
+	ctx = context.WithValue(ctx, cs.CtContextRealm, change.CreatorRealm)
+	ctx = context.WithValue(ctx, cs.CtContextUserID, change.CreatorID)
+	ctx = context.WithValue(ctx, cs.CtContextUsername, change.CreatorUsername)
+
+	var allowed, err = s.isCreatorAllowed(ctx, accessToken, change)
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		s.logger.Info(ctx, "msg", "Creator of the scheduled user change is not allowed anymore to apply it", "realm", change.RealmName,
+			"userID", change.UserID, "changeID", change.ID)
+		return s.dropChange(ctx, change, "SCHEDULED_USER_CHANGE_CANCELLED", "creator not allowed")
+	}
+
+	if change.Action == dto.ScheduledActionUnlock {
+		err = s.component.UnlockUser(ctx, change.RealmName, change.UserID)
+	} else {
+		err = s.component.LockUser(ctx, change.RealmName, change.UserID)
+	}
+	// The user may have been deleted in the meantime
+	if e, ok := errors.Cause(err).(kc.HTTPError); ok && e.HTTPStatus == http.StatusNotFound {
+		err = nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if _, err = s.changesDB.DeleteScheduledUserChange(ctx, change.RealmName, change.UserID, change.ID); err != nil {
+		return err
+	}
+
+	var apiCall = "SCHEDULED_USER_CHANGE_APPLIED"
+	if change.Action == dto.ScheduledActionExpire {
+		apiCall = "ACCOUNT_EXPIRED"
+	}
+	var additionalInfo = database.CreateAdditionalInfo("change_id", strconv.FormatInt(change.ID, 10), "action", change.Action,
+		"scheduled_by", change.CreatorUsername)
+	reportEvent(ctx, s.eventDBModule, s.logger, apiCall, database.CtEventRealmName, change.RealmName, database.CtEventUserID, change.UserID,
+		database.CtEventAdditionalInfo, additionalInfo)
+
+	return nil
+}
+
+// isCreatorAllowed tells whether the user who scheduled the change still exists and is still allowed to lock or unlock the
+// target user. The groups of the creator are read from Keycloak as the change is not applied with their token.
+func (s *userChangesScheduler) isCreatorAllowed(ctx context.Context, accessToken string, change dto.DBScheduledUserChange) (bool, error) {
+	var groups, err = s.keycloakClient.GetGroupsOfUser(accessToken, change.CreatorRealm, change.CreatorID)
+	if e, ok := errors.Cause(err).(kc.HTTPError); ok && e.HTTPStatus == http.StatusNotFound {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	var groupNames = []string{}
+	for _, group := range groups {
+		if group.Name != nil {
+			groupNames = append(groupNames, *group.Name)
+		}
+	}
+	ctx = context.WithValue(ctx, cs.CtContextGroups, groupNames)
+
+	var action = MGMTLockUser
+	if change.Action == dto.ScheduledActionUnlock {
+		action = MGMTUnlockUser
+	}
+	err = s.authManager.CheckAuthorizationOnTargetUser(ctx, action.String(), change.RealmName, change.UserID)
+	if _, ok := err.(security.ForbiddenError); ok {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// recordFailure counts a failed attempt to apply the change. The change is dropped once it failed maxScheduledChangeAttempts times.
+func (s *userChangesScheduler) recordFailure(ctx context.Context, change dto.DBScheduledUserChange, cause error) {
+	var err error
+	if change.Attempts+1 >= maxScheduledChangeAttempts {
+		err = s.dropChange(ctx, change, "SCHEDULED_USER_CHANGE_FAILED", cause.Error())
+	} else {
+		err = s.changesDB.UpdateScheduledUserChangeAttempts(ctx, change.ID, change.Attempts+1)
+	}
+	if err != nil {
+		s.logger.Warn(ctx, "msg", "Can't record failure of scheduled user change", "err", err.Error(), "changeID", change.ID)
+	}
+}
+
+// dropChange deletes a change which won't be applied and records why in the audit database
+func (s *userChangesScheduler) dropChange(ctx context.Context, change dto.DBScheduledUserChange, apiCall string, reason string) error {
+	if _, err := s.changesDB.DeleteScheduledUserChange(ctx, change.RealmName, change.UserID, change.ID); err != nil {
+		return err
+	}
+
+	var additionalInfo = database.CreateAdditionalInfo("change_id", strconv.FormatInt(change.ID, 10), "action", change.Action,
+		"scheduled_by", change.CreatorUsername, "reason", reason)
+	reportEvent(ctx, s.eventDBModule, s.logger, apiCall, database.CtEventRealmName, change.RealmName, database.CtEventUserID, change.UserID,
+		database.CtEventAdditionalInfo, additionalInfo)
+	return nil
+}
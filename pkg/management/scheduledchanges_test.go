@@ -0,0 +1,199 @@
+package management
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	cs "github.com/cloudtrust/common-service"
+	"github.com/cloudtrust/common-service/database"
+	errorhandler "github.com/cloudtrust/common-service/errors"
+	"github.com/cloudtrust/common-service/log"
+	"github.com/cloudtrust/common-service/security"
+	api "github.com/cloudtrust/keycloak-bridge/api/management"
+	"github.com/cloudtrust/keycloak-bridge/internal/dto"
+	"github.com/cloudtrust/keycloak-bridge/pkg/management/mock"
+	kc "github.com/cloudtrust/keycloak-client"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScheduledChangesComponent(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+	var mockChangesDB = mock.NewScheduledUserChangesDBModule(mockCtrl)
+	var mockEventDBModule = mock.NewEventDBModule(mockCtrl)
+
+	var component = NewScheduledChangesComponent(mockChangesDB, mockEventDBModule, log.NewNopLogger())
+
+	var ctx = context.WithValue(context.TODO(), cs.CtContextRealm, "master")
+	ctx = context.WithValue(ctx, cs.CtContextUserID, "operator-id")
+	ctx = context.WithValue(ctx, cs.CtContextUsername, "operator")
+	var realm = "DEP"
+	var userID = "f467ed7c-0a1d-4eee-9bb8-669c6f89c0ee"
+	var action = dto.ScheduledActionExpire
+	var applyAt = time.Now().Add(24 * time.Hour).Unix()
+	var expectedError = errors.New("db error")
+
+	t.Run("Get scheduled changes", func(t *testing.T) {
+		mockChangesDB.EXPECT().GetScheduledUserChanges(ctx, realm, userID).Return(nil, expectedError)
+		var _, err = component.GetScheduledUserChanges(ctx, realm, userID)
+		assert.Equal(t, expectedError, err)
+
+		mockChangesDB.EXPECT().GetScheduledUserChanges(ctx, realm, userID).Return([]dto.DBScheduledUserChange{{ID: 3, Action: action}}, nil)
+		changes, err := component.GetScheduledUserChanges(ctx, realm, userID)
+		assert.Nil(t, err)
+		assert.Len(t, changes, 1)
+		assert.Equal(t, int64(3), *changes[0].ID)
+	})
+
+	t.Run("Change in the past", func(t *testing.T) {
+		var past = time.Now().Add(-time.Minute).Unix()
+		var _, err = component.ScheduleUserChange(ctx, realm, userID, api.ScheduledUserChangeRepresentation{Action: &action, ApplyAt: &past})
+		assert.Equal(t, http.StatusBadRequest, err.(errorhandler.Error).Status)
+	})
+
+	t.Run("Change can't be stored", func(t *testing.T) {
+		mockChangesDB.EXPECT().CreateScheduledUserChange(ctx, gomock.Any()).Return(int64(0), expectedError)
+		var _, err = component.ScheduleUserChange(ctx, realm, userID, api.ScheduledUserChangeRepresentation{Action: &action, ApplyAt: &applyAt})
+		assert.Equal(t, expectedError, err)
+	})
+
+	t.Run("Change is scheduled", func(t *testing.T) {
+		mockChangesDB.EXPECT().CreateScheduledUserChange(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, change dto.DBScheduledUserChange) (int64, error) {
+			assert.Equal(t, realm, change.RealmName)
+			assert.Equal(t, action, change.Action)
+			assert.Equal(t, applyAt, change.ApplyAt.Unix())
+			assert.Equal(t, "master", change.CreatorRealm)
+			assert.Equal(t, "operator", change.CreatorUsername)
+			return 5, nil
+		})
+		mockEventDBModule.EXPECT().ReportEvent(ctx, "API_SCHEDULE_USER_CHANGE", "back-office", database.CtEventRealmName, realm, database.CtEventUserID, userID,
+			database.CtEventAdditionalInfo, gomock.Any()).Return(nil)
+		var id, err = component.ScheduleUserChange(ctx, realm, userID, api.ScheduledUserChangeRepresentation{Action: &action, ApplyAt: &applyAt})
+		assert.Nil(t, err)
+		assert.Equal(t, int64(5), id)
+	})
+
+	t.Run("Cancel unknown change", func(t *testing.T) {
+		mockChangesDB.EXPECT().DeleteScheduledUserChange(ctx, realm, userID, int64(5)).Return(false, nil)
+		var err = component.CancelScheduledUserChange(ctx, realm, userID, 5)
+		assert.Equal(t, http.StatusNotFound, err.(errorhandler.Error).Status)
+	})
+
+	t.Run("Cancel change", func(t *testing.T) {
+		mockChangesDB.EXPECT().DeleteScheduledUserChange(ctx, realm, userID, int64(5)).Return(true, nil)
+		mockEventDBModule.EXPECT().ReportEvent(ctx, "API_CANCEL_SCHEDULED_USER_CHANGE", "back-office", database.CtEventRealmName, realm, database.CtEventUserID,
+			userID, database.CtEventAdditionalInfo, gomock.Any()).Return(nil)
+		assert.Nil(t, component.CancelScheduledUserChange(ctx, realm, userID, 5))
+	})
+}
+
+func TestUserChangesScheduler(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+	var mockManagementComponent = mock.NewManagementComponent(mockCtrl)
+	var mockKeycloakClient = mock.NewKeycloakClient(mockCtrl)
+	var mockAuthManager = mock.NewAuthorizationManager(mockCtrl)
+	var mockChangesDB = mock.NewScheduledUserChangesDBModule(mockCtrl)
+	var mockTokenProvider = mock.NewTokenProvider(mockCtrl)
+	var mockEventDBModule = mock.NewEventDBModule(mockCtrl)
+	var mockIDGenerator = mock.NewIDGenerator(mockCtrl)
+
+	var scheduler = NewUserChangesScheduler(mockManagementComponent, mockKeycloakClient, mockAuthManager, mockChangesDB, mockTokenProvider,
+		mockEventDBModule, mockIDGenerator, log.NewNopLogger())
+
+	var ctx = context.TODO()
+	var accessToken = "TOKEN=="
+	var realm = "DEP"
+	var userID = "f467ed7c-0a1d-4eee-9bb8-669c6f89c0ee"
+	var now = time.Now()
+	var lock = dto.DBScheduledUserChange{ID: 1, RealmName: realm, UserID: userID, Action: dto.ScheduledActionLock, CreatorRealm: "master",
+		CreatorID: "operator-id", CreatorUsername: "operator"}
+	var unlock = dto.DBScheduledUserChange{ID: 2, RealmName: realm, UserID: userID, Action: dto.ScheduledActionUnlock, CreatorRealm: "master",
+		CreatorID: "operator-id", CreatorUsername: "operator"}
+	var expire = dto.DBScheduledUserChange{ID: 3, RealmName: realm, UserID: userID, Action: dto.ScheduledActionExpire, CreatorRealm: "master",
+		CreatorID: "operator-id", CreatorUsername: "operator"}
+	var groupName = "operators"
+	var creatorGroups = []kc.GroupRepresentation{{Name: &groupName}}
+
+	t.Run("Nothing to apply", func(t *testing.T) {
+		mockChangesDB.EXPECT().GetDueScheduledUserChanges(ctx, now).Return([]dto.DBScheduledUserChange{}, nil)
+		assert.Nil(t, scheduler.ApplyDueChanges(ctx, now))
+	})
+
+	t.Run("Can't get technical token", func(t *testing.T) {
+		var expectedError = errors.New("kc error")
+		mockChangesDB.EXPECT().GetDueScheduledUserChanges(ctx, now).Return([]dto.DBScheduledUserChange{lock}, nil)
+		mockTokenProvider.EXPECT().ProvideToken(ctx).Return("", expectedError)
+		assert.Equal(t, expectedError, scheduler.ApplyDueChanges(ctx, now))
+	})
+
+	t.Run("Failing change does not prevent the others from being applied", func(t *testing.T) {
+		mockChangesDB.EXPECT().GetDueScheduledUserChanges(ctx, now).Return([]dto.DBScheduledUserChange{lock, unlock, expire}, nil)
+		mockTokenProvider.EXPECT().ProvideToken(ctx).Return(accessToken, nil)
+		mockKeycloakClient.EXPECT().GetGroupsOfUser(accessToken, "master", "operator-id").Return(creatorGroups, nil).Times(3)
+		mockAuthManager.EXPECT().CheckAuthorizationOnTargetUser(gomock.Any(), MGMTLockUser.String(), realm, userID).DoAndReturn(
+			func(ctx context.Context, _, _, _ string) error {
+				assert.Equal(t, []string{groupName}, ctx.Value(cs.CtContextGroups))
+				return nil
+			}).Times(2)
+		mockAuthManager.EXPECT().CheckAuthorizationOnTargetUser(gomock.Any(), MGMTUnlockUser.String(), realm, userID).Return(nil)
+		mockManagementComponent.EXPECT().LockUser(gomock.Any(), realm, userID).DoAndReturn(func(ctx context.Context, _, _ string) error {
+			assert.Equal(t, accessToken, ctx.Value(cs.CtContextAccessToken))
+			assert.Equal(t, "master", ctx.Value(cs.CtContextRealm))
+			assert.Equal(t, "operator", ctx.Value(cs.CtContextUsername))
+			return errors.New("kc error")
+		})
+		mockChangesDB.EXPECT().UpdateScheduledUserChangeAttempts(gomock.Any(), int64(1), 1).Return(nil)
+		mockManagementComponent.EXPECT().UnlockUser(gomock.Any(), realm, userID).Return(nil)
+		mockChangesDB.EXPECT().DeleteScheduledUserChange(gomock.Any(), realm, userID, int64(2)).Return(true, nil)
+		mockEventDBModule.EXPECT().ReportEvent(gomock.Any(), "SCHEDULED_USER_CHANGE_APPLIED", "back-office", database.CtEventRealmName, realm,
+			database.CtEventUserID, userID, database.CtEventAdditionalInfo, gomock.Any()).Return(nil)
+		mockManagementComponent.EXPECT().LockUser(gomock.Any(), realm, userID).Return(kc.HTTPError{HTTPStatus: 404})
+		mockChangesDB.EXPECT().DeleteScheduledUserChange(gomock.Any(), realm, userID, int64(3)).Return(true, nil)
+		mockEventDBModule.EXPECT().ReportEvent(gomock.Any(), "ACCOUNT_EXPIRED", "back-office", database.CtEventRealmName, realm,
+			database.CtEventUserID, userID, database.CtEventAdditionalInfo, gomock.Any()).Return(errors.New("db error"))
+		assert.Nil(t, scheduler.ApplyDueChanges(ctx, now))
+	})
+
+	t.Run("Change is dropped when its creator is not allowed anymore", func(t *testing.T) {
+		mockChangesDB.EXPECT().GetDueScheduledUserChanges(ctx, now).Return([]dto.DBScheduledUserChange{lock, unlock}, nil)
+		mockTokenProvider.EXPECT().ProvideToken(ctx).Return(accessToken, nil)
+		mockKeycloakClient.EXPECT().GetGroupsOfUser(accessToken, "master", "operator-id").Return(creatorGroups, nil)
+		mockAuthManager.EXPECT().CheckAuthorizationOnTargetUser(gomock.Any(), MGMTLockUser.String(), realm, userID).Return(security.ForbiddenError{})
+		mockChangesDB.EXPECT().DeleteScheduledUserChange(gomock.Any(), realm, userID, int64(1)).Return(true, nil)
+		mockEventDBModule.EXPECT().ReportEvent(gomock.Any(), "SCHEDULED_USER_CHANGE_CANCELLED", "back-office", database.CtEventRealmName, realm,
+			database.CtEventUserID, userID, database.CtEventAdditionalInfo, gomock.Any()).Return(nil)
+		// The creator was deleted
+		mockKeycloakClient.EXPECT().GetGroupsOfUser(accessToken, "master", "operator-id").Return(nil, kc.HTTPError{HTTPStatus: http.StatusNotFound})
+		mockChangesDB.EXPECT().DeleteScheduledUserChange(gomock.Any(), realm, userID, int64(2)).Return(true, nil)
+		mockEventDBModule.EXPECT().ReportEvent(gomock.Any(), "SCHEDULED_USER_CHANGE_CANCELLED", "back-office", database.CtEventRealmName, realm,
+			database.CtEventUserID, userID, database.CtEventAdditionalInfo, gomock.Any()).Return(nil)
+		assert.Nil(t, scheduler.ApplyDueChanges(ctx, now))
+	})
+
+	t.Run("Change is dropped after too many failures", func(t *testing.T) {
+		var failing = lock
+		failing.Attempts = maxScheduledChangeAttempts - 1
+		mockChangesDB.EXPECT().GetDueScheduledUserChanges(ctx, now).Return([]dto.DBScheduledUserChange{failing}, nil)
+		mockTokenProvider.EXPECT().ProvideToken(ctx).Return(accessToken, nil)
+		mockKeycloakClient.EXPECT().GetGroupsOfUser(accessToken, "master", "operator-id").Return(nil, errors.New("kc error"))
+		mockChangesDB.EXPECT().DeleteScheduledUserChange(gomock.Any(), realm, userID, int64(1)).Return(true, nil)
+		mockEventDBModule.EXPECT().ReportEvent(gomock.Any(), "SCHEDULED_USER_CHANGE_FAILED", "back-office", database.CtEventRealmName, realm,
+			database.CtEventUserID, userID, database.CtEventAdditionalInfo, gomock.Any()).Return(nil)
+		assert.Nil(t, scheduler.ApplyDueChanges(ctx, now))
+	})
+
+	t.Run("Run", func(t *testing.T) {
+		var c = make(chan time.Time, 1)
+		var ctxRun = context.WithValue(context.Background(), cs.CtContextCorrelationID, "corr-id")
+		mockIDGenerator.EXPECT().NextID().Return("corr-id")
+		mockChangesDB.EXPECT().GetDueScheduledUserChanges(ctxRun, now).Return(nil, errors.New("db error"))
+		c <- now
+		close(c)
+		scheduler.Run(c)
+	})
+}
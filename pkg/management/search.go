@@ -0,0 +1,389 @@
+package management
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	cs "github.com/cloudtrust/common-service"
+	errorhandler "github.com/cloudtrust/common-service/errors"
+	api "github.com/cloudtrust/keycloak-bridge/api/management"
+	"github.com/cloudtrust/keycloak-bridge/internal/constants"
+	"github.com/cloudtrust/keycloak-bridge/internal/dto"
+	"github.com/cloudtrust/keycloak-bridge/internal/keycloakb"
+	kc "github.com/cloudtrust/keycloak-client"
+	"github.com/pkg/errors"
+)
+
+const (
+	searchPageSize = 100
+	// Users found in the index are read one by one from Keycloak
+	maxIndexedSearchCandidates = 100
+	// Users filtered by the bridge are read by pages of searchPageSize from Keycloak
+	maxScannedSearchUsers = 1000
+)
+
+// UsersSearchIndexDBModule is the interface of the users search index
+type UsersSearchIndexDBModule interface {
+	StoreOrUpdateUserSearchIndex(ctx context.Context, realm string, index dto.DBUserSearchIndex) error
+	DeleteUserSearchIndex(ctx context.Context, realm string, userID string) error
+	SearchUserIDs(ctx context.Context, realm string, criteria dto.DBUserSearchIndex, max int) ([]string, error)
+}
+
+// SearchComponent is the interface of the advanced users search
+type SearchComponent interface {
+	SearchUsers(ctx context.Context, realmName string, criteria api.UserSearchCriteria) (api.UsersPageRepresentation, error)
+	IndexUsers(ctx context.Context, realmName string) (api.BulkJobRepresentation, error)
+}
+
+// UsersIndexer keeps the search index of the users up to date
+type UsersIndexer interface {
+	IndexUser(ctx context.Context, realmName string, userID string) error
+	UnindexUser(ctx context.Context, realmName string, userID string) error
+}
+
+type searchComponent struct {
+	keycloakClient KeycloakClient
+	usersDBModule  UsersDetailsDBModule
+	indexDBModule  UsersSearchIndexDBModule
+	indexer        keycloakb.BlindIndexer
+	usersIndexer   UsersIndexer
+	jobs           keycloakb.JobStore
+	logger         keycloakb.Logger
+}
+
+// NewSearchComponent returns the advanced users search component. The users details are read to check the indexed
+// document number of the users found in the index.
+func NewSearchComponent(keycloakClient KeycloakClient, usersDBModule UsersDetailsDBModule, indexDBModule UsersSearchIndexDBModule,
+	indexer keycloakb.BlindIndexer, usersIndexer UsersIndexer, jobs keycloakb.JobStore, logger keycloakb.Logger) SearchComponent {
+	return &searchComponent{
+		keycloakClient: keycloakClient,
+		usersDBModule:  usersDBModule,
+		indexDBModule:  indexDBModule,
+		indexer:        indexer,
+		usersIndexer:   usersIndexer,
+		jobs:           jobs,
+		logger:         logger,
+	}
+}
+
+// SearchUsers combines the Keycloak search with the bridge criteria. Without bridge criteria, the search is delegated to Keycloak.
+// Otherwise, the users given by the index or by Keycloak are filtered by the bridge before the requested page is extracted. The
+// number of users read to do so is limited: the search fails if the criteria are too broad.
+func (c *searchComponent) SearchUsers(ctx context.Context, realmName string, criteria api.UserSearchCriteria) (api.UsersPageRepresentation, error) {
+	if !criteria.HasIndexedCriteria() && !criteria.HasUserCriteria() {
+		var paramKV = append([]string{prmQryFirst, strconv.Itoa(criteria.First), prmQryMax, strconv.Itoa(criteria.Max)}, criteria.KeycloakParams...)
+		var page, err = c.getUsersPage(ctx, realmName, criteria.GroupIDs, paramKV)
+		if err != nil {
+			return api.UsersPageRepresentation{}, err
+		}
+		if criteria.IncludeDeleted {
+			return api.ConvertToAPIUsersPage(ctx, page, c.logger), nil
+		}
+		return api.ConvertToAPIUsersPage(ctx, page, c.logger).WithoutDeletedUsers(), nil
+	}
+
+	var candidates map[string]bool
+	var search = c.searchIndex(realmName, criteria)
+	if criteria.HasIndexedCriteria() {
+		var userIDs, err = c.indexDBModule.SearchUserIDs(ctx, realmName, search, maxIndexedSearchCandidates+1)
+		if err != nil {
+			return api.UsersPageRepresentation{}, err
+		}
+		if len(userIDs) > maxIndexedSearchCandidates {
+			c.logger.Warn(ctx, "msg", "Too many users found in the search index", "realm", realmName)
+			return api.UsersPageRepresentation{}, tooManyResultsError()
+		}
+		if !criteria.HasKeycloakCriteria() {
+			return c.searchIndexedUsers(ctx, realmName, userIDs, search, criteria)
+		}
+		candidates = map[string]bool{}
+		for _, userID := range userIDs {
+			candidates[userID] = true
+		}
+	}
+
+	var matches []api.UserRepresentation
+	for first := 0; ; first += searchPageSize {
+		var paramKV = append([]string{prmQryFirst, strconv.Itoa(first), prmQryMax, strconv.Itoa(searchPageSize)}, criteria.KeycloakParams...)
+		var page, err = c.getUsersPage(ctx, realmName, criteria.GroupIDs, paramKV)
+		if err != nil {
+			return api.UsersPageRepresentation{}, err
+		}
+		if (page.Count != nil && *page.Count > maxScannedSearchUsers) || first >= maxScannedSearchUsers {
+			c.logger.Warn(ctx, "msg", "Too many users to filter", "realm", realmName)
+			return api.UsersPageRepresentation{}, tooManyResultsError()
+		}
+		for _, user := range api.ConvertToAPIUsersPage(ctx, page, c.logger).Users {
+			if (candidates != nil && (user.ID == nil || !candidates[*user.ID])) || !criteria.Match(user) {
+				continue
+			}
+			if candidates != nil {
+				if indexed, err := c.matchesSearchIndex(ctx, realmName, *user.ID, user, search); err != nil {
+					return api.UsersPageRepresentation{}, err
+				} else if !indexed {
+					continue
+				}
+			}
+			matches = append(matches, user)
+		}
+		if len(page.Users) < searchPageSize {
+			return usersPage(matches, criteria.First, criteria.Max), nil
+		}
+	}
+}
+
+func tooManyResultsError() error {
+	return errorhandler.Error{
+		Status:  http.StatusBadRequest,
+		Message: keycloakb.ComponentName + "." + constants.MsgErrTooManyResults,
+	}
+}
+
+// searchIndexedUsers gets the users found in the index from Keycloak. Users who do not exist anymore are removed from the index.
+func (c *searchComponent) searchIndexedUsers(ctx context.Context, realmName string, userIDs []string, search dto.DBUserSearchIndex,
+	criteria api.UserSearchCriteria) (api.UsersPageRepresentation, error) {
+	var accessToken = ctx.Value(cs.CtContextAccessToken).(string)
+
+	var matches []api.UserRepresentation
+	for _, userID := range userIDs {
+		var userKc, err = c.keycloakClient.GetUser(accessToken, realmName, userID)
+		if e, ok := errors.Cause(err).(kc.HTTPError); ok && e.HTTPStatus == http.StatusNotFound {
+			_ = c.usersIndexer.UnindexUser(ctx, realmName, userID)
+			continue
+		}
+		if err != nil {
+			c.logger.Warn(ctx, "err", err.Error())
+			return api.UsersPageRepresentation{}, err
+		}
+		keycloakb.ConvertLegacyAttribute(&userKc)
+		var user = api.ConvertToAPIUser(ctx, userKc, c.logger)
+		if !criteria.Match(user) {
+			continue
+		}
+		if indexed, err := c.matchesSearchIndex(ctx, realmName, userID, user, search); err != nil {
+			return api.UsersPageRepresentation{}, err
+		} else if indexed {
+			matches = append(matches, user)
+		}
+	}
+	return usersPage(matches, criteria.First, criteria.Max), nil
+}
+
+// matchesSearchIndex checks the current values of the indexed fields of a user found in the index. The index is stale when
+// the user has been changed without the bridge: the user is then dropped from the results and indexed again.
+func (c *searchComponent) matchesSearchIndex(ctx context.Context, realmName string, userID string, user api.UserRepresentation,
+	search dto.DBUserSearchIndex) (bool, error) {
+	var current = dto.DBUserSearchIndex{
+		PhoneNumberHash: blindIndex(c.indexer, realmName, keycloakb.IndexPhoneNumber, user.PhoneNumber),
+		BirthDateHash:   blindIndex(c.indexer, realmName, keycloakb.IndexBirthDate, user.BirthDate),
+	}
+	if search.IDDocumentNumberHash != nil {
+		var dbUser, err = c.usersDBModule.GetUserDetails(ctx, realmName, userID)
+		if err != nil {
+			c.logger.Warn(ctx, "err", err.Error())
+			return false, err
+		}
+		current.IDDocumentNumberHash = blindIndex(c.indexer, realmName, keycloakb.IndexIDDocumentNumber, dbUser.IDDocumentNumber)
+	}
+
+	if matchesHash(search.PhoneNumberHash, current.PhoneNumberHash) && matchesHash(search.BirthDateHash, current.BirthDateHash) &&
+		matchesHash(search.IDDocumentNumberHash, current.IDDocumentNumberHash) {
+		return true, nil
+	}
+	c.logger.Info(ctx, "msg", "Search index of user is stale", "realm", realmName, "userID", userID)
+	_ = c.usersIndexer.IndexUser(ctx, realmName, userID)
+	return false, nil
+}
+
+func matchesHash(searched *string, current *string) bool {
+	return searched == nil || (current != nil && *searched == *current)
+}
+
+// IndexUsers rebuilds in background the search index of all the users of a realm. The report is obtained with GetBulkJob.
+func (c *searchComponent) IndexUsers(ctx context.Context, realmName string) (api.BulkJobRepresentation, error) {
+	var jobID = c.jobs.Start(ctx, realmName, func(ctx context.Context, progress func(int, int)) (interface{}, error) {
+		var report = api.SearchIndexReportRepresentation{}
+		for first := 0; ; first += searchPageSize {
+			var page, err = c.getUsersPage(ctx, realmName, nil, []string{prmQryFirst, strconv.Itoa(first), prmQryMax, strconv.Itoa(searchPageSize)})
+			if err != nil {
+				return nil, err
+			}
+			if page.Count != nil {
+				report.Total = *page.Count
+			}
+			for _, user := range page.Users {
+				if user.ID == nil {
+					continue
+				}
+				if err = c.usersIndexer.IndexUser(ctx, realmName, *user.ID); err != nil {
+					report.Failed++
+				} else {
+					report.Indexed++
+				}
+				progress(report.Indexed+report.Failed, report.Total)
+			}
+			if len(page.Users) < searchPageSize {
+				return report, nil
+			}
+		}
+	})
+
+	return api.BulkJobRepresentation{
+		ID:     jobID,
+		Status: keycloakb.JobStatusRunning,
+	}, nil
+}
+
+func (c *searchComponent) getUsersPage(ctx context.Context, realmName string, groupIDs []string, paramKV []string) (kc.UsersPageRepresentation, error) {
+	var accessToken = ctx.Value(cs.CtContextAccessToken).(string)
+	var ctxRealm = ctx.Value(cs.CtContextRealm).(string)
+
+	for _, groupID := range groupIDs {
+		paramKV = append(paramKV, "groupId", groupID)
+	}
+
+	var page, err = c.keycloakClient.GetUsers(accessToken, ctxRealm, realmName, paramKV...)
+	if err != nil {
+		c.logger.Warn(ctx, "err", err.Error())
+		return kc.UsersPageRepresentation{}, err
+	}
+	for i := range page.Users {
+		keycloakb.ConvertLegacyAttribute(&page.Users[i])
+	}
+	return page, nil
+}
+
+func usersPage(users []api.UserRepresentation, first int, max int) api.UsersPageRepresentation {
+	var count = len(users)
+	var res = api.UsersPageRepresentation{
+		Users: []api.UserRepresentation{},
+		Count: &count,
+	}
+	if first < count {
+		var last = first + max
+		if last > count {
+			last = count
+		}
+		res.Users = users[first:last]
+	}
+	return res
+}
+
+func (c *searchComponent) searchIndex(realmName string, criteria api.UserSearchCriteria) dto.DBUserSearchIndex {
+	return dto.DBUserSearchIndex{
+		PhoneNumberHash:      blindIndex(c.indexer, realmName, keycloakb.IndexPhoneNumber, criteria.PhoneNumber),
+		BirthDateHash:        blindIndex(c.indexer, realmName, keycloakb.IndexBirthDate, criteria.BirthDate),
+		IDDocumentNumberHash: blindIndex(c.indexer, realmName, keycloakb.IndexIDDocumentNumber, criteria.IDDocumentNumber),
+	}
+}
+
+func blindIndex(indexer keycloakb.BlindIndexer, realmName string, field string, value *string) *string {
+	if value == nil {
+		return nil
+	}
+	var hash = indexer.Index(realmName, field, *value)
+	return &hash
+}
+
+type usersIndexer struct {
+	keycloakClient KeycloakClient
+	usersDBModule  UsersDetailsDBModule
+	indexDBModule  UsersSearchIndexDBModule
+	indexer        keycloakb.BlindIndexer
+	logger         keycloakb.Logger
+}
+
+// NewUsersIndexer returns the component which updates the search index of the users
+func NewUsersIndexer(keycloakClient KeycloakClient, usersDBModule UsersDetailsDBModule, indexDBModule UsersSearchIndexDBModule,
+	indexer keycloakb.BlindIndexer, logger keycloakb.Logger) UsersIndexer {
+	return &usersIndexer{
+		keycloakClient: keycloakClient,
+		usersDBModule:  usersDBModule,
+		indexDBModule:  indexDBModule,
+		indexer:        indexer,
+		logger:         logger,
+	}
+}
+
+// IndexUser updates the blind indexes of a user from its Keycloak attributes and its details
+func (c *usersIndexer) IndexUser(ctx context.Context, realmName string, userID string) error {
+	var accessToken = ctx.Value(cs.CtContextAccessToken).(string)
+
+	var userKc, err = c.keycloakClient.GetUser(accessToken, realmName, userID)
+	if err != nil {
+		c.logger.Warn(ctx, "err", err.Error())
+		return err
+	}
+	keycloakb.ConvertLegacyAttribute(&userKc)
+	var user = api.ConvertToAPIUser(ctx, userKc, c.logger)
+
+	var dbUser dto.DBUser
+	if dbUser, err = c.usersDBModule.GetUserDetails(ctx, realmName, userID); err != nil {
+		c.logger.Warn(ctx, "err", err.Error())
+		return err
+	}
+
+	return c.indexDBModule.StoreOrUpdateUserSearchIndex(ctx, realmName, dto.DBUserSearchIndex{
+		UserID:               userID,
+		PhoneNumberHash:      blindIndex(c.indexer, realmName, keycloakb.IndexPhoneNumber, user.PhoneNumber),
+		BirthDateHash:        blindIndex(c.indexer, realmName, keycloakb.IndexBirthDate, user.BirthDate),
+		IDDocumentNumberHash: blindIndex(c.indexer, realmName, keycloakb.IndexIDDocumentNumber, dbUser.IDDocumentNumber),
+	})
+}
+
+// UnindexUser removes a user from the search index
+func (c *usersIndexer) UnindexUser(ctx context.Context, realmName string, userID string) error {
+	return c.indexDBModule.DeleteUserSearchIndex(ctx, realmName, userID)
+}
+
+// Keeps the search index up to date when users are created, updated or deleted through the management component
+type searchIndexComponentMW struct {
+	Component
+	indexer UsersIndexer
+	logger  keycloakb.Logger
+}
+
+// MakeSearchIndexComponentMW updates the search index after the successful changes of users. A failure to update the index
+// does not fail the call: the index can be rebuilt with IndexUsers.
+func MakeSearchIndexComponentMW(indexer UsersIndexer, logger keycloakb.Logger) func(Component) Component {
+	return func(next Component) Component {
+		return &searchIndexComponentMW{
+			Component: next,
+			indexer:   indexer,
+			logger:    logger,
+		}
+	}
+}
+
+func (m *searchIndexComponentMW) CreateUser(ctx context.Context, realmName string, user api.UserRepresentation) (string, error) {
+	var location, err = m.Component.CreateUser(ctx, realmName, user)
+	if err == nil {
+		m.indexUser(ctx, realmName, idInLocation.FindString(location))
+	}
+	return location, err
+}
+
+func (m *searchIndexComponentMW) UpdateUser(ctx context.Context, realmName, userID string, user api.UserRepresentation) error {
+	var err = m.Component.UpdateUser(ctx, realmName, userID, user)
+	if err == nil {
+		m.indexUser(ctx, realmName, userID)
+	}
+	return err
+}
+
+func (m *searchIndexComponentMW) DeleteUser(ctx context.Context, realmName, userID string) error {
+	var err = m.Component.DeleteUser(ctx, realmName, userID)
+	if err == nil {
+		if errIndex := m.indexer.UnindexUser(ctx, realmName, userID); errIndex != nil {
+			m.logger.Warn(ctx, "msg", "Can't remove user from search index", "err", errIndex.Error(), "userID", userID)
+		}
+	}
+	return err
+}
+
+func (m *searchIndexComponentMW) indexUser(ctx context.Context, realmName, userID string) {
+	if err := m.indexer.IndexUser(ctx, realmName, userID); err != nil {
+		m.logger.Warn(ctx, "msg", "Can't update search index of user", "err", err.Error(), "userID", userID)
+	}
+}
@@ -0,0 +1,286 @@
+package management
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	cs "github.com/cloudtrust/common-service"
+	errorhandler "github.com/cloudtrust/common-service/errors"
+	"github.com/cloudtrust/common-service/log"
+	api "github.com/cloudtrust/keycloak-bridge/api/management"
+	"github.com/cloudtrust/keycloak-bridge/internal/constants"
+	"github.com/cloudtrust/keycloak-bridge/internal/dto"
+	"github.com/cloudtrust/keycloak-bridge/internal/keycloakb"
+	"github.com/cloudtrust/keycloak-bridge/pkg/management/mock"
+	kc "github.com/cloudtrust/keycloak-client"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSearchUsers(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+	var mockKeycloakClient = mock.NewKeycloakClient(mockCtrl)
+	var mockUsersDetailsDBModule = mock.NewUsersDetailsDBModule(mockCtrl)
+	var mockIndexDBModule = mock.NewUsersSearchIndexDBModule(mockCtrl)
+	var mockUsersIndexer = mock.NewUsersIndexer(mockCtrl)
+	var mockJobStore = mock.NewJobStore(mockCtrl)
+	var indexer, _ = keycloakb.NewBlindIndexerFromBase64("3q8Xbp1NlUqzyFjz+5xk1u6uW8Y7Fg0m9yZbq2iCqWU=")
+
+	var component = NewSearchComponent(mockKeycloakClient, mockUsersDetailsDBModule, mockIndexDBModule, indexer, mockUsersIndexer, mockJobStore, log.NewNopLogger())
+
+	var accessToken = "TOKEN=="
+	var ctxRealm = "master"
+	var realm = "DEP"
+	var groupID = "123-456-789"
+	var ctx = context.WithValue(context.Background(), cs.CtContextAccessToken, accessToken)
+	ctx = context.WithValue(ctx, cs.CtContextRealm, ctxRealm)
+
+	var enabled, disabled = true, false
+	var userID1, userID2 = "user-1", "user-2"
+	var phoneNumber = "+41791234567"
+	var phoneHash = indexer.Index(realm, keycloakb.IndexPhoneNumber, phoneNumber)
+	var accreditations = kc.Attributes{constants.AttrbAccreditations: []string{`{"type":"SHADOW","expiryDate":"31.12.2030"}`},
+		constants.AttrbPhoneNumber: []string{phoneNumber}}
+	var phoneAttributes = kc.Attributes{constants.AttrbPhoneNumber: []string{phoneNumber}}
+	var enabledUser = kc.UserRepresentation{ID: &userID1, Enabled: &enabled, Attributes: &accreditations}
+	var disabledUser = kc.UserRepresentation{ID: &userID2, Enabled: &disabled, Attributes: &phoneAttributes}
+	var count = 2
+	var kcUsers = kc.UsersPageRepresentation{Count: &count, Users: []kc.UserRepresentation{enabledUser, disabledUser}}
+
+	t.Run("Keycloak criteria only", func(t *testing.T) {
+		var criteria = api.UserSearchCriteria{GroupIDs: []string{groupID}, KeycloakParams: []string{prmQryEmail, "a@b.ch"}, First: 10, Max: 5}
+		mockKeycloakClient.EXPECT().GetUsers(accessToken, ctxRealm, realm, prmQryFirst, "10", prmQryMax, "5", prmQryEmail, "a@b.ch", "groupId", groupID).Return(kcUsers, nil)
+		var res, err = component.SearchUsers(ctx, realm, criteria)
+		assert.Nil(t, err)
+		assert.Len(t, res.Users, 2)
+	})
+
+	t.Run("Soft deleted users are hidden", func(t *testing.T) {
+		var deleted = kc.Attributes{constants.AttrbSoftDeleted: []string{"1591000000000"}}
+		var deletedUser = kc.UserRepresentation{ID: &userID2, Enabled: &disabled, Attributes: &deleted}
+		var page = kc.UsersPageRepresentation{Count: &count, Users: []kc.UserRepresentation{enabledUser, deletedUser}}
+		mockKeycloakClient.EXPECT().GetUsers(accessToken, ctxRealm, realm, prmQryFirst, "0", prmQryMax, "5").Return(page, nil).Times(2)
+
+		var res, err = component.SearchUsers(ctx, realm, api.UserSearchCriteria{Max: 5})
+		assert.Nil(t, err)
+		assert.Len(t, res.Users, 1)
+		res, err = component.SearchUsers(ctx, realm, api.UserSearchCriteria{Max: 5, IncludeDeleted: true})
+		assert.Nil(t, err)
+		assert.Len(t, res.Users, 2)
+	})
+
+	t.Run("Keycloak fails", func(t *testing.T) {
+		var kcError = errors.New("kc error")
+		mockKeycloakClient.EXPECT().GetUsers(accessToken, ctxRealm, realm, gomock.Any()).Return(kc.UsersPageRepresentation{}, kcError)
+		var _, err = component.SearchUsers(ctx, realm, api.UserSearchCriteria{Enabled: &enabled, Max: 10})
+		assert.Equal(t, kcError, err)
+	})
+
+	t.Run("Users criteria are checked on the Keycloak pages", func(t *testing.T) {
+		var accreditationType = "SHADOW"
+		var criteria = api.UserSearchCriteria{Enabled: &enabled, AccreditationType: &accreditationType, Max: 10}
+		mockKeycloakClient.EXPECT().GetUsers(accessToken, ctxRealm, realm, prmQryFirst, "0", prmQryMax, "100").Return(kcUsers, nil)
+		var res, err = component.SearchUsers(ctx, realm, criteria)
+		assert.Nil(t, err)
+		assert.Equal(t, 1, *res.Count)
+		assert.Equal(t, userID1, *res.Users[0].ID)
+	})
+
+	t.Run("Index search fails", func(t *testing.T) {
+		var dbError = errors.New("db error")
+		mockIndexDBModule.EXPECT().SearchUserIDs(ctx, realm, dto.DBUserSearchIndex{PhoneNumberHash: &phoneHash}, maxIndexedSearchCandidates+1).Return(nil, dbError)
+		var _, err = component.SearchUsers(ctx, realm, api.UserSearchCriteria{PhoneNumber: &phoneNumber, Max: 10})
+		assert.Equal(t, dbError, err)
+	})
+
+	t.Run("Indexed users are read from Keycloak, unknown ones are removed from the index", func(t *testing.T) {
+		var unknownID = "unknown"
+		mockIndexDBModule.EXPECT().SearchUserIDs(ctx, realm, dto.DBUserSearchIndex{PhoneNumberHash: &phoneHash}, maxIndexedSearchCandidates+1).Return([]string{unknownID, userID1, userID2}, nil)
+		mockKeycloakClient.EXPECT().GetUser(accessToken, realm, unknownID).Return(kc.UserRepresentation{}, kc.HTTPError{HTTPStatus: http.StatusNotFound})
+		mockUsersIndexer.EXPECT().UnindexUser(ctx, realm, unknownID).Return(nil)
+		mockKeycloakClient.EXPECT().GetUser(accessToken, realm, userID1).Return(enabledUser, nil)
+		mockKeycloakClient.EXPECT().GetUser(accessToken, realm, userID2).Return(disabledUser, nil)
+		var res, err = component.SearchUsers(ctx, realm, api.UserSearchCriteria{PhoneNumber: &phoneNumber, First: 1, Max: 10})
+		assert.Nil(t, err)
+		assert.Equal(t, 2, *res.Count)
+		assert.Len(t, res.Users, 1)
+		assert.Equal(t, userID2, *res.Users[0].ID)
+	})
+
+	t.Run("Stale users found in the index are dropped and indexed again", func(t *testing.T) {
+		var otherPhoneAttributes = kc.Attributes{constants.AttrbPhoneNumber: []string{"+41797654321"}}
+		mockIndexDBModule.EXPECT().SearchUserIDs(ctx, realm, dto.DBUserSearchIndex{PhoneNumberHash: &phoneHash}, maxIndexedSearchCandidates+1).Return([]string{userID1, userID2}, nil)
+		mockKeycloakClient.EXPECT().GetUser(accessToken, realm, userID1).Return(enabledUser, nil)
+		mockKeycloakClient.EXPECT().GetUser(accessToken, realm, userID2).Return(kc.UserRepresentation{ID: &userID2, Enabled: &disabled, Attributes: &otherPhoneAttributes}, nil)
+		mockUsersIndexer.EXPECT().IndexUser(ctx, realm, userID2).Return(nil)
+		var res, err = component.SearchUsers(ctx, realm, api.UserSearchCriteria{PhoneNumber: &phoneNumber, Max: 10})
+		assert.Nil(t, err)
+		assert.Equal(t, 1, *res.Count)
+		assert.Equal(t, userID1, *res.Users[0].ID)
+	})
+
+	t.Run("Document number of the users found in the index is read from the users details", func(t *testing.T) {
+		var docNumber, otherDocNumber = "AB123456", "CD654321"
+		var docHash = indexer.Index(realm, keycloakb.IndexIDDocumentNumber, docNumber)
+		var criteria = api.UserSearchCriteria{IDDocumentNumber: &docNumber, Max: 10}
+		var dbError = errors.New("db error")
+
+		mockIndexDBModule.EXPECT().SearchUserIDs(ctx, realm, dto.DBUserSearchIndex{IDDocumentNumberHash: &docHash}, maxIndexedSearchCandidates+1).Return([]string{userID1}, nil)
+		mockKeycloakClient.EXPECT().GetUser(accessToken, realm, userID1).Return(enabledUser, nil)
+		mockUsersDetailsDBModule.EXPECT().GetUserDetails(ctx, realm, userID1).Return(dto.DBUser{}, dbError)
+		var _, err = component.SearchUsers(ctx, realm, criteria)
+		assert.Equal(t, dbError, err)
+
+		mockIndexDBModule.EXPECT().SearchUserIDs(ctx, realm, dto.DBUserSearchIndex{IDDocumentNumberHash: &docHash}, maxIndexedSearchCandidates+1).Return([]string{userID1, userID2}, nil)
+		mockKeycloakClient.EXPECT().GetUser(accessToken, realm, userID1).Return(enabledUser, nil)
+		mockUsersDetailsDBModule.EXPECT().GetUserDetails(ctx, realm, userID1).Return(dto.DBUser{IDDocumentNumber: &docNumber}, nil)
+		mockKeycloakClient.EXPECT().GetUser(accessToken, realm, userID2).Return(disabledUser, nil)
+		mockUsersDetailsDBModule.EXPECT().GetUserDetails(ctx, realm, userID2).Return(dto.DBUser{IDDocumentNumber: &otherDocNumber}, nil)
+		mockUsersIndexer.EXPECT().IndexUser(ctx, realm, userID2).Return(nil)
+		res, err := component.SearchUsers(ctx, realm, criteria)
+		assert.Nil(t, err)
+		assert.Equal(t, 1, *res.Count)
+		assert.Equal(t, userID1, *res.Users[0].ID)
+	})
+
+	t.Run("Indexed users are intersected with Keycloak search", func(t *testing.T) {
+		mockIndexDBModule.EXPECT().SearchUserIDs(ctx, realm, dto.DBUserSearchIndex{PhoneNumberHash: &phoneHash}, maxIndexedSearchCandidates+1).Return([]string{userID2}, nil)
+		mockKeycloakClient.EXPECT().GetUsers(accessToken, ctxRealm, realm, prmQryFirst, "0", prmQryMax, "100", "groupId", groupID).Return(kcUsers, nil)
+		var res, err = component.SearchUsers(ctx, realm, api.UserSearchCriteria{GroupIDs: []string{groupID}, PhoneNumber: &phoneNumber, Max: 10})
+		assert.Nil(t, err)
+		assert.Equal(t, 1, *res.Count)
+		assert.Equal(t, userID2, *res.Users[0].ID)
+	})
+
+	t.Run("Too many users found in the index", func(t *testing.T) {
+		var userIDs = make([]string, maxIndexedSearchCandidates+1)
+		mockIndexDBModule.EXPECT().SearchUserIDs(ctx, realm, dto.DBUserSearchIndex{PhoneNumberHash: &phoneHash}, maxIndexedSearchCandidates+1).Return(userIDs, nil)
+		var _, err = component.SearchUsers(ctx, realm, api.UserSearchCriteria{PhoneNumber: &phoneNumber, Max: 10})
+		assert.NotNil(t, err)
+		assert.Equal(t, http.StatusBadRequest, err.(errorhandler.Error).Status)
+	})
+
+	t.Run("Too many users to filter", func(t *testing.T) {
+		var total = maxScannedSearchUsers + 1
+		mockKeycloakClient.EXPECT().GetUsers(accessToken, ctxRealm, realm, prmQryFirst, "0", prmQryMax, "100").Return(kc.UsersPageRepresentation{Count: &total, Users: kcUsers.Users}, nil)
+		var _, err = component.SearchUsers(ctx, realm, api.UserSearchCriteria{Enabled: &enabled, Max: 10})
+		assert.NotNil(t, err)
+		assert.Equal(t, http.StatusBadRequest, err.(errorhandler.Error).Status)
+	})
+}
+
+func TestIndexUsers(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+	var mockKeycloakClient = mock.NewKeycloakClient(mockCtrl)
+	var mockUsersDetailsDBModule = mock.NewUsersDetailsDBModule(mockCtrl)
+	var mockIndexDBModule = mock.NewUsersSearchIndexDBModule(mockCtrl)
+	var mockUsersIndexer = mock.NewUsersIndexer(mockCtrl)
+	var mockJobStore = mock.NewJobStore(mockCtrl)
+	var indexer, _ = keycloakb.NewBlindIndexerFromBase64("3q8Xbp1NlUqzyFjz+5xk1u6uW8Y7Fg0m9yZbq2iCqWU=")
+
+	var accessToken = "TOKEN=="
+	var ctxRealm = "master"
+	var realm = "DEP"
+	var jobID = "job-id"
+	var ctx = context.WithValue(context.Background(), cs.CtContextAccessToken, accessToken)
+	ctx = context.WithValue(ctx, cs.CtContextRealm, ctxRealm)
+	var userID1, userID2 = "user-1", "user-2"
+	var kcError = errors.New("kc error")
+
+	t.Run("Index one user", func(t *testing.T) {
+		var usersIndexer = NewUsersIndexer(mockKeycloakClient, mockUsersDetailsDBModule, mockIndexDBModule, indexer, log.NewNopLogger())
+		var attributes = kc.Attributes{constants.AttrbPhoneNumber: []string{"+41 79 123 45 67"}}
+		var docNumber = "AB123456"
+		var phoneHash = indexer.Index(realm, keycloakb.IndexPhoneNumber, "+41791234567")
+		var docHash = indexer.Index(realm, keycloakb.IndexIDDocumentNumber, docNumber)
+
+		mockKeycloakClient.EXPECT().GetUser(accessToken, realm, userID1).Return(kc.UserRepresentation{}, kcError)
+		assert.Equal(t, kcError, usersIndexer.IndexUser(ctx, realm, userID1))
+
+		mockKeycloakClient.EXPECT().GetUser(accessToken, realm, userID1).Return(kc.UserRepresentation{ID: &userID1, Attributes: &attributes}, nil)
+		mockUsersDetailsDBModule.EXPECT().GetUserDetails(ctx, realm, userID1).Return(dto.DBUser{IDDocumentNumber: &docNumber}, nil)
+		mockIndexDBModule.EXPECT().StoreOrUpdateUserSearchIndex(ctx, realm, dto.DBUserSearchIndex{UserID: userID1, PhoneNumberHash: &phoneHash, IDDocumentNumberHash: &docHash}).Return(nil)
+		assert.Nil(t, usersIndexer.IndexUser(ctx, realm, userID1))
+	})
+
+	t.Run("Rebuild the index of a realm", func(t *testing.T) {
+		var component = NewSearchComponent(mockKeycloakClient, mockUsersDetailsDBModule, mockIndexDBModule, indexer, mockUsersIndexer, mockJobStore, log.NewNopLogger())
+		var count = 2
+		var report api.SearchIndexReportRepresentation
+		mockJobStore.EXPECT().Start(ctx, realm, gomock.Any()).DoAndReturn(func(ctx context.Context, realm string, fn keycloakb.JobFunc) string {
+			mockKeycloakClient.EXPECT().GetUsers(accessToken, ctxRealm, realm, prmQryFirst, "0", prmQryMax, "100").
+				Return(kc.UsersPageRepresentation{Count: &count, Users: []kc.UserRepresentation{{ID: &userID1}, {ID: &userID2}}}, nil)
+			mockUsersIndexer.EXPECT().IndexUser(ctx, realm, userID1).Return(nil)
+			mockUsersIndexer.EXPECT().IndexUser(ctx, realm, userID2).Return(kcError)
+			var res, err = fn(ctx, func(int, int) {})
+			assert.Nil(t, err)
+			report = res.(api.SearchIndexReportRepresentation)
+			return jobID
+		})
+
+		var res, err = component.IndexUsers(ctx, realm)
+		assert.Nil(t, err)
+		assert.Equal(t, jobID, res.ID)
+		assert.Equal(t, api.SearchIndexReportRepresentation{Total: 2, Indexed: 1, Failed: 1}, report)
+	})
+}
+
+func TestSearchIndexComponentMW(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+	var mockManagementComponent = mock.NewManagementComponent(mockCtrl)
+	var mockUsersIndexer = mock.NewUsersIndexer(mockCtrl)
+
+	var component = MakeSearchIndexComponentMW(mockUsersIndexer, log.NewNopLogger())(mockManagementComponent)
+
+	var ctx = context.TODO()
+	var realm = "DEP"
+	var userID = "f467ed7c-0a1d-4eee-9bb8-669c6f89c0ee"
+	var user = api.UserRepresentation{}
+	var kcError = errors.New("kc error")
+
+	t.Run("Create user", func(t *testing.T) {
+		var location = "https://keycloak/auth/admin/realms/" + realm + "/users/" + userID
+		mockManagementComponent.EXPECT().CreateUser(ctx, realm, user).Return(location, nil)
+		mockUsersIndexer.EXPECT().IndexUser(ctx, realm, userID).Return(kcError)
+		var res, err = component.CreateUser(ctx, realm, user)
+		assert.Nil(t, err)
+		assert.Equal(t, location, res)
+	})
+
+	t.Run("Update user fails", func(t *testing.T) {
+		mockManagementComponent.EXPECT().UpdateUser(ctx, realm, userID, user).Return(kcError)
+		assert.Equal(t, kcError, component.UpdateUser(ctx, realm, userID, user))
+	})
+
+	t.Run("Update user", func(t *testing.T) {
+		mockManagementComponent.EXPECT().UpdateUser(ctx, realm, userID, user).Return(nil)
+		mockUsersIndexer.EXPECT().IndexUser(ctx, realm, userID).Return(nil)
+		assert.Nil(t, component.UpdateUser(ctx, realm, userID, user))
+	})
+
+	t.Run("Delete user", func(t *testing.T) {
+		mockManagementComponent.EXPECT().DeleteUser(ctx, realm, userID).Return(nil)
+		mockUsersIndexer.EXPECT().UnindexUser(ctx, realm, userID).Return(nil)
+		assert.Nil(t, component.DeleteUser(ctx, realm, userID))
+	})
+
+	t.Run("Other calls are forwarded", func(t *testing.T) {
+		mockManagementComponent.EXPECT().LockUser(ctx, realm, userID).Return(nil)
+		assert.Nil(t, component.LockUser(ctx, realm, userID))
+	})
+}
+
+func TestUsersPage(t *testing.T) {
+	var id1, id2, id3 = "1", "2", "3"
+	var users = []api.UserRepresentation{{ID: &id1}, {ID: &id2}, {ID: &id3}}
+
+	assert.Len(t, usersPage(users, 0, 2).Users, 2)
+	assert.Equal(t, []api.UserRepresentation{{ID: &id3}}, usersPage(users, 2, 2).Users)
+	assert.Len(t, usersPage(users, 5, 2).Users, 0)
+	assert.Equal(t, 3, *usersPage(users, 5, 2).Count)
+}
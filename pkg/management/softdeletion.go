@@ -0,0 +1,75 @@
+package management
+
+import (
+	"context"
+
+	"github.com/cloudtrust/common-service/database"
+	"github.com/cloudtrust/keycloak-bridge/internal/keycloakb"
+)
+
+// UserSoftDeletion is the interface of the soft deletion of users
+type UserSoftDeletion interface {
+	SoftDeleteUser(ctx context.Context, realmName string, userID string) error
+	RestoreUser(ctx context.Context, realmName string, userID string) error
+}
+
+// SoftDeletionComponent is the interface of the restoration of soft deleted users
+type SoftDeletionComponent interface {
+	RestoreUser(ctx context.Context, realmName string, userID string) error
+}
+
+type softDeletionComponent struct {
+	softDeletion  UserSoftDeletion
+	eventDBModule database.EventsDBModule
+	logger        keycloakb.Logger
+}
+
+// NewSoftDeletionComponent returns the component used to restore soft deleted users
+func NewSoftDeletionComponent(softDeletion UserSoftDeletion, eventDBModule database.EventsDBModule, logger keycloakb.Logger) SoftDeletionComponent {
+	return &softDeletionComponent{
+		softDeletion:  softDeletion,
+		eventDBModule: eventDBModule,
+		logger:        logger,
+	}
+}
+
+// RestoreUser restores a user who has been soft deleted and is not purged yet
+func (c *softDeletionComponent) RestoreUser(ctx context.Context, realmName string, userID string) error {
+	if err := c.softDeletion.RestoreUser(ctx, realmName, userID); err != nil {
+		return err
+	}
+
+	reportEvent(ctx, c.eventDBModule, c.logger, "API_ACCOUNT_RESTORE", database.CtEventRealmName, realmName, database.CtEventUserID, userID)
+
+	return nil
+}
+
+type softDeletionComponentMW struct {
+	Component
+	softDeletion  UserSoftDeletion
+	eventDBModule database.EventsDBModule
+	logger        keycloakb.Logger
+}
+
+// MakeSoftDeletionComponentMW replaces the deletion of users by a soft deletion: the users are disabled and marked as deleted,
+// they are purged once their grace period is elapsed
+func MakeSoftDeletionComponentMW(softDeletion UserSoftDeletion, eventDBModule database.EventsDBModule, logger keycloakb.Logger) func(Component) Component {
+	return func(next Component) Component {
+		return &softDeletionComponentMW{
+			Component:     next,
+			softDeletion:  softDeletion,
+			eventDBModule: eventDBModule,
+			logger:        logger,
+		}
+	}
+}
+
+func (m *softDeletionComponentMW) DeleteUser(ctx context.Context, realmName, userID string) error {
+	if err := m.softDeletion.SoftDeleteUser(ctx, realmName, userID); err != nil {
+		return err
+	}
+
+	reportEvent(ctx, m.eventDBModule, m.logger, "API_ACCOUNT_SOFT_DELETION", database.CtEventRealmName, realmName, database.CtEventUserID, userID)
+
+	return nil
+}
@@ -0,0 +1,69 @@
+package management
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/cloudtrust/common-service/database"
+	"github.com/cloudtrust/common-service/log"
+	"github.com/cloudtrust/keycloak-bridge/pkg/management/mock"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRestoreUser(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+	var mockSoftDeletion = mock.NewUserSoftDeletion(mockCtrl)
+	var mockEventDBModule = mock.NewEventDBModule(mockCtrl)
+
+	var component = NewSoftDeletionComponent(mockSoftDeletion, mockEventDBModule, log.NewNopLogger())
+
+	var ctx = context.TODO()
+	var realm = "DEP"
+	var userID = "f467ed7c-0a1d-4eee-9bb8-669c6f89c0ee"
+
+	t.Run("Restoration fails", func(t *testing.T) {
+		var expectedError = errors.New("db error")
+		mockSoftDeletion.EXPECT().RestoreUser(ctx, realm, userID).Return(expectedError)
+		assert.Equal(t, expectedError, component.RestoreUser(ctx, realm, userID))
+	})
+
+	t.Run("User is restored", func(t *testing.T) {
+		mockSoftDeletion.EXPECT().RestoreUser(ctx, realm, userID).Return(nil)
+		mockEventDBModule.EXPECT().ReportEvent(ctx, "API_ACCOUNT_RESTORE", "back-office", database.CtEventRealmName, realm, database.CtEventUserID, userID).Return(nil)
+		assert.Nil(t, component.RestoreUser(ctx, realm, userID))
+	})
+}
+
+func TestSoftDeletionComponentMW(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+	var mockManagementComponent = mock.NewManagementComponent(mockCtrl)
+	var mockSoftDeletion = mock.NewUserSoftDeletion(mockCtrl)
+	var mockEventDBModule = mock.NewEventDBModule(mockCtrl)
+
+	var component = MakeSoftDeletionComponentMW(mockSoftDeletion, mockEventDBModule, log.NewNopLogger())(mockManagementComponent)
+
+	var ctx = context.TODO()
+	var realm = "DEP"
+	var userID = "f467ed7c-0a1d-4eee-9bb8-669c6f89c0ee"
+
+	t.Run("Soft deletion fails", func(t *testing.T) {
+		var expectedError = errors.New("kc error")
+		mockSoftDeletion.EXPECT().SoftDeleteUser(ctx, realm, userID).Return(expectedError)
+		assert.Equal(t, expectedError, component.DeleteUser(ctx, realm, userID))
+	})
+
+	t.Run("User is soft deleted", func(t *testing.T) {
+		mockSoftDeletion.EXPECT().SoftDeleteUser(ctx, realm, userID).Return(nil)
+		mockEventDBModule.EXPECT().ReportEvent(ctx, "API_ACCOUNT_SOFT_DELETION", "back-office", database.CtEventRealmName, realm, database.CtEventUserID, userID).Return(errors.New("db error"))
+		assert.Nil(t, component.DeleteUser(ctx, realm, userID))
+	})
+
+	t.Run("Other calls are forwarded", func(t *testing.T) {
+		mockManagementComponent.EXPECT().LockUser(ctx, realm, userID).Return(nil)
+		assert.Nil(t, component.LockUser(ctx, realm, userID))
+	})
+}
@@ -116,3 +116,8 @@ func (c *authorizationComponentMW) RegisterUser(ctx context.Context, realmName s
 func (c *authorizationComponentMW) GetConfiguration(ctx context.Context, realmName string) (apiregister.ConfigurationRepresentation, error) {
 	return c.next.GetConfiguration(ctx, realmName)
 }
+
+// authorizationComponentMW implements Component.
+func (c *authorizationComponentMW) ValidatePassword(ctx context.Context, request apiregister.PasswordValidationRequest) (apiregister.PasswordValidationRepresentation, error) {
+	return c.next.ValidatePassword(ctx, request)
+}
@@ -113,4 +113,9 @@ func TestMakeAuthorizationRegisterComponentMW(t *testing.T) {
 	mockComponent.EXPECT().GetConfiguration(ctx, realm).Return(apiregister.ConfigurationRepresentation{}, expectedErr).Times(1)
 	_, err = component.GetConfiguration(ctx, realm)
 	assert.Equal(t, expectedErr, err)
+
+	var request = apiregister.PasswordValidationRequest{}
+	mockComponent.EXPECT().ValidatePassword(ctx, request).Return(apiregister.PasswordValidationRepresentation{}, expectedErr).Times(1)
+	_, err = component.ValidatePassword(ctx, request)
+	assert.Equal(t, expectedErr, err)
 }
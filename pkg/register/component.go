@@ -57,6 +57,7 @@ type ConfigurationDBModule interface {
 type Component interface {
 	RegisterUser(ctx context.Context, clientRealmName string, user apiregister.UserRepresentation) (string, error)
 	GetConfiguration(ctx context.Context, realmName string) (apiregister.ConfigurationRepresentation, error)
+	ValidatePassword(ctx context.Context, request apiregister.PasswordValidationRequest) (apiregister.PasswordValidationRepresentation, error)
 }
 
 // Component is the management component.
@@ -71,13 +72,14 @@ type component struct {
 	usersDBModule           keycloakb.UsersDetailsDBModule
 	configDBModule          ConfigurationDBModule
 	eventsDBModule          database.EventsDBModule
+	passwordValidator       keycloakb.PasswordValidator
 	logger                  internal.Logger
 }
 
 // NewComponent returns the management component.
 func NewComponent(keycloakURL string, realm string, ssePublicURL string, registerEnduserClientID string, registerEndUserGroups []string, keycloakClient KeycloakClient,
 	tokenProvider toolbox.OidcTokenProvider, usersDBModule keycloakb.UsersDetailsDBModule,
-	configDBModule ConfigurationDBModule, eventsDBModule database.EventsDBModule, logger internal.Logger) (Component, error) {
+	configDBModule ConfigurationDBModule, eventsDBModule database.EventsDBModule, passwordValidator keycloakb.PasswordValidator, logger internal.Logger) (Component, error) {
 	var c = &component{
 		keycloakURL:             keycloakURL,
 		realm:                   realm,
@@ -88,6 +90,7 @@ func NewComponent(keycloakURL string, realm string, ssePublicURL string, registe
 		usersDBModule:           usersDBModule,
 		configDBModule:          configDBModule,
 		eventsDBModule:          eventsDBModule,
+		passwordValidator:       passwordValidator,
 		logger:                  logger,
 	}
 	var err error
@@ -325,6 +328,23 @@ func (c *component) GetConfiguration(ctx context.Context, realmName string) (api
 	}, nil
 }
 
+// ValidatePassword checks a candidate password against the password policy of the realm of the registered users
+func (c *component) ValidatePassword(ctx context.Context, request apiregister.PasswordValidationRequest) (apiregister.PasswordValidationRepresentation, error) {
+	var username, email string
+	if request.Username != nil {
+		username = *request.Username
+	}
+	if request.Email != nil {
+		email = *request.Email
+	}
+
+	var failures, err = c.passwordValidator.ValidatePassword(ctx, c.realm, *request.Password, username, email)
+	if err != nil {
+		return apiregister.PasswordValidationRepresentation{}, err
+	}
+	return apiregister.ConvertToAPIPasswordValidation(failures), nil
+}
+
 func (c *component) generateUsername(chars []rune, length int) string {
 	var b strings.Builder
 
@@ -11,6 +11,7 @@ import (
 	errorhandler "github.com/cloudtrust/common-service/errors"
 	log "github.com/cloudtrust/common-service/log"
 	apiregister "github.com/cloudtrust/keycloak-bridge/api/register"
+	"github.com/cloudtrust/keycloak-bridge/internal/keycloakb"
 	"github.com/cloudtrust/keycloak-bridge/pkg/register/mock"
 	kc "github.com/cloudtrust/keycloak-client"
 	"github.com/golang/mock/gomock"
@@ -70,31 +71,31 @@ func TestGroupIDsResolution(t *testing.T) {
 
 	t.Run("ProvideToken fails", func(t *testing.T) {
 		mockTokenProvider.EXPECT().ProvideToken(gomock.Any()).Return(accessToken, anError)
-		var _, err = NewComponent(anyString, targetRealm, anyString, anyString, enduserGroups, nil, mockTokenProvider, nil, nil, nil, nil)
+		var _, err = NewComponent(anyString, targetRealm, anyString, anyString, enduserGroups, nil, mockTokenProvider, nil, nil, nil, nil, nil)
 		assert.Equal(t, anError, err)
 	})
 	mockTokenProvider.EXPECT().ProvideToken(gomock.Any()).Return(accessToken, nil).AnyTimes()
 
 	t.Run("GetGroups fails", func(t *testing.T) {
 		mockKeycloakClient.EXPECT().GetGroups(accessToken, targetRealm).Return(nil, anError)
-		var _, err = NewComponent(anyString, targetRealm, anyString, anyString, enduserGroups, mockKeycloakClient, mockTokenProvider, nil, nil, nil, nil)
+		var _, err = NewComponent(anyString, targetRealm, anyString, anyString, enduserGroups, mockKeycloakClient, mockTokenProvider, nil, nil, nil, nil, nil)
 		assert.Equal(t, anError, err)
 	})
 	t.Run("Unknown groups", func(t *testing.T) {
 		mockKeycloakClient.EXPECT().GetGroups(accessToken, targetRealm).Return([]kc.GroupRepresentation{}, nil)
-		var _, err = NewComponent(anyString, targetRealm, anyString, anyString, enduserGroups, mockKeycloakClient, mockTokenProvider, nil, nil, nil, nil)
+		var _, err = NewComponent(anyString, targetRealm, anyString, anyString, enduserGroups, mockKeycloakClient, mockTokenProvider, nil, nil, nil, nil, nil)
 		assert.NotNil(t, err)
 	})
 	mockKeycloakClient.EXPECT().GetGroups(accessToken, targetRealm).Return(groups, nil).AnyTimes()
 
 	t.Run("Success", func(t *testing.T) {
-		var res, err = NewComponent(anyString, targetRealm, anyString, anyString, enduserGroups, mockKeycloakClient, mockTokenProvider, nil, nil, nil, nil)
+		var res, err = NewComponent(anyString, targetRealm, anyString, anyString, enduserGroups, mockKeycloakClient, mockTokenProvider, nil, nil, nil, nil, nil)
 		assert.Nil(t, err)
 		assert.Len(t, res.(*component).registerEndUserGroups, len(enduserGroups))
 	})
 }
 
-func createComponent(keycloakURL, targetRealm, ssePublicURL, enduserClientID string, enduserGroups []string, keycloakClient *mock.KeycloakClient, tokenProvider *mock.OidcTokenProvider, usersDB *mock.UsersDetailsDBModule, configDB *mock.ConfigurationDBModule, eventsDB *mock.EventsDBModule) Component {
+func createComponent(keycloakURL, targetRealm, ssePublicURL, enduserClientID string, enduserGroups []string, keycloakClient *mock.KeycloakClient, tokenProvider *mock.OidcTokenProvider, usersDB *mock.UsersDetailsDBModule, configDB *mock.ConfigurationDBModule, eventsDB *mock.EventsDBModule, passwordValidator *mock.PasswordValidator) Component {
 	var accessToken = "the-access-token"
 	var group1ID = "end_user-group-id"
 	var group1Name = "end_user"
@@ -103,7 +104,7 @@ func createComponent(keycloakURL, targetRealm, ssePublicURL, enduserClientID str
 	tokenProvider.EXPECT().ProvideToken(gomock.Any()).Return(accessToken, nil)
 	keycloakClient.EXPECT().GetGroups(accessToken, targetRealm).Return(groups, nil)
 
-	var c, _ = NewComponent(keycloakURL, targetRealm, ssePublicURL, enduserClientID, enduserGroups, keycloakClient, tokenProvider, usersDB, configDB, eventsDB, log.NewNopLogger())
+	var c, _ = NewComponent(keycloakURL, targetRealm, ssePublicURL, enduserClientID, enduserGroups, keycloakClient, tokenProvider, usersDB, configDB, eventsDB, passwordValidator, log.NewNopLogger())
 	return c
 }
 
@@ -128,7 +129,7 @@ func TestRegisterUser(t *testing.T) {
 	var accessToken = "abcdef"
 	var empty = 0
 	var usersSearchResult = kc.UsersPageRepresentation{Count: &empty}
-	var component = createComponent(keycloakURL, targetRealm, ssePublicURL, enduserClientID, enduserGroups, mockKeycloakClient, mockTokenProvider, mockUsersDB, mockConfigDB, mockEventsDB)
+	var component = createComponent(keycloakURL, targetRealm, ssePublicURL, enduserClientID, enduserGroups, mockKeycloakClient, mockTokenProvider, mockUsersDB, mockConfigDB, mockEventsDB, nil)
 
 	t.Run("User is not valid", func(t *testing.T) {
 		// User is not valid
@@ -254,7 +255,7 @@ func TestRegisterUser(t *testing.T) {
 		var successURL = "http://couldtrust.ch"
 		var enduserGroups = []string{"end_user"}
 		var realmConfiguration = configuration.RealmConfiguration{RegisterExecuteActions: &requiredActions, RedirectSuccessfulRegistrationURL: &successURL}
-		var component = createComponent("not\nvalid\nURL", targetRealm, "", "", enduserGroups, mockKeycloakClient, mockTokenProvider, mockUsersDB, mockConfigDB, mockEventsDB)
+		var component = createComponent("not\nvalid\nURL", targetRealm, "", "", enduserGroups, mockKeycloakClient, mockTokenProvider, mockUsersDB, mockConfigDB, mockEventsDB, nil)
 
 		mockConfigDB.EXPECT().GetConfiguration(ctx, confRealm).Return(realmConfiguration, nil)
 		mockTokenProvider.EXPECT().ProvideToken(ctx).Return(token, nil)
@@ -371,7 +372,7 @@ func TestGetConfiguration(t *testing.T) {
 	var enduserGroups = []string{"end_user"}
 	var targetRealm = "cloudtrust"
 	var confRealm = "test"
-	var component = createComponent(keycloakURL, targetRealm, ssePublicURL, enduserClientID, enduserGroups, mockKeycloakClient, mockTokenProvider, mockUsersDB, mockConfigDB, mockEventsDB)
+	var component = createComponent(keycloakURL, targetRealm, ssePublicURL, enduserClientID, enduserGroups, mockKeycloakClient, mockTokenProvider, mockUsersDB, mockConfigDB, mockEventsDB, nil)
 
 	t.Run("Retrieve configuration successfully", func(t *testing.T) {
 		// Retrieve configuration successfully
@@ -387,3 +388,34 @@ func TestGetConfiguration(t *testing.T) {
 		assert.NotNil(t, err)
 	})
 }
+
+func TestValidatePassword(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var mockKeycloakClient = mock.NewKeycloakClient(mockCtrl)
+	var mockTokenProvider = mock.NewOidcTokenProvider(mockCtrl)
+	var mockPasswordValidator = mock.NewPasswordValidator(mockCtrl)
+
+	var ctx = context.TODO()
+	var targetRealm = "cloudtrust"
+	var enduserGroups = []string{"end_user"}
+	var password = "password"
+	var email = "john.doe@example.com"
+	var component = createComponent("https://idp.trustid.ch", targetRealm, "https://sse.trustid.ch", "selfserviceid", enduserGroups, mockKeycloakClient,
+		mockTokenProvider, nil, nil, nil, mockPasswordValidator)
+
+	t.Run("Validation fails", func(t *testing.T) {
+		mockPasswordValidator.EXPECT().ValidatePassword(ctx, targetRealm, password, "", "").Return(nil, errors.New("kc fails"))
+		var _, err = component.ValidatePassword(ctx, apiregister.PasswordValidationRequest{Password: &password})
+		assert.NotNil(t, err)
+	})
+	t.Run("Invalid password", func(t *testing.T) {
+		mockPasswordValidator.EXPECT().ValidatePassword(ctx, targetRealm, password, "", email).
+			Return([]keycloakb.PasswordPolicyFailure{{Rule: keycloakb.PolicyBreached}}, nil)
+		var res, err = component.ValidatePassword(ctx, apiregister.PasswordValidationRequest{Password: &password, Email: &email})
+		assert.Nil(t, err)
+		assert.False(t, res.Valid)
+		assert.Equal(t, keycloakb.PolicyBreached, res.Failures[0].Rule)
+	})
+}
@@ -2,6 +2,7 @@ package register
 
 import (
 	"context"
+	"encoding/json"
 
 	cs "github.com/cloudtrust/common-service"
 	commonerrors "github.com/cloudtrust/common-service/errors"
@@ -14,6 +15,7 @@ import (
 type Endpoints struct {
 	RegisterUser     endpoint.Endpoint
 	GetConfiguration endpoint.Endpoint
+	ValidatePassword endpoint.Endpoint
 }
 
 // MakeRegisterUserEndpoint endpoint creation
@@ -46,3 +48,20 @@ func MakeGetConfigurationEndpoint(component Component) cs.Endpoint {
 		return component.GetConfiguration(ctx, realm)
 	}
 }
+
+// MakeValidatePasswordEndpoint endpoint creation
+func MakeValidatePasswordEndpoint(component Component) cs.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		var m = req.(map[string]string)
+
+		var request apiregister.PasswordValidationRequest
+		if err := json.Unmarshal([]byte(m[ReqBody]), &request); err != nil {
+			return nil, commonerrors.CreateBadRequestError(commonerrors.MsgErrInvalidParam + "." + msg.BodyContent)
+		}
+		if err := request.Validate(); err != nil {
+			return nil, err
+		}
+
+		return component.ValidatePassword(ctx, request)
+	}
+}
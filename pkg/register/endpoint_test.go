@@ -68,3 +68,29 @@ func TestMakeGetConfigurationEndpoint(t *testing.T) {
 		assert.Nil(t, err)
 	})
 }
+
+func TestMakeValidatePasswordEndpoint(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockRegisterComponent := mock.NewComponent(mockCtrl)
+
+	t.Run("Invalid body", func(t *testing.T) {
+		_, err := MakeValidatePasswordEndpoint(mockRegisterComponent)(context.Background(), map[string]string{ReqBody: "{"})
+		assert.NotNil(t, err)
+	})
+
+	t.Run("Missing password", func(t *testing.T) {
+		_, err := MakeValidatePasswordEndpoint(mockRegisterComponent)(context.Background(), map[string]string{ReqBody: `{"email":"john.doe@example.com"}`})
+		assert.NotNil(t, err)
+	})
+
+	t.Run("Success", func(t *testing.T) {
+		var password = "P@ssw0rd"
+		mockRegisterComponent.EXPECT().ValidatePassword(gomock.Any(), apiregister.PasswordValidationRequest{Password: &password}).
+			Return(apiregister.PasswordValidationRepresentation{Valid: true}, nil).Times(1)
+		res, err := MakeValidatePasswordEndpoint(mockRegisterComponent)(context.Background(), map[string]string{ReqBody: `{"password":"P@ssw0rd"}`})
+		assert.Nil(t, err)
+		assert.True(t, res.(apiregister.PasswordValidationRepresentation).Valid)
+	})
+}
@@ -7,3 +7,4 @@ package register
 //go:generate mockgen -destination=./mock/sqltypes.go -package=mock -mock_names=SQLRow=SQLRow,Transaction=Transaction github.com/cloudtrust/common-service/database/sqltypes SQLRow,Transaction
 //go:generate mockgen -destination=./mock/http.go -package=mock -mock_names=Handler=Handler,ResponseWriter=ResponseWriter net/http Handler,ResponseWriter
 //go:generate mockgen -destination=./mock/security.go -package=mock -mock_names=AuthorizationManager=AuthorizationManager github.com/cloudtrust/common-service/security AuthorizationManager
+//go:generate mockgen -destination=./mock/passwordpolicy.go -package=mock -mock_names=PasswordValidator=PasswordValidator github.com/cloudtrust/keycloak-bridge/internal/keycloakb PasswordValidator
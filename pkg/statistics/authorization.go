@@ -23,13 +23,16 @@ func newAction(as string, scope security.Scope) security.Action {
 
 // Actions used for authorization module
 var (
-	STGetActions                      = newAction("ST_GetActions", security.ScopeGlobal)
-	STGetStatistics                   = newAction("ST_GetStatistics", security.ScopeRealm)
-	STGetStatisticsUsers              = newAction("ST_GetStatisticsUsers", security.ScopeRealm)
-	STGetStatisticsAuthenticators     = newAction("ST_GetStatisticsAuthenticators", security.ScopeRealm)
-	STGetStatisticsAuthentications    = newAction("ST_GetStatisticsAuthentications", security.ScopeRealm)
-	STGetStatisticsAuthenticationsLog = newAction("ST_GetStatisticsAuthenticationsLog", security.ScopeRealm)
-	STGetMigrationReport              = newAction("ST_GetMigrationReport", security.ScopeRealm)
+	STGetActions                            = newAction("ST_GetActions", security.ScopeGlobal)
+	STGetStatistics                         = newAction("ST_GetStatistics", security.ScopeRealm)
+	STGetStatisticsUsers                    = newAction("ST_GetStatisticsUsers", security.ScopeRealm)
+	STGetStatisticsAuthenticators           = newAction("ST_GetStatisticsAuthenticators", security.ScopeRealm)
+	STGetStatisticsAuthentications          = newAction("ST_GetStatisticsAuthentications", security.ScopeRealm)
+	STGetStatisticsAuthenticationsLog       = newAction("ST_GetStatisticsAuthenticationsLog", security.ScopeRealm)
+	STGetMigrationReport                    = newAction("ST_GetMigrationReport", security.ScopeRealm)
+	STGetStatisticsAuthenticationsByCountry = newAction("ST_GetStatisticsAuthenticationsByCountry", security.ScopeRealm)
+	STGetStatisticsAuthenticationsByDevice  = newAction("ST_GetStatisticsAuthenticationsByDevice", security.ScopeRealm)
+	STGetStatisticsUserAuthentications      = newAction("ST_GetStatisticsUserAuthentications", security.ScopeGroup)
 )
 
 // Tracking middleware at component level.
@@ -119,12 +122,96 @@ func (c *authorizationComponentMW) GetStatisticsAuthenticationsLog(ctx context.C
 	return c.next.GetStatisticsAuthenticationsLog(ctx, realm, max)
 }
 
-func (c *authorizationComponentMW) GetMigrationReport(ctx context.Context, realm string) (map[string]bool, error) {
+func (c *authorizationComponentMW) GetStatisticsAuthenticationsByCountry(ctx context.Context, realm string, unit string) (map[string]int64, error) {
+	var action = STGetStatisticsAuthenticationsByCountry.String()
+
+	if err := c.authManager.CheckAuthorizationOnTargetRealm(ctx, action, realm); err != nil {
+		return nil, err
+	}
+
+	return c.next.GetStatisticsAuthenticationsByCountry(ctx, realm, unit)
+}
+
+func (c *authorizationComponentMW) GetStatisticsAuthenticationsByDevice(ctx context.Context, realm string, unit string) ([]api.StatisticsDeviceRepresentation, error) {
+	var action = STGetStatisticsAuthenticationsByDevice.String()
+
+	if err := c.authManager.CheckAuthorizationOnTargetRealm(ctx, action, realm); err != nil {
+		return nil, err
+	}
+
+	return c.next.GetStatisticsAuthenticationsByDevice(ctx, realm, unit)
+}
+
+func (c *authorizationComponentMW) GetStatisticsUserAuthentications(ctx context.Context, realm string, userID string, max string) ([]api.StatisticsUserConnectionRepresentation, error) {
+	var action = STGetStatisticsUserAuthentications.String()
+
+	if err := c.authManager.CheckAuthorizationOnTargetUser(ctx, action, realm, userID); err != nil {
+		return nil, err
+	}
+
+	return c.next.GetStatisticsUserAuthentications(ctx, realm, userID, max)
+}
+
+func (c *authorizationComponentMW) GetMigrationReport(ctx context.Context, realm string, filter api.MigrationReportFilter) (api.MigrationReportRepresentation, error) {
+	var action = STGetMigrationReport.String()
+
+	if err := c.authManager.CheckAuthorizationOnTargetRealm(ctx, action, realm); err != nil {
+		return api.MigrationReportRepresentation{}, err
+	}
+
+	return c.next.GetMigrationReport(ctx, realm, filter)
+}
+
+func (c *authorizationComponentMW) StartMigrationReport(ctx context.Context, realm string, filter api.MigrationReportFilter) (api.MigrationReportJobRepresentation, error) {
 	var action = STGetMigrationReport.String()
 
 	if err := c.authManager.CheckAuthorizationOnTargetRealm(ctx, action, realm); err != nil {
-		return map[string]bool{}, err
+		return api.MigrationReportJobRepresentation{}, err
+	}
+
+	return c.next.StartMigrationReport(ctx, realm, filter)
+}
+
+func (c *authorizationComponentMW) GetMigrationReportJob(ctx context.Context, realm string, jobID string) (api.MigrationReportJobRepresentation, error) {
+	var action = STGetMigrationReport.String()
+
+	if err := c.authManager.CheckAuthorizationOnTargetRealm(ctx, action, realm); err != nil {
+		return api.MigrationReportJobRepresentation{}, err
+	}
+
+	return c.next.GetMigrationReportJob(ctx, realm, jobID)
+}
+
+// checkExportAuthorizations checks the authorizations of each of the exported statistics
+func (c *authorizationComponentMW) checkExportAuthorizations(ctx context.Context, realm string) error {
+	for _, action := range []security.Action{STGetStatistics, STGetStatisticsAuthentications, STGetStatisticsAuthenticators, STGetStatisticsUsers, STGetMigrationReport} {
+		if err := c.authManager.CheckAuthorizationOnTargetRealm(ctx, action.String(), realm); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *authorizationComponentMW) StartStatisticsExport(ctx context.Context, realm string, unit string, timeshift *string, format string) (api.StatisticsExportJobRepresentation, error) {
+	if err := c.checkExportAuthorizations(ctx, realm); err != nil {
+		return api.StatisticsExportJobRepresentation{}, err
+	}
+
+	return c.next.StartStatisticsExport(ctx, realm, unit, timeshift, format)
+}
+
+func (c *authorizationComponentMW) GetStatisticsExportJob(ctx context.Context, realm string, jobID string) (api.StatisticsExportJobRepresentation, error) {
+	if err := c.checkExportAuthorizations(ctx, realm); err != nil {
+		return api.StatisticsExportJobRepresentation{}, err
+	}
+
+	return c.next.GetStatisticsExportJob(ctx, realm, jobID)
+}
+
+func (c *authorizationComponentMW) GetStatisticsExportFile(ctx context.Context, realm string, jobID string) (ExportFile, error) {
+	if err := c.checkExportAuthorizations(ctx, realm); err != nil {
+		return ExportFile{}, err
 	}
 
-	return c.next.GetMigrationReport(ctx, realm)
+	return c.next.GetStatisticsExportFile(ctx, realm, jobID)
 }
@@ -124,6 +124,55 @@ func TestGetStatisticsAuthenticationsLogAllow(t *testing.T) {
 	})
 }
 
+func TestGetMigrationReportAllow(t *testing.T) {
+	testAuthorization(t, WithAuthorization(), func(auth Component, mockComponent *mock.Component, ctx context.Context, mp map[string]string) {
+		var filter = api.MigrationReportFilter{Max: 10}
+		mockComponent.EXPECT().GetMigrationReport(ctx, mp[PrmRealm], filter).Return(api.MigrationReportRepresentation{}, nil).Times(1)
+		_, err := auth.GetMigrationReport(ctx, mp[PrmRealm], filter)
+		assert.Nil(t, err)
+
+		mockComponent.EXPECT().StartMigrationReport(ctx, mp[PrmRealm], filter).Return(api.MigrationReportJobRepresentation{}, nil).Times(1)
+		_, err = auth.StartMigrationReport(ctx, mp[PrmRealm], filter)
+		assert.Nil(t, err)
+
+		mockComponent.EXPECT().GetMigrationReportJob(ctx, mp[PrmRealm], "job-id").Return(api.MigrationReportJobRepresentation{}, nil).Times(1)
+		_, err = auth.GetMigrationReportJob(ctx, mp[PrmRealm], "job-id")
+		assert.Nil(t, err)
+	})
+}
+
+func TestExportStatisticsAllow(t *testing.T) {
+	testAuthorization(t, WithAuthorization(), func(auth Component, mockComponent *mock.Component, ctx context.Context, mp map[string]string) {
+		mockComponent.EXPECT().StartStatisticsExport(ctx, mp[PrmRealm], "days", nil, ExportFormatCSV).Return(api.StatisticsExportJobRepresentation{}, nil).Times(1)
+		_, err := auth.StartStatisticsExport(ctx, mp[PrmRealm], "days", nil, ExportFormatCSV)
+		assert.Nil(t, err)
+
+		mockComponent.EXPECT().GetStatisticsExportJob(ctx, mp[PrmRealm], "job-id").Return(api.StatisticsExportJobRepresentation{}, nil).Times(1)
+		_, err = auth.GetStatisticsExportJob(ctx, mp[PrmRealm], "job-id")
+		assert.Nil(t, err)
+
+		mockComponent.EXPECT().GetStatisticsExportFile(ctx, mp[PrmRealm], "job-id").Return(ExportFile{}, nil).Times(1)
+		_, err = auth.GetStatisticsExportFile(ctx, mp[PrmRealm], "job-id")
+		assert.Nil(t, err)
+	})
+}
+
+func TestGetStatisticsEnrichedAuthenticationsAllow(t *testing.T) {
+	testAuthorization(t, WithAuthorization(), func(auth Component, mockComponent *mock.Component, ctx context.Context, mp map[string]string) {
+		mockComponent.EXPECT().GetStatisticsAuthenticationsByCountry(ctx, mp[PrmRealm], "days").Return(map[string]int64{}, nil).Times(1)
+		_, err := auth.GetStatisticsAuthenticationsByCountry(ctx, mp[PrmRealm], "days")
+		assert.Nil(t, err)
+
+		mockComponent.EXPECT().GetStatisticsAuthenticationsByDevice(ctx, mp[PrmRealm], "days").Return([]api.StatisticsDeviceRepresentation{}, nil).Times(1)
+		_, err = auth.GetStatisticsAuthenticationsByDevice(ctx, mp[PrmRealm], "days")
+		assert.Nil(t, err)
+
+		mockComponent.EXPECT().GetStatisticsUserAuthentications(ctx, mp[PrmRealm], mp["userID"], "10").Return([]api.StatisticsUserConnectionRepresentation{}, nil).Times(1)
+		_, err = auth.GetStatisticsUserAuthentications(ctx, mp[PrmRealm], mp["userID"], "10")
+		assert.Nil(t, err)
+	})
+}
+
 func TestGetActionsDeny(t *testing.T) {
 	testAuthorization(t, WithoutAuthorization, func(auth Component, mockComponent *mock.Component, ctx context.Context, mp map[string]string) {
 		_, err := auth.GetActions(ctx)
@@ -165,3 +214,42 @@ func TestGetStatisticsAuthenticatorsDeny(t *testing.T) {
 		assert.Equal(t, security.ForbiddenError{}, err)
 	})
 }
+
+func TestGetMigrationReportDeny(t *testing.T) {
+	testAuthorization(t, WithoutAuthorization, func(auth Component, mockComponent *mock.Component, ctx context.Context, mp map[string]string) {
+		_, err := auth.GetMigrationReport(ctx, mp[PrmRealm], api.MigrationReportFilter{})
+		assert.Equal(t, security.ForbiddenError{}, err)
+
+		_, err = auth.StartMigrationReport(ctx, mp[PrmRealm], api.MigrationReportFilter{})
+		assert.Equal(t, security.ForbiddenError{}, err)
+
+		_, err = auth.GetMigrationReportJob(ctx, mp[PrmRealm], "job-id")
+		assert.Equal(t, security.ForbiddenError{}, err)
+	})
+}
+
+func TestGetStatisticsEnrichedAuthenticationsDeny(t *testing.T) {
+	testAuthorization(t, WithoutAuthorization, func(auth Component, mockComponent *mock.Component, ctx context.Context, mp map[string]string) {
+		_, err := auth.GetStatisticsAuthenticationsByCountry(ctx, mp[PrmRealm], "days")
+		assert.Equal(t, security.ForbiddenError{}, err)
+
+		_, err = auth.GetStatisticsAuthenticationsByDevice(ctx, mp[PrmRealm], "days")
+		assert.Equal(t, security.ForbiddenError{}, err)
+
+		_, err = auth.GetStatisticsUserAuthentications(ctx, mp[PrmRealm], mp["userID"], "10")
+		assert.Equal(t, security.ForbiddenError{}, err)
+	})
+}
+
+func TestExportStatisticsDeny(t *testing.T) {
+	testAuthorization(t, WithoutAuthorization, func(auth Component, mockComponent *mock.Component, ctx context.Context, mp map[string]string) {
+		_, err := auth.StartStatisticsExport(ctx, mp[PrmRealm], "days", nil, ExportFormatCSV)
+		assert.Equal(t, security.ForbiddenError{}, err)
+
+		_, err = auth.GetStatisticsExportJob(ctx, mp[PrmRealm], "job-id")
+		assert.Equal(t, security.ForbiddenError{}, err)
+
+		_, err = auth.GetStatisticsExportFile(ctx, mp[PrmRealm], "job-id")
+		assert.Equal(t, security.ForbiddenError{}, err)
+	})
+}
@@ -1,8 +1,13 @@
 package statistics
 
 import (
+	"bytes"
 	"context"
+	"fmt"
 	"regexp"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	cs "github.com/cloudtrust/common-service"
@@ -22,7 +27,15 @@ type Component interface {
 	GetStatisticsAuthenticators(context.Context, string) (map[string]int64, error)
 	GetStatisticsAuthentications(context.Context, string, string, *string) ([][]int64, error)
 	GetStatisticsAuthenticationsLog(context.Context, string, string) ([]api.StatisticsConnectionRepresentation, error)
-	GetMigrationReport(context.Context, string) (map[string]bool, error)
+	GetStatisticsAuthenticationsByCountry(context.Context, string, string) (map[string]int64, error)
+	GetStatisticsAuthenticationsByDevice(context.Context, string, string) ([]api.StatisticsDeviceRepresentation, error)
+	GetStatisticsUserAuthentications(context.Context, string, string, string) ([]api.StatisticsUserConnectionRepresentation, error)
+	GetMigrationReport(context.Context, string, api.MigrationReportFilter) (api.MigrationReportRepresentation, error)
+	StartMigrationReport(context.Context, string, api.MigrationReportFilter) (api.MigrationReportJobRepresentation, error)
+	GetMigrationReportJob(context.Context, string, string) (api.MigrationReportJobRepresentation, error)
+	StartStatisticsExport(context.Context, string, string, *string, string) (api.StatisticsExportJobRepresentation, error)
+	GetStatisticsExportJob(context.Context, string, string) (api.StatisticsExportJobRepresentation, error)
+	GetStatisticsExportFile(context.Context, string, string) (ExportFile, error)
 }
 
 // KeycloakClient interface
@@ -32,21 +45,58 @@ type KeycloakClient interface {
 	GetStatisticsAuthenticators(accessToken string, realmName string) (map[string]int64, error)
 }
 
+// Number of users loaded from Keycloak at once when computing a migration report
+const migrationReportBatchSize = 500
+
 type component struct {
 	db             keycloakb.EventsDBModule
 	keycloakClient KeycloakClient
+	jobs           keycloakb.JobStore
+	tokenProvider  keycloakb.TokenProvider
+	technicalRealm string
 	logger         log.Logger
 }
 
-// NewComponent returns a component
-func NewComponent(db keycloakb.EventsDBModule, keycloakClient KeycloakClient, logger log.Logger) Component {
+// NewComponent returns a component. The background jobs call Keycloak with the token of the technical user, which is
+// given by tokenProvider and belongs to technicalRealm: the token of the caller may expire before the job ends.
+func NewComponent(db keycloakb.EventsDBModule, keycloakClient KeycloakClient, jobs keycloakb.JobStore, tokenProvider keycloakb.TokenProvider,
+	technicalRealm string, logger log.Logger) Component {
 	return &component{
 		db:             db,
 		keycloakClient: keycloakClient,
+		jobs:           jobs,
+		tokenProvider:  tokenProvider,
+		technicalRealm: technicalRealm,
 		logger:         logger,
 	}
 }
 
+// usersReader gives the realm and the token used to read the users from Keycloak. The token is provided before each
+// batch so that a technical token can be refreshed during a long walk.
+type usersReader struct {
+	reqRealm     string
+	provideToken func(context.Context) (string, error)
+}
+
+// callerReader reads the users with the token of the caller
+func callerReader(ctx context.Context) usersReader {
+	var accessToken = ctx.Value(cs.CtContextAccessToken).(string)
+	return usersReader{
+		reqRealm: ctx.Value(cs.CtContextRealm).(string),
+		provideToken: func(context.Context) (string, error) {
+			return accessToken, nil
+		},
+	}
+}
+
+// technicalReader reads the users with the token of the technical user
+func (ec *component) technicalReader() usersReader {
+	return usersReader{
+		reqRealm:     ec.technicalRealm,
+		provideToken: ec.tokenProvider.ProvideToken,
+	}
+}
+
 // Get actions
 func (ec *component) GetActions(ctx context.Context) ([]api.ActionRepresentation, error) {
 	var apiActions = []api.ActionRepresentation{}
@@ -172,28 +222,212 @@ func (ec *component) GetStatisticsAuthenticationsLog(ctx context.Context, realmN
 	return res, nil
 }
 
-// Compute Migration Report
-func (ec *component) GetMigrationReport(ctx context.Context, realmName string) (map[string]bool, error) {
-	var accessToken = ctx.Value(cs.CtContextAccessToken).(string)
-	var ctxRealm = ctx.Value(cs.CtContextRealm).(string)
+// GetStatisticsAuthenticationsByCountry gives the number of successful authentications per country over the last day,
+// month or year (unit hours, days or months)
+func (ec *component) GetStatisticsAuthenticationsByCountry(ctx context.Context, realmName string, unit string) (map[string]int64, error) {
+	var from, err = periodStart(unit, time.Now())
+	if err != nil {
+		ec.logger.Warn(ctx, "err", "Invalid parameter value")
+		return nil, err
+	}
 
-	var paramKV = []string{}
-	paramKV = append(paramKV, PrmQryMax, "0") //All
+	res, err := ec.db.GetConnectionsCountByCountry(ctx, realmName, from)
+	if err != nil {
+		ec.logger.Warn(ctx, "err", err.Error())
+		return nil, err
+	}
+	return res, nil
+}
 
-	usersKc, err := ec.keycloakClient.GetUsers(accessToken, ctxRealm, realmName, paramKV...)
+// GetStatisticsAuthenticationsByDevice gives the number of successful authentications per kind of device over the last day,
+// month or year (unit hours, days or months)
+func (ec *component) GetStatisticsAuthenticationsByDevice(ctx context.Context, realmName string, unit string) ([]api.StatisticsDeviceRepresentation, error) {
+	var from, err = periodStart(unit, time.Now())
+	if err != nil {
+		ec.logger.Warn(ctx, "err", "Invalid parameter value")
+		return nil, err
+	}
 
+	res, err := ec.db.GetConnectionsCountByDevice(ctx, realmName, from)
 	if err != nil {
 		ec.logger.Warn(ctx, "err", err.Error())
-		return map[string]bool{}, err
+		return nil, err
 	}
+	return res, nil
+}
+
+// GetStatisticsUserAuthentications gives the last successful authentications of a user with the new device/new country flags
+func (ec *component) GetStatisticsUserAuthentications(ctx context.Context, realmName string, userID string, max string) ([]api.StatisticsUserConnectionRepresentation, error) {
+	if ok, _ := regexp.MatchString(api.RegExpTwoDigitsNumber, max); !ok {
+		ec.logger.Warn(ctx, "err", "Invalid parameter max")
+		return nil, errorhandler.CreateBadRequestError(msg.MsgErrInvalidParam + "." + msg.Max)
+	}
+	var maxValue, _ = strconv.Atoi(max)
+
+	res, err := ec.db.GetUserConnections(ctx, realmName, userID, maxValue)
+	if err != nil {
+		ec.logger.Warn(ctx, "err", err.Error())
+		return nil, err
+	}
+	return res, nil
+}
+
+// periodStart gives the beginning of the period covered by the authentications graph of the given unit
+func periodStart(unit string, now time.Time) (time.Time, error) {
+	switch unit {
+	case "hours":
+		return now.Add(-24 * time.Hour), nil
+	case "days":
+		return now.AddDate(0, -1, 0), nil
+	case "months":
+		return now.AddDate(-1, 0, 0), nil
+	default:
+		return time.Time{}, errorhandler.CreateInvalidQueryParameterError(msg.Unit)
+	}
+}
+
+// GetMigrationReport computes the migration report of a realm: counts of migrated and not migrated users and a page of the users matching
+// the filter. All the users of the realm are read before the response: StartMigrationReport should be preferred for large realms.
+func (ec *component) GetMigrationReport(ctx context.Context, realmName string, filter api.MigrationReportFilter) (api.MigrationReportRepresentation, error) {
+	return ec.computeMigrationReport(ctx, realmName, filter, callerReader(ctx), nil)
+}
+
+// StartMigrationReport computes the migration report in background. Keycloak is called with the token of the technical user, the
+// authorizations of the caller being checked when the job is started. Result can be obtained using GetMigrationReportJob
+func (ec *component) StartMigrationReport(ctx context.Context, realmName string, filter api.MigrationReportFilter) (api.MigrationReportJobRepresentation, error) {
+	var jobID = ec.jobs.Start(ctx, realmName, func(ctx context.Context, progress func(int, int)) (interface{}, error) {
+		var report, err = ec.computeMigrationReport(ctx, realmName, filter, ec.technicalReader(), progress)
+		if err != nil {
+			return nil, err
+		}
+		return report, nil
+	})
+
+	return api.MigrationReportJobRepresentation{
+		ID:     jobID,
+		Status: keycloakb.JobStatusRunning,
+	}, nil
+}
+
+// GetMigrationReportJob gives the status of a migration report computed in background and the report itself once available
+func (ec *component) GetMigrationReportJob(ctx context.Context, realmName string, jobID string) (api.MigrationReportJobRepresentation, error) {
+	var job, ok = ec.jobs.Get(realmName, jobID)
+	if !ok {
+		ec.logger.Warn(ctx, "err", "Unknown migration report job", "job", jobID)
+		return api.MigrationReportJobRepresentation{}, errorhandler.CreateNotFoundError(msg.JobID)
+	}
+
+	var res = api.MigrationReportJobRepresentation{
+		ID:     job.ID,
+		Status: job.Status,
+	}
+	if job.Total > 0 {
+		var progress = job.Done * 100 / job.Total
+		res.Progress = &progress
+	}
+	if report, ok := job.Result.(api.MigrationReportRepresentation); ok {
+		res.Report = &report
+	}
+	if job.Err != nil {
+		var message = job.Err.Error()
+		res.Error = &message
+	}
+	return res, nil
+}
+
+func (ec *component) computeMigrationReport(ctx context.Context, realmName string, filter api.MigrationReportFilter, reader usersReader,
+	progress func(int, int)) (api.MigrationReportRepresentation, error) {
+	var res = api.MigrationReportRepresentation{
+		Users: []api.MigrationReportUserRepresentation{},
+	}
+
+	var err = ec.walkUsers(ctx, realmName, filter.GroupIDs, reader, progress, func(user kc.UserRepresentation) error {
+		if !isCreatedInPeriod(user, filter.CreatedAfter, filter.CreatedBefore) {
+			return nil
+		}
+
+		var migrated = isMigrated(user)
+		res.Total++
+		if migrated {
+			res.Migrated++
+		} else {
+			res.NotMigrated++
+		}
+
+		if filter.Migrated != nil && *filter.Migrated != migrated {
+			return nil
+		}
+		if res.Count >= filter.First && len(res.Users) < filter.Max {
+			res.Users = append(res.Users, api.MigrationReportUserRepresentation{
+				ID:               user.Id,
+				Username:         user.Username,
+				CreatedTimestamp: user.CreatedTimestamp,
+				Migrated:         migrated,
+			})
+		}
+		res.Count++
+		return nil
+	})
+	if err != nil {
+		return api.MigrationReportRepresentation{}, err
+	}
+
+	return res, nil
+}
+
+// walkUsers calls visit on each user of the realm, or of the given groups if any. Users are loaded by batches to avoid
+// keeping the whole realm in memory.
+func (ec *component) walkUsers(ctx context.Context, realmName string, groupIDs []string, reader usersReader, progress func(int, int),
+	visit func(kc.UserRepresentation) error) error {
+	for first := 0; ; first += migrationReportBatchSize {
+		var paramKV = []string{PrmQryFirst, strconv.Itoa(first), PrmQryMax, strconv.Itoa(migrationReportBatchSize)}
+		for _, groupID := range groupIDs {
+			paramKV = append(paramKV, "groupId", groupID)
+		}
+
+		var accessToken, err = reader.provideToken(ctx)
+		if err != nil {
+			ec.logger.Warn(ctx, "msg", "Can't get token", "err", err.Error())
+			return err
+		}
+
+		usersKc, err := ec.keycloakClient.GetUsers(accessToken, reader.reqRealm, realmName, paramKV...)
+		if err != nil {
+			ec.logger.Warn(ctx, "err", err.Error())
+			return err
+		}
 
-	var migratedUsers = map[string]bool{}
+		for _, user := range usersKc.Users {
+			if err = visit(user); err != nil {
+				return err
+			}
+		}
+
+		if progress != nil && usersKc.Count != nil {
+			progress(first+len(usersKc.Users), *usersKc.Count)
+		}
+		if len(usersKc.Users) < migrationReportBatchSize {
+			return nil
+		}
+	}
+}
 
-	for _, user := range usersKc.Users {
-		migratedUsers[*user.Username] = isMigrated(user)
+func isCreatedInPeriod(user kc.UserRepresentation, after *time.Time, before *time.Time) bool {
+	if after == nil && before == nil {
+		return true
+	}
+	if user.CreatedTimestamp == nil {
+		return false
 	}
 
-	return migratedUsers, nil
+	var created = time.Unix(0, *user.CreatedTimestamp*int64(time.Millisecond))
+	if after != nil && created.Before(*after) {
+		return false
+	}
+	if before != nil && !created.Before(*before) {
+		return false
+	}
+	return true
 }
 
 func isMigrated(user kc.UserRepresentation) bool {
@@ -209,3 +443,211 @@ func isMigrated(user kc.UserRepresentation) bool {
 
 	return false
 }
+
+// StartStatisticsExport produces in background a workbook (XLSX or ZIP archive of CSV files) containing all the statistics of a realm,
+// one sheet per section. The statistics are read before the job is started, the users of the migration sheet are then read from
+// Keycloak by the job with the token of the technical user. The workbook can be downloaded using GetStatisticsExportFile once the
+// job succeeded.
+func (ec *component) StartStatisticsExport(ctx context.Context, realmName string, unit string, timeshift *string, format string) (api.StatisticsExportJobRepresentation, error) {
+	var contentType string
+	switch format {
+	case ExportFormatCSV:
+		contentType = "application/zip"
+	case ExportFormatXLSX:
+		contentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	default:
+		ec.logger.Warn(ctx, "err", "Invalid parameter format")
+		return api.StatisticsExportJobRepresentation{}, errorhandler.CreateInvalidQueryParameterError(msg.Format)
+	}
+
+	connections, err := ec.GetStatistics(ctx, realmName)
+	if err != nil {
+		ec.logger.Warn(ctx, "err", err.Error())
+		return api.StatisticsExportJobRepresentation{}, err
+	}
+	authentications, err := ec.GetStatisticsAuthentications(ctx, realmName, unit, timeshift)
+	if err != nil {
+		return api.StatisticsExportJobRepresentation{}, err
+	}
+	authenticators, err := ec.GetStatisticsAuthenticators(ctx, realmName)
+	if err != nil {
+		return api.StatisticsExportJobRepresentation{}, err
+	}
+	users, err := ec.GetStatisticsUsers(ctx, realmName)
+	if err != nil {
+		return api.StatisticsExportJobRepresentation{}, err
+	}
+
+	var extension = format
+	if format == ExportFormatCSV {
+		extension = "zip"
+	}
+	var filename = fmt.Sprintf("statistics-%s-%s.%s", realmName, time.Now().UTC().Format("20060102"), extension)
+
+	var jobID = ec.jobs.Start(ctx, realmName, func(ctx context.Context, progress func(int, int)) (interface{}, error) {
+		// The users sheet comes after the migration sheet: the migration counts are computed while the latter is written
+		var migration api.MigrationReportRepresentation
+		var sheets = []sheet{
+			connectionsSheet(connections),
+			authenticationsSheet(unit, authentications),
+			authenticatorsSheet(authenticators),
+			ec.migrationSheet(ctx, realmName, ec.technicalReader(), progress, &migration),
+			usersSheet(users, &migration),
+		}
+
+		var content bytes.Buffer
+		if err := writeWorkbook(&content, format, sheets); err != nil {
+			ec.logger.Warn(ctx, "err", err.Error())
+			return nil, err
+		}
+		return ExportFile{
+			Filename:    filename,
+			ContentType: contentType,
+			Content:     content.Bytes(),
+		}, nil
+	})
+
+	return api.StatisticsExportJobRepresentation{
+		ID:     jobID,
+		Status: keycloakb.JobStatusRunning,
+	}, nil
+}
+
+// GetStatisticsExportJob gives the status of a statistics export produced in background
+func (ec *component) GetStatisticsExportJob(ctx context.Context, realmName string, jobID string) (api.StatisticsExportJobRepresentation, error) {
+	var job, ok = ec.jobs.Get(realmName, jobID)
+	if !ok {
+		ec.logger.Warn(ctx, "err", "Unknown statistics export job", "job", jobID)
+		return api.StatisticsExportJobRepresentation{}, errorhandler.CreateNotFoundError(msg.JobID)
+	}
+
+	var res = api.StatisticsExportJobRepresentation{
+		ID:     job.ID,
+		Status: job.Status,
+	}
+	if job.Total > 0 {
+		var progress = job.Done * 100 / job.Total
+		res.Progress = &progress
+	}
+	if job.Err != nil {
+		var message = job.Err.Error()
+		res.Error = &message
+	}
+	return res, nil
+}
+
+// GetStatisticsExportFile gives the workbook produced by a statistics export which succeeded
+func (ec *component) GetStatisticsExportFile(ctx context.Context, realmName string, jobID string) (ExportFile, error) {
+	var job, ok = ec.jobs.Get(realmName, jobID)
+	if !ok {
+		ec.logger.Warn(ctx, "err", "Unknown statistics export job", "job", jobID)
+		return ExportFile{}, errorhandler.CreateNotFoundError(msg.JobID)
+	}
+	var file, isFile = job.Result.(ExportFile)
+	if job.Status != keycloakb.JobStatusSucceeded || !isFile {
+		ec.logger.Warn(ctx, "err", "Statistics export is not available", "job", jobID, "status", job.Status)
+		return ExportFile{}, errorhandler.CreateNotFoundError(msg.JobID)
+	}
+	return file, nil
+}
+
+func formatInt(value int64) string {
+	return strconv.FormatInt(value, 10)
+}
+
+func connectionsSheet(stats api.StatisticsRepresentation) sheet {
+	return sheet{
+		name:    "connections",
+		columns: []column{{"metric", cellString}, {"value", cellNumber}},
+		rows: staticRows([][]string{
+			{"lastConnection", formatInt(stats.LastConnection)},
+			{"lastTwelveHours", formatInt(stats.TotalConnections.LastTwelveHours)},
+			{"lastDay", formatInt(stats.TotalConnections.LastDay)},
+			{"lastWeek", formatInt(stats.TotalConnections.LastWeek)},
+			{"lastMonth", formatInt(stats.TotalConnections.LastMonth)},
+			{"lastYear", formatInt(stats.TotalConnections.LastYear)},
+		}),
+	}
+}
+
+func authenticationsSheet(unit string, graph [][]int64) sheet {
+	var rows [][]string
+	for _, point := range graph {
+		if len(point) == 2 {
+			rows = append(rows, []string{formatInt(point[0]), formatInt(point[1])})
+		}
+	}
+	return sheet{
+		name:    "authentications",
+		columns: []column{{strings.TrimSuffix(unit, "s"), cellNumber}, {"connections", cellNumber}},
+		rows:    staticRows(rows),
+	}
+}
+
+func authenticatorsSheet(authenticators map[string]int64) sheet {
+	var names []string
+	for name := range authenticators {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var rows [][]string
+	for _, name := range names {
+		rows = append(rows, []string{name, formatInt(authenticators[name])})
+	}
+	return sheet{
+		name:    "authenticators",
+		columns: []column{{"authenticator", cellString}, {"users", cellNumber}},
+		rows:    staticRows(rows),
+	}
+}
+
+// usersSheet gives the user statistics. Migration counts are read when the sheet is written.
+func usersSheet(users api.StatisticsUsersRepresentation, migration *api.MigrationReportRepresentation) sheet {
+	return sheet{
+		name:    "users",
+		columns: []column{{"metric", cellString}, {"value", cellNumber}},
+		rows: func(emit func([]string) error) error {
+			return staticRows([][]string{
+				{"total", formatInt(users.Total)},
+				{"disabled", formatInt(users.Disabled)},
+				{"inactive", formatInt(users.Inactive)},
+				{"migrated", strconv.Itoa(migration.Migrated)},
+				{"notMigrated", strconv.Itoa(migration.NotMigrated)},
+			})(emit)
+		},
+	}
+}
+
+// migrationSheet gives the migration status of each user of the realm. Counts of the migration report are updated while
+// the sheet is written.
+func (ec *component) migrationSheet(ctx context.Context, realmName string, reader usersReader, progress func(int, int),
+	migration *api.MigrationReportRepresentation) sheet {
+	return sheet{
+		name:    "migration",
+		columns: []column{{"id", cellString}, {"username", cellString}, {"createdTimestamp", cellNumber}, {"migrated", cellBoolean}},
+		rows: func(emit func([]string) error) error {
+			return ec.walkUsers(ctx, realmName, nil, reader, progress, func(user kc.UserRepresentation) error {
+				var migrated = isMigrated(user)
+				migration.Total++
+				if migrated {
+					migration.Migrated++
+				} else {
+					migration.NotMigrated++
+				}
+
+				var row = []string{"", "", "", strconv.FormatBool(migrated)}
+				if user.Id != nil {
+					row[0] = *user.Id
+				}
+				if user.Username != nil {
+					row[1] = *user.Username
+				}
+				if user.CreatedTimestamp != nil {
+					row[2] = formatInt(*user.CreatedTimestamp)
+				}
+				return emit(row)
+			})
+		},
+	}
+}
@@ -3,11 +3,14 @@ package statistics
 import (
 	"context"
 	"errors"
+	"strconv"
 	"testing"
+	"time"
 
 	cs "github.com/cloudtrust/common-service"
 	"github.com/cloudtrust/common-service/log"
 	api "github.com/cloudtrust/keycloak-bridge/api/statistics"
+	"github.com/cloudtrust/keycloak-bridge/internal/keycloakb"
 	"github.com/cloudtrust/keycloak-bridge/pkg/statistics/mock"
 	kc "github.com/cloudtrust/keycloak-client"
 	"github.com/golang/mock/gomock"
@@ -20,7 +23,7 @@ func executeTest(t *testing.T, tester func(mockDBModule *mock.EventsDBModule, co
 	var mockDBModule = mock.NewEventsDBModule(mockCtrl)
 	var mockKcClient = mock.NewKcClient(mockCtrl)
 	var mockLogger = log.NewNopLogger()
-	tester(mockDBModule, NewComponent(mockDBModule, mockKcClient, mockLogger))
+	tester(mockDBModule, NewComponent(mockDBModule, mockKcClient, mock.NewJobStore(mockCtrl), nil, "", mockLogger))
 }
 
 func TestGetStatistics(t *testing.T) {
@@ -30,7 +33,7 @@ func TestGetStatistics(t *testing.T) {
 	var mockDBModule = mock.NewEventsDBModule(mockCtrl)
 	var mockKcClient = mock.NewKcClient(mockCtrl)
 	var mockLogger = log.NewNopLogger()
-	component := NewComponent(mockDBModule, mockKcClient, mockLogger)
+	component := NewComponent(mockDBModule, mockKcClient, mock.NewJobStore(mockCtrl), nil, "", mockLogger)
 
 	var errDbModule = errors.New("Dummy error in db module")
 	var realm = "the_realm_name"
@@ -73,7 +76,7 @@ func TestGetStatisticsUsers(t *testing.T) {
 	var mockDBModule = mock.NewEventsDBModule(mockCtrl)
 	var mockKcClient = mock.NewKcClient(mockCtrl)
 	var mockLogger = log.NewNopLogger()
-	component := NewComponent(mockDBModule, mockKcClient, mockLogger)
+	component := NewComponent(mockDBModule, mockKcClient, mock.NewJobStore(mockCtrl), nil, "", mockLogger)
 
 	var realm = "the_realm_name"
 	var accessToken = "TOKEN=="
@@ -113,7 +116,7 @@ func TestGetStatisticsAuthenticators(t *testing.T) {
 	var mockDBModule = mock.NewEventsDBModule(mockCtrl)
 	var mockKcClient = mock.NewKcClient(mockCtrl)
 	var mockLogger = log.NewNopLogger()
-	component := NewComponent(mockDBModule, mockKcClient, mockLogger)
+	component := NewComponent(mockDBModule, mockKcClient, mock.NewJobStore(mockCtrl), nil, "", mockLogger)
 
 	var realm = "the_realm_name"
 	var accessToken = "TOKEN=="
@@ -147,7 +150,7 @@ func TestGetStatisticsAuthentications(t *testing.T) {
 	var mockDBModule = mock.NewEventsDBModule(mockCtrl)
 	var mockKcClient = mock.NewKcClient(mockCtrl)
 	var mockLogger = log.NewNopLogger()
-	component := NewComponent(mockDBModule, mockKcClient, mockLogger)
+	component := NewComponent(mockDBModule, mockKcClient, mock.NewJobStore(mockCtrl), nil, "", mockLogger)
 
 	var timeshift = 0
 	var realm = "the_realm_name"
@@ -202,7 +205,7 @@ func TestGetStatisticsAuthenticationsLog(t *testing.T) {
 	var mockDBModule = mock.NewEventsDBModule(mockCtrl)
 	var mockKcClient = mock.NewKcClient(mockCtrl)
 	var mockLogger = log.NewNopLogger()
-	component := NewComponent(mockDBModule, mockKcClient, mockLogger)
+	component := NewComponent(mockDBModule, mockKcClient, mock.NewJobStore(mockCtrl), nil, "", mockLogger)
 
 	var realm = "the_realm_name"
 	var accessToken = "TOKEN=="
@@ -234,6 +237,84 @@ func TestGetStatisticsAuthenticationsLog(t *testing.T) {
 	})
 }
 
+func TestGetStatisticsEnrichedAuthentications(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var mockDBModule = mock.NewEventsDBModule(mockCtrl)
+	var mockKcClient = mock.NewKcClient(mockCtrl)
+	var mockLogger = log.NewNopLogger()
+	component := NewComponent(mockDBModule, mockKcClient, mock.NewJobStore(mockCtrl), nil, "", mockLogger)
+
+	var realm = "the_realm_name"
+	var userID = "the-user-id"
+	var ctx = context.WithValue(context.Background(), cs.CtContextRealm, realm)
+	var expectedErr = errors.New("error")
+
+	t.Run("By country", func(t *testing.T) {
+		var countries = map[string]int64{"CH": 12, "": 1}
+
+		var _, err = component.GetStatisticsAuthenticationsByCountry(ctx, realm, "weeks")
+		assert.NotNil(t, err)
+
+		mockDBModule.EXPECT().GetConnectionsCountByCountry(ctx, realm, gomock.Any()).Return(nil, expectedErr)
+		_, err = component.GetStatisticsAuthenticationsByCountry(ctx, realm, "days")
+		assert.Equal(t, expectedErr, err)
+
+		mockDBModule.EXPECT().GetConnectionsCountByCountry(ctx, realm, gomock.Any()).Return(countries, nil)
+		res, err := component.GetStatisticsAuthenticationsByCountry(ctx, realm, "months")
+		assert.Nil(t, err)
+		assert.Equal(t, countries, res)
+	})
+
+	t.Run("By device", func(t *testing.T) {
+		var devices = []api.StatisticsDeviceRepresentation{{DeviceClass: "mobile", OS: "iOS", Browser: "Safari", Count: 3}}
+
+		var _, err = component.GetStatisticsAuthenticationsByDevice(ctx, realm, "weeks")
+		assert.NotNil(t, err)
+
+		mockDBModule.EXPECT().GetConnectionsCountByDevice(ctx, realm, gomock.Any()).Return(nil, expectedErr)
+		_, err = component.GetStatisticsAuthenticationsByDevice(ctx, realm, "hours")
+		assert.Equal(t, expectedErr, err)
+
+		mockDBModule.EXPECT().GetConnectionsCountByDevice(ctx, realm, gomock.Any()).Return(devices, nil)
+		res, err := component.GetStatisticsAuthenticationsByDevice(ctx, realm, "days")
+		assert.Nil(t, err)
+		assert.Equal(t, devices, res)
+	})
+
+	t.Run("User authentications", func(t *testing.T) {
+		var connections = []api.StatisticsUserConnectionRepresentation{{Date: 1591005600, IP: "8.8.8.8", Country: "US", NewCountry: true}}
+
+		var _, err = component.GetStatisticsUserAuthentications(ctx, realm, userID, "101")
+		assert.NotNil(t, err)
+
+		mockDBModule.EXPECT().GetUserConnections(ctx, realm, userID, 10).Return(nil, expectedErr)
+		_, err = component.GetStatisticsUserAuthentications(ctx, realm, userID, "10")
+		assert.Equal(t, expectedErr, err)
+
+		mockDBModule.EXPECT().GetUserConnections(ctx, realm, userID, 10).Return(connections, nil)
+		res, err := component.GetStatisticsUserAuthentications(ctx, realm, userID, "10")
+		assert.Nil(t, err)
+		assert.Equal(t, connections, res)
+	})
+}
+
+func TestPeriodStart(t *testing.T) {
+	var now = time.Date(2020, time.March, 31, 10, 0, 0, 0, time.UTC)
+
+	var res, err = periodStart("hours", now)
+	assert.Nil(t, err)
+	assert.Equal(t, time.Date(2020, time.March, 30, 10, 0, 0, 0, time.UTC), res)
+
+	res, err = periodStart("months", now)
+	assert.Nil(t, err)
+	assert.Equal(t, time.Date(2019, time.March, 31, 10, 0, 0, 0, time.UTC), res)
+
+	_, err = periodStart("years", now)
+	assert.NotNil(t, err)
+}
+
 func TestGetActions(t *testing.T) {
 	var mockCtrl = gomock.NewController(t)
 	defer mockCtrl.Finish()
@@ -241,7 +322,7 @@ func TestGetActions(t *testing.T) {
 	var mockDBModule = mock.NewEventsDBModule(mockCtrl)
 	var mockKcClient = mock.NewKcClient(mockCtrl)
 	var mockLogger = log.NewNopLogger()
-	component := NewComponent(mockDBModule, mockKcClient, mockLogger)
+	component := NewComponent(mockDBModule, mockKcClient, mock.NewJobStore(mockCtrl), nil, "", mockLogger)
 
 	var realm = "the_realm_name"
 	var accessToken = "TOKEN=="
@@ -253,3 +334,359 @@ func TestGetActions(t *testing.T) {
 	assert.Nil(t, err)
 	assert.Equal(t, len(actions), len(res))
 }
+
+func createMigrationUser(id string, created int64, migrated bool) kc.UserRepresentation {
+	var username = "user-" + id
+	var attributes = kc.Attributes{}
+	if migrated {
+		attributes["migrated"] = []string{"true"}
+	}
+	return kc.UserRepresentation{
+		Id:               &id,
+		Username:         &username,
+		CreatedTimestamp: &created,
+		Attributes:       &attributes,
+	}
+}
+
+func TestGetMigrationReport(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var mockDBModule = mock.NewEventsDBModule(mockCtrl)
+	var mockKcClient = mock.NewKcClient(mockCtrl)
+	var mockLogger = log.NewNopLogger()
+	var ec = NewComponent(mockDBModule, mockKcClient, mock.NewJobStore(mockCtrl), nil, "", mockLogger).(*component)
+
+	var realm = "the_realm_name"
+	var accessToken = "TOKEN=="
+	var ctx = context.WithValue(context.Background(), cs.CtContextAccessToken, accessToken)
+	ctx = context.WithValue(ctx, cs.CtContextRealm, realm)
+
+	var day = int64(24 * 3600 * 1000)
+	var users = []kc.UserRepresentation{
+		createMigrationUser("1", 1*day, true),
+		createMigrationUser("2", 2*day, false),
+		createMigrationUser("3", 3*day, true),
+		createMigrationUser("4", 4*day, false),
+	}
+	var count = len(users)
+	var batchSize = strconv.Itoa(migrationReportBatchSize)
+
+	t.Run("GetUsers fails", func(t *testing.T) {
+		mockKcClient.EXPECT().GetUsers(accessToken, realm, realm, PrmQryFirst, "0", PrmQryMax, batchSize).Return(kc.UsersPageRepresentation{}, errors.New("error"))
+		_, err := ec.GetMigrationReport(ctx, realm, api.MigrationReportFilter{Max: 10})
+		assert.NotNil(t, err)
+	})
+
+	t.Run("Counts and first page", func(t *testing.T) {
+		mockKcClient.EXPECT().GetUsers(accessToken, realm, realm, PrmQryFirst, "0", PrmQryMax, batchSize).Return(kc.UsersPageRepresentation{Count: &count, Users: users}, nil)
+		res, err := ec.GetMigrationReport(ctx, realm, api.MigrationReportFilter{First: 1, Max: 2})
+		assert.Nil(t, err)
+		assert.Equal(t, 4, res.Total)
+		assert.Equal(t, 2, res.Migrated)
+		assert.Equal(t, 2, res.NotMigrated)
+		assert.Equal(t, 4, res.Count)
+		assert.Len(t, res.Users, 2)
+		assert.Equal(t, "2", *res.Users[0].ID)
+		assert.Equal(t, "3", *res.Users[1].ID)
+	})
+
+	t.Run("Filter by group, creation date and status", func(t *testing.T) {
+		var groupID = "group-id"
+		var after = time.Unix(0, 2*day*int64(time.Millisecond))
+		var before = time.Unix(0, 4*day*int64(time.Millisecond))
+		var notMigrated = false
+
+		mockKcClient.EXPECT().GetUsers(accessToken, realm, realm, PrmQryFirst, "0", PrmQryMax, batchSize, "groupId", groupID).Return(kc.UsersPageRepresentation{Count: &count, Users: users}, nil)
+		res, err := ec.GetMigrationReport(ctx, realm, api.MigrationReportFilter{
+			GroupIDs:      []string{groupID},
+			CreatedAfter:  &after,
+			CreatedBefore: &before,
+			Migrated:      &notMigrated,
+			Max:           10,
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, 2, res.Total)
+		assert.Equal(t, 1, res.Migrated)
+		assert.Equal(t, 1, res.NotMigrated)
+		assert.Equal(t, 1, res.Count)
+		assert.Len(t, res.Users, 1)
+		assert.Equal(t, "2", *res.Users[0].ID)
+	})
+
+	t.Run("Users are loaded by batches", func(t *testing.T) {
+		var manyUsers []kc.UserRepresentation
+		for i := 0; i < migrationReportBatchSize; i++ {
+			manyUsers = append(manyUsers, createMigrationUser(strconv.Itoa(i), day, i%2 == 0))
+		}
+		var total = migrationReportBatchSize + 1
+
+		gomock.InOrder(
+			mockKcClient.EXPECT().GetUsers(accessToken, realm, realm, PrmQryFirst, "0", PrmQryMax, batchSize).Return(kc.UsersPageRepresentation{Count: &total, Users: manyUsers}, nil),
+			mockKcClient.EXPECT().GetUsers(accessToken, realm, realm, PrmQryFirst, batchSize, PrmQryMax, batchSize).Return(kc.UsersPageRepresentation{Count: &total, Users: users[0:1]}, nil),
+		)
+		var progress [][]int
+		res, err := ec.computeMigrationReport(ctx, realm, api.MigrationReportFilter{Max: 0}, callerReader(ctx), func(done, total int) {
+			progress = append(progress, []int{done, total})
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, total, res.Total)
+		assert.Equal(t, migrationReportBatchSize/2+1, res.Migrated)
+		assert.Len(t, res.Users, 0)
+		assert.Equal(t, [][]int{{migrationReportBatchSize, total}, {total, total}}, progress)
+	})
+}
+
+func TestStartMigrationReport(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var mockDBModule = mock.NewEventsDBModule(mockCtrl)
+	var mockKcClient = mock.NewKcClient(mockCtrl)
+	var mockJobStore = mock.NewJobStore(mockCtrl)
+	var mockTokenProvider = mock.NewTokenProvider(mockCtrl)
+	var mockLogger = log.NewNopLogger()
+	var technicalRealm = "master"
+	component := NewComponent(mockDBModule, mockKcClient, mockJobStore, mockTokenProvider, technicalRealm, mockLogger)
+
+	var realm = "the_realm_name"
+	var accessToken = "TOKEN=="
+	var technicalToken = "TECHNICAL-TOKEN=="
+	var jobID = "job-id"
+	var ctx = context.WithValue(context.Background(), cs.CtContextAccessToken, accessToken)
+	ctx = context.WithValue(ctx, cs.CtContextRealm, realm)
+	var count = 0
+
+	t.Run("Technical token can't be obtained", func(t *testing.T) {
+		var expectedError = errors.New("kc error")
+		mockJobStore.EXPECT().Start(ctx, realm, gomock.Any()).DoAndReturn(func(ctx context.Context, realm string, fn keycloakb.JobFunc) string {
+			mockTokenProvider.EXPECT().ProvideToken(ctx).Return("", expectedError)
+			var _, err = fn(ctx, func(int, int) {})
+			assert.Equal(t, expectedError, err)
+			return jobID
+		})
+
+		var _, err = component.StartMigrationReport(ctx, realm, api.MigrationReportFilter{Max: 10})
+		assert.Nil(t, err)
+	})
+
+	t.Run("Users are read with the technical token", func(t *testing.T) {
+		mockJobStore.EXPECT().Start(ctx, realm, gomock.Any()).DoAndReturn(func(ctx context.Context, realm string, fn keycloakb.JobFunc) string {
+			mockTokenProvider.EXPECT().ProvideToken(ctx).Return(technicalToken, nil)
+			mockKcClient.EXPECT().GetUsers(technicalToken, technicalRealm, realm, gomock.Any()).Return(kc.UsersPageRepresentation{Count: &count}, nil)
+			var res, err = fn(ctx, func(int, int) {})
+			assert.Nil(t, err)
+			assert.IsType(t, api.MigrationReportRepresentation{}, res)
+			return jobID
+		})
+
+		res, err := component.StartMigrationReport(ctx, realm, api.MigrationReportFilter{Max: 10})
+		assert.Nil(t, err)
+		assert.Equal(t, jobID, res.ID)
+		assert.Equal(t, keycloakb.JobStatusRunning, res.Status)
+	})
+}
+
+func TestGetMigrationReportJob(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var mockDBModule = mock.NewEventsDBModule(mockCtrl)
+	var mockKcClient = mock.NewKcClient(mockCtrl)
+	var mockJobStore = mock.NewJobStore(mockCtrl)
+	var mockLogger = log.NewNopLogger()
+	component := NewComponent(mockDBModule, mockKcClient, mockJobStore, nil, "", mockLogger)
+
+	var realm = "the_realm_name"
+	var jobID = "job-id"
+	var ctx = context.TODO()
+
+	t.Run("Unknown job", func(t *testing.T) {
+		mockJobStore.EXPECT().Get(realm, jobID).Return(keycloakb.Job{}, false)
+		_, err := component.GetMigrationReportJob(ctx, realm, jobID)
+		assert.NotNil(t, err)
+	})
+
+	t.Run("Running job", func(t *testing.T) {
+		mockJobStore.EXPECT().Get(realm, jobID).Return(keycloakb.Job{ID: jobID, Status: keycloakb.JobStatusRunning, Done: 1, Total: 4}, true)
+		res, err := component.GetMigrationReportJob(ctx, realm, jobID)
+		assert.Nil(t, err)
+		assert.Equal(t, keycloakb.JobStatusRunning, res.Status)
+		assert.Equal(t, 25, *res.Progress)
+		assert.Nil(t, res.Report)
+	})
+
+	t.Run("Succeeded job", func(t *testing.T) {
+		var report = api.MigrationReportRepresentation{Total: 3}
+		mockJobStore.EXPECT().Get(realm, jobID).Return(keycloakb.Job{ID: jobID, Status: keycloakb.JobStatusSucceeded, Result: report}, true)
+		res, err := component.GetMigrationReportJob(ctx, realm, jobID)
+		assert.Nil(t, err)
+		assert.Equal(t, report, *res.Report)
+		assert.Nil(t, res.Error)
+	})
+
+	t.Run("Failed job", func(t *testing.T) {
+		mockJobStore.EXPECT().Get(realm, jobID).Return(keycloakb.Job{ID: jobID, Status: keycloakb.JobStatusFailed, Err: errors.New("error")}, true)
+		res, err := component.GetMigrationReportJob(ctx, realm, jobID)
+		assert.Nil(t, err)
+		assert.Equal(t, keycloakb.JobStatusFailed, res.Status)
+		assert.Equal(t, "error", *res.Error)
+	})
+}
+
+func TestStartStatisticsExport(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var mockDBModule = mock.NewEventsDBModule(mockCtrl)
+	var mockKcClient = mock.NewKcClient(mockCtrl)
+	var mockJobStore = mock.NewJobStore(mockCtrl)
+	var mockTokenProvider = mock.NewTokenProvider(mockCtrl)
+	var mockLogger = log.NewNopLogger()
+	var technicalRealm = "master"
+	component := NewComponent(mockDBModule, mockKcClient, mockJobStore, mockTokenProvider, technicalRealm, mockLogger)
+
+	var realm = "the_realm_name"
+	var accessToken = "TOKEN=="
+	var technicalToken = "TECHNICAL-TOKEN=="
+	var jobID = "job-id"
+	var ctx = context.WithValue(context.Background(), cs.CtContextAccessToken, accessToken)
+	ctx = context.WithValue(ctx, cs.CtContextRealm, realm)
+	var dbErr = errors.New("db error")
+
+	var expectStatistics = func() {
+		mockDBModule.EXPECT().GetLastConnection(gomock.Any(), realm).Return(int64(1234), nil)
+		mockDBModule.EXPECT().GetTotalConnectionsCount(gomock.Any(), realm, gomock.Any()).Return(int64(5), nil).Times(5)
+	}
+
+	t.Run("Invalid format", func(t *testing.T) {
+		_, err := component.StartStatisticsExport(ctx, realm, "days", nil, "pdf")
+		assert.NotNil(t, err)
+	})
+
+	t.Run("Statistics fail", func(t *testing.T) {
+		mockDBModule.EXPECT().GetLastConnection(gomock.Any(), realm).Return(int64(0), dbErr)
+		_, err := component.StartStatisticsExport(ctx, realm, "days", nil, ExportFormatCSV)
+		assert.Equal(t, dbErr, err)
+	})
+
+	t.Run("Authenticators fail", func(t *testing.T) {
+		expectStatistics()
+		mockDBModule.EXPECT().GetTotalConnectionsDaysCount(ctx, realm, gomock.Any(), 0).Return([][]int64{{1, 3}}, nil)
+		mockKcClient.EXPECT().GetStatisticsAuthenticators(accessToken, realm).Return(nil, dbErr)
+		_, err := component.StartStatisticsExport(ctx, realm, "days", nil, ExportFormatCSV)
+		assert.Equal(t, dbErr, err)
+	})
+
+	t.Run("Success", func(t *testing.T) {
+		var count = 1
+		expectStatistics()
+		mockDBModule.EXPECT().GetTotalConnectionsDaysCount(ctx, realm, gomock.Any(), 0).Return([][]int64{{1, 3}, {2, 4}}, nil)
+		mockKcClient.EXPECT().GetStatisticsAuthenticators(accessToken, realm).Return(map[string]int64{"password": 3, "ctpapercard": 1}, nil)
+		mockKcClient.EXPECT().GetStatisticsUsers(accessToken, realm).Return(kc.StatisticsUsersRepresentation{Total: 1}, nil)
+		mockJobStore.EXPECT().Start(ctx, realm, gomock.Any()).DoAndReturn(func(ctx context.Context, realm string, fn keycloakb.JobFunc) string {
+			mockTokenProvider.EXPECT().ProvideToken(ctx).Return(technicalToken, nil)
+			mockKcClient.EXPECT().GetUsers(technicalToken, technicalRealm, realm, gomock.Any()).Return(kc.UsersPageRepresentation{Count: &count,
+				Users: []kc.UserRepresentation{createMigrationUser("1", 1000, true)}}, nil)
+			var res, err = fn(ctx, func(int, int) {})
+			assert.Nil(t, err)
+
+			var file = res.(ExportFile)
+			assert.Equal(t, "application/zip", file.ContentType)
+			assert.Contains(t, file.Filename, "statistics-the_realm_name-")
+			var files = readArchive(t, file.Content)
+			assert.Equal(t, "day,connections\n1,3\n2,4\n", files["authentications.csv"])
+			assert.Equal(t, "authenticator,users\nctpapercard,1\npassword,3\n", files["authenticators.csv"])
+			assert.Contains(t, files["users.csv"], "migrated,1\n")
+			assert.Equal(t, "id,username,createdTimestamp,migrated\n1,user-1,1000,true\n", files["migration.csv"])
+			assert.Contains(t, files["connections.csv"], "lastConnection,1234\n")
+			return jobID
+		})
+
+		res, err := component.StartStatisticsExport(ctx, realm, "days", nil, ExportFormatCSV)
+		assert.Nil(t, err)
+		assert.Equal(t, jobID, res.ID)
+		assert.Equal(t, keycloakb.JobStatusRunning, res.Status)
+	})
+
+	t.Run("Users can't be read by the job", func(t *testing.T) {
+		expectStatistics()
+		mockDBModule.EXPECT().GetTotalConnectionsDaysCount(ctx, realm, gomock.Any(), 0).Return([][]int64{}, nil)
+		mockKcClient.EXPECT().GetStatisticsAuthenticators(accessToken, realm).Return(map[string]int64{}, nil)
+		mockKcClient.EXPECT().GetStatisticsUsers(accessToken, realm).Return(kc.StatisticsUsersRepresentation{}, nil)
+		mockJobStore.EXPECT().Start(ctx, realm, gomock.Any()).DoAndReturn(func(ctx context.Context, realm string, fn keycloakb.JobFunc) string {
+			mockTokenProvider.EXPECT().ProvideToken(ctx).Return(technicalToken, nil)
+			mockKcClient.EXPECT().GetUsers(technicalToken, technicalRealm, realm, gomock.Any()).Return(kc.UsersPageRepresentation{}, dbErr)
+			var _, err = fn(ctx, func(int, int) {})
+			assert.Equal(t, dbErr, err)
+			return jobID
+		})
+
+		_, err := component.StartStatisticsExport(ctx, realm, "days", nil, ExportFormatXLSX)
+		assert.Nil(t, err)
+	})
+}
+
+func TestGetStatisticsExportJob(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var mockJobStore = mock.NewJobStore(mockCtrl)
+	component := NewComponent(mock.NewEventsDBModule(mockCtrl), mock.NewKcClient(mockCtrl), mockJobStore, nil, "", log.NewNopLogger())
+
+	var realm = "the_realm_name"
+	var jobID = "job-id"
+	var ctx = context.Background()
+	var file = ExportFile{Filename: "statistics.zip", ContentType: "application/zip", Content: []byte("content")}
+
+	t.Run("Unknown job", func(t *testing.T) {
+		mockJobStore.EXPECT().Get(realm, jobID).Return(keycloakb.Job{}, false)
+		var _, err = component.GetStatisticsExportJob(ctx, realm, jobID)
+		assert.NotNil(t, err)
+
+		mockJobStore.EXPECT().Get(realm, jobID).Return(keycloakb.Job{}, false)
+		_, err = component.GetStatisticsExportFile(ctx, realm, jobID)
+		assert.NotNil(t, err)
+	})
+
+	t.Run("Running job", func(t *testing.T) {
+		var job = keycloakb.Job{ID: jobID, Status: keycloakb.JobStatusRunning, Done: 1, Total: 4}
+		mockJobStore.EXPECT().Get(realm, jobID).Return(job, true)
+		var res, err = component.GetStatisticsExportJob(ctx, realm, jobID)
+		assert.Nil(t, err)
+		assert.Equal(t, 25, *res.Progress)
+
+		mockJobStore.EXPECT().Get(realm, jobID).Return(job, true)
+		_, err = component.GetStatisticsExportFile(ctx, realm, jobID)
+		assert.NotNil(t, err)
+	})
+
+	t.Run("Failed job", func(t *testing.T) {
+		var job = keycloakb.Job{ID: jobID, Status: keycloakb.JobStatusFailed, Err: errors.New("error")}
+		mockJobStore.EXPECT().Get(realm, jobID).Return(job, true)
+		var res, err = component.GetStatisticsExportJob(ctx, realm, jobID)
+		assert.Nil(t, err)
+		assert.Equal(t, "error", *res.Error)
+	})
+
+	t.Run("Job of a migration report", func(t *testing.T) {
+		var job = keycloakb.Job{ID: jobID, Status: keycloakb.JobStatusSucceeded, Result: api.MigrationReportRepresentation{}}
+		mockJobStore.EXPECT().Get(realm, jobID).Return(job, true)
+		var _, err = component.GetStatisticsExportFile(ctx, realm, jobID)
+		assert.NotNil(t, err)
+	})
+
+	t.Run("Succeeded job", func(t *testing.T) {
+		var job = keycloakb.Job{ID: jobID, Status: keycloakb.JobStatusSucceeded, Result: file}
+		mockJobStore.EXPECT().Get(realm, jobID).Return(job, true)
+		var res, err = component.GetStatisticsExportJob(ctx, realm, jobID)
+		assert.Nil(t, err)
+		assert.Equal(t, keycloakb.JobStatusSucceeded, res.Status)
+
+		mockJobStore.EXPECT().Get(realm, jobID).Return(job, true)
+		resFile, err := component.GetStatisticsExportFile(ctx, realm, jobID)
+		assert.Nil(t, err)
+		assert.Equal(t, file, resFile)
+	})
+}
@@ -2,24 +2,43 @@ package statistics
 
 import (
 	"context"
+	"strconv"
+	"strings"
+	"time"
 
 	cs "github.com/cloudtrust/common-service"
 	errorhandler "github.com/cloudtrust/common-service/errors"
+	api "github.com/cloudtrust/keycloak-bridge/api/statistics"
 	msg "github.com/cloudtrust/keycloak-bridge/internal/constants"
 	"github.com/go-kit/kit/endpoint"
 )
 
 // Endpoints exposed for path /events
 type Endpoints struct {
-	GetActions                      endpoint.Endpoint
-	GetStatistics                   endpoint.Endpoint
-	GetStatisticsUsers              endpoint.Endpoint
-	GetStatisticsAuthenticators     endpoint.Endpoint
-	GetStatisticsAuthentications    endpoint.Endpoint
-	GetStatisticsAuthenticationsLog endpoint.Endpoint
-	GetMigrationReport              endpoint.Endpoint
+	GetActions                            endpoint.Endpoint
+	GetStatistics                         endpoint.Endpoint
+	GetStatisticsUsers                    endpoint.Endpoint
+	GetStatisticsAuthenticators           endpoint.Endpoint
+	GetStatisticsAuthentications          endpoint.Endpoint
+	GetStatisticsAuthenticationsLog       endpoint.Endpoint
+	GetStatisticsAuthenticationsByCountry endpoint.Endpoint
+	GetStatisticsAuthenticationsByDevice  endpoint.Endpoint
+	GetStatisticsUserAuthentications      endpoint.Endpoint
+	GetMigrationReport                    endpoint.Endpoint
+	StartMigrationReport                  endpoint.Endpoint
+	GetMigrationReportJob                 endpoint.Endpoint
+	StartStatisticsExport                 endpoint.Endpoint
+	GetStatisticsExportJob                endpoint.Endpoint
+	GetStatisticsExportFile               endpoint.Endpoint
 }
 
+const (
+	// Number of users returned by default in a migration report
+	defaultMigrationReportMax = 100
+	// Maximum number of users returned in a migration report, all of them are kept in memory
+	maxMigrationReportMax = 1000
+)
+
 // MakeGetActionsEndpoint creates an endpoint for GetActions
 func MakeGetActionsEndpoint(ec Component) cs.Endpoint {
 	return func(ctx context.Context, _ interface{}) (interface{}, error) {
@@ -78,10 +97,147 @@ func MakeGetStatisticsAuthenticationsLogEndpoint(ec Component) cs.Endpoint {
 	}
 }
 
+// MakeGetStatisticsAuthenticationsByCountryEndpoint makes the endpoint giving the authentications per country.
+func MakeGetStatisticsAuthenticationsByCountryEndpoint(ec Component) cs.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		var m = req.(map[string]string)
+		if _, ok := m[PrmQryUnit]; !ok {
+			return nil, errorhandler.CreateMissingParameterError(msg.Unit)
+		}
+		return ec.GetStatisticsAuthenticationsByCountry(ctx, m[PrmRealm], m[PrmQryUnit])
+	}
+}
+
+// MakeGetStatisticsAuthenticationsByDeviceEndpoint makes the endpoint giving the authentications per kind of device.
+func MakeGetStatisticsAuthenticationsByDeviceEndpoint(ec Component) cs.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		var m = req.(map[string]string)
+		if _, ok := m[PrmQryUnit]; !ok {
+			return nil, errorhandler.CreateMissingParameterError(msg.Unit)
+		}
+		return ec.GetStatisticsAuthenticationsByDevice(ctx, m[PrmRealm], m[PrmQryUnit])
+	}
+}
+
+// MakeGetStatisticsUserAuthenticationsEndpoint makes the endpoint giving the last authentications of a user.
+func MakeGetStatisticsUserAuthenticationsEndpoint(ec Component) cs.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		var m = req.(map[string]string)
+		if _, ok := m[PrmQryMax]; !ok {
+			return nil, errorhandler.CreateMissingParameterError(msg.Max)
+		}
+		return ec.GetStatisticsUserAuthentications(ctx, m[PrmRealm], m[PrmUserID], m[PrmQryMax])
+	}
+}
+
 // MakeGetMigrationReportEndpoint makes the migration reporting endpoint.
 func MakeGetMigrationReportEndpoint(ec Component) cs.Endpoint {
 	return func(ctx context.Context, req interface{}) (interface{}, error) {
 		var m = req.(map[string]string)
-		return ec.GetMigrationReport(ctx, m[PrmRealm])
+		var filter, err = toMigrationReportFilter(m)
+		if err != nil {
+			return nil, err
+		}
+		return ec.GetMigrationReport(ctx, m[PrmRealm], filter)
+	}
+}
+
+// MakeStartMigrationReportEndpoint makes the endpoint which launches the computation of a migration report in background.
+func MakeStartMigrationReportEndpoint(ec Component) cs.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		var m = req.(map[string]string)
+		var filter, err = toMigrationReportFilter(m)
+		if err != nil {
+			return nil, err
+		}
+		return ec.StartMigrationReport(ctx, m[PrmRealm], filter)
+	}
+}
+
+// MakeGetMigrationReportJobEndpoint makes the endpoint used to poll a migration report computed in background.
+func MakeGetMigrationReportJobEndpoint(ec Component) cs.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		var m = req.(map[string]string)
+		return ec.GetMigrationReportJob(ctx, m[PrmRealm], m[PrmJobID])
+	}
+}
+
+// MakeStartStatisticsExportEndpoint makes the endpoint which launches the export of all the statistics of a realm as a workbook.
+func MakeStartStatisticsExportEndpoint(ec Component) cs.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		var m = req.(map[string]string)
+		if _, ok := m[PrmQryUnit]; !ok {
+			return nil, errorhandler.CreateMissingParameterError(msg.Unit)
+		}
+		var timeshift *string
+		if timeshiftStr, ok := m[PrmQryTimeshift]; ok {
+			timeshift = &timeshiftStr
+		}
+		var format = ExportFormatCSV
+		if value, ok := m[PrmQryFormat]; ok {
+			format = value
+		}
+		return ec.StartStatisticsExport(ctx, m[PrmRealm], m[PrmQryUnit], timeshift, format)
+	}
+}
+
+// MakeGetStatisticsExportJobEndpoint makes the endpoint used to poll a statistics export produced in background.
+func MakeGetStatisticsExportJobEndpoint(ec Component) cs.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		var m = req.(map[string]string)
+		return ec.GetStatisticsExportJob(ctx, m[PrmRealm], m[PrmJobID])
+	}
+}
+
+// MakeGetStatisticsExportFileEndpoint makes the endpoint used to download the workbook of a statistics export.
+func MakeGetStatisticsExportFileEndpoint(ec Component) cs.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		var m = req.(map[string]string)
+		return ec.GetStatisticsExportFile(ctx, m[PrmRealm], m[PrmJobID])
+	}
+}
+
+func toMigrationReportFilter(m map[string]string) (api.MigrationReportFilter, error) {
+	var filter = api.MigrationReportFilter{
+		Max: defaultMigrationReportMax,
+	}
+	var err error
+
+	if value, ok := m[PrmQryGroupIDs]; ok {
+		filter.GroupIDs = strings.Split(value, ",")
+	}
+	if value, ok := m[PrmQryFirst]; ok {
+		if filter.First, err = strconv.Atoi(value); err != nil {
+			return filter, errorhandler.CreateInvalidQueryParameterError(msg.First)
+		}
+	}
+	if value, ok := m[PrmQryMax]; ok {
+		if filter.Max, err = strconv.Atoi(value); err != nil || filter.Max < 0 || filter.Max > maxMigrationReportMax {
+			return filter, errorhandler.CreateInvalidQueryParameterError(msg.Max)
+		}
+	}
+	if value, ok := m[PrmQryMigrated]; ok {
+		var migrated = value == "true"
+		filter.Migrated = &migrated
+	}
+	if filter.CreatedAfter, err = parseDate(m, PrmQryCreatedAfter, msg.CreatedAfter); err != nil {
+		return filter, err
+	}
+	if filter.CreatedBefore, err = parseDate(m, PrmQryCreatedBefore, msg.CreatedBefore); err != nil {
+		return filter, err
+	}
+	return filter, nil
+}
+
+func parseDate(m map[string]string, key string, paramName string) (*time.Time, error) {
+	var value, ok = m[key]
+	if !ok {
+		return nil, nil
+	}
+	for _, layout := range msg.SupportedDateLayouts {
+		if date, err := time.Parse(layout, value); err == nil {
+			return &date, nil
+		}
 	}
+	return nil, errorhandler.CreateInvalidQueryParameterError(paramName)
 }
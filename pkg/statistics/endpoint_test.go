@@ -3,6 +3,7 @@ package statistics
 import (
 	"context"
 	"testing"
+	"time"
 
 	api "github.com/cloudtrust/keycloak-bridge/api/statistics"
 	"github.com/cloudtrust/keycloak-bridge/pkg/statistics/mock"
@@ -117,3 +118,203 @@ func TestMakeGetStatisticsAuthenticationsLogEndpoint(t *testing.T) {
 	assert.Nil(t, err)
 	assert.NotNil(t, res)
 }
+
+func TestMakeGetStatisticsEnrichedAuthenticationsEndpoints(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var mockComponent = mock.NewComponent(mockCtrl)
+	var ctx = context.Background()
+
+	t.Run("By country", func(t *testing.T) {
+		var e = MakeGetStatisticsAuthenticationsByCountryEndpoint(mockComponent)
+		var _, err = e(ctx, map[string]string{PrmRealm: "realm"})
+		assert.NotNil(t, err)
+
+		mockComponent.EXPECT().GetStatisticsAuthenticationsByCountry(ctx, "realm", "days").Return(map[string]int64{}, nil)
+		res, err := e(ctx, map[string]string{PrmRealm: "realm", PrmQryUnit: "days"})
+		assert.Nil(t, err)
+		assert.NotNil(t, res)
+	})
+	t.Run("By device", func(t *testing.T) {
+		var e = MakeGetStatisticsAuthenticationsByDeviceEndpoint(mockComponent)
+		var _, err = e(ctx, map[string]string{PrmRealm: "realm"})
+		assert.NotNil(t, err)
+
+		mockComponent.EXPECT().GetStatisticsAuthenticationsByDevice(ctx, "realm", "hours").Return([]api.StatisticsDeviceRepresentation{}, nil)
+		res, err := e(ctx, map[string]string{PrmRealm: "realm", PrmQryUnit: "hours"})
+		assert.Nil(t, err)
+		assert.NotNil(t, res)
+	})
+	t.Run("User authentications", func(t *testing.T) {
+		var e = MakeGetStatisticsUserAuthenticationsEndpoint(mockComponent)
+		var _, err = e(ctx, map[string]string{PrmRealm: "realm", PrmUserID: "user-id"})
+		assert.NotNil(t, err)
+
+		mockComponent.EXPECT().GetStatisticsUserAuthentications(ctx, "realm", "user-id", "5").Return([]api.StatisticsUserConnectionRepresentation{}, nil)
+		res, err := e(ctx, map[string]string{PrmRealm: "realm", PrmUserID: "user-id", PrmQryMax: "5"})
+		assert.Nil(t, err)
+		assert.NotNil(t, res)
+	})
+}
+
+func TestMakeGetMigrationReportEndpoint(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var mockComponent = mock.NewComponent(mockCtrl)
+
+	var e = MakeGetMigrationReportEndpoint(mockComponent)
+
+	var ctx = context.Background()
+
+	t.Run("Default filter", func(t *testing.T) {
+		var req = map[string]string{PrmRealm: "realm"}
+		var filter = api.MigrationReportFilter{Max: defaultMigrationReportMax}
+
+		mockComponent.EXPECT().GetMigrationReport(ctx, "realm", filter).Return(api.MigrationReportRepresentation{}, nil).Times(1)
+		var res, err = e(ctx, req)
+		assert.Nil(t, err)
+		assert.NotNil(t, res)
+	})
+
+	t.Run("Max too large", func(t *testing.T) {
+		var req = map[string]string{PrmRealm: "realm", PrmQryMax: "1001"}
+		var _, err = e(ctx, req)
+		assert.NotNil(t, err)
+	})
+
+	t.Run("Negative max", func(t *testing.T) {
+		var req = map[string]string{PrmRealm: "realm", PrmQryMax: "-1"}
+		var _, err = e(ctx, req)
+		assert.NotNil(t, err)
+	})
+}
+
+func TestMakeStartMigrationReportEndpoint(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var mockComponent = mock.NewComponent(mockCtrl)
+
+	var e = MakeStartMigrationReportEndpoint(mockComponent)
+
+	var ctx = context.Background()
+
+	t.Run("Default filter", func(t *testing.T) {
+		var req = map[string]string{PrmRealm: "realm"}
+		var filter = api.MigrationReportFilter{Max: defaultMigrationReportMax}
+
+		mockComponent.EXPECT().StartMigrationReport(ctx, "realm", filter).Return(api.MigrationReportJobRepresentation{}, nil).Times(1)
+		var res, err = e(ctx, req)
+		assert.Nil(t, err)
+		assert.NotNil(t, res)
+	})
+
+	t.Run("All filters", func(t *testing.T) {
+		var req = map[string]string{
+			PrmRealm:            "realm",
+			PrmQryFirst:         "20",
+			PrmQryMax:           "10",
+			PrmQryGroupIDs:      "group1,group2",
+			PrmQryMigrated:      "false",
+			PrmQryCreatedAfter:  "01.02.2020",
+			PrmQryCreatedBefore: "2020-03-01",
+		}
+		var migrated = false
+		var after = time.Date(2020, time.February, 1, 0, 0, 0, 0, time.UTC)
+		var before = time.Date(2020, time.March, 1, 0, 0, 0, 0, time.UTC)
+		var filter = api.MigrationReportFilter{
+			GroupIDs:      []string{"group1", "group2"},
+			CreatedAfter:  &after,
+			CreatedBefore: &before,
+			Migrated:      &migrated,
+			First:         20,
+			Max:           10,
+		}
+
+		mockComponent.EXPECT().StartMigrationReport(ctx, "realm", filter).Return(api.MigrationReportJobRepresentation{}, nil).Times(1)
+		var _, err = e(ctx, req)
+		assert.Nil(t, err)
+	})
+
+	t.Run("Paging disabled", func(t *testing.T) {
+		var req = map[string]string{PrmRealm: "realm", PrmQryMax: "0"}
+
+		mockComponent.EXPECT().StartMigrationReport(ctx, "realm", api.MigrationReportFilter{}).Return(api.MigrationReportJobRepresentation{}, nil).Times(1)
+		var _, err = e(ctx, req)
+		assert.Nil(t, err)
+	})
+
+	t.Run("Invalid date", func(t *testing.T) {
+		var req = map[string]string{PrmRealm: "realm", PrmQryCreatedAfter: "2020-13-45"}
+		var _, err = e(ctx, req)
+		assert.NotNil(t, err)
+	})
+}
+
+func TestMakeGetMigrationReportJobEndpoint(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var mockComponent = mock.NewComponent(mockCtrl)
+
+	var e = MakeGetMigrationReportJobEndpoint(mockComponent)
+
+	var ctx = context.Background()
+	var req = map[string]string{PrmRealm: "realm", PrmJobID: "job-id"}
+
+	mockComponent.EXPECT().GetMigrationReportJob(ctx, "realm", "job-id").Return(api.MigrationReportJobRepresentation{}, nil).Times(1)
+	var res, err = e(ctx, req)
+	assert.Nil(t, err)
+	assert.NotNil(t, res)
+}
+
+func TestMakeStartStatisticsExportEndpoint(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var mockComponent = mock.NewComponent(mockCtrl)
+
+	var e = MakeStartStatisticsExportEndpoint(mockComponent)
+
+	var ctx = context.Background()
+
+	t.Run("Missing unit", func(t *testing.T) {
+		var _, err = e(ctx, map[string]string{PrmRealm: "realm"})
+		assert.NotNil(t, err)
+	})
+
+	t.Run("Default format", func(t *testing.T) {
+		var req = map[string]string{PrmRealm: "realm", PrmQryUnit: "days"}
+		mockComponent.EXPECT().StartStatisticsExport(ctx, "realm", "days", nil, ExportFormatCSV).Return(api.StatisticsExportJobRepresentation{}, nil).Times(1)
+		var _, err = e(ctx, req)
+		assert.Nil(t, err)
+	})
+
+	t.Run("XLSX with timeshift", func(t *testing.T) {
+		var timeshift = "+60"
+		var req = map[string]string{PrmRealm: "realm", PrmQryUnit: "months", PrmQryTimeshift: timeshift, PrmQryFormat: ExportFormatXLSX}
+		mockComponent.EXPECT().StartStatisticsExport(ctx, "realm", "months", &timeshift, ExportFormatXLSX).Return(api.StatisticsExportJobRepresentation{}, nil).Times(1)
+		var _, err = e(ctx, req)
+		assert.Nil(t, err)
+	})
+}
+
+func TestMakeGetStatisticsExportJobEndpoint(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var mockComponent = mock.NewComponent(mockCtrl)
+
+	var ctx = context.Background()
+	var req = map[string]string{PrmRealm: "realm", PrmJobID: "job-id"}
+
+	mockComponent.EXPECT().GetStatisticsExportJob(ctx, "realm", "job-id").Return(api.StatisticsExportJobRepresentation{}, nil).Times(1)
+	var _, err = MakeGetStatisticsExportJobEndpoint(mockComponent)(ctx, req)
+	assert.Nil(t, err)
+
+	mockComponent.EXPECT().GetStatisticsExportFile(ctx, "realm", "job-id").Return(ExportFile{}, nil).Times(1)
+	_, err = MakeGetStatisticsExportFileEndpoint(mockComponent)(ctx, req)
+	assert.Nil(t, err)
+}
@@ -2,6 +2,7 @@ package statistics
 
 import (
 	"context"
+	"fmt"
 	"net/http"
 
 	commonhttp "github.com/cloudtrust/common-service/http"
@@ -13,18 +14,26 @@ import (
 
 // Parameter names
 const (
-	PrmRealm = "realm"
+	PrmRealm  = "realm"
+	PrmJobID  = "jobID"
+	PrmUserID = "userID"
 
-	PrmQryUnit = "unit"
-	PrmQryMax = "max"
-	PrmQryTimeshift = "timeshift"
+	PrmQryUnit          = "unit"
+	PrmQryMax           = "max"
+	PrmQryTimeshift     = "timeshift"
+	PrmQryFirst         = "first"
+	PrmQryGroupIDs      = "groupIds"
+	PrmQryCreatedAfter  = "createdAfter"
+	PrmQryCreatedBefore = "createdBefore"
+	PrmQryMigrated      = "migrated"
+	PrmQryFormat        = "format"
 )
 
 // MakeStatisticsHandler make an HTTP handler for a Statistics endpoint.
 func MakeStatisticsHandler(e endpoint.Endpoint, logger log.Logger) *http_transport.Server {
 	return http_transport.NewServer(e,
 		decodeEventsRequest,
-		commonhttp.EncodeReply,
+		encodeStatisticsReply,
 		http_transport.ServerErrorEncoder(commonhttp.ErrorHandler(logger)),
 	)
 }
@@ -32,14 +41,36 @@ func MakeStatisticsHandler(e endpoint.Endpoint, logger log.Logger) *http_transpo
 // decodeEventsRequest gets the HTTP parameters and body content
 func decodeEventsRequest(ctx context.Context, req *http.Request) (interface{}, error) {
 	var pathParams = map[string]string{
-		PrmRealm: "^[a-zA-Z0-9_-]{1,36}$",
+		PrmRealm:  "^[a-zA-Z0-9_-]{1,36}$",
+		PrmJobID:  stat_api.RegExpJobID,
+		PrmUserID: stat_api.RegExpID,
 	}
 
 	var queryParams = map[string]string{
-		PrmQryUnit:      stat_api.RegExpPeriod,
-		PrmQryMax:       stat_api.RegExpNumber,
-		PrmQryTimeshift: stat_api.RegExpTimeshift,
+		PrmQryUnit:          stat_api.RegExpPeriod,
+		PrmQryMax:           stat_api.RegExpNumber,
+		PrmQryTimeshift:     stat_api.RegExpTimeshift,
+		PrmQryFirst:         stat_api.RegExpNumber,
+		PrmQryGroupIDs:      stat_api.RegExpGroupIds,
+		PrmQryCreatedAfter:  stat_api.RegExpDate,
+		PrmQryCreatedBefore: stat_api.RegExpDate,
+		PrmQryMigrated:      stat_api.RegExpBoolean,
+		PrmQryFormat:        stat_api.RegExpExportFormat,
 	}
 
 	return commonhttp.DecodeRequest(ctx, req, pathParams, queryParams)
 }
+
+// encodeStatisticsReply encodes the reply. Exported files are sent as attachments
+func encodeStatisticsReply(ctx context.Context, w http.ResponseWriter, rep interface{}) error {
+	switch r := rep.(type) {
+	case ExportFile:
+		w.Header().Set("Content-Type", r.ContentType)
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", r.Filename))
+		w.WriteHeader(http.StatusOK)
+		_, err := w.Write(r.Content)
+		return err
+	default:
+		return commonhttp.EncodeReply(ctx, w, rep)
+	}
+}
@@ -50,3 +50,31 @@ func TestHTTPStatisticsHandler(t *testing.T) {
 		assert.Equal(t, string(statsJSON), buf.String())
 	}
 }
+
+func TestHTTPStatisticsExport(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+	var mockComponent = mock.NewComponent(mockCtrl)
+
+	var exportHandler = MakeStatisticsHandler(keycloakb.ToGoKitEndpoint(MakeGetStatisticsExportFileEndpoint(mockComponent)), log.NewNopLogger())
+
+	r := mux.NewRouter()
+	r.Handle("/statistics/realms/{realm}/export/jobs/{jobID}/file", exportHandler)
+
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	var file = ExportFile{Filename: "stats.xlsx", ContentType: "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", Content: []byte("content")}
+	mockComponent.EXPECT().GetStatisticsExportFile(gomock.Any(), "master", "job-id").Return(file, nil).Times(1)
+
+	res, err := http.Get(ts.URL + "/statistics/realms/master/export/jobs/job-id/file")
+
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, file.ContentType, res.Header.Get("Content-Type"))
+	assert.Equal(t, `attachment; filename="stats.xlsx"`, res.Header.Get("Content-Disposition"))
+
+	buf := new(bytes.Buffer)
+	buf.ReadFrom(res.Body)
+	assert.Equal(t, "content", buf.String())
+}
@@ -6,3 +6,9 @@ package statistics
 //go:generate mockgen -destination=./mock/keycloak_client.go -package=mock -mock_names=KeycloakClient=KeycloakClient github.com/cloudtrust/common-service/security KeycloakClient
 //go:generate mockgen -destination=./mock/dbmodule.go -package=mock -mock_names=EventsDBModule=EventsDBModule github.com/cloudtrust/keycloak-bridge/internal/keycloakb EventsDBModule
 //go:generate mockgen -destination=./mock/authentication_db_reader.go -package=mock -mock_names=AuthorizationDBReader=AuthorizationDBReader github.com/cloudtrust/common-service/security AuthorizationDBReader
+//go:generate mockgen -destination=./mock/tokenprovider.go -package=mock -mock_names=TokenProvider=TokenProvider github.com/cloudtrust/keycloak-bridge/internal/keycloakb TokenProvider
+//go:generate mockgen -destination=./mock/jobs.go -package=mock -mock_names=JobStore=JobStore github.com/cloudtrust/keycloak-bridge/internal/keycloakb JobStore
+//go:generate mockgen -destination=./mock/reports.go -package=mock -mock_names=ReportSchedulesDBModule=ReportSchedulesDBModule github.com/cloudtrust/keycloak-bridge/pkg/statistics ReportSchedulesDBModule
+//go:generate mockgen -destination=./mock/auditeventsdbmodule.go -package=mock -mock_names=EventsDBModule=AuditEventsDBModule github.com/cloudtrust/common-service/database EventsDBModule
+//go:generate mockgen -destination=./mock/emailsender.go -package=mock -mock_names=EmailSender=EmailSender github.com/cloudtrust/keycloak-bridge/internal/keycloakb EmailSender
+//go:generate mockgen -destination=./mock/idgenerator.go -package=mock -mock_names=IDGenerator=IDGenerator github.com/cloudtrust/common-service/idgenerator IDGenerator
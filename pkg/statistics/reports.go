@@ -0,0 +1,256 @@
+package statistics
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	cs "github.com/cloudtrust/common-service"
+	"github.com/cloudtrust/common-service/database"
+	"github.com/cloudtrust/common-service/idgenerator"
+	"github.com/cloudtrust/common-service/log"
+	"github.com/cloudtrust/keycloak-bridge/internal/dto"
+	"github.com/cloudtrust/keycloak-bridge/internal/keycloakb"
+)
+
+const (
+	reportEmailTemplate = "statistics-report.ftl"
+	reportDateLayout    = "2006-01-02 15:04 MST"
+
+	// a report is sent at most reportSendAttempts times to a recipient, waiting reportSendRetryDelay between two attempts
+	reportSendAttempts   = 3
+	reportSendRetryDelay = 10 * time.Second
+)
+
+// Audit event types counted in the statistics reports
+var (
+	reportLoginEvents         = []string{"LOGON_OK"}
+	reportFailureEvents       = []string{"LOGON_ERROR"}
+	reportNewUserEvents       = []string{"ACCOUNT_CREATED", "API_ACCOUNT_CREATION", "REGISTER_USER"}
+	reportLockedAccountEvents = []string{"LOCK_ACCOUNT", "TEMPORARILY_LOCKED"}
+)
+
+// ReportSchedulesDBModule is the interface of the configuration database module used by the report scheduler
+type ReportSchedulesDBModule interface {
+	GetAllReportSchedules(ctx context.Context) ([]dto.DBReportSchedule, error)
+	ClaimReportSchedule(ctx context.Context, scheduleID int64, occurrence time.Time, now time.Time) (bool, error)
+}
+
+// ReportScheduler sends the scheduled statistics reports by email
+type ReportScheduler interface {
+	SendDueReports(ctx context.Context, now time.Time) error
+	Run(c <-chan time.Time)
+}
+
+type statisticsReport struct {
+	realmName      string
+	from           time.Time
+	to             time.Time
+	logins         int64
+	failures       int64
+	newUsers       int64
+	lockedAccounts int64
+}
+
+type reportScheduler struct {
+	configDB      ReportSchedulesDBModule
+	eventsDB      keycloakb.EventsDBModule
+	auditEventsDB database.EventsDBModule
+	sender        keycloakb.EmailSender
+	idGenerator   idgenerator.IDGenerator
+	logger        log.Logger
+	retryDelay    time.Duration
+}
+
+// NewReportScheduler creates a report scheduler. The reports which can't be sent to a recipient are recorded in the
+// audit database.
+func NewReportScheduler(configDB ReportSchedulesDBModule, eventsDB keycloakb.EventsDBModule, auditEventsDB database.EventsDBModule,
+	sender keycloakb.EmailSender, idGenerator idgenerator.IDGenerator, logger log.Logger) ReportScheduler {
+	return &reportScheduler{
+		configDB:      configDB,
+		eventsDB:      eventsDB,
+		auditEventsDB: auditEventsDB,
+		sender:        sender,
+		idGenerator:   idGenerator,
+		logger:        logger,
+		retryDelay:    reportSendRetryDelay,
+	}
+}
+
+// Run sends the due reports at each tick of the given channel
+func (s *reportScheduler) Run(c <-chan time.Time) {
+	for now := range c {
+		var ctx = context.WithValue(context.Background(), cs.CtContextCorrelationID, s.idGenerator.NextID())
+		if err := s.SendDueReports(ctx, now); err != nil {
+			s.logger.Warn(ctx, "msg", "Can't send statistics reports", "err", err.Error())
+		}
+	}
+}
+
+// SendDueReports sends the reports whose last occurrence is more recent than the last time they were sent. A report is
+// claimed in the database before being sent, so that it is sent only once when several instances of the bridge run
+// the scheduler.
+func (s *reportScheduler) SendDueReports(ctx context.Context, now time.Time) error {
+	var schedules, err = s.configDB.GetAllReportSchedules(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, schedule := range schedules {
+		var occurrence = lastOccurrence(schedule, now)
+		if schedule.LastSent != nil && !schedule.LastSent.Before(occurrence) {
+			continue
+		}
+
+		var report, err = s.computeReport(ctx, schedule.RealmName, previousOccurrence(schedule, occurrence), occurrence)
+		if err != nil {
+			s.logger.Warn(ctx, "msg", "Can't compute statistics report", "err", err.Error(), "realm", schedule.RealmName, "scheduleID", schedule.ID)
+			continue
+		}
+
+		claimed, err := s.configDB.ClaimReportSchedule(ctx, schedule.ID, occurrence, now)
+		if err != nil {
+			s.logger.Warn(ctx, "msg", "Can't claim statistics report", "err", err.Error(), "realm", schedule.RealmName, "scheduleID", schedule.ID)
+			continue
+		}
+		if !claimed {
+			s.logger.Debug(ctx, "msg", "Statistics report already sent", "realm", schedule.RealmName, "scheduleID", schedule.ID)
+			continue
+		}
+
+		s.sendReport(ctx, schedule, report)
+	}
+
+	return nil
+}
+
+func (s *reportScheduler) computeReport(ctx context.Context, realmName string, from, to time.Time) (statisticsReport, error) {
+	var counts, err = s.eventsDB.GetEventsCountByType(ctx, realmName, from, to)
+	if err != nil {
+		return statisticsReport{}, err
+	}
+
+	return statisticsReport{
+		realmName:      realmName,
+		from:           from,
+		to:             to,
+		logins:         sumCounts(counts, reportLoginEvents),
+		failures:       sumCounts(counts, reportFailureEvents),
+		newUsers:       sumCounts(counts, reportNewUserEvents),
+		lockedAccounts: sumCounts(counts, reportLockedAccountEvents),
+	}, nil
+}
+
+// sendReport sends the report to each recipient. The recipients who can't be reached are recorded in the audit database
+// as STATISTICS_REPORT_NOT_SENT.
+func (s *reportScheduler) sendReport(ctx context.Context, schedule dto.DBReportSchedule, report statisticsReport) {
+	for _, recipient := range schedule.Recipients {
+		var err = s.sendEmail(ctx, schedule.RealmName, report.toEmail(recipient))
+		if err == nil {
+			continue
+		}
+		s.logger.Warn(ctx, "msg", "Can't send statistics report", "err", err.Error(), "realm", schedule.RealmName, "scheduleID", schedule.ID, "recipient", recipient)
+
+		var additionalInfo = database.CreateAdditionalInfo("schedule_id", strconv.FormatInt(schedule.ID, 10), "recipient", recipient, "error", err.Error())
+		var values = []string{database.CtEventRealmName, schedule.RealmName, database.CtEventAdditionalInfo, additionalInfo}
+		if errEvent := s.auditEventsDB.ReportEvent(ctx, "STATISTICS_REPORT_NOT_SENT", "back-office", values...); errEvent != nil {
+			keycloakb.LogUnrecordedEvent(ctx, s.logger, "STATISTICS_REPORT_NOT_SENT", errEvent.Error(), values...)
+		}
+	}
+}
+
+// sendEmail tries reportSendAttempts times to send an email
+func (s *reportScheduler) sendEmail(ctx context.Context, realmName string, email keycloakb.Email) error {
+	var err error
+	for attempt := 1; attempt <= reportSendAttempts; attempt++ {
+		if err = s.sender.SendEmail(ctx, realmName, email); err == nil {
+			return nil
+		}
+		if attempt < reportSendAttempts {
+			time.Sleep(s.retryDelay)
+		}
+	}
+	return err
+}
+
+func (r statisticsReport) toEmail(recipient string) keycloakb.Email {
+	var from = r.from.UTC().Format(reportDateLayout)
+	var to = r.to.UTC().Format(reportDateLayout)
+
+	return keycloakb.Email{
+		Recipient: recipient,
+		Subject:   fmt.Sprintf("Statistics report for realm %s", r.realmName),
+		Template:  reportEmailTemplate,
+		Attributes: map[string]string{
+			"realm":          r.realmName,
+			"from":           from,
+			"to":             to,
+			"logins":         strconv.FormatInt(r.logins, 10),
+			"failures":       strconv.FormatInt(r.failures, 10),
+			"newUsers":       strconv.FormatInt(r.newUsers, 10),
+			"lockedAccounts": strconv.FormatInt(r.lockedAccounts, 10),
+		},
+		Body: fmt.Sprintf("Statistics report for realm %s\nPeriod: %s - %s\n\nLogins: %d\nLogin failures: %d\nNew users: %d\nLocked accounts: %d\n",
+			r.realmName, from, to, r.logins, r.failures, r.newUsers, r.lockedAccounts),
+	}
+}
+
+func sumCounts(counts map[string]int64, eventTypes []string) int64 {
+	var res int64
+	for _, eventType := range eventTypes {
+		res += counts[eventType]
+	}
+	return res
+}
+
+// lastOccurrence gives the most recent time before now at which the report should have been sent
+func lastOccurrence(schedule dto.DBReportSchedule, now time.Time) time.Time {
+	now = now.UTC()
+	var res time.Time
+
+	switch schedule.Frequency {
+	case dto.ReportFrequencyWeekly:
+		var daysSince = (int(now.Weekday()) - schedule.Day + 7) % 7
+		res = time.Date(now.Year(), now.Month(), now.Day()-daysSince, schedule.Hour, 0, 0, 0, time.UTC)
+		if res.After(now) {
+			res = res.AddDate(0, 0, -7)
+		}
+	case dto.ReportFrequencyMonthly:
+		res = monthlyOccurrence(now.Year(), now.Month(), schedule)
+		if res.After(now) {
+			res = monthlyOccurrence(now.Year(), now.Month()-1, schedule)
+		}
+	default:
+		res = time.Date(now.Year(), now.Month(), now.Day(), schedule.Hour, 0, 0, 0, time.UTC)
+		if res.After(now) {
+			res = res.AddDate(0, 0, -1)
+		}
+	}
+
+	return res
+}
+
+// previousOccurrence gives the occurrence which precedes the given one. It is the start of the period covered by a report.
+func previousOccurrence(schedule dto.DBReportSchedule, occurrence time.Time) time.Time {
+	switch schedule.Frequency {
+	case dto.ReportFrequencyWeekly:
+		return occurrence.AddDate(0, 0, -7)
+	case dto.ReportFrequencyMonthly:
+		return monthlyOccurrence(occurrence.Year(), occurrence.Month()-1, schedule)
+	default:
+		return occurrence.AddDate(0, 0, -1)
+	}
+}
+
+// monthlyOccurrence gives the occurrence of a monthly schedule for the given month. The day is capped to the last day of
+// the month (a report scheduled on the 31st is sent on the 30th in April)
+func monthlyOccurrence(year int, month time.Month, schedule dto.DBReportSchedule) time.Time {
+	var firstDay = time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
+	var lastDay = firstDay.AddDate(0, 1, -1).Day()
+	var day = schedule.Day
+	if day > lastDay {
+		day = lastDay
+	}
+	return time.Date(firstDay.Year(), firstDay.Month(), day, schedule.Hour, 0, 0, 0, time.UTC)
+}
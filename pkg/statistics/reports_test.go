@@ -0,0 +1,184 @@
+package statistics
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	cs "github.com/cloudtrust/common-service"
+	"github.com/cloudtrust/common-service/database"
+	"github.com/cloudtrust/common-service/log"
+	"github.com/cloudtrust/keycloak-bridge/internal/dto"
+	"github.com/cloudtrust/keycloak-bridge/internal/keycloakb"
+	"github.com/cloudtrust/keycloak-bridge/pkg/statistics/mock"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLastOccurrence(t *testing.T) {
+	// Sunday 2020-05-31 10:30 UTC
+	var now = time.Date(2020, time.May, 31, 10, 30, 0, 0, time.UTC)
+
+	t.Run("Daily", func(t *testing.T) {
+		var schedule = dto.DBReportSchedule{Frequency: dto.ReportFrequencyDaily, Hour: 8}
+		assert.Equal(t, time.Date(2020, time.May, 31, 8, 0, 0, 0, time.UTC), lastOccurrence(schedule, now))
+		schedule.Hour = 11
+		assert.Equal(t, time.Date(2020, time.May, 30, 11, 0, 0, 0, time.UTC), lastOccurrence(schedule, now))
+		assert.Equal(t, time.Date(2020, time.May, 29, 11, 0, 0, 0, time.UTC), previousOccurrence(schedule, lastOccurrence(schedule, now)))
+	})
+	t.Run("Weekly", func(t *testing.T) {
+		var schedule = dto.DBReportSchedule{Frequency: dto.ReportFrequencyWeekly, Day: int(time.Monday), Hour: 8}
+		assert.Equal(t, time.Date(2020, time.May, 25, 8, 0, 0, 0, time.UTC), lastOccurrence(schedule, now))
+		assert.Equal(t, time.Date(2020, time.May, 18, 8, 0, 0, 0, time.UTC), previousOccurrence(schedule, lastOccurrence(schedule, now)))
+		schedule.Day = int(time.Sunday)
+		schedule.Hour = 11
+		assert.Equal(t, time.Date(2020, time.May, 24, 11, 0, 0, 0, time.UTC), lastOccurrence(schedule, now))
+	})
+	t.Run("Monthly", func(t *testing.T) {
+		var schedule = dto.DBReportSchedule{Frequency: dto.ReportFrequencyMonthly, Day: 1, Hour: 6}
+		assert.Equal(t, time.Date(2020, time.May, 1, 6, 0, 0, 0, time.UTC), lastOccurrence(schedule, now))
+		assert.Equal(t, time.Date(2020, time.April, 1, 6, 0, 0, 0, time.UTC), previousOccurrence(schedule, lastOccurrence(schedule, now)))
+		schedule.Day = 31
+		schedule.Hour = 12
+		assert.Equal(t, time.Date(2020, time.April, 30, 12, 0, 0, 0, time.UTC), lastOccurrence(schedule, now))
+		assert.Equal(t, time.Date(2020, time.March, 31, 12, 0, 0, 0, time.UTC), previousOccurrence(schedule, lastOccurrence(schedule, now)))
+	})
+	t.Run("Monthly in January", func(t *testing.T) {
+		var schedule = dto.DBReportSchedule{Frequency: dto.ReportFrequencyMonthly, Day: 15, Hour: 0}
+		var january = time.Date(2021, time.January, 10, 0, 0, 0, 0, time.UTC)
+		assert.Equal(t, time.Date(2020, time.December, 15, 0, 0, 0, 0, time.UTC), lastOccurrence(schedule, january))
+	})
+}
+
+func TestSendDueReports(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var mockConfigDB = mock.NewReportSchedulesDBModule(mockCtrl)
+	var mockEventsDB = mock.NewEventsDBModule(mockCtrl)
+	var mockAuditEventsDB = mock.NewAuditEventsDBModule(mockCtrl)
+	var mockIDGenerator = mock.NewIDGenerator(mockCtrl)
+
+	var realm = "my-realm"
+	var now = time.Date(2020, time.May, 31, 10, 30, 0, 0, time.UTC)
+	var occurrence = time.Date(2020, time.May, 31, 8, 0, 0, 0, time.UTC)
+	var alreadySent = now.Add(-time.Hour)
+	var ctx = context.TODO()
+	var schedule = dto.DBReportSchedule{ID: 1, RealmName: realm, Frequency: dto.ReportFrequencyDaily, Hour: 8, Recipients: []string{"a@example.com", "b@example.com"}}
+	var counts = map[string]int64{"LOGON_OK": 10, "LOGON_ERROR": 2, "ACCOUNT_CREATED": 1, "REGISTER_USER": 3, "TEMPORARILY_LOCKED": 1}
+
+	t.Run("Can't load schedules", func(t *testing.T) {
+		var sender = keycloakb.NewFakeEmailSender(log.NewNopLogger())
+		var scheduler = NewReportScheduler(mockConfigDB, mockEventsDB, mockAuditEventsDB, sender, mockIDGenerator, log.NewNopLogger())
+		var expectedErr = errors.New("db error")
+		mockConfigDB.EXPECT().GetAllReportSchedules(ctx).Return(nil, expectedErr)
+
+		assert.Equal(t, expectedErr, scheduler.SendDueReports(ctx, now))
+	})
+
+	t.Run("Report already sent", func(t *testing.T) {
+		var sender = keycloakb.NewFakeEmailSender(log.NewNopLogger())
+		var scheduler = NewReportScheduler(mockConfigDB, mockEventsDB, mockAuditEventsDB, sender, mockIDGenerator, log.NewNopLogger())
+		var sentSchedule = schedule
+		sentSchedule.LastSent = &alreadySent
+		mockConfigDB.EXPECT().GetAllReportSchedules(ctx).Return([]dto.DBReportSchedule{sentSchedule}, nil)
+
+		assert.Nil(t, scheduler.SendDueReports(ctx, now))
+		assert.Len(t, sender.Emails(), 0)
+	})
+
+	t.Run("Can't compute report", func(t *testing.T) {
+		var sender = keycloakb.NewFakeEmailSender(log.NewNopLogger())
+		var scheduler = NewReportScheduler(mockConfigDB, mockEventsDB, mockAuditEventsDB, sender, mockIDGenerator, log.NewNopLogger())
+		mockConfigDB.EXPECT().GetAllReportSchedules(ctx).Return([]dto.DBReportSchedule{schedule}, nil)
+		mockEventsDB.EXPECT().GetEventsCountByType(ctx, realm, occurrence.AddDate(0, 0, -1), occurrence).Return(nil, errors.New("db error"))
+
+		assert.Nil(t, scheduler.SendDueReports(ctx, now))
+		assert.Len(t, sender.Emails(), 0)
+	})
+
+	t.Run("Can't claim report", func(t *testing.T) {
+		var sender = keycloakb.NewFakeEmailSender(log.NewNopLogger())
+		var scheduler = NewReportScheduler(mockConfigDB, mockEventsDB, mockAuditEventsDB, sender, mockIDGenerator, log.NewNopLogger())
+		mockConfigDB.EXPECT().GetAllReportSchedules(ctx).Return([]dto.DBReportSchedule{schedule}, nil)
+		mockEventsDB.EXPECT().GetEventsCountByType(ctx, realm, occurrence.AddDate(0, 0, -1), occurrence).Return(counts, nil)
+		mockConfigDB.EXPECT().ClaimReportSchedule(ctx, schedule.ID, occurrence, now).Return(false, errors.New("db error"))
+
+		assert.Nil(t, scheduler.SendDueReports(ctx, now))
+		assert.Len(t, sender.Emails(), 0)
+	})
+
+	t.Run("Report already claimed by another instance", func(t *testing.T) {
+		var sender = keycloakb.NewFakeEmailSender(log.NewNopLogger())
+		var scheduler = NewReportScheduler(mockConfigDB, mockEventsDB, mockAuditEventsDB, sender, mockIDGenerator, log.NewNopLogger())
+		mockConfigDB.EXPECT().GetAllReportSchedules(ctx).Return([]dto.DBReportSchedule{schedule}, nil)
+		mockEventsDB.EXPECT().GetEventsCountByType(ctx, realm, occurrence.AddDate(0, 0, -1), occurrence).Return(counts, nil)
+		mockConfigDB.EXPECT().ClaimReportSchedule(ctx, schedule.ID, occurrence, now).Return(false, nil)
+
+		assert.Nil(t, scheduler.SendDueReports(ctx, now))
+		assert.Len(t, sender.Emails(), 0)
+	})
+
+	t.Run("Sending is retried and failures are recorded per recipient", func(t *testing.T) {
+		var mockSender = mock.NewEmailSender(mockCtrl)
+		var scheduler = NewReportScheduler(mockConfigDB, mockEventsDB, mockAuditEventsDB, mockSender, mockIDGenerator, log.NewNopLogger())
+		scheduler.(*reportScheduler).retryDelay = 0
+		var sendErr = errors.New("smtp error")
+		mockConfigDB.EXPECT().GetAllReportSchedules(ctx).Return([]dto.DBReportSchedule{schedule}, nil)
+		mockEventsDB.EXPECT().GetEventsCountByType(ctx, realm, occurrence.AddDate(0, 0, -1), occurrence).Return(counts, nil)
+		mockConfigDB.EXPECT().ClaimReportSchedule(ctx, schedule.ID, occurrence, now).Return(true, nil)
+		gomock.InOrder(
+			mockSender.EXPECT().SendEmail(ctx, realm, gomock.Any()).Return(sendErr),
+			mockSender.EXPECT().SendEmail(ctx, realm, gomock.Any()).Return(nil),
+		)
+		mockSender.EXPECT().SendEmail(ctx, realm, gomock.Any()).DoAndReturn(func(_ context.Context, _ string, email keycloakb.Email) error {
+			assert.Equal(t, "b@example.com", email.Recipient)
+			return sendErr
+		}).Times(reportSendAttempts)
+		mockAuditEventsDB.EXPECT().ReportEvent(ctx, "STATISTICS_REPORT_NOT_SENT", "back-office", database.CtEventRealmName, realm,
+			database.CtEventAdditionalInfo, gomock.Any()).DoAndReturn(func(_ context.Context, _ string, _ string, values ...string) error {
+			assert.True(t, strings.Contains(values[3], "b@example.com"))
+			return nil
+		})
+
+		assert.Nil(t, scheduler.SendDueReports(ctx, now))
+	})
+
+	t.Run("Report sent to all recipients", func(t *testing.T) {
+		var sender = keycloakb.NewFakeEmailSender(log.NewNopLogger())
+		var scheduler = NewReportScheduler(mockConfigDB, mockEventsDB, mockAuditEventsDB, sender, mockIDGenerator, log.NewNopLogger())
+		mockConfigDB.EXPECT().GetAllReportSchedules(ctx).Return([]dto.DBReportSchedule{schedule}, nil)
+		mockEventsDB.EXPECT().GetEventsCountByType(ctx, realm, occurrence.AddDate(0, 0, -1), occurrence).Return(counts, nil)
+		mockConfigDB.EXPECT().ClaimReportSchedule(ctx, schedule.ID, occurrence, now).Return(true, nil)
+
+		assert.Nil(t, scheduler.SendDueReports(ctx, now))
+
+		var emails = sender.Emails()
+		assert.Len(t, emails, 2)
+		assert.Equal(t, "a@example.com", emails[0].Recipient)
+		assert.Equal(t, "b@example.com", emails[1].Recipient)
+		assert.Equal(t, reportEmailTemplate, emails[0].Template)
+		assert.Equal(t, "10", emails[0].Attributes["logins"])
+		assert.Equal(t, "2", emails[0].Attributes["failures"])
+		assert.Equal(t, "4", emails[0].Attributes["newUsers"])
+		assert.Equal(t, "1", emails[0].Attributes["lockedAccounts"])
+		assert.True(t, strings.Contains(emails[0].Body, "Period: 2020-05-30 08:00 UTC - 2020-05-31 08:00 UTC"))
+	})
+
+	t.Run("Run", func(t *testing.T) {
+		var sender = keycloakb.NewFakeEmailSender(log.NewNopLogger())
+		var scheduler = NewReportScheduler(mockConfigDB, mockEventsDB, mockAuditEventsDB, sender, mockIDGenerator, log.NewNopLogger())
+		var ticks = make(chan time.Time, 1)
+		var corrID = "corr-id"
+		var ctx = context.WithValue(context.Background(), cs.CtContextCorrelationID, corrID)
+
+		mockIDGenerator.EXPECT().NextID().Return(corrID)
+		mockConfigDB.EXPECT().GetAllReportSchedules(ctx).Return([]dto.DBReportSchedule{}, nil)
+
+		ticks <- now
+		close(ticks)
+		scheduler.Run(ticks)
+	})
+}
@@ -0,0 +1,227 @@
+package statistics
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"io"
+
+	"github.com/cloudtrust/keycloak-bridge/internal/keycloakb"
+)
+
+// Export formats
+const (
+	ExportFormatCSV  = "csv"
+	ExportFormatXLSX = "xlsx"
+)
+
+// ExportFile is a file produced by an export. Its content is fully produced by a background job before it can be
+// downloaded: an error can't occur once the reply is being sent.
+type ExportFile struct {
+	Filename    string
+	ContentType string
+	Content     []byte
+}
+
+// Types of the cells of a column
+type cellType int
+
+const (
+	cellString cellType = iota
+	cellNumber
+	cellBoolean
+)
+
+// column is the header of a column of a sheet. Its type tells how the values are written in an XLSX workbook.
+type column struct {
+	name     string
+	cellType cellType
+}
+
+// sheet is a named table of a workbook. The rows, header excluded, are produced by calling emit on each of them.
+type sheet struct {
+	name    string
+	columns []column
+	rows    func(emit func([]string) error) error
+}
+
+// staticRows produces rows already held in memory
+func staticRows(rows [][]string) func(func([]string) error) error {
+	return func(emit func([]string) error) error {
+		for _, row := range rows {
+			if err := emit(row); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// writeWorkbook writes the sheets using the given format: an XLSX workbook or a ZIP archive containing one CSV file per
+// sheet. Sheets are written in the given order.
+func writeWorkbook(w io.Writer, format string, sheets []sheet) error {
+	switch format {
+	case ExportFormatXLSX:
+		return writeXLSX(w, sheets)
+	default:
+		return writeCSVArchive(w, sheets)
+	}
+}
+
+func writeCSVArchive(w io.Writer, sheets []sheet) error {
+	var archive = zip.NewWriter(w)
+	for _, s := range sheets {
+		var f, err = archive.Create(s.name + ".csv")
+		if err != nil {
+			return err
+		}
+		var csvWriter = csv.NewWriter(f)
+		var header []string
+		for _, col := range s.columns {
+			header = append(header, col.name)
+		}
+		if err = csvWriter.Write(header); err != nil {
+			return err
+		}
+		var writeRow = func(row []string) error {
+			return csvWriter.Write(keycloakb.EscapeCSVRecord(row))
+		}
+		if err = s.rows(writeRow); err != nil {
+			return err
+		}
+		csvWriter.Flush()
+		if err = csvWriter.Error(); err != nil {
+			return err
+		}
+	}
+	return archive.Close()
+}
+
+const (
+	xlsxContentTypes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">
+<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>
+<Default Extension="xml" ContentType="application/xml"/>
+<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>
+%s</Types>`
+	xlsxContentTypeSheet = `<Override PartName="/xl/worksheets/sheet%d.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>
+`
+	xlsxRootRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>
+</Relationships>`
+	xlsxWorkbook = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+<sheets>
+%s</sheets>
+</workbook>`
+	xlsxWorkbookSheet = `<sheet name="%s" sheetId="%d" r:id="rId%d"/>
+`
+	xlsxWorkbookRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+%s</Relationships>`
+	xlsxWorkbookRelsSheet = `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet%d.xml"/>
+`
+)
+
+// writeXLSX writes a minimal Office Open XML workbook. Cells are typed according to their column.
+func writeXLSX(w io.Writer, sheets []sheet) error {
+	var contentTypes, workbookSheets, workbookRels bytes.Buffer
+	for i, s := range sheets {
+		var id = i + 1
+		fmt.Fprintf(&contentTypes, xlsxContentTypeSheet, id)
+		fmt.Fprintf(&workbookSheets, xlsxWorkbookSheet, xmlEscape(s.name), id, id)
+		fmt.Fprintf(&workbookRels, xlsxWorkbookRelsSheet, id, id)
+	}
+
+	var files = []struct {
+		name    string
+		content string
+	}{
+		{"[Content_Types].xml", fmt.Sprintf(xlsxContentTypes, contentTypes.String())},
+		{"_rels/.rels", xlsxRootRels},
+		{"xl/workbook.xml", fmt.Sprintf(xlsxWorkbook, workbookSheets.String())},
+		{"xl/_rels/workbook.xml.rels", fmt.Sprintf(xlsxWorkbookRels, workbookRels.String())},
+	}
+
+	var archive = zip.NewWriter(w)
+	for _, file := range files {
+		var f, err = archive.Create(file.name)
+		if err != nil {
+			return err
+		}
+		if _, err = io.WriteString(f, file.content); err != nil {
+			return err
+		}
+	}
+	for i, s := range sheets {
+		var f, err = archive.Create(fmt.Sprintf("xl/worksheets/sheet%d.xml", i+1))
+		if err != nil {
+			return err
+		}
+		if err = writeXLSXSheet(f, s); err != nil {
+			return err
+		}
+	}
+	return archive.Close()
+}
+
+func writeXLSXSheet(w io.Writer, s sheet) error {
+	var header = make([]string, len(s.columns))
+	for i, col := range s.columns {
+		header[i] = col.name
+	}
+
+	if _, err := io.WriteString(w, `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>`+"\n"+
+		`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`); err != nil {
+		return err
+	}
+	if _, err := io.WriteString(w, xlsxRow(header, nil)); err != nil {
+		return err
+	}
+	var err = s.rows(func(row []string) error {
+		var _, err = io.WriteString(w, xlsxRow(row, s.columns))
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, "</sheetData></worksheet>")
+	return err
+}
+
+// xlsxRow formats a row. Without columns, all the values are written as strings.
+func xlsxRow(values []string, columns []column) string {
+	var buf bytes.Buffer
+	buf.WriteString("<row>")
+	for i, value := range values {
+		var cellType = cellString
+		if i < len(columns) {
+			cellType = columns[i].cellType
+		}
+		switch {
+		case value == "":
+			buf.WriteString("<c/>")
+		case cellType == cellNumber:
+			fmt.Fprintf(&buf, "<c><v>%s</v></c>", xmlEscape(value))
+		case cellType == cellBoolean:
+			var flag = "0"
+			if value == "true" {
+				flag = "1"
+			}
+			fmt.Fprintf(&buf, `<c t="b"><v>%s</v></c>`, flag)
+		default:
+			fmt.Fprintf(&buf, `<c t="inlineStr"><is><t>%s</t></is></c>`, xmlEscape(value))
+		}
+	}
+	buf.WriteString("</row>")
+	return buf.String()
+}
+
+func xmlEscape(value string) string {
+	var buf bytes.Buffer
+	xml.EscapeText(&buf, []byte(value))
+	return buf.String()
+}
@@ -0,0 +1,79 @@
+package statistics
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func readArchive(t *testing.T, content []byte) map[string]string {
+	var reader, err = zip.NewReader(bytes.NewReader(content), int64(len(content)))
+	assert.Nil(t, err)
+
+	var res = map[string]string{}
+	for _, f := range reader.File {
+		var rc, err = f.Open()
+		assert.Nil(t, err)
+		data, _ := ioutil.ReadAll(rc)
+		rc.Close()
+		res[f.Name] = string(data)
+	}
+	return res
+}
+
+var testSheets = []sheet{
+	{
+		name:    "first",
+		columns: []column{{"name", cellString}, {"value", cellNumber}, {"enabled", cellBoolean}},
+		rows:    staticRows([][]string{{"a&b", "12", "true"}, {"42", "", "false"}}),
+	},
+	{name: "second", columns: []column{{"empty", cellString}}, rows: staticRows(nil)},
+}
+
+func TestWriteCSVArchive(t *testing.T) {
+	var buf bytes.Buffer
+	var err = writeWorkbook(&buf, ExportFormatCSV, testSheets)
+	assert.Nil(t, err)
+
+	var files = readArchive(t, buf.Bytes())
+	assert.Len(t, files, 2)
+	assert.Equal(t, "name,value,enabled\na&b,12,true\n42,,false\n", files["first.csv"])
+	assert.Equal(t, "empty\n", files["second.csv"])
+}
+
+func TestWriteCSVArchiveEscapesFormulas(t *testing.T) {
+	var sheets = []sheet{{name: "users", columns: []column{{"username", cellString}}, rows: staticRows([][]string{{"=1+1"}, {"@jdoe"}})}}
+	var buf bytes.Buffer
+	assert.Nil(t, writeWorkbook(&buf, ExportFormatCSV, sheets))
+	assert.Equal(t, "username\n'=1+1\n'@jdoe\n", readArchive(t, buf.Bytes())["users.csv"])
+}
+
+func TestWriteXLSX(t *testing.T) {
+	var buf bytes.Buffer
+	var err = writeWorkbook(&buf, ExportFormatXLSX, testSheets)
+	assert.Nil(t, err)
+
+	var files = readArchive(t, buf.Bytes())
+	assert.Len(t, files, 6)
+	assert.Contains(t, files["[Content_Types].xml"], "/xl/worksheets/sheet2.xml")
+	assert.Contains(t, files["xl/workbook.xml"], `<sheet name="first" sheetId="1" r:id="rId1"/>`)
+	assert.Contains(t, files["xl/_rels/workbook.xml.rels"], `Target="worksheets/sheet2.xml"`)
+	// Cells are typed by their column, whatever their value looks like
+	assert.Contains(t, files["xl/worksheets/sheet1.xml"], `<row><c t="inlineStr"><is><t>a&amp;b</t></is></c><c><v>12</v></c><c t="b"><v>1</v></c></row>`)
+	assert.Contains(t, files["xl/worksheets/sheet1.xml"], `<row><c t="inlineStr"><is><t>42</t></is></c><c/><c t="b"><v>0</v></c></row>`)
+}
+
+func TestWriteWorkbookRowsError(t *testing.T) {
+	var expectedError = errors.New("rows error")
+	var sheets = []sheet{{name: "failing", columns: []column{{"name", cellString}}, rows: func(func([]string) error) error {
+		return expectedError
+	}}}
+
+	for _, format := range []string{ExportFormatCSV, ExportFormatXLSX} {
+		assert.Equal(t, expectedError, writeWorkbook(ioutil.Discard, format, sheets))
+	}
+}